@@ -24,6 +24,13 @@ func NewService(ctx core.Context) Service {
 	return &ServiceImpl{context: ctx, evaluator: dsl.NewEvaluator(ctx)}
 }
 
+// NewRestrictedService returns a Service that can only evaluate the allowed functions ; none means all.
+func NewRestrictedService(ctx core.Context, allowed []string) Service {
+	eval := dsl.NewEvaluator(ctx)
+	eval.Allow(allowed)
+	return &ServiceImpl{context: ctx, evaluator: eval}
+}
+
 func (s *ServiceImpl) Context() core.Context { return s.context }
 
 func (s *ServiceImpl) ChangeDefaultDeviceAndChannel(isInput bool, deviceID int, channel int) error {
@@ -58,7 +65,7 @@ func (s *ServiceImpl) CommandInspect(file string, lineEnd int, source string) (i
 
 	lastValue, err := s.evaluator.EvaluateProgram(source)
 	if err != nil {
-		return nil, patchFilelocation(err, lineEnd)
+		return nil, patchFilelocation(err, file, lineEnd, source)
 	}
 	if lastValue == nil {
 		core.PrintValue(s.context, nil)
@@ -79,7 +86,7 @@ func (s *ServiceImpl) CommandPlay(file string, lineEnd int, source string) (inte
 
 	returnValue, err := s.evaluator.EvaluateProgram(source)
 	if err != nil {
-		return nil, patchFilelocation(err, lineEnd)
+		return nil, patchFilelocation(err, file, lineEnd, source)
 	}
 
 	if pl, ok := returnValue.(core.Playable); ok {
@@ -91,7 +98,7 @@ func (s *ServiceImpl) CommandPlay(file string, lineEnd int, source string) (inte
 			notify.Infof("play(%s)", displayString(s.context, seq))
 			s.context.Device().Play(
 				core.NoCondition,
-				seq,
+				core.Transposed(s.context.Control(), seq),
 				s.context.Control().BPM(),
 				time.Now())
 		}
@@ -99,12 +106,24 @@ func (s *ServiceImpl) CommandPlay(file string, lineEnd int, source string) (inte
 
 	return returnValue, nil
 }
+
+// CommandAudition plays the expression once on the audition channel, see dsl.Evaluator.Audition.
+func (s *ServiceImpl) CommandAudition(file string, lineEnd int, source string) (interface{}, error) {
+	s.updateMetadata(file, lineEnd, source)
+
+	returnValue, err := s.evaluator.Audition(source)
+	if err != nil {
+		return nil, patchFilelocation(err, file, lineEnd, source)
+	}
+	notify.Infof("audition(%s) on channel %d", displayString(s.context, returnValue), dsl.AuditionChannel)
+	return returnValue, nil
+}
 func (s *ServiceImpl) CommandStop(file string, lineEnd int, source string) (interface{}, error) {
 	s.updateMetadata(file, lineEnd, source)
 
 	returnValue, err := s.evaluator.EvaluateProgram(source)
 	if err != nil {
-		return nil, patchFilelocation(err, lineEnd)
+		return nil, patchFilelocation(err, file, lineEnd, source)
 	}
 
 	if p, ok := returnValue.(core.Stoppable); ok {
@@ -119,7 +138,7 @@ func (s *ServiceImpl) CommandEvaluate(file string, lineEnd int, source string) (
 
 	returnValue, err := s.evaluator.EvaluateProgram(source)
 	if err != nil {
-		return nil, patchFilelocation(err, lineEnd)
+		return nil, patchFilelocation(err, file, lineEnd, source)
 	}
 	return returnValue, nil
 }
@@ -149,7 +168,7 @@ func (s *ServiceImpl) CommandMIDISample(filename string, lineEnd int, source str
 
 	returnValue, err := s.evaluator.EvaluateProgram(source)
 	if err != nil {
-		return []byte{}, patchFilelocation(err, lineEnd)
+		return []byte{}, patchFilelocation(err, filename, lineEnd, source)
 	}
 	buffer := new(bytes.Buffer)
 	err = midifile.ExportOn(buffer, returnValue, s.context.Control().BPM(), s.context.Control().BIAB())
@@ -167,8 +186,14 @@ func displayString(ctx core.Context, v interface{}) string {
 	return name
 }
 
-func patchFilelocation(err error, lineEnd int) error {
+func patchFilelocation(err error, filename string, lineEnd int, source string) error {
 	// patch Location of error
+	if de, ok := err.(*dsl.Error); ok {
+		de.File = filename
+		// the source ends at lineEnd
+		de.Line = de.Line - 1 + lineEnd - strings.Count(source, "\n")
+		return de
+	}
 	if fe, ok := err.(*file.Error); ok {
 		fe.Line = fe.Line - 1 + lineEnd
 		return fe
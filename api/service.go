@@ -6,6 +6,7 @@ type Service interface {
 	Context() core.Context
 	CommandInspect(file string, lineEnd int, source string) (interface{}, error)
 	CommandPlay(file string, lineEnd int, source string) (interface{}, error)
+	CommandAudition(file string, lineEnd int, source string) (interface{}, error)
 	CommandStop(file string, lineEnd int, source string) (interface{}, error)
 	CommandEvaluate(file string, lineEnd int, source string) (interface{}, error)
 	CommandKill() error
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
+	"github.com/emicklei/melrose/midi"
+)
+
+const (
+	melroseExtension = ".mel"
+	goldenExtension  = ".golden"
+)
+
+// eventLog evaluates the program and returns the normalized log of MIDI note events
+// that are produced when playing the result on a device without output stream.
+func eventLog(source string) (string, error) {
+	ctx := core.PlayContext{
+		VariableStorage: dsl.NewVariableStore(),
+		LoopControl:     core.NoLooper,
+	}
+	r, err := dsl.NewEvaluator(ctx).EvaluateProgram(source)
+	if err != nil {
+		return "", err
+	}
+	seq, ok := r.(core.Sequenceable)
+	if !ok {
+		return "", fmt.Errorf("last expression must be a musical object, got (%T) %v", r, r)
+	}
+	tim := core.NewTimeline()
+	d := midi.NewOutputDevice(0, nil, 1, tim)
+	begin := time.Now()
+	d.Play(core.NoCondition, seq, ctx.Control().BPM(), begin)
+	events := tim.NoteEvents()
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Start.Equal(events[j].Start) {
+			return events[i].Number < events[j].Number
+		}
+		return events[i].Start.Before(events[j].Start)
+	})
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "# start(ms) end(ms) number velocity note")
+	for _, each := range events {
+		n, _ := core.MIDItoNote(0.25, each.Number, core.Normal)
+		fmt.Fprintf(&buf, "%d %d %d %d %s\n",
+			each.Start.Sub(begin).Milliseconds(),
+			each.End.Sub(begin).Milliseconds(),
+			each.Number,
+			each.Velocity,
+			n.String())
+	}
+	return buf.String(), nil
+}
+
+// checkDirectory compares (or writes) the golden file for each melrose file in a directory.
+// It returns a description for each mismatch.
+func checkDirectory(dir string, update bool) (failures []string, err error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+melroseExtension))
+	if err != nil {
+		return nil, err
+	}
+	for _, each := range files {
+		source, err := os.ReadFile(each)
+		if err != nil {
+			return nil, err
+		}
+		log, err := eventLog(string(source))
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", each, err))
+			continue
+		}
+		golden := strings.TrimSuffix(each, melroseExtension) + goldenExtension
+		if update {
+			if err := os.WriteFile(golden, []byte(log), 0644); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: missing golden file, run with -update", each))
+			continue
+		}
+		if string(want) != log {
+			failures = append(failures, fmt.Sprintf("%s: event log differs from %s\ngot:\n%swant:\n%s", each, golden, log, string(want)))
+		}
+	}
+	return
+}
@@ -0,0 +1,13 @@
+package main
+
+import "testing"
+
+func TestGoldenFiles(t *testing.T) {
+	failures, err := checkDirectory("testdata", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, each := range failures {
+		t.Error(each)
+	}
+}
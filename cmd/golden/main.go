@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// see Makefile how to run this
+
+var update = flag.Bool("update", false, "write the golden files instead of comparing them")
+
+func main() {
+	flag.Parse()
+	dir := "testdata"
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	failures, err := checkDirectory(dir, *update)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, each := range failures {
+		fmt.Fprintln(os.Stderr, each)
+	}
+	if len(failures) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/emicklei/melrose/dsl"
+)
+
+// runCheck compiles the statements of scripts without evaluating them and reports all problems.
+// Unknown names, wrong number of arguments and invalid notation strings are problems.
+//
+//	melrose check song.mel intro.mel
+func runCheck(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: melrose check file.mel ...")
+	}
+	count := 0
+	for _, script := range args {
+		source, err := os.ReadFile(script)
+		if err != nil {
+			return err
+		}
+		for _, each := range dsl.NewEvaluator(newHeadlessContext(script)).CheckProgram(string(source)) {
+			// problems are zero-based
+			fmt.Fprintf(w, "%s:%d:%d: %s\n", script, each.Line+1, each.Column+1, each.Message)
+			count++
+		}
+	}
+	if count > 0 {
+		return fmt.Errorf("%d problem(s) found", count)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
+	midifile "github.com/emicklei/melrose/midi/file"
+	"github.com/emicklei/melrose/notation/export"
+	"github.com/emicklei/melrose/notify"
+)
+
+// exporters write a musical object in a format ; the key is the value of the format flag.
+var exporters = map[string]struct {
+	extension string
+	write     func(fileName string, m interface{}, bpm float64, biab int) error
+}{
+	"midi":     {".mid", midifile.Export},
+	"musicxml": {".musicxml", export.ExportMusicXML},
+	"lily":     {".ly", export.ExportLilyPond},
+	"json":     {".json", export.ExportJSON},
+	"csv":      {".csv", export.ExportCSV},
+}
+
+// runExport evaluates a script without audio and writes the result of its last expression.
+//
+//	melrose export --format midi|musicxml|lily|json|csv [--bars 32] [--seed 7] [--o out.mid] file.mel
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "midi", "midi, musicxml, lily, json or csv")
+	bars := fs.Int("bars", 0, "number of bars to write ; a loop is repeated to fill them. 0 means all")
+	seed := fs.Int64("seed", 0, "seed for the randomness of generators, for reproducible results")
+	output := fs.String("o", "", "name of the file to write ; default is the script name with the extension of the format")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: melrose export [--format midi|musicxml|lily|json|csv] [--bars n] [--seed n] [--o file] file.mel")
+	}
+	exporter, ok := exporters[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q, expected midi, musicxml, lily, json or csv", *format)
+	}
+	if err := useSeed(fs, *seed); err != nil {
+		return err
+	}
+	script := fs.Arg(0)
+	ctx, r, err := evaluateScript(script)
+	if err != nil {
+		return err
+	}
+	if v, ok := r.(core.HasValue); ok {
+		r = v.Value()
+	}
+	if r == nil {
+		return fmt.Errorf("%s: the last expression has no musical object to export", script)
+	}
+	biab := ctx.Control().BIAB()
+	if *bars > 0 {
+		if r, err = firstBars(r, *bars, biab); err != nil {
+			return err
+		}
+	}
+	name := *output
+	if len(name) == 0 {
+		name = strings.TrimSuffix(script, filepath.Ext(script)) + exporter.extension
+	}
+	return exporter.write(name, r, ctx.Control().BPM(), biab)
+}
+
+// useSeed makes the randomness of generators reproducible if the seed flag was given.
+func useSeed(fs *flag.FlagSet, seed int64) error {
+	seeded := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seeded = true
+		}
+	})
+	if !seeded {
+		return nil
+	}
+	return core.UseRandomSource("seeded", seed)
+}
+
+// newHeadlessContext returns a context without MIDI devices for evaluating a script ; playing and listening are ignored.
+func newHeadlessContext(script string) *core.PlayContext {
+	ctx := new(core.PlayContext)
+	ctx.EnvironmentVars = new(sync.Map)
+	ctx.VariableStorage = dsl.NewVariableStore()
+	ctx.LoopControl = core.NewBeatmaster(ctx, 120)
+	ctx.AudioDevice = headlessDevice{}
+	ctx.CapabilityFlags = &core.Capabilities{ExportMIDI: true, ImportMelrose: true}
+	ctx.EnvironmentVars.Store(core.WorkingDirectory, filepath.Dir(script))
+	return ctx
+}
+
+// headlessDevice is an AudioDevice that ignores all playing and listening such that a script can be evaluated without audio.
+type headlessDevice struct{}
+
+func (headlessDevice) Command(args []string) notify.Message { return nil }
+
+func (headlessDevice) DefaultDeviceIDs() (inputDeviceID, outputDeviceID int) { return -1, -1 }
+
+func (headlessDevice) HandleSetting(name string, values []interface{}) error { return nil }
+
+func (headlessDevice) Play(condition core.Condition, seq core.Sequenceable, bpm float64, beginAt time.Time) time.Time {
+	return beginAt
+}
+
+func (headlessDevice) HasInputCapability() bool { return false }
+
+func (headlessDevice) Listen(deviceID int, who core.NoteListener, startOrStop bool) {}
+
+func (headlessDevice) OnKey(ctx core.Context, deviceID int, channel int, note core.Note, fun core.HasValue) error {
+	return nil
+}
+
+func (headlessDevice) Schedule(event core.TimelineEvent, beginAt time.Time) {}
+
+func (headlessDevice) Reset() {}
+
+func (headlessDevice) Close() error { return nil }
+
+// evaluateScript evaluates a script in a context without MIDI devices and returns the result of its last expression.
+func evaluateScript(script string) (core.Context, interface{}, error) {
+	source, err := os.ReadFile(script)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx := newHeadlessContext(script)
+	r, err := dsl.NewEvaluator(ctx).EvaluateProgram(string(source))
+	if err != nil {
+		if located, ok := err.(*dsl.Error); ok {
+			located.File = script
+			return nil, nil, located
+		}
+		return nil, nil, fmt.Errorf("%s: %v", script, err)
+	}
+	return ctx, r, nil
+}
+
+// firstBars returns the music of a number of bars ; a loop is repeated to fill them and longer music is cut.
+func firstBars(m interface{}, bars, biab int) (interface{}, error) {
+	var s core.Sequence
+	switch v := m.(type) {
+	case *core.Loop:
+		once := v.S().Bars(biab)
+		if once <= 0 {
+			return v.S(), nil
+		}
+		s = v.ToSequence(int(math.Ceil(float64(bars) / once)))
+	case core.Sequenceable:
+		s = v.S()
+	default:
+		return nil, fmt.Errorf("bars can only be applied to a sequence or loop, got (%T)", m)
+	}
+	limit := float32(bars*biab) / 4
+	position := float32(0)
+	groups := [][]core.Note{}
+	for _, group := range s.Notes {
+		if position >= limit-0.001 {
+			break
+		}
+		groups = append(groups, group)
+		if len(group) > 0 {
+			position += group[0].DurationFactor()
+		}
+	}
+	return core.Sequence{Notes: groups}, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestRunExport_JSON(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "song.mel")
+	if err := os.WriteFile(script, []byte("bpm(90)\nm = sequence('C D E F')\nsong = loop(m)"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := runExport([]string{"--format", "json", "--bars", "3", script}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "song.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var score struct {
+		BPM    float64
+		Tracks []struct{ Notes []interface{} }
+	}
+	if err := json.Unmarshal(data, &score); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := score.BPM, 90.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := len(score.Tracks[0].Notes), 12; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestRunExport_Play(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "song.mel")
+	source := "m = sequence('C D E F')\nplay(m)\nm"
+	if err := os.WriteFile(script, []byte(source), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := runExport([]string{"--format", "json", script}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "song.json")); err != nil {
+		t.Error(err)
+	}
+	if err := runRender([]string{script}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunExport_UnknownFormat(t *testing.T) {
+	if err := runExport([]string{"--format", "wav", "song.mel"}); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestFirstBars(t *testing.T) {
+	m, err := firstBars(core.MustParseSequence("1C 1D 1E"), 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.(core.Sequence).Storex(), "sequence('1C 1D')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := firstBars(core.NewTrack("t", 1), 2, 4); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestRunRender(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "song.mel")
+	exported := filepath.Join(dir, "part.mid")
+	source := "m = sequence('C D E F')\nexport('" + exported + "',m)\nm"
+	if err := os.WriteFile(script, []byte(source), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "song.json")
+	if err := runRender([]string{"--out", out, script}); err != nil {
+		t.Fatal(err)
+	}
+	for _, each := range []string{exported, out} {
+		if _, err := os.Stat(each); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRunRender_Errors(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "song.mel")
+	if err := os.WriteFile(script, []byte("m = sequence('C D')\nexport('"+filepath.Join(dir, "missing", "m.mid")+"',m)"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := runRender([]string{script}); err == nil {
+		t.Error("error expected for failing export")
+	}
+	if err := runRender([]string{"--out", "song.wav", script}); err == nil {
+		t.Error("error expected for unknown extension")
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "song.mel")
+	if err := os.WriteFile(script, []byte("m = sequence('C D')\nplay(unknown)\nsequence('C Z')"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := runCheck([]string{script}, &b); err == nil {
+		t.Error("error expected")
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if got, want := len(lines), 2; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := lines[1], script+":3:10: invalid sequence notation"; !strings.HasPrefix(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+	"sync"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
+	"github.com/emicklei/melrose/lsp"
+	"github.com/emicklei/melrose/notify"
+)
+
+// runLSP serves the Language Server Protocol on stdin and stdout for editors.
+//
+//	melrose lsp
+func runLSP() error {
+	// stdout is reserved for protocol messages
+	notify.Console.DeviceIn = os.Stderr
+	notify.Console.DeviceOut = os.Stderr
+	notify.Console.StandardOut = os.Stderr
+	ctx := new(core.PlayContext)
+	ctx.EnvironmentVars = new(sync.Map)
+	ctx.VariableStorage = dsl.NewVariableStore()
+	ctx.LoopControl = core.NewBeatmaster(ctx, 120)
+	return lsp.NewServer(ctx).Run(os.Stdin, os.Stdout)
+}
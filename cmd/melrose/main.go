@@ -1,8 +1,13 @@
 package main
 
 import (
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
 
+	"github.com/emicklei/melrose/core"
 	"github.com/emicklei/melrose/server"
 	"github.com/emicklei/melrose/system"
 	"github.com/emicklei/melrose/ui/cli"
@@ -10,12 +15,72 @@ import (
 
 var BuildTag = "dev"
 
+// subcommands are dispatched on the first argument ; each parses the arguments that follow it.
+var subcommands = map[string]func(args []string) error{
+	"export": runExport,
+	"render": runRender,
+	"check":  func(args []string) error { return runCheck(args, os.Stdout) },
+	"lsp":    func(args []string) error { return runLSP() },
+	"watch":  runWatch,
+}
+
 func main() {
+	run, args := runREPL, os.Args[1:]
+	if len(os.Args) > 1 {
+		if sub, ok := subcommands[os.Args[1]]; ok {
+			run, args = sub, os.Args[2:]
+		}
+	}
+	if err := run(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// runREPL sets up the devices, the HTTP server and the session and reads statements until exit.
+//
+//	melrose [-http :8118] [-restore]
+func runREPL(args []string) error {
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return err
+	}
+	if flag.NArg() > 0 {
+		return fmt.Errorf("unknown subcommand %q, expected export, render, check, lsp or watch", flag.Arg(0))
+	}
+	return startREPL("")
+}
+
+// runWatch starts the REPL and evaluates a script again whenever the file changes on disk.
+// The flags are those of the REPL.
+//
+//	melrose watch [-http :8118] song.mel
+func runWatch(args []string) error {
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return err
+	}
+	if flag.NArg() != 1 {
+		return errors.New("usage: melrose watch [flags] file.mel")
+	}
+	return startREPL(flag.Arg(0))
+}
+
+// startREPL reads statements until exit ; if a script is given then it is watched.
+func startREPL(script string) error {
 	ctx, err := system.Setup(BuildTag)
 	if err != nil {
-		log.Fatalln(err)
+		return err
 	}
+	system.RestoreSession(ctx)
+	system.LoadProject(ctx)
 	server.Start(ctx)
 	defer system.TearDown(ctx)
+	system.TearDownOnSignal(ctx)
+	if len(script) > 0 {
+		ctx.Environment().Store(core.WorkingDirectory, filepath.Dir(script))
+		if err := cli.Watch(ctx, script); err != nil {
+			return err
+		}
+	}
 	cli.StartREPL(ctx)
+	return nil
 }
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunREPL_UnknownSubcommand(t *testing.T) {
+	err := runREPL([]string{"exprot", "song.mel"})
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	if got, want := err.Error(), `unknown subcommand "exprot"`; !strings.HasPrefix(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestRunWatch_Usage(t *testing.T) {
+	if err := runWatch([]string{}); err == nil {
+		t.Error("error expected")
+	}
+	if err := runWatch([]string{"a.mel", "b.mel"}); err == nil {
+		t.Error("error expected")
+	}
+}
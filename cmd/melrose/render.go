@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// runRender evaluates a script without MIDI devices or REPL ; its export statements write their files.
+// If an output file is given then the result of the last expression is also written, in the format of its extension.
+//
+//	melrose render [--seed 7] [--out song.mid] song.mel
+func runRender(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	seed := fs.Int64("seed", 0, "seed for the randomness of generators, for reproducible results")
+	output := fs.String("out", "", "name of the file to write the last expression to ; the extension selects the format")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: melrose render [--seed n] [--out file] file.mel")
+	}
+	var write func(fileName string, m interface{}, bpm float64, biab int) error
+	if len(*output) > 0 {
+		ext := filepath.Ext(*output)
+		for _, each := range exporters {
+			if each.extension == ext {
+				write = each.write
+			}
+		}
+		if write == nil {
+			return fmt.Errorf("unknown extension %q, expected .mid, .musicxml, .ly, .json or .csv", ext)
+		}
+	}
+	if err := useSeed(fs, *seed); err != nil {
+		return err
+	}
+	script := fs.Arg(0)
+	ctx, r, err := evaluateScript(script)
+	if err != nil {
+		return err
+	}
+	if write == nil {
+		return nil
+	}
+	if v, ok := r.(core.HasValue); ok {
+		r = v.Value()
+	}
+	if r == nil {
+		return fmt.Errorf("%s: the last expression has no musical object to render", script)
+	}
+	return write(*output, r, ctx.Control().BPM(), ctx.Control().BIAB())
+}
@@ -0,0 +1,199 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// CCListen stores the value of each control change (CC) with a number, received from an input device, in a variable.
+// The value [0..127] is scaled to [min..max] ; it is an integer if both min and max are integers.
+// Control changes from all channels are used.
+type CCListen struct {
+	mutex        *sync.RWMutex
+	ctx          core.Context
+	deviceID     int
+	number       int
+	variableName string
+	min, max     interface{} // int or float64
+	isRunning    bool
+}
+
+func NewCCListen(ctx core.Context, deviceID, number int, variableName string, min, max interface{}) *CCListen {
+	return &CCListen{
+		mutex:        new(sync.RWMutex),
+		ctx:          ctx,
+		deviceID:     deviceID,
+		number:       number,
+		variableName: variableName,
+		min:          min,
+		max:          max,
+	}
+}
+
+// Inspect implements Inspectable
+func (c *CCListen) Inspect(i core.Inspection) {
+	i.Properties["running"] = c.IsPlaying()
+	i.Properties["device"] = c.deviceID
+	i.Properties["number"] = c.number
+	i.Properties["variable"] = c.variableName
+}
+
+// Replace takes the settings of another CCListen ; if running then it keeps running with these settings.
+func (c *CCListen) Replace(other *CCListen) {
+	running := c.IsPlaying()
+	if running && other.deviceID != c.deviceID {
+		c.Stop(c.ctx)
+	}
+	c.mutex.Lock()
+	c.deviceID = other.deviceID
+	c.number = other.number
+	c.variableName = other.variableName
+	c.min, c.max = other.min, other.max
+	c.mutex.Unlock()
+	if running {
+		c.Play(c.ctx, time.Now())
+	}
+}
+
+// Play is part of core.Playable
+func (c *CCListen) Play(ctx core.Context, at time.Time) error {
+	if !ctx.Device().HasInputCapability() {
+		return errors.New("input is not available for this device")
+	}
+	c.mutex.Lock()
+	if c.isRunning {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.isRunning = true
+	deviceID := c.deviceID
+	// unlock before listening ; the device can be dispatching a control change to this listener
+	c.mutex.Unlock()
+	ctx.Device().Listen(deviceID, c, true)
+	return nil
+}
+
+// Stop is part of core.Stoppable
+func (c *CCListen) Stop(ctx core.Context) error {
+	c.mutex.Lock()
+	if !c.isRunning {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.isRunning = false
+	deviceID := c.deviceID
+	c.mutex.Unlock()
+	ctx.Device().Listen(deviceID, c, false)
+	return nil
+}
+
+// IsPlaying is part of core.Stoppable
+func (c *CCListen) IsPlaying() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.isRunning
+}
+
+// NoteOn is part of core.NoteListener
+func (c *CCListen) NoteOn(channel int, n core.Note) {}
+
+// NoteOff is part of core.NoteListener
+func (c *CCListen) NoteOff(channel int, n core.Note) {}
+
+// ControlChange is part of core.NoteListener
+func (c *CCListen) ControlChange(channel, number, value int) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if number != c.number {
+		return
+	}
+	if core.IsDebug() {
+		notify.Debugf("control.cclisten ch=%d,nr=%d,val=%d", channel, number, value)
+	}
+	c.ctx.Variables().Put(c.variableName, scaleControlValue(value, c.min, c.max))
+}
+
+// scaleControlValue maps a value [0..127] to [min..max] ; an integer if both min and max are integers.
+func scaleControlValue(value int, min, max interface{}) interface{} {
+	if min == nil || max == nil {
+		return value
+	}
+	imin, minIsInt := min.(int)
+	imax, maxIsInt := max.(int)
+	if minIsInt && maxIsInt {
+		// round to nearest
+		return imin + ((imax-imin)*value*2+127)/(127*2)
+	}
+	fmin, fmax := toFloat64(min), toFloat64(max)
+	return fmin + (fmax-fmin)*float64(value)/127.0
+}
+
+func toFloat64(v interface{}) float64 {
+	if i, ok := v.(int); ok {
+		return float64(i)
+	}
+	f, _ := v.(float64)
+	return f
+}
+
+// Storex is part of core.Storable
+func (c *CCListen) Storex() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if c.min == nil || c.max == nil {
+		return fmt.Sprintf("cclisten(%d,%d,%s)", c.deviceID, c.number, c.variableName)
+	}
+	return fmt.Sprintf("cclisten(%d,%d,%s,%v,%v)", c.deviceID, c.number, c.variableName, c.min, c.max)
+}
+
+// CCLearn waits for the first control change from an input device and then
+// starts a CCListen for its number that stores the values in a variable.
+type CCLearn struct {
+	ctx          core.Context
+	deviceID     int
+	variableName string
+	once         sync.Once
+	learned      func(*CCListen)
+}
+
+// NewCCLearn returns a CCLearn that calls learned with the started CCListen.
+func NewCCLearn(ctx core.Context, deviceID int, variableName string, learned func(*CCListen)) *CCLearn {
+	return &CCLearn{ctx: ctx, deviceID: deviceID, variableName: variableName, learned: learned}
+}
+
+// Start listens for the first control change.
+func (l *CCLearn) Start() error {
+	if !l.ctx.Device().HasInputCapability() {
+		return errors.New("input is not available for this device")
+	}
+	l.ctx.Device().Listen(l.deviceID, l, true)
+	return nil
+}
+
+// NoteOn is part of core.NoteListener
+func (l *CCLearn) NoteOn(channel int, n core.Note) {}
+
+// NoteOff is part of core.NoteListener
+func (l *CCLearn) NoteOff(channel int, n core.Note) {}
+
+// ControlChange is part of core.NoteListener
+func (l *CCLearn) ControlChange(channel, number, value int) {
+	l.once.Do(func() {
+		// not while the device is dispatching to its listeners
+		go func() {
+			l.ctx.Device().Listen(l.deviceID, l, false)
+			cc := NewCCListen(l.ctx, l.deviceID, number, l.variableName, nil, nil)
+			if err := cc.Play(l.ctx, time.Now()); err != nil {
+				notify.Warnf("cannot listen to control change %d: %v", number, err)
+				return
+			}
+			cc.ControlChange(channel, number, value)
+			l.learned(cc)
+		}()
+	})
+}
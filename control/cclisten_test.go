@@ -0,0 +1,23 @@
+package control
+
+import "testing"
+
+func TestScaleControlValue(t *testing.T) {
+	for _, each := range []struct {
+		value    int
+		min, max interface{}
+		want     interface{}
+	}{
+		{64, nil, nil, 64},
+		{0, 0, 100, 0},
+		{127, 0, 100, 100},
+		{64, 0, 100, 50},
+		{127, 1, 127, 127},
+		{0, 0.0, 1.0, 0.0},
+		{127, 0, 1.0, 1.0},
+	} {
+		if got, want := scaleControlValue(each.value, each.min, each.max), each.want; got != want {
+			t.Errorf("%d [%v..%v]: got [%v:%T] want [%v:%T]", each.value, each.min, each.max, got, got, want, want)
+		}
+	}
+}
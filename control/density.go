@@ -0,0 +1,42 @@
+package control
+
+import (
+	"fmt"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+type SetDensity struct {
+	density core.HasValue
+}
+
+func NewDensity(density core.HasValue) SetDensity {
+	return SetDensity{density: density}
+}
+
+// S has the side effect of setting the density
+func (s SetDensity) S() core.Sequence {
+	s.Evaluate(nil)
+	return core.EmptySequence
+}
+
+// Evaluate implements Evaluatable
+// performs the set operation ; the value is kept such that a knob can change the density
+func (s SetDensity) Evaluate(ctx core.Context) error {
+	if core.IsDebug() {
+		notify.Debugf("control.density set %s", core.Storex(s.density))
+	}
+	core.SetDensity(s.density)
+	return nil
+}
+
+// Inspect implements Inspectable
+func (s SetDensity) Inspect(i core.Inspection) {
+	i.Properties["density"] = fmt.Sprintf("%.2f", core.Float(s.density))
+}
+
+// Storex implements Storable
+func (s SetDensity) Storex() string {
+	return fmt.Sprintf("density(%s)", core.Storex(s.density))
+}
@@ -3,6 +3,7 @@ package control
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,6 +11,13 @@ import (
 	"github.com/emicklei/melrose/notify"
 )
 
+// ChordSuffix is appended to the variable name of a Listen for the variable that has the chord of all held notes.
+const ChordSuffix = "_chord"
+
+// Listen stores each note received from an input device in a variable and evaluates a callback.
+// All notes that are held at the same time are stored as a chord in the variable with the ChordSuffix, e.g. "rec_chord".
+// Only notes with a MIDI number in its range, and on its channel if set, are handled
+// such that multiple listeners can split a keyboard or a multi-channel controller.
 type Listen struct {
 	mutex           *sync.RWMutex
 	ctx             core.Context
@@ -18,7 +26,10 @@ type Listen struct {
 	isRunning       bool
 	callback        core.HasValue
 	notesOn         map[int]int
+	heldNotes       map[int]core.Note
 	noteChangeCount int
+	from, to        int // MIDI numbers, inclusive
+	channel         int // zero means any channel
 }
 
 func NewListen(ctx core.Context, deviceID int, variableName string, target core.HasValue) *Listen {
@@ -29,14 +40,57 @@ func NewListen(ctx core.Context, deviceID int, variableName string, target core.
 		variableName:    variableName,
 		callback:        target,
 		notesOn:         map[int]int{},
+		heldNotes:       map[int]core.Note{},
 		noteChangeCount: 0,
+		from:            0,
+		to:              127,
 	}
 }
 
+// Range returns the lowest and highest MIDI number of the notes that are handled.
+func (l *Listen) Range() (from, to int) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.from, l.to
+}
+
+// SetRange changes the lowest and highest MIDI number of the notes that are handled.
+func (l *Listen) SetRange(from, to int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.from, l.to = from, to
+}
+
+// Channel returns the MIDI channel of the notes that are handled ; zero means any channel.
+func (l *Listen) Channel() int {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.channel
+}
+
+// SetChannel changes the MIDI channel of the notes that are handled ; zero means any channel.
+func (l *Listen) SetChannel(channel int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.channel = channel
+}
+
+func (l *Listen) accepts(channel, nr int) bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return (l.channel == 0 || l.channel == channel) && nr >= l.from && nr <= l.to
+}
+
 // Inspect implements Inspectable
 func (l *Listen) Inspect(i core.Inspection) {
 	i.Properties["running"] = l.isRunning
 	i.Properties["device"] = l.deviceID
+	if l.channel > 0 {
+		i.Properties["channel"] = l.channel
+	}
+	if l.from > 0 || l.to < 127 {
+		i.Properties["range"] = fmt.Sprintf("%d..%d", l.from, l.to)
+	}
 }
 
 // Target is for replacing functions
@@ -73,6 +127,9 @@ func (l *Listen) IsPlaying() bool {
 
 // NoteOn is part of core.NoteListener
 func (l *Listen) NoteOn(channel int, n core.Note) {
+	if !l.accepts(channel, n.MIDI()) {
+		return
+	}
 	l.mutex.Lock()
 	if core.IsDebug() {
 		notify.Debugf("control.listen ON %v", n)
@@ -81,7 +138,9 @@ func (l *Listen) NoteOn(channel int, n core.Note) {
 	countCheck := l.noteChangeCount
 	nr := n.MIDI()
 	l.notesOn[nr] = countCheck
+	l.heldNotes[nr] = n
 	l.ctx.Variables().Put(l.variableName, n)
+	l.ctx.Variables().Put(l.variableName+ChordSuffix, l.heldChord())
 
 	// release so condition can be evaluated
 	l.mutex.Unlock()
@@ -112,6 +171,21 @@ func (l *Listen) NoteOff(channel int, n core.Note) {
 		notify.Debugf("control.listen OFF %v", n)
 	}
 	delete(l.notesOn, n.MIDI())
+	delete(l.heldNotes, n.MIDI())
+}
+
+// heldChord returns a sequence with one group of all held notes, from low to high. Requires a lock.
+func (l *Listen) heldChord() core.Sequence {
+	nrs := []int{}
+	for nr := range l.heldNotes {
+		nrs = append(nrs, nr)
+	}
+	sort.Ints(nrs)
+	group := []core.Note{}
+	for _, each := range nrs {
+		group = append(group, l.heldNotes[each])
+	}
+	return core.Sequence{Notes: [][]core.Note{group}}
 }
 
 func (l *Listen) ControlChange(channel, number, value int) {
@@ -120,5 +194,13 @@ func (l *Listen) ControlChange(channel, number, value int) {
 
 // Storex is part of core.Storable
 func (l *Listen) Storex() string {
-	return fmt.Sprintf("listen(%d,%s,%s)", l.deviceID, l.variableName, core.Storex(l.callback))
+	from, to := l.Range()
+	target := l.variableName
+	if ch := l.Channel(); ch > 0 {
+		target = fmt.Sprintf("channel(%d,%s)", ch, target)
+	}
+	if from > 0 || to < 127 {
+		return fmt.Sprintf("listen(device(%d,%s),%s,%d,%d)", l.deviceID, target, core.Storex(l.callback), from, to)
+	}
+	return fmt.Sprintf("listen(device(%d,%s),%s)", l.deviceID, target, core.Storex(l.callback))
 }
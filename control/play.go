@@ -30,7 +30,7 @@ func (p Play) Play(ctx core.Context, at time.Time) error {
 		cond = with.Condition()
 	}
 	for _, each := range p.target {
-		end := p.ctx.Device().Play(cond, each, p.ctx.Control().BPM(), at)
+		end := p.ctx.Device().Play(cond, core.Transposed(p.ctx.Control(), each), p.ctx.Control().BPM(), at)
 		if !p.sync {
 			// play after each other
 			at = end
@@ -48,7 +48,7 @@ func (p Play) Evaluate(ctx core.Context) error {
 		cond = with.Condition()
 	}
 	for _, each := range p.target {
-		end := p.ctx.Device().Play(cond, each, p.ctx.Control().BPM(), moment)
+		end := p.ctx.Device().Play(cond, core.Transposed(p.ctx.Control(), each), p.ctx.Control().BPM(), moment)
 		if !p.sync {
 			// play after each other
 			moment = end
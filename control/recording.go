@@ -2,17 +2,21 @@ package control
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/emicklei/melrose/core"
 	"github.com/emicklei/melrose/notify"
 )
 
+// Recording records the notes from an input device in a variable.
+// While the sustain pedal is down, the release of notes is deferred.
 type Recording struct {
 	deviceID     int
 	timeline     *core.Timeline
 	variableName string
 	bpm          float64
+	pedal        *sustainPedal
 }
 
 func NewRecording(deviceID int, variableName string, bpm float64) *Recording {
@@ -22,6 +26,7 @@ func NewRecording(deviceID int, variableName string, bpm float64) *Recording {
 		timeline:     tim,
 		variableName: variableName,
 		bpm:          bpm,
+		pedal:        newSustainPedal(),
 	}
 }
 
@@ -34,6 +39,7 @@ func (r *Recording) GetTargetFrom(other *Recording) {
 func (r *Recording) Play(ctx core.Context, at time.Time) error {
 	// flush
 	r.timeline.Reset()
+	r.pedal = newSustainPedal()
 	ctx.Device().Listen(r.deviceID, r, true)
 	return nil
 }
@@ -73,8 +79,22 @@ func (r *Recording) S() core.Sequenceable {
 	return builder.Build()
 }
 
+// Onsets is part of core.HasOnsets ; the positions are not quantized and the first is zero.
+func (r *Recording) Onsets() (onsets []float64) {
+	whole := float64(core.WholeNoteDuration(r.bpm).Milliseconds())
+	for _, each := range r.timeline.BuildNotePeriods() {
+		onsets = append(onsets, float64(each.Start().UnixNano()/1e6)/whole)
+	}
+	sort.Float64s(onsets)
+	return
+}
+
 func (r *Recording) NoteOn(channel int, n core.Note) {
 	when := time.Now()
+	if r.pedal.noteOn(n) {
+		// end the sustained note before it starts again
+		r.scheduleNoteOff(n, when)
+	}
 	change := core.NewNoteChange(true, int64(n.MIDI()), int64(n.Velocity))
 	if core.IsDebug() {
 		notify.Debugf("recording.noteon note:%v t:%s", n, when.Format("04:05.000"))
@@ -83,7 +103,13 @@ func (r *Recording) NoteOn(channel int, n core.Note) {
 }
 
 func (r *Recording) NoteOff(channel int, n core.Note) {
-	when := time.Now()
+	if r.pedal.noteOff(n) {
+		return
+	}
+	r.scheduleNoteOff(n, time.Now())
+}
+
+func (r *Recording) scheduleNoteOff(n core.Note, when time.Time) {
 	change := core.NewNoteChange(false, int64(n.MIDI()), int64(n.Velocity))
 	if core.IsDebug() {
 		notify.Debugf("recording.noteoff note:%v t:%s", n, when.Format("04:05.000"))
@@ -91,8 +117,14 @@ func (r *Recording) NoteOff(channel int, n core.Note) {
 	r.timeline.Schedule(change, when)
 }
 
-// ControlChange is ignored
-func (r *Recording) ControlChange(channel, number, value int) {}
+// ControlChange releases the notes that were held by the sustain pedal ; other changes are ignored.
+func (r *Recording) ControlChange(channel, number, value int) {
+	_, released := r.pedal.controlChange(number, value)
+	when := time.Now()
+	for _, each := range released {
+		r.scheduleNoteOff(each, when)
+	}
+}
 
 func (r *Recording) Inspect(i core.Inspection) {
 	i.Properties["sequence"] = r.S()
@@ -0,0 +1,186 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// StepRecord appends each note, or chord of notes held together, received from an input device
+// to the sequence of a variable. All steps have the same fraction ; there is no real-time pressure.
+// Optionally, a key appends a rest and a key removes the last step.
+type StepRecord struct {
+	mutex        *sync.Mutex
+	ctx          core.Context
+	deviceID     int
+	variableName string
+	fraction     float32
+	restKey      int // MIDI number, -1 if not used
+	backKey      int // MIDI number, -1 if not used
+	isRunning    bool
+	steps        [][]core.Note
+	held         map[int]bool      // MIDI numbers of keys that are down
+	pressed      map[int]core.Note // notes pressed since all keys were up
+}
+
+func NewStepRecord(ctx core.Context, deviceID int, variableName string, fraction float32, restKey, backKey int) *StepRecord {
+	return &StepRecord{
+		mutex:        new(sync.Mutex),
+		ctx:          ctx,
+		deviceID:     deviceID,
+		variableName: variableName,
+		fraction:     fraction,
+		restKey:      restKey,
+		backKey:      backKey,
+		held:         map[int]bool{},
+		pressed:      map[int]core.Note{},
+	}
+}
+
+// Inspect implements Inspectable
+func (s *StepRecord) Inspect(i core.Inspection) {
+	i.Properties["running"] = s.IsPlaying()
+	i.Properties["device"] = s.deviceID
+	i.Properties["fraction"] = s.fraction
+	s.mutex.Lock()
+	i.Properties["steps"] = len(s.steps)
+	s.mutex.Unlock()
+}
+
+// Replace takes the settings of another StepRecord ; recorded steps are kept.
+func (s *StepRecord) Replace(other *StepRecord) {
+	running := s.IsPlaying()
+	if running && other.deviceID != s.deviceID {
+		s.Stop(s.ctx)
+	}
+	s.mutex.Lock()
+	s.deviceID = other.deviceID
+	s.variableName = other.variableName
+	s.fraction = other.fraction
+	s.restKey, s.backKey = other.restKey, other.backKey
+	s.mutex.Unlock()
+	if running {
+		s.Play(s.ctx, time.Now())
+	}
+}
+
+// Play is part of core.Playable ; recording continues after the notes of the variable, if any.
+func (s *StepRecord) Play(ctx core.Context, at time.Time) error {
+	if !ctx.Device().HasInputCapability() {
+		return errors.New("input is not available for this device")
+	}
+	s.mutex.Lock()
+	if s.isRunning {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.isRunning = true
+	s.steps = [][]core.Note{}
+	if v, ok := ctx.Variables().Get(s.variableName); ok {
+		if seq, ok := core.ValueOf(v).(core.Sequenceable); ok {
+			s.steps = append(s.steps, seq.S().Notes...)
+		}
+	}
+	deviceID := s.deviceID
+	// unlock before listening ; the device can be dispatching a note to this listener
+	s.mutex.Unlock()
+	ctx.Device().Listen(deviceID, s, true)
+	return nil
+}
+
+// Stop is part of core.Stoppable
+func (s *StepRecord) Stop(ctx core.Context) error {
+	s.mutex.Lock()
+	if !s.isRunning {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.isRunning = false
+	deviceID := s.deviceID
+	s.mutex.Unlock()
+	ctx.Device().Listen(deviceID, s, false)
+	return nil
+}
+
+// IsPlaying is part of core.Stoppable
+func (s *StepRecord) IsPlaying() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.isRunning
+}
+
+// NoteOn is part of core.NoteListener
+func (s *StepRecord) NoteOn(channel int, n core.Note) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	nr := n.MIDI()
+	switch nr {
+	case s.restKey:
+		s.append([]core.Note{core.Rest4.WithFraction(s.fraction, false)})
+		return
+	case s.backKey:
+		if len(s.steps) > 0 {
+			s.steps = s.steps[:len(s.steps)-1]
+			s.put()
+		}
+		return
+	}
+	s.held[nr] = true
+	s.pressed[nr] = n.WithFraction(s.fraction, false)
+}
+
+// NoteOff is part of core.NoteListener ; when all keys are up, the pressed notes are appended as one step.
+func (s *StepRecord) NoteOff(channel int, n core.Note) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.held, n.MIDI())
+	if len(s.held) > 0 || len(s.pressed) == 0 {
+		return
+	}
+	nrs := []int{}
+	for nr := range s.pressed {
+		nrs = append(nrs, nr)
+	}
+	sort.Ints(nrs)
+	group := []core.Note{}
+	for _, each := range nrs {
+		group = append(group, s.pressed[each])
+	}
+	s.pressed = map[int]core.Note{}
+	s.append(group)
+}
+
+// ControlChange is part of core.NoteListener
+func (s *StepRecord) ControlChange(channel, number, value int) {}
+
+// append adds a step and updates the variable. Requires a lock.
+func (s *StepRecord) append(group []core.Note) {
+	s.steps = append(s.steps, group)
+	s.put()
+}
+
+// put stores the sequence of all steps in the variable. Requires a lock.
+func (s *StepRecord) put() {
+	steps := make([][]core.Note, len(s.steps))
+	copy(steps, s.steps)
+	seq := core.Sequence{Notes: steps}
+	s.ctx.Variables().Put(s.variableName, seq)
+	if core.IsDebug() {
+		notify.Debugf("control.steprecord %s = %s", s.variableName, seq.Storex())
+	}
+}
+
+// Storex is part of core.Storable
+func (s *StepRecord) Storex() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.restKey < 0 && s.backKey < 0 {
+		return fmt.Sprintf("steprecord(device(%d,%s),%v)", s.deviceID, s.variableName, s.fraction)
+	}
+	return fmt.Sprintf("steprecord(device(%d,%s),%v,%d,%d)", s.deviceID, s.variableName, s.fraction, s.restKey, s.backKey)
+}
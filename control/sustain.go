@@ -0,0 +1,49 @@
+package control
+
+import "github.com/emicklei/melrose/core"
+
+// sustainPedalNumber is the MIDI control change number of the sustain (damper) pedal.
+const sustainPedalNumber = 64
+
+// sustainPedal defers the release of notes while the sustain pedal is down, such that what is
+// recorded or forwarded is what was actually heard.
+type sustainPedal struct {
+	down     bool
+	deferred map[int]core.Note // MIDI number -> note released while the pedal is down
+}
+
+func newSustainPedal() *sustainPedal {
+	return &sustainPedal{deferred: map[int]core.Note{}}
+}
+
+// noteOn returns whether the note was still sounding because of the pedal ; it must be released before it is played again.
+func (s *sustainPedal) noteOn(n core.Note) bool {
+	_, ok := s.deferred[n.MIDI()]
+	delete(s.deferred, n.MIDI())
+	return ok
+}
+
+// noteOff returns whether the release of the note is deferred until the pedal is up.
+func (s *sustainPedal) noteOff(n core.Note) bool {
+	if !s.down {
+		return false
+	}
+	s.deferred[n.MIDI()] = n
+	return true
+}
+
+// controlChange returns whether the change is for the pedal and, if the pedal is released, the notes to release now.
+func (s *sustainPedal) controlChange(number, value int) (isPedal bool, released []core.Note) {
+	if number != sustainPedalNumber {
+		return false, nil
+	}
+	s.down = value >= 64
+	if s.down {
+		return true, nil
+	}
+	for nr, each := range s.deferred {
+		released = append(released, each)
+		delete(s.deferred, nr)
+	}
+	return true, released
+}
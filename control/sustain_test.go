@@ -0,0 +1,58 @@
+package control
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestSustainPedalDefersNoteOff(t *testing.T) {
+	p := newSustainPedal()
+	c := core.MustParseNote("C")
+	if got, want := p.noteOff(c), false; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	p.controlChange(sustainPedalNumber, 127)
+	if got, want := p.noteOff(c), true; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	isPedal, released := p.controlChange(sustainPedalNumber, 0)
+	if got, want := isPedal, true; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := len(released), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := released[0].MIDI(), c.MIDI(); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestSustainPedalReplayedNote(t *testing.T) {
+	p := newSustainPedal()
+	c := core.MustParseNote("C")
+	p.controlChange(sustainPedalNumber, 64)
+	p.noteOff(c)
+	if got, want := p.noteOn(c), true; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	_, released := p.controlChange(sustainPedalNumber, 0)
+	if got, want := len(released), 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestRecordingSustainPedal(t *testing.T) {
+	r := NewRecording(1, "rec", 120)
+	c := core.MustParseNote("C")
+	r.ControlChange(1, sustainPedalNumber, 127)
+	r.NoteOn(1, c)
+	r.NoteOff(1, c)
+	if got, want := r.timeline.Len(), int64(1); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	r.ControlChange(1, sustainPedalNumber, 0)
+	if got, want := r.timeline.Len(), int64(2); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
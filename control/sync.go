@@ -77,7 +77,7 @@ func (s SyncPlay) Evaluate(ctx core.Context) error {
 			_ = ply.Play(ctx, time.Now())
 		} else {
 			if seq, ok := val.(core.Sequenceable); ok {
-				_ = ctx.Device().Play(core.NoCondition, seq, ctx.Control().BPM(), time.Now())
+				_ = ctx.Device().Play(core.NoCondition, core.Transposed(ctx.Control(), seq), ctx.Control().BPM(), time.Now())
 			}
 		}
 	}
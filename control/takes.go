@@ -0,0 +1,183 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// Takes records a number of takes in a row from an input device, each a number of bars long.
+// Use At to select a take ; the first take has index 1.
+type Takes struct {
+	mutex     *sync.Mutex
+	ctx       core.Context
+	deviceID  int
+	count     int
+	bars      int
+	takes     []core.Sequence
+	current   *Recording // nil if not recording
+	timer     *time.Timer
+	isRunning bool
+}
+
+func NewTakes(ctx core.Context, deviceID, count, bars int) *Takes {
+	return &Takes{
+		mutex:    new(sync.Mutex),
+		ctx:      ctx,
+		deviceID: deviceID,
+		count:    count,
+		bars:     bars,
+	}
+}
+
+// Inspect implements Inspectable
+func (t *Takes) Inspect(i core.Inspection) {
+	i.Properties["running"] = t.IsPlaying()
+	i.Properties["device"] = t.deviceID
+	i.Properties["bars"] = t.bars
+	t.mutex.Lock()
+	i.Properties["takes"] = fmt.Sprintf("%d/%d", len(t.takes), t.count)
+	t.mutex.Unlock()
+}
+
+// Replace takes the settings of another Takes for the next recording ; recorded takes are kept.
+func (t *Takes) Replace(other *Takes) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.isRunning {
+		notify.Warnf("cannot change takes while recording")
+		return
+	}
+	t.deviceID = other.deviceID
+	t.count = other.count
+	t.bars = other.bars
+}
+
+// Play is part of core.Playable ; it starts recording all takes, replacing the previous ones.
+func (t *Takes) Play(ctx core.Context, at time.Time) error {
+	if !ctx.Device().HasInputCapability() {
+		return errors.New("input is not available for this device")
+	}
+	t.mutex.Lock()
+	if t.isRunning {
+		t.mutex.Unlock()
+		return nil
+	}
+	t.isRunning = true
+	t.takes = []core.Sequence{}
+	bpm := ctx.Control().BPM()
+	t.current = NewRecording(t.deviceID, "", bpm)
+	// the duration of a take
+	take := core.WholeNoteDuration(bpm) / 4 * time.Duration(ctx.Control().BIAB()*t.bars)
+	t.timer = time.AfterFunc(time.Until(at.Add(take)), func() { t.nextTake(ctx, take) })
+	deviceID := t.deviceID
+	notify.Infof("recording take 1 of %d", t.count)
+	// unlock before listening ; the device can be dispatching a note to this listener
+	t.mutex.Unlock()
+	ctx.Device().Listen(deviceID, t, true)
+	return nil
+}
+
+// nextTake keeps the current take and starts the next one, if any.
+func (t *Takes) nextTake(ctx core.Context, take time.Duration) {
+	t.mutex.Lock()
+	if !t.isRunning {
+		t.mutex.Unlock()
+		return
+	}
+	t.keepCurrent()
+	if len(t.takes) < t.count {
+		t.current = NewRecording(t.deviceID, "", t.current.bpm)
+		t.timer = time.AfterFunc(take, func() { t.nextTake(ctx, take) })
+		notify.Infof("recording take %d of %d", len(t.takes)+1, t.count)
+		t.mutex.Unlock()
+		return
+	}
+	t.isRunning = false
+	deviceID := t.deviceID
+	t.mutex.Unlock()
+	ctx.Device().Listen(deviceID, t, false)
+	notify.Infof("recorded %d takes", t.count)
+}
+
+// keepCurrent appends the sequence of the current recording to the takes. Requires a lock.
+func (t *Takes) keepCurrent() {
+	if t.current == nil {
+		return
+	}
+	t.takes = append(t.takes, t.current.S().S())
+	t.current = nil
+}
+
+// Stop is part of core.Stoppable ; the take being recorded is kept.
+func (t *Takes) Stop(ctx core.Context) error {
+	t.mutex.Lock()
+	if !t.isRunning {
+		t.mutex.Unlock()
+		return nil
+	}
+	t.isRunning = false
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.keepCurrent()
+	deviceID := t.deviceID
+	t.mutex.Unlock()
+	ctx.Device().Listen(deviceID, t, false)
+	return nil
+}
+
+// IsPlaying is part of core.Stoppable
+func (t *Takes) IsPlaying() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.isRunning
+}
+
+// At is part of core.Indexable ; one-based. Returns an empty sequence if the take is not recorded.
+func (t *Takes) At(i int) core.Sequenceable {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if i < 1 || i > len(t.takes) {
+		return core.EmptySequence
+	}
+	return t.takes[i-1]
+}
+
+// NoteOn is part of core.NoteListener
+func (t *Takes) NoteOn(channel int, n core.Note) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.current != nil {
+		t.current.NoteOn(channel, n)
+	}
+}
+
+// NoteOff is part of core.NoteListener
+func (t *Takes) NoteOff(channel int, n core.Note) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.current != nil {
+		t.current.NoteOff(channel, n)
+	}
+}
+
+// ControlChange is part of core.NoteListener
+func (t *Takes) ControlChange(channel, number, value int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.current != nil {
+		t.current.ControlChange(channel, number, value)
+	}
+}
+
+// Storex is part of core.Storable
+func (t *Takes) Storex() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return fmt.Sprintf("recordtakes(%d,%d,%d)", t.deviceID, t.count, t.bars)
+}
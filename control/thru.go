@@ -0,0 +1,216 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/midi"
+	"github.com/emicklei/melrose/notify"
+)
+
+const controlChange int64 = 0xB0 // 10110000 , 176
+
+// Thru forwards notes received from an input device to an output device.
+// If a transform is set then each incoming note is stored in a variable
+// and the notes of the transform (which refers to that variable) are sent instead.
+type Thru struct {
+	mutex          *sync.Mutex
+	ctx            core.Context
+	inputDeviceID  int
+	outputDeviceID int
+	variableName   string
+	transform      core.HasValue
+	isRunning      bool
+	// for each incoming note number, the channel and note numbers that were sent
+	notesOn map[int]thruNotes
+	// while down, notes are released when the pedal is up
+	pedal *sustainPedal
+}
+
+type thruNotes struct {
+	channel int
+	numbers []int
+}
+
+func NewThru(ctx core.Context, inputDeviceID, outputDeviceID int, variableName string, transform core.HasValue) *Thru {
+	return &Thru{
+		mutex:          new(sync.Mutex),
+		ctx:            ctx,
+		inputDeviceID:  inputDeviceID,
+		outputDeviceID: outputDeviceID,
+		variableName:   variableName,
+		transform:      transform,
+		notesOn:        map[int]thruNotes{},
+		pedal:          newSustainPedal(),
+	}
+}
+
+// Inspect implements Inspectable
+func (t *Thru) Inspect(i core.Inspection) {
+	i.Properties["running"] = t.IsPlaying()
+	i.Properties["input"] = t.inputDeviceID
+	i.Properties["output"] = t.outputDeviceID
+}
+
+// Target is for replacing functions
+func (t *Thru) Target() core.HasValue {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.transform
+}
+
+// SetTarget is for replacing functions
+func (t *Thru) SetTarget(c core.HasValue) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.transform = c
+}
+
+// Play is part of core.Playable
+func (t *Thru) Play(ctx core.Context, at time.Time) error {
+	if !ctx.Device().HasInputCapability() {
+		return errors.New("input is not available for this device")
+	}
+	t.mutex.Lock()
+	if t.isRunning {
+		t.mutex.Unlock()
+		return nil
+	}
+	t.isRunning = true
+	// unlock before listening ; the device can be dispatching a note to this thru
+	t.mutex.Unlock()
+	ctx.Device().Listen(t.inputDeviceID, t, true)
+	return nil
+}
+
+// Stop is part of core.Stoppable
+func (t *Thru) Stop(ctx core.Context) error {
+	t.mutex.Lock()
+	if !t.isRunning {
+		t.mutex.Unlock()
+		return nil
+	}
+	t.isRunning = false
+	t.mutex.Unlock()
+	ctx.Device().Listen(t.inputDeviceID, t, false)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	// release all notes that are still sounding
+	for nr, each := range t.notesOn {
+		t.sendAll(noteOff, each, 0)
+		delete(t.notesOn, nr)
+	}
+	t.pedal = newSustainPedal()
+	return nil
+}
+
+// IsPlaying is part of core.Stoppable
+func (t *Thru) IsPlaying() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.isRunning
+}
+
+// NoteOn is part of core.NoteListener
+func (t *Thru) NoteOn(channel int, n core.Note) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if core.IsDebug() {
+		notify.Debugf("control.thru ON %v", n)
+	}
+	if t.pedal.noteOn(n) {
+		// release the sustained note before it is played again
+		t.release(n.MIDI())
+	}
+	sent := t.transformed(channel, n)
+	t.notesOn[n.MIDI()] = sent
+	t.sendAll(noteOn, sent, n.Velocity)
+}
+
+// NoteOff is part of core.NoteListener
+func (t *Thru) NoteOff(channel int, n core.Note) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if core.IsDebug() {
+		notify.Debugf("control.thru OFF %v", n)
+	}
+	if t.pedal.noteOff(n) {
+		return
+	}
+	t.release(n.MIDI())
+}
+
+// release sends note offs for the notes that were sent for an incoming note number. Requires a lock.
+func (t *Thru) release(nr int) {
+	sent, ok := t.notesOn[nr]
+	if !ok {
+		return
+	}
+	delete(t.notesOn, nr)
+	t.sendAll(noteOff, sent, 0)
+}
+
+// ControlChange is part of core.NoteListener ; notes held by the sustain pedal are released when it is up.
+func (t *Thru) ControlChange(channel, number, value int) {
+	t.mutex.Lock()
+	_, released := t.pedal.controlChange(number, value)
+	for _, each := range released {
+		t.release(each.MIDI())
+	}
+	t.mutex.Unlock()
+	t.send(controlChange, channel, number, value)
+}
+
+// transformed returns the channel and note numbers to send for an incoming note.
+func (t *Thru) transformed(channel int, n core.Note) thruNotes {
+	if t.transform == nil || t.variableName == "" {
+		return thruNotes{channel: channel, numbers: []int{n.MIDI()}}
+	}
+	t.ctx.Variables().Put(t.variableName, n)
+	value := t.transform.Value()
+	if sel, ok := value.(core.ChannelSelector); ok {
+		channel = sel.Channel()
+	}
+	result := thruNotes{channel: channel}
+	seq, ok := value.(core.Sequenceable)
+	if !ok {
+		notify.Warnf("cannot transform incoming note with (%T) %s", value, core.Storex(value))
+		return result
+	}
+	for _, group := range seq.S().Notes {
+		for _, each := range group {
+			if !each.IsHearable() {
+				continue
+			}
+			result.numbers = append(result.numbers, each.MIDI())
+		}
+	}
+	return result
+}
+
+func (t *Thru) sendAll(status int64, notes thruNotes, velocity int) {
+	for _, nr := range notes.numbers {
+		t.send(status, notes.channel, nr, velocity)
+	}
+}
+
+func (t *Thru) send(status int64, channel, data1, data2 int) {
+	midi.NewMessage(
+		t.ctx.Device(),
+		core.On(t.outputDeviceID),
+		int(status),
+		core.On(channel),
+		core.On(data1),
+		core.On(data2)).Evaluate(t.ctx)
+}
+
+// Storex is part of core.Storable
+func (t *Thru) Storex() string {
+	if t.transform == nil || t.variableName == "" {
+		return fmt.Sprintf("thru(%d,%d)", t.inputDeviceID, t.outputDeviceID)
+	}
+	return fmt.Sprintf("thru(device(%d,%s),%d,%s)", t.inputDeviceID, t.variableName, t.outputDeviceID, core.Storex(t.transform))
+}
@@ -0,0 +1,56 @@
+package control
+
+import (
+	"fmt"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// SetTranspose changes the semitones added to everything that is played or looped from now on.
+type SetTranspose struct {
+	semitones core.HasValue // a number or a named interval such as M2
+	ctx       core.Context
+}
+
+func NewTranspose(semitones core.HasValue, ctx core.Context) SetTranspose {
+	return SetTranspose{semitones: semitones, ctx: ctx}
+}
+
+// S has the side effect of setting the transposition
+func (s SetTranspose) S() core.Sequence {
+	if err := s.Evaluate(s.ctx); err != nil {
+		notify.Errorf("%v", err)
+	}
+	return core.EmptySequence
+}
+
+// Evaluate implements Evaluatable
+// performs the set operation
+func (s SetTranspose) Evaluate(ctx core.Context) error {
+	var semitones int
+	if name, ok := core.ValueOf(s.semitones).(string); ok {
+		n, err := core.ParseInterval(name)
+		if err != nil {
+			return err
+		}
+		semitones = n
+	} else {
+		semitones = core.Int(s.semitones)
+	}
+	if core.IsDebug() {
+		notify.Debugf("control.transpose set %d", semitones)
+	}
+	ctx.Control().SetTranspose(semitones)
+	return nil
+}
+
+// Inspect implements Inspectable
+func (s SetTranspose) Inspect(i core.Inspection) {
+	i.Properties["semitones"] = core.Storex(s.semitones)
+}
+
+// Storex implements Storable
+func (s SetTranspose) Storex() string {
+	return fmt.Sprintf("transposeall(%s)", core.Storex(s.semitones))
+}
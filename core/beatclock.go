@@ -0,0 +1,53 @@
+package core
+
+import (
+	"math"
+	"time"
+)
+
+// BeatClock is a source of beat timing that is shared with other applications, e.g. an Ableton Link session.
+// Beats are counted by the clock ; its bars are multiples of the BIAB of the control.
+type BeatClock interface {
+	// BeatAt returns the number of beats, with a fraction, at a moment.
+	BeatAt(t time.Time) float64
+	// TimeAt returns the moment of a number of beats.
+	TimeAt(beats float64) time.Time
+}
+
+// ClockedController is a LoopController that can follow a BeatClock.
+type ClockedController interface {
+	// SetClock changes the clock of the beats ; nil means the own ticker.
+	SetClock(c BeatClock)
+	Clock() BeatClock
+}
+
+// ClockOf returns the BeatClock of the control of a context, if any.
+func ClockOf(ctx Context) (BeatClock, bool) {
+	c, ok := ctx.Control().(ClockedController)
+	if !ok {
+		return nil, false
+	}
+	clock := c.Clock()
+	return clock, clock != nil
+}
+
+// nextBeatOf returns the moment and the number of the first whole beat of a clock after a moment.
+func nextBeatOf(c BeatClock, after time.Time) (time.Time, int64) {
+	beat := math.Floor(c.BeatAt(after)) + 1
+	return c.TimeAt(beat), int64(beat)
+}
+
+// nearestBeatOf returns the moment of the whole beat of a clock that is nearest to a moment.
+func nearestBeatOf(c BeatClock, moment time.Time) time.Time {
+	return c.TimeAt(math.Round(c.BeatAt(moment)))
+}
+
+// nextBarOf returns the first moment, at or after a moment, on a bar of a clock.
+func nextBarOf(c BeatClock, moment time.Time, biab int) time.Time {
+	if biab <= 0 {
+		return moment
+	}
+	// tolerate rounding of durations
+	bars := math.Ceil(c.BeatAt(moment)/float64(biab) - 1e-6)
+	return c.TimeAt(bars * float64(biab))
+}
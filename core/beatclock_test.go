@@ -0,0 +1,87 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// fixedClock has beats of the same duration, counted from an origin.
+type fixedClock struct {
+	origin time.Time
+	beat   time.Duration
+}
+
+func (c fixedClock) BeatAt(t time.Time) float64 {
+	return float64(t.Sub(c.origin)) / float64(c.beat)
+}
+
+func (c fixedClock) TimeAt(beats float64) time.Time {
+	return c.origin.Add(time.Duration(beats * float64(c.beat)))
+}
+
+func TestBeatClock_Moments(t *testing.T) {
+	origin := time.Now()
+	c := fixedClock{origin: origin, beat: 500 * time.Millisecond}
+	next, beat := nextBeatOf(c, origin.Add(1200*time.Millisecond))
+	if got, want := next, origin.Add(1500*time.Millisecond); !got.Equal(want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := beat, int64(3); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := nearestBeatOf(c, origin.Add(1200*time.Millisecond)), origin.Add(time.Second); !got.Equal(want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := nextBarOf(c, origin.Add(1200*time.Millisecond), 4), origin.Add(2*time.Second); !got.Equal(want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// on a bar
+	if got, want := nextBarOf(c, origin.Add(2*time.Second), 4), origin.Add(2*time.Second); !got.Equal(want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestBeatmaster_tickOnClock(t *testing.T) {
+	b := NewBeatmaster(PlayContext{}, 120.0)
+	performed := 0
+	b.Schedule(0, func(when time.Time) { performed++ })
+	// beat 2 of the clock is not on a bar
+	b.tickOnClock(time.Now(), 2)
+	if got, want := performed, 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := b.beats, int64(0); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	b.tickOnClock(time.Now(), 4)
+	if got, want := performed, 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// the schedule is empty
+	b.tickOnClock(time.Now(), 5)
+	if got, want := b.beats, int64(0); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestLoop_PlayOnClock(t *testing.T) {
+	runningLoop = nil
+	defer func() { runningLoop = nil }()
+	b := NewBeatmaster(PlayContext{}, 120.0)
+	origin := time.Now()
+	b.SetClock(fixedClock{origin: origin, beat: 500 * time.Millisecond})
+	ctx := PlayContext{LoopControl: b, AudioDevice: new(sequencingDevice)}
+	l := NewLoop(ctx, []Sequenceable{MustParseSequence("c d e")})
+	if err := l.Play(ctx, origin.Add(100*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Stop(ctx)
+	// starts on the next bar of the clock
+	if got, want := l.startedAt, origin.Add(2*time.Second); !got.Equal(want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// three quarters later, on a beat of the clock
+	if got, want := l.nextPlayAt, origin.Add(3500*time.Millisecond); !got.Equal(want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
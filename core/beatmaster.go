@@ -2,6 +2,7 @@ package core
 
 import (
 	"math"
+	"sync"
 	"time"
 
 	"github.com/emicklei/melrose/notify"
@@ -18,7 +19,10 @@ type Beatmaster struct {
 	beats           int64   // monotonic increasing number, starting at 0
 	biab            int64   // current number of beats in a bar
 	bpm             float64 // current beats per minute
+	transpose       int     // semitones added to everything that is played
 	settingNotifier func(LoopController)
+	mutex           *sync.RWMutex // guards the clock
+	clock           BeatClock     // nil if the ticker makes the beats
 }
 
 func NewBeatmaster(ctx Context, bpm float64) *Beatmaster {
@@ -30,7 +34,22 @@ func NewBeatmaster(ctx Context, bpm float64) *Beatmaster {
 		schedule:   NewBeatSchedule(),
 		beats:      0,
 		biab:       4,
-		bpm:        bpm}
+		bpm:        bpm,
+		mutex:      new(sync.RWMutex)}
+}
+
+// SetClock is part of ClockedController
+func (b *Beatmaster) SetClock(c BeatClock) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.clock = c
+}
+
+// Clock is part of ClockedController
+func (b *Beatmaster) Clock() BeatClock {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.clock
 }
 
 func (b *Beatmaster) Reset() {
@@ -54,6 +73,16 @@ func (b *Beatmaster) BIAB() int {
 	return int(b.biab)
 }
 
+// SetTranspose changes the semitones added to everything that is played from now on, except drums on channel 10.
+func (b *Beatmaster) SetTranspose(semitones int) {
+	b.transpose = semitones
+	b.notifySettingChanged()
+}
+
+func (b *Beatmaster) Transpose() int {
+	return b.transpose
+}
+
 func (b *Beatmaster) BeatsAndBars() (int64, int64) {
 	return b.beats, b.beats / b.biab
 }
@@ -72,11 +101,18 @@ func (b *Beatmaster) Plan(bars int64, seq Sequenceable) {
 	b.schedule.Schedule(atBeats, func(when time.Time) {
 		d := b.context.Device()
 		if d != nil { // TODO happens on testing; NEEDSFIX
-			d.Play(NoCondition, seq, b.bpm, when)
+			at := WithPosition(b.context, Position{Bar: atBeats / b.biab, BIAB: int(b.biab), Start: when})
+			d.Play(NoCondition, Transposed(b, Positioned(at, seq)), b.bpm, when)
 		}
 	})
 }
 
+// Schedule is part of LoopControl
+// beats is the absolute number of beats at which the action is performed.
+func (b *Beatmaster) Schedule(beats int64, action BeatAction) {
+	b.schedule.Schedule(beats, action)
+}
+
 func (b *Beatmaster) beatsAtNextBar() int64 {
 	if b.beats%b.biab == 0 {
 		return b.beats
@@ -132,6 +168,7 @@ func (b *Beatmaster) Start() {
 	b.ticker = time.NewTicker(beatTickerDuration(b.bpm))
 	b.beating = true
 	go func() {
+		last := time.Now()
 		if IsDebug() {
 			notify.Debugf("core.beatmaster: started bpm=%v tick=%v", b.bpm, beatTickerDuration(b.bpm))
 		}
@@ -155,24 +192,67 @@ func (b *Beatmaster) Start() {
 				}
 			}
 			// in between bars
+			clock := b.Clock()
+			if clock == nil {
+				select {
+				case <-b.done:
+					return
+				case now := <-b.ticker.C:
+					b.tick(now)
+				}
+				continue
+			}
+			// the beats are made by the clock
+			after := time.Now()
+			if after.Before(last) {
+				after = last
+			}
+			next, beat := nextBeatOf(clock, after)
+			timer := time.NewTimer(time.Until(next))
 			select {
 			case <-b.done:
+				timer.Stop()
 				return
-			case now := <-b.ticker.C:
-				if b.schedule.IsEmpty() {
-					b.beats = 0
-				} else {
-					actions := b.schedule.Unschedule(b.beats)
-					for _, each := range actions {
-						each(now)
-					}
-					b.beats++
-				}
+			case <-timer.C:
+				last = next
+				b.tickOnClock(next, beat)
 			}
 		}
 	}()
 }
 
+// tick performs the actions scheduled at the current beat.
+func (b *Beatmaster) tick(now time.Time) {
+	if b.schedule.IsEmpty() {
+		b.beats = 0
+		return
+	}
+	actions := b.schedule.Unschedule(b.beats)
+	for _, each := range actions {
+		performSafely(each, now)
+	}
+	b.beats++
+}
+
+// tickOnClock performs the actions scheduled at the current beat if it is in phase with a beat of the clock.
+// Otherwise the beat waits such that bars start together with the bars of the clock.
+func (b *Beatmaster) tickOnClock(now time.Time, beat int64) {
+	if !b.schedule.IsEmpty() && (b.beats-beat)%b.biab != 0 {
+		return
+	}
+	b.tick(now)
+}
+
+// performSafely calls a scheduled action ; if it fails then it is reported and the beats go on.
+func performSafely(action func(time.Time), now time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			notify.Errorf("failed to perform scheduled action: %v", r)
+		}
+	}()
+	action(now)
+}
+
 func beatTickerDuration(bpm float64) time.Duration {
 	return time.Duration(int(math.Round(float64(60*1000)/bpm))) * time.Millisecond
 }
@@ -202,6 +282,9 @@ func (s zeroBeat) SetBPM(bpm float64)                           {}
 func (s zeroBeat) BPM() float64                                 { return 120.0 }
 func (s zeroBeat) SetBIAB(biab int)                             {}
 func (s zeroBeat) BIAB() int                                    { return 4 }
+func (s zeroBeat) SetTranspose(semitones int)                   {}
+func (s zeroBeat) Transpose() int                               { return 0 }
 func (s zeroBeat) BeatsAndBars() (int64, int64)                 { return 0, 0 }
 func (s zeroBeat) Plan(bars int64, seq Sequenceable)            {}
+func (s zeroBeat) Schedule(beats int64, action BeatAction)      {}
 func (s zeroBeat) SettingNotifier(handler func(LoopController)) {}
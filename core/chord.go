@@ -2,6 +2,7 @@ package core
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,9 +12,52 @@ import (
 // https://en.wikipedia.org/wiki/Chord_(music)
 type Chord struct {
 	start     Note
-	inversion int // Ground,Inversion1,Inversion2,Inversion3
-	interval  int // Triad,Seventh,Sixth
-	quality   int // Major,Minor,Dominant,Augmented,Diminished,Suspended2,Suspended4
+	inversion int    // Ground,Inversion1,Inversion2,Inversion3
+	interval  int    // Triad,Seventh,Sixth
+	quality   int    // Major,Minor,Dominant,Augmented,Diminished,Suspended2,Suspended4
+	extension string // if set then the name of an extended or altered chord, see extendedChords
+	bass      Note   // if its Name is set then this note is played below the chord (slash chord)
+}
+
+// chordExtension describes an extended or altered chord.
+type chordExtension struct {
+	quality   int
+	interval  int
+	semitones []int // above the root
+}
+
+// extendedChords maps the name of an extended or altered chord to its tones.
+// The 11th is left out of the (major) 13th chords as is common practice.
+var extendedChords = map[string]chordExtension{
+	"9":     {Septiem, Seventh, []int{4, 7, 10, 14}},
+	"maj9":  {Major, Seventh, []int{4, 7, 11, 14}},
+	"m9":    {Minor, Seventh, []int{3, 7, 10, 14}},
+	"11":    {Septiem, Seventh, []int{4, 7, 10, 14, 17}},
+	"maj11": {Major, Seventh, []int{4, 7, 11, 14, 17}},
+	"m11":   {Minor, Seventh, []int{3, 7, 10, 14, 17}},
+	"13":    {Septiem, Seventh, []int{4, 7, 10, 14, 21}},
+	"maj13": {Major, Seventh, []int{4, 7, 11, 14, 21}},
+	"m13":   {Minor, Seventh, []int{3, 7, 10, 14, 17, 21}},
+	"add9":  {Major, Triad, []int{4, 7, 14}},
+	"madd9": {Minor, Triad, []int{3, 7, 14}},
+	"69":    {Major, Sixth, []int{4, 7, 9, 14}},
+	"7b9":   {Septiem, Seventh, []int{4, 7, 10, 13}},
+	"7#9":   {Septiem, Seventh, []int{4, 7, 10, 15}},
+	"7#11":  {Septiem, Seventh, []int{4, 7, 10, 18}},
+	"7b5":   {Septiem, Seventh, []int{4, 6, 10}},
+	"m7b5":  {Diminished, Seventh, []int{3, 6, 10}},
+	"7sus4": {Septiem, Seventh, []int{5, 7, 10}},
+	"mmaj7": {Minor, Seventh, []int{3, 7, 11}},
+}
+
+// chordAliases maps alternative names to those of extendedChords.
+var chordAliases = map[string]string{
+	"M9":  "maj9",
+	"M11": "maj11",
+	"M13": "maj13",
+	"ø":   "m7b5",
+	"ø7":  "m7b5",
+	"mM7": "mmaj7",
 }
 
 func zeroChord() Chord {
@@ -22,6 +66,7 @@ func zeroChord() Chord {
 
 func (c Chord) Inspect(i Inspection) {
 	i.Properties["sequence"] = c.S().String()
+	i.Properties["symbol"] = c.Symbol()
 }
 
 func (c Chord) WithInterval(i int) Chord {
@@ -55,61 +100,121 @@ func (c Chord) String() string {
 	}
 	var b bytes.Buffer
 	fmt.Fprint(&b, c.start.String())
-	endsWithSlash := false
-	emitSeparator := func() {
-		if !endsWithSlash {
-			io.WriteString(&b, "/")
+	suffix := c.suffix()
+	if len(suffix) > 0 {
+		fmt.Fprintf(&b, "/%s", suffix)
+	}
+	if len(c.bass.Name) > 0 {
+		if len(suffix) == 0 {
+			io.WriteString(&b, "/M")
 		}
-		endsWithSlash = false
+		fmt.Fprintf(&b, "/%s", c.bass.String())
+		return b.String()
 	}
+	switch c.inversion {
+	case Inversion1:
+		io.WriteString(&b, "/1")
+	case Inversion2:
+		io.WriteString(&b, "/2")
+	case Inversion3:
+		io.WriteString(&b, "/3")
+	}
+	return b.String()
+}
 
-	if c.quality != Major {
+// suffix returns the quality and interval part of the notation, e.g. m7.
+func (c Chord) suffix() string {
+	if len(c.extension) > 0 {
+		return c.extension
+	}
+	switch c.interval {
+	case Seventh:
 		switch c.quality {
-		case Minor:
-			emitSeparator()
-			io.WriteString(&b, "m")
 		case Major:
-			emitSeparator()
-			io.WriteString(&b, "M")
-		case Diminished:
-			emitSeparator()
-			io.WriteString(&b, "dim")
+			return "maj7"
+		case Minor:
+			return "m7"
 		case Augmented:
-			emitSeparator()
-			io.WriteString(&b, "aug") // OR +  TODO
-		case Suspended2:
-			emitSeparator()
-			io.WriteString(&b, "sus2")
-		case Suspended4:
-			emitSeparator()
-			io.WriteString(&b, "sus4")
+			return "aug7"
+		case Diminished:
+			return "dim7"
+		case Septiem:
+			return "7"
 		}
-
-	}
-	if c.interval != Triad {
-		switch c.interval {
-		case Sixth:
-			emitSeparator()
-			io.WriteString(&b, "6")
-		case Seventh:
-			emitSeparator()
-			io.WriteString(&b, "7")
+	case Sixth:
+		if c.quality == Minor {
+			return "m6"
 		}
+		return "6"
+	}
+	switch c.quality {
+	case Minor:
+		return "m"
+	case Diminished:
+		return "dim"
+	case Augmented:
+		return "aug"
+	case Suspended2:
+		return "sus2"
+	case Suspended4:
+		return "sus4"
 	}
-	if c.inversion != Ground {
-		switch c.inversion {
-		case Inversion1:
-			emitSeparator()
-			io.WriteString(&b, "1")
-		case Inversion2:
-			emitSeparator()
-			io.WriteString(&b, "2")
-		case Inversion3:
-			emitSeparator()
-			io.WriteString(&b, "3")
+	return ""
+}
+
+// withSuffix returns a chord with the quality and interval of a notation, e.g. m7.
+func (c Chord) withSuffix(s string) (Chord, error) {
+	switch s {
+	case "", "maj", "M":
+	case "maj7", "M7":
+		c.interval = Seventh
+	case "m", "min":
+		c.quality = Minor
+	case "m7", "min7":
+		c.quality = Minor
+		c.interval = Seventh
+	case "m6":
+		c.quality = Minor
+		c.interval = Sixth
+	case "6", "maj6", "M6":
+		c.interval = Sixth
+	case "dim", "o":
+		c.quality = Diminished
+	case "dim7", "o7":
+		c.quality = Diminished
+		c.interval = Seventh
+	case "aug", "+":
+		c.quality = Augmented
+	case "aug7", "+7":
+		c.quality = Augmented
+		c.interval = Seventh
+	case "sus", "sus4":
+		c.quality = Suspended4
+	case "sus2":
+		c.quality = Suspended2
+	case "7":
+		c.quality = Septiem
+		c.interval = Seventh
+	case "1":
+		c.inversion = Inversion1
+	case "2":
+		c.inversion = Inversion2
+	case "3":
+		c.inversion = Inversion3
+	default:
+		name := s
+		if alias, ok := chordAliases[s]; ok {
+			name = alias
+		}
+		ext, ok := extendedChords[name]
+		if !ok {
+			return c, errors.New("unexpected quality:" + s)
 		}
+		c.extension = name
+		c.quality = ext.quality
+		c.interval = ext.interval
 	}
-	return b.String()
+	return c, nil
 }
 
 // Storex implements Storable
@@ -139,53 +244,80 @@ func (c Chord) Notes() []Note {
 		c.start.IsPedalUpDown() {
 		return notes
 	}
-	var semitones []int
-	if c.interval == Triad {
-		if c.quality == Augmented {
-			semitones = []int{4, 8}
-		} else if c.quality == Diminished {
-			semitones = []int{3, 6}
-		} else if c.quality == Major {
-			semitones = []int{4, 7}
-		} else if c.quality == Minor {
-			semitones = []int{3, 7}
-		} else if c.quality == Suspended2 {
-			semitones = []int{2, 7}
-		} else if c.quality == Suspended4 {
-			semitones = []int{5, 7}
-		}
-	}
-	if c.interval == Seventh {
-		if c.quality == Augmented {
-			semitones = []int{4, 8, 10}
-		} else if c.quality == Diminished {
-			semitones = []int{3, 6, 9}
-		} else if c.quality == Minor {
-			semitones = []int{3, 7, 10}
-		} else if c.quality == Major {
-			semitones = []int{4, 7, 11}
-		} else if c.quality == Septiem {
-			semitones = []int{4, 7, 10}
-		}
-	}
-	for _, each := range semitones {
+	for _, each := range c.semitones() {
 		next := c.start.Pitched(each)
 		notes = append(notes, next)
 	}
 	// apply inversion
-	if c.interval == Triad {
-		if c.inversion == Inversion1 {
+	rotations := 0
+	switch c.inversion {
+	case Inversion1:
+		rotations = 1
+	case Inversion2:
+		rotations = 2
+	case Inversion3:
+		rotations = 3
+	}
+	if rotations < len(notes) {
+		for i := 0; i < rotations; i++ {
 			notes = append(notes, notes[0].Octaved(1))[1:]
 		}
-		if c.inversion == Inversion2 {
-			notes = append(notes, notes[0].Octaved(1))[1:]
-			notes = append(notes, notes[0].Octaved(1))[1:]
+	}
+	// bass below the lowest note
+	if len(c.bass.Name) > 0 {
+		up := ((c.bass.MIDI()-c.start.MIDI())%12 + 12) % 12
+		bass := c.start.Pitched(up)
+		for bass.MIDI() >= notes[0].MIDI() {
+			bass = bass.Octaved(-1)
 		}
-		// TODO handle inversion 3
+		notes = append([]Note{bass}, notes...)
 	}
 	return notes
 }
 
+// semitones returns the tones above the root.
+func (c Chord) semitones() []int {
+	if ext, ok := extendedChords[c.extension]; ok {
+		return ext.semitones
+	}
+	switch c.interval {
+	case Triad:
+		switch c.quality {
+		case Augmented:
+			return []int{4, 8}
+		case Diminished:
+			return []int{3, 6}
+		case Major:
+			return []int{4, 7}
+		case Minor:
+			return []int{3, 7}
+		case Suspended2:
+			return []int{2, 7}
+		case Suspended4:
+			return []int{5, 7}
+		}
+	case Seventh:
+		switch c.quality {
+		case Augmented:
+			return []int{4, 8, 10}
+		case Diminished:
+			return []int{3, 6, 9}
+		case Minor:
+			return []int{3, 7, 10}
+		case Major:
+			return []int{4, 7, 11}
+		case Septiem:
+			return []int{4, 7, 10}
+		}
+	case Sixth:
+		if c.quality == Minor {
+			return []int{3, 7, 9}
+		}
+		return []int{4, 7, 9}
+	}
+	return nil
+}
+
 var chordRegexp = regexp.MustCompile("([Mmdijaugo+su]*)([2467]?)")
 
 // C/D7/2 = C dominant 7, 2nd inversion
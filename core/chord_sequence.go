@@ -2,6 +2,7 @@ package core
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -20,6 +21,9 @@ func MustParseChordSequence(s string) ChordSequence {
 
 func ParseChordSequence(input string) (ChordSequence, error) {
 	p := ChordSequence{}
+	if len(input) > maxParseInputLength {
+		return p, errInputTooLong
+	}
 	// hack to keep scanning simple, TODO
 	splitable := strings.Replace(input, groupOpen, " "+groupOpen+" ", -1)
 	splitable = strings.Replace(splitable, groupClose, " "+groupClose+" ", -1)
@@ -28,9 +32,15 @@ func ParseChordSequence(input string) (ChordSequence, error) {
 	var group []Chord
 	for _, each := range parts {
 		if groupOpen == each {
+			if ingroup {
+				return p, errors.New("unexpected (")
+			}
 			ingroup = true
 			group = []Chord{}
 		} else if groupClose == each {
+			if !ingroup {
+				return p, errors.New("unexpected )")
+			}
 			ingroup = false
 			p.Chords = append(p.Chords, group)
 		} else {
@@ -45,6 +55,9 @@ func ParseChordSequence(input string) (ChordSequence, error) {
 			}
 		}
 	}
+	if ingroup {
+		return p, errors.New("missing )")
+	}
 	return p, nil
 }
 
@@ -99,4 +112,28 @@ func (p ChordSequence) Storex() string {
 
 func (p ChordSequence) Inspect(i Inspection) {
 	i.Properties[""] = p.S().String()
+	i.Properties["symbols"] = p.Symbols()
+}
+
+// Symbols returns the chord symbols separated by a space ; parallel chords are grouped, e.g. C (Am/C G).
+func (p ChordSequence) Symbols() string {
+	var b bytes.Buffer
+	for i, each := range p.Chords {
+		if i > 0 {
+			fmt.Fprint(&b, " ")
+		}
+		if len(each) == 1 {
+			fmt.Fprint(&b, each[0].Symbol())
+		} else {
+			fmt.Fprint(&b, "(")
+			for j, other := range each {
+				if j > 0 {
+					fmt.Fprint(&b, " ")
+				}
+				fmt.Fprint(&b, other.Symbol())
+			}
+			fmt.Fprint(&b, ")")
+		}
+	}
+	return b.String()
 }
@@ -40,3 +40,10 @@ func check(t *testing.T, err error) {
 		t.Fatal(err)
 	}
 }
+
+func TestChordSequence_Symbols(t *testing.T) {
+	par := MustParseChordSequence("A/m (E F/7) =")
+	if got, want := par.Symbols(), "Am (E F7) N.C."; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
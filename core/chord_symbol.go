@@ -0,0 +1,98 @@
+package core
+
+import (
+	"errors"
+	"strings"
+)
+
+// noChordSymbol is the conventional symbol for a rest in a lead sheet.
+const noChordSymbol = "N.C."
+
+// Symbol returns the conventional chord symbol as printed on lead sheets, e.g. Am7/G.
+// Inversions are written as slash chords with the lowest note in the bass, e.g. C/E.
+func (c Chord) Symbol() string {
+	if c.start.IsRest() {
+		return noChordSymbol
+	}
+	var b strings.Builder
+	b.WriteString(symbolNoteName(c.start))
+	switch suffix := c.suffix(); suffix {
+	case "aug":
+		b.WriteString("+")
+	case "aug7":
+		b.WriteString("+7")
+	default:
+		b.WriteString(suffix)
+	}
+	if len(c.bass.Name) > 0 {
+		b.WriteString("/")
+		b.WriteString(symbolNoteName(c.bass))
+	} else if c.inversion != Ground {
+		b.WriteString("/")
+		b.WriteString(symbolNoteName(c.Notes()[0]))
+	}
+	return b.String()
+}
+
+// symbolNoteName returns the name and accidental of a note, e.g. Bb.
+func symbolNoteName(n Note) string {
+	return n.Name + n.accidentalf(true)
+}
+
+// ParseChordSymbol returns the chord for a conventional chord symbol, e.g. Am7/G or Bb/D.
+// A bass note that is a tone of the chord is read as an inversion.
+func ParseChordSymbol(s string) (Chord, error) {
+	c := zeroChord()
+	s = strings.TrimSpace(s)
+	if s == noChordSymbol {
+		c.start = Rest4
+		return c, nil
+	}
+	root, rest, err := symbolRoot(s)
+	if err != nil {
+		return c, err
+	}
+	c.start = root
+	suffix, bassName, hasBass := strings.Cut(rest, "/")
+	switch suffix {
+	case "1", "2", "3":
+		return c, errors.New("unexpected quality:" + suffix)
+	}
+	if c, err = c.withSuffix(suffix); err != nil {
+		return c, err
+	}
+	if !hasBass {
+		return c, nil
+	}
+	bass, rest, err := symbolRoot(bassName)
+	if err != nil {
+		return c, err
+	}
+	if len(rest) > 0 {
+		return c, errors.New("unexpected bass:" + bassName)
+	}
+	notes := c.Notes()
+	for i, each := range []int{Inversion1, Inversion2, Inversion3} {
+		if i+1 < len(notes) && (notes[i+1].MIDI()-bass.MIDI())%12 == 0 {
+			c.inversion = each
+			return c, nil
+		}
+	}
+	c.bass = bass
+	return c, nil
+}
+
+// symbolRoot reads the note name with an optional accidental (# or b) and returns the remainder.
+func symbolRoot(s string) (Note, string, error) {
+	if len(s) == 0 || !strings.ContainsRune("ABCDEFG", rune(s[0])) {
+		return Rest4, s, errors.New("chord symbol must start with a note name [A-G]:" + s)
+	}
+	name, rest := s[:1], s[1:]
+	if strings.HasPrefix(rest, "#") {
+		name, rest = name+"#", rest[1:]
+	} else if strings.HasPrefix(rest, "b") {
+		name, rest = name+"_", rest[1:]
+	}
+	n, err := ParseNote(name)
+	return n, rest, err
+}
@@ -95,7 +95,106 @@ func TestParseChord_C(t *testing.T) {
 			"('(D5 G5 B5)')",
 			false,
 		},
-		// Fifth
+		// Sixth
+		{
+			"C 6",
+			args{"C/6"},
+			"('(C E G A)')",
+			false,
+		},
+		{
+			"C minor 6",
+			args{"C/m6"},
+			"('(C E_ G A)')",
+			false,
+		},
+		// Extended
+		{
+			"C 9",
+			args{"C/9"},
+			"('(C E G B_ D5)')",
+			false,
+		},
+		{
+			"C major 9",
+			args{"C/maj9"},
+			"('(C E G B D5)')",
+			false,
+		},
+		{
+			"C minor 11",
+			args{"C/m11"},
+			"('(C E_ G B_ D5 F5)')",
+			false,
+		},
+		{
+			"C 13",
+			args{"C/13"},
+			"('(C E G B_ D5 A5)')",
+			false,
+		},
+		{
+			"C add9",
+			args{"C/add9"},
+			"('(C E G D5)')",
+			false,
+		},
+		// Altered
+		{
+			"C 7b9",
+			args{"C/7b9"},
+			"('(C E G B_ D_5)')",
+			false,
+		},
+		{
+			"C 7#11",
+			args{"C/7#11"},
+			"('(C E G B_ G_5)')",
+			false,
+		},
+		{
+			"C diminished 7",
+			args{"C/dim7"},
+			"('(C E_ G_ A)')",
+			false,
+		},
+		{
+			"C half diminished",
+			args{"C/m7b5"},
+			"('(C E_ G_ B_)')",
+			false,
+		},
+		{
+			"C 7sus4",
+			args{"C/7sus4"},
+			"('(C F G B_)')",
+			false,
+		},
+		// Slash
+		{
+			"A minor 7 over G",
+			args{"A/m7/G"},
+			"('(G A C5 E5 G5)')",
+			false,
+		},
+		{
+			"C over E",
+			args{"C/M/E"},
+			"('(E3 C E G)')",
+			false,
+		},
+		{
+			"unknown quality",
+			args{"C/m7x"},
+			"('(C E G)')",
+			true,
+		},
+		{
+			"bass not a note",
+			args{"C/m/=}"},
+			"('(C E G)')",
+			true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -222,3 +321,84 @@ func TestParseChord(t *testing.T) {
 		break
 	}
 }
+
+func TestChord_StringRoundtrip(t *testing.T) {
+	for _, each := range []string{
+		"C", "C/m", "C/7", "C/m7", "C/maj7", "C/dim7", "C/6", "C/m6", "C/aug7", "C/sus2",
+		"C/9", "C/m11", "C/13", "C/add9", "C/7b9", "C/7#11", "C/m7b5", "C/69",
+		"C#/1", "E/m/2", "A/m7/G", "C/M/E", "D/7/F#",
+	} {
+		c, err := ParseChord(each)
+		if err != nil {
+			t.Fatalf("%s: %v", each, err)
+		}
+		if got, want := c.String(), each; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	}
+}
+
+func TestChord_Alias(t *testing.T) {
+	c, _ := ParseChord("C/ø")
+	if got, want := c.String(), "C/m7b5"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestChord_Symbol(t *testing.T) {
+	for _, each := range []struct {
+		chord, symbol string
+	}{
+		{"C", "C"},
+		{"a/m7/g", "Am7/G"},
+		{"c/1", "C/E"},
+		{"e_/maj7", "Ebmaj7"},
+		{"f#/m7b5", "F#m7b5"},
+		{"g/aug", "G+"},
+		{"b/dim7", "Bdim7"},
+		{"d/sus4/2", "Dsus4/A"},
+		{"=", "N.C."},
+	} {
+		c := MustParseChord(each.chord)
+		if got, want := c.Symbol(), each.symbol; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+		back, err := ParseChordSymbol(each.symbol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := back.Symbol(), each.symbol; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+		if got, want := MustParseChord(back.String()).Storex(), back.Storex(); got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	}
+}
+
+func TestParseChordSymbol_Inversion(t *testing.T) {
+	for _, each := range []struct {
+		symbol, chord string
+	}{
+		{"C/E", "C/1"},
+		{"Bb/F", "B_/2"},
+		{"Am7/G", "A/m7/3"},
+		{"C/D", "C/M/D"},
+	} {
+		c, err := ParseChordSymbol(each.symbol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := c.String(), each.chord; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	}
+}
+
+func TestParseChordSymbol_Invalid(t *testing.T) {
+	for _, each := range []string{"", "H", "Cx", "C/2", "C/Ex"} {
+		if _, err := ParseChordSymbol(each); err == nil {
+			t.Errorf("error expected for %q", each)
+		}
+	}
+}
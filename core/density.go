@@ -0,0 +1,51 @@
+package core
+
+import "sync"
+
+// NeutralDensity is the density for which operators that opt in behave as if no density was set.
+const NeutralDensity = 0.5
+
+var density = struct {
+	mutex sync.RWMutex
+	value HasValue
+}{value: On(NeutralDensity)}
+
+// SetDensity changes the global density ; its value is taken each time an operator needs it
+// such that it can be controlled by e.g. a knob.
+func SetDensity(v HasValue) {
+	density.mutex.Lock()
+	defer density.mutex.Unlock()
+	density.value = v
+}
+
+// Density returns the current global density in [0..1].
+// Values above 1 are taken as a MIDI control value in [0..127], e.g. from a knob.
+func Density() float64 {
+	density.mutex.RLock()
+	v := density.value
+	density.mutex.RUnlock()
+	f := float64(Float(v))
+	if f > 1 {
+		f = f / 127.0
+	}
+	if f < 0 {
+		return 0
+	}
+	if f > 1 {
+		return 1
+	}
+	return f
+}
+
+// DensityScaled returns a value in [0..max] that is scaled by the global density.
+// A density below neutral moves the value towards 0, above neutral towards max.
+func DensityScaled(value, max float64) float64 {
+	d := Density()
+	if d == NeutralDensity {
+		return value
+	}
+	if d < NeutralDensity {
+		return value * d / NeutralDensity
+	}
+	return value + (max-value)*(d-NeutralDensity)/(1-NeutralDensity)
+}
@@ -0,0 +1,25 @@
+package core
+
+import "testing"
+
+func TestDensityScaled(t *testing.T) {
+	defer SetDensity(On(NeutralDensity))
+	for _, each := range []struct {
+		density interface{}
+		value   float64
+		want    float64
+	}{
+		{NeutralDensity, 0.8, 0.8},
+		{0.0, 0.8, 0.0},
+		{0.25, 0.8, 0.4},
+		{1.0, 0.8, 1.0},
+		{0.75, 0.5, 0.75},
+		{127, 0.5, 1.0}, // as control value
+		{-1, 0.5, 0.0},
+	} {
+		SetDensity(On(each.density))
+		if got, want := DensityScaled(each.value, 1), each.want; got != want {
+			t.Errorf("density %v: got [%v:%T] want [%v:%T]", each.density, got, got, want, want)
+		}
+	}
+}
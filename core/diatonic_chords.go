@@ -0,0 +1,96 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emicklei/melrose/notify"
+)
+
+// DiatonicChords are the seven chords built on the degrees of a scale, either triads or seventh chords.
+type DiatonicChords struct {
+	scale    HasValue
+	sevenths bool
+}
+
+func NewDiatonicChords(scale HasValue, sevenths bool) DiatonicChords {
+	return DiatonicChords{scale: scale, sevenths: sevenths}
+}
+
+// C returns the chords for each degree of the scale.
+func (d DiatonicChords) C() []Chord {
+	sc, ok := ValueOf(d.scale).(Scale)
+	if !ok {
+		notify.Warnf("diatonic chords require a scale, type: %T", ValueOf(d.scale))
+		return noChords
+	}
+	return d.chordsOf(sc)
+}
+
+func (d DiatonicChords) chordsOf(sc Scale) []Chord {
+	chords := []Chord{}
+	for i := 1; i <= 7; i++ {
+		chords = append(chords, sc.DiatonicChordAt(i, d.sevenths))
+	}
+	return chords
+}
+
+// At is part of Indexable ; one-based. Returns an empty sequence if the index is not a degree.
+func (d DiatonicChords) At(i int) Sequenceable {
+	chords := d.C()
+	if i < 1 || i > len(chords) {
+		return EmptySequence
+	}
+	return chords[i-1]
+}
+
+// SWith is part of ContextSequenceable ; the chords are in the active key, if any.
+func (d DiatonicChords) SWith(ctx Context) Sequence {
+	key, ok := KeyOf(ctx)
+	if !ok {
+		return d.S()
+	}
+	sc, ok := ValueOf(d.scale).(Scale)
+	if !ok {
+		return d.S()
+	}
+	return joinedChords(d.chordsOf(sc.InKey(key)))
+}
+
+// S is part of Sequenceable
+func (d DiatonicChords) S() Sequence {
+	return joinedChords(d.C())
+}
+
+func joinedChords(chords []Chord) Sequence {
+	j := EmptySequence
+	for _, each := range chords {
+		j = j.SequenceJoin(each.S())
+	}
+	return j
+}
+
+// Inspect is part of Inspectable
+func (d DiatonicChords) Inspect(i Inspection) {
+	list := []string{}
+	for _, each := range d.C() {
+		list = append(list, each.Symbol())
+	}
+	i.Properties["symbols"] = strings.Join(list, " ")
+}
+
+// Storex is part of Storable
+func (d DiatonicChords) Storex() string {
+	if d.sevenths {
+		return fmt.Sprintf("sevenths(%s)", Storex(d.scale))
+	}
+	return fmt.Sprintf("triads(%s)", Storex(d.scale))
+}
+
+// Replaced is part of Replaceable
+func (d DiatonicChords) Replaced(from, to Sequenceable) Sequenceable {
+	if IsIdenticalTo(from, d) {
+		return to
+	}
+	return d
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/emicklei/melrose/notify"
+)
+
+// maxExpectDifferences is the number of different groups reported by Expect.
+const maxExpectDifferences = 4
+
+// Expect compares the notes of its target with the notes of an expected sequence when evaluated or played.
+// Notes are equal if they have the same pitch, fraction and velocity ; enharmonic spellings such as F# and G_ are equal.
+type Expect struct {
+	Target   Sequenceable
+	Notation string // of the expected sequence
+}
+
+// Evaluate is part of Evaluatable ; it returns an error with the differences, if any.
+func (e Expect) Evaluate(ctx Context) error {
+	return e.Check()
+}
+
+// S is part of Sequenceable ; it reports the differences, if any, as an error notification.
+func (e Expect) S() Sequence {
+	if err := e.Check(); err != nil {
+		notify.Errorf("%v", err)
+	}
+	return EmptySequence
+}
+
+// Check returns an error that describes the differences between the actual and expected notes.
+func (e Expect) Check() error {
+	want, err := ParseSequence(e.Notation)
+	if err != nil {
+		return fmt.Errorf("invalid expected sequence: %v", err)
+	}
+	got := e.Target.S()
+	var b bytes.Buffer
+	differences := 0
+	for i := 0; i < len(got.Notes) || i < len(want.Notes); i++ {
+		var g, w []Note
+		if i < len(got.Notes) {
+			g = got.Notes[i]
+		}
+		if i < len(want.Notes) {
+			w = want.Notes[i]
+		}
+		if sameGroup(g, w) {
+			continue
+		}
+		differences++
+		if differences <= maxExpectDifferences {
+			fmt.Fprintf(&b, "\nat %d: expected %s got %s", i+1, groupString(w), groupString(g))
+		}
+	}
+	if differences == 0 {
+		return nil
+	}
+	if differences > maxExpectDifferences {
+		fmt.Fprintf(&b, "\n... and %d more", differences-maxExpectDifferences)
+	}
+	return fmt.Errorf("expected %d notes or groups, got %d, with %d difference(s):%s", len(want.Notes), len(got.Notes), differences, b.String())
+}
+
+// sameGroup returns whether both groups have the same notes, in any order.
+func sameGroup(g, w []Note) bool {
+	if len(g) != len(w) {
+		return false
+	}
+	gk, wk := noteKeys(g), noteKeys(w)
+	for i := range gk {
+		if gk[i] != wk[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func noteKeys(group []Note) []string {
+	keys := []string{}
+	for _, each := range group {
+		switch {
+		case each.IsHearable():
+			keys = append(keys, fmt.Sprintf("%d/%.4f/%d", each.MIDI(), each.DurationFactor(), each.Velocity))
+		case each.IsRest():
+			keys = append(keys, fmt.Sprintf("=/%.4f", each.DurationFactor()))
+		default:
+			keys = append(keys, each.String())
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func groupString(group []Note) string {
+	if len(group) == 0 {
+		return "nothing"
+	}
+	return Sequence{Notes: [][]Note{group}}.String()
+}
+
+// Storex is part of Storable
+func (e Expect) Storex() string {
+	return fmt.Sprintf("expect(%s,'%s')", Storex(e.Target), e.Notation)
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpect_Equal(t *testing.T) {
+	e := Expect{Target: MustParseSequence("c (e g) f# 8="), Notation: "C (G E) G_ 8="}
+	if err := e.Check(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExpect_Differences(t *testing.T) {
+	e := Expect{Target: MustParseSequence("c d f 8g"), Notation: "C D E G A"}
+	err := e.Check()
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	for _, want := range []string{
+		"expected 5 notes or groups, got 4, with 3 difference(s)",
+		"at 3: expected E got F",
+		"at 4: expected G got 8G",
+		"at 5: expected A got nothing",
+	} {
+		if got := err.Error(); !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+}
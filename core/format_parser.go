@@ -9,8 +9,22 @@ import (
 	"text/scanner"
 )
 
+// maxParseInputLength protects the parsers against pathological input.
+const maxParseInputLength = 1 << 20
+
+// maxVelocityLength is the length of the longest dynamic, e.g. +++++
+const maxVelocityLength = 5
+
+const (
+	minOctave = 0
+	maxOctave = 10
+)
+
+var errInputTooLong = fmt.Errorf("input too long, must be less than %d characters", maxParseInputLength)
+
 type formatParser struct {
 	scanner *scanner.Scanner
+	size    int
 }
 
 func newFormatParser(src string) *formatParser {
@@ -18,10 +32,13 @@ func newFormatParser(src string) *formatParser {
 	s.Init(strings.NewReader(src))
 	s.Whitespace ^= 1 << ' '
 	s.Mode = scanner.ScanChars | scanner.ScanInts
-	return &formatParser{scanner: s}
+	return &formatParser{scanner: s, size: len(src)}
 }
 
 func (f *formatParser) parseNote() (Note, error) {
+	if f.size > maxParseInputLength {
+		return Rest4, errInputTooLong
+	}
 	var err error
 	// capture scan errors
 	f.scanner.Error = func(s *scanner.Scanner, m string) {
@@ -44,6 +61,9 @@ func (f *formatParser) parseNote() (Note, error) {
 }
 
 func (f *formatParser) parseTabNote() (TabNote, error) {
+	if f.size > maxParseInputLength {
+		return TabNote{}, errInputTooLong
+	}
 	var err error
 	// capture scan errors
 	f.scanner.Error = func(s *scanner.Scanner, m string) {
@@ -66,6 +86,9 @@ func (f *formatParser) parseTabNote() (TabNote, error) {
 }
 
 func (f *formatParser) parseSequence() (Sequence, error) {
+	if f.size > maxParseInputLength {
+		return EmptySequence, errInputTooLong
+	}
 	var err error
 	// capture scan errors
 	f.scanner.Error = func(s *scanner.Scanner, m string) {
@@ -84,11 +107,19 @@ func (f *formatParser) parseSequence() (Sequence, error) {
 			return EmptySequence, err
 		}
 	}
-	stm.endNote()
+	if err := stm.endNote(); err != nil {
+		return EmptySequence, err
+	}
+	if stm.ingroup {
+		return EmptySequence, errors.New("missing )")
+	}
 	return stm.sequence()
 }
 
 func (f *formatParser) parseChordProgression(s Scale) ([]Chord, error) {
+	if f.size > maxParseInputLength {
+		return []Chord{}, errInputTooLong
+	}
 	var err error
 	// capture scan errors
 	f.scanner.Error = func(s *scanner.Scanner, m string) {
@@ -116,6 +147,9 @@ func (f *formatParser) parseChordProgression(s Scale) ([]Chord, error) {
 }
 
 func (f *formatParser) parseChord() (Chord, error) {
+	if f.size > maxParseInputLength {
+		return zeroChord(), errInputTooLong
+	}
 	var err error
 	// capture scan errors
 	f.scanner.Error = func(s *scanner.Scanner, m string) {
@@ -151,6 +185,8 @@ type noteSTM struct {
 	accidental int
 	octave     int
 	velocity   string
+	exact      int // explicit velocity, e.g. C:v96 ; 0 if not set
+	exactState int // 1 after the colon, 2 after the v
 	tied       []Note
 }
 
@@ -168,14 +204,11 @@ type chordprogressionSTM struct {
 }
 
 type chordSTM struct {
-	note      *noteSTM
-	quality   int
-	interval  int
-	inversion int
-	// substate
-	wantsNote      bool
-	wantsQuality   bool
-	wantsInversion bool
+	note *noteSTM
+	// text of the parts separated by a slash
+	suffix string // quality and interval, e.g. m7
+	last   string // inversion or bass note
+	part   int    // 0 = note, 1 = suffix, 2 = last
 }
 
 func newChordSTM() *chordSTM {
@@ -186,10 +219,9 @@ func newChordSTM() *chordSTM {
 
 func (c *chordSTM) reset() {
 	c.note = nil
-	c.quality = Major
-	c.interval = Triad
-	c.wantsNote = true
-	c.wantsInversion = false
+	c.suffix = ""
+	c.last = ""
+	c.part = 0
 }
 
 func (c *chordSTM) accept(scan *scanner.Scanner) error {
@@ -202,84 +234,25 @@ func (c *chordSTM) accept(scan *scanner.Scanner) error {
 		if c.note == nil {
 			return errors.New("expected note")
 		}
-		if c.wantsNote {
-			c.wantsNote = false
-			c.wantsQuality = true
-			// match on full tokens
-			scan.Mode = scanner.ScanIdents
-			return nil
-		}
-		if c.wantsQuality {
-			c.wantsQuality = false
-			// match on integer tokens
-			scan.Mode = scanner.ScanInts
-			c.wantsInversion = true
+		if c.part == 2 {
+			return errors.New("unexpected /")
 		}
+		c.part++
+		// collect the text of the part, e.g. 7#11 is scanned as 4 tokens
+		scan.Mode = scanner.ScanIdents
 		return nil
 	}
 	// act on state
-	if c.wantsNote {
+	switch c.part {
+	case 0:
 		if c.note == nil {
 			c.note = newNoteSTM()
 		}
 		return c.note.accept(lit)
-	}
-	if c.wantsQuality {
-		switch lit {
-		case "maj", "M":
-		case "maj7", "M7":
-			c.interval = Seventh
-		case "m":
-			c.quality = Minor
-		case "m7":
-			c.quality = Minor
-			c.interval = Seventh
-		case "dim":
-			c.quality = Diminished
-		case "o":
-			c.quality = Diminished
-		case "aug":
-			c.quality = Augmented
-		case "aug7":
-			c.quality = Augmented
-			c.interval = Seventh
-		case "+":
-			c.quality = Augmented
-		case "sus2":
-			c.quality = Suspended2
-		case "sus4":
-			c.quality = Suspended4
-		case "7":
-			c.quality = Septiem
-			c.interval = Seventh
-		case "6":
-			c.interval = Sixth
-		case "3":
-			c.inversion = Inversion3
-		case "2":
-			c.inversion = Inversion2
-		case "1":
-			c.inversion = Inversion1
-		default:
-			return errors.New("unexpected quality:" + lit)
-		}
-	}
-	if c.wantsInversion {
-		if c.inversion == 0 {
-			c.inversion = Ground
-		} else {
-			return errors.New("unexpected inversion:" + lit)
-		}
-		switch lit {
-		case "1":
-			c.inversion = Inversion1
-		case "2":
-			c.inversion = Inversion2
-		case "3":
-			c.inversion = Inversion3
-		default:
-			return errors.New("unexpected inversion:" + lit)
-		}
+	case 1:
+		c.suffix += lit
+	default:
+		c.last += lit
 	}
 	return nil
 }
@@ -292,12 +265,30 @@ func (c *chordSTM) chord() (Chord, error) {
 	if err != nil {
 		return zeroChord(), err
 	}
-	return Chord{
+	chord, err := Chord{
 		start:     n,
-		quality:   c.quality,
-		interval:  c.interval,
-		inversion: c.inversion,
-	}, nil
+		quality:   Major,
+		interval:  Triad,
+		inversion: Ground,
+	}.withSuffix(c.suffix)
+	if err != nil {
+		return zeroChord(), err
+	}
+	switch c.last {
+	case "":
+	case "1", "2", "3":
+		if chord.inversion != Ground {
+			return zeroChord(), errors.New("unexpected inversion:" + c.last)
+		}
+		chord, _ = chord.withSuffix(c.last)
+	default:
+		bass, err := ParseNote(c.last)
+		if err != nil || bass.IsRest() || len(bass.Name) != 1 || !strings.Contains("ABCDEFG", bass.Name) {
+			return zeroChord(), errors.New("unexpected inversion or bass note:" + c.last)
+		}
+		chord.bass = bass
+	}
+	return chord, nil
 }
 
 var romanChordRegex = regexp.MustCompile("([iIvV]{1,3})([Mmaj]{0,3})([dim]{0,3})(7?)")
@@ -334,6 +325,9 @@ func (s *chordprogressionSTM) accept(lit string) error {
 
 	// velocity
 	if strings.ContainsAny(lit, "-o+") {
+		if len(s.velocity)+len(lit) > maxVelocityLength {
+			return fmt.Errorf("invalid dynamic, unexpected:%s", s.velocity+lit)
+		}
 		s.velocity += lit
 		return nil
 	}
@@ -426,6 +420,8 @@ func (s *noteSTM) reset() {
 	s.name = ""
 	s.octave = 4
 	s.velocity = ""
+	s.exact = 0
+	s.exactState = 0
 }
 
 func (s *noteSTM) accept(lit string) error {
@@ -480,6 +476,9 @@ func (s *noteSTM) accept(lit string) error {
 		return nil
 	} else {
 		// name is set
+		if s.exactState > 0 || lit == ":" {
+			return s.acceptExactVelocity(lit)
+		}
 		if strings.ContainsAny(lit, allowedNoteNames) {
 			return fmt.Errorf("name already known, got:%s", lit)
 		}
@@ -500,6 +499,9 @@ func (s *noteSTM) accept(lit string) error {
 		}
 		// velocity
 		if strings.ContainsAny(lit, "-o+") {
+			if len(s.velocity)+len(lit) > maxVelocityLength {
+				return fmt.Errorf("invalid dynamic, unexpected:%s", s.velocity+lit)
+			}
 			s.velocity += lit
 			return nil
 		}
@@ -517,12 +519,40 @@ func (s *noteSTM) accept(lit string) error {
 		if i, err := strconv.Atoi(lit); err != nil {
 			return fmt.Errorf("invalid octave, unexpected:%s", lit)
 		} else {
+			if i < minOctave || i > maxOctave {
+				return fmt.Errorf("invalid octave, must be in [%d..%d], got:%d", minOctave, maxOctave, i)
+			}
 			s.octave = i
 		}
 	}
 	return nil
 }
 
+// acceptExactVelocity reads the explicit velocity of a note, e.g. :v96
+func (s *noteSTM) acceptExactVelocity(lit string) error {
+	switch s.exactState {
+	case 0:
+		if s.exact != 0 || len(s.velocity) > 0 {
+			return fmt.Errorf("velocity already known, unexpected:%s", lit)
+		}
+		s.exactState = 1
+		return nil
+	case 1:
+		if lit != "v" {
+			return fmt.Errorf("invalid velocity, expected v, unexpected:%s", lit)
+		}
+		s.exactState = 2
+		return nil
+	}
+	v, err := strconv.Atoi(lit)
+	if err != nil || v < 1 || v > 127 {
+		return fmt.Errorf("invalid velocity, must be in [1..127], got:%s", lit)
+	}
+	s.exact = v
+	s.exactState = 0
+	return nil
+}
+
 func (s *noteSTM) currentNote() (Note, error) {
 	// pedal
 	switch s.name {
@@ -533,8 +563,17 @@ func (s *noteSTM) currentNote() (Note, error) {
 	case ">":
 		return PedalDown, nil
 	}
+	if s.exactState > 0 {
+		return Rest4, errors.New("missing velocity after :v")
+	}
 	vel := Normal
+	if s.exact > 0 {
+		vel = s.exact
+	}
 	if len(s.velocity) > 0 {
+		if s.exact > 0 {
+			return Rest4, fmt.Errorf("velocity already known, unexpected:%s", s.velocity)
+		}
 		vel = ParseVelocity(s.velocity)
 		if vel == -1 {
 			return Rest4, fmt.Errorf("invalid dynamic, unexpected:%s", s.velocity)
@@ -586,7 +625,7 @@ func (s *sequenceSTM) accept(lit string) error {
 		s.ingroup = true
 	case ")" == lit:
 		if !s.ingroup {
-			return fmt.Errorf("unexpected (")
+			return fmt.Errorf("unexpected )")
 		}
 		if err := s.endNote(); err != nil {
 			return err
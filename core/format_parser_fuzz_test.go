@@ -0,0 +1,52 @@
+package core
+
+import "testing"
+
+var fuzzSeeds = []string{
+	"c", "8.c#5++", "(c e g)", "c (d e f) a =", "C/m", "g/M/2", "c~c", "^", "(c d", "c d)", "((c))", "♯c", "c♭", "32=", "I IV V7", "",
+}
+
+func FuzzParseNote(f *testing.F) {
+	for _, each := range fuzzSeeds {
+		f.Add(each)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		ParseNote(s)
+	})
+}
+
+func FuzzParseSequence(f *testing.F) {
+	for _, each := range fuzzSeeds {
+		f.Add(each)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		ParseSequence(s)
+	})
+}
+
+func FuzzParseChord(f *testing.F) {
+	for _, each := range fuzzSeeds {
+		f.Add(each)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		ParseChord(s)
+	})
+}
+
+func FuzzParseChordSequence(f *testing.F) {
+	for _, each := range fuzzSeeds {
+		f.Add(each)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		ParseChordSequence(s)
+	})
+}
+
+func FuzzParseChordProgression(f *testing.F) {
+	for _, each := range fuzzSeeds {
+		f.Add("c", each)
+	}
+	f.Fuzz(func(t *testing.T, scale, s string) {
+		ParseChordProgression(scale, s)
+	})
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -217,3 +218,26 @@ func Test_formatParser_ParseChord(t *testing.T) {
 		}
 	}
 }
+
+func TestParse_Hardened(t *testing.T) {
+	for _, each := range []string{
+		"(c d",
+		"c d)",
+		"((c) d)",
+		"c+++++++",
+		"c99999",
+		strings.Repeat("c ", maxParseInputLength),
+	} {
+		if _, err := ParseSequence(each); err == nil {
+			t.Errorf("error expected for %.20q", each)
+		}
+	}
+	for _, each := range []string{"(c/m", "c/m)", "((c) d)"} {
+		if _, err := ParseChordSequence(each); err == nil {
+			t.Errorf("error expected for %q", each)
+		}
+	}
+	if _, err := ParseChordProgression("c", "I+++++++"); err == nil {
+		t.Error("error expected")
+	}
+}
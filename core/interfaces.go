@@ -23,6 +23,11 @@ type Indexable interface {
 	At(i int) Sequenceable
 }
 
+// HasOnsets is for objects that know the unquantized start positions, in whole notes, of their notes.
+type HasOnsets interface {
+	Onsets() []float64
+}
+
 type Nextable interface {
 	Next() interface{}
 }
@@ -66,8 +71,12 @@ type LoopController interface {
 	SetBIAB(biab int)
 	BIAB() int
 
+	SetTranspose(semitones int)
+	Transpose() int
+
 	BeatsAndBars() (int64, int64)
 	Plan(bars int64, seq Sequenceable)
+	Schedule(beats int64, action BeatAction)
 
 	SettingNotifier(handler func(control LoopController))
 }
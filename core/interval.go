@@ -2,6 +2,8 @@ package core
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 )
 
 const (
@@ -11,12 +13,19 @@ const (
 	OnceFromToFrom
 	// RepeatFromTo "repeat"
 	RepeatFromTo
-	// RepeatFromToFrom "repeat-two-way"
+	// RepeatFromToFrom "repeat-two-way" or "ping-pong"
 	RepeatFromToFrom
+	// RandomFromTo "random"
+	RandomFromTo
+	// GeometricFromTo "geometric"
+	GeometricFromTo
 )
 
 // Interval is a HasValue that has a Value between [from..to] and increments with [by].
-// If the end of the interval is reached then the Value is set to [from].
+// The strategy decides what happens if the end of the interval is reached:
+// "repeat" sets the Value to [from], "once" keeps the Value at [to],
+// "two-way" goes back down to [from] and stays there, "repeat-two-way" (or "ping-pong") keeps going up and down.
+// With "random", each Value is a random step in the interval ; with "geometric", [by] is a factor instead of an increment.
 // The fields of an Interval are also HasValue.
 type Interval struct {
 	from     HasValue
@@ -24,32 +33,76 @@ type Interval struct {
 	by       HasValue
 	strategy intervalStrategy
 	value    int
+	down     bool // for two-way strategies
 }
 
 func (i *Interval) Value() interface{} {
 	return i.value
 }
 
-// Next returns and increases its value with [by].
+// Next returns and changes its value using [by] and its strategy.
 func (i *Interval) Next() interface{} {
-	by := Int(i.by)
-	next := i.value + by
-	if by < 0 {
-		if next < Int(i.from) {
-			i.value = Int(i.to)
-			return i.value
+	from, to, by := Int(i.from), Int(i.to), Int(i.by)
+	switch i.strategy.id() {
+	case OnceFromTo:
+		if next := i.value + by; next >= from && next <= to {
+			i.value = next
 		}
-	}
-	if by > 0 {
-		if next > Int(i.to) {
-			i.value = Int(i.from)
-			return i.value
+	case OnceFromToFrom, RepeatFromToFrom:
+		i.value = i.nextTwoWay(from, to, abs(by), i.strategy.id() == RepeatFromToFrom)
+	case RandomFromTo:
+		steps := 0
+		if by != 0 {
+			steps = (to - from) / abs(by)
+		}
+		i.value = from + int(RandomFloat64()*float64(steps+1))*abs(by)
+	case GeometricFromTo:
+		next := i.value * by
+		if next > to || next <= i.value {
+			next = from
+		}
+		i.value = next
+	default: // RepeatFromTo
+		next := i.value + by
+		if by < 0 && next < from {
+			next = to
 		}
+		if by > 0 && next > to {
+			next = from
+		}
+		i.value = next
 	}
-	i.value = next
 	return i.value
 }
 
+// nextTwoWay returns the next value going up to [to] and then down to [from] ; again if repeating.
+func (i *Interval) nextTwoWay(from, to, by int, repeat bool) int {
+	if by == 0 || from == to {
+		return i.value
+	}
+	if !i.down {
+		if next := i.value + by; next <= to {
+			return next
+		}
+		i.down = true
+	}
+	if next := i.value - by; next >= from {
+		return next
+	}
+	if !repeat {
+		return i.value
+	}
+	i.down = false
+	return i.value + by
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
 // NewInterval creates new Interval. The initial Value is set to [from]. Specify the repeat strategy.
 func NewInterval(from, to, by HasValue, strategy int) *Interval {
 	start := Int(from)
@@ -58,9 +111,6 @@ func NewInterval(from, to, by HasValue, strategy int) *Interval {
 
 // Storex is part of Storable.
 func (i Interval) Storex() string {
-	if i.strategy.id() == OnceFromTo {
-		return fmt.Sprintf("interval(%v,%v,%v)", Storex(i.from), Storex(i.to), Storex(i.by))
-	}
 	name := intervalStrategyName(i.strategy.id())
 	return fmt.Sprintf("interval(%s,%s,%s,'%s')", Storex(i.from), Storex(i.to), Storex(i.by), name)
 }
@@ -71,13 +121,22 @@ func (i Interval) Inspect(n Inspection) {
 	n.Properties["length"] = (Int(i.to)-Int(i.from))/Int(i.by) + 1
 }
 
-// ParseIntervalStrategy return the non-exposed strategy based on the name. If unknown then return OnceFromTo ("once").
-func ParseIntervalStrategy(s string) intervalStrategy {
+// ParseIntervalStrategy returns the strategy, such as RepeatFromTo, by its name.
+func ParseIntervalStrategy(s string) (int, error) {
+	if s == "ping-pong" {
+		return RepeatFromToFrom, nil
+	}
 	if is, ok := intervalStrategies[s]; ok {
-		return is
+		return is.id(), nil
 	}
-	return strategyOnceFromTo{}
+	names := []string{"ping-pong"}
+	for each := range intervalStrategies {
+		names = append(names, each)
+	}
+	sort.Strings(names)
+	return OnceFromTo, fmt.Errorf("unknown interval method [%s], expected one of %s", s, strings.Join(names, ","))
 }
+
 func intervalStrategyName(i int) string {
 	for name, each := range intervalStrategies {
 		if each.id() == i {
@@ -106,6 +165,8 @@ var intervalStrategies = map[string]intervalStrategy{
 	"repeat":         strategyRepeatFromTo{},
 	"two-way":        strategyOnceFromToFrom{},
 	"repeat-two-way": strategyRepeatFromToFrom{},
+	"random":         strategyRandomFromTo{},
+	"geometric":      strategyGeometricFromTo{},
 }
 
 type strategyOnceFromTo struct{}
@@ -123,3 +184,11 @@ func (s strategyOnceFromToFrom) id() int { return OnceFromToFrom }
 type strategyRepeatFromToFrom struct{}
 
 func (s strategyRepeatFromToFrom) id() int { return RepeatFromToFrom }
+
+type strategyRandomFromTo struct{}
+
+func (s strategyRandomFromTo) id() int { return RandomFromTo }
+
+type strategyGeometricFromTo struct{}
+
+func (s strategyGeometricFromTo) id() int { return GeometricFromTo }
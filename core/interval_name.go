@@ -0,0 +1,66 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// semitonesOfDegree are the semitones of the perfect or major interval of each degree (unison..seventh).
+var semitonesOfDegree = [7]int{0, 2, 4, 5, 7, 9, 11}
+
+// intervalNames are the names of the intervals within an octave, by semitones.
+var intervalNames = [12]string{"P1", "m2", "M2", "m3", "M3", "P4", "A4", "P5", "m6", "M6", "m7", "M7"}
+
+var intervalNameRegex = regexp.MustCompile(`^([+-]?)(P|M|m|A|d)([1-9][0-9]?)$`)
+
+// ParseInterval returns the semitones of a named interval such as m3, P5, -M6 or M9 ; TT is the tritone.
+// A minus sign is a descending interval.
+func ParseInterval(s string) (int, error) {
+	sign := 1
+	if strings.TrimLeft(s, "+-") == "TT" {
+		if strings.HasPrefix(s, "-") {
+			sign = -1
+		}
+		return sign * 6, nil
+	}
+	matches := intervalNameRegex.FindStringSubmatch(s)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid interval [%s], expected a quality (P,M,m,A,d) and a number such as m3, P5 or -M6", s)
+	}
+	if matches[1] == "-" {
+		sign = -1
+	}
+	number, _ := strconv.Atoi(matches[3])
+	degree := (number - 1) % 7
+	semitones := semitonesOfDegree[degree] + (number-1)/7*12
+	perfect := degree == 0 || degree == 3 || degree == 4
+	switch quality := matches[2]; {
+	case quality == "P" && perfect, quality == "M" && !perfect:
+	case quality == "m" && !perfect:
+		semitones--
+	case quality == "A":
+		semitones++
+	case quality == "d" && perfect:
+		semitones--
+	case quality == "d" && !perfect:
+		semitones -= 2
+	default:
+		return 0, fmt.Errorf("invalid interval [%s], %s is not a quality of interval number %d", s, quality, number)
+	}
+	return sign * semitones, nil
+}
+
+// IntervalName returns the name of an interval of semitones, e.g. M3 for 4 and -P5 for -7.
+// Intervals larger than an octave are compound, e.g. M9 for 14.
+func IntervalName(semitones int) string {
+	sign := ""
+	if semitones < 0 {
+		sign = "-"
+		semitones = -semitones
+	}
+	simple := intervalNames[semitones%12]
+	number, _ := strconv.Atoi(simple[1:])
+	return fmt.Sprintf("%s%s%d", sign, simple[:1], number+semitones/12*7)
+}
@@ -0,0 +1,58 @@
+package core
+
+import "testing"
+
+func TestParseInterval(t *testing.T) {
+	for _, each := range []struct {
+		name      string
+		semitones int
+	}{
+		{"P1", 0},
+		{"m3", 3},
+		{"M3", 4},
+		{"P5", 7},
+		{"-M6", -9},
+		{"+m7", 10},
+		{"A4", 6},
+		{"d5", 6},
+		{"TT", 6},
+		{"-TT", -6},
+		{"d7", 9},
+		{"P8", 12},
+		{"M9", 14},
+		{"P11", 17},
+	} {
+		got, err := ParseInterval(each.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := each.semitones; got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each.name, got, got, want, want)
+		}
+	}
+	for _, each := range []string{"", "3", "M5", "P3", "m4", "X3", "M0"} {
+		if _, err := ParseInterval(each); err == nil {
+			t.Errorf("%s: error expected", each)
+		}
+	}
+}
+
+func TestIntervalName(t *testing.T) {
+	for _, each := range []struct {
+		semitones int
+		name      string
+	}{
+		{0, "P1"},
+		{4, "M3"},
+		{6, "A4"},
+		{-7, "-P5"},
+		{12, "P8"},
+		{14, "M9"},
+		{-15, "-m10"},
+		{24, "P15"},
+	} {
+		if got, want := IntervalName(each.semitones), each.name; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -36,3 +37,43 @@ func TestInterval_Value_Storex(t *testing.T) {
 		t.Errorf("got [%v] want [%v]", got, want)
 	}
 }
+
+func TestInterval_Strategies(t *testing.T) {
+	defer UseRandomSource("pseudo", 0)
+	UseRandomSource("seeded", 1)
+	for _, each := range []struct {
+		from, to, by int
+		strategy     int
+		want         []int
+	}{
+		{0, 4, 2, RepeatFromTo, []int{0, 2, 4, 0, 2}},
+		{0, 4, 2, OnceFromTo, []int{0, 2, 4, 4, 4}},
+		{0, 4, 2, OnceFromToFrom, []int{0, 2, 4, 2, 0, 0, 0}},
+		{0, 4, 2, RepeatFromToFrom, []int{0, 2, 4, 2, 0, 2, 4}},
+		{1, 8, 2, GeometricFromTo, []int{1, 2, 4, 8, 1, 2}},
+	} {
+		i := NewInterval(On(each.from), On(each.to), On(each.by), each.strategy)
+		got := []int{i.Value().(int)}
+		for len(got) < len(each.want) {
+			got = append(got, i.Next().(int))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(each.want) {
+			t.Errorf("%s: got [%v] want [%v]", intervalStrategyName(each.strategy), got, each.want)
+		}
+	}
+	r := NewInterval(On(0), On(6), On(3), RandomFromTo)
+	for k := 0; k < 20; k++ {
+		if v := r.Next().(int); v != 0 && v != 3 && v != 6 {
+			t.Errorf("random value %d not a step in the interval", v)
+		}
+	}
+}
+
+func TestParseIntervalStrategy(t *testing.T) {
+	if got, _ := ParseIntervalStrategy("ping-pong"); got != RepeatFromToFrom {
+		t.Errorf("got [%v] want [%v]", got, RepeatFromToFrom)
+	}
+	if _, err := ParseIntervalStrategy("zigzag"); err == nil {
+		t.Error("error expected")
+	}
+}
@@ -0,0 +1,45 @@
+package core
+
+import "fmt"
+
+// KeyChange makes a scale the key of a track from a bar on.
+// Progressions, scales and diatonic chords on that track resolve against the active key.
+type KeyChange struct {
+	Bar HasValue // one-based
+	Key HasValue // scale notation, e.g. 'd' or 'a/m'
+}
+
+func NewKeyChange(bar, key HasValue) KeyChange {
+	return KeyChange{Bar: bar, Key: key}
+}
+
+// Storex implements Storable
+func (k KeyChange) Storex() string {
+	return fmt.Sprintf("changekey(%s,%s)", Storex(k.Bar), Storex(k.Key))
+}
+
+// KeyOf returns the active key from the position in the context, if it has one.
+func KeyOf(ctx Context) (Scale, bool) {
+	p, ok := PositionOf(ctx)
+	if !ok || len(p.Key) == 0 {
+		return Scale{}, false
+	}
+	sc, err := ParseScale(p.Key)
+	if err != nil {
+		return Scale{}, false
+	}
+	return sc, true
+}
+
+// InKey returns the scale with the tonic and variant of a key ; the tonic moves to the nearest pitch
+// such that the duration, octave and dynamic of the scale are kept.
+func (s Scale) InKey(key Scale) Scale {
+	shift := (key.start.MIDI() - s.start.MIDI()) % 12
+	if shift < 0 {
+		shift += 12
+	}
+	if shift > 6 {
+		shift -= 12
+	}
+	return Scale{start: s.start.Pitched(shift), variant: key.variant, scaleType: s.scaleType}
+}
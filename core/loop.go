@@ -3,6 +3,7 @@ package core
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"sync"
 	"time"
 
@@ -20,8 +21,27 @@ type Loop struct {
 	condition  Condition
 	startedAt  time.Time
 	nextPlayAt time.Time
+	iteration  int64   // zero-based number of the iteration that is planned
+	bars       float64 // number of bars planned since started, in bars of the loop
+	meter      int     // beats in a bar of the loop ; zero means the BIAB of the control
+	tempo      float64 // multiplier of the BPM of the control ; zero means 1
+	cycle      float64 // number of bars of the last iteration
+	realign    bool    // if true then the next iteration starts at a bar of the control
+	// the last scheduled iterations, oldest first ; protected by its own mutex such that other loops can read it while this one plans
+	played      []playedIteration
+	playedMutex sync.RWMutex
 }
 
+// playedIteration is what the loop has scheduled for one iteration.
+type playedIteration struct {
+	iteration int64
+	start     time.Time
+	notes     Sequence // at the BPM of the control
+}
+
+// maxPlayedIterations is the number of scheduled iterations that a loop remembers.
+const maxPlayedIterations = 2
+
 func NewLoop(ctx Context, target []Sequenceable) *Loop {
 	return &Loop{
 		ctx:       ctx,
@@ -30,8 +50,85 @@ func NewLoop(ctx Context, target []Sequenceable) *Loop {
 	}
 }
 
+// NewPolyLoop returns a Loop with its own number of beats in a bar, such as 5 against the 4 of other loops.
+func NewPolyLoop(ctx Context, meter int, target []Sequenceable) *Loop {
+	l := NewLoop(ctx, target)
+	l.meter = meter
+	return l
+}
+
 func (l *Loop) Target() []Sequenceable { return l.target }
 
+// Meter returns the beats in a bar of the loop ; zero means the BIAB of the control.
+func (l *Loop) Meter() int {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.meter
+}
+
+// SetMeter changes the beats in a bar of the loop, starting with its next iteration.
+func (l *Loop) SetMeter(meter int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.meter = meter
+}
+
+// Realign makes the next iteration start at the next bar of the control, measured from the start of the leading loop.
+// Loops with different meters drift apart until they are realigned.
+func (l *Loop) Realign() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.realign = true
+}
+
+// WithTempo returns a copy of the loop that plays at the BPM of the control multiplied by a factor.
+// Loops with different tempi drift apart until they are realigned.
+func (l *Loop) WithTempo(factor float64) *Loop {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	c := NewPolyLoop(l.ctx, l.meter, l.target)
+	c.tempo = factor
+	return c
+}
+
+// Tempo returns the multiplier of the BPM of the control ; zero means 1.
+func (l *Loop) Tempo() float64 {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.tempo
+}
+
+// SetTempo changes the multiplier of the BPM of the control, starting with the next iteration.
+func (l *Loop) SetTempo(factor float64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.tempo = factor
+}
+
+// bpm returns the beats per minute of the loop. Requires a lock.
+func (l *Loop) bpm() float64 {
+	if l.tempo > 0 {
+		return l.ctx.Control().BPM() * l.tempo
+	}
+	return l.ctx.Control().BPM()
+}
+
+// inTempo returns the notes as if played at the BPM of the control.
+func (l *Loop) inTempo(s Sequence) Sequence {
+	if l.tempo > 0 && l.tempo != 1 {
+		return s.Stretched(float32(1 / l.tempo))
+	}
+	return s
+}
+
+// biab returns the beats in a bar of the loop. Requires a lock.
+func (l *Loop) biab() int {
+	if l.meter > 0 {
+		return l.meter
+	}
+	return l.ctx.Control().BIAB()
+}
+
 func (l *Loop) SetTarget(newTarget []Sequenceable) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
@@ -46,15 +143,24 @@ func (l *Loop) IsRunning() bool {
 
 func (l *Loop) Storex() string {
 	var b bytes.Buffer
-	fmt.Fprintf(&b, "loop(")
-	AppendStorexList(&b, true, l.target)
+	if l.meter > 0 {
+		fmt.Fprintf(&b, "polyloop(%d", l.meter)
+		AppendStorexList(&b, false, l.target)
+	} else {
+		fmt.Fprintf(&b, "loop(")
+		AppendStorexList(&b, true, l.target)
+	}
 	fmt.Fprintf(&b, ")")
+	if l.tempo > 0 && l.tempo != 1 {
+		return fmt.Sprintf("tempo(%v,%s)", l.tempo, b.String())
+	}
 	return b.String()
 }
 
 func (l *Loop) Evaluate(ctx Context) error {
 	// create and start a clone
-	clone := NewLoop(l.ctx, l.target)
+	clone := NewPolyLoop(l.ctx, l.meter, l.target)
+	clone.tempo = l.tempo
 	cond := NoCondition
 	if with, ok := ctx.(Conditional); ok {
 		cond = with.Condition()
@@ -73,6 +179,15 @@ func (l *Loop) Inspect(i Inspection) {
 	if runningLoop == l {
 		i.Properties["leader"] = true
 	}
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if l.meter > 0 {
+		i.Properties["meter"] = fmt.Sprintf("%d/4", l.meter)
+		i.Properties["cycle"] = fmt.Sprintf("%.2f bars", l.cycle)
+	}
+	if l.tempo > 0 && l.tempo != 1 {
+		i.Properties["tempo"] = l.tempo
+	}
 }
 
 // in mutex
@@ -84,11 +199,49 @@ func (l *Loop) reschedule(d AudioDevice, when time.Time) {
 		l.isRunning = false
 		return
 	}
+	bpm := l.bpm()
+	biab := l.biab()
+	bar := WholeNoteDuration(bpm) * time.Duration(biab) / 4
 	moment := when
+	started := l.bars
+	notes := Sequence{}
+	record := func(s Sequence) { notes = notes.SequenceJoin(s) }
 	for _, each := range l.target {
+		// tolerate rounding of durations
+		whole := math.Floor(l.bars + 1e-6)
+		at := WithPosition(l.ctx, Position{
+			Iteration: l.iteration,
+			Bar:       int64(whole),
+			Beat:      int64((l.bars-whole)*float64(biab) + 1e-6),
+			BIAB:      biab,
+			Start:     moment,
+		})
 		// after each other
-		moment = d.Play(l.condition, each, l.ctx.Control().BPM(), moment)
+		next, err := playSafely(d, l.condition, recording(Transposed(l.ctx.Control(), Positioned(at, each)), record), bpm, moment)
+		if err != nil {
+			notify.Errorf("loop cannot play %s: %v", Storex(each), err)
+			continue
+		}
+		l.bars += float64(next.Sub(moment)) / float64(bar)
+		moment = next
+	}
+	// nothing was played ; rest a bar such that the loop keeps running and can play a fixed target
+	if !moment.After(when) {
+		moment = when.Add(bar)
+		l.bars++
+		notes = notes.SequenceJoin(RestSequence(1, biab))
+	}
+	l.remember(playedIteration{iteration: l.iteration, start: when, notes: l.inTempo(notes)})
+	l.cycle = l.bars - started
+	if l.realign {
+		l.realign = false
+		moment = l.nextControlBar(moment, l.ctx.Control().BPM())
 	}
+	// keep in phase with a shared clock, e.g. when its tempo was changed by another application
+	if clock, ok := ClockOf(l.ctx); ok && (l.tempo == 0 || l.tempo == 1) {
+		moment = nearestBeatOf(clock, moment)
+	}
+	l.iteration++
 	if IsDebug() {
 		notify.Debugf("core.loop: next=%s", moment.Format("15:04:05.00"))
 	}
@@ -97,6 +250,86 @@ func (l *Loop) reschedule(d AudioDevice, when time.Time) {
 	d.Schedule(l, moment)
 }
 
+// remember keeps a scheduled iteration, forgetting the oldest.
+func (l *Loop) remember(p playedIteration) {
+	l.playedMutex.Lock()
+	defer l.playedMutex.Unlock()
+	l.played = append(l.played, p)
+	if len(l.played) > maxPlayedIterations {
+		l.played = l.played[len(l.played)-maxPlayedIterations:]
+	}
+}
+
+// forget removes all scheduled iterations.
+func (l *Loop) forget() {
+	l.playedMutex.Lock()
+	defer l.playedMutex.Unlock()
+	l.played = nil
+}
+
+// recording returns the object to play such that the notes it creates are passed to a function ; selectors are kept.
+func recording(s Sequenceable, record func(Sequence)) Sequenceable {
+	switch v := s.(type) {
+	case DeviceSelector:
+		return NewDeviceSelector(recording(v.Target, record), v.ID)
+	case ChannelSelector:
+		return NewChannelSelector(recording(v.Target, record), v.Number)
+	case HasValue:
+		// e.g. a variable, its value can have selectors
+		if seq, ok := v.Value().(Sequenceable); ok {
+			return recording(seq, record)
+		}
+	}
+	return recorded{target: s, record: record}
+}
+
+// recorded passes the notes of its target to a function when they are created.
+type recorded struct {
+	target Sequenceable
+	record func(Sequence)
+}
+
+// S is part of Sequenceable
+func (r recorded) S() Sequence {
+	s := r.target.S()
+	r.record(s)
+	return s
+}
+
+// Storex is part of Storable
+func (r recorded) Storex() string { return Storex(r.target) }
+
+// playSafely plays a musical object ; it returns an error if the object fails to produce its notes.
+func playSafely(d AudioDevice, condition Condition, s Sequenceable, bpm float64, when time.Time) (next time.Time, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return d.Play(condition, s, bpm, when), nil
+}
+
+// nextControlBar returns the first moment, at or after a moment, on a bar of the control. Requires a lock.
+// Bars are counted from the start of the leading loop, or by the clock of the control if any.
+func (l *Loop) nextControlBar(moment time.Time, bpm float64) time.Time {
+	if clock, ok := ClockOf(l.ctx); ok {
+		return nextBarOf(clock, moment, l.ctx.Control().BIAB())
+	}
+	bar := WholeNoteDuration(bpm) * time.Duration(l.ctx.Control().BIAB()) / 4
+	origin := l.startedAt
+	if runningLoop != nil && runningLoop != l {
+		runningLoop.mutex.RLock()
+		origin = runningLoop.startedAt
+		runningLoop.mutex.RUnlock()
+	}
+	if bar <= 0 || moment.Before(origin) {
+		return moment
+	}
+	// tolerate rounding of durations
+	bars := math.Ceil(float64(moment.Sub(origin))/float64(bar) - 1e-6)
+	return origin.Add(time.Duration(bars) * bar)
+}
+
 func (l *Loop) NextPlayAt() time.Time {
 	l.mutex.RLock()
 	defer l.mutex.RUnlock()
@@ -106,6 +339,46 @@ func (l *Loop) NextPlayAt() time.Time {
 	return l.nextPlayAt
 }
 
+// PlayingBar returns the zero-based bar that is playing since the loop began, or -1 if it is not running.
+func (l *Loop) PlayingBar(now time.Time) int64 {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if !l.isRunning || now.Before(l.startedAt) {
+		return -1
+	}
+	bar := WholeNoteDuration(l.bpm()) * time.Duration(l.biab()) / 4
+	if bar <= 0 {
+		return 0
+	}
+	return int64(now.Sub(l.startedAt) / bar)
+}
+
+// PlayedAt returns the zero-based iteration that the loop plays at a moment, the notes it has scheduled for that iteration
+// and the position within those notes, in whole notes at the BPM of the control.
+// If that iteration is not yet scheduled then the notes of the last scheduled iteration are taken to repeat.
+// It returns false if the loop has not scheduled an iteration that starts at or before that moment.
+func (l *Loop) PlayedAt(moment time.Time) (iteration int64, notes Sequence, position float64, ok bool) {
+	l.playedMutex.RLock()
+	defer l.playedMutex.RUnlock()
+	whole := WholeNoteDuration(l.ctx.Control().BPM())
+	for i := len(l.played) - 1; i >= 0; i-- {
+		each := l.played[i]
+		if moment.Before(each.start) {
+			continue
+		}
+		length := each.notes.DurationFactor()
+		if length <= 0 || whole <= 0 {
+			return each.iteration, each.notes, 0, true
+		}
+		// tolerate rounding of durations
+		elapsed := float64(moment.Sub(each.start))/float64(whole) + 1e-6
+		repeats := int64(elapsed / length)
+		position = math.Max(0, elapsed-float64(repeats)*length-1e-6)
+		return each.iteration + repeats, each.notes, position, true
+	}
+	return 0, Sequence{}, 0, false
+}
+
 // Handle is part of TimelineEvent
 func (l *Loop) Handle(tim *Timeline, when time.Time) {
 	l.mutex.Lock()
@@ -135,9 +408,16 @@ func (l *Loop) Play(ctx Context, at time.Time) error {
 		}
 	} else {
 		runningLoop = l
+		// start on a bar of a shared clock
+		if clock, ok := ClockOf(ctx); ok {
+			when = nextBarOf(clock, at, ctx.Control().BIAB())
+		}
 	}
 	l.isRunning = true
 	l.startedAt = when
+	l.iteration = 0
+	l.bars = 0
+	l.forget()
 	l.reschedule(l.ctx.Device(), when)
 	return nil
 }
@@ -150,6 +430,7 @@ func (l *Loop) Stop(ctx Context) error {
 		return nil
 	}
 	l.isRunning = false
+	l.forget()
 
 	if l == runningLoop {
 		runningLoop = nil
@@ -167,6 +448,42 @@ func (l *Loop) S() Sequence {
 	return l.ToSequence(1)
 }
 
+// Iterations returns the notes of a number of iterations as if the loop was playing them.
+// Each target creates its notes knowing its position ; stateful targets, such as an interval using next, advance.
+func (l *Loop) Iterations(count int) Sequence {
+	l.mutex.RLock()
+	target := l.target
+	biab := l.biab()
+	l.mutex.RUnlock()
+	all := Sequence{}
+	bars := 0.0
+	for i := 0; i < count; i++ {
+		played := false
+		for _, each := range target {
+			// tolerate rounding of durations
+			whole := math.Floor(bars + 1e-6)
+			at := WithPosition(l.ctx, Position{
+				Iteration: int64(i),
+				Bar:       int64(whole),
+				Beat:      int64((bars-whole)*float64(biab) + 1e-6),
+				BIAB:      biab,
+			})
+			seq := Positioned(at, each).S()
+			if seq.DurationFactor() > 0 {
+				played = true
+			}
+			all = all.SequenceJoin(seq)
+			bars += seq.Bars(biab)
+		}
+		// nothing was played ; the loop rests a bar
+		if !played {
+			all = all.SequenceJoin(RestSequence(1, biab))
+			bars++
+		}
+	}
+	return l.inTempo(all)
+}
+
 func (l *Loop) ToSequence(loopcount int) Sequence {
 	all := Sequence{}
 	for i := 0; i < loopcount; i++ {
@@ -174,5 +491,5 @@ func (l *Loop) ToSequence(loopcount int) Sequence {
 			all = all.SequenceJoin(each.S())
 		}
 	}
-	return all
+	return l.inTempo(all)
 }
@@ -1,27 +1,46 @@
 package core
 
 import (
-	"fmt"
+	"bytes"
+	"io"
+	"sync"
 )
 
-// Nexter is an empty Sequence that has a sideeffect to call Value().Next() on its target when asked for the Sequence.
+// nextMutex makes sure that the targets of a Nexter advance together.
+var nextMutex sync.Mutex
+
+// Nexter is an empty Sequence that has a sideeffect to call Value().Next() on its targets when asked for the Sequence.
+// All targets advance together, in order, before any other Nexter can advance them.
 type Nexter struct {
-	Target HasValue
+	Targets []HasValue
 }
 
 // S is part of Sequenceable
 func (n Nexter) S() Sequence {
-	v := n.Target.Value()
-	if t, ok := v.(Nextable); ok {
-		t.Next()
+	nextMutex.Lock()
+	defer nextMutex.Unlock()
+	for _, each := range n.Targets {
+		if t, ok := each.Value().(Nextable); ok {
+			t.Next()
+		}
 	}
 	return EmptySequence
 }
 
 // Storex is part of Storable
 func (n Nexter) Storex() string {
-	if st, ok := n.Target.(Storable); ok {
-		return fmt.Sprintf("next(%s)", st.Storex())
+	var b bytes.Buffer
+	io.WriteString(&b, "next(")
+	for i, each := range n.Targets {
+		st, ok := each.(Storable)
+		if !ok {
+			return ""
+		}
+		if i > 0 {
+			io.WriteString(&b, ",")
+		}
+		io.WriteString(&b, st.Storex())
 	}
-	return ""
+	io.WriteString(&b, ")")
+	return b.String()
 }
@@ -27,6 +27,7 @@ type Note struct {
 
 	fraction float32       // {0.03175,0.0625,0.125,0.25,0.5,1}
 	duration time.Duration // if set then this overrides Dotted and fraction
+	cents    float64       // deviation from the pitch, see FrequencyToNote
 
 	tied []Note // succeeding identical notes that are tied to this ; mostly empty
 }
@@ -39,6 +40,7 @@ func (n Note) Equals(o Note) bool {
 		n.Velocity == o.Velocity &&
 		n.fraction == o.fraction &&
 		n.duration == o.duration &&
+		n.cents == o.cents &&
 		n.HasEqualTied(o)
 }
 
@@ -55,6 +57,9 @@ func (n Note) HasEqualTied(o Note) bool {
 }
 
 func (n Note) Storex() string {
+	if n.cents != 0 {
+		return n.hzStorex()
+	}
 	return fmt.Sprintf("note('%s')", n.String())
 }
 
@@ -402,7 +407,11 @@ func (n Note) printOn(buf *bytes.Buffer, sharpOrFlatKey int) {
 		fmt.Fprintf(buf, "%d", n.Octave)
 	}
 	if n.Velocity != Normal {
-		io.WriteString(buf, VelocityToDynamic(n.Velocity))
+		if dynamic := VelocityToDynamic(n.Velocity); ParseVelocity(dynamic) == n.Velocity {
+			io.WriteString(buf, dynamic)
+		} else {
+			fmt.Fprintf(buf, ":v%d", n.Velocity)
+		}
 	}
 	if len(n.tied) > 0 {
 		for _, each := range n.tied {
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// FrequencyToNote returns the note with the nearest MIDI number of a frequency in Hz
+// and its deviation in cents [-50..50], which is played using pitch bend.
+func FrequencyToNote(hz float64, fraction float32) (Note, error) {
+	if hz <= 0 {
+		return Rest4, errors.New("frequency must be > 0")
+	}
+	exact := 69 + 12*math.Log2(hz/440)
+	nr := math.Round(exact)
+	n, err := MIDItoNote(fraction, int(nr), Normal)
+	if err != nil {
+		return Rest4, fmt.Errorf("frequency %v Hz is out of the MIDI range", hz)
+	}
+	// tolerate rounding
+	n.cents = math.Round((exact-nr)*100*1000) / 1000
+	return n, nil
+}
+
+// Cents returns the deviation from the pitch of the note ; it is zero unless created from a frequency.
+func (n Note) Cents() float64 { return n.cents }
+
+// Frequency returns the frequency in Hz of a hearable note, including its deviation in cents.
+func (n Note) Frequency() float64 {
+	return 440 * math.Pow(2, (float64(n.MIDI()-69)+n.cents/100)/12)
+}
+
+func (n Note) hzStorex() string {
+	hz := strconv.FormatFloat(math.Round(n.Frequency()*100)/100, 'f', -1, 64)
+	f := n.fraction
+	if n.Dotted {
+		f *= 1.5
+	}
+	if f == 0.25 {
+		return fmt.Sprintf("hz(%s)", hz)
+	}
+	if inv := 1 / f; inv == float32(math.Round(float64(inv))) {
+		return fmt.Sprintf("hz(%s,%d)", hz, int(inv))
+	}
+	return fmt.Sprintf("hz(%s,%v)", hz, f)
+}
@@ -0,0 +1,33 @@
+package core
+
+import "testing"
+
+func TestFrequencyToNote(t *testing.T) {
+	n, err := FrequencyToNote(440, 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n.String(), "A"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := n.Cents(), 0.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	n, _ = FrequencyToNote(450, 0.125)
+	if got, want := n.MIDI(), 69; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := n.Cents(), 38.906; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := n.Storex(), "hz(450,8)"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// keeps its deviation
+	if got, want := n.Octaved(1).Cents(), 38.906; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := FrequencyToNote(0, 0.25); err == nil {
+		t.Error("error expected")
+	}
+}
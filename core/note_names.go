@@ -0,0 +1,108 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// NoteNamesKey is the environment key of the scheme of note names in notations, see TranslateNoteNames.
+const NoteNamesKey = "notation.names"
+
+// Schemes of note names.
+const (
+	EnglishNoteNames = "english" // C D E F G A B
+	GermanNoteNames  = "german"  // C D E F G A H and B for B flat
+	SolfegeNoteNames = "solfege" // do re mi fa sol la si
+)
+
+var solfegeNames = map[string]string{
+	"do":  "C",
+	"re":  "D",
+	"mi":  "E",
+	"fa":  "F",
+	"sol": "G",
+	"so":  "G",
+	"la":  "A",
+	"si":  "B",
+	"ti":  "B",
+}
+
+// IsNoteNames returns whether the scheme of note names is known.
+func IsNoteNames(scheme string) bool {
+	switch scheme {
+	case EnglishNoteNames, GermanNoteNames, SolfegeNoteNames:
+		return true
+	}
+	return false
+}
+
+// TranslateNoteNames rewrites the note names of a notation in a scheme to the (English) note names of the parsers.
+// In a chord, the quality and interval part after the first slash is kept.
+func TranslateNoteNames(scheme, input string) (string, error) {
+	if scheme == EnglishNoteNames || len(scheme) == 0 {
+		return input, nil
+	}
+	if !IsNoteNames(scheme) {
+		return input, fmt.Errorf("unknown note names %q, expected %q, %q or %q", scheme, EnglishNoteNames, GermanNoteNames, SolfegeNoteNames)
+	}
+	var out strings.Builder
+	runes := []rune(input)
+	slashes := 0 // in the current chord
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if !unicode.IsLetter(r) {
+			switch r {
+			case '/':
+				slashes++
+			case ' ', '(', ')':
+				slashes = 0
+			}
+			out.WriteRune(r)
+			i++
+			continue
+		}
+		// a word of letters
+		j := i
+		for j < len(runes) && unicode.IsLetter(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		if slashes == 1 || (i > 0 && runes[i-1] == ':') {
+			// quality and interval, or the v of a velocity
+			out.WriteString(word)
+			i = j
+			continue
+		}
+		name, err := translateNoteName(scheme, word, runes[j:])
+		if err != nil {
+			return input, err
+		}
+		out.WriteString(name)
+		i = j
+	}
+	return out.String(), nil
+}
+
+// translateNoteName returns the English note name of a word ; rest are the runes after it.
+func translateNoteName(scheme, word string, rest []rune) (string, error) {
+	switch scheme {
+	case GermanNoteNames:
+		switch strings.ToUpper(word) {
+		case "H":
+			return "B", nil
+		case "B":
+			if len(rest) > 0 && (rest[0] == '#' || rest[0] == '_') {
+				return word, fmt.Errorf("German B is B flat, use H with an accidental")
+			}
+			return "B_", nil
+		}
+		return word, nil
+	case SolfegeNoteNames:
+		if name, ok := solfegeNames[strings.ToLower(word)]; ok {
+			return name, nil
+		}
+		return word, fmt.Errorf("unknown solfège note name %q", word)
+	}
+	return word, nil
+}
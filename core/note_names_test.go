@@ -0,0 +1,41 @@
+package core
+
+import "testing"
+
+func TestTranslateNoteNames(t *testing.T) {
+	for _, each := range []struct {
+		scheme, input, want string
+	}{
+		{EnglishNoteNames, "c d b", "c d b"},
+		{GermanNoteNames, "c h b", "c B B_"},
+		{GermanNoteNames, "(8H2 F#) =", "(8B2 F#) ="},
+		{GermanNoteNames, "h/m7/a", "B/m7/a"},
+		{GermanNoteNames, "b/m", "B_/m"},
+		{SolfegeNoteNames, "do re mi fa sol la si", "C D E F G A B"},
+		{SolfegeNoteNames, "8Do#5++ (ti re)", "8C#5++ (B D)"},
+		{SolfegeNoteNames, "la/m7//sol", "A/m7//G"},
+		{SolfegeNoteNames, "re:v96", "D:v96"},
+	} {
+		got, err := TranslateNoteNames(each.scheme, each.input)
+		if err != nil {
+			t.Fatalf("%s %q: %v", each.scheme, each.input, err)
+		}
+		if got != each.want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each.scheme, got, got, each.want, each.want)
+		}
+	}
+}
+
+func TestTranslateNoteNamesErrors(t *testing.T) {
+	for _, each := range []struct {
+		scheme, input string
+	}{
+		{GermanNoteNames, "b#"},
+		{SolfegeNoteNames, "do x"},
+		{"dutch", "c"},
+	} {
+		if _, err := TranslateNoteNames(each.scheme, each.input); err == nil {
+			t.Errorf("%s %q: error expected", each.scheme, each.input)
+		}
+	}
+}
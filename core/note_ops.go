@@ -13,6 +13,7 @@ func (n Note) Pitched(howManySemitones int) Note {
 		panic(err)
 	}
 	p := MakeNote(simple.Name, simple.Octave, n.fraction, simple.Accidental, n.Dotted, n.Velocity)
+	p.cents = n.cents
 	// handle tied notes
 	for _, each := range n.tied {
 		p = p.WithTiedNote(each.Pitched(howManySemitones))
@@ -20,11 +21,37 @@ func (n Note) Pitched(howManySemitones int) Note {
 	return p
 }
 
+// Spelled returns the note with a sharp or a flat for the same pitch, as wanted by a key ; see printOn.
+func (n Note) Spelled(sharpOrFlatKey int) Note {
+	var p Note
+	if Sharp == sharpOrFlatKey && n.Accidental == -1 { // want Sharp, specified in Flat
+		lower := n.Pitched(-1)
+		if lower.Accidental != 0 {
+			return n
+		}
+		p = MakeNote(lower.Name, lower.Octave, n.fraction, 1, n.Dotted, n.Velocity)
+	} else if Flat == sharpOrFlatKey && n.Accidental == 1 { // want Flat, specified in Sharp
+		upper := n.Pitched(1)
+		if upper.Accidental != 0 {
+			return n
+		}
+		p = MakeNote(upper.Name, upper.Octave, n.fraction, -1, n.Dotted, n.Velocity)
+	} else {
+		return n
+	}
+	p.cents = n.cents
+	for _, each := range n.tied {
+		p = p.WithTiedNote(each.Spelled(sharpOrFlatKey))
+	}
+	return p
+}
+
 func (n Note) Octaved(howmuch int) Note {
 	if howmuch == 0 {
 		return n
 	}
 	p := MakeNote(n.Name, n.Octave+howmuch, n.fraction, n.Accidental, n.Dotted, n.Velocity)
+	p.cents = n.cents
 	// handle tied notes
 	for _, each := range n.tied {
 		p = p.WithTiedNote(each.Octaved(howmuch))
@@ -37,6 +64,7 @@ func (n Note) Stretched(f float32) Note {
 		return n
 	}
 	p := MakeNote(n.Name, n.Octave, n.fraction*f, n.Accidental, n.Dotted, n.Velocity)
+	p.cents = n.cents
 	// handle tied notes
 	for _, each := range n.tied {
 		p = p.WithTiedNote(each.Stretched(f))
@@ -154,7 +154,7 @@ func ExampleParseNote() {
 
 func TestNote_Storex(t *testing.T) {
 	n, _ := NewNote("A", 4, 0.25, 1, false, 1)
-	if got, want := n.Storex(), `note('A#-----')`; got != want {
+	if got, want := n.Storex(), `note('A#:v1')`; got != want {
 		t.Errorf("got [%v] want [%v]", got, want)
 	}
 }
@@ -286,3 +286,26 @@ func TestQuantizeFraction(t *testing.T) {
 		})
 	}
 }
+
+func TestParseNote_ExactVelocity(t *testing.T) {
+	n, err := ParseNote("8C#5:v96")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n.Velocity, 96; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// a dynamic level is written as a dynamic
+	if got, want := n.String(), "8C#5+++"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	n, _ = ParseNote("E:v100")
+	if got, want := n.Storex(), "note('E:v100')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	for _, each := range []string{"C:v0", "C:v128", "C:x96", "C:v", "C++:v96", "C:v96+"} {
+		if _, err := ParseNote(each); err == nil {
+			t.Errorf("%s: error expected", each)
+		}
+	}
+}
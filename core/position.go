@@ -0,0 +1,97 @@
+package core
+
+import "time"
+
+// Position is where music is planned: the iteration of a loop and the bar and beat in which it starts.
+type Position struct {
+	Iteration int64     // zero-based iteration of a loop ; 0 if not looping
+	Bar       int64     // zero-based bar since the start of the loop, track or beatmaster
+	Beat      int64     // zero-based beat within the bar
+	BIAB      int       // beats in a bar
+	Key       string    // scale notation of the active key of a track ; empty if not changed
+	Start     time.Time // moment at which the music starts playing ; zero if not known, e.g. when exporting
+}
+
+// IsLastBarOf returns whether the bar is the last of a phrase of a number of bars.
+func (p Position) IsLastBarOf(bars int64) bool {
+	if bars <= 0 {
+		return false
+	}
+	return p.Bar%bars == bars-1
+}
+
+// Positional is implemented by a context that knows where music is planned.
+type Positional interface {
+	Position() Position
+}
+
+// PositionalContext is a Context with the Position of the music that is planned.
+type PositionalContext struct {
+	Context
+	position Position
+}
+
+// Position is part of Positional
+func (p PositionalContext) Position() Position { return p.position }
+
+// Condition is part of Conditional ; it is the condition of the wrapped context, if any.
+func (p PositionalContext) Condition() Condition {
+	if with, ok := p.Context.(Conditional); ok {
+		return with.Condition()
+	}
+	return NoCondition
+}
+
+// WithPosition returns a context that has the position of the music that is planned.
+func WithPosition(ctx Context, p Position) Context {
+	if with, ok := ctx.(PositionalContext); ok {
+		ctx = with.Context
+	}
+	return PositionalContext{Context: ctx, position: p}
+}
+
+// PositionOf returns the position from the context, if it has one.
+func PositionOf(ctx Context) (Position, bool) {
+	if with, ok := ctx.(Positional); ok {
+		return with.Position(), true
+	}
+	return Position{}, false
+}
+
+// ContextSequenceable is implemented by musical objects that use the context, e.g. its Position, to create their notes.
+// Without a context, S is used.
+type ContextSequenceable interface {
+	Sequenceable
+	SWith(ctx Context) Sequence
+}
+
+// Positioned returns the object to play such that a ContextSequenceable, possibly in a channel or device selector,
+// creates its notes using the context.
+func Positioned(ctx Context, s Sequenceable) Sequenceable {
+	switch v := s.(type) {
+	case DeviceSelector:
+		return NewDeviceSelector(Positioned(ctx, v.Target), v.ID)
+	case ChannelSelector:
+		return NewChannelSelector(Positioned(ctx, v.Target), v.Number)
+	case ContextSequenceable:
+		return positioned{ctx: ctx, target: v}
+	case HasValue:
+		// e.g. a variable
+		if cs, ok := v.Value().(ContextSequenceable); ok {
+			return positioned{ctx: ctx, target: cs}
+		}
+	}
+	return s
+}
+
+// positioned binds a context to a ContextSequenceable.
+type positioned struct {
+	ctx    Context
+	target ContextSequenceable
+}
+
+// S is part of Sequenceable
+func (p positioned) S() Sequence { return p.target.SWith(p.ctx) }
+
+// Storex is part of Storable
+func (p positioned) Storex() string { return Storex(p.target) }
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/emicklei/melrose/notify"
+)
+
+type positionRecorder struct {
+	positions []Position // without Start
+	starts    []time.Time
+}
+
+func (r *positionRecorder) S() Sequence { return MustParseSequence("1c") }
+
+func (r *positionRecorder) SWith(ctx Context) Sequence {
+	p, _ := PositionOf(ctx)
+	r.starts = append(r.starts, p.Start)
+	p.Start = time.Time{}
+	r.positions = append(r.positions, p)
+	return r.S()
+}
+
+func TestPositioned_Selectors(t *testing.T) {
+	r := new(positionRecorder)
+	at := WithPosition(PlayContext{}, Position{Bar: 2})
+	p := Positioned(at, NewChannelSelector(r, On(3)))
+	sel, ok := p.(ChannelSelector)
+	if !ok {
+		t.Fatalf("got %T", p)
+	}
+	sel.S()
+	if got, want := r.positions[0].Bar, int64(2); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestPositionalContext_Condition(t *testing.T) {
+	ctx := PlayContext{}.WithCondition(TrueCondition)
+	at := WithPosition(WithPosition(ctx, Position{Bar: 1}), Position{Bar: 2})
+	if c := at.(Conditional).Condition(); c == nil || !c() {
+		t.Error("condition expected")
+	}
+	if p, _ := PositionOf(at); p.Bar != 2 {
+		t.Errorf("got [%v] want [2]", p.Bar)
+	}
+}
+
+func TestLoop_Positions(t *testing.T) {
+	r := new(positionRecorder)
+	ctx := PlayContext{LoopControl: NoLooper, AudioDevice: new(sequencingDevice)}
+	l := NewLoop(ctx, []Sequenceable{r, r})
+	l.isRunning = true
+	now := time.Now()
+	l.reschedule(ctx.Device(), now)
+	l.reschedule(ctx.Device(), now)
+	want := []Position{
+		{Iteration: 0, Bar: 0, BIAB: 4},
+		{Iteration: 0, Bar: 1, BIAB: 4},
+		{Iteration: 1, Bar: 2, BIAB: 4},
+		{Iteration: 1, Bar: 3, BIAB: 4},
+	}
+	if len(r.positions) != len(want) {
+		t.Fatalf("got %v", r.positions)
+	}
+	for i, each := range want {
+		if got := r.positions[i]; got != each {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, each, each)
+		}
+	}
+	// each whole note starts 2 seconds after the previous at 120 BPM
+	if got, want := r.starts[1].Sub(r.starts[0]), 2*time.Second; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+// sequencingDevice plays by returning the end time of a sequence.
+type sequencingDevice struct{}
+
+func (d *sequencingDevice) DefaultDeviceIDs() (int, int)                            { return 0, 0 }
+func (d *sequencingDevice) Command(args []string) notify.Message                    { return nil }
+func (d *sequencingDevice) HandleSetting(name string, values []interface{}) error   { return nil }
+func (d *sequencingDevice) HasInputCapability() bool                                { return false }
+func (d *sequencingDevice) Listen(deviceID int, who NoteListener, startOrStop bool) {}
+func (d *sequencingDevice) Schedule(event TimelineEvent, beginAt time.Time)         {}
+func (d *sequencingDevice) Reset()                                                  {}
+func (d *sequencingDevice) Close() error                                            { return nil }
+func (d *sequencingDevice) OnKey(ctx Context, deviceID int, channel int, note Note, fun HasValue) error {
+	return nil
+}
+func (d *sequencingDevice) Play(condition Condition, seq Sequenceable, bpm float64, beginAt time.Time) time.Time {
+	return beginAt.Add(time.Duration(float64(WholeNoteDuration(bpm)) * seq.S().DurationFactor()))
+}
+
+func TestLoop_Meter(t *testing.T) {
+	r := new(positionRecorder)
+	ctx := PlayContext{LoopControl: NoLooper, AudioDevice: new(sequencingDevice)}
+	// a whole note in bars of 5 beats
+	l := NewPolyLoop(ctx, 5, []Sequenceable{r})
+	l.isRunning = true
+	now := time.Now()
+	l.reschedule(ctx.Device(), now)
+	l.reschedule(ctx.Device(), now)
+	if got, want := r.positions[1], (Position{Iteration: 1, Bar: 0, Beat: 4, BIAB: 5}); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := l.cycle, 0.8; math.Abs(got-want) > 1e-6 {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := NewPolyLoop(ctx, 5, []Sequenceable{MustParseSequence("c")}).Storex(), "polyloop(5,sequence('C'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestLoop_Realign(t *testing.T) {
+	ctx := PlayContext{LoopControl: NoLooper, AudioDevice: new(sequencingDevice)}
+	// 3 quarters at 120 bpm in bars of 4 beats
+	l := NewPolyLoop(ctx, 3, []Sequenceable{MustParseSequence("c d e")})
+	l.isRunning = true
+	l.startedAt = time.Now()
+	l.Realign()
+	l.reschedule(ctx.Device(), l.startedAt)
+	if got, want := l.nextPlayAt.Sub(l.startedAt), 2*time.Second; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// only once
+	l.reschedule(ctx.Device(), l.nextPlayAt)
+	if got, want := l.nextPlayAt.Sub(l.startedAt), 3500*time.Millisecond; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestLoop_Tempo(t *testing.T) {
+	ctx := PlayContext{LoopControl: NoLooper, AudioDevice: new(sequencingDevice)}
+	// 4 quarters at half of 120 bpm
+	l := NewLoop(ctx, []Sequenceable{MustParseSequence("c d e f")}).WithTempo(0.5)
+	l.isRunning = true
+	l.startedAt = time.Now()
+	l.reschedule(ctx.Device(), l.startedAt)
+	if got, want := l.nextPlayAt.Sub(l.startedAt), 4*time.Second; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := l.S().DurationFactor(), 2.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := l.Storex(), "tempo(0.5,loop(sequence('C D E F')))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
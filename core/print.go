@@ -18,9 +18,11 @@ func ToggleDebug() bool {
 	return debugEnabled
 }
 
+// Print writes the value of its target, or an inspection of it, when evaluated or played.
 type Print struct {
 	Context Context
 	Target  interface{}
+	Inspect bool // if true then print the inspection instead of the value
 }
 
 func (w Print) Play(ctx Context, at time.Time) error {
@@ -37,7 +39,22 @@ func (w Print) Evaluate(ctx Context) error {
 // S is part of Sequenceable
 func (w Print) S() Sequence {
 	beats, bars := w.Context.Control().BeatsAndBars()
-	in := NewInspect(w.Context, "", w.Target)
+	if !w.Inspect {
+		if bars > 0 || beats > 0 {
+			notify.Infof("bar %d beat %d: %s", bars, beats, Storex(ValueOf(w.Target)))
+		} else {
+			notify.Infof("%s", Storex(ValueOf(w.Target)))
+		}
+		return EmptySequence
+	}
+	target := w.Target
+	if h, ok := target.(HasValue); ok {
+		// e.g. a variable ; keep generators that can be inspected themselves
+		if _, ok := target.(Inspectable); !ok {
+			target = h.Value()
+		}
+	}
+	in := NewInspect(w.Context, w.Context.Variables().NameFor(target), target)
 	if bars > 0 {
 		in.Properties["bar"] = bars
 	}
@@ -50,5 +67,8 @@ func (w Print) S() Sequence {
 
 // Storex is part of Storable
 func (w Print) Storex() string {
+	if w.Inspect {
+		return fmt.Sprintf("inspect(%s)", Storex(w.Target))
+	}
 	return fmt.Sprintf("print(%s)", Storex(w.Target))
 }
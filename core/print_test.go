@@ -6,7 +6,7 @@ import (
 
 func TestPrint_Interval(t *testing.T) {
 	i := NewInterval(On(1), On(2), On(1), RepeatFromTo)
-	n := Nexter{Target: i}
+	n := Nexter{Targets: []HasValue{i}}
 	var w Sequenceable = Print{Target: n}
 	t.Log(Storex(w))
 }
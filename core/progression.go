@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/emicklei/melrose/notify"
 )
@@ -15,6 +16,9 @@ func NewChordProgression(root, sequence HasValue) ChordProgression {
 	return ChordProgression{root: root, sequence: sequence}
 }
 
+// Root returns the scale that is used to create the chords.
+func (c ChordProgression) Root() HasValue { return c.root }
+
 // Replaced is part of Replaceable
 func (c ChordProgression) Replaced(from, to Sequenceable) Sequenceable {
 	if IsIdenticalTo(from, c) {
@@ -30,12 +34,47 @@ func (c ChordProgression) Storex() string {
 
 // S is part of Sequenceable
 func (c ChordProgression) S() Sequence {
-	chords := c.C()
-	j := EmptySequence
-	for _, each := range chords {
-		j = j.SequenceJoin(each.S())
+	return joinedChords(c.C())
+}
+
+// SWith is part of ContextSequenceable ; the chords are in the active key, if any.
+func (c ChordProgression) SWith(ctx Context) Sequence {
+	key, ok := KeyOf(ctx)
+	if !ok {
+		return c.S()
 	}
-	return j
+	cs, ok := c.root.Value().(string)
+	if !ok {
+		return c.S()
+	}
+	input, ok := c.sequence.Value().(string)
+	if !ok {
+		return c.S()
+	}
+	sc, err := ParseScale(cs)
+	if err != nil {
+		return c.S()
+	}
+	chords, err := newFormatParser(input).parseChordProgression(sc.InKey(key))
+	if err != nil {
+		notify.Warnf("parsing progression failed, error: %v", err)
+		return EmptySequence
+	}
+	return joinedChords(chords)
+}
+
+// Inspect is part of Inspectable
+func (c ChordProgression) Inspect(i Inspection) {
+	i.Properties["symbols"] = c.Symbols()
+}
+
+// Symbols returns the chord symbols separated by a space, e.g. C Am F G7.
+func (c ChordProgression) Symbols() string {
+	list := []string{}
+	for _, each := range c.C() {
+		list = append(list, each.Symbol())
+	}
+	return strings.Join(list, " ")
 }
 
 var noChords = []Chord{}
@@ -46,21 +85,24 @@ func (c ChordProgression) C() []Chord {
 		notify.Warnf("chord progression root must be string, type: %T", c.root.Value())
 		return noChords
 	}
-	sc, err := ParseScale(cs)
-	if err != nil {
-		notify.Warnf("chord progression root must use scale notation, error: %v", err)
-		return noChords
-	}
 	input, ok := c.sequence.Value().(string)
 	if !ok {
 		notify.Warnf("chord progression must be string, type: %T", c.sequence.Value())
 		return noChords
 	}
-	p := newFormatParser(input)
-	chords, err := p.parseChordProgression(sc)
+	chords, err := ParseChordProgression(cs, input)
 	if err != nil {
 		notify.Warnf("parsing progression failed, error: %v", err)
 		return noChords
 	}
 	return chords
 }
+
+// ParseChordProgression returns the chords for a space-separated list of roman chords using a scale.
+func ParseChordProgression(scale, input string) ([]Chord, error) {
+	sc, err := ParseScale(scale)
+	if err != nil {
+		return noChords, fmt.Errorf("chord progression root must use scale notation, error: %v", err)
+	}
+	return newFormatParser(input).parseChordProgression(sc)
+}
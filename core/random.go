@@ -0,0 +1,118 @@
+package core
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RandomSource provides numbers in [0,1) for generators such as probability and random.
+type RandomSource interface {
+	Float64() float64
+}
+
+// RandomSourceSetting is the name and seed by which a source was selected.
+type RandomSourceSetting struct {
+	Name string `json:"name"`
+	Seed int64  `json:"seed"`
+}
+
+var randomSource = struct {
+	mutex   sync.Mutex
+	source  RandomSource
+	setting RandomSourceSetting
+}{
+	source:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	setting: RandomSourceSetting{Name: "pseudo"},
+}
+
+// SetRandomSource changes the source used by all generators that do not have their own.
+// The setting becomes unknown ; use UseRandomSource to select a source by name.
+func SetRandomSource(s RandomSource) {
+	randomSource.mutex.Lock()
+	defer randomSource.mutex.Unlock()
+	randomSource.source = s
+	randomSource.setting = RandomSourceSetting{}
+}
+
+// UseRandomSource changes the source used by all generators to a new one by name and seed.
+func UseRandomSource(name string, seed int64) error {
+	s, err := NewRandomSource(name, seed)
+	if err != nil {
+		return err
+	}
+	randomSource.mutex.Lock()
+	defer randomSource.mutex.Unlock()
+	randomSource.source = s
+	randomSource.setting = RandomSourceSetting{Name: name, Seed: seed}
+	return nil
+}
+
+// CurrentRandomSource returns how the current source was selected ; the name is empty if unknown.
+func CurrentRandomSource() RandomSourceSetting {
+	randomSource.mutex.Lock()
+	defer randomSource.mutex.Unlock()
+	return randomSource.setting
+}
+
+// RandomFloat64 returns the next number in [0,1) from the current source.
+func RandomFloat64() float64 {
+	randomSource.mutex.Lock()
+	defer randomSource.mutex.Unlock()
+	return randomSource.source.Float64()
+}
+
+// NewRandomSource returns a source by name:
+// "pseudo" is a time seeded PRNG,
+// "seeded" is a PRNG with a given seed for reproducible results,
+// "crypto" is a true random source and
+// "halton" is a low-discrepancy sequence for which the seed is the base (default 2).
+func NewRandomSource(name string, seed int64) (RandomSource, error) {
+	switch name {
+	case "pseudo":
+		return rand.New(rand.NewSource(time.Now().UnixNano())), nil
+	case "seeded":
+		return rand.New(rand.NewSource(seed)), nil
+	case "crypto":
+		return cryptoSource{}, nil
+	case "halton":
+		if seed == 0 {
+			seed = 2
+		}
+		if seed < 2 {
+			return nil, fmt.Errorf("halton base must be at least 2, got %d", seed)
+		}
+		return &haltonSource{base: seed}, nil
+	}
+	return nil, fmt.Errorf("unknown random source %q, must be one of pseudo,seeded,crypto,halton", name)
+}
+
+type cryptoSource struct{}
+
+func (cryptoSource) Float64() float64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		return rand.Float64()
+	}
+	// use 53 bits for the mantissa
+	return float64(binary.BigEndian.Uint64(b[:])>>11) / (1 << 53)
+}
+
+// haltonSource produces the van der Corput sequence of a base ; numbers are evenly spread over [0,1).
+type haltonSource struct {
+	base  int64
+	index int64
+}
+
+func (h *haltonSource) Float64() float64 {
+	h.index++
+	f, r := 1.0, 0.0
+	for i := h.index; i > 0; i /= h.base {
+		f = f / float64(h.base)
+		r += f * float64(i%h.base)
+	}
+	return r
+}
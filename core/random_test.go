@@ -0,0 +1,38 @@
+package core
+
+import "testing"
+
+func TestHaltonSource(t *testing.T) {
+	s, _ := NewRandomSource("halton", 0)
+	for _, want := range []float64{0.5, 0.25, 0.75, 0.125, 0.625} {
+		if got := s.Float64(); got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	}
+}
+
+func TestSeededSource(t *testing.T) {
+	a, _ := NewRandomSource("seeded", 42)
+	b, _ := NewRandomSource("seeded", 42)
+	if got, want := a.Float64(), b.Float64(); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestCryptoSource(t *testing.T) {
+	s, _ := NewRandomSource("crypto", 0)
+	for i := 0; i < 100; i++ {
+		if f := s.Float64(); f < 0 || f >= 1 {
+			t.Fatalf("out of range: %v", f)
+		}
+	}
+}
+
+func TestUnknownSource(t *testing.T) {
+	if _, err := NewRandomSource("dice", 0); err == nil {
+		t.Fatal("error expected")
+	}
+	if _, err := NewRandomSource("halton", 1); err == nil {
+		t.Fatal("error expected")
+	}
+}
@@ -15,6 +15,9 @@ type Scale struct {
 }
 
 func (s Scale) Storex() string {
+	if s.variant == Minor {
+		return fmt.Sprintf("scale('%s %s/m')", s.scaleType, s.start.String())
+	}
 	return fmt.Sprintf("scale('%s %s')", s.scaleType, s.start.String())
 }
 
@@ -52,7 +55,7 @@ func ParseScale(s string) (Scale, error) {
 
 var (
 	majorScale        = [7]int{0, 2, 4, 5, 7, 9, 11}
-	naturalMinorScale = [7]int{0, 1, 3, 5, 7, 8, 10}
+	naturalMinorScale = [7]int{0, 2, 3, 5, 7, 8, 10}
 	romans            = [7]int{Major, Minor, Minor, Major, Major, Minor, Major}
 )
 
@@ -66,18 +69,86 @@ func (s Scale) ChordAt(index int) Chord {
 		offset := majorScale[index-1]
 		return Chord{start: s.start.Pitched(offset), inversion: Ground, interval: Triad, quality: romans[index-1]}
 	}
-	// TODO
-	return zeroChord()
+	return s.DiatonicChordAt(index, false)
+}
+
+// DiatonicChordAt returns the chord, using one-based index, that stacks thirds of the scale on a degree ;
+// a triad or a seventh chord with the quality that follows from the scale, e.g. ii is minor and vii is diminished.
+func (s Scale) DiatonicChordAt(index int, seventh bool) Chord {
+	if index < 1 || index > 7 {
+		notify.Warnf("invalid index for DiatonicChordAt, got %d", index)
+		return zeroChord()
+	}
+	steps := s.steps()
+	root := steps[index-1]
+	above := func(third int) int {
+		d := index - 1 + third*2
+		return steps[d%7] + (d/7)*12 - root
+	}
+	c := Chord{start: s.start.Pitched(root), inversion: Ground, interval: Triad, quality: Major}
+	switch [2]int{above(1), above(2)} {
+	case [2]int{3, 7}:
+		c.quality = Minor
+	case [2]int{3, 6}:
+		c.quality = Diminished
+	case [2]int{4, 8}:
+		c.quality = Augmented
+	}
+	if !seventh {
+		return c
+	}
+	c.interval = Seventh
+	switch [3]int{above(1), above(2), above(3)} {
+	case [3]int{4, 7, 10}:
+		c.quality = Septiem
+	case [3]int{3, 6, 10}:
+		c.extension = "m7b5"
+	case [3]int{3, 7, 11}:
+		c.extension = "mmaj7"
+	}
+	return c
+}
+
+func (s Scale) steps() [7]int {
+	if s.variant == Minor {
+		return naturalMinorScale
+	}
+	return majorScale
+}
+
+// SWith is part of ContextSequenceable ; the scale is in the active key, if any.
+func (s Scale) SWith(ctx Context) Sequence {
+	if key, ok := KeyOf(ctx); ok {
+		return s.InKey(key).S()
+	}
+	return s.S()
 }
 
 func (s Scale) S() Sequence {
+	key := s.sharpOrFlatKey()
 	notes := []Note{}
-	steps := majorScale
+	for _, p := range s.steps() {
+		notes = append(notes, s.start.Pitched(p).Spelled(key))
+	}
+	return BuildSequence(notes)
+}
+
+// sharpOrFlatKey returns Sharp or Flat, whichever the key signature of the scale has.
+// A scale that starts with an accidental uses that one, e.g. F# major has sharps and E_ minor has flats.
+func (s Scale) sharpOrFlatKey() int {
+	switch s.start.Accidental {
+	case 1:
+		return Sharp
+	case -1:
+		return Flat
+	}
+	// natural starts with flats in the key signature
+	flats := "F"
 	if s.variant == Minor {
-		steps = naturalMinorScale
+		flats = "DGCF"
 	}
-	for _, p := range steps {
-		notes = append(notes, s.start.Pitched(p))
+	if strings.Contains(flats, s.start.Name) {
+		return Flat
 	}
-	return BuildSequence(notes)
+	return Sharp
 }
@@ -33,14 +33,55 @@ func TestScale_ChordAt(t *testing.T) {
 
 func TestScale_MinorC(t *testing.T) {
 	s, _ := ParseScale("E/m")
-	if got, want := s.S().Storex(), "sequence('E F G A B C5 D5')"; got != want {
+	if got, want := s.S().Storex(), "sequence('E F# G A B C5 D5')"; got != want {
 		t.Errorf("got [%v] want [%v]", got, want)
 	}
 }
 
 func TestScale_MajorG(t *testing.T) {
 	s, _ := ParseScale("G")
-	if got, want := s.S().Storex(), "sequence('G A B C5 D5 E5 G_5')"; got != want {
+	if got, want := s.S().Storex(), "sequence('G A B C5 D5 E5 F#5')"; got != want {
 		t.Errorf("got [%v] want [%v]", got, want)
 	}
 }
+
+func TestScale_DiatonicChordAt(t *testing.T) {
+	major, _ := ParseScale("C")
+	minor, _ := ParseScale("A/m")
+	for _, each := range []struct {
+		scale   Scale
+		step    int
+		seventh bool
+		symbol  string
+	}{
+		{major, 1, false, "C"},
+		{major, 2, false, "Dm"},
+		{major, 7, false, "Bdim"},
+		{major, 1, true, "Cmaj7"},
+		{major, 5, true, "G7"},
+		{major, 7, true, "Bm7b5"},
+		{minor, 1, false, "Am"},
+		{minor, 2, false, "Bdim"},
+		{minor, 3, false, "C"},
+		{minor, 5, false, "Em"},
+		{minor, 7, true, "G7"},
+	} {
+		if got, want := each.scale.DiatonicChordAt(each.step, each.seventh).Symbol(), each.symbol; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	}
+}
+
+func TestDiatonicChords_At(t *testing.T) {
+	sc, _ := ParseScale("A/m")
+	d := NewDiatonicChords(On(sc), false)
+	if got, want := d.At(5).S().Storex(), "sequence('(E5 G5 B5)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := d.At(8).S().Storex(), "sequence('')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := d.Storex(), "triads(scale('major A/m'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
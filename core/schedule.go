@@ -0,0 +1,50 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emicklei/melrose/notify"
+)
+
+// Schedule performs its target at the start of a bar, counted by the beatmaster.
+// A playable such as a loop is played, an evaluatable is evaluated and any other sequenceable is played once.
+type Schedule struct {
+	Bar    HasValue // one-based
+	Target HasValue
+}
+
+// Evaluate is part of Evaluatable
+func (s Schedule) Evaluate(ctx Context) error {
+	bar := Int(s.Bar)
+	if bar < 1 {
+		return fmt.Errorf("bar must be one or larger, got %d", bar)
+	}
+	control := ctx.Control()
+	beats := int64(bar-1) * int64(control.BIAB())
+	if now, _ := control.BeatsAndBars(); beats < now {
+		return fmt.Errorf("cannot schedule at bar %d, it has passed", bar)
+	}
+	control.Schedule(beats, func(when time.Time) {
+		s.perform(ctx, when)
+	})
+	return nil
+}
+
+func (s Schedule) perform(ctx Context, when time.Time) {
+	switch v := ValueOf(s.Target).(type) {
+	case Playable:
+		v.Play(ctx, when)
+	case Evaluatable:
+		if err := v.Evaluate(ctx); err != nil {
+			notify.Errorf("failed to perform scheduled %s: %v", Storex(s.Target), err)
+		}
+	case Sequenceable:
+		ctx.Device().Play(NoCondition, Transposed(ctx.Control(), v), ctx.Control().BPM(), when)
+	}
+}
+
+// Storex is part of Storable
+func (s Schedule) Storex() string {
+	return fmt.Sprintf("schedule(%s,%s)", Storex(s.Bar), Storex(s.Target))
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+type countingEvaluatable struct{ count *int }
+
+func (c countingEvaluatable) Evaluate(ctx Context) error {
+	*c.count++
+	return nil
+}
+
+func TestSchedule_Evaluate(t *testing.T) {
+	looper := &TestLooper{Biab: 4}
+	ctx := PlayContext{LoopControl: looper}
+	count := 0
+	s := Schedule{Bar: On(3), Target: On(countingEvaluatable{&count})}
+	if err := s.Evaluate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	actions := looper.Scheduled[8]
+	if got, want := len(actions), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	actions[0](time.Now())
+	if got, want := count, 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	looper.Beats = 12
+	if err := s.Evaluate(ctx); err == nil {
+		t.Error("error expected for a bar that has passed")
+	}
+	s = Schedule{Bar: On(3), Target: On(MustParseSequence("c"))}
+	if got, want := s.Storex(), "schedule(3,sequence('C'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestWait_Evaluate(t *testing.T) {
+	ctx := PlayContext{LoopControl: NoLooper}
+	start := time.Now()
+	if err := (Wait{Beats: On(0.1)}).Evaluate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// 0.1 beat at 120 BPM
+	if got := time.Since(start); got < 50*time.Millisecond {
+		t.Errorf("got [%v] want at least [%v]", got, 50*time.Millisecond)
+	}
+	if err := (Wait{Beats: On(-1)}).Evaluate(ctx); err == nil {
+		t.Error("error expected")
+	}
+}
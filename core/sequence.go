@@ -99,6 +99,7 @@ func (s Sequence) Inspect(i Inspection) {
 	i.Properties["duration"] = s.DurationAt(i.Context.Control().BPM())
 	i.Properties["note(s)|groups"] = len(s.Notes)
 	i.Properties["bars"] = s.Bars(i.Context.Control().BIAB())
+	i.Properties["beats"] = s.Beats()
 }
 
 // Conversion
@@ -185,6 +186,11 @@ func (s Sequence) DurationAt(bpm float64) time.Duration {
 	return l
 }
 
+// Beats returns the length in quarter notes.
+func (s Sequence) Beats() float64 {
+	return s.DurationFactor() * 4
+}
+
 func (s Sequence) Bars(biab int) float64 {
 	return float64(s.DurationFactor()) * 4 / float64(biab) // 4 because signature denominator
 }
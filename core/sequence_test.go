@@ -104,3 +104,13 @@ func TestSequence_RestSequence(t *testing.T) {
 		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
 	}
 }
+
+func TestParseSequence_ExactVelocity(t *testing.T) {
+	s, err := ParseSequence("C:v100 (E:v33 G) 8=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Storex(), "sequence('C:v100 (E:v33 G) 8=')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
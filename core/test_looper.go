@@ -1,9 +1,11 @@
 package core
 
 type TestLooper struct {
-	Beats int64
-	Bars  int64
-	Biab  int64
+	Beats     int64
+	Bars      int64
+	Biab      int64
+	Scheduled map[int64][]BeatAction
+	Semitones int
 }
 
 func (t *TestLooper) Tick() {
@@ -30,6 +32,9 @@ func (t *TestLooper) BIAB() int {
 	return int(t.Biab)
 }
 
+func (t *TestLooper) SetTranspose(semitones int) { t.Semitones = semitones }
+func (t *TestLooper) Transpose() int             { return t.Semitones }
+
 func (t *TestLooper) StartLoop(l *Loop) {}
 func (t *TestLooper) EndLoop(l *Loop)   {}
 
@@ -41,4 +46,11 @@ func (t *TestLooper) Plan(bars int64, seq Sequenceable) {
 
 }
 
+func (t *TestLooper) Schedule(beats int64, action BeatAction) {
+	if t.Scheduled == nil {
+		t.Scheduled = map[int64][]BeatAction{}
+	}
+	t.Scheduled[beats] = append(t.Scheduled[beats], action)
+}
+
 func (t *TestLooper) SettingNotifier(handler func(LoopController)) {}
@@ -13,14 +13,16 @@ type Timeline struct {
 	head       *scheduledTimelineEvent // earliest
 	tail       *scheduledTimelineEvent // latest
 	protection sync.RWMutex
-	isPlaying  bool
-	resume     chan bool
+	wakeup     chan bool // signals that the head has changed
+	timing     *timingRecorder
 }
 
 // NewTimeline creates a new Timeline.
 func NewTimeline() *Timeline {
 	return &Timeline{
 		protection: sync.RWMutex{},
+		wakeup:     make(chan bool, 1),
+		timing:     new(timingRecorder),
 	}
 }
 
@@ -54,38 +56,77 @@ func (t *Timeline) Len() int64 {
 }
 
 // Play runs a loop to handle all the events in time. This is blocking.
+// Instead of polling, it waits until the earliest event is due or until an earlier event is scheduled.
 func (t *Timeline) Play() {
-	t.resume = make(chan bool)
-	t.isPlaying = true
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
 	for {
 		t.protection.RLock()
 		here := t.head
+		var when time.Time
+		if here != nil {
+			// read while locked ; the event can be recycled once handled
+			when = here.when
+		}
 		t.protection.RUnlock()
 		if here == nil {
-			<-t.resume
+			<-t.wakeup
 			continue
 		}
-		now := time.Now()
-		for now.After(here.when) {
-			here.event.Handle(t, now)
+		if untilNext := time.Until(when); untilNext > 0 {
+			timer.Reset(untilNext)
+			select {
+			case <-timer.C:
+			case <-t.wakeup:
+				// head has changed ; the timer must not fire for the old head
+				if !timer.Stop() {
+					<-timer.C
+				}
+				continue
+			}
+		}
+		t.handleDue(time.Now())
+	}
+}
 
-			t.protection.Lock()
-			t.head = t.head.next
-			here = t.head
+// handleDue takes all events from the chain that are due and handles them.
+func (t *Timeline) handleDue(now time.Time) {
+	for {
+		t.protection.Lock()
+		here := t.head
+		if here == nil || here.when.After(now) {
 			t.protection.Unlock()
-
-			if here == nil {
-				break
-			}
+			return
 		}
-		if here != nil {
-			untilNext := here.when.Sub(now)
-			if wait < untilNext {
-				time.Sleep(wait) // 1/16 note
-			} else {
-				time.Sleep(untilNext) // < 1/16 note
-			}
+		t.head = here.next
+		if t.head == nil {
+			t.tail = nil
 		}
+		t.protection.Unlock()
+		// handle outside the lock ; events can schedule new events
+		start := time.Now()
+		handleSafely(t, here.event, now)
+		end := time.Now()
+		t.timing.record(timingSample{handledAt: end, delay: start.Sub(here.when), callback: end.Sub(start)})
+		recycleScheduledEvent(here)
+	}
+}
+
+// handleSafely handles an event ; if it fails then it is reported and the next events are still handled.
+func handleSafely(t *Timeline, event TimelineEvent, when time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			notify.Errorf("failed to handle %T: %v", event, r)
+		}
+	}()
+	event.Handle(t, when)
+}
+
+// signalWakeup tells the play loop to re-evaluate the head ; it never blocks.
+func (t *Timeline) signalWakeup() {
+	select {
+	case t.wakeup <- true:
+	default:
 	}
 }
 
@@ -96,6 +137,12 @@ func (t *Timeline) Reset() {
 	}
 	t.protection.Lock()
 	defer t.protection.Unlock()
+	here := t.head
+	for here != nil {
+		next := here.next
+		recycleScheduledEvent(here)
+		here = next
+	}
 	t.head = nil
 	t.tail = nil
 }
@@ -107,10 +154,7 @@ func (t *Timeline) Schedule(event TimelineEvent, when time.Time) error {
 	if diff < -wait {
 		return fmt.Errorf("core.timeline: cannot schedule in the past:%v", now.Sub(when))
 	}
-	t.schedule(&scheduledTimelineEvent{
-		when:  when,
-		event: event,
-	})
+	t.schedule(newScheduledEvent(event, when))
 	return nil
 }
 
@@ -121,11 +165,8 @@ func (t *Timeline) schedule(event *scheduledTimelineEvent) {
 	if t.head == nil {
 		t.head = event
 		t.tail = event
-		// before resume otherwise run loop will deadlock
 		t.protection.Unlock()
-		if t.isPlaying {
-			t.resume <- true
-		}
+		t.signalWakeup()
 		return
 	}
 	defer t.protection.Unlock()
@@ -139,6 +180,7 @@ func (t *Timeline) schedule(event *scheduledTimelineEvent) {
 		// event is before head, new head
 		event.next = t.head
 		t.head = event
+		t.signalWakeup()
 		return
 	}
 	if t.head.next == nil {
@@ -185,10 +227,7 @@ func (t *Timeline) ZeroStarting() *Timeline {
 	zero := time.Time{}
 	t.EventsDo(func(event TimelineEvent, when time.Time) {
 		d := when.Sub(t.head.when)
-		result.schedule(&scheduledTimelineEvent{
-			when:  zero.Add(d),
-			event: event,
-		})
+		result.schedule(newScheduledEvent(event, zero.Add(d)))
 	})
 	return result
 }
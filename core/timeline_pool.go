@@ -0,0 +1,27 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// scheduledEventPool reuses the chain elements of all timelines.
+// A loop that plays for days schedules millions of events ; reusing them keeps the garbage collector quiet.
+// Sequences are not pooled because the result of S() can be shared, e.g. by a variable ;
+// the MIDI numbers of the notes that an output device plays are pooled by the midi package.
+var scheduledEventPool = sync.Pool{
+	New: func() interface{} { return new(scheduledTimelineEvent) },
+}
+
+func newScheduledEvent(event TimelineEvent, when time.Time) *scheduledTimelineEvent {
+	e := scheduledEventPool.Get().(*scheduledTimelineEvent)
+	e.event = event
+	e.when = when
+	return e
+}
+
+// recycleScheduledEvent returns an element that is no longer part of a chain.
+func recycleScheduledEvent(e *scheduledTimelineEvent) {
+	*e = scheduledTimelineEvent{}
+	scheduledEventPool.Put(e)
+}
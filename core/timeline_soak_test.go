@@ -0,0 +1,102 @@
+package core
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// soakEvent reschedules itself like a loop does, alternating note on and off.
+type soakEvent struct {
+	interval time.Duration
+	handled  int
+}
+
+func (e *soakEvent) NoteChangesDo(block func(NoteChange)) {}
+func (e *soakEvent) Handle(t *Timeline, when time.Time) {
+	e.handled++
+	t.schedule(newScheduledEvent(e, when.Add(e.interval)))
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+// TestTimelineSoak simulates hours of playback without waiting and checks that memory stays stable.
+// Set MELROSE_SOAK (e.g. 24h) to change the simulated period.
+func TestTimelineSoak(t *testing.T) {
+	if testing.Short() {
+		t.Skip("soak test")
+	}
+	period := time.Hour
+	if s := os.Getenv("MELROSE_SOAK"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		period = d
+	}
+	tim := NewTimeline()
+	start := time.Now()
+	// 4 voices of sixteenth notes at 120 bpm
+	voices := []*soakEvent{}
+	for i := 0; i < 4; i++ {
+		e := &soakEvent{interval: 125 * time.Millisecond}
+		voices = append(voices, e)
+		tim.schedule(newScheduledEvent(e, start))
+	}
+	step := 25 * time.Millisecond
+	warmup := period / 10
+	var baseline uint64
+	for now := start; now.Sub(start) < period; now = now.Add(step) {
+		tim.handleDue(now)
+		if baseline == 0 && now.Sub(start) >= warmup {
+			baseline = heapInUse()
+		}
+	}
+	end := heapInUse()
+	handled := 0
+	for _, each := range voices {
+		handled += each.handled
+	}
+	t.Logf("simulated %v, handled %d events, heap %d -> %d bytes", period, handled, baseline, end)
+	if got, want := tim.Len(), int64(len(voices)); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if end > baseline+1<<20 {
+		t.Errorf("heap grew from %d to %d bytes", baseline, end)
+	}
+}
+
+func TestScheduleAndHandleWithoutAllocations(t *testing.T) {
+	tim := NewTimeline()
+	e := new(testEvent)
+	now := time.Now()
+	// warm up pool and timing recorder
+	for i := 0; i < 100; i++ {
+		tim.schedule(newScheduledEvent(e, now))
+		tim.handleDue(now)
+	}
+	allocs := testing.AllocsPerRun(1000, func() {
+		tim.schedule(newScheduledEvent(e, now))
+		tim.handleDue(now)
+	})
+	if allocs >= 1 {
+		t.Errorf("got %v allocations per event, want none", allocs)
+	}
+}
+
+func TestTimingRecorderIsBounded(t *testing.T) {
+	r := new(timingRecorder)
+	now := time.Now()
+	for i := 0; i < 3*maxTimingSamples; i++ {
+		r.record(timingSample{handledAt: now})
+	}
+	if got := len(r.samples); got > maxTimingSamples {
+		t.Errorf("got %d samples want at most %d", got, maxTimingSamples)
+	}
+}
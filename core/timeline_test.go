@@ -67,3 +67,77 @@ func TestScheduleAdd(t *testing.T) {
 		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
 	}
 }
+
+type handledEvent struct {
+	handled chan time.Time
+}
+
+func (e handledEvent) NoteChangesDo(block func(NoteChange)) {}
+func (e handledEvent) Handle(t *Timeline, w time.Time)      { e.handled <- time.Now() }
+
+func TestPlayEarlierEventWhileWaiting(t *testing.T) {
+	tim := NewTimeline()
+	go tim.Play()
+	late := handledEvent{handled: make(chan time.Time, 1)}
+	early := handledEvent{handled: make(chan time.Time, 1)}
+	now := time.Now()
+	tim.Schedule(late, now.Add(1*time.Second))
+	// give the play loop time to start waiting for the late event
+	time.Sleep(10 * time.Millisecond)
+	due := now.Add(30 * time.Millisecond)
+	tim.Schedule(early, due)
+	select {
+	case at := <-early.handled:
+		if lag := at.Sub(due); lag > 20*time.Millisecond {
+			t.Errorf("event handled too late: %v", lag)
+		}
+	case <-late.handled:
+		t.Fatal("late event handled first")
+	case <-time.After(2 * time.Second):
+		t.Fatal("event not handled")
+	}
+}
+
+func TestTimingStats(t *testing.T) {
+	r := new(timingRecorder)
+	now := time.Now()
+	r.record(timingSample{handledAt: now.Add(-2 * time.Minute), delay: time.Second})
+	r.record(timingSample{handledAt: now, delay: 2 * time.Millisecond, callback: time.Millisecond})
+	r.record(timingSample{handledAt: now, delay: 10 * time.Millisecond, callback: 3 * time.Millisecond})
+	s := r.stats(now)
+	if got, want := s.Events, 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := s.Late, 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := s.MeanJitter, 6*time.Millisecond; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := s.MaxJitter, 10*time.Millisecond; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := s.MeanCallback, 2*time.Millisecond; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+type failingEvent struct{}
+
+func (e failingEvent) NoteChangesDo(block func(NoteChange)) {}
+func (e failingEvent) Handle(t *Timeline, w time.Time)      { panic("failed") }
+
+func TestFailingEventDoesNotStopTimeline(t *testing.T) {
+	tim := NewTimeline()
+	go tim.Play()
+	defer tim.Reset()
+	next := handledEvent{handled: make(chan time.Time, 1)}
+	now := time.Now()
+	tim.Schedule(failingEvent{}, now.Add(10*time.Millisecond))
+	tim.Schedule(next, now.Add(20*time.Millisecond))
+	select {
+	case <-next.handled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("event after failing event not handled")
+	}
+}
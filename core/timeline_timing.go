@@ -0,0 +1,91 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// timingWindow is the period over which timing samples are kept.
+	timingWindow = time.Minute
+	// lateThreshold is the delay after which an event is considered late.
+	lateThreshold = 5 * time.Millisecond
+	// maxTimingSamples bounds the memory of the recorder ; the oldest samples are dropped first.
+	maxTimingSamples = 10000
+)
+
+// TimingStats summarizes how well the events of a Timeline were handled in time.
+type TimingStats struct {
+	Events       int           // number of handled events in the window
+	Late         int           // number of events handled later than the threshold
+	MeanJitter   time.Duration // mean delay between the scheduled and the actual time
+	MaxJitter    time.Duration // maximum delay between the scheduled and the actual time
+	MeanCallback time.Duration // mean duration of handling an event
+	QueueDepth   int64         // number of events currently scheduled
+}
+
+type timingSample struct {
+	handledAt time.Time
+	delay     time.Duration
+	callback  time.Duration
+}
+
+// timingRecorder keeps the timing samples of the last window.
+type timingRecorder struct {
+	mutex   sync.Mutex
+	samples []timingSample
+}
+
+func (r *timingRecorder) record(s timingSample) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.prune(s.handledAt)
+	if len(r.samples) == maxTimingSamples {
+		// drop the oldest quarter such that this does not happen for each sample
+		r.samples = append(r.samples[:0], r.samples[maxTimingSamples/4:]...)
+	}
+	r.samples = append(r.samples, s)
+}
+
+// prune removes the samples that are outside the window ; requires lock
+func (r *timingRecorder) prune(now time.Time) {
+	from := now.Add(-timingWindow)
+	i := 0
+	for i < len(r.samples) && r.samples[i].handledAt.Before(from) {
+		i++
+	}
+	if i > 0 {
+		r.samples = append(r.samples[:0], r.samples[i:]...)
+	}
+}
+
+func (r *timingRecorder) stats(now time.Time) (s TimingStats) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.prune(now)
+	s.Events = len(r.samples)
+	if s.Events == 0 {
+		return
+	}
+	var delays, callbacks time.Duration
+	for _, each := range r.samples {
+		delays += each.delay
+		callbacks += each.callback
+		if each.delay > s.MaxJitter {
+			s.MaxJitter = each.delay
+		}
+		if each.delay > lateThreshold {
+			s.Late++
+		}
+	}
+	s.MeanJitter = delays / time.Duration(s.Events)
+	s.MeanCallback = callbacks / time.Duration(s.Events)
+	return
+}
+
+// Timing returns the timing statistics of the events handled in the last minute.
+func (t *Timeline) Timing() TimingStats {
+	s := t.timing.stats(time.Now())
+	s.QueueDepth = t.Len()
+	return s
+}
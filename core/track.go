@@ -3,35 +3,146 @@ package core
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/emicklei/melrose/notify"
 )
 
 type Track struct {
-	Title   string
-	Channel int
-	Content map[int]Sequenceable // bar -> musical object
+	Title    string
+	Channel  int
+	DeviceID int                  // -1 means the default output device
+	Tempo    float64              // multiplier of the BPM of the control
+	Content  map[int]Sequenceable // bar -> musical object, in bars of the track
+	Keys     map[int]string       // bar -> scale notation of the key from that bar on
+	Volume   HasValue             // sent as CC 7 when the track starts playing ; nil means not sent
+	Pan      HasValue             // sent as CC 10 when the track starts playing, 64 is center ; nil means not sent
 }
 
+// MIDI control change numbers for mixing
+const (
+	volumeControl = 7
+	panControl    = 10
+)
+
 func NewTrack(title string, channel int) *Track {
 	return &Track{
-		Title:   title,
-		Channel: channel,
-		Content: map[int]Sequenceable{},
+		Title:    title,
+		Channel:  channel,
+		DeviceID: -1,
+		Tempo:    1,
+		Content:  map[int]Sequenceable{},
+		Keys:     map[int]string{},
+	}
+}
+
+// WithDevice returns a copy of the track that plays on the output device with the given ID.
+func (t *Track) WithDevice(deviceID int) *Track {
+	c := NewTrack(t.Title, t.Channel)
+	c.DeviceID = deviceID
+	c.Tempo = t.Tempo
+	c.Volume = t.Volume
+	c.Pan = t.Pan
+	for k, v := range t.Content {
+		c.Content[k] = v
 	}
+	for k, v := range t.Keys {
+		c.Keys[k] = v
+	}
+	return c
+}
+
+// WithTempo returns a copy of the track that plays at the BPM of the control multiplied by a factor.
+func (t *Track) WithTempo(factor float64) *Track {
+	c := t.WithDevice(t.DeviceID)
+	c.Tempo = factor
+	return c
+}
+
+// WithVolume returns a copy of the track that sets the volume of its channel, [0..127], when it starts playing.
+func (t *Track) WithVolume(volume HasValue) *Track {
+	c := t.WithDevice(t.DeviceID)
+	c.Volume = volume
+	return c
+}
+
+// WithPan returns a copy of the track that sets the pan of its channel, [0..127], when it starts playing.
+func (t *Track) WithPan(pan HasValue) *Track {
+	c := t.WithDevice(t.DeviceID)
+	c.Pan = pan
+	return c
+}
+
+// sendMixing sends the volume and pan, if set, as control changes on the channel of the track.
+// The values are taken each time such that they can be automated, e.g. by a knob or an interval.
+func (t *Track) sendMixing(ctx Context) {
+	for _, each := range []struct {
+		number int
+		value  HasValue
+	}{{volumeControl, t.Volume}, {panControl, t.Pan}} {
+		if each.value == nil {
+			continue
+		}
+		v := Int(each.value)
+		if v < 0 {
+			v = 0
+		}
+		if v > 127 {
+			v = 127
+		}
+		if err := ctx.Device().HandleSetting("midi.out.cc", []interface{}{t.DeviceID, t.Channel, each.number, v}); err != nil {
+			notify.Errorf("track %s cannot send control change %d: %v", t.Title, each.number, err)
+		}
+	}
+}
+
+// KeyAt returns the scale notation of the key that is active at a bar ; empty if the key was not changed.
+func (t *Track) KeyAt(bar int) string {
+	key, from := "", 0
+	for k, v := range t.Keys {
+		if k <= bar && k > from {
+			key, from = v, k
+		}
+	}
+	return key
+}
+
+// at returns the context for the music that starts at a bar of the track.
+func (t *Track) at(ctx Context, bar, biab int) Context {
+	return WithPosition(ctx, Position{Bar: int64(bar - 1), BIAB: biab, Key: t.KeyAt(bar)})
+}
+
+// SequenceAt returns the notes of the musical object that starts at a bar, in the key that is active.
+func (t *Track) SequenceAt(bar, biab int) Sequence {
+	seq, ok := t.Content[bar]
+	if !ok {
+		return EmptySequence
+	}
+	return Positioned(t.at(PlayContext{LoopControl: NoLooper}, bar, biab), seq).S()
+}
+
+// selector returns the musical object wrapped with the channel and, if set, the device of this track.
+func (t *Track) selector(seq Sequenceable) Sequenceable {
+	cs := NewChannelSelector(seq, On(t.Channel))
+	if t.DeviceID == -1 {
+		return cs
+	}
+	return NewDeviceSelector(cs, On(t.DeviceID))
 }
 
 func (t *Track) Play(ctx Context, now time.Time) error {
-	bpm := ctx.Control().BPM()
+	t.sendMixing(ctx)
+	bpm := ctx.Control().BPM() * t.Tempo
 	biab := ctx.Control().BIAB()
 	whole := WholeNoteDuration(bpm)
 	for bars, each := range t.Content {
-		cs := NewChannelSelector(each, On(t.Channel))
+		at := t.at(ctx, bars, biab)
+		cs := Transposed(ctx.Control(), Positioned(at, t.selector(each)))
 		offset := int64((bars-1)*biab) * whole.Nanoseconds() / 4
 		when := now.Add(time.Duration(time.Duration(offset)))
 		if IsDebug() {
-			notify.Debugf("core.track title=%s channel=%d bar=%d, biab=%d, bpm=%.2f time=%s", t.Title, t.Channel, bars, biab, bpm, when.Format("04:05.000"))
+			notify.Debugf("core.track title=%s device=%d channel=%d bar=%d, biab=%d, bpm=%.2f time=%s", t.Title, t.DeviceID, t.Channel, bars, biab, bpm, when.Format("04:05.000"))
 		}
 		ctx.Device().Play(NoCondition, cs, bpm, when)
 	}
@@ -40,6 +151,18 @@ func (t *Track) Play(ctx Context, now time.Time) error {
 
 func (t *Track) Inspect(i Inspection) {
 	i.Properties["channel"] = t.Channel
+	if t.DeviceID != -1 {
+		i.Properties["device"] = t.DeviceID
+	}
+	if t.Tempo != 1 {
+		i.Properties["tempo"] = t.Tempo
+	}
+	if t.Volume != nil {
+		i.Properties["volume"] = Int(t.Volume)
+	}
+	if t.Pan != nil {
+		i.Properties["pan"] = Int(t.Pan)
+	}
 	i.Properties["pieces"] = len(t.Content)
 }
 
@@ -49,6 +172,11 @@ func (t *Track) Add(seq SequenceOnTrack) {
 	t.Content[b] = seq.Target
 }
 
+// AddKeyChange adds a KeyChange
+func (t *Track) AddKeyChange(k KeyChange) {
+	t.Keys[Int(k.Bar)] = String(k.Key)
+}
+
 // Storex implements Storable
 func (t *Track) Storex() string {
 	var buf bytes.Buffer
@@ -58,8 +186,25 @@ func (t *Track) Storex() string {
 		sont := NewSequenceOnTrack(On(k), v) // TODO
 		fmt.Fprint(&buf, sont.Storex())
 	}
+	for k, v := range t.Keys {
+		fmt.Fprint(&buf, ",")
+		fmt.Fprint(&buf, NewKeyChange(On(k), On(v)).Storex())
+	}
 	fmt.Fprintf(&buf, ")")
-	return buf.String()
+	s := buf.String()
+	if t.DeviceID != -1 {
+		s = fmt.Sprintf("device(%d,%s)", t.DeviceID, s)
+	}
+	if t.Pan != nil {
+		s = fmt.Sprintf("pan(%s,%s)", Storex(t.Pan), s)
+	}
+	if t.Volume != nil {
+		s = fmt.Sprintf("volume(%s,%s)", Storex(t.Volume), s)
+	}
+	if t.Tempo != 1 {
+		s = fmt.Sprintf("tempo(%v,%s)", t.Tempo, s)
+	}
+	return s
 }
 
 type SequenceOnTrack struct {
@@ -113,9 +258,21 @@ func (m MultiTrack) Play(ctx Context, at time.Time) error {
 	ctx.Control().Stop()
 	for _, each := range m.Tracks {
 		if track, ok := each.Value().(*Track); ok {
+			track.sendMixing(ctx)
 			for bar, seq := range track.Content {
-				ch := NewChannelSelector(seq, On(track.Channel))
-				ctx.Control().Plan(int64(bar-1), ch)
+				if len(track.Keys) > 0 {
+					seq = Positioned(track.at(ctx, bar, ctx.Control().BIAB()), seq)
+				}
+				if track.Tempo == 1 {
+					ctx.Control().Plan(int64(bar-1), track.selector(seq))
+					continue
+				}
+				// bars of the track are shorter or longer than those of the control
+				beats := int64(math.Round(float64((bar-1)*ctx.Control().BIAB()) / track.Tempo))
+				part := Transposed(ctx.Control(), track.selector(seq))
+				ctx.Control().Schedule(beats, func(when time.Time) {
+					ctx.Device().Play(NoCondition, part, ctx.Control().BPM()*track.Tempo, when)
+				})
 			}
 		} else {
 			// TODO
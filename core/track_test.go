@@ -1,6 +1,7 @@
 package core
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -12,3 +13,78 @@ func TestTrack_Add(t *testing.T) {
 		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
 	}
 }
+
+func TestTrack_WithDevice(t *testing.T) {
+	tr := NewTrack("test", 2)
+	tr.Add(NewSequenceOnTrack(On(1), MustParseSequence("C")))
+	dt := tr.WithDevice(3)
+	if got, want := Storex(dt), "device(3,track('test',2,onbar(1,sequence('C'))))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := tr.DeviceID, -1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	sel, ok := dt.selector(MustParseSequence("C")).(DeviceSelector)
+	if !ok {
+		t.Fatal("device selector expected")
+	}
+	if got, want := sel.DeviceID(), 3; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestTrack_WithTempo(t *testing.T) {
+	tr := NewTrack("bass", 2)
+	tr.Add(NewSequenceOnTrack(On(1), MustParseSequence("c")))
+	half := tr.WithTempo(0.5)
+	if got, want := tr.Tempo, 1.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := half.Storex(), "tempo(0.5,track('bass',2,onbar(1,sequence('C'))))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestTrack_KeyChange(t *testing.T) {
+	tr := NewTrack("chords", 1)
+	p := NewChordProgression(On("c"), On("I"))
+	tr.Add(NewSequenceOnTrack(On(1), p))
+	tr.Add(NewSequenceOnTrack(On(3), p))
+	tr.AddKeyChange(NewKeyChange(On(3), On("a/m")))
+	if got, want := tr.KeyAt(2), ""; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := tr.KeyAt(4), "a/m"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := tr.SequenceAt(1, 4).Storex(), "sequence('(C E G)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// the tonic moves down to the nearest A
+	if got, want := tr.SequenceAt(3, 4).Storex(), "sequence('(A3 C E)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestTrack_VolumeAndPan(t *testing.T) {
+	tr := NewTrack("bass", 2).WithVolume(On(100)).WithPan(On(200))
+	if got, want := tr.Storex(), "volume(100,pan(200,track('bass',2)))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	d := new(settingDevice)
+	tr.sendMixing(PlayContext{AudioDevice: d})
+	if got, want := fmt.Sprint(d.settings), "[[-1 2 7 100] [-1 2 10 127]]"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+// settingDevice records the values of settings.
+type settingDevice struct {
+	sequencingDevice
+	settings [][]interface{}
+}
+
+func (d *settingDevice) HandleSetting(name string, values []interface{}) error {
+	d.settings = append(d.settings, values)
+	return nil
+}
@@ -0,0 +1,44 @@
+package core
+
+// drumChannel is the MIDI channel of percussion ; its notes are not pitches and are never transposed.
+const drumChannel = 10
+
+// Transposed returns the musical object with the semitones of the control added to its notes, except for drums.
+// Device and channel selectors are kept around the result such that a device can detect them.
+func Transposed(control LoopController, s Sequenceable) Sequenceable {
+	semitones := control.Transpose()
+	if semitones == 0 {
+		return s
+	}
+	return transposedBy(semitones, s)
+}
+
+func transposedBy(semitones int, s Sequenceable) Sequenceable {
+	switch v := s.(type) {
+	case DeviceSelector:
+		return NewDeviceSelector(transposedBy(semitones, v.Target), v.ID)
+	case ChannelSelector:
+		if v.Channel() == drumChannel {
+			return s
+		}
+		return NewChannelSelector(transposedBy(semitones, v.Target), v.Number)
+	case HasValue:
+		// e.g. a variable, its value can have selectors
+		if seq, ok := v.Value().(Sequenceable); ok {
+			return transposedBy(semitones, seq)
+		}
+	}
+	return transposed{target: s, semitones: semitones}
+}
+
+// transposed adds semitones to the notes of a musical object when asked for its sequence.
+type transposed struct {
+	target    Sequenceable
+	semitones int
+}
+
+// S is part of Sequenceable
+func (t transposed) S() Sequence { return t.target.S().Pitched(t.semitones) }
+
+// Storex is part of Storable
+func (t transposed) Storex() string { return Storex(t.target) }
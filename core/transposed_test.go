@@ -0,0 +1,55 @@
+package core
+
+import "testing"
+
+func TestTransposed(t *testing.T) {
+	looper := &TestLooper{Biab: 4}
+	s := MustParseSequence("c e")
+	if got := Transposed(looper, s); got.S().Storex() != s.Storex() {
+		t.Errorf("got [%v] want [%v]", got.S().Storex(), s.Storex())
+	}
+	looper.SetTranspose(2)
+	if got, want := Transposed(looper, s).S().Storex(), "sequence('D G_')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	sel := Transposed(looper, NewDeviceSelector(NewChannelSelector(s, On(1)), On(2)))
+	ds, ok := sel.(DeviceSelector)
+	if !ok {
+		t.Fatalf("device selector expected, got %T", sel)
+	}
+	if got, want := ds.Unwrap().S().Notes[0][0].MIDI(), 62; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	drums := NewChannelSelector(s, On(10))
+	if got, want := Transposed(looper, drums).S().Notes[0][0].MIDI(), 60; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+// sequenceVariable is a HasValue that is also Sequenceable, like a variable of a script.
+type sequenceVariable struct {
+	value Sequenceable
+}
+
+func (v sequenceVariable) Value() interface{} { return v.value }
+func (v sequenceVariable) S() Sequence        { return v.value.S() }
+
+func TestTransposed_Variable(t *testing.T) {
+	looper := &TestLooper{Biab: 4}
+	looper.SetTranspose(2)
+	drums := sequenceVariable{value: NewChannelSelector(MustParseSequence("c e"), On(10))}
+	sel, ok := Transposed(looper, drums).(ChannelSelector)
+	if !ok {
+		t.Fatalf("channel selector expected, got %T", Transposed(looper, drums))
+	}
+	if got, want := sel.Channel(), 10; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := sel.S().Notes[0][0].MIDI(), 60; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	melody := sequenceVariable{value: MustParseSequence("c e")}
+	if got, want := Transposed(looper, melody).S().Notes[0][0].MIDI(), 62; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -74,6 +74,18 @@ func Storex(v interface{}) string {
 	if s, ok := v.(string); ok {
 		return fmt.Sprintf("'%s'", s)
 	}
+	if list, ok := v.([]interface{}); ok {
+		var b bytes.Buffer
+		io.WriteString(&b, "[")
+		for i, each := range list {
+			if i > 0 {
+				io.WriteString(&b, ",")
+			}
+			io.WriteString(&b, Storex(each))
+		}
+		io.WriteString(&b, "]")
+		return b.String()
+	}
 	return fmt.Sprintf("%v", v)
 }
 
@@ -50,3 +50,9 @@ func Test_parseIndices(t *testing.T) {
 		})
 	}
 }
+
+func TestStorexList(t *testing.T) {
+	if got, want := Storex([]interface{}{"c", 1, On(2)}), "['c',1,2]"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
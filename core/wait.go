@@ -0,0 +1,26 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// Wait blocks the evaluation of a script for a number of beats, at the current BPM.
+type Wait struct {
+	Beats HasValue
+}
+
+// Evaluate is part of Evaluatable
+func (w Wait) Evaluate(ctx Context) error {
+	beats := Float(w.Beats)
+	if beats < 0 {
+		return fmt.Errorf("number of beats to wait must be positive, got %v", beats)
+	}
+	time.Sleep(time.Duration(float64(beats) * float64(beatTickerDuration(ctx.Control().BPM()))))
+	return nil
+}
+
+// Storex is part of Storable
+func (w Wait) Storex() string {
+	return fmt.Sprintf("wait(%s)", Storex(w.Beats))
+}
@@ -0,0 +1,69 @@
+package dsl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/parser"
+)
+
+// NotAllowedError is returned when an expression uses functions that are not allowed by the evaluator.
+type NotAllowedError struct {
+	Functions []string // sorted
+}
+
+func (e *NotAllowedError) Error() string {
+	return fmt.Sprintf("functions not allowed:%s", strings.Join(e.Functions, ","))
+}
+
+// Allow restricts the functions that expressions can use, e.g. when remote controlled.
+// No names means all functions are allowed.
+func (e *Evaluator) Allow(names []string) {
+	e.allowed = map[string]bool{}
+	for _, each := range names {
+		e.allowed[each] = true
+	}
+}
+
+func (e *Evaluator) isAllowed(name string) bool {
+	return len(e.allowed) == 0 || e.allowed[name]
+}
+
+// checkAllowed returns a NotAllowedError if the expression refers to functions that are not allowed.
+// The expression must have its pipes rewritten ; any reference counts, not only a call, e.g. map(play,list).
+func (e *Evaluator) checkAllowed(entry string) error {
+	if len(e.allowed) == 0 {
+		return nil
+	}
+	tree, err := parser.Parse(entry)
+	if err != nil {
+		return nil // reported by compile
+	}
+	v := &functionVisitor{evaluator: e, seen: map[string]bool{}}
+	ast.Walk(&tree.Node, v)
+	if len(v.denied) == 0 {
+		return nil
+	}
+	sort.Strings(v.denied)
+	return &NotAllowedError{Functions: v.denied}
+}
+
+// functionVisitor collects the names of functions that are not allowed.
+type functionVisitor struct {
+	evaluator *Evaluator
+	seen      map[string]bool
+	denied    []string
+}
+
+func (v *functionVisitor) Visit(node *ast.Node) {
+	id, ok := (*node).(*ast.IdentifierNode)
+	if !ok || v.seen[id.Value] {
+		return
+	}
+	if _, ok := v.evaluator.funcs[id.Value]; ok && !v.evaluator.isAllowed(id.Value) {
+		v.seen[id.Value] = true
+		v.denied = append(v.denied, id.Value)
+	}
+}
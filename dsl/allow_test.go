@@ -0,0 +1,33 @@
+package dsl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEvaluator_Allow(t *testing.T) {
+	e := newTestEvaluator()
+	e.Allow([]string{"sequence", "map", "reverse"})
+	for source, want := range map[string]string{
+		"play(sequence('c'))":               "play",
+		"sequence('c') |> play":             "play",
+		"sequence('c') |> reverse |> play":  "play",
+		"map(play,[sequence('c')])":         "play",
+		"map('play(_)',[sequence('c')])":    "play",
+		"export('x',note('c'))":             "export,note",
+		"s = sequence('c') |> midi_send(1)": "midi_send",
+	} {
+		_, err := e.EvaluateProgram(source)
+		var denied *NotAllowedError
+		if !errors.As(err, &denied) {
+			t.Errorf("%s: not allowed error expected, got %v", source, err)
+			continue
+		}
+		if got := strings.Join(denied.Functions, ","); got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", source, got, got, want, want)
+		}
+	}
+	_, err := e.EvaluateProgram("map(reverse,[sequence('c d')])")
+	checkError(t, err)
+}
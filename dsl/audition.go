@@ -0,0 +1,66 @@
+package dsl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// AuditionChannel is the MIDI channel on which an expression is auditioned.
+var AuditionChannel = 16
+
+// auditionVelocityFactor reduces the velocity of auditioned notes.
+const auditionVelocityFactor = 0.6
+
+// Audition evaluates an expression and plays its notes once on the AuditionChannel at a reduced velocity.
+// No variables are assigned and running loops are not stopped.
+func (e *Evaluator) Audition(entry string) (core.Sequenceable, error) {
+	if _, _, ok := IsAssignment(entry); ok {
+		return nil, fmt.Errorf("cannot audition an assignment, only an expression")
+	}
+	r, err := e.EvaluateExpression(withoutTrailingComment(entry))
+	if err != nil {
+		return nil, err
+	}
+	s, ok := getSequenceable(getValue(r))
+	if !ok {
+		return nil, fmt.Errorf("cannot audition (%T) %v", r, r)
+	}
+	// any channel or device of the expression is replaced by those for auditioning
+	for {
+		if sel, ok := s.(core.ChannelSelector); ok {
+			s = sel.Unwrap()
+			continue
+		}
+		if sel, ok := s.(core.DeviceSelector); ok {
+			s = sel.Unwrap()
+			continue
+		}
+		break
+	}
+	quiet := auditioned(s.S())
+	e.context.Device().Play(
+		core.NoCondition,
+		core.Transposed(e.context.Control(), core.NewChannelSelector(quiet, core.On(AuditionChannel))),
+		e.context.Control().BPM(),
+		time.Now())
+	return quiet, nil
+}
+
+// auditioned returns the sequence with all velocities reduced.
+func auditioned(s core.Sequence) core.Sequence {
+	groups := [][]core.Note{}
+	for _, group := range s.Notes {
+		quiet := []core.Note{}
+		for _, each := range group {
+			v := int(float32(each.Velocity) * auditionVelocityFactor)
+			if v < 1 {
+				v = 1
+			}
+			quiet = append(quiet, each.WithVelocity(v))
+		}
+		groups = append(groups, quiet)
+	}
+	return core.Sequence{Notes: groups}
+}
@@ -19,6 +19,7 @@ func TestAdd_Value(t *testing.T) {
 	}{
 		{"1+2", fields{1, 2}, 3},
 		{"1.0+2.0", fields{1.0, 2.0}, 3.0},
+		{"1+0.5", fields{1, 0.5}, 1.5},
 		{"1+[2]", fields{1, core.On(2)}, 3},
 		{"[1]+[2]", fields{core.On(1), core.On(2)}, 3},
 		{"[[1]]+[2]", fields{core.ValueHolder{Any: core.On(1)}, core.On(2)}, 3},
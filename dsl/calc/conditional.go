@@ -0,0 +1,25 @@
+package calc
+
+import (
+	"fmt"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// Conditional is the value of Then if the Condition is true, the value of Else otherwise.
+type Conditional struct {
+	Condition core.HasValue
+	Then      interface{}
+	Else      interface{}
+}
+
+func (c Conditional) Storex() string {
+	return fmt.Sprintf("if(%s,%s,%s)", core.Storex(c.Condition), core.Storex(c.Then), core.Storex(c.Else))
+}
+
+func (c Conditional) Value() interface{} {
+	if b, ok := core.ValueOf(c.Condition).(bool); ok && b {
+		return core.ValueOf(c.Then)
+	}
+	return core.ValueOf(c.Else)
+}
@@ -0,0 +1,18 @@
+package calc
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestConditional_Value(t *testing.T) {
+	c := Conditional{Condition: NumberCompare{Left: core.On(4), Right: 3, Operator: ">"}, Then: 120, Else: core.On(90)}
+	if got, want := c.Value(), 120; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	c.Condition = NumberCompare{Left: core.On(2), Right: 3, Operator: ">"}
+	if got, want := c.Value(), 90; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,48 @@
+package calc
+
+import (
+	"fmt"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// Divide is an integer division if both operands are integers, a float division otherwise.
+// Dividing by zero results in zero.
+type Divide struct {
+	Left  interface{}
+	Right interface{}
+}
+
+func (d Divide) Storex() string {
+	return fmt.Sprintf("%s / %s", core.Storex(d.Left), core.Storex(d.Right))
+}
+
+func (d Divide) Value() interface{} {
+	l, lok := resolveInt(d.Left)
+	r, rok := resolveInt(d.Right)
+	if !lok || !rok {
+		// try floats
+		if f, ok := d.floatValue(); ok {
+			return f
+		}
+	}
+	if r == 0 {
+		return 0
+	}
+	return l / r
+}
+
+func (d Divide) floatValue() (float64, bool) {
+	l, ok := resolveFloat(d.Left)
+	if !ok {
+		return 0.0, false
+	}
+	r, ok := resolveFloat(d.Right)
+	if !ok {
+		return 0.0, false
+	}
+	if r == 0 {
+		return 0.0, true
+	}
+	return l / r, true
+}
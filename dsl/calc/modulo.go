@@ -0,0 +1,48 @@
+package calc
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// Modulo is the remainder of a division ; it is zero if the divisor is zero.
+type Modulo struct {
+	Left  interface{}
+	Right interface{}
+}
+
+func (m Modulo) Storex() string {
+	return fmt.Sprintf("%s %% %s", core.Storex(m.Left), core.Storex(m.Right))
+}
+
+func (m Modulo) Value() interface{} {
+	l, lok := resolveInt(m.Left)
+	r, rok := resolveInt(m.Right)
+	if !lok || !rok {
+		// try floats
+		if f, ok := m.floatValue(); ok {
+			return f
+		}
+	}
+	if r == 0 {
+		return 0
+	}
+	return l % r
+}
+
+func (m Modulo) floatValue() (float64, bool) {
+	l, ok := resolveFloat(m.Left)
+	if !ok {
+		return 0.0, false
+	}
+	r, ok := resolveFloat(m.Right)
+	if !ok {
+		return 0.0, false
+	}
+	if r == 0 {
+		return 0.0, true
+	}
+	return math.Mod(l, r), true
+}
@@ -0,0 +1,37 @@
+package calc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestModulo_Value(t *testing.T) {
+	type fields struct {
+		Left  interface{}
+		Right interface{}
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   interface{}
+	}{
+		{"7%2", fields{7, 2}, 1},
+		{"7.5%2", fields{7.5, 2}, 1.5},
+		{"7%0", fields{7, 0}, 0},
+		{"[8]%[3]", fields{core.On(8), core.On(3)}, 2},
+		{"[[8]]%[3]", fields{core.ValueHolder{Any: core.On(8)}, core.On(3)}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Modulo{
+				Left:  tt.fields.Left,
+				Right: tt.fields.Right,
+			}
+			if got := a.Value(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Modulo.Value() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
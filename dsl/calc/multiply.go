@@ -16,18 +16,13 @@ func (m Multiply) Storex() string {
 }
 
 func (m Multiply) Value() interface{} {
-	l, ok := resolveInt(m.Left)
-	if !ok {
+	l, lok := resolveInt(m.Left)
+	r, rok := resolveInt(m.Right)
+	if !lok || !rok {
 		// try floats
-		f, ok := m.floatValue()
-		if ok {
+		if f, ok := m.floatValue(); ok {
 			return f
 		}
-		l = 0
-	}
-	r, ok := resolveInt(m.Right)
-	if !ok {
-		r = 0
 	}
 	return l * r
 }
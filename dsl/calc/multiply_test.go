@@ -19,6 +19,7 @@ func TestMultiply_Value(t *testing.T) {
 	}{
 		{"3*2", fields{3, 2}, 6},
 		{"3.0*2.0", fields{3.0, 2.0}, 6.0},
+		{"3*0.5", fields{3, 0.5}, 1.5},
 		{"3*[2]", fields{3, core.On(2)}, 6},
 		{"[3]*[2]", fields{core.On(3), core.On(2)}, 6},
 		{"[[3]]*[2]", fields{core.ValueHolder{Any: core.On(3)}, core.On(2)}, 6},
@@ -17,18 +17,13 @@ func (a NumberCompare) Storex() string {
 }
 
 func (a NumberCompare) Value() interface{} {
-	l, ok := resolveInt(a.Left)
-	if !ok {
+	l, lok := resolveInt(a.Left)
+	r, rok := resolveInt(a.Right)
+	if !lok || !rok {
 		// try floats
-		f, ok := a.floatValue()
-		if ok {
+		if f, ok := a.floatValue(); ok {
 			return f
 		}
-		l = 0
-	}
-	r, ok := resolveInt(a.Right)
-	if !ok {
-		r = 0
 	}
 	switch a.Operator {
 	case "<":
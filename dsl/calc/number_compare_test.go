@@ -21,7 +21,7 @@ func TestCompare_Value(t *testing.T) {
 		{"1<2", fields{1, 2, "<"}, true},
 		{"1.0>2.0", fields{1.0, 2.0, ">"}, false},
 		{"3.0==3.0", fields{3.0, 3.0, "=="}, true},
-		//{"3.0==3", fields{3.0, 3, "=="}, true},
+		{"3.0==3", fields{3.0, 3, "=="}, true},
 		{"1<=[2]", fields{1, core.On(2), "<="}, true},
 		{"[1]>=[2]", fields{core.On(1), core.On(2), ">="}, false},
 		{"[[1]]!=[2]", fields{core.ValueHolder{Any: core.On(1)}, core.On(2), "!="}, true},
@@ -16,18 +16,13 @@ func (s Sub) Storex() string {
 }
 
 func (s Sub) Value() interface{} {
-	l, ok := resolveInt(s.Left)
-	if !ok {
+	l, lok := resolveInt(s.Left)
+	r, rok := resolveInt(s.Right)
+	if !lok || !rok {
 		// try floats
-		f, ok := s.floatValue()
-		if ok {
+		if f, ok := s.floatValue(); ok {
 			return f
 		}
-		l = 0
-	}
-	r, ok := resolveInt(s.Right)
-	if !ok {
-		r = 0
 	}
 	return l - r
 }
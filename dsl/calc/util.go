@@ -12,10 +12,14 @@ func resolveInt(v interface{}) (int, bool) {
 	return 0, false
 }
 
+// resolveFloat also accepts an int such that ints and floats can be combined.
 func resolveFloat(v interface{}) (float64, bool) {
 	if i, ok := v.(float64); ok {
 		return i, true
 	}
+	if i, ok := v.(int); ok {
+		return float64(i), true
+	}
 	if v, ok := v.(core.HasValue); ok {
 		return resolveFloat(v.Value())
 	}
@@ -0,0 +1,150 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/file"
+	"github.com/expr-lang/expr/parser"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// Problem is a syntax or compile error of a statement in a program.
+type Problem struct {
+	Line    int // zero-based
+	Column  int // zero-based
+	Message string
+}
+
+// CheckProgram compiles each statement of a program without evaluating it and returns all problems found.
+// Variables assigned earlier in the program are known to the statements that follow.
+func (e *Evaluator) CheckProgram(source string) (list []Problem) {
+	assigned := map[string]interface{}{}
+	lines := strings.Split(source, "\n")
+	for lineNr := 0; lineNr < len(lines); lineNr++ {
+		each := lines[lineNr]
+		if strings.HasPrefix(each, "\t") || strings.HasPrefix(each, fourSpaces) {
+			list = append(list, Problem{Line: lineNr, Message: "syntax error, line with TAB must be part of expression"})
+			continue
+		}
+		// collect continuation lines
+		entry := withoutTrailingComment(each)
+		start := lineNr
+		for lineNr+1 < len(lines) && (strings.HasPrefix(lines[lineNr+1], "\t") || strings.HasPrefix(lines[lineNr+1], fourSpaces)) {
+			lineNr++
+			entry += " " + withoutTrailingComment(lines[lineNr])
+		}
+		entry = strings.Replace(entry, "\t", " ", -1)
+		if len(strings.TrimSpace(entry)) == 0 {
+			continue
+		}
+		expression := entry
+		if varName, right, ok := IsAssignment(entry); ok {
+			if _, conflict := e.funcs[varName]; conflict {
+				list = append(list, Problem{Line: start, Message: fmt.Sprintf("cannot use variable [%s] because it is a defined function", varName)})
+				continue
+			}
+			assigned[varName] = variable{Name: varName, store: e.context.Variables()}
+			expression = right
+		}
+		if err := e.check(expression, assigned); err != nil {
+			p := Problem{Line: start, Message: err.Error()}
+			var ferr *file.Error
+			if errors.As(err, &ferr) {
+				p.Message = ferr.Message
+				// only a position on the first line of a statement can be mapped on the source
+				if ferr.Line <= 1 {
+					p.Column = strings.Index(each, strings.TrimSpace(expression)) + ferr.Column
+				}
+			}
+			if p.Column < 0 {
+				p.Column = 0
+			}
+			list = append(list, p)
+		}
+	}
+	return
+}
+
+// check returns the compile or notation error of an expression that is not a sequence or chord either.
+func (e *Evaluator) check(expression string, assigned map[string]interface{}) error {
+	_, _, err := e.compile(expression, assigned)
+	if err == nil {
+		return e.checkNotations(expression)
+	}
+	entry := strings.TrimSpace(expression)
+	if strings.Contains(entry, "/") {
+		if _, suberr := core.ParseChord(entry); suberr == nil {
+			return nil
+		}
+	}
+	if _, suberr := core.ParseSequence(entry); suberr == nil {
+		return nil
+	}
+	return err
+}
+
+// notationParsers are the functions with a notation string argument that can be checked before evaluation.
+var notationParsers = map[string]func(string) error{
+	"sequence":      func(s string) error { _, err := core.ParseSequence(s); return err },
+	"note":          func(s string) error { _, err := core.ParseNote(s); return err },
+	"chord":         func(s string) error { _, err := core.ParseChord(s); return err },
+	"scale":         func(s string) error { _, err := core.NewScale(s); return err },
+	"chordsequence": func(s string) error { _, err := core.ParseChordSequence(s); return err },
+}
+
+// checkNotations returns the error of the first notation string argument that cannot be parsed.
+func (e *Evaluator) checkNotations(expression string) error {
+	rewritten, err := rewritePipes(expression)
+	if err != nil {
+		return err
+	}
+	tree, err := parser.Parse(rewritten)
+	if err != nil {
+		return nil // reported by compile
+	}
+	v := &notationVisitor{context: e.context}
+	ast.Walk(&tree.Node, v)
+	if v.err != nil {
+		return v.err.Bind(file.NewSource(rewritten))
+	}
+	return nil
+}
+
+// notationVisitor collects the first error of a notation string argument.
+type notationVisitor struct {
+	context core.Context
+	err     *file.Error
+}
+
+func (v *notationVisitor) Visit(node *ast.Node) {
+	if v.err != nil {
+		return
+	}
+	call, ok := (*node).(*ast.CallNode)
+	if !ok || len(call.Arguments) != 1 {
+		return
+	}
+	callee, ok := call.Callee.(*ast.IdentifierNode)
+	if !ok {
+		return
+	}
+	parse, ok := notationParsers[callee.Value]
+	if !ok {
+		return
+	}
+	arg, ok := call.Arguments[0].(*ast.StringNode)
+	if !ok {
+		return
+	}
+	notation, err := localNotation(v.context, arg.Value)
+	if err == nil {
+		err = parse(notation)
+	}
+	if err != nil {
+		v.err = &file.Error{Location: arg.Location(), Message: fmt.Sprintf("invalid %s notation: %v", callee.Value, err)}
+	}
+}
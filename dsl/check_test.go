@@ -0,0 +1,59 @@
+package dsl
+
+import "testing"
+
+func TestEvaluator_CheckProgram(t *testing.T) {
+	e := NewEvaluator(testContext())
+	src := `// melody
+m = sequence('c e g')
+s = repeat(2, m) // uses m
+
+x = sequence('c' +
+	)
+c e g
+unknown(1)`
+	got := e.CheckProgram(src)
+	if len(got) != 2 {
+		t.Fatalf("got [%v:%T] want [%v:%T]", len(got), got, 2, 2)
+	}
+	if got, want := got[0].Line, 4; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := got[1].Line, 7; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := got[1].Column, 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestEvaluator_CheckProgramFunctionAsVariable(t *testing.T) {
+	e := NewEvaluator(testContext())
+	if got, want := len(e.CheckProgram("note = note('c')")), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestEvaluator_CheckProgramNotation(t *testing.T) {
+	e := NewEvaluator(testContext())
+	got := e.CheckProgram("s = sequence('c d x')\nc = chord('e/m')\nn = note('c') + repeat(1,scale('k'))")
+	if len(got) != 2 {
+		t.Fatalf("got [%v:%T] want [%v:%T]", len(got), got, 2, 2)
+	}
+	if got, want := got[0].Line, 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := got[0].Column, 13; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := got[1].Line, 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestEvaluator_CheckProgramArity(t *testing.T) {
+	e := NewEvaluator(testContext())
+	if got, want := len(e.CheckProgram("n = note('c','d')")), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
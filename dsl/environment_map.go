@@ -16,6 +16,8 @@ func (envMap) exprOperators() []expr.Option {
 		expr.Operator("-", "Sub"),
 		expr.Operator("+", "Add"),
 		expr.Operator("*", "Multiply"),
+		expr.Operator("/", "Divide"),
+		expr.Operator("%", "Modulo"),
 		expr.Operator("<", "LessThan"),
 		expr.Operator("<=", "LessEqualThan"),
 		expr.Operator(">", "GreaterThan"),
@@ -36,6 +38,14 @@ func (envMap) Multiply(l, r interface{}) core.HasValue {
 	return calc.Multiply{Left: l, Right: r}
 }
 
+func (envMap) Divide(l, r interface{}) core.HasValue {
+	return calc.Divide{Left: l, Right: r}
+}
+
+func (envMap) Modulo(l, r interface{}) core.HasValue {
+	return calc.Modulo{Left: l, Right: r}
+}
+
 func (envMap) LessThan(l, r interface{}) core.HasValue {
 	return calc.NumberCompare{Left: l, Right: r, Operator: "<"}
 }
@@ -100,3 +110,22 @@ func (p *indexedAccessPatcher) Visit(node *ast.Node) {
 		//log.Printf("%T %v %v\n", node, ast.Dump(*node), methodName)
 	}
 }
+
+// unaryMinusPatcher exist to patch expressions which negate a variable, e.g. -i becomes 0 - i.
+type unaryMinusPatcher struct{}
+
+func (p *unaryMinusPatcher) Visit(node *ast.Node) {
+	n, ok := (*node).(*ast.UnaryNode)
+	if !ok || n.Operator != "-" {
+		return
+	}
+	in, ok := n.Node.(*ast.IdentifierNode)
+	if !ok || in.Type() != variableType {
+		return
+	}
+	ast.Patch(node, &ast.BinaryNode{
+		Operator: "-",
+		Left:     &ast.IntegerNode{Value: 0},
+		Right:    in,
+	})
+}
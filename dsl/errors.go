@@ -0,0 +1,74 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/expr-lang/expr/file"
+)
+
+// Error is an error of a statement in a script, with its location and source line.
+type Error struct {
+	File    string // empty if not known
+	Line    int    // one-based
+	Column  int    // one-based, zero if not known
+	Message string
+	Source  string // the line of the script
+	Err     error  // of the statement
+}
+
+// Error returns the location, the message and the source line with a caret below the column.
+//
+//	song.mel:3:5: unknown name foo
+//	 | x = foo(1)
+//	 | ....^
+func (e *Error) Error() string {
+	var b strings.Builder
+	if len(e.File) > 0 {
+		fmt.Fprintf(&b, "%s:", e.File)
+	}
+	fmt.Fprintf(&b, "%d", e.Line)
+	if e.Column > 0 {
+		fmt.Fprintf(&b, ":%d", e.Column)
+	}
+	fmt.Fprintf(&b, ": %s", e.Message)
+	if len(strings.TrimSpace(e.Source)) > 0 {
+		// a TAB is one column
+		fmt.Fprintf(&b, "\n | %s", strings.Replace(e.Source, "\t", " ", -1))
+		if e.Column > 0 {
+			fmt.Fprintf(&b, "\n | %s^", strings.Repeat(".", e.Column-1))
+		}
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// newError returns the error of a statement with the line and column in the source of a program.
+func newError(source string, st statement, err error) *Error {
+	e := &Error{Line: st.line + 1, Message: err.Error(), Err: err}
+	var ferr *file.Error
+	if errors.As(err, &ferr) && ferr.Line <= 1 {
+		e.Message = ferr.Message
+		// position of the expression in the statement, see evaluateCleanStatement
+		text := strings.Replace(st.text, "\t", " ", -1)
+		entry := withoutTrailingComment(strings.TrimSpace(text))
+		offset := len(text) - len(strings.TrimLeft(text, " "))
+		if _, expression, ok := IsAssignment(entry); ok {
+			offset += len(strings.TrimSpace(entry)) - len(expression)
+		}
+		column := offset + ferr.Column
+		// find the line of the statement with that column
+		for i, each := range st.offsets {
+			if each <= column {
+				e.Line = st.line + i + 1
+				e.Column = column - each + 1
+			}
+		}
+	}
+	if lines := strings.Split(source, "\n"); e.Line <= len(lines) {
+		e.Source = lines[e.Line-1]
+	}
+	return e
+}
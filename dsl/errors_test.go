@@ -0,0 +1,55 @@
+package dsl
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEvaluateProgram_ErrorLocation(t *testing.T) {
+	for _, each := range []struct {
+		source       string
+		line, column int
+		text         string
+	}{
+		{"a = 1\nx = foo(1)", 2, 5, "2:5: unknown name foo\n | x = foo(1)\n | ....^"},
+		{"a = sequence('c') +\n\t\tbar(2) // bar", 2, 3, "2:3: unknown name bar\n |   bar(2) // bar\n | ..^"},
+		{"\nnote = 1", 2, 0, "2: cannot use variable [note] because it is a defined function\n | note = 1"},
+	} {
+		_, err := newTestEvaluator().EvaluateProgram(each.source)
+		var located *Error
+		if !errors.As(err, &located) {
+			t.Fatalf("got [%v:%T] want *Error", err, err)
+		}
+		if got, want := located.Line, each.line; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+		if got, want := located.Column, each.column; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+		if got, want := located.Error(), each.text; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	}
+}
+
+func TestError_File(t *testing.T) {
+	e := &Error{File: "song.mel", Line: 3, Message: "failed"}
+	if got, want := e.Error(), "song.mel:3: failed"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestEvaluateProgram_FunctionError(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram("a = note('C')\nb = note('Z')\nc = 1")
+	var located *Error
+	if !errors.As(err, &located) {
+		t.Fatalf("got [%v:%T] want *Error", err, err)
+	}
+	if got, want := located.Line, 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, ok := e.context.Variables().Get("a"); !ok {
+		t.Error("statement before the failing one must be evaluated")
+	}
+}
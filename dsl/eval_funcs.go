@@ -9,18 +9,32 @@ import (
 
 	"github.com/emicklei/melrose/control"
 	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl/calc"
+	"github.com/emicklei/melrose/link"
 	"github.com/emicklei/melrose/midi"
 
 	"github.com/emicklei/melrose/midi/file"
 
+	"github.com/emicklei/melrose/notation/abc"
+	"github.com/emicklei/melrose/notation/export"
+	"github.com/emicklei/melrose/notation/hydrogen"
 	"github.com/emicklei/melrose/notify"
 	"github.com/emicklei/melrose/op"
+	"github.com/emicklei/melrose/osc"
+	"github.com/emicklei/melrose/synth"
+	"github.com/emicklei/melrose/ui/roll"
 )
 
 // SyntaxVersion tells what language version this package is supporting.
 const SyntaxVersion = "0.37" // major,minor
 
 func EvalFunctions(ctx core.Context) map[string]Function {
+	return NewEvaluator(ctx).funcs
+}
+
+// evalFunctions returns the functions of an evaluator.
+// Functions that evaluate expressions, such as map and import, use that same evaluator such that its allowed functions apply.
+func evalFunctions(ctx core.Context, owner *Evaluator) map[string]Function {
 	eval := map[string]Function{}
 
 	// TODO allow fractions:  0.5, 0.25, 0.0125
@@ -34,17 +48,17 @@ Fraction can also be an exact float value between 0 and 1.
 		IsComposer: true,
 		Template:   `fraction(${1:object},${2:object})`,
 		Samples:    `fraction(8,sequence('e f')) // => 8E 8F , shorten the notes from quarter to eight`,
-		Func: func(param interface{}, playables ...interface{}) interface{} {
+		Func: func(param interface{}, playables ...interface{}) (interface{}, error) {
 			joined := []core.Sequenceable{}
 			for _, p := range playables {
 				if s, ok := getSequenceable(p); !ok {
 					notify.Warnf("cannot fraction (%T) %v", p, p)
-					return nil
+					return nil, nil
 				} else {
 					joined = append(joined, s)
 				}
 			}
-			return op.NewFraction(getHasValue(param), joined)
+			return op.NewFraction(getHasValue(param), joined), nil
 		}})
 
 	registerFunction(eval, "dynamic", Function{
@@ -57,17 +71,17 @@ Fraction can also be an exact float value between 0 and 1.
 		Template:   `dynamic(${1:emphasis},${2:object})`,
 		Samples: `dynamic('++',sequence('e f')) // => E++ F++
 dynamic(112,note('a')) // => A++++`,
-		Func: func(emphasis interface{}, playables ...interface{}) interface{} {
+		Func: func(emphasis interface{}, playables ...interface{}) (interface{}, error) {
 			joined := []core.Sequenceable{}
 			for _, p := range playables {
 				if s, ok := getSequenceable(p); !ok {
 					notify.Warnf("cannot dynamic (%T) %v", p, p)
-					return nil
+					return nil, nil
 				} else {
 					joined = append(joined, s)
 				}
 			}
-			return op.Dynamic{Target: joined, Emphasis: getHasValue(emphasis)}
+			return op.Dynamic{Target: joined, Emphasis: getHasValue(emphasis)}, nil
 		}})
 
 	registerFunction(eval, "dynamicmap", Function{
@@ -78,11 +92,11 @@ dynamic(112,note('a')) // => A++++`,
 		Template:    `dynamicmap('${1:mapping}',${2:object})`,
 		Samples: `dynamicmap('1:++,2:--',sequence('e f')) // => E++ F--
 dynamicmap('2:o,1:++,2:--,1:++', sequence('a b') // => B A++ B-- A++`,
-		Func: func(mapping string, playables ...interface{}) interface{} {
+		Func: func(mapping string, playables ...interface{}) (interface{}, error) {
 			joined := []core.Sequenceable{}
 			for _, p := range playables {
 				if s, ok := getSequenceable(p); !ok {
-					return notify.Panic(fmt.Errorf("cannot dynamicmap (%T) %v", p, p))
+					return nil, fmt.Errorf("cannot dynamicmap (%T) %v", p, p)
 				} else {
 					joined = append(joined, s)
 				}
@@ -90,9 +104,9 @@ dynamicmap('2:o,1:++,2:--,1:++', sequence('a b') // => B A++ B-- A++`,
 			mapper, err := op.NewDynamicMap(joined, mapping)
 			if err != nil {
 				notify.NewWarningf("cannot create dynamic mapping %v", err)
-				return nil
+				return nil, nil
 			}
-			return mapper
+			return mapper, nil
 		}})
 
 	registerFunction(eval, "progression", Function{
@@ -102,8 +116,34 @@ dynamicmap('2:o,1:++,2:--,1:++', sequence('a b') // => B A++ B-- A++`,
 		IsCore:      true,
 		Template:    `progression('${1:scale}','${2:space-separated-roman-chords}')`,
 		Samples:     `progression('1c3++','II V I') // => (1D3++ 1F3++ 1A3++) (1G3++ 1B3++ 1D++) (1C3++ 1E3++ 1G3++)`,
-		Func: func(scale, chords interface{}) interface{} {
-			return core.NewChordProgression(getHasValue(scale), getHasValue(chords))
+		Func: func(scale, chords interface{}) (interface{}, error) {
+			return core.NewChordProgression(getHasValue(scale), getHasValue(chords)), nil
+		}})
+
+	registerFunction(eval, "cadence", Function{
+		Title:       "Cadence operator for chord progressions",
+		Description: "substitutes the last two chords of every phrase of N bars with an authentic (V I) or plagal (IV I) cadence",
+		Prefix:      "cad",
+		IsComposer:  true,
+		Template:    `cadence(${1:bars},${2:progression})`,
+		Samples: `p = progression('c','1I 1vi 1IV 1ii')
+cadence(4,p) // => I vi V I
+cadence(4,p,'plagal') // => I vi IV I`,
+		Func: func(every, progression interface{}, kind ...string) (interface{}, error) {
+			if _, ok := getValue(progression).(core.ChordProgression); !ok {
+				return nil, fmt.Errorf("cadence requires a chord progression, got (%T) %s", progression, core.Storex(progression))
+			}
+			plagal := false
+			if len(kind) > 0 {
+				switch kind[0] {
+				case "plagal":
+					plagal = true
+				case "authentic":
+				default:
+					return nil, fmt.Errorf("cadence must be authentic or plagal, got %s", kind[0])
+				}
+			}
+			return op.NewCadence(getHasValue(every), getHasValue(progression), plagal, ctx.Control()), nil
 		}})
 
 	registerFunction(eval, "chordsequence", Function{
@@ -114,12 +154,16 @@ dynamicmap('2:o,1:++,2:--,1:++', sequence('a b') // => B A++ B-- A++`,
 		Template:    `chordsequence('${1:chords}')`,
 		Samples: `chordsequence('e f') // => (E A_ B) (F A C5)
 chordsequence('(c d)') // => (C E G D G_ A)`,
-		Func: func(chords string) interface{} {
+		Func: func(chords string) (interface{}, error) {
+			chords, err := localNotation(ctx, chords)
+			if err != nil {
+				return nil, err
+			}
 			p, err := core.ParseChordSequence(chords)
 			if err != nil {
-				return notify.Panic(err)
+				return nil, err
 			}
-			return p
+			return p, nil
 		}})
 
 	registerFunction(eval, "prob", Function{
@@ -130,8 +174,106 @@ chordsequence('(c d)') // => (C E G D G_ A)`,
 		Template:    `prob(${1:perc},${2:note-or-sequenceable})`,
 		Samples: `prob(50,note('c')) // 50% chance of playing the note C, otherwise a quarter rest
 prob(0.8,sequence('(c e g)')) // 80% chance of playing the chord C, otherwise a quarter rest`,
-		Func: func(prec interface{}, noteOrSeq interface{}) interface{} {
-			return op.NewProbability(getHasValue(prec), getHasValue(noteOrSeq))
+		Func: func(prec interface{}, noteOrSeq interface{}) (interface{}, error) {
+			return op.NewProbability(getHasValue(prec), getHasValue(noteOrSeq)), nil
+		}})
+
+	registerFunction(eval, "unless", Function{
+		Title:       "Sidechain operator to silence notes",
+		Prefix:      "unl",
+		Description: "Creates a new musical object for which the notes are replaced by rests where another object, e.g. another loop, has a hit at the same position. Against a running loop, hits are taken from where that loop is when the notes are played",
+		IsComposer:  true,
+		Template:    `unless(${1:other},${2:object})`,
+		Samples: `snare = sequence('= c = c')
+hihat = sequence('8e 8e 8e 8e 8e 8e 8e 8e')
+unless(snare,hihat) // => 8E 8E 8= 8E 8E 8E 8= 8E
+snares = loop(snare)
+hihats = loop(unless(snares,hihat)) // follows the bars of the snares loop`,
+		Func: func(other, target interface{}) (interface{}, error) {
+			if _, ok := getValue(other).(core.Sequenceable); !ok {
+				return nil, fmt.Errorf("cannot sidechain on (%T) %s", other, core.Storex(other))
+			}
+			return op.NewSidechain(getHasValue(other), getHasValue(target), false), nil
+		}})
+
+	registerFunction(eval, "with", Function{
+		Title:       "Sidechain operator to enable notes",
+		Prefix:      "wit",
+		Description: "Creates a new musical object for which only the notes are kept where another object, e.g. another loop, has a hit at the same position. Against a running loop, hits are taken from where that loop is when the notes are played",
+		IsComposer:  true,
+		Template:    `with(${1:other},${2:object})`,
+		Samples: `kick = sequence('c = = c')
+bass = sequence('c2 d2 e2 f2')
+with(kick,bass) // => C2 = = F2`,
+		Func: func(other, target interface{}) (interface{}, error) {
+			if _, ok := getValue(other).(core.Sequenceable); !ok {
+				return nil, fmt.Errorf("cannot sidechain on (%T) %s", other, core.Storex(other))
+			}
+			return op.NewSidechain(getHasValue(other), getHasValue(target), true), nil
+		}})
+
+	registerFunction(eval, "target", Function{
+		Title:       "Chord tone targeting operator",
+		Prefix:      "targ",
+		Description: "Creates a new musical object for which the notes on strong beats (1 and 3 of a 4/4 bar) are moved to the nearest tone of the chord that sounds at the same position in another object, e.g. a progression. In a loop, the position is the bar and beat of the loop",
+		IsComposer:  true,
+		Template:    `target(${1:chords},${2:object})`,
+		Samples: `chords = progression('c','I IV')
+line = sequence('d e f g a b c5 d5')
+target(chords,line) // => C E E G G B C5 D5`,
+		Func: func(chords, target interface{}) (interface{}, error) {
+			if _, ok := getValue(chords).(core.Sequenceable); !ok {
+				return nil, fmt.Errorf("cannot target chords of (%T) %s", chords, core.Storex(chords))
+			}
+			return op.NewChordTarget(getHasValue(chords), getHasValue(target)), nil
+		}})
+
+	registerFunction(eval, "voicelead", Function{
+		Title:       "Voice leading operator",
+		Prefix:      "voi",
+		Description: "Creates a new musical object for which each chord uses the inversion and octave that moves the voices the least from the previous chord",
+		IsComposer:  true,
+		Template:    `voicelead(${1:progression})`,
+		Samples:     `voicelead(progression('c','I IV V I')) // => (C E G) (C F A) (D G B) (E G C5)`,
+		Func: func(progression interface{}) (interface{}, error) {
+			if _, ok := getValue(progression).(core.Sequenceable); !ok {
+				return nil, fmt.Errorf("cannot voicelead (%T) %s", progression, core.Storex(progression))
+			}
+			return op.NewVoiceLead(getHasValue(progression)), nil
+		}})
+
+	registerFunction(eval, "source", Function{
+		Title:       "Random source selection",
+		Prefix:      "sou",
+		Description: "change the source of randomness for all generators such as prob and random: pseudo (default), seeded (reproducible), crypto (true random) or halton (evenly spread)",
+		Template:    `source('${1:name}')`,
+		Samples: `source('seeded',42) // same results on each run
+source('crypto')
+source('halton') // low-discrepancy sequence ; the optional second parameter is the base, default 2`,
+		Func: func(name string, seed ...int) (interface{}, error) {
+			var s int64
+			if len(seed) > 0 {
+				s = int64(seed[0])
+			}
+			if err := core.UseRandomSource(name, s); err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}})
+
+	registerFunction(eval, "randomseed", Function{
+		Title:       "Random seed",
+		Prefix:      "rands",
+		Description: "use a seeded source of randomness for all generators such as prob, random and genmelody, such that the same program produces the same notes. Same as source('seeded',seed)",
+		Template:    `randomseed(${1:seed})`,
+		Samples: `randomseed(42)
+r = random(1,10) // same numbers on each run of the program`,
+		Func: func(seed interface{}) (interface{}, error) {
+			n, ok := getValue(seed).(int)
+			if !ok {
+				return nil, fmt.Errorf("random seed must be an integer, got (%T) %v", seed, seed)
+			}
+			return nil, core.UseRandomSource("seeded", int64(n))
 		}})
 
 	registerFunction(eval, "joinmap", Function{
@@ -142,14 +284,14 @@ prob(0.8,sequence('(c e g)')) // 80% chance of playing the chord C, otherwise a
 		Template:    `joinmap('${1:indices}',${2:join})`,
 		Samples: `j = join(note('c'), sequence('d e f'))
 jm = joinmap('1 (2 3) 4',j) // => C = D =`,
-		Func: func(indices interface{}, join interface{}) interface{} { // allow multiple seq?
+		Func: func(indices interface{}, join interface{}) (interface{}, error) { // allow multiple seq?
 			v := getHasValue(join)
 			vNow := v.Value()
 			if _, ok := vNow.(op.Join); !ok {
-				return notify.Panic(fmt.Errorf("cannot joinmap (%T) %v, must be a join", join, join))
+				return nil, fmt.Errorf("cannot joinmap (%T) %v, must be a join", join, join)
 			}
 			p := getHasValue(indices)
-			return op.NewJoinMap(v, p)
+			return op.NewJoinMap(v, p), nil
 		}})
 
 	registerFunction(eval, "bars", Function{
@@ -157,14 +299,32 @@ jm = joinmap('1 (2 3) 4',j) // => C = D =`,
 		Description: "compute the number of bars that is taken when playing a musical object",
 		IsComposer:  true,
 		Template:    `bars(${1:object})`,
-		Func: func(seq interface{}) interface{} {
+		Func: func(seq interface{}) (interface{}, error) {
 			s, ok := getSequenceable(seq)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot compute how many bars for (%T) %v", seq, seq))
+				return nil, fmt.Errorf("cannot compute how many bars for (%T) %v", seq, seq)
 			}
 			// TODO handle loop
 			biab := ctx.Control().BIAB()
-			return int(math.Round((s.S().DurationFactor() * 4) / float64(biab)))
+			return int(math.Round((s.S().DurationFactor() * 4) / float64(biab))), nil
+		}})
+
+	registerFunction(eval, "fitbars", Function{
+		Title:       "Fit bars operator",
+		Prefix:      "fit",
+		Description: "pad a musical object with a rest or truncate it such that it takes exactly a number of bars, e.g. to keep loops aligned",
+		IsComposer:  true,
+		Template:    `fitbars(${1:bars},${2:object})`,
+		Samples: `fitbars(1,sequence('c d e')) // => C D E =
+fitbars(1,sequence('c d e 2f')) // => C D E F`,
+		Func: func(bars, seq interface{}) (interface{}, error) {
+			if _, ok := getValue(bars).(int); !ok {
+				return nil, fmt.Errorf("number of bars must be an integer, got (%T) %v", bars, bars)
+			}
+			if _, ok := getSequenceable(seq); !ok {
+				return nil, fmt.Errorf("cannot fit bars of (%T) %v", seq, seq)
+			}
+			return op.NewFitBars(getHasValue(bars), getHasValue(seq), ctx.Control()), nil
 		}})
 
 	registerFunction(eval, "beats", Function{
@@ -172,32 +332,123 @@ jm = joinmap('1 (2 3) 4',j) // => C = D =`,
 		Description: "compute the number of beats that is taken when playing a musical object",
 		IsComposer:  true,
 		Template:    `beats(${1:object})`,
-		Func: func(seq interface{}) interface{} {
+		Func: func(seq interface{}) (interface{}, error) {
 			s, ok := getSequenceable(seq)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot compute how many beats for (%T) %v", seq, seq))
+				return nil, fmt.Errorf("cannot compute how many beats for (%T) %v", seq, seq)
 			}
-			return len(s.S().Notes)
+			return len(s.S().Notes), nil
+		}})
+
+	registerFunction(eval, "durationof", Function{
+		Prefix:      "dura",
+		Description: "compute the time in seconds that is taken when playing a musical object at the current BPM",
+		IsComposer:  true,
+		Template:    `durationof(${1:object})`,
+		Samples:     `durationof(sequence('c d e f')) // => 2 at 120 BPM`,
+		Func: func(seq interface{}) (interface{}, error) {
+			s, ok := getSequenceable(seq)
+			if !ok {
+				return nil, fmt.Errorf("cannot compute the duration of (%T) %v", seq, seq)
+			}
+			return s.S().DurationAt(ctx.Control().BPM()).Seconds(), nil
+		}})
+
+	registerFunction(eval, "lengthof", Function{
+		Prefix:      "leng",
+		Description: "compute the length in beats (quarter notes) of a musical object",
+		IsComposer:  true,
+		Template:    `lengthof(${1:object})`,
+		Samples:     `lengthof(sequence('8c 8d e 2f')) // => 4.0`,
+		Func: func(seq interface{}) (interface{}, error) {
+			s, ok := getSequenceable(seq)
+			if !ok {
+				return nil, fmt.Errorf("cannot compute the length of (%T) %v", seq, seq)
+			}
+			return s.S().Beats(), nil
 		}})
 
 	registerFunction(eval, "track", Function{
 		Title:       "Track creator",
-		Description: "create a named track for a given MIDI channel with a musical object",
+		Description: "create a named track for a given MIDI channel with musical objects and key changes ; the channel assigned to the title by the project, if any, is used instead",
 		Prefix:      "tr",
 		Template:    `track('${1:title}',${2:midi-channel}, onbar(1,${3:object}))`,
-		Samples:     `track("lullaby",1,onbar(2, sequence('c d e'))) // => a new track on MIDI channel 1 with sequence starting at bar 2`,
-		Func: func(title string, channel int, onbars ...core.SequenceOnTrack) interface{} {
+		Samples: `track("lullaby",1,onbar(2, sequence('c d e'))) // => a new track on MIDI channel 1 with sequence starting at bar 2
+track("chords",1,onbar(1,p),onbar(5,p),changekey(5,'d')) // => progression p in the key of D from bar 5`,
+		Func: func(title string, channel int, onbars ...interface{}) (interface{}, error) {
 			if len(title) == 0 {
-				return notify.Panic(fmt.Errorf("cannot have a track without title"))
+				return nil, fmt.Errorf("cannot have a track without title")
+			}
+			if err := checkMIDIChannel(channel); err != nil {
+				return nil, err
 			}
-			if channel < 1 || channel > 15 {
-				return notify.Panic(fmt.Errorf("MIDI channel must be in [1..15]"))
+			// the project decides the channel such that the song plays the same on each machine
+			if assigned, ok := projectChannel(ctx, title); ok {
+				channel = assigned
 			}
 			tr := core.NewTrack(title, channel)
 			for _, each := range onbars {
-				tr.Add(each)
+				switch v := getValue(each).(type) {
+				case core.SequenceOnTrack:
+					tr.Add(v)
+				case core.KeyChange:
+					tr.AddKeyChange(v)
+				default:
+					return nil, fmt.Errorf("cannot put on track (%T) %v, use onbar or changekey", each, each)
+				}
+			}
+			return tr, nil
+		}})
+
+	registerFunction(eval, "volume", Function{
+		Title:         "Track volume",
+		Description:   "create a copy of a track that sets the volume [0..127] of its channel (CC 7) when it starts playing ; use a knob or an interval to automate it",
+		ControlsAudio: true,
+		Prefix:        "vol",
+		Template:      `volume(${1:value},${2:track})`,
+		Samples: `volume(100,track('bass',2,onbar(1,sequence('c2 g2'))))
+fader = knob(1,16) // the volume follows a controller on the next play
+volume(fader,bass)`,
+		Func: func(value, track interface{}) (interface{}, error) {
+			tr, err := mixingTrack("volume", value, track)
+			if err != nil {
+				return nil, err
+			}
+			return tr.WithVolume(getHasValue(value)), nil
+		}})
+
+	registerFunction(eval, "pan", Function{
+		Title:         "Track pan",
+		Description:   "create a copy of a track that sets the pan [0..127] of its channel (CC 10) when it starts playing ; 0 is left, 64 is center and 127 is right",
+		ControlsAudio: true,
+		Template:      `pan(${1:value},${2:track})`,
+		Samples:       `pan(32,track('hihat',10,onbar(1,sequence('8f#2 8f#2')))) // a bit to the left`,
+		Func: func(value, track interface{}) (interface{}, error) {
+			tr, err := mixingTrack("pan", value, track)
+			if err != nil {
+				return nil, err
+			}
+			return tr.WithPan(getHasValue(value)), nil
+		}})
+
+	registerFunction(eval, "changekey", Function{
+		Title:       "Track key change",
+		Description: "changes the key of a track from a bar on ; progressions, scales and diatonic chords on the track resolve against the active key",
+		Prefix:      "changek",
+		Template:    `changekey(${1:bar},'${2:scale}')`,
+		Samples:     `track("chords",1,onbar(1,p),onbar(5,p),changekey(5,'d')) // p is in the key of D from bar 5`,
+		Func: func(bar interface{}, key interface{}) (interface{}, error) {
+			if _, ok := getValue(bar).(int); !ok {
+				return nil, fmt.Errorf("bar must be an integer, got (%T) %v", bar, bar)
+			}
+			notation, ok := getValue(key).(string)
+			if !ok {
+				return nil, fmt.Errorf("key must be a scale notation, got (%T) %v", key, key)
+			}
+			if _, err := core.ParseScale(notation); err != nil {
+				return nil, err
 			}
-			return tr
+			return core.NewKeyChange(getHasValue(bar), getHasValue(key)), nil
 		}})
 
 	registerFunction(eval, "multitrack", Function{
@@ -207,12 +458,39 @@ jm = joinmap('1 (2 3) 4',j) // => C = D =`,
 		Template:      `multitrack(${1:track})`,
 		Samples:       `multitrack(track1,track2,track3) // 3 tracks in one multi-track object`,
 		ControlsAudio: true,
-		Func: func(varOrTrack ...interface{}) interface{} {
+		Func: func(varOrTrack ...interface{}) (interface{}, error) {
 			tracks := []core.HasValue{}
 			for _, each := range varOrTrack {
 				tracks = append(tracks, getHasValue(each))
 			}
-			return core.MultiTrack{Tracks: tracks}
+			return core.MultiTrack{Tracks: tracks}, nil
+		}})
+
+	registerFunction(eval, "canon", Function{
+		Title:         "Canon creator",
+		Description:   "create a multi-track canon (or round) in which the musical object enters on successive MIDI channels, each voice a number of bars later and transposed by a number of semitones more than the previous voice",
+		Prefix:        "can",
+		Template:      `canon(${1:voices},${2:delay-bars},${3:semitones},${4:object})`,
+		Samples:       `canon(3,2,0,sequence('c d e c c d e c e f 2g')) // a round of 3 voices on channels 1,2 and 3, entering every 2 bars`,
+		ControlsAudio: true,
+		Func: func(voices, delay, interval, subject interface{}) (interface{}, error) {
+			count, ok := getValue(voices).(int)
+			if !ok || count < 1 || count > 15 {
+				return nil, fmt.Errorf("number of voices must be in [1..15], got (%T) %v", voices, voices)
+			}
+			bars, ok := getValue(delay).(int)
+			if !ok || bars < 0 {
+				return nil, fmt.Errorf("delay in bars must be a non-negative integer, got (%T) %v", delay, delay)
+			}
+			semitones, ok := getValue(interval).(int)
+			if !ok {
+				return nil, fmt.Errorf("interval in semitones must be an integer, got (%T) %v", interval, interval)
+			}
+			s, ok := getSequenceable(subject)
+			if !ok {
+				return nil, fmt.Errorf("cannot canon (%T) %v", subject, subject)
+			}
+			return op.NewCanon(count, bars, semitones, s), nil
 		}})
 
 	registerFunction(eval, "midi", Function{
@@ -226,19 +504,97 @@ The third parameter is the velocity (~ loudness) and must be one of [0..127]`,
 		Samples: `midi(500,52,80) // => 500ms E3+
 midi(16,36,70) // => 16C2 (kick)`,
 		IsCore: true,
-		Func: func(dur, nr, velocity interface{}) interface{} {
+		Func: func(dur, nr, velocity interface{}) (interface{}, error) {
 			durVal := getHasValue(dur)
 			nrVal := getHasValue(nr)
 			velVal := getHasValue(velocity)
-			return core.NewMIDI(durVal, nrVal, velVal)
+			return core.NewMIDI(durVal, nrVal, velVal), nil
 		}})
 
 	registerFunction(eval, "print", Function{
 		Title:       "Printer creator",
-		Description: "prints an object when evaluated (play,loop)",
+		Description: "prints the value of an object when evaluated, in a script or when played (play,loop)",
 		Template:    `print(${1:object})`,
-		Func: func(m interface{}) interface{} {
-			return core.Print{Context: ctx, Target: m}
+		Samples: `print(lengthof(sequence('c d e'))) // => 3
+loop(sequence('c'),print(r)) // prints the value of r with the bar and beat`,
+		Func: func(m interface{}) (interface{}, error) {
+			return core.Print{Context: ctx, Target: m}, nil
+		}})
+
+	registerFunction(eval, "inspect", Function{
+		Title:       "Inspector creator",
+		Description: "prints the details of an object when evaluated, in a script or when played (play,loop)",
+		Template:    `inspect(${1:object})`,
+		Samples:     `inspect(sequence('c d e')) // => duration, bars, beats, ...`,
+		Func: func(m interface{}) (interface{}, error) {
+			return core.Print{Context: ctx, Target: m, Inspect: true}, nil
+		}})
+
+	registerFunction(eval, "expect", Function{
+		Title:       "Expectation",
+		Description: "compares the notes of an object with the notes of an expected sequence ; reports the differences, if any, as an error when evaluated or played",
+		Template:    `expect(${1:object},'${2:notation}')`,
+		Samples: `expect(transpose(2,sequence('c d')),'D E')
+expect(reverse(sequence('c d e')),'E D F') // => error at 3: expected F got C`,
+		Func: func(m interface{}, notation string) (interface{}, error) {
+			s, ok := getSequenceable(m)
+			if !ok {
+				return nil, fmt.Errorf("cannot expect notes of (%T) %v", m, m)
+			}
+			notation, err := localNotation(ctx, notation)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := core.ParseSequence(notation); err != nil {
+				return nil, err
+			}
+			return core.Expect{Target: s, Notation: notation}, nil
+		}})
+
+	registerFunction(eval, "wait", Function{
+		Title:       "Wait command",
+		Description: "blocks the evaluation of the script for a number of beats, at the current BPM, such that the statements that follow are evaluated later",
+		Template:    `wait(${1:beats})`,
+		Samples: `a = loop(sequence('c e g'))
+b = loop(sequence('2c2 2g2'))
+play(a)
+wait(32) // 8 bars of 4 beats
+play(b)`,
+		Func: func(beats interface{}) (interface{}, error) {
+			if !isNumeric(getValue(beats)) {
+				return nil, fmt.Errorf("number of beats must be a number, got (%T) %v", beats, beats)
+			}
+			return core.Wait{Beats: getHasValue(beats)}, nil
+		}})
+
+	registerFunction(eval, "schedule", Function{
+		Title:       "Schedule command",
+		Description: "performs an object at the start of a bar, counted by the beatmaster from its start ; a loop is played, an evaluatable is evaluated and other musical objects are played once",
+		Template:    `schedule(${1:bar},${2:object})`,
+		Samples: `a = loop(sequence('c e g'))
+b = loop(sequence('2c2 2g2'))
+schedule(1,a)
+schedule(9,b) // begin loop b at bar 9`,
+		Func: func(bar, m interface{}) (interface{}, error) {
+			if _, ok := getValue(bar).(int); !ok {
+				return nil, fmt.Errorf("bar must be an integer, got (%T) %v", bar, bar)
+			}
+			return core.Schedule{Bar: getHasValue(bar), Target: getHasValue(m)}, nil
+		}})
+
+	registerFunction(eval, "draw", Function{
+		Title:       "Piano roll drawer",
+		Description: "prints a piano roll of a musical object with a row for each pitch and a column for each 16th ; a drum grid if played on channel 10",
+		Template:    `draw(${1:object})`,
+		Samples: `draw(sequence('c e g 2c5'))
+draw(channel(10,sequence('8c2 8f#2 8d2 8f#2'))) // drum grid`,
+		Func: func(m interface{}) (interface{}, error) {
+			s, err := roll.Of(getValue(m), ctx.Control().BIAB())
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprint(notify.Console.StandardOut, s)
+			return nil, nil
 		}})
 
 	registerFunction(eval, "chord", Function{
@@ -246,14 +602,40 @@ midi(16,36,70) // => 16C2 (kick)`,
 		Prefix:      "cho",
 		Template:    `chord('${1:note}')`,
 		Samples: `chord('c#5/m/1')
-chord('g/M/2') // Major G second inversion`,
+chord('g/M/2') // Major G second inversion
+chord('d/m9') // also 6 9 11 13 add9 sus2 sus4 7b9 7#9 7#11 dim7 m7b5
+chord('a/m7/g') // A minor seventh with G in the bass`,
 		IsCore: true,
-		Func: func(chord string) interface{} {
+		Func: func(chord string) (interface{}, error) {
+			chord, err := localNotation(ctx, chord)
+			if err != nil {
+				return nil, err
+			}
 			c, err := core.ParseChord(chord)
 			if err != nil {
-				return notify.Panic(err)
+				return nil, err
 			}
-			return c
+			return c, nil
+		}})
+
+	registerFunction(eval, "symbol", Function{
+		Title:       "Chord symbol",
+		Description: "returns the conventional chord symbol(s) of a chord, a chord sequence or a chord progression",
+		Prefix:      "sym",
+		Template:    `symbol(${1:chord})`,
+		Samples: `symbol(chord('a/m7/g')) // => Am7/G
+symbol(chord('c/1')) // => C/E
+symbol(progression('c','I vi IV V7')) // => C Am F G7`,
+		Func: func(m interface{}) (interface{}, error) {
+			switch v := getValue(m).(type) {
+			case core.Chord:
+				return v.Symbol(), nil
+			case core.ChordSequence:
+				return v.Symbols(), nil
+			case core.ChordProgression:
+				return v.Symbols(), nil
+			}
+			return nil, fmt.Errorf("cannot create symbol for (%T) %v", m, m)
 		}})
 
 	registerFunction(eval, "transposemap", Function{
@@ -263,12 +645,12 @@ chord('g/M/2') // Major G second inversion`,
 		Template:    `transposemap('${1:int2int}',${2:object})`,
 		IsComposer:  true,
 		Samples:     `transposemap('1:-1,1:0,1:1',note('c')) // => B3 C D`,
-		Func: func(indices string, m interface{}) interface{} {
+		Func: func(indices string, m interface{}) (interface{}, error) {
 			s, ok := getSequenceable(m)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot transposemap (%T) %v", m, m))
+				return nil, fmt.Errorf("cannot transposemap (%T) %v", m, m)
 			}
-			return op.NewTransposeMap(s, indices)
+			return op.NewTransposeMap(s, indices), nil
 		}})
 
 	registerFunction(eval, "octavemap", Function{
@@ -278,12 +660,12 @@ chord('g/M/2') // Major G second inversion`,
 		Template:    `octavemap('${1:int2int}',${2:object})`,
 		IsComposer:  true,
 		Samples:     `octavemap('1:-1,2:0,3:1',chord('c')) // => (C3 E G5)`,
-		Func: func(indices string, m interface{}) interface{} {
+		Func: func(indices string, m interface{}) (interface{}, error) {
 			s, ok := getSequenceable(m)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot octavemap (%T) %v", m, m))
+				return nil, fmt.Errorf("cannot octavemap (%T) %v", m, m)
 			}
-			return op.NewOctaveMap(s, indices)
+			return op.NewOctaveMap(s, indices), nil
 		}})
 
 	registerFunction(eval, "velocitymap", Function{
@@ -293,30 +675,174 @@ chord('g/M/2') // Major G second inversion`,
 		Template:    `velocitymap('${1:int2int}',${2:object})`,
 		IsComposer:  true,
 		Samples:     `velocitymap('1:30,2:0,3:60',chord('c')) // => (C3--- E G5+)`,
-		Func: func(indices string, m interface{}) interface{} {
+		Func: func(indices string, m interface{}) (interface{}, error) {
 			s, ok := getSequenceable(m)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot velocitymap (%T) %v", m, m))
+				return nil, fmt.Errorf("cannot velocitymap (%T) %v", m, m)
 			}
-			return op.NewVelocityMap(s, indices)
+			return op.NewVelocityMap(s, indices), nil
+		}})
+
+	registerFunction(eval, "accents", Function{
+		Title:       "Accents operator",
+		Description: "raise the velocity of the notes on the steps marked with 1 in a pattern that repeats over the (groups of) notes",
+		Prefix:      "acc",
+		Template:    `accents('${1:pattern}',${2:amount},${3:object})`,
+		IsComposer:  true,
+		Samples: `hihat = sequence('8e 8e 8e 8e 8e 8e 8e 8e')
+accents('1 0 0 1 0 1 0 0',20,hihat) // => 8E:v79 8E 8E 8E:v79 8E 8E:v79 8E 8E`,
+		Func: func(pattern string, amount, target interface{}) (interface{}, error) {
+			if err := op.CheckAccentPattern(pattern); err != nil {
+				return nil, err
+			}
+			if !isNumeric(getValue(amount)) {
+				return nil, fmt.Errorf("amount must be a number, got (%T) %v", amount, amount)
+			}
+			if _, ok := getSequenceable(target); !ok {
+				return nil, fmt.Errorf("cannot accent (%T) %v", target, target)
+			}
+			return op.NewAccents(pattern, getHasValue(amount), getHasValue(target)), nil
+		}})
+
+	registerFunction(eval, "polyrhythm", Function{
+		Title:       "Polyrhythm operator",
+		Description: "play two musical objects at the same time, each with its notes evenly spread over the length of the longest, such as 3 notes against 4",
+		Prefix:      "poly",
+		Template:    `polyrhythm(${1:object},${2:object})`,
+		IsComposer:  true,
+		Samples: `three = sequence('c5 c5 c5')
+four = sequence('c3 c3 c3 c3')
+polyrhythm(three,four) // three notes in the time of four quarters`,
+		Func: func(a, b interface{}) (interface{}, error) {
+			if _, ok := getSequenceable(a); !ok {
+				return nil, fmt.Errorf("cannot polyrhythm (%T) %v", a, a)
+			}
+			if _, ok := getSequenceable(b); !ok {
+				return nil, fmt.Errorf("cannot polyrhythm (%T) %v", b, b)
+			}
+			return op.NewPolyrhythm(getHasValue(a), getHasValue(b)), nil
+		}})
+
+	registerFunction(eval, "groove", Function{
+		Title: "Groove operator",
+		Description: `change the timing of the notes that start on a grid step using a template.
+A template is the name of a built-in template or a recording (or sequence) from which the timing of the notes on the sixteenths of a bar is taken.
+Built-in templates are MPC swing percentages of eighths (mpc8_50,mpc8_54,mpc8_58,mpc8_62,mpc8_66,mpc8_71) and sixteenths (mpc16_50 ...),
+and push16 and pull16 that play the off-beat sixteenths slightly early or late`,
+		Prefix:     "gro",
+		Template:   `groove('${1:template}',${2:object})`,
+		IsComposer: true,
+		Samples: `groove('mpc16_58',sequence('16c 16e 16g 16e'))
+rec = sequence('')
+r = record(rec) // play(r) to record a groove, stop(r) when done
+groove(r,sequence('8c 8e 8g 8e'))`,
+		Func: func(template, target interface{}) (interface{}, error) {
+			switch v := getValue(template).(type) {
+			case string:
+				if err := op.CheckGrooveTemplate(v); err != nil {
+					return nil, err
+				}
+			case core.HasOnsets, core.Sequenceable:
+			default:
+				return nil, fmt.Errorf("groove template must be a name, recording or sequence, got (%T) %v", template, template)
+			}
+			if _, ok := getSequenceable(target); !ok {
+				return nil, fmt.Errorf("cannot groove (%T) %v", target, target)
+			}
+			return op.NewGroove(getHasValue(template), getHasValue(target)), nil
 		}})
 
 	registerFunction(eval, "transpose", Function{
 		Title:       "Transpose operator",
-		Description: "change the pitch with a delta of semitones",
+		Description: "change the pitch with a delta of semitones or a named interval such as 'm3', 'P5' or '-M6'",
 		Alias:       "pitch",
 		Prefix:      "tran",
 		Template:    `transpose(${1:semitones},${2:sequenceable})`,
 		Samples: `transpose(-1,sequence('c d e'))
+transpose('P5',sequence('c d e')) // => G A B
 p = interval(-4,4,1)
 transpose(p,note('c'))`,
 		IsComposer: true,
-		Func: func(semitones, m interface{}) interface{} {
+		Func: func(semitones, m interface{}) (interface{}, error) {
+			if name, ok := getValue(semitones).(string); ok {
+				if _, err := core.ParseInterval(name); err != nil {
+					return nil, err
+				}
+			}
 			s, ok := getSequenceable(m)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot transpose (%T) %v", m, m))
+				return nil, fmt.Errorf("cannot transpose (%T) %v", m, m)
 			}
-			return op.Transpose{Target: s, Semitones: getHasValue(semitones)}
+			return op.Transpose{Target: s, Semitones: getHasValue(semitones)}, nil
+		}})
+
+	registerFunction(eval, "intervalbetween", Function{
+		Title:       "Interval query",
+		Description: "returns the name of the interval from the first to the second note, such as 'M3' or '-P5'",
+		Prefix:      "intb",
+		Template:    `intervalbetween(${1:note},${2:note})`,
+		Samples: `intervalbetween(note('c'),note('e')) // => 'M3'
+intervalbetween('c','f3') // => '-P5'`,
+		Func: func(from, to interface{}) (interface{}, error) {
+			a, err := midiNumberOf(from)
+			if err != nil {
+				return nil, err
+			}
+			b, err := midiNumberOf(to)
+			if err != nil {
+				return nil, err
+			}
+			return core.IntervalName(b - a), nil
+		}})
+
+	registerFunction(eval, "invert", Function{
+		Title:       "Invert operator",
+		Description: "mirror the pitches of the notes of a musical object around the pitch of an axis note",
+		Prefix:      "inv",
+		Template:    `invert(${1:axis-note},${2:object})`,
+		Samples:     `invert(note('e'),sequence('c d e f g')) // => A_ G_ E E_ D_`,
+		IsComposer:  true,
+		Func: func(axis, target interface{}) (interface{}, error) {
+			if _, ok := getValue(axis).(core.NoteConvertable); !ok {
+				return nil, fmt.Errorf("axis must be a note, got (%T) %v", axis, axis)
+			}
+			s, ok := getSequenceable(target)
+			if !ok {
+				return nil, fmt.Errorf("cannot invert (%T) %v", target, target)
+			}
+			return op.Invert{Axis: getHasValue(axis), Target: s}, nil
+		}})
+
+	registerFunction(eval, "tonerow", Function{
+		Title: "Tone row creator",
+		Description: `create a row of distinct pitch classes for twelve-tone composition, in one of its forms:
+P (prime), I (inversion), R (retrograde) or RI (retrograde inversion) followed by a transposition of 0..11 semitones.
+The pitches of a form are within the octave above the first note of the row`,
+		Prefix:   "ton",
+		Template: `tonerow('${1:notes}','${2:form}')`,
+		Samples: `row = 'c b g c# d# d f# e f a a# g#'
+p0 = tonerow(row)
+ri3 = tonerow(row,'RI3')`,
+		IsCore: true,
+		Func: func(row interface{}, form ...string) (interface{}, error) {
+			notes, ok := getValue(row).(string)
+			if !ok {
+				return nil, fmt.Errorf("cannot create tonerow from (%T) %s", row, core.Storex(row))
+			}
+			t, err := op.NewToneRow(notes)
+			if err != nil {
+				return nil, err
+			}
+			if len(form) > 1 {
+				return nil, fmt.Errorf("tonerow has one form, got %v", form)
+			}
+			if len(form) == 1 {
+				if err := op.CheckToneRowForm(form[0]); err != nil {
+					return nil, err
+				}
+				t = t.WithForm(form[0])
+			}
+			return t, nil
 		}})
 
 	registerFunction(eval, "reverse", Function{
@@ -326,12 +852,12 @@ transpose(p,note('c'))`,
 		Template:    `reverse(${1:sequenceable})`,
 		Samples:     `reverse(chord('a'))`,
 		IsComposer:  true,
-		Func: func(m interface{}) interface{} {
+		Func: func(m interface{}) (interface{}, error) {
 			s, ok := getSequenceable(m)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot reverse (%T) %v", m, m))
+				return nil, fmt.Errorf("cannot reverse (%T) %v", m, m)
 			}
-			return op.Reverse{Target: s}
+			return op.Reverse{Target: s}, nil
 		}})
 
 	registerFunction(eval, "repeat", Function{
@@ -341,16 +867,146 @@ transpose(p,note('c'))`,
 		Template:    `repeat(${1:times},${2:sequenceables})`,
 		Samples:     `repeat(4,sequence('c d e'))`,
 		IsComposer:  true,
-		Func: func(howMany interface{}, playables ...interface{}) interface{} {
+		Func: func(howMany interface{}, playables ...interface{}) (interface{}, error) {
 			joined := []core.Sequenceable{}
 			for _, p := range playables {
 				if s, ok := getSequenceable(p); !ok {
-					return notify.Panic(fmt.Errorf("cannot repeat (%T) %v", p, p))
+					return nil, fmt.Errorf("cannot repeat (%T) %v", p, p)
 				} else {
 					joined = append(joined, s)
 				}
 			}
-			return op.Repeat{Target: joined, Times: getHasValue(howMany)}
+			return op.Repeat{Target: joined, Times: getHasValue(howMany)}, nil
+		}})
+
+	registerFunction(eval, "fill", Function{
+		Title:       "Fill operator",
+		Description: "plays the fill instead of the musical object in the last bar of every number of bars, when played in a loop or on a track",
+		Prefix:      "fil",
+		Template:    `fill(${1:bars},${2:sequenceable},${3:fill})`,
+		Samples: `beat = sequence('1c2')
+loop(fill(4,beat,sequence('8c2 8c2 8c2 8c2 8c2 8c2 8c2 8c2'))) // a fill every 4th bar`,
+		IsComposer: true,
+		Func: func(bars, target, fill interface{}) (interface{}, error) {
+			s, ok := getSequenceable(target)
+			if !ok {
+				return nil, fmt.Errorf("cannot fill (%T) %v", target, target)
+			}
+			f, ok := getSequenceable(fill)
+			if !ok {
+				return nil, fmt.Errorf("cannot fill with (%T) %v", fill, fill)
+			}
+			return op.Fill{Every: getHasValue(bars), Target: s, Fill: f}, nil
+		}})
+
+	registerFunction(eval, "phase", Function{
+		Title:       "Phase operator",
+		Description: "in each iteration of a loop, start the musical object later by an amount (such as 16 for a sixteenth) wrapping around its length, to shift it against an unshifted copy in another loop",
+		Prefix:      "pha",
+		Template:    `phase(${1:amount},${2:sequenceable})`,
+		Samples: `clap = sequence('8c 8c 8= 8c 8= 8c 8c 8=')
+play(loop(clap),loop(phase(16,clap))) // the second loop shifts a sixteenth each iteration`,
+		IsComposer: true,
+		Func: func(delta, target interface{}) (interface{}, error) {
+			if !isNumeric(getValue(delta)) {
+				return nil, fmt.Errorf("phase amount must be a number, got (%T) %v", delta, delta)
+			}
+			s, ok := getSequenceable(target)
+			if !ok {
+				return nil, fmt.Errorf("cannot phase (%T) %v", target, target)
+			}
+			return op.Phase{Delta: getHasValue(delta), Target: s}, nil
+		}})
+
+	registerFunction(eval, "chordify", Function{
+		Title:       "Chordify operator",
+		Description: "group the notes that start within the same rhythmic window (such as 4 for a quarter) into chords, e.g. to reduce a recording or imported MIDI",
+		Prefix:      "chordi",
+		Template:    `chordify(${1:window},${2:sequenceable})`,
+		Samples:     `chordify(4,sequence('8c 8e 8g 8= 16a 16b 8= 2c5')) // => (C E) G (A B) 2C5`,
+		IsComposer:  true,
+		Func: func(window, target interface{}) (interface{}, error) {
+			if !isNumeric(getValue(window)) {
+				return nil, fmt.Errorf("chordify window must be a number, got (%T) %v", window, window)
+			}
+			s, ok := getSequenceable(target)
+			if !ok {
+				return nil, fmt.Errorf("cannot chordify (%T) %v", target, target)
+			}
+			return op.Chordify{Window: getHasValue(window), Target: s}, nil
+		}})
+
+	registerFunction(eval, "topline", Function{
+		Title:       "Top line operator",
+		Description: "keep the highest note of each group of notes, e.g. to extract the melody of a recording",
+		Prefix:      "top",
+		Template:    `topline(${1:sequenceable})`,
+		Samples:     `topline(sequence('(c e g) (d f a)')) // => G A`,
+		IsComposer:  true,
+		Func: func(target interface{}) (interface{}, error) {
+			s, ok := getSequenceable(target)
+			if !ok {
+				return nil, fmt.Errorf("cannot take the topline of (%T) %v", target, target)
+			}
+			return op.Line{Target: s, Highest: true}, nil
+		}})
+
+	registerFunction(eval, "bassline", Function{
+		Title:       "Bass line operator",
+		Description: "keep the lowest note of each group of notes, e.g. to extract the bass of a recording ; or generate a bass line in a style (root,root-fifth,walking,octaves) that follows the chords of a progression",
+		Prefix:      "bassl",
+		Template:    `bassline(${1:sequenceable})`,
+		Samples: `bassline(sequence('(c e g) (d f a)')) // => C D
+bassline('walking',progression('c','1I 1vi')) // => C2 E2 G2 A_2 A2 C3 E3 D_2`,
+		IsComposer: true,
+		Func: func(target interface{}, progression ...interface{}) (interface{}, error) {
+			if len(progression) > 0 {
+				style, ok := getValue(target).(string)
+				if !ok {
+					return nil, fmt.Errorf("bass line style must be a string, got (%T) %v", target, target)
+				}
+				if err := op.CheckBassLineStyle(style); err != nil {
+					return nil, err
+				}
+				if _, ok := getValue(progression[0]).(core.Sequenceable); !ok {
+					return nil, fmt.Errorf("cannot generate a bass line for (%T) %v", progression[0], progression[0])
+				}
+				return op.NewBassLine(style, getHasValue(progression[0]), ctx.Control()), nil
+			}
+			s, ok := getSequenceable(target)
+			if !ok {
+				return nil, fmt.Errorf("cannot take the bassline of (%T) %v", target, target)
+			}
+			return op.Line{Target: s}, nil
+		}})
+
+	registerFunction(eval, "genmelody", Function{
+		Title:       "Melody generator",
+		Description: "generate a number of quarter notes that move along a scale ; notes on strong beats are tones of the active chord of a progression. The same seed generates the same melody ; without a seed, one is taken from the source of randomness",
+		Prefix:      "genm",
+		Template:    `genmelody(${1:length},${2:scale},${3:progression},${4:seed})`,
+		Samples: `genmelody(16,scale('c'),progression('c','2I 2IV 2V 2I'),42)
+randomseed(1)
+genmelody(16,scale('c'),progression('c','2I 2IV 2V 2I')) // same seed on each run`,
+		IsComposer: true,
+		Func: func(length, scale, progression interface{}, optionalSeed ...interface{}) (interface{}, error) {
+			var seed interface{} = int(core.RandomFloat64() * math.MaxInt32)
+			if len(optionalSeed) > 0 {
+				seed = optionalSeed[0]
+			}
+			if _, ok := getValue(length).(int); !ok {
+				return nil, fmt.Errorf("melody length must be an integer, got (%T) %v", length, length)
+			}
+			if _, ok := getValue(scale).(core.Scale); !ok {
+				return nil, fmt.Errorf("genmelody requires a scale, got (%T) %s", scale, core.Storex(scale))
+			}
+			if _, ok := getValue(progression).(core.Sequenceable); !ok {
+				return nil, fmt.Errorf("genmelody requires a progression, got (%T) %s", progression, core.Storex(progression))
+			}
+			if _, ok := getValue(seed).(int); !ok {
+				return nil, fmt.Errorf("melody seed must be an integer, got (%T) %v", seed, seed)
+			}
+			return op.NewMelody(getHasValue(length), getHasValue(scale), getHasValue(progression), getHasValue(seed)), nil
 		}})
 
 	registerFunction(eval, "join", Function{
@@ -360,18 +1016,112 @@ transpose(p,note('c'))`,
 		Template:    `join(${1:first},${2:second})`,
 		Samples: `a = chord('a')
 b = sequence('(c e g)')
-ab = join(a,b) // => (A D_5 E5) (C E G)`,
+ab = join(a,b) // => (A D_5 E5) (C E G)
+abba = join([a,b,b,a])`,
 		IsComposer: true,
-		Func: func(playables ...interface{}) interface{} {
+		Func: func(playables ...interface{}) (interface{}, error) {
 			joined := []core.Sequenceable{}
+			// the elements of a list are joined too
+			flat := []interface{}{}
 			for _, p := range playables {
+				if list, ok := listOf(p); ok {
+					flat = append(flat, list...)
+				} else {
+					flat = append(flat, p)
+				}
+			}
+			for _, p := range flat {
 				if s, ok := getSequenceable(p); !ok {
-					return notify.Panic(fmt.Errorf("cannot join (%T) %v", p, p))
+					return nil, fmt.Errorf("cannot join (%T) %v", p, p)
 				} else {
 					joined = append(joined, s)
 				}
 			}
-			return op.Join{Target: joined}
+			return op.Join{Target: joined}, nil
+		}})
+
+	registerFunction(eval, "map", Function{
+		Title: "Map operator",
+		Description: `returns a new list with the result of a function for each element of a list.
+The function is either the name of a function, e.g. chord, or an expression in which _ is the element`,
+		Template: `map(${1:function},${2:list})`,
+		Samples: `roots = ['c','f','g','c']
+p = join(map(chord, roots)) // => (C E G) (F A C5) (G B D5) (C E G)
+low = join(map('octave(-1, chord(_))', roots))`,
+		Func: func(fn interface{}, list interface{}) (interface{}, error) {
+			elements, ok := listOf(list)
+			if !ok {
+				return nil, fmt.Errorf("cannot map (%T) %v, expected list", list, list)
+			}
+			mapped, err := owner.mapList(fn, elements)
+			if err != nil {
+				return nil, err
+			}
+			return mapped, nil
+		}})
+
+	registerFunction(eval, "mapnote", Function{
+		Title: "Map note operator",
+		Description: `replaces each note of a musical object by the result of an expression in which _ is the note.
+The result is a note or a group of notes ; rests are kept. The expression is evaluated each time the object is played`,
+		Prefix:     "mapn",
+		Template:   `mapnote('${1:expression}',${2:object})`,
+		IsComposer: true,
+		Samples: `mapnote('if(_.MIDI() < 60, octave(1,_), _)', sequence('c3 e g5')) // => C4 E G5
+mapnote('dynamic(_.MIDI(),_)', sequence('c3 c5')) // velocity depends on pitch
+mapnote('join(_,transpose(7,_))', sequence('c d')) // => C G D A`,
+		Func: func(expression string, target interface{}) (interface{}, error) {
+			s, ok := getSequenceable(target)
+			if !ok {
+				return nil, fmt.Errorf("cannot mapnote (%T) %v", target, target)
+			}
+			if _, _, err := owner.compile(expression, map[string]interface{}{elementName: core.Rest4}); err != nil {
+				return nil, fmt.Errorf("invalid expression [%s]: %v", expression, err)
+			}
+			return op.MapNote{Expression: expression, Target: s, Mapper: func(expression string, n core.Note) (interface{}, error) {
+				return owner.apply(expression, n)
+			}}, nil
+		}})
+
+	registerFunction(eval, "filter", Function{
+		Title: "Filter operator",
+		Description: `returns a new list with the elements of a list for which a condition is true.
+The condition is either the name of a function, or an expression in which _ is the element`,
+		Template: `filter(${1:condition},${2:list})`,
+		Samples:  `even = filter('_ % 2 == 0', [1,2,3,4]) // => [2,4]`,
+		Func: func(fn interface{}, list interface{}) (interface{}, error) {
+			elements, ok := listOf(list)
+			if !ok {
+				return nil, fmt.Errorf("cannot filter (%T) %v, expected list", list, list)
+			}
+			filtered, err := owner.filterList(fn, elements)
+			if err != nil {
+				return nil, err
+			}
+			return filtered, nil
+		}})
+
+	registerFunction(eval, "select", Function{
+		Title: "Select operator",
+		Description: `keeps the notes of a musical object for which a predicate is true ; the other notes become rests.
+A predicate compares the pitch (note or MIDI number), velocity, beat (one-based in a bar) or duration (note length) of a note,
+with ==, !=, <, <=, >, >= or in a list. Conditions are combined with and`,
+		Prefix:     "sel",
+		Template:   `select('${1:predicate}',${2:object})`,
+		IsComposer: true,
+		Samples: `drums = sequence('(c2 f#2) f#2 (d2 f#2) f#2')
+kick = select('pitch==C2',drums) // => C2 = = =
+hats = select('pitch==F#2',drums)
+select('beat in [1,3] and velocity<64',drums) // => (C2 F#2) = (D2 F#2) =`,
+		Func: func(predicate string, target interface{}) (interface{}, error) {
+			if _, err := op.ParseNotePredicate(predicate); err != nil {
+				return nil, err
+			}
+			s, ok := getSequenceable(target)
+			if !ok {
+				return nil, fmt.Errorf("cannot select from (%T) %v", target, target)
+			}
+			return op.Select{Predicate: predicate, Target: s}, nil
 		}})
 
 	registerFunction(eval, "bpm", Function{
@@ -383,8 +1133,41 @@ ab = join(a,b) // => (A D_5 E5) (C E G)`,
 		Samples: `bpm(90)
 speedup = iterator(80,100,120,140)
 l = loop(bpm(speedup),sequence('c e g'),next(speedup))`,
-		Func: func(v interface{}) interface{} {
-			return control.NewBPM(core.On(v), ctx)
+		Func: func(v interface{}) (interface{}, error) {
+			return control.NewBPM(core.On(v), ctx), nil
+		}})
+
+	registerFunction(eval, "transposeall", Function{
+		Title:         "Global transposition",
+		Description:   "set the number of semitones, or a named interval, that is added to everything played or looped from now on, except drums on channel 10 ; default is 0",
+		ControlsAudio: true,
+		Prefix:        "transposea",
+		Template:      `transposeall(${1:semitones})`,
+		Samples: `transposeall(-2) // a whole tone lower for the singer
+transposeall('m3')
+transposeall(0) // as written`,
+		Func: func(v interface{}) (interface{}, error) {
+			if name, ok := getValue(v).(string); ok {
+				if _, err := core.ParseInterval(name); err != nil {
+					return nil, err
+				}
+			} else if _, ok := getValue(v).(int); !ok {
+				return nil, fmt.Errorf("semitones must be an integer or a named interval, got (%T) %v", v, v)
+			}
+			return control.NewTranspose(getHasValue(v), ctx), nil
+		}})
+
+	registerFunction(eval, "density", Function{
+		Title:         "Density macro parameter",
+		Description:   "set the global density [0..1] that thins out (< 0.5) or intensifies (> 0.5) the probability and dynamic operators; default is 0.5",
+		ControlsAudio: true,
+		Prefix:        "dens",
+		Template:      `density(${1:density})`,
+		Samples: `density(0.2) // fewer and softer notes
+k = knob(1,16) // values from [0..127] are mapped to [0..1]
+density(k)`,
+		Func: func(v interface{}) (interface{}, error) {
+			return control.NewDensity(getHasValue(v)), nil
 		}})
 
 	registerFunction(eval, "duration", Function{
@@ -393,11 +1176,11 @@ l = loop(bpm(speedup),sequence('c e g'),next(speedup))`,
 		Prefix:      "dur",
 		Template:    `duration(${1:object})`,
 		Samples:     `duration(note('c')) // => 375ms`,
-		Func: func(m interface{}) time.Duration {
+		Func: func(m interface{}) (time.Duration, error) {
 			if s, ok := getSequenceable(m); ok {
-				return s.S().DurationAt(ctx.Control().BPM())
+				return s.S().DurationAt(ctx.Control().BPM()), nil
 			}
-			return time.Duration(0)
+			return time.Duration(0), nil
 		}})
 
 	registerFunction(eval, "biab", Function{
@@ -407,29 +1190,32 @@ l = loop(bpm(speedup),sequence('c e g'),next(speedup))`,
 		Prefix:        "biab",
 		Template:      `biab(${1:beats-in-a-bar})`,
 		Samples:       `biab(4)`,
-		Func: func(i int) interface{} {
+		Func: func(i int) (interface{}, error) {
 			if i < 1 {
-				return notify.Panic(fmt.Errorf("invalid beats-in-a-bar, must be positive, %d = ", i))
+				return nil, fmt.Errorf("invalid beats-in-a-bar, must be positive, %d = ", i)
 			}
 			ctx.Control().SetBIAB(i)
-			return nil
+			return nil, nil
 		}})
 
 	registerFunction(eval, "import", Function{
-		Title:         "Import script",
-		Description:   "evaluate all the statements from another file",
+		Title: "Import script",
+		Description: `evaluate all the statements from another file, relative to the directory of the current script.
+Use it to share kits, scales and riffs across songs`,
+		Alias:         "include",
 		ControlsAudio: false,
 		Template:      `import(${1:filename})`,
-		Samples:       `import('drumpatterns.mel')`,
-		Func: func(f string) interface{} {
+		Samples: `import('drumpatterns.mel')
+include('lib/drums.mel') // lib/drums.mel can include('kit.mel') from lib`,
+		Func: func(f string) (interface{}, error) {
 			if !ctx.Capabilities().ImportMelrose {
-				return notify.NewWarningf("import not available")
+				return notify.NewWarningf("import not available"), nil
 			}
-			err := ImportProgram(ctx, f)
+			err := owner.importProgram(f)
 			if err != nil {
-				return notify.Panic(fmt.Errorf("failed to import [%s], %v", f, err))
+				return nil, fmt.Errorf("failed to import [%s], %v", f, err)
 			}
-			return nil
+			return nil, nil
 		},
 	})
 
@@ -443,12 +1229,16 @@ l = loop(bpm(speedup),sequence('c e g'),next(speedup))`,
 sequence('(8c d e)') // => (8C D E)
 sequence('c (d e f) a =')`,
 		IsCore: true,
-		Func: func(s string) interface{} {
+		Func: func(s string) (interface{}, error) {
+			s, err := localNotation(ctx, s)
+			if err != nil {
+				return nil, err
+			}
 			sq, err := core.ParseSequence(s)
 			if err != nil {
-				return notify.Panic(err)
+				return nil, err
 			}
-			return sq
+			return sq, nil
 		}})
 
 	registerFunction(eval, "note", Function{
@@ -459,12 +1249,50 @@ sequence('c (d e f) a =')`,
 		Samples: `note('e')
 note('2.e#--')`,
 		IsCore: true,
-		Func: func(s string) interface{} {
+		Func: func(s string) (interface{}, error) {
+			s, err := localNotation(ctx, s)
+			if err != nil {
+				return nil, err
+			}
 			n, err := core.ParseNote(s)
 			if err != nil {
-				return notify.Panic(err)
+				return nil, err
+			}
+			return n, nil
+		}})
+
+	registerFunction(eval, "hz", Function{
+		Title:       "Note from frequency creator",
+		Description: `create a Note from a frequency in Hz with the nearest pitch ; its deviation in cents is played using pitch bend on its channel. The optional duration is 1 = whole, 2 = half, 4 = quarter (default), 8 = eight, 16 = sixteenth or a float value between 0 and 1`,
+		Template:    `hz(${1:frequency},${2:duration})`,
+		Samples: `hz(440.0) // => A4
+hz(450,8) // => 8A4 and 39 cents higher`,
+		IsCore: true,
+		Func: func(frequency interface{}, duration ...interface{}) (interface{}, error) {
+			if !isNumeric(frequency) {
+				return nil, fmt.Errorf("frequency must be a number, got %v (%T)", frequency, frequency)
+			}
+			fraction := float32(0.25)
+			if len(duration) > 1 {
+				return nil, fmt.Errorf("expected a frequency and an optional duration")
+			}
+			if len(duration) == 1 {
+				if !isNumeric(duration[0]) {
+					return nil, fmt.Errorf("duration must be a number, got %v (%T)", duration[0], duration[0])
+				}
+				fraction = core.Float(getHasValue(duration[0]))
+				if fraction > 1.0 {
+					fraction = 1.0 / fraction
+				}
+				if fraction <= 0 {
+					return nil, fmt.Errorf("duration must be > 0")
+				}
+			}
+			hz, ok := core.ValueOf(frequency).(float64)
+			if !ok {
+				hz = float64(core.ValueOf(frequency).(int))
 			}
-			return n
+			return core.FrequencyToNote(hz, fraction)
 		}})
 
 	registerFunction(eval, "scale", Function{
@@ -475,34 +1303,78 @@ note('2.e#--')`,
 		IsCore:      true,
 		Samples: `
 // E major
-scale('e') // => E G_ A_ A B D_5 E_5
+scale('e') // => E F# G# A B C#5 D#5
 / E minor
-scale('e/m') // => E F G A B C5 D5
+scale('e/m') // => E F# G A B C5 D5
 // E flat minor
-scale('e_/m') // => E_ E G_ A_ B_ B D_5
+scale('e_/m') // => E_ F G_ A_ B_ B D_5
 `,
-		Func: func(s string) interface{} {
+		Func: func(s string) (interface{}, error) {
+			s, err := localNotation(ctx, s)
+			if err != nil {
+				return nil, err
+			}
 			sc, err := core.NewScale(s)
 			if err != nil {
 				notify.Print(notify.NewError(err))
-				return nil
+				return nil, nil
 			}
-			return sc
+			return sc, nil
 		}})
 
+	diatonicChords := func(name string, sevenths bool) func(scale interface{}) (interface{}, error) {
+		return func(scale interface{}) (interface{}, error) {
+			if s, ok := getValue(scale).(string); ok {
+				s, err := localNotation(ctx, s)
+				if err != nil {
+					return nil, err
+				}
+				sc, err := core.NewScale(s)
+				if err != nil {
+					return nil, err
+				}
+				return core.NewDiatonicChords(core.On(sc), sevenths), nil
+			}
+			if _, ok := getValue(scale).(core.Scale); !ok {
+				return nil, fmt.Errorf("%s requires a scale, got (%T) %s", name, scale, core.Storex(scale))
+			}
+			return core.NewDiatonicChords(getHasValue(scale), sevenths), nil
+		}
+	}
+
+	registerFunction(eval, "triads", Function{
+		Title:       "Diatonic triads creator",
+		Description: "create the triads on each degree of a scale ; use at to select the chord of a degree",
+		Prefix:      "tri",
+		Template:    `triads(${1:scale})`,
+		IsComposer:  true,
+		Samples: `triads(scale('c')) // => C Dm Em F G Am Bdim
+at(5,triads('a/m')) // => (E5 G5 B5)`,
+		Func: diatonicChords("triads", false)})
+
+	registerFunction(eval, "sevenths", Function{
+		Title:       "Diatonic seventh chords creator",
+		Description: "create the seventh chords on each degree of a scale ; use at to select the chord of a degree",
+		Prefix:      "sev",
+		Template:    `sevenths(${1:scale})`,
+		IsComposer:  true,
+		Samples: `sevenths(scale('c')) // => Cmaj7 Dm7 Em7 Fmaj7 G7 Am7 Bm7b5
+at(5,sevenths('c')) // => (G B D5 F5)`,
+		Func: diatonicChords("sevenths", true)})
+
 	registerFunction(eval, "at", Function{
 		Title:       "Index getter",
 		Description: "create an index getter (1-based) to select a musical object",
 		Prefix:      "at",
 		Template:    `at(${1:index},${2:object})`,
 		Samples:     `at(1,scale('e/m')) // => E`,
-		Func: func(index interface{}, object interface{}) interface{} {
+		Func: func(index interface{}, object interface{}) (interface{}, error) {
 			indexVal := getHasValue(index)
 			objectSeq, ok := getSequenceable(object)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot index (%T) %v", object, object))
+				return nil, fmt.Errorf("cannot index (%T) %v", object, object)
 			}
-			return op.NewAtIndex(indexVal, objectSeq)
+			return op.NewAtIndex(indexVal, objectSeq), nil
 		}})
 
 	registerFunction(eval, "onbar", Function{
@@ -511,12 +1383,12 @@ scale('e_/m') // => E_ E G_ A_ B_ B D_5
 		Prefix:      "onbar",
 		Template:    `onbar(${1:bar},${2:object})`,
 		Samples:     `tr = track("solo",2, onbar(1,soloSequence)) // 2 = channel`,
-		Func: func(bar interface{}, seq interface{}) interface{} {
+		Func: func(bar interface{}, seq interface{}) (interface{}, error) {
 			s, ok := getSequenceable(seq)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot put on track (%T) %v", seq, seq))
+				return nil, fmt.Errorf("cannot put on track (%T) %v", seq, seq)
 			}
-			return core.NewSequenceOnTrack(getHasValue(bar), s)
+			return core.NewSequenceOnTrack(getHasValue(bar), s), nil
 		}})
 
 	registerFunction(eval, "random", Function{
@@ -526,10 +1398,41 @@ scale('e_/m') // => E_ E G_ A_ B_ B D_5
 		Template:    `random(${1:from},${2:to})`,
 		Samples: `num = random(1,10)
 next(num)`,
-		Func: func(from interface{}, to interface{}) interface{} {
+		Func: func(from interface{}, to interface{}) (interface{}, error) {
 			fromVal := getHasValue(from)
 			toVal := getHasValue(to)
-			return op.NewRandomInteger(fromVal, toVal)
+			return op.NewRandomInteger(fromVal, toVal), nil
+		}})
+
+	registerFunction(eval, "freeze", Function{
+		Title:       "Freeze generator",
+		Description: "hold the current value of a generator, such as random or interval, until thawed ; next() on the freeze advances the generator and takes its new value",
+		Prefix:      "fre",
+		Template:    `freeze(${1:generator})`,
+		Samples: `r = random(-1,1)
+f = freeze(r)
+lp = loop(transpose(f,sequence('c e')),octave(f,sequence('g')),next(f)) // same value for both`,
+		Func: func(generator interface{}) (interface{}, error) {
+			if getValue(generator) == nil {
+				return nil, fmt.Errorf("cannot freeze (%T) %v", generator, generator)
+			}
+			return op.NewFreeze(getHasValue(generator)), nil
+		}})
+
+	registerFunction(eval, "thaw", Function{
+		Title:       "Thaw operator",
+		Description: "take the current value of the generator of a freeze ; like next(), the function itself does not return a value",
+		Prefix:      "tha",
+		Template:    `thaw(${1:freeze})`,
+		Samples: `f = freeze(random(1,4))
+lp = loop(repeat(f,sequence('c')),thaw(f))`,
+		Func: func(frozen interface{}) (interface{}, error) {
+			if _, ok := frozen.(*op.Freeze); !ok {
+				if _, ok := getValue(frozen).(*op.Freeze); !ok {
+					return nil, fmt.Errorf("cannot thaw (%T) %v, must be a freeze", frozen, frozen)
+				}
+			}
+			return op.Thawer{Target: getHasValue(frozen)}, nil
 		}})
 
 	registerFunction(eval, "play", Function{
@@ -539,7 +1442,7 @@ next(num)`,
 		Prefix:        "pla",
 		Template:      `play(${1:sequenceable})`,
 		Samples:       `play(s1,s2,s3) // play s3 after s2 after s1`,
-		Func: func(playables ...interface{}) interface{} {
+		Func: func(playables ...interface{}) (interface{}, error) {
 			list := []core.Sequenceable{}
 			for _, p := range playables {
 				// first check Playable
@@ -554,7 +1457,7 @@ next(num)`,
 					notify.Warnf("cannot play (%T) %v", p, p)
 				}
 			}
-			return control.NewPlay(ctx, list, false)
+			return control.NewPlay(ctx, list, false), nil
 		}})
 
 	registerFunction(eval, "sync", Function{
@@ -565,12 +1468,12 @@ next(num)`,
 		Template:      `sync(${1:object})`,
 		Samples: `sync(s1,s2,s3) // play s1,s2 and s3 at the same time
 sync(loop1,loop2) // begin loop2 at the next start of loop1`,
-		Func: func(playables ...interface{}) interface{} {
+		Func: func(playables ...interface{}) (interface{}, error) {
 			vals := []core.HasValue{}
 			for _, p := range playables {
 				vals = append(vals, getHasValue(p))
 			}
-			return control.NewSyncPlay(vals)
+			return control.NewSyncPlay(vals), nil
 		}})
 
 	registerFunction(eval, "ungroup", Function{
@@ -581,17 +1484,17 @@ sync(loop1,loop2) // begin loop2 at the next start of loop1`,
 		IsComposer:  true,
 		Samples: `ungroup(chord('e')) // => E G B
 ungroup(sequence('(c d)'),note('e')) // => C D E`,
-		Func: func(playables ...interface{}) interface{} {
+		Func: func(playables ...interface{}) (interface{}, error) {
 			joined := []core.Sequenceable{}
 			for _, p := range playables {
 				if s, ok := getSequenceable(p); !ok {
 					notify.NewWarningf("cannot ungroup (%T) %v", p, p)
-					return nil
+					return nil, nil
 				} else {
 					joined = append(joined, s)
 				}
 			}
-			return op.Serial{Target: joined}
+			return op.Serial{Target: joined}, nil
 		}})
 
 	registerFunction(eval, "octave", Function{
@@ -601,17 +1504,17 @@ ungroup(sequence('(c d)'),note('e')) // => C D E`,
 		Template:    `octave(${1:offset},${2:sequenceable})`,
 		IsComposer:  true,
 		Samples:     `octave(1,sequence('c d')) // => C5 D5`,
-		Func: func(scalarOrVar interface{}, playables ...interface{}) interface{} {
+		Func: func(scalarOrVar interface{}, playables ...interface{}) (interface{}, error) {
 			list := []core.Sequenceable{}
 			for _, p := range playables {
 				if s, ok := getSequenceable(p); !ok {
 					notify.NewWarningf("cannot octave (%T) %v", p, p)
-					return nil
+					return nil, nil
 				} else {
 					list = append(list, s)
 				}
 			}
-			return op.Octave{Target: list, Offset: core.ToHasValue(scalarOrVar)}
+			return op.Octave{Target: list, Offset: core.ToHasValue(scalarOrVar)}, nil
 		}})
 
 	registerFunction(eval, "bare", Function{
@@ -620,27 +1523,27 @@ ungroup(sequence('(c d)'),note('e')) // => C D E`,
 		ControlsAudio: false,
 		Template:      `bare(somevar,othervar)`,
 		Samples:       `b = bare(sequence('.2F+++ =')) // => 2F`,
-		Func: func(playables ...interface{}) interface{} {
+		Func: func(playables ...interface{}) (interface{}, error) {
 			list := []core.Sequenceable{}
 			for _, p := range playables {
 				if s, ok := getSequenceable(p); !ok {
 					notify.NewWarningf("cannot bare (%T) %v", p, p)
-					return nil
+					return nil, nil
 				} else {
 					list = append(list, s)
 				}
 			}
-			return op.Bare{Target: list}
+			return op.Bare{Target: list}, nil
 		}})
 
 	registerFunction(eval, "record", Function{
 		Title:         "Recording creator",
-		Description:   "create a recorded sequence of notes from the current MIDI input device using the currrent BPM",
+		Description:   "create a recorded sequence of notes from the current MIDI input device using the currrent BPM ; while the sustain pedal is down, notes are held",
 		ControlsAudio: true,
 		Template:      `record(rec)`,
 		Samples: `rec = sequence('') // variable to store the recorded sequence
 record(rec) // record notes played on the current input device`,
-		Func: func(varOrDeviceSelector interface{}) interface{} {
+		Func: func(varOrDeviceSelector interface{}) (interface{}, error) {
 			var injectable variable
 			deviceID, _ := ctx.Device().DefaultDeviceIDs()
 			if ds, ok := varOrDeviceSelector.(core.DeviceSelector); ok {
@@ -649,17 +1552,50 @@ record(rec) // record notes played on the current input device`,
 				if v, ok := first.(variable); ok {
 					injectable = v
 				} else {
-					return notify.Panic(fmt.Errorf("missing variable parameter"))
+					return nil, fmt.Errorf("missing variable parameter")
 				}
 			} else {
 				// must be variable
 				if v, ok := varOrDeviceSelector.(variable); ok {
 					injectable = v
 				} else {
-					return notify.Panic(fmt.Errorf("missing variable parameter"))
+					return nil, fmt.Errorf("missing variable parameter")
 				}
 			}
-			return control.NewRecording(deviceID, injectable.Name, ctx.Control().BPM())
+			return control.NewRecording(deviceID, injectable.Name, ctx.Control().BPM()), nil
+		}})
+
+	registerFunction(eval, "recordtakes", Function{
+		Title:         "Recording takes creator",
+		Description:   "create a number of recorded takes in a row, each a number of bars long, from a MIDI input device using the current BPM and beats in a bar. Use at() to select a take",
+		ControlsAudio: true,
+		Template:      `recordtakes(${1:count},${2:bars})`,
+		Samples: `takes = recordtakes(4,2) // 4 takes of 2 bars each from the current input device
+play(takes) // start recording the takes
+best = at(3,takes) // select the third take
+takes1 = recordtakes(1,4,2) // 4 takes of 2 bars each from input device 1`,
+		Func: func(params ...interface{}) (interface{}, error) {
+			deviceID, _ := ctx.Device().DefaultDeviceIDs()
+			if len(params) == 3 {
+				id, ok := getValue(params[0]).(int)
+				if !ok {
+					return nil, fmt.Errorf("device id must be an integer, got %v (%T)", params[0], params[0])
+				}
+				deviceID = id
+				params = params[1:]
+			}
+			if len(params) != 2 {
+				return nil, fmt.Errorf("expected count and bars, got %d parameter(s)", len(params))
+			}
+			count, ok := getValue(params[0]).(int)
+			if !ok || count < 1 {
+				return nil, fmt.Errorf("count must be a positive integer, got %v (%T)", params[0], params[0])
+			}
+			bars, ok := getValue(params[1]).(int)
+			if !ok || bars < 1 {
+				return nil, fmt.Errorf("bars must be a positive integer, got %v (%T)", params[1], params[1])
+			}
+			return control.NewTakes(ctx, deviceID, count, bars), nil
 		}})
 
 	registerFunction(eval, "undynamic", Function{
@@ -669,11 +1605,11 @@ record(rec) // record notes played on the current input device`,
 		Template:    `undynamic(${1:sequenceable})`,
 		IsComposer:  true,
 		Samples:     `undynamic('A+ B++ C-- D-') // =>  A B C D`,
-		Func: func(value interface{}) interface{} {
+		Func: func(value interface{}) (interface{}, error) {
 			if s, ok := getSequenceable(value); !ok {
-				return notify.Panic(fmt.Errorf("cannot undynamic (%T) %v", value, value))
+				return nil, fmt.Errorf("cannot undynamic (%T) %v", value, value)
 			} else {
-				return op.Undynamic{Target: s}
+				return op.Undynamic{Target: s}, nil
 			}
 		}})
 
@@ -686,10 +1622,10 @@ record(rec) // record notes played on the current input device`,
 p = transpose(i,note('c'))
 lp = loop(p,next(i))
 		`,
-		Func: func(values ...interface{}) *core.Iterator {
+		Func: func(values ...interface{}) (*core.Iterator, error) {
 			return &core.Iterator{
 				Target: values,
-			}
+			}, nil
 		}})
 
 	registerFunction(eval, "rotate", Function{
@@ -698,15 +1634,15 @@ lp = loop(p,next(i))
 		Template:    `rotate(${1:count},${2:object})`,
 		Samples: `rotate(-1,sequence('C E G')) // E G C
 			`,
-		Func: func(count interface{}, m interface{}) interface{} {
+		Func: func(count interface{}, m interface{}) (interface{}, error) {
 			s, ok := getSequenceable(m)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot rotate (%T) %v", s, s))
+				return nil, fmt.Errorf("cannot rotate (%T) %v", s, s)
 			}
 			return op.Rotate{
 				Times:  getHasValue(count),
 				Target: s,
-			}
+			}, nil
 		}})
 
 	registerFunction(eval, "stretch", Function{
@@ -717,12 +1653,12 @@ lp = loop(p,next(i))
 		Samples: `stretch(2,note('c'))  // 2C
 stretch(0.25,sequence('(c e g)'))  // (16C 16E 16G)
 stretch(8,note('c'))  // C with length of 8 x 0.25 (quarter) = 2 bars`,
-		Func: func(factor interface{}, m ...interface{}) interface{} {
+		Func: func(factor interface{}, m ...interface{}) (interface{}, error) {
 			list, ok := getSequenceableList(m...)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot stretch (%T) %v", m, m))
+				return nil, fmt.Errorf("cannot stretch (%T) %v", m, m)
 			}
-			return op.NewStretch(getHasValue(factor), list)
+			return op.NewStretch(getHasValue(factor), list), nil
 		}})
 
 	registerFunction(eval, "group", Function{
@@ -732,11 +1668,11 @@ stretch(8,note('c'))  // C with length of 8 x 0.25 (quarter) = 2 bars`,
 		Template:    `group(${1:sequenceable})`,
 		Samples:     `group(sequence('c d e')) // => (C D E)`,
 		IsComposer:  true,
-		Func: func(value interface{}) interface{} {
+		Func: func(value interface{}) (interface{}, error) {
 			if s, ok := getSequenceable(value); !ok {
-				return notify.Panic(fmt.Errorf("cannot group (%T) %v", value, value))
+				return nil, fmt.Errorf("cannot group (%T) %v", value, value)
 			} else {
-				return op.Group{Target: s}
+				return op.Group{Target: s}, nil
 			}
 		}})
 
@@ -749,17 +1685,85 @@ stretch(8,note('c'))  // C with length of 8 x 0.25 (quarter) = 2 bars`,
 		Template:      `loop(${1:object})`,
 		Samples: `cb = sequence('c d e f g a b')
 loop(cb,reverse(cb))`,
-		Func: func(playables ...interface{}) interface{} {
+		Func: func(playables ...interface{}) (interface{}, error) {
 			joined := []core.Sequenceable{}
 			for _, p := range playables {
 				if s, ok := getSequenceable(p); !ok {
 					notify.Warnf("cannot loop (%T) %v", p, p)
-					return nil
+					return nil, nil
 				} else {
 					joined = append(joined, s)
 				}
 			}
-			return core.NewLoop(ctx, joined)
+			return core.NewLoop(ctx, joined), nil
+		}})
+
+	registerFunction(eval, "polyloop", Function{
+		Title:         "Polymeter loop creator",
+		Description:   "create a new loop from one or more musical objects with its own number of beats in a bar, such as 5 against the 4 of other loops. Loops with different meters drift apart until they are aligned",
+		ControlsAudio: true,
+		Prefix:        "polyl",
+		Template:      `polyloop(${1:beats},${2:object})`,
+		Samples: `five = polyloop(5,sequence('c5 e5 g5 e5 g5'))
+four = loop(sequence('c3 g3 c3 g3'))
+play(five,four)`,
+		Func: func(beats interface{}, playables ...interface{}) (interface{}, error) {
+			meter, ok := getValue(beats).(int)
+			if !ok || meter < 1 {
+				return nil, fmt.Errorf("beats in a bar must be a positive integer, got (%T) %v", beats, beats)
+			}
+			joined := []core.Sequenceable{}
+			for _, p := range playables {
+				s, ok := getSequenceable(p)
+				if !ok {
+					return nil, fmt.Errorf("cannot loop (%T) %v", p, p)
+				}
+				joined = append(joined, s)
+			}
+			return core.NewPolyLoop(ctx, meter, joined), nil
+		}})
+
+	registerFunction(eval, "tempo", Function{
+		Title:         "Tempo changer",
+		Description:   "create a copy of a loop or track that plays at the BPM multiplied by a factor, such as 0.5 for half-time against other loops. Loops with different tempi drift apart until they are aligned",
+		ControlsAudio: true,
+		Template:      `tempo(${1:factor},${2:loop-or-track})`,
+		Samples: `half = tempo(0.5,loop(sequence('c3 g3')))
+play(half,loop(sequence('c e g e')))`,
+		Func: func(factor, m interface{}) (interface{}, error) {
+			if !isNumeric(getValue(factor)) {
+				return nil, fmt.Errorf("factor must be a number, got (%T) %v", factor, factor)
+			}
+			f := float64(core.Float(getHasValue(factor)))
+			if f <= 0 {
+				return nil, fmt.Errorf("factor must be positive, got %v", f)
+			}
+			switch v := getValue(m).(type) {
+			case *core.Loop:
+				return v.WithTempo(f), nil
+			case *core.Track:
+				return v.WithTempo(f), nil
+			}
+			return nil, fmt.Errorf("cannot change the tempo of (%T) %v, use stretch for other objects", m, m)
+		}})
+
+	registerFunction(eval, "align", Function{
+		Title:         "Align loops",
+		Description:   "make the next iteration of one or more running loops start at the next bar of the leading loop, such that loops with different meters are together again",
+		ControlsAudio: true,
+		Template:      `align(${1:loop})`,
+		Samples: `five = polyloop(5,sequence('c5 e5 g5 e5 g5'))
+play(five,loop(sequence('c3 g3 c3 g3')))
+align(five)`,
+		Func: func(vars ...variable) (interface{}, error) {
+			for _, each := range vars {
+				l, ok := each.Value().(*core.Loop)
+				if !ok {
+					return nil, fmt.Errorf("cannot align (%T) %v", each.Value(), each.Value())
+				}
+				l.Realign()
+			}
+			return nil, nil
 		}})
 
 	registerFunction(eval, "stop", Function{
@@ -771,10 +1775,10 @@ loop(cb,reverse(cb))`,
 play(l1)
 stop(l1)
 stop() // stop all playables`,
-		Func: func(vars ...variable) interface{} {
+		Func: func(vars ...variable) (interface{}, error) {
 			if len(vars) == 0 {
 				StopAllPlayables(ctx)
-				return nil
+				return nil, nil
 			}
 			for _, each := range vars {
 				if l, ok := each.Value().(core.Stoppable); ok {
@@ -784,7 +1788,22 @@ stop() // stop all playables`,
 					notify.Warnf("cannot stop (%T) %v", each.Value(), each.Value())
 				}
 			}
-			return nil
+			return nil, nil
+		}})
+
+	registerFunction(eval, "panic", Function{
+		Title:         "Panic",
+		Description:   "send All Notes Off and All Sound Off to all channels of all open output devices and forget all scheduled notes",
+		ControlsAudio: true,
+		Template:      `panic()`,
+		Samples:       `panic() // silence all hanging notes`,
+		Func: func() (interface{}, error) {
+			reg, ok := ctx.Device().(*midi.DeviceRegistry)
+			if !ok {
+				return notify.NewWarningf("panic is not available for this device"), nil
+			}
+			reg.Panic()
+			return nil, nil
 		}})
 
 	// END Loop and control
@@ -795,28 +1814,30 @@ stop() // stop all playables`,
 		Prefix:        "chan",
 		Template:      `channel(${1:number},${2:sequenceable})`,
 		Samples:       `channel(2,sequence('c2 e3')) // plays on instrument connected to MIDI channel 2`,
-		Func: func(midiChannel interface{}, m interface{}) interface{} {
+		Func: func(midiChannel interface{}, m interface{}) (interface{}, error) {
 			seq, ok := getSequenceable(m)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot decorate with channel (%T) %s", m, core.Storex(m)))
+				return nil, fmt.Errorf("cannot decorate with channel (%T) %s", m, core.Storex(m))
 			}
-			return core.NewChannelSelector(seq, getHasValue(midiChannel))
+			return core.NewChannelSelector(seq, getHasValue(midiChannel)), nil
 		}})
 
 	registerFunction(eval, "fractionmap", Function{
 		Title:       "Fraction Map operator",
-		Description: "create a sequence with notes for which the fractions are changed. 1-based indexing. use space or comma as separator",
+		Description: "create a sequence with notes for which the fractions (durations) are changed. 1-based indexing. use space or comma as separator",
+		Alias:       "durationmap",
 		Prefix:      "frm",
 		Template:    `fractionmap('${1:fraction-mapping}',${2:object})`,
 		IsComposer:  true,
 		Samples: `fractionmap('3:. 2:4,1:2',sequence('c e g')) // => .G E 2C
-fractionmap('. 8 2',sequence('c e g')) // => .C 8E 2G`,
-		Func: func(indices interface{}, m interface{}) interface{} {
+fractionmap('. 8 2',sequence('c e g')) // => .C 8E 2G
+durationmap('1:8 2:8 3:4. 4:2',sequence('c d e f')) // => 8C 8D .E 2F`,
+		Func: func(indices interface{}, m interface{}) (interface{}, error) {
 			s, ok := getSequenceable(m)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot fractionmap (%T) %v", m, m))
+				return nil, fmt.Errorf("cannot fractionmap (%T) %v", m, m)
 			}
-			return op.NewFractionMap(getHasValue(indices), s)
+			return op.NewFractionMap(getHasValue(indices), s), nil
 		}})
 
 	// eval["input"] = Function{
@@ -860,14 +1881,18 @@ c2 = key(device(1,note('c2'))) // C2 key on input device 1
 c2 = key(device(1,channel(2,note('c2'))) // C2 key on input device 1 and channel 2
 c2 = key(channel(3,note('c2')) // C2 key on the default input device and channel 3`,
 		ControlsAudio: true,
-		Func: func(noteEntry interface{}) interface{} {
+		Func: func(noteEntry interface{}) (interface{}, error) {
 			// check string
 			if s, ok := noteEntry.(string); ok {
+				s, err := localNotation(ctx, s)
+				if err != nil {
+					return nil, err
+				}
 				note, err := core.ParseNote(s)
 				if err != nil {
-					return notify.Panic(fmt.Errorf("cannot create Note with input %q", note))
+					return nil, fmt.Errorf("cannot create Note with input %q", note)
 				}
-				return control.NewKey(1, 1, note)
+				return control.NewKey(1, 1, note), nil
 			}
 			deviceID, _ := ctx.Device().DefaultDeviceIDs()
 			channel := 1 // TODO
@@ -886,7 +1911,7 @@ c2 = key(channel(3,note('c2')) // C2 key on the default input device and channel
 			if n, ok := getValue(noteEntry).(core.Note); ok {
 				note = n // TODO
 			}
-			return control.NewKey(deviceID, channel, note)
+			return control.NewKey(deviceID, channel, note), nil
 		}})
 
 	registerFunction(eval, "knob", Function{
@@ -898,18 +1923,18 @@ B1 = 20 // MIDI number assigned to this knob on the controller
 k = knob(axiom,B1)
 transpose(k,scale(1,'E')) // when played, use the current value of knob "k"`,
 		ControlsAudio: true,
-		Func: func(deviceIDOrVar interface{}, numberOrVar interface{}) interface{} {
+		Func: func(deviceIDOrVar interface{}, numberOrVar interface{}) (interface{}, error) {
 			deviceID, ok := getValue(deviceIDOrVar).(int)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot create knob with device (%T) %v", deviceIDOrVar, deviceIDOrVar))
+				return nil, fmt.Errorf("cannot create knob with device (%T) %v", deviceIDOrVar, deviceIDOrVar)
 			}
 			number, ok := getValue(numberOrVar).(int)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot create knob with number (%T) %v", numberOrVar, numberOrVar))
+				return nil, fmt.Errorf("cannot create knob with number (%T) %v", numberOrVar, numberOrVar)
 			}
 			k := control.NewKnob(deviceID, 0, number)
 			ctx.Device().Listen(deviceID, k, true)
-			return k
+			return k, nil
 		}})
 
 	registerFunction(eval, "onkey", Function{
@@ -927,9 +1952,9 @@ axiom = 1 // device ID for the M-Audio Axiom 25
 c2 = key(device(axiom,note('c2')))
 fun = play(scale(2,'c')) // what to do when a key is pressed (NoteOn)
 onkey(c2, fun) // if C2 is pressed on the axiom device then evaluate the function "fun"`,
-		Func: func(keyOrVar interface{}, playOrEval interface{}) interface{} {
+		Func: func(keyOrVar interface{}, playOrEval interface{}) (interface{}, error) {
 			if !ctx.Device().HasInputCapability() {
-				return notify.Panic(errors.New("input is not available for this device"))
+				return nil, errors.New("input is not available for this device")
 			}
 			var key control.Key
 			// key is mandatory
@@ -942,7 +1967,7 @@ onkey(c2, fun) // if C2 is pressed on the axiom device then evaluate the functio
 			} else {
 				keyVar, ok := getValue(keyOrVar).(control.Key)
 				if !ok {
-					return notify.Panic(fmt.Errorf("cannot install onkey because parameter is not a key (%T) %v", keyOrVar, keyOrVar))
+					return nil, fmt.Errorf("cannot install onkey because parameter is not a key (%T) %v", keyOrVar, keyOrVar)
 				}
 				key = keyVar
 			}
@@ -950,47 +1975,149 @@ onkey(c2, fun) // if C2 is pressed on the axiom device then evaluate the functio
 			if playOrEval == nil {
 				// uninstall binding
 				ctx.Device().OnKey(ctx, key.DeviceID(), key.Channel(), key.Note(), nil)
-				return nil
+				return nil, nil
 			}
 			_, ok = getValue(playOrEval).(core.Playable)
 			if !ok {
 				_, ok = getValue(playOrEval).(core.Evaluatable)
 				if !ok {
-					return notify.Panic(fmt.Errorf("cannot onkey and call (%T) %s", playOrEval, core.Storex(playOrEval)))
+					return nil, fmt.Errorf("cannot onkey and call (%T) %s", playOrEval, core.Storex(playOrEval))
 				}
 			}
 			err := ctx.Device().OnKey(ctx, key.DeviceID(), key.Channel(), key.Note(), getHasValue(playOrEval))
 			if err != nil {
-				return notify.Panic(fmt.Errorf("cannot install onkey because error:%v", err))
+				return nil, fmt.Errorf("cannot install onkey because error:%v", err)
+			}
+			return nil, nil
+		}})
+
+	registerFunction(eval, "notetrigger", Function{
+		Title: "Note trigger creator",
+		Description: `Assign a playable, an evaluatable or a statement to a MIDI note number of an input device, e.g. a pad of a Launchpad.
+If the note is pressed, on any channel, then a playable is started or stopped if it was started, an evaluatable or a statement (text) is evaluated.
+Remove the assignment using the value nil`,
+		ControlsAudio: true,
+		Prefix:        "notet",
+		Template:      `notetrigger(${1:device-id},${2:note-number},${3:playable-or-evaluatable-or-statement})`,
+		Samples: `pad = 1 // device ID for the Launchpad
+notetrigger(pad,36,drums) // if pad 36 is pressed then start or stop the loop "drums"
+notetrigger(pad,37,'bpm(140)') // if pad 37 is pressed then evaluate the statement
+notetrigger(pad,36,nil) // remove the assignment of pad 36`,
+		Func: func(deviceIDOrVar, numberOrVar, playOrEval interface{}) (interface{}, error) {
+			if !ctx.Device().HasInputCapability() {
+				return nil, errors.New("input is not available for this device")
+			}
+			deviceID, ok := getValue(deviceIDOrVar).(int)
+			if !ok {
+				return nil, fmt.Errorf("device must be an integer, got (%T) %s", deviceIDOrVar, core.Storex(deviceIDOrVar))
+			}
+			number, ok := getValue(numberOrVar).(int)
+			if !ok || number < 0 || number > 127 {
+				return nil, fmt.Errorf("note number must be an integer in [0..127], got (%T) %s", numberOrVar, core.Storex(numberOrVar))
+			}
+			note, err := core.MIDItoNote(0.25, number, core.Normal)
+			if err != nil {
+				return nil, err
 			}
-			return nil
+			var fun core.HasValue
+			switch v := getValue(playOrEval).(type) {
+			case nil:
+				// uninstall binding
+			case string:
+				fun = core.On(statementTrigger{entry: v, evaluator: owner})
+			case core.Playable, core.Evaluatable:
+				fun = getHasValue(playOrEval)
+			default:
+				return nil, fmt.Errorf("cannot notetrigger and call (%T) %s", playOrEval, core.Storex(playOrEval))
+			}
+			// any channel
+			if err := ctx.Device().OnKey(ctx, deviceID, 0, note, fun); err != nil {
+				return nil, fmt.Errorf("cannot install notetrigger because error:%v", err)
+			}
+			return nil, nil
 		}})
 
 	registerFunction(eval, "device", Function{
 		Title:         "MIDI device selector",
-		Description:   "select a MIDI device from the available device IDs; must become before channel",
+		Description:   "select a MIDI device from the available device IDs; must become before channel. Can also route a track to a device",
 		ControlsAudio: true,
 		Prefix:        "dev",
 		Template:      `device(${1:number},${2:sequenceable})`,
-		Samples:       `device(1,channel(2,sequence('c2 e3'))) // plays on connected device 1 through MIDI channel 2`,
-		Func: func(deviceID interface{}, m interface{}) interface{} {
+		Samples: `device(1,channel(2,sequence('c2 e3'))) // plays on connected device 1 through MIDI channel 2
+device(2,track('bass',1,onbar(1,sequence('c2 e2')))) // track plays on connected device 2 through MIDI channel 1`,
+		Func: func(deviceID interface{}, m interface{}) (interface{}, error) {
+			if tr, ok := getValue(m).(*core.Track); ok {
+				id, ok := getValue(deviceID).(int)
+				if !ok {
+					return nil, fmt.Errorf("integer device argument expected, got (%T) %v", deviceID, deviceID)
+				}
+				return tr.WithDevice(id), nil
+			}
 			seq, ok := getSequenceable(m)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot decorate with device (%T) %s", m, core.Storex(m)))
+				return nil, fmt.Errorf("cannot decorate with device (%T) %s", m, core.Storex(m))
 			}
-			return core.NewDeviceSelector(seq, getHasValue(deviceID))
+			return core.NewDeviceSelector(seq, getHasValue(deviceID)), nil
+		}})
+
+	registerFunction(eval, "inputdevice", Function{
+		Title:       "MIDI input device finder",
+		Description: "returns the ID of the first MIDI input device for which the name contains the text (case-insensitive)",
+		Prefix:      "inputd",
+		Template:    `inputdevice('${1:name}')`,
+		Samples: `arturia = inputdevice('Arturia')
+set('midi.in',arturia) // default MIDI input device is the Arturia keyboard`,
+		Func: func(name string) (interface{}, error) {
+			reg, ok := ctx.Device().(*midi.DeviceRegistry)
+			if !ok {
+				return nil, errors.New("input devices cannot be found by name for this device")
+			}
+			id, err := reg.InputDeviceID(name)
+			if err != nil {
+				return nil, err
+			}
+			return id, nil
+		}})
+
+	registerFunction(eval, "outputdevice", Function{
+		Title:       "MIDI output device finder",
+		Description: "returns the ID of the first MIDI output device for which the name contains the text (case-insensitive)",
+		Prefix:      "outputd",
+		Template:    `outputdevice('${1:name}')`,
+		Samples: `fluid = outputdevice('Fluid')
+device(fluid,sequence('c e g')) // plays on the FluidSynth output device`,
+		Func: func(name string) (interface{}, error) {
+			reg, ok := ctx.Device().(*midi.DeviceRegistry)
+			if !ok {
+				return nil, errors.New("output devices cannot be found by name for this device")
+			}
+			id, err := reg.OutputDeviceID(name)
+			if err != nil {
+				return nil, err
+			}
+			return id, nil
 		}})
 
 	registerFunction(eval, "interval", Function{
 		Title:       "Interval creator",
-		Description: "create an integer repeating interval (from,to,by,method). Default method is 'repeat', Use next() to get a new integer",
+		Description: "create an integer repeating interval (from,to,by,method). Default method is 'repeat', others are 'once' (then hold), 'two-way' (up, down then hold), 'ping-pong' (up and down), 'random' (within the interval) and 'geometric' (by is a factor). Use next() to get a new integer",
 		Prefix:      "int",
 		Template:    `interval(${1:from},${2:to},${3:by})`,
 		Samples: `int1 = interval(-2,4,1)
-lp_cdef = loop(transpose(int1,sequence('c d e f')), next(int1))`,
+lp_cdef = loop(transpose(int1,sequence('c d e f')), next(int1))
+int2 = interval(0,4,2,'ping-pong') // => 0 2 4 2 0 2 ...
+int3 = interval(1,8,2,'geometric') // => 1 2 4 8 1 ...`,
 		IsComposer: true,
-		Func: func(from, to, by interface{}) *core.Interval {
-			return core.NewInterval(core.ToHasValue(from), core.ToHasValue(to), core.ToHasValue(by), core.RepeatFromTo)
+		Func: func(from, to, by interface{}, method ...string) (*core.Interval, error) {
+			strategy := core.RepeatFromTo
+			if len(method) > 0 {
+				s, err := core.ParseIntervalStrategy(method[0])
+				if err != nil {
+					return nil, err
+				}
+				strategy = s
+			}
+			return core.NewInterval(core.ToHasValue(from), core.ToHasValue(to), core.ToHasValue(by), strategy), nil
 		}})
 
 	registerFunction(eval, "resequence", Function{
@@ -1002,12 +2129,12 @@ lp_cdef = loop(transpose(int1,sequence('c d e f')), next(int1))`,
 i1 = resequence('6 5 4 3 2 1',s1) // => B A G F E D
 i2 = resequence('(6 5) 4 3 (2 1)',s1) // => (B A) G F (E D)`,
 		IsComposer: true,
-		Func: func(pattern, m interface{}) interface{} {
+		Func: func(pattern, m interface{}) (interface{}, error) {
 			s, ok := getSequenceable(m)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot create resequencer on (%T) %v", m, m))
+				return nil, fmt.Errorf("cannot create resequencer on (%T) %v", m, m)
 			}
-			return op.NewResequencer(s, core.ToHasValue(pattern))
+			return op.NewResequencer(s, core.ToHasValue(pattern)), nil
 		}})
 
 	registerFunction(eval, "notemap", Function{
@@ -1017,12 +2144,12 @@ i2 = resequence('(6 5) 4 3 (2 1)',s1) // => (B A) G F (E D)`,
 		IsComposer:  true,
 		Samples: `m1 = notemap('..!..!..!', note('c2'))
 m2 = notemap('3 6 9', octave(-1,note('d2')))`,
-		Func: func(indices string, note interface{}) interface{} {
+		Func: func(indices string, note interface{}) (interface{}, error) {
 			m, err := op.NewNoteMap(indices, getHasValue(note))
 			if err != nil {
-				return notify.Panic(fmt.Errorf("cannot create notemap, error:%v", err))
+				return nil, fmt.Errorf("cannot create notemap, error:%v", err)
 			}
-			return m
+			return m, nil
 		}})
 
 	registerFunction(eval, "merge", Function{
@@ -1033,44 +2160,52 @@ m2 = notemap('3 6 9', octave(-1,note('d2')))`,
 m2 = notemap('4 7 10', note('d2'))
 all = merge(m1,m2) // => = = C2 D2 = C2 D2 = C2 D2 = =`,
 		IsComposer: true,
-		Func: func(seqs ...interface{}) op.Merge {
+		Func: func(seqs ...interface{}) (op.Merge, error) {
 			s := []core.Sequenceable{}
 			for _, each := range seqs {
 				seq, ok := getSequenceable(each)
 				if ok {
 					s = append(s, seq)
 				} else {
-					notify.Panic(fmt.Errorf("cannot merge (%T) %v", each, each))
+					return op.Merge{}, fmt.Errorf("cannot merge (%T) %v", each, each)
 				}
 			}
-			return op.Merge{Target: s}
+			return op.Merge{Target: s}, nil
 		}})
 
 	registerFunction(eval, "if", Function{
 		Title:       "Conditional operator",
 		Template:    `if(${1:condition},${2:then},${3:else})`,
-		Description: "Supports conditions with operators on numbers: <,<=,>,>=,!=,==",
-		Samples:     ``,
-		Func: func(c interface{}, thenelse ...interface{}) interface{} {
+		Description: "Supports conditions with operators on numbers: <,<=,>,>=,!=,==. Then and else are musical objects or numbers",
+		Samples: `i = interval(1,6,1)
+m = if(i > 3, sequence('c e g'), sequence('d f a'))
+bpm(if(i > 3, 120, 90))`,
+		Func: func(c interface{}, thenelse ...interface{}) (interface{}, error) {
 			if len(thenelse) == 0 {
-				notify.Panic(fmt.Errorf("requires at least a <then>"))
+				return nil, fmt.Errorf("requires at least a <then>")
 			}
 			if len(thenelse) > 2 {
-				notify.Panic(fmt.Errorf("requires at most a <then> and an <else>"))
+				return nil, fmt.Errorf("requires at most a <then> and an <else>")
+			}
+			if isNumeric(thenelse[0]) {
+				if len(thenelse) == 1 || !isNumeric(thenelse[1]) {
+					return nil, fmt.Errorf("requires a number for both <then> and <else>")
+				}
+				return calc.Conditional{Condition: getHasValue(c), Then: thenelse[0], Else: thenelse[1]}, nil
 			}
 			thenarg, ok := getSequenceable(thenelse[0])
 			if !ok {
-				notify.Panic(fmt.Errorf("cannot conditional use (%T) %v", thenelse[0], thenelse[0]))
+				return nil, fmt.Errorf("cannot conditional use (%T) %v", thenelse[0], thenelse[0])
 			}
 			ifop := op.IfCondition{Condition: getHasValue(c), Then: thenarg, Else: core.EmptySequence}
 			if len(thenelse) == 2 {
 				elsearg, ok := getSequenceable(thenelse[1])
 				if !ok {
-					notify.Panic(fmt.Errorf("cannot conditional use (%T) %v", thenelse[1], thenelse[1]))
+					return nil, fmt.Errorf("cannot conditional use (%T) %v", thenelse[1], thenelse[1])
 				}
 				ifop.Else = elsearg
 			}
-			return ifop
+			return ifop, nil
 		},
 	})
 
@@ -1078,40 +2213,46 @@ all = merge(m1,m2) // => = = C2 D2 = C2 D2 = C2 D2 = =`,
 		Title:       "Value operator",
 		Description: "returns the current value of a variable",
 		Template:    `value(${1:variable})`,
-		Func: func(v interface{}) interface{} {
+		Func: func(v interface{}) (interface{}, error) {
 			return core.ValueFunction{
 				StoreString: fmt.Sprintf("value(%s)", core.Storex(v)),
 				Function: func() interface{} {
 					return core.ValueOf(v)
 				},
-			}
+			}, nil
 		},
 	})
 	registerFunction(eval, "index", Function{
 		Title:       "Index operator",
 		Template:    `index(${1:generator})`,
 		Description: "returns the current index of an object (e.g. iterator,interval,repeat)",
-		Func: func(v interface{}) interface{} {
+		Func: func(v interface{}) (interface{}, error) {
 			return core.ValueFunction{
 				StoreString: fmt.Sprintf("index(%s)", core.Storex(v)),
 				Function: func() interface{} {
 					return core.IndexOf(v)
 				},
-			}
+			}, nil
 		},
 	})
 
 	registerFunction(eval, "next", Function{
 		Title:    "Next operator",
 		Template: `next(${1:generator})`,
-		Description: `is used to produce the next value in a generator such as random, iterator and interval.
-The function itself does not return the value; use the generator for that.`,
+		Description: `is used to produce the next value in one or more generators such as random, iterator and interval.
+The function itself does not return the value; use the generator for that. Multiple generators advance together.`,
 		Samples: `i = interval(-4,4,2)
 pi = transpose(i,sequence('c d e f g a b')) // current value of "i" is used
 lp_pi = loop(pi,next(i)) // "i" will advance to the next value
-begin(lp_pi)`,
-		Func: func(v interface{}) interface{} {
-			return core.Nexter{Target: getHasValue(v)}
+begin(lp_pi)
+r = random(1,4)
+lp_both = loop(repeat(r,pi),next(i,r)) // "i" and "r" advance together`,
+		Func: func(v interface{}, others ...interface{}) (interface{}, error) {
+			targets := []core.HasValue{getHasValue(v)}
+			for _, each := range others {
+				targets = append(targets, getHasValue(each))
+			}
+			return core.Nexter{Targets: targets}, nil
 		}})
 
 	registerFunction(eval, "export", Function{
@@ -1119,21 +2260,194 @@ begin(lp_pi)`,
 		Description: `writes a multi-track MIDI file`,
 		Template:    `export(${1:filename},${2:sequenceable})`,
 		Samples:     `export('myMelody-v1',myObject)`,
-		Func: func(filename string, m interface{}) interface{} {
+		Func: func(filename string, m interface{}) (interface{}, error) {
 			if !ctx.Capabilities().ExportMIDI {
-				return notify.NewWarningf("export MIDI not available")
+				return notify.NewWarningf("export MIDI not available"), nil
 			}
 			if len(filename) == 0 {
-				return notify.Panic(fmt.Errorf("missing filename to export MIDI %v", m))
+				return nil, fmt.Errorf("missing filename to export MIDI %v", m)
 			}
 			_, ok := getSequenceable(m)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot MIDI export (%T) %v", m, m))
+				return nil, fmt.Errorf("cannot MIDI export (%T) %v", m, m)
+			}
+			if !strings.HasSuffix(filename, "mid") {
+				filename += ".mid"
+			}
+			return file.Export(filename, getValue(m), ctx.Control().BPM(), ctx.Control().BIAB()), nil
+		}})
+
+	registerFunction(eval, "exportloop", Function{
+		Title:       "Export loop command",
+		Description: `writes a MIDI file with the notes of a number of iterations of a loop, as if it was playing, such that changes in each iteration, e.g. by next or fill, are captured`,
+		Template:    `exportloop(${1:filename},${2:loop},${3:iterations})`,
+		Samples: `i = interval(0,4,2)
+lp = loop(transpose(i,sequence('c e g')),next(i))
+exportloop('myLoop-v1',lp,4) // transposed by 0,2,4,0`,
+		Func: func(filename string, m interface{}, iterations interface{}) (interface{}, error) {
+			if !ctx.Capabilities().ExportMIDI {
+				return notify.NewWarningf("export MIDI not available"), nil
+			}
+			if len(filename) == 0 {
+				return nil, fmt.Errorf("missing filename to export MIDI %v", m)
+			}
+			lp, ok := getValue(m).(*core.Loop)
+			if !ok {
+				return nil, fmt.Errorf("cannot MIDI export loop iterations of (%T) %v", m, m)
+			}
+			count, ok := getValue(iterations).(int)
+			if !ok || count < 1 {
+				return nil, fmt.Errorf("iterations must be a positive integer, got %v (%T)", iterations, iterations)
 			}
 			if !strings.HasSuffix(filename, "mid") {
 				filename += ".mid"
 			}
-			return file.Export(filename, getValue(m), ctx.Control().BPM(), ctx.Control().BIAB())
+			return file.Export(filename, lp.Iterations(count), ctx.Control().BPM(), ctx.Control().BIAB()), nil
+		}})
+
+	registerFunction(eval, "exportlily", Function{
+		Title:       "Export LilyPond command",
+		Description: `writes a LilyPond source file with a staff for each track, with bars from the current BIAB, to engrave a score`,
+		Template:    `exportlily(${1:filename},${2:sequenceable})`,
+		Samples:     `exportlily('score.ly',myObject)`,
+		Func: func(filename string, m interface{}) (interface{}, error) {
+			if len(filename) == 0 {
+				return nil, fmt.Errorf("missing filename to export LilyPond %v", m)
+			}
+			if !strings.HasSuffix(filename, ".ly") {
+				filename += ".ly"
+			}
+			return export.ExportLilyPond(filename, getValue(m), ctx.Control().BPM(), ctx.Control().BIAB()), nil
+		}})
+
+	registerFunction(eval, "exportcsv", Function{
+		Title:       "Export CSV command",
+		Description: `writes a CSV file with a row for each note event of each track: start and duration in beats, MIDI number, velocity and channel`,
+		Template:    `exportcsv(${1:filename},${2:sequenceable})`,
+		Samples:     `exportcsv('notes.csv',myObject)`,
+		Func: func(filename string, m interface{}) (interface{}, error) {
+			if len(filename) == 0 {
+				return nil, fmt.Errorf("missing filename to export CSV %v", m)
+			}
+			if !strings.HasSuffix(filename, ".csv") {
+				filename += ".csv"
+			}
+			return export.ExportCSV(filename, getValue(m), ctx.Control().BPM(), ctx.Control().BIAB()), nil
+		}})
+
+	registerFunction(eval, "exportxml", Function{
+		Title:       "Export MusicXML command",
+		Description: `writes a MusicXML file with a part for each track, with bars from the current BIAB, to open in MuseScore, Finale or Sibelius`,
+		Template:    `exportxml(${1:filename},${2:sequenceable})`,
+		Samples:     `exportxml('score.musicxml',myObject)`,
+		Func: func(filename string, m interface{}) (interface{}, error) {
+			if len(filename) == 0 {
+				return nil, fmt.Errorf("missing filename to export MusicXML %v", m)
+			}
+			if !strings.HasSuffix(filename, ".musicxml") && !strings.HasSuffix(filename, ".xml") {
+				filename += ".musicxml"
+			}
+			return export.ExportMusicXML(filename, getValue(m), ctx.Control().BPM(), ctx.Control().BIAB()), nil
+		}})
+
+	registerFunction(eval, "engrave", Function{
+		Title:       "Engrave command",
+		Description: `writes an SVG or PNG image with staff notation (clef, note heads, accidentals and bars) of a musical object, for each track a staff. A PNG is written if the filename ends with .png`,
+		Template:    `engrave(${1:filename},${2:sequenceable})`,
+		Samples: `engrave('melody.svg',melody)
+engrave('chords.png',progression('c','I vi IV V'))`,
+		Func: func(filename string, m interface{}) (interface{}, error) {
+			if len(filename) == 0 {
+				return nil, fmt.Errorf("missing filename to engrave %v", m)
+			}
+			if !strings.HasSuffix(filename, ".svg") && !strings.HasSuffix(filename, ".png") {
+				filename += ".svg"
+			}
+			return export.Engrave(filename, getValue(m), ctx.Control().BIAB()), nil
+		}})
+
+	registerFunction(eval, "abc", Function{
+		Title:       "ABC notation",
+		Description: `create a Sequence from the first tune in <a href="https://abcnotation.com">ABC notation</a>. Repeats are expanded`,
+		Template:    `abc('${1:tune}')`,
+		Samples: `abc('L:1/4\nK:G\n|: GABc dedB :|') // => G A B C5 D5 E5 D5 B G A B C5 D5 E5 D5 B
+abc('CDEF') // => 8C 8D 8E 8F`,
+		Func: func(tune string) (interface{}, error) {
+			s, err := abc.Parse(tune)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ABC notation, %v", err)
+			}
+			return s, nil
+		}})
+
+	registerFunction(eval, "importabc", Function{
+		Title:       "Import ABC notation",
+		Description: `reads a file with <a href="https://abcnotation.com">ABC notation</a> and returns a Sequence of the first tune`,
+		Template:    `importabc(${1:filename})`,
+		Samples:     `s = importabc('reel.abc')`,
+		Func: func(filename string) (interface{}, error) {
+			s, err := abc.ParseFile(filename)
+			if err != nil {
+				return nil, fmt.Errorf("failed to import ABC [%s], %v", filename, err)
+			}
+			return s, nil
+		}})
+
+	registerFunction(eval, "importxml", Function{
+		Title:       "Import MusicXML",
+		Description: `reads a MusicXML (partwise) file and returns a sequence, or a multitrack if it has multiple parts. Only the first voice of each part is read`,
+		Template:    `importxml(${1:filename})`,
+		Samples:     `s = importxml('score.musicxml')`,
+		Func: func(filename string) (interface{}, error) {
+			v, err := export.ImportMusicXML(filename)
+			if err != nil {
+				return nil, fmt.Errorf("failed to import MusicXML [%s], %v", filename, err)
+			}
+			return v, nil
+		}})
+
+	registerFunction(eval, "importhydrogen", Function{
+		Title:       "Import Hydrogen drum pattern",
+		Description: `reads a <a href="http://hydrogen-music.org">Hydrogen</a> song (.h2song) or pattern (.h2pattern) file and returns the drum pattern as a sequence on channel 10. The pattern is selected by its index (1-based) or name ; default is the first`,
+		Template:    `importhydrogen(${1:filename})`,
+		Samples: `beat = importhydrogen('rock.h2song') // first pattern
+fill = importhydrogen('rock.h2song','fill') // pattern by name
+loop(beat,beat,beat,fill)`,
+		Func: func(filename string, pattern ...interface{}) (interface{}, error) {
+			if len(pattern) > 1 {
+				return nil, fmt.Errorf("expected a filename and an optional pattern index or name")
+			}
+			var selector interface{}
+			if len(pattern) == 1 {
+				selector = getValue(pattern[0])
+			}
+			s, err := hydrogen.ParseFile(filename, selector)
+			if err != nil {
+				return nil, fmt.Errorf("failed to import Hydrogen [%s], %v", filename, err)
+			}
+			return s, nil
+		}})
+
+	registerFunction(eval, "renderaudio", Function{
+		Title:       "Render audio command",
+		Description: `writes a WAV file with the audio of an object, rendered with the SoundFont of the device (-audio sf2=<file>) at the current BPM`,
+		Template:    `renderaudio(${1:filename},${2:sequenceable})`,
+		Samples:     `renderaudio('myMelody-v1.wav',myObject)`,
+		Func: func(filename string, m interface{}) (interface{}, error) {
+			dev, ok := ctx.Device().(*synth.Device)
+			if !ok {
+				return nil, fmt.Errorf("renderaudio requires a SoundFont, start melrose with -audio sf2=<file>")
+			}
+			if len(filename) == 0 {
+				return nil, fmt.Errorf("missing filename to render audio %v", m)
+			}
+			if _, ok := getSequenceable(m); !ok {
+				return nil, fmt.Errorf("cannot render audio (%T) %v", m, m)
+			}
+			if !strings.HasSuffix(filename, ".wav") {
+				filename += ".wav"
+			}
+			return synth.ExportWAV(filename, dev.Font(), getValue(m), ctx.Control().BPM()), nil
 		}})
 
 	registerFunction(eval, "trim", Function{
@@ -1141,15 +2455,15 @@ begin(lp_pi)`,
 		Description: `create a new sequence object with notes trimmed at the start or/and at the end.`,
 		Template:    `trim(${1:remove-from-start},${2:remove-from-end},${3:object})`,
 		Samples:     `t = trim(1,2,sequence('c d e f a') // d e`,
-		Func: func(skipStart, skipEnd, object interface{}) interface{} {
+		Func: func(skipStart, skipEnd, object interface{}) (interface{}, error) {
 			s, ok := getSequenceable(object)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot trim non-sequenceable"))
+				return nil, fmt.Errorf("cannot trim non-sequenceable")
 			}
 			return op.Trim{
 				Start:  getHasValue(skipStart),
 				End:    getHasValue(skipEnd),
-				Target: s}
+				Target: s}, nil
 		}})
 
 	registerFunction(eval, "tabs", Function{
@@ -1157,36 +2471,50 @@ begin(lp_pi)`,
 		Description: `Create a tabs using this <a href="/docs/reference/notations/#tabs">format</a>`,
 		Template:    `tabs($1:string)`,
 		Samples:     `bass = tabs('E e3 a2 a5 d5 a5 a3 e3 G24')`,
-		Func: func(notation string) interface{} {
+		Func: func(notation string) (interface{}, error) {
 			t, err := core.ParseBassTablature(notation)
 			if err != nil {
-				return notify.Panic(fmt.Errorf("invalid tabs syntax: %v", err))
+				return nil, fmt.Errorf("invalid tabs syntax: %v", err)
 			}
-			return t
+			return t, nil
 		}})
 
 	registerFunction(eval, "replace", Function{
-		Title:       "Replace operator",
-		Description: `replaces all occurrences of one musical object with another object for a given composed musical object`,
-		Template:    `replace(${1:target},${2:from},${3:to})`,
+		Title: "Replace operator",
+		Description: `replaces all occurrences of one musical object with another object for a given composed musical object.
+Instead of objects, patterns can be replaced in the notes: a pitch class (F#) in any octave, a rhythm value (8.) or a chord quality (/m7) of a chord in root position`,
+		Template: `replace(${1:target},${2:from},${3:to})`,
 		Samples: `c = note('c')
 d = note('d')
 pitchA = transpose(1,c)
-pitchD = replace(pitchA, c, d) // c -> d in pitchA`,
-		Func: func(target interface{}, from, to interface{}) interface{} {
+pitchD = replace(pitchA, c, d) // c -> d in pitchA
+replace(sequence('f g f a'),'f','f#') // => G_ G G_ A
+replace(sequence('8c 8d e'),'8','16') // => 16C 16D E
+replace(chordsequence('c d/m'),'/m','/m7') // => (C E G) (D F A C5)`,
+		Func: func(target interface{}, from, to interface{}) (interface{}, error) {
 			targetS, ok := getSequenceable(target)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot create replace inside (%T) %v", target, target))
+				return nil, fmt.Errorf("cannot create replace inside (%T) %v", target, target)
+			}
+			if fromPattern, ok := getValue(from).(string); ok {
+				toPattern, ok := getValue(to).(string)
+				if !ok {
+					return nil, fmt.Errorf("cannot replace pattern [%s] with (%T) %v, expected a pattern", fromPattern, to, to)
+				}
+				if err := op.CheckReplacePatterns(fromPattern, toPattern); err != nil {
+					return nil, err
+				}
+				return op.ReplacePattern{Target: targetS, From: fromPattern, To: toPattern}, nil
 			}
 			fromS, ok := getSequenceable(from)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot create replace (%T) %v", from, from))
+				return nil, fmt.Errorf("cannot create replace (%T) %v", from, from)
 			}
 			toS, ok := getSequenceable(to)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot create replace with (%T) %v", to, to))
+				return nil, fmt.Errorf("cannot create replace with (%T) %v", to, to)
 			}
-			return op.Replace{Target: targetS, From: fromS, To: toS}
+			return op.Replace{Target: targetS, From: fromS, To: toS}, nil
 		}})
 
 	registerFunction(eval, "midi_send", Function{
@@ -1197,8 +2525,8 @@ pitchD = replace(pitchA, c, d) // c -> d in pitchA`,
 midi_send(1,0xC0,2,1,0) // program change, select program 1 for channel 2
 midi_send(2,0xB0,4,0,16) // control change, bank select 16 for channel 4
 midi_send(3,0xB0,1,120,0) // control change, all notes off for channel 1`,
-		Func: func(deviceID int, status int, channel, data1, data2 interface{}) interface{} {
-			return midi.NewMessage(ctx.Device(), core.On(deviceID), status, core.On(channel), core.On(data1), core.On(data2))
+		Func: func(deviceID int, status int, channel, data1, data2 interface{}) (interface{}, error) {
+			return midi.NewMessage(ctx.Device(), core.On(deviceID), status, core.On(channel), core.On(data1), core.On(data2)), nil
 		}})
 
 	registerFunction(eval, "set", Function{
@@ -1208,48 +2536,305 @@ midi_send(3,0xB0,1,120,0) // control change, all notes off for channel 1`,
 		Template:      "set(${1:setting-name},${2:setting-value})",
 		Samples: `set('midi.in',1) // default MIDI input device is 1
 set('midi.in.channel',2,10) // default MIDI channel for device 2 is 10
-set('midi.out',3) // default MIDI output device is 3`,
-		Func: func(settingName string, settingValues ...interface{}) interface{} {
+set('midi.out',3) // default MIDI output device is 3
+set('midi.out.noteoff',1,'tie') // device 1 keeps a note sounding if it is played again when it ends
+set('midi.out.cc',1,2,7,100) // device 1 sends control change 7 (volume) with value 100 on channel 2 ; device -1 is the default
+set('midi.in.velocity',1,'linear',40,110) // incoming velocities of device 1 are scaled to [40..110]
+set('midi.in.velocity',1,'exp',1,127,0.5) // soft notes of device 1 are louder ; use 'fixed',100 for one velocity or 'off'
+set('midi.out.tuning',1,'rast.scl','rast.kbm') // device 1 plays notes in a Scala tuning using pitch bend ; the .kbm file is optional, use 'off' for 12-TET
+set('notation.names','german') // note names in notations are German, H is B and B is B flat ; also 'solfege' (do re mi) and 'english'`,
+		Func: func(settingName string, settingValues ...interface{}) (interface{}, error) {
+			if settingName == core.NoteNamesKey {
+				if err := setNoteNames(ctx, settingValues); err != nil {
+					notify.Errorf("%v", err)
+				}
+				return nil, nil
+			}
 			if err := ctx.Device().HandleSetting(settingName, settingValues); err != nil {
 				notify.Errorf("%v", err)
 			}
-			return nil
+			return nil, nil
 		},
 	})
 
 	registerFunction(eval, "listen", Function{
 		Title:       "Start a MIDI listener",
-		Description: "Listen for note(s) from a device and call a playable function to handle",
+		Description: "Listen for note(s) from a device, optionally on one MIDI channel, and call a playable function to handle ; optionally only for notes in a range (inclusive) such that multiple listeners can split a keyboard. The held notes are stored as a chord in the variable with the suffix _chord",
 		Template:    "listen(${1:variable-or-device-selector},${2:function})",
 		Samples: `rec = note('c') // define a variable "rec" with a initial object ; this is a place holder
 fun = play(rec) // define the playable function to call when notes are received ; loop and print are also possible
 listen(rec,fun) // start a listener for notes from default input device, store it in "rec" and call "fun"
-listen(device(1,rec),fun) // start a listener for notes from input device 1`,
-		Func: func(varOrDeviceSelector interface{}, function interface{}) interface{} {
+listen(device(1,rec),fun) // start a listener for notes from input device 1
+listen(device(1,channel(2,rec)),fun) // only notes on MIDI channel 2 from input device 1
+listen(1,2,rec,fun) // same as above
+low = note('c2')
+high = note('c5')
+bass = listen(low,play(octave(-1,low)),'C0','B3') // notes below middle C play the bass
+chords = listen(high,play(chord(high)),'C4','B9') // notes from middle C play a chord
+rec_chord = sequence('(c e g)') // all notes that are held together are stored in the variable with suffix _chord
+echo = listen(rec,play(octave(1,rec_chord))) // play the held chord an octave higher`,
+		Func: func(varOrSelectorOrDevice interface{}, functionOrChannel interface{}, rest ...interface{}) (interface{}, error) {
+			deviceID, _ := ctx.Device().DefaultDeviceIDs()
+			channel := 0 // any
+			selector, function, fromTo := varOrSelectorOrDevice, functionOrChannel, rest
+			// listen(device,channel,variable,function)
+			if id, ok := getValue(varOrSelectorOrDevice).(int); ok {
+				if len(rest) < 2 {
+					return nil, fmt.Errorf("expected device, channel, variable and function")
+				}
+				ch, ok := getValue(functionOrChannel).(int)
+				if !ok {
+					return nil, fmt.Errorf("channel must be an integer, got (%T) %s", functionOrChannel, core.Storex(functionOrChannel))
+				}
+				deviceID, channel = id, ch
+				selector, function, fromTo = rest[0], rest[1], rest[2:]
+			}
 			_, ok := getValue(function).(core.Evaluatable)
 			if !ok {
-				return notify.Panic(fmt.Errorf("cannot listen and call (%T) %s", function, core.Storex(function)))
+				return nil, fmt.Errorf("cannot listen and call (%T) %s", function, core.Storex(function))
+			}
+			injectable, ok := selector.(variable)
+			// unwrap device and channel selectors
+			for !ok {
+				switch v := selector.(type) {
+				case core.DeviceSelector:
+					deviceID = v.DeviceID()
+					selector = v.Target
+				case core.ChannelSelector:
+					channel = v.Channel()
+					selector = v.Target
+				default:
+					return nil, fmt.Errorf("missing variable parameter")
+				}
+				injectable, ok = selector.(variable)
+			}
+			if channel < 0 || channel > 16 {
+				return nil, fmt.Errorf("MIDI channel must be in [1..16], got %d", channel)
+			}
+			// use function as HasValue and not the Evaluatable to allow redefinition of the callback function in the script
+			listen := control.NewListen(ctx, deviceID, injectable.Name, getHasValue(function))
+			listen.SetChannel(channel)
+			if len(fromTo) == 0 {
+				return listen, nil
+			}
+			if len(fromTo) != 2 {
+				return nil, fmt.Errorf("expected both the lowest and highest note of the range")
+			}
+			from, err := midiNumberOf(fromTo[0])
+			if err != nil {
+				return nil, err
+			}
+			to, err := midiNumberOf(fromTo[1])
+			if err != nil {
+				return nil, err
+			}
+			if from > to {
+				return nil, fmt.Errorf("lowest note of the range must not be higher than the highest, got %d and %d", from, to)
+			}
+			listen.SetRange(from, to)
+			return listen, nil
+		},
+	})
+
+	registerFunction(eval, "steprecord", Function{
+		Title:       "Start a MIDI step recorder",
+		Description: "Append each note, or chord of notes held together, from an input device to the sequence of a variable ; each step has the same fraction. Optionally, a key (note name or MIDI number) appends a rest and a key removes the last step",
+		Template:    "steprecord(${1:variable-or-device-selector},${2:fraction})",
+		Samples: `melody = sequence('') // define a variable "melody" ; recording appends to its notes
+rec = steprecord(melody,8) // each key or chord pressed on the default input device appends an eighth
+play(rec) // start recording
+bass = sequence('')
+rec2 = steprecord(device(1,bass),4,'C2','D2') // on input device 1, key C2 appends a rest and key D2 removes the last step`,
+		ControlsAudio: true,
+		Func: func(varOrDeviceSelector interface{}, fraction interface{}, restAndBack ...interface{}) (interface{}, error) {
+			if !ctx.Device().HasInputCapability() {
+				return nil, errors.New("input is not available for this device")
 			}
-			var injectable variable
 			deviceID, _ := ctx.Device().DefaultDeviceIDs()
-			if ds, ok := varOrDeviceSelector.(core.DeviceSelector); ok {
+			selector := varOrDeviceSelector
+			if ds, ok := selector.(core.DeviceSelector); ok {
 				deviceID = ds.DeviceID()
-				first := ds.Target
-				if v, ok := first.(variable); ok {
-					injectable = v
-				} else {
-					return notify.Panic(fmt.Errorf("missing variable parameter"))
+				selector = ds.Target
+			}
+			injectable, ok := selector.(variable)
+			if !ok {
+				return nil, fmt.Errorf("missing variable parameter")
+			}
+			var frac float32
+			switch f := getValue(fraction).(type) {
+			case int:
+				if f <= 0 {
+					return nil, fmt.Errorf("fraction must be positive, got %d", f)
 				}
-			} else {
-				// must be variable
-				if v, ok := varOrDeviceSelector.(variable); ok {
+				frac = 1.0 / float32(f)
+			case float64:
+				frac = float32(f)
+			default:
+				return nil, fmt.Errorf("fraction must be a number, e.g. 8 or 0.125, got (%T) %s", fraction, core.Storex(fraction))
+			}
+			if _, err := core.MIDItoNote(frac, 60, core.Normal); err != nil {
+				return nil, err
+			}
+			restKey, backKey := -1, -1
+			if len(restAndBack) > 0 {
+				if len(restAndBack) != 2 {
+					return nil, fmt.Errorf("expected both the rest and the back key")
+				}
+				keys := []int{}
+				for _, each := range restAndBack {
+					if i, ok := getValue(each).(int); ok && i == -1 {
+						// not used
+						keys = append(keys, -1)
+						continue
+					}
+					nr, err := midiNumberOf(each)
+					if err != nil {
+						return nil, err
+					}
+					keys = append(keys, nr)
+				}
+				restKey, backKey = keys[0], keys[1]
+			}
+			return control.NewStepRecord(ctx, deviceID, injectable.Name, frac, restKey, backKey), nil
+		},
+	})
+
+	registerFunction(eval, "link", Function{
+		Title:       "Ableton Link synchronization",
+		Description: "share the tempo and the bars with other Link-enabled applications on the local network, such as Ableton Live and Bitwig ; a change of BPM on either side is followed by all",
+		Template:    "link(${1:true})",
+		Samples: `link(true) // join the session of the network ; loops start on its bars
+bpm(90) // the other applications follow
+link(false)`,
+		ControlsAudio: true,
+		Func: func(enabled interface{}) (interface{}, error) {
+			on, ok := getValue(enabled).(bool)
+			if !ok {
+				return nil, fmt.Errorf("link expects true or false, got (%T) %s", enabled, core.Storex(enabled))
+			}
+			if !on {
+				link.Disable(ctx)
+				return nil, nil
+			}
+			return nil, link.Enable(ctx)
+		},
+	})
+
+	registerFunction(eval, "osclisten", Function{
+		Title:       "Start an OSC listener",
+		Description: "Listen for OSC messages on a UDP port of the host set by the -osc.host flag (default 127.0.0.1), store the first argument of each message that matches the address pattern in a variable and optionally call a function",
+		Template:    "osclisten(${1:port},'${2:address}',${3:variable},${4:function})",
+		Samples: `level = 0 // define a variable "level" with a initial value
+osclisten(8000,'/1/fader1',level) // a TouchOSC fader changes the value of "level"
+hit = 0
+osclisten(8000,'/pads/*',hit,play(note('c'))) // play a note for any pad ; patterns can use * ? and [..]`,
+		Func: func(port int, address string, varName interface{}, function ...interface{}) (interface{}, error) {
+			injectable, ok := varName.(variable)
+			if !ok {
+				return nil, fmt.Errorf("missing variable parameter")
+			}
+			if len(address) == 0 || address[0] != '/' {
+				return nil, fmt.Errorf("address pattern must start with /")
+			}
+			if len(function) > 1 {
+				return nil, fmt.Errorf("at most one function is allowed")
+			}
+			if len(function) == 0 {
+				return osc.NewListen(ctx, port, address, injectable.Name, nil), nil
+			}
+			if _, ok := getValue(function[0]).(core.Evaluatable); !ok {
+				return nil, fmt.Errorf("cannot listen and call (%T) %s", function[0], core.Storex(function[0]))
+			}
+			// use function as HasValue and not the Evaluatable to allow redefinition of the callback function in the script
+			return osc.NewListen(ctx, port, address, injectable.Name, getHasValue(function[0])), nil
+		},
+	})
+
+	registerFunction(eval, "cclisten", Function{
+		Title:       "Start a MIDI control change listener",
+		Description: "Listen for control changes (CC) with a number from an input device and store each value in a variable ; the value [0..127] can be scaled to [min..max]",
+		Template:    "cclisten(${1:device-id},${2:cc-number},${3:variable})",
+		Samples: `cutoff = 64 // define a variable "cutoff" with a initial value
+knob1 = cclisten(1,74,cutoff) // CC 74 from input device 1 changes the value of "cutoff"
+play(knob1) // start listening
+chance = 100
+knob2 = cclisten(1,75,chance,0,100) // scale the value to [0..100]
+l = loop(prob(chance,sequence('c e g')))`,
+		ControlsAudio: true,
+		Func: func(deviceIDOrVar, numberOrVar, varName interface{}, minMax ...interface{}) (interface{}, error) {
+			if !ctx.Device().HasInputCapability() {
+				return nil, errors.New("input is not available for this device")
+			}
+			deviceID, ok := getValue(deviceIDOrVar).(int)
+			if !ok {
+				return nil, fmt.Errorf("device must be an integer, got (%T) %s", deviceIDOrVar, core.Storex(deviceIDOrVar))
+			}
+			number, ok := getValue(numberOrVar).(int)
+			if !ok || number < 0 || number > 127 {
+				return nil, fmt.Errorf("control change number must be an integer in [0..127], got (%T) %s", numberOrVar, core.Storex(numberOrVar))
+			}
+			injectable, ok := varName.(variable)
+			if !ok {
+				return nil, fmt.Errorf("missing variable parameter")
+			}
+			if len(minMax) == 0 {
+				return control.NewCCListen(ctx, deviceID, number, injectable.Name, nil, nil), nil
+			}
+			if len(minMax) != 2 {
+				return nil, fmt.Errorf("expected both min and max")
+			}
+			min, max := getValue(minMax[0]), getValue(minMax[1])
+			if !isNumeric(min) || !isNumeric(max) {
+				return nil, fmt.Errorf("min and max must be numbers, got (%T) %v and (%T) %v", min, min, max, max)
+			}
+			return control.NewCCListen(ctx, deviceID, number, injectable.Name, min, max), nil
+		},
+	})
+
+	registerFunction(eval, "thru", Function{
+		Title:       "Forward MIDI input to an output",
+		Description: "Forward notes from an input device to an output device, optionally applying a transform to each incoming note ; while the sustain pedal is down, notes are held",
+		Template:    "thru(${1:variable-or-device-selector},${2:output-device-id},${3:transform})",
+		Samples: `thru(1,2) // forward all notes from input device 1 to output device 2
+hit = note('c') // define a variable "hit" with a initial object ; this is a place holder
+thru(device(1,hit),2,transpose(7,hit)) // play a fifth higher for each note from input device 1
+thru(hit,2,channel(3,join(hit,transpose(12,hit)))) // add the octave and send to channel 3 ; uses default input device`,
+		Func: func(inputOrVariable, output interface{}, transform ...interface{}) (interface{}, error) {
+			outputID, ok := getValue(output).(int)
+			if !ok {
+				return nil, fmt.Errorf("output device must be an integer, got (%T) %s", output, core.Storex(output))
+			}
+			var deviceID int
+			var injectable variable
+			if id, ok := getValue(inputOrVariable).(int); ok {
+				deviceID = id
+			} else if ds, ok := inputOrVariable.(core.DeviceSelector); ok {
+				deviceID = ds.DeviceID()
+				if v, ok := ds.Target.(variable); ok {
 					injectable = v
 				} else {
-					return notify.Panic(fmt.Errorf("missing variable parameter"))
+					return nil, fmt.Errorf("missing variable parameter")
 				}
+			} else if v, ok := inputOrVariable.(variable); ok {
+				deviceID, _ = ctx.Device().DefaultDeviceIDs()
+				injectable = v
+			} else {
+				return nil, fmt.Errorf("input must be a device id, a variable or a device selector, got (%T) %s", inputOrVariable, core.Storex(inputOrVariable))
 			}
-			// use function as HasValue and not the Evaluatable to allow redefinition of the callback function in the script
-			return control.NewListen(ctx, deviceID, injectable.Name, getHasValue(function))
+			if len(transform) > 1 {
+				return nil, fmt.Errorf("at most one transform is allowed")
+			}
+			if len(transform) == 0 {
+				return control.NewThru(ctx, deviceID, outputID, injectable.Name, nil), nil
+			}
+			if injectable.Name == "" {
+				return nil, fmt.Errorf("a transform requires a variable to hold the incoming note")
+			}
+			if _, ok := getValue(transform[0]).(core.Sequenceable); !ok {
+				return nil, fmt.Errorf("cannot transform with (%T) %s", transform[0], core.Storex(transform[0]))
+			}
+			// use transform as HasValue to allow redefinition in the script
+			return control.NewThru(ctx, deviceID, outputID, injectable.Name, getHasValue(transform[0])), nil
 		},
 	})
 
@@ -1264,14 +2849,18 @@ listen(device(1,rec),fun) // start a listener for notes from input device 1`,
 // A second hit of C4 will stop it
 
 onkey('c4',onoff('e')) // uses default input and default output MIDI device`,
-		Func: func(noteSource string) interface{} {
+		Func: func(noteSource string) (interface{}, error) {
 			// Simple first
 			_, deviceID := ctx.Device().DefaultDeviceIDs()
+			noteSource, err := localNotation(ctx, noteSource)
+			if err != nil {
+				return nil, err
+			}
 			note, err := core.ParseNote(noteSource)
 			if err != nil {
-				notify.Panic(err)
+				return nil, err
 			}
-			return control.NewOnOff(deviceID, 1, note)
+			return control.NewOnOff(deviceID, 1, note), nil
 		},
 	})
 
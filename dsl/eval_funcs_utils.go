@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
 )
 
 func IsCompatibleSyntax(s string) bool {
@@ -101,6 +102,15 @@ func getHasValue(val interface{}) core.HasValue {
 	return core.On(val)
 }
 
+// isNumeric returns whether the (current) value of val is an int or a float.
+func isNumeric(val interface{}) bool {
+	switch core.ValueOf(val).(type) {
+	case int, float64:
+		return true
+	}
+	return false
+}
+
 // getValue returns the Value() of val iff val is a HasValue, else returns val
 func getValue(val interface{}) interface{} {
 	if v, ok := val.(core.HasValue); ok {
@@ -108,3 +118,102 @@ func getValue(val interface{}) interface{} {
 	}
 	return val
 }
+
+// statementTrigger evaluates a statement each time it is triggered, e.g. by a note of a pad controller.
+type statementTrigger struct {
+	entry     string
+	evaluator *Evaluator // if nil then a new one for the context
+}
+
+// Evaluate is part of core.Evaluatable
+func (s statementTrigger) Evaluate(ctx core.Context) error {
+	eval := s.evaluator
+	if eval == nil {
+		eval = NewEvaluator(ctx)
+	}
+	_, err := eval.EvaluateStatement(s.entry)
+	return err
+}
+
+// Storex is part of core.Storable
+func (s statementTrigger) Storex() string {
+	return fmt.Sprintf("'%s'", s.entry)
+}
+
+// midiNumberOf returns the MIDI number of a note name, e.g. 'C4', a note or an integer [0..127].
+func midiNumberOf(val interface{}) (int, error) {
+	switch v := core.ValueOf(val).(type) {
+	case int:
+		if v < 0 || v > 127 {
+			return 0, fmt.Errorf("MIDI number must be in [0..127], got %d", v)
+		}
+		return v, nil
+	case string:
+		n, err := core.ParseNote(v)
+		if err != nil {
+			return 0, err
+		}
+		return n.MIDI(), nil
+	case core.NoteConvertable:
+		n, err := v.ToNote()
+		if err != nil {
+			return 0, err
+		}
+		return n.MIDI(), nil
+	}
+	return 0, fmt.Errorf("cannot get MIDI number of (%T) %v", val, val)
+}
+
+// checkMIDIChannel returns an error if the channel is not in [1..16].
+func checkMIDIChannel(channel int) error {
+	if channel < 1 || channel > 16 {
+		return fmt.Errorf("MIDI channel must be in [1..16], got %d", channel)
+	}
+	return nil
+}
+
+// localNotation returns the notation with English note names if the note names of the context are in another scheme.
+func localNotation(ctx core.Context, notation string) (string, error) {
+	if ctx.Environment() == nil {
+		return notation, nil
+	}
+	scheme, ok := ctx.Environment().Load(core.NoteNamesKey)
+	if !ok {
+		return notation, nil
+	}
+	return core.TranslateNoteNames(scheme.(string), notation)
+}
+
+// setNoteNames changes the scheme of note names in notations.
+func setNoteNames(ctx core.Context, values []interface{}) error {
+	if len(values) != 1 {
+		return fmt.Errorf("one argument expected")
+	}
+	scheme, ok := values[0].(string)
+	if !ok || !core.IsNoteNames(scheme) {
+		return fmt.Errorf("note names %q, %q or %q expected, got %v", core.EnglishNoteNames, core.GermanNoteNames, core.SolfegeNoteNames, values[0])
+	}
+	if ctx.Environment() == nil {
+		return fmt.Errorf("cannot change note names without an environment")
+	}
+	ctx.Environment().Store(core.NoteNamesKey, scheme)
+	notify.Infof("note names in notations are %s", scheme)
+	return nil
+}
+
+// mixingTrack returns the track for a volume or pan ; a fixed value must be in [0..127].
+func mixingTrack(what string, value, track interface{}) (*core.Track, error) {
+	v := core.ValueOf(value)
+	if i, ok := v.(int); ok {
+		if i < 0 || i > 127 {
+			return nil, fmt.Errorf("%s must be in [0..127], got %d", what, i)
+		}
+	} else if !isNumeric(v) {
+		return nil, fmt.Errorf("%s must be a number, got (%T) %v", what, value, value)
+	}
+	tr, ok := getValue(track).(*core.Track)
+	if !ok {
+		return nil, fmt.Errorf("cannot set the %s of (%T) %v, a track is expected", what, track, track)
+	}
+	return tr, nil
+}
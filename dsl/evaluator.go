@@ -9,20 +9,22 @@ import (
 	"github.com/emicklei/melrose/control"
 	"github.com/emicklei/melrose/core"
 	"github.com/emicklei/melrose/notify"
+	"github.com/emicklei/melrose/osc"
 
 	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
 )
 
 type Evaluator struct {
 	context core.Context
 	funcs   map[string]Function
+	allowed map[string]bool // empty means all functions are allowed
 }
 
 func NewEvaluator(ctx core.Context) *Evaluator {
-	return &Evaluator{
-		context: ctx,
-		funcs:   EvalFunctions(ctx),
-	}
+	e := &Evaluator{context: ctx}
+	e.funcs = evalFunctions(ctx, e)
+	return e
 }
 
 const fourSpaces = "    "
@@ -32,7 +34,37 @@ const fourSpaces = "    "
 // If a line is prefixed by 4 SPACES then that line is appended to the previous.
 // Return the result of the last expression or statement.
 func (e *Evaluator) EvaluateProgram(source string) (interface{}, error) {
-	lines := []string{}
+	statements, err := splitStatements(source)
+	if err != nil {
+		return nil, err
+	}
+	var lastResult interface{}
+	for _, each := range statements {
+		result, err := e.evaluateCleanStatement(each.text)
+		if err != nil {
+			return nil, newError(source, each, err)
+		}
+		// a command such as export reports its failure as its result
+		if failed, ok := result.(error); ok && failed != nil {
+			return nil, newError(source, each, failed)
+		}
+		if result != nil {
+			lastResult = result
+		}
+	}
+	return lastResult, nil
+}
+
+// statement is one or more lines of a program.
+type statement struct {
+	text    string
+	line    int   // zero-based, of the first line
+	offsets []int // in text, of each line
+}
+
+// splitStatements returns the statements of a program ; lines prefixed by TAB or 4 SPACES are appended to the previous.
+func splitStatements(source string) ([]statement, error) {
+	lines := []statement{}
 	splitted := strings.Split(source, "\n")
 	nrOfLastExpression := -1
 	for lineNr, each := range splitted {
@@ -43,24 +75,17 @@ func (e *Evaluator) EvaluateProgram(source string) (interface{}, error) {
 			if nrOfLastExpression+1 != lineNr {
 				return nil, fmt.Errorf("syntax error, line with TAB [%d] must be part of expression", lineNr+1)
 			}
-			lines[len(lines)-1] = withoutTrailingComment(lines[len(lines)-1]) + each // with TAB TODO
+			last := &lines[len(lines)-1]
+			last.text = withoutTrailingComment(last.text)
+			last.offsets = append(last.offsets, len(last.text))
+			last.text += each // with TAB TODO
 			nrOfLastExpression = lineNr
 			continue
 		}
-		lines = append(lines, each)
+		lines = append(lines, statement{text: each, line: lineNr, offsets: []int{0}})
 		nrOfLastExpression = lineNr
 	}
-	var lastResult interface{}
-	for _, each := range lines {
-		result, err := e.evaluateCleanStatement(each)
-		if err != nil {
-			return nil, err
-		}
-		if result != nil {
-			lastResult = result
-		}
-	}
-	return lastResult, nil
+	return lines, nil
 }
 
 func (e *Evaluator) RecoveringEvaluateStatement(entry string) (interface{}, error) {
@@ -80,7 +105,18 @@ func (e *Evaluator) EvaluateStatement(entry string) (interface{}, error) {
 	return e.evaluateCleanStatement(entry)
 }
 
-func (e *Evaluator) evaluateCleanStatement(entry string) (interface{}, error) {
+func (e *Evaluator) evaluateCleanStatement(entry string) (result interface{}, err error) {
+	// a failing statement must not tear down the caller such as the REPL, the HTTP server or a running loop
+	defer func() {
+		if r := recover(); r != nil {
+			result = nil
+			if rerr, ok := r.(error); ok {
+				err = rerr
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
 	// replace all TABs
 	entry = strings.Replace(entry, "\t", " ", -1)
 
@@ -176,15 +212,19 @@ func (e *Evaluator) handleAssignment(varName string, r interface{}) (interface{}
 		if theLoop, ok := r.(*core.Loop); ok {
 			if storedValue, present := e.context.Variables().Get(varName); present {
 				if otherLoop, replaceme := storedValue.(*core.Loop); replaceme {
+					// put first such that the current target can be undone
+					e.assign(varName, otherLoop)
 					otherLoop.SetTarget(theLoop.Target())
+					otherLoop.SetMeter(theLoop.Meter())
+					otherLoop.SetTempo(theLoop.Tempo())
 					r = otherLoop
 				} else {
 					// existing variable but not a Loop
-					e.context.Variables().Put(varName, theLoop)
+					e.assign(varName, theLoop)
 				}
 			} else {
 				// new variable for theLoop
-				e.context.Variables().Put(varName, theLoop)
+				e.assign(varName, theLoop)
 			}
 			return r, nil
 		}
@@ -198,14 +238,85 @@ func (e *Evaluator) handleAssignment(varName string, r interface{}) (interface{}
 			if storedValue, present := e.context.Variables().Get(varName); present {
 				if otherListen, replaceme := storedValue.(*control.Listen); replaceme {
 					otherListen.SetTarget(theListen.Target())
+					otherListen.SetRange(theListen.Range())
+					otherListen.SetChannel(theListen.Channel())
 					r = otherListen
 				} else {
 					// existing variable but not a Listen
-					e.context.Variables().Put(varName, theListen)
+					e.assign(varName, theListen)
 				}
 			} else {
 				// new variable for theLoop
-				e.context.Variables().Put(varName, theListen)
+				e.assign(varName, theListen)
+			}
+			return r, nil
+		}
+		// special case for OSC Listen, same as Listen
+		if theListen, ok := r.(*osc.Listen); ok {
+			if storedValue, present := e.context.Variables().Get(varName); present {
+				if otherListen, replaceme := storedValue.(*osc.Listen); replaceme {
+					otherListen.SetTarget(theListen.Target())
+					r = otherListen
+				} else {
+					// existing variable but not a OSC Listen
+					e.assign(varName, theListen)
+				}
+			} else {
+				e.assign(varName, theListen)
+			}
+			return r, nil
+		}
+		// special case for CC Listen
+		// if the variable refers to an existing cc listen
+		// 		then change the settings of that cc listen
+		//		else store the cc listen
+		if theCC, ok := r.(*control.CCListen); ok {
+			if storedValue, present := e.context.Variables().Get(varName); present {
+				if otherCC, replaceme := storedValue.(*control.CCListen); replaceme {
+					otherCC.Replace(theCC)
+					r = otherCC
+				} else {
+					// existing variable but not a CC Listen
+					e.assign(varName, theCC)
+				}
+			} else {
+				e.assign(varName, theCC)
+			}
+			return r, nil
+		}
+		// special case for Step Record
+		// if the variable refers to an existing step record
+		// 		then change the settings of that step record
+		//		else store the step record
+		if theStepRecord, ok := r.(*control.StepRecord); ok {
+			if storedValue, present := e.context.Variables().Get(varName); present {
+				if otherStepRecord, replaceme := storedValue.(*control.StepRecord); replaceme {
+					otherStepRecord.Replace(theStepRecord)
+					r = otherStepRecord
+				} else {
+					// existing variable but not a Step Record
+					e.assign(varName, theStepRecord)
+				}
+			} else {
+				e.assign(varName, theStepRecord)
+			}
+			return r, nil
+		}
+		// special case for Thru
+		// if the variable refers to an existing thru
+		// 		then change the transform of that thru
+		//		else store the thru
+		if theThru, ok := r.(*control.Thru); ok {
+			if storedValue, present := e.context.Variables().Get(varName); present {
+				if otherThru, replaceme := storedValue.(*control.Thru); replaceme {
+					otherThru.SetTarget(theThru.Target())
+					r = otherThru
+				} else {
+					// existing variable but not a Thru
+					e.assign(varName, theThru)
+				}
+			} else {
+				e.assign(varName, theThru)
 			}
 			return r, nil
 		}
@@ -222,17 +333,32 @@ func (e *Evaluator) handleAssignment(varName string, r interface{}) (interface{}
 					r = storedRecording
 				} else {
 					// existing variable but not a Recording
-					e.context.Variables().Put(varName, theRecording)
+					e.assign(varName, theRecording)
 				}
 			} else {
 				// new variable for theRecording
-				e.context.Variables().Put(varName, theRecording)
+				e.assign(varName, theRecording)
+			}
+			return r, nil
+		}
+
+		// special case for Takes
+		// if the variable refers to existing takes
+		// 		then change the settings of those takes ; recorded takes are kept
+		//		else store the takes
+		if theTakes, ok := r.(*control.Takes); ok {
+			if storedValue, present := e.context.Variables().Get(varName); present {
+				if storedTakes, replaceme := storedValue.(*control.Takes); replaceme {
+					storedTakes.Replace(theTakes)
+					return storedTakes, nil
+				}
 			}
+			e.assign(varName, theTakes)
 			return r, nil
 		}
 
 		// not a Loop or Listen or Recording
-		e.context.Variables().Put(varName, r)
+		e.assign(varName, r)
 		if aware, ok := r.(core.NameAware); ok {
 			aware.VariableName(varName)
 		}
@@ -240,24 +366,19 @@ func (e *Evaluator) handleAssignment(varName string, r interface{}) (interface{}
 	return r, nil
 }
 
+// assign stores the result of an assignment such that it can be undone, if the store supports that.
+func (e *Evaluator) assign(varName string, value interface{}) {
+	if store, ok := e.context.Variables().(*VariableStore); ok {
+		store.Assign(varName, value)
+		return
+	}
+	e.context.Variables().Put(varName, value)
+}
+
 // EvaluateExpression returns the result of an expression (entry) using a given store of variables.
 // The result is either FunctionResult or a "raw" Go object.
 func (e *Evaluator) EvaluateExpression(entry string) (interface{}, error) {
-	options := []expr.Option{}
-	// since 1.14.3
-	for _, each := range []string{"join", "repeat", "trim", "replace", "duration"} {
-		options = append(options, expr.DisableBuiltin(each))
-	}
-	env := envMap{}
-	for k, f := range e.funcs {
-		env[k] = f.Func
-	}
-	for k := range e.context.Variables().Variables() {
-		env[k] = variable{Name: k, store: e.context.Variables()}
-	}
-	options = append(options, expr.Env(env))
-	options = append(options, expr.Patch(new(indexedAccessPatcher)))
-	program, err := expr.Compile(entry, append(options, env.exprOperators()...)...)
+	program, env, err := e.compile(entry, nil)
 	if err != nil {
 		// try parsing the entry as a sequence or chord
 		// this can be requested from the editor to listen to a part of a sequence,chord,note,progression
@@ -282,6 +403,40 @@ func (e *Evaluator) EvaluateExpression(entry string) (interface{}, error) {
 	return expr.Run(program, env)
 }
 
+// compile returns the program of an expression and the environment to run it with.
+// The environment has all allowed functions, all variables and the extra bindings.
+func (e *Evaluator) compile(entry string, extra map[string]interface{}) (*vm.Program, envMap, error) {
+	entry, err := rewritePipes(entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := e.checkAllowed(entry); err != nil {
+		return nil, nil, err
+	}
+	options := []expr.Option{}
+	// since 1.14.3
+	for _, each := range []string{"join", "repeat", "trim", "replace", "duration", "map", "filter"} {
+		options = append(options, expr.DisableBuiltin(each))
+	}
+	env := envMap{}
+	for k, f := range e.funcs {
+		if e.isAllowed(k) {
+			env[k] = f.Func
+		}
+	}
+	for k := range e.context.Variables().Variables() {
+		env[k] = variable{Name: k, store: e.context.Variables()}
+	}
+	for k, v := range extra {
+		env[k] = v
+	}
+	options = append(options, expr.Env(env))
+	options = append(options, expr.Patch(new(indexedAccessPatcher)))
+	options = append(options, expr.Patch(new(unaryMinusPatcher)))
+	program, err := expr.Compile(entry, append(options, env.exprOperators()...)...)
+	return program, env, err
+}
+
 // https://regex101.com/
 var assignmentRegex = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*)\s*=\s*(.*)$`)
 
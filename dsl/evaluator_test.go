@@ -2,6 +2,7 @@ package dsl
 
 import (
 	"testing"
+	"time"
 
 	"github.com/emicklei/melrose/control"
 	"github.com/emicklei/melrose/core"
@@ -224,6 +225,27 @@ func TestEvaluate_Scale_At(t *testing.T) {
 	}
 }
 
+func TestEvaluateArithmetic(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram("base = 100\ni = 4")
+	checkError(t, err)
+	for expression, want := range map[string]interface{}{
+		"base + 10":       110,
+		"base / 3":        33,
+		"i % 3":           1,
+		"(i + 1) * 2":     10,
+		"i * 0.5":         2.0,
+		"-i":              -4,
+		"if(i > 3, 1, 2)": 1,
+	} {
+		r, err := e.EvaluateExpression(expression)
+		checkError(t, err)
+		if got := core.ValueOf(r); got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", expression, got, got, want, want)
+		}
+	}
+}
+
 func TestLineCommentOnBrokenExpression(t *testing.T) {
 	e := newTestEvaluator()
 	r, err := e.EvaluateProgram(
@@ -255,3 +277,278 @@ func TestKeyOnChannelNote(t *testing.T) {
 		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
 	}
 }
+
+type auditionDevice struct {
+	testAudioDevice
+	played core.Sequenceable
+}
+
+func (a *auditionDevice) Play(condition core.Condition, seq core.Sequenceable, bpm float64, beginAt time.Time) (endingAt time.Time) {
+	a.played = seq
+	return beginAt
+}
+
+func TestEvaluator_Audition(t *testing.T) {
+	device := new(auditionDevice)
+	ctx := core.PlayContext{
+		VariableStorage: NewVariableStore(),
+		LoopControl:     new(core.TestLooper),
+		AudioDevice:     device,
+	}
+	ctx.Variables().Put("m", core.MustParseSequence("c e"))
+	e := NewEvaluator(ctx)
+	if _, err := e.Audition("channel(2,fraction(8,m))"); err != nil {
+		t.Fatal(err)
+	}
+	sel, ok := device.played.(core.ChannelSelector)
+	if !ok {
+		t.Fatalf("channel selector expected, got %T", device.played)
+	}
+	if got, want := sel.Channel(), AuditionChannel; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := sel.Unwrap().S().Storex(), "sequence('8C:v35 8E:v35')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := e.Audition("n = note('c')"); err == nil {
+		t.Error("error expected")
+	}
+	if _, ok := ctx.Variables().Get("n"); ok {
+		t.Error("no variable expected")
+	}
+}
+
+func TestCCListen(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram("cutoff = 0\nk = cclisten(1,74,cutoff,0,100)")
+	checkError(t, err)
+	v, _ := e.context.Variables().Get("k")
+	cc := v.(*control.CCListen)
+	cc.ControlChange(1, 73, 127)
+	cc.ControlChange(1, 74, 127)
+	if got, want := core.ValueOf(mustGet(t, e.context, "cutoff")), 100; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// redefine keeps the listener
+	_, err = e.EvaluateProgram("k = cclisten(1,75,cutoff)")
+	checkError(t, err)
+	if got, want := mustGet(t, e.context, "k"), interface{}(cc); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	cc.ControlChange(1, 75, 12)
+	if got, want := core.ValueOf(mustGet(t, e.context, "cutoff")), 12; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestStatementTrigger(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateStatement("notetrigger(1,37,'hits = 1')")
+	checkError(t, err)
+	trigger := statementTrigger{entry: "hits = 1"}
+	checkError(t, trigger.Evaluate(e.context))
+	if got, want := core.ValueOf(mustGet(t, e.context, "hits")), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := e.EvaluateStatement("notetrigger(1,128,'hits = 1')"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestListenSplitKeyboard(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram(`low = note('c2')
+high = note('c5')
+bass = listen(low,play(low),'C0','B3')
+chords = listen(high,play(high),60,127)`)
+	checkError(t, err)
+	c3, _ := core.MIDItoNote(0.25, 48, core.Normal)
+	e4, _ := core.MIDItoNote(0.25, 64, core.Normal)
+	for _, each := range []string{"bass", "chords"} {
+		l := mustGet(t, e.context, each).(*control.Listen)
+		l.NoteOn(1, c3)
+		l.NoteOn(1, e4)
+	}
+	if got, want := core.Storex(mustGet(t, e.context, "low")), "note('C3')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.Storex(mustGet(t, e.context, "high")), "note('E')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.Storex(mustGet(t, e.context, "bass")), "listen(device(1,low),play(low),12,59)"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := e.EvaluateStatement("x = listen(low,play(low),'C4','C3')"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestListenChannel(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram(`rec = note('c')
+zone1 = listen(device(1,channel(2,rec)),play(rec))
+zone2 = listen(1,3,rec,play(rec))`)
+	checkError(t, err)
+	d, _ := core.MIDItoNote(0.25, 62, core.Normal)
+	e4, _ := core.MIDItoNote(0.25, 64, core.Normal)
+	zone1 := mustGet(t, e.context, "zone1").(*control.Listen)
+	zone1.NoteOn(3, e4)
+	zone1.NoteOn(2, d)
+	if got, want := core.Storex(mustGet(t, e.context, "rec")), "note('D')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.Storex(zone1), "listen(device(1,channel(2,rec)),play(rec))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.Storex(mustGet(t, e.context, "zone2")), "listen(device(1,channel(3,rec)),play(rec))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := e.EvaluateStatement("x = listen(1,17,rec,play(rec))"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestListenChord(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram(`rec = note('c')
+rec_chord = sequence('c')
+l = listen(rec,play(rec_chord))`)
+	checkError(t, err)
+	l := mustGet(t, e.context, "l").(*control.Listen)
+	for _, each := range []int{67, 60, 64} {
+		n, _ := core.MIDItoNote(0.25, each, core.Normal)
+		l.NoteOn(1, n)
+	}
+	if got, want := core.Storex(mustGet(t, e.context, "rec_chord")), "sequence('(C E G)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	e4, _ := core.MIDItoNote(0.25, 64, core.Normal)
+	l.NoteOff(1, e4)
+	a, _ := core.MIDItoNote(0.25, 69, core.Normal)
+	l.NoteOn(1, a)
+	if got, want := core.Storex(mustGet(t, e.context, "rec_chord")), "sequence('(C G A)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestStepRecord(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram(`melody = sequence('c')
+rec = steprecord(device(1,melody),8,'C2','D2')`)
+	checkError(t, err)
+	rec := mustGet(t, e.context, "rec").(*control.StepRecord)
+	checkError(t, rec.Play(e.context, time.Now()))
+	press := func(nrs ...int) {
+		for _, each := range nrs {
+			n, _ := core.MIDItoNote(0.25, each, core.Normal)
+			rec.NoteOn(1, n)
+		}
+		for _, each := range nrs {
+			n, _ := core.MIDItoNote(0.25, each, core.Normal)
+			rec.NoteOff(1, n)
+		}
+	}
+	press(62)
+	press(64, 67, 60)
+	press(36) // rest
+	press(69)
+	press(38) // back
+	if got, want := core.Storex(mustGet(t, e.context, "melody")), "sequence('C 8D (8C 8E 8G) 8=')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.Storex(rec), "steprecord(device(1,melody),0.125,36,38)"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestRecordTakes(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram(`takes = recordtakes(4,2)`)
+	checkError(t, err)
+	takes := mustGet(t, e.context, "takes").(*control.Takes)
+	checkError(t, takes.Play(e.context, time.Now()))
+	c := core.MustParseNote("C")
+	takes.NoteOn(1, c)
+	time.Sleep(10 * time.Millisecond)
+	takes.NoteOff(1, c)
+	checkError(t, takes.Stop(e.context))
+	_, err = e.EvaluateProgram(`best = at(1,takes)
+none = at(2,takes)`)
+	checkError(t, err)
+	if got, want := len(mustGet(t, e.context, "best").(core.Sequenceable).S().Notes), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := len(mustGet(t, e.context, "none").(core.Sequenceable).S().Notes), 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// settings change, takes are kept
+	_, err = e.EvaluateProgram(`takes = recordtakes(1,3,1)`)
+	checkError(t, err)
+	if got, want := core.Storex(mustGet(t, e.context, "takes")), "recordtakes(1,3,1)"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := len(takes.At(1).S().Notes), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestLoopIterations(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram(`i = interval(0,4,2)
+lp = loop(transpose(i,sequence('c')),next(i))`)
+	checkError(t, err)
+	lp := mustGet(t, e.context, "lp").(*core.Loop)
+	if got, want := core.Storex(lp.Iterations(4)), "sequence('C D E C')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestLoopIterations_NextAll(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram(`i = interval(0,4,2)
+j = interval(0,1,1)
+n = next(i,j)
+lp = loop(transpose(i,sequence('c')),octave(j,sequence('c')),n)`)
+	checkError(t, err)
+	if got, want := core.Storex(mustGet(t, e.context, "n")), "next(i,j)"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	lp := mustGet(t, e.context, "lp").(*core.Loop)
+	if got, want := core.Storex(lp.Iterations(3)), "sequence('C C D C5 E C')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestHz(t *testing.T) {
+	e := newTestEvaluator()
+	r, err := e.EvaluateProgram(`hz(450,8)`)
+	checkError(t, err)
+	if got, want := core.Storex(r), "hz(450,8)"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	r, err = e.EvaluateProgram(`hz(261.63)`)
+	checkError(t, err)
+	if got, want := r.(core.Note).String(), "C"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestNoteNames(t *testing.T) {
+	e := NewEvaluator(importTestContext(t.TempDir()))
+	_, err := e.EvaluateProgram(`set('notation.names','german')
+s = sequence('h b')
+c = chord('h/m')`)
+	checkError(t, err)
+	if got, want := core.Storex(mustGet(t, e.context, "s")), "sequence('B B_')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.Storex(mustGet(t, e.context, "c")), "chord('B/m')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	_, err = e.EvaluateProgram(`set('notation.names','solfege')
+n = note('8sol')`)
+	checkError(t, err)
+	if got, want := core.Storex(mustGet(t, e.context, "n")), "note('8G')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -2,25 +2,64 @@ package dsl
 
 import (
 	"fmt"
-	"io/ioutil"
+	"os"
 	"path/filepath"
 
 	"github.com/emicklei/melrose/core"
 )
 
-// ImportProgram runs a script from a file
+// importingKey is a key in a context environment for the stack of files being imported.
+const importingKey = "dsl.importing"
+
+// ImportProgram runs a script from a file.
+// A relative filename is relative to the WorkingDirectory of the context.
+// While running, the WorkingDirectory is the directory of that file such that it can import files relative to itself.
 func ImportProgram(ctx core.Context, filename string) error {
-	pwd, ok := ctx.Environment().Load(core.WorkingDirectory)
-	if !ok {
+	return NewEvaluator(ctx).importProgram(filename)
+}
+
+// importProgram runs a script from a file with the functions of this evaluator.
+func (e *Evaluator) importProgram(filename string) error {
+	ctx := e.context
+	pwd, hasPwd := ctx.Environment().Load(core.WorkingDirectory)
+	if !hasPwd {
 		pwd = ""
 	}
-	fullName := filepath.Join(pwd.(string), filename)
-	data, err := ioutil.ReadFile(fullName)
+	fullName := filename
+	if !filepath.IsAbs(filename) {
+		fullName = filepath.Join(pwd.(string), filename)
+	}
+	abs, _ := filepath.Abs(fullName)
+	var stack []string
+	if v, ok := ctx.Environment().Load(importingKey); ok {
+		stack = v.([]string)
+	}
+	for _, each := range stack {
+		if each == abs {
+			return fmt.Errorf("cyclic import of file[%s]", abs)
+		}
+	}
+	data, err := os.ReadFile(fullName)
 	if err != nil {
-		abs, _ := filepath.Abs(fullName)
 		return fmt.Errorf("unable to read file[%s] :%v", abs, err)
 	}
-	eval := NewEvaluator(ctx)
-	_, err = eval.EvaluateProgram(string(data))
+	ctx.Environment().Store(importingKey, append(stack, abs))
+	ctx.Environment().Store(core.WorkingDirectory, filepath.Dir(fullName))
+	defer func() {
+		if hasPwd {
+			ctx.Environment().Store(core.WorkingDirectory, pwd)
+		} else {
+			ctx.Environment().Delete(core.WorkingDirectory)
+		}
+		if len(stack) == 0 {
+			ctx.Environment().Delete(importingKey)
+		} else {
+			ctx.Environment().Store(importingKey, stack)
+		}
+	}()
+	_, err = e.EvaluateProgram(string(data))
+	if located, ok := err.(*Error); ok {
+		located.File = filename
+	}
 	return err
 }
@@ -0,0 +1,60 @@
+package dsl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func importTestContext(dir string) core.Context {
+	ctx := core.PlayContext{
+		VariableStorage: NewVariableStore(),
+		LoopControl:     core.NoLooper,
+		AudioDevice:     testAudioDevice{},
+		EnvironmentVars: new(sync.Map),
+		CapabilityFlags: core.NewCapabilities(),
+	}
+	ctx.EnvironmentVars.Store(core.WorkingDirectory, dir)
+	return ctx
+}
+
+func writeScript(t *testing.T, name, source string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIncludeRelativeToIncludingFile(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, filepath.Join(dir, "lib", "drums.mel"), "include('kit.mel')\nbeat = sequence('c c')")
+	writeScript(t, filepath.Join(dir, "lib", "kit.mel"), "kick = note('c2')")
+	ctx := importTestContext(dir)
+	_, err := NewEvaluator(ctx).EvaluateProgram("include('lib/drums.mel')")
+	checkError(t, err)
+	for _, each := range []string{"beat", "kick"} {
+		if _, ok := ctx.Variables().Get(each); !ok {
+			t.Errorf("missing variable %s", each)
+		}
+	}
+	if got, _ := ctx.Environment().Load(core.WorkingDirectory); got != dir {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, dir, dir)
+	}
+}
+
+func TestIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, filepath.Join(dir, "a.mel"), "include('b.mel')")
+	writeScript(t, filepath.Join(dir, "b.mel"), "include('a.mel')")
+	_, err := NewEvaluator(importTestContext(dir)).EvaluateProgram("include('a.mel')")
+	if err == nil || !strings.Contains(err.Error(), "cyclic") {
+		t.Errorf("cyclic import error expected, got %v", err)
+	}
+}
@@ -42,6 +42,7 @@ func TestImplementsPlayable(t *testing.T) {
 	}{
 		{source: new(core.Loop)},
 		{source: new(control.Listen)},
+		{source: new(control.Thru)},
 	} {
 		if !each.notSequenceable {
 			if _, ok := each.source.(core.Playable); !ok {
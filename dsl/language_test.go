@@ -1,10 +1,15 @@
 package dsl
 
 import (
+	"bytes"
+	"io"
+	"math"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
 )
 
 func TestNote(t *testing.T) {
@@ -29,6 +34,66 @@ func TestChord_Invalid(t *testing.T) {
 	mustError(t, "chord('k')", "illegal note")
 }
 
+func TestSymbol(t *testing.T) {
+	for _, each := range []struct {
+		expression, symbol string
+	}{
+		{"symbol(chord('a/m7/g'))", "Am7/G"},
+		{"symbol(chordsequence('c/1 (d e_/m)'))", "C/E (D Ebm)"},
+		{"symbol(progression('c','I vi IV V7'))", "C Am F G7"},
+	} {
+		if got, want := eval(t, each.expression), each.symbol; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	}
+}
+
+func TestSymbol_Invalid(t *testing.T) {
+	mustError(t, "symbol(note('c'))", "cannot create symbol")
+}
+
+func TestABC(t *testing.T) {
+	r := eval(t, `abc('L:1/4\nK:G\n|: GAB :|')`)
+	checkStorex(t, r, "sequence('G A B G A B')")
+}
+
+func TestABC_Invalid(t *testing.T) {
+	mustError(t, `abc('K:C\nC ? D')`, "invalid ABC notation")
+}
+
+func TestFill(t *testing.T) {
+	r := eval(t, "fill(4,sequence('c'),sequence('d'))")
+	checkStorex(t, r, "fill(4,sequence('C'),sequence('D'))")
+}
+
+func TestDraw(t *testing.T) {
+	var b bytes.Buffer
+	defer func(w io.Writer) { notify.Console.StandardOut = w }(notify.Console.StandardOut)
+	notify.Console.StandardOut = &b
+	eval(t, "draw(sequence('c d'))")
+	if got, want := b.String(), "D4 |....o---........|"; !strings.Contains(got, want) {
+		t.Errorf("missing %q in\n%s", want, got)
+	}
+}
+
+func TestPrintAndInspect(t *testing.T) {
+	var b bytes.Buffer
+	defer func(w io.Writer) { notify.Console.StandardOut = w }(notify.Console.StandardOut)
+	notify.Console.StandardOut = &b
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram(`s = sequence('c d e')
+print(s)
+print(lengthof(s) * 2)
+inspect(s)`)
+	checkError(t, err)
+	for _, want := range []string{"sequence('C D E')\n", "6\n", "beats", "bars"} {
+		if got := b.String(); !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+	checkStorex(t, eval(t, "inspect(note('c'))"), "inspect(note('C'))")
+}
+
 func TestSequence(t *testing.T) {
 	r := eval(t, "sequence('c (d e g) =')")
 	checkStorex(t, r, "sequence('C (D E G) =')")
@@ -53,7 +118,7 @@ func TestScale(t *testing.T) {
 	r := eval(t, "scale('16e2')")
 	checkStorex(t, r, "scale('major 16E2')")
 	checkStorex(t, r.(core.Sequenceable).S(),
-		"sequence('16E2 16G_2 16A_2 16A2 16B2 16D_3 16E_3')")
+		"sequence('16E2 16F#2 16G#2 16A2 16B2 16C#3 16D#3')")
 }
 
 func TestTranspose_ChordSequence(t *testing.T) {
@@ -68,6 +133,13 @@ func TestTrack(t *testing.T) {
 	checkStorex(t, r, "track('test',1,onbar(1,note('C')))")
 }
 
+func TestTrack_Channel(t *testing.T) {
+	r := eval(t, "track('drums',16,onbar(1,note('c')))")
+	checkStorex(t, r, "track('drums',16,onbar(1,note('C')))")
+	mustError(t, "track('drums',17,onbar(1,note('c')))", "MIDI channel must be in [1..16]")
+	mustError(t, "track('drums',0,onbar(1,note('c')))", "MIDI channel must be in [1..16]")
+}
+
 func TestChannelSelector(t *testing.T) {
 	r := eval(t, "channel(1,note('f'))")
 	checkStorex(t, r, "channel(1,note('F'))")
@@ -192,7 +264,7 @@ func TestVelocityMap(t *testing.T) {
 	r := eval(t, "velocitymap('1:30,2:60,2:0',sequence('c g'))")
 	checkStorex(t, r, "velocitymap('1:30,2:60,2:0',sequence('C G'))")
 	checkStorex(t, r.(core.Sequenceable).S(),
-		"sequence('C--- G+ G')")
+		"sequence('C:v30 G:v60 G')")
 }
 
 func TestValueOfVar(t *testing.T) {
@@ -206,11 +278,10 @@ v = value(i)`)
 }
 
 func TestDeviceOnTrack(t *testing.T) {
-	t.Skip() // TODO
 	r := eval(t, `
 s = sequence('a b')
 dt = device(1,track('title',4, onbar(1,s)))`)
-	checkStorex(t, r, "value(i)")
+	checkStorex(t, r, "device(1,track('title',4,onbar(1,s)))")
 }
 
 func TestIteratorIndex(t *testing.T) {
@@ -218,3 +289,465 @@ func TestIteratorIndex(t *testing.T) {
 idx = it.Index()`)
 	checkStorex(t, r, "it.Index()")
 }
+
+func TestThru(t *testing.T) {
+	r := eval(t, `tr = thru(1,2)`)
+	checkStorex(t, r, "thru(1,2)")
+	r = eval(t, `
+hit = note('c')
+tr = thru(device(1,hit),2,transpose(7,hit))`)
+	checkStorex(t, r, "thru(device(1,hit),2,transpose(7,hit))")
+}
+
+func TestUnlessWith(t *testing.T) {
+	r := eval(t, `
+snare = sequence('= c = c')
+hihat = sequence('8e 8e 8e 8e 8e 8e 8e 8e')
+u = unless(snare,hihat)`)
+	checkStorex(t, r, "unless(snare,hihat)")
+	checkStorex(t, r.(core.Sequenceable).S(), "sequence('8E 8E 8= 8E 8E 8E 8= 8E')")
+	r = eval(t, `
+kick = sequence('c = = c')
+bass = sequence('c2 d2 e2 f2')
+w = with(loop(kick),bass)`)
+	checkStorex(t, r.(core.Sequenceable).S(), "sequence('C2 = = F2')")
+}
+
+func TestDensity(t *testing.T) {
+	defer core.SetDensity(core.On(core.NeutralDensity))
+	r := eval(t, `density(0)`)
+	checkStorex(t, r, "density(0)")
+	r.(core.Evaluatable).Evaluate(nil)
+	r = eval(t, `dynamic(100,note('c'))`)
+	if got, want := r.(core.Sequenceable).S().Notes[0][0].Velocity, 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestChordTarget(t *testing.T) {
+	r := eval(t, `
+chords = progression('c','I IV')
+line = sequence('d e f g a b c5 d5')
+t = target(chords,line)`)
+	checkStorex(t, r, "target(chords,line)")
+	checkStorex(t, r.(core.Sequenceable).S(), "sequence('C E E G G B C5 D5')")
+}
+
+func TestCadence(t *testing.T) {
+	r := eval(t, `
+p = progression('c','1I 1vi 1IV 1ii 1I 1vi 1IV 1ii')
+c = cadence(4,p)`)
+	checkStorex(t, r, "cadence(4,p)")
+	checkStorex(t, r.(core.Sequenceable).S(), "sequence('(1C 1E 1G) (1A 1C5 1E5) (1G 1B 1D5) (1C 1E 1G) (1C 1E 1G) (1A 1C5 1E5) (1G 1B 1D5) (1C 1E 1G)')")
+	r = eval(t, `
+p = progression('c','1I 1vi 1IV 1ii')
+c = cadence(4,p,'plagal')`)
+	checkStorex(t, r.(core.Sequenceable).S(), "sequence('(1C 1E 1G) (1A 1C5 1E5) (1F 1A 1C5) (1C 1E 1G)')")
+}
+
+func TestOSCListen(t *testing.T) {
+	r := eval(t, `
+level = 0
+o = osclisten(8000,'/1/fader1',level)`)
+	checkStorex(t, r, "osclisten(8000,'/1/fader1',level)")
+}
+
+func TestLink(t *testing.T) {
+	for _, each := range []string{"link(42)", "link('on')", "link(sequence('c'))"} {
+		if _, err := newTestEvaluator().evaluateCleanStatement(each); err == nil {
+			t.Errorf("error expected for %s", each)
+		}
+	}
+	// not enabled
+	if _, err := newTestEvaluator().evaluateCleanStatement("link(false)"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAccents(t *testing.T) {
+	r := eval(t, "accents('1 0',10,sequence('c d e'))")
+	checkStorex(t, r, "accents('1 0',10,sequence('C D E'))")
+	checkStorex(t, r.(core.Sequenceable).S(), "sequence('C:v69 D E:v69')")
+}
+
+func TestGroove(t *testing.T) {
+	r := eval(t, "groove('push16',sequence('16c 16d'))")
+	checkStorex(t, r, "groove('push16',sequence('16C 16D'))")
+	if _, err := newTestEvaluator().evaluateCleanStatement("groove('shuffle',sequence('c'))"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestPolyrhythm(t *testing.T) {
+	r := eval(t, "polyrhythm(sequence('c c c'),sequence('e e e e'))")
+	checkStorex(t, r, "polyrhythm(sequence('C C C'),sequence('E E E E'))")
+	if got, want := r.(core.Sequenceable).S().DurationFactor(), 1.0; math.Abs(got-want) > 1e-4 {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestPolyLoop(t *testing.T) {
+	e := newTestEvaluator()
+	r, err := e.EvaluateProgram("l = polyloop(5,sequence('c d e f g'))")
+	checkError(t, err)
+	checkStorex(t, r, "polyloop(5,sequence('C D E F G'))")
+	// replacing keeps the loop and changes its meter
+	_, err = e.EvaluateProgram("l = loop(sequence('c'))")
+	checkError(t, err)
+	if got, want := mustGet(t, e.context, "l").(*core.Loop).Meter(), 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	_, err = e.EvaluateProgram("align(l)")
+	checkError(t, err)
+}
+
+func TestPhase(t *testing.T) {
+	r := eval(t, "phase(16,sequence('8c 8d'))")
+	checkStorex(t, r, "phase(16,sequence('8C 8D'))")
+}
+
+func TestCanon(t *testing.T) {
+	r := eval(t, "canon(2,1,12,sequence('c'))")
+	checkStorex(t, r, "multitrack(track('voice 1',1,onbar(1,sequence('C'))),track('voice 2',2,onbar(2,transpose(12,sequence('C')))))")
+}
+
+func TestReplacePattern(t *testing.T) {
+	r := eval(t, "replace(sequence('8c 8d e'),'8','16')")
+	checkStorex(t, r, "replace(sequence('8C 8D E'),'8','16')")
+	checkStorex(t, r.(core.Sequenceable).S(), "sequence('16C 16D E')")
+	if _, err := newTestEvaluator().evaluateCleanStatement("replace(sequence('c'),'c',note('d'))"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	r := eval(t, "select('pitch==C2',sequence('(c2 e2) e2'))")
+	checkStorex(t, r, "select('pitch==C2',sequence('(C2 E2) E2'))")
+	checkStorex(t, r.(core.Sequenceable).S(), "sequence('C2 =')")
+}
+
+func TestMapNote(t *testing.T) {
+	r := eval(t, "mapnote('if(_.MIDI() < 60, octave(1,_), _)', sequence('c3 (e g) g5'))")
+	checkStorex(t, r, "mapnote('if(_.MIDI() < 60, octave(1,_), _)',sequence('C3 (E G) G5'))")
+	checkStorex(t, r.(core.Sequenceable).S(), "sequence('C (E G) G5')")
+	r = eval(t, `mapnote("chord('c')", sequence('c ='))`)
+	checkStorex(t, r, `mapnote("chord('c')",sequence('C ='))`)
+	checkStorex(t, r.(core.Sequenceable).S(), "sequence('(C E G) =')")
+	if _, err := newTestEvaluator().evaluateCleanStatement("mapnote('_ +', sequence('c'))"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestToneRow(t *testing.T) {
+	r := eval(t, "tonerow('c d e','R0')")
+	checkStorex(t, r, "tonerow('C D E','R0')")
+	checkStorex(t, r.(core.Sequenceable).S(), "sequence('E D C')")
+	checkStorex(t, eval(t, "invert(note('d'),sequence('c'))"), "invert(note('D'),sequence('C'))")
+	e := newTestEvaluator()
+	r, err := e.EvaluateProgram("row = 'c d e'\ntonerow(row,'R0')")
+	checkError(t, err)
+	checkStorex(t, r, "tonerow('C D E','R0')")
+	if _, err := newTestEvaluator().evaluateCleanStatement("tonerow(1)"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestDiatonicChords(t *testing.T) {
+	r := eval(t, "at(5,triads(scale('a/m')))")
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('(E5 G5 B5)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	r = eval(t, "at(5,sevenths('c'))")
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('(G B D5 F5)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	checkStorex(t, eval(t, "triads('c')"), "triads(scale('major C'))")
+	checkStorex(t, eval(t, "sevenths(scale('e/m'))"), "sevenths(scale('major E/m'))")
+	if _, err := newTestEvaluator().evaluateCleanStatement("triads(note('c'))"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestVoiceLead(t *testing.T) {
+	r := eval(t, "voicelead(progression('c','I IV V I'))")
+	checkStorex(t, r, "voicelead(progression('c','I IV V I'))")
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('(C E G) (C F A) (D G B) (E G C5)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := newTestEvaluator().evaluateCleanStatement("voicelead(1)"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestChordifyAndLines(t *testing.T) {
+	r := eval(t, "chordify(4,sequence('8c 8e 8g 8= 16a 16b 8= 2c5'))")
+	checkStorex(t, r, "chordify(4,sequence('8C 8E 8G 8= 16A 16B 8= 2C5'))")
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('(C E) G (A B) 2C5')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	r = eval(t, "topline(sequence('(c e g) (d f a)'))")
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('G A')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	r = eval(t, "bassline(sequence('(c e g) (d f a)'))")
+	checkStorex(t, r, "bassline(sequence('(C E G) (D F A)'))")
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('C D')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := newTestEvaluator().evaluateCleanStatement("chordify('c',note('c'))"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestTransposeInterval(t *testing.T) {
+	r := eval(t, "transpose('P5',sequence('c d e'))")
+	checkStorex(t, r, "transpose('P5',sequence('C D E'))")
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('G A B')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	r = eval(t, "pitch('-m3',note('c'))")
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('A3')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := newTestEvaluator().evaluateCleanStatement("transpose('M5',note('c'))"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestIntervalBetween(t *testing.T) {
+	if got, want := eval(t, "intervalbetween(note('c'),note('e'))"), "M3"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := eval(t, "intervalbetween('c','f3')"), "-P5"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	r := eval(t, "transpose(intervalbetween('c','g'),note('d'))")
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('A')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestBassLineGenerator(t *testing.T) {
+	r := eval(t, "bassline('walking',progression('c','1I 1vi'))")
+	checkStorex(t, r, "bassline('walking',progression('c','1I 1vi'))")
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('C2 E2 G2 A_2 A2 C3 E3 D_2')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := newTestEvaluator().evaluateCleanStatement("bassline('funky',progression('c','I'))"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestGenMelody(t *testing.T) {
+	r := eval(t, "genmelody(8,scale('c'),progression('c','2I 2V'),42)")
+	checkStorex(t, r, "genmelody(8,scale('major C'),progression('c','2I 2V'),42)")
+	first := r.(core.Sequenceable).S()
+	if got, want := len(first.Notes), 8; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	again := eval(t, "genmelody(8,scale('c'),progression('c','2I 2V'),42)").(core.Sequenceable).S()
+	if got, want := again.Storex(), first.Storex(); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := newTestEvaluator().evaluateCleanStatement("genmelody(8,'c',progression('c','I'),1)"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestRandomSeed(t *testing.T) {
+	defer core.UseRandomSource("pseudo", 0)
+	draw := func() string {
+		e := newTestEvaluator()
+		if _, err := e.EvaluateProgram(`randomseed(7)
+r = random(1,1000)
+m = genmelody(8,scale('c'),progression('c','2I 2V'))`); err != nil {
+			t.Fatal(err)
+		}
+		r := mustGet(t, e.context, "r").(core.HasValue)
+		return core.Storex(r.Value()) + " " + core.Storex(mustGet(t, e.context, "m"))
+	}
+	if got, want := draw(), draw(); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.CurrentRandomSource(), (core.RandomSourceSetting{Name: "seeded", Seed: 7}); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := newTestEvaluator().evaluateCleanStatement("randomseed('x')"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestIntervalMethod(t *testing.T) {
+	r := eval(t, "interval(0,4,2,'ping-pong')")
+	checkStorex(t, r, "interval(0,4,2,'repeat-two-way')")
+	i := r.(*core.Interval)
+	got := core.Storex([]interface{}{i.Value(), i.Next(), i.Next(), i.Next(), i.Next()})
+	if want := "[0,2,4,2,0]"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	checkStorex(t, eval(t, "interval(1,2,1)"), "interval(1,2,1,'repeat')")
+	if _, err := newTestEvaluator().evaluateCleanStatement("interval(1,2,1,'zigzag')"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestFreezeAndThaw(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram(`i = interval(0,2,1)
+f = freeze(i)
+lp = loop(transpose(f,sequence('c')),octave(f,sequence('c')),next(f))
+t = thaw(f)`)
+	checkError(t, err)
+	checkStorex(t, mustGet(t, e.context, "f"), "freeze(i)")
+	checkStorex(t, mustGet(t, e.context, "t"), "thaw(f)")
+	lp := mustGet(t, e.context, "lp").(*core.Loop)
+	if got, want := core.Storex(lp.Iterations(3)), "sequence('C C D_ C5 D C6')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// advancing the generator does not change the freeze until thawed
+	mustGet(t, e.context, "i").(*core.Interval).Next()
+	if got, want := mustGet(t, e.context, "f").(core.HasValue).Value(), 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	mustGet(t, e.context, "t").(core.Sequenceable).S()
+	if got, want := mustGet(t, e.context, "f").(core.HasValue).Value(), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := newTestEvaluator().evaluateCleanStatement("thaw(1)"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestDurationMap(t *testing.T) {
+	r := eval(t, "durationmap('1:8 2:8 3:4. 4:2',sequence('c d e f'))")
+	checkStorex(t, r, "fractionmap('1:8 2:8 3:4. 4:2',sequence('C D E F'))")
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('8C 8D .E 2F')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestFitBars(t *testing.T) {
+	r := eval(t, "fitbars(2,sequence('c d e'))")
+	checkStorex(t, r, "fitbars(2,sequence('C D E'))")
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('C D E 1= =')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := newTestEvaluator().evaluateCleanStatement("fitbars('a',note('c'))"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestDurationAndLengthOf(t *testing.T) {
+	if got, want := eval(t, "durationof(sequence('c d e f'))"), 2.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := eval(t, "lengthof(sequence('8c 8d e 2.f'))"), 5.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.ValueOf(eval(t, "lengthof(sequence('c d')) * 2")), 4.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := newTestEvaluator().evaluateCleanStatement("lengthof(1)"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestExpect(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram(`s = sequence('c d e')
+expect(transpose(2,s),'D E F#')`)
+	checkError(t, err)
+	_, err = e.EvaluateProgram(`expect(reverse(s),'E D F')`)
+	if err == nil || !strings.Contains(err.Error(), "at 3: expected F got C") {
+		t.Errorf("expected difference, got %v", err)
+	}
+	checkStorex(t, eval(t, "expect(note('c'),'c')"), "expect(note('C'),'c')")
+	if _, err := newTestEvaluator().evaluateCleanStatement("expect(note('c'),'k')"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestWaitAndSchedule(t *testing.T) {
+	checkStorex(t, eval(t, "wait(0)"), "wait(0)")
+	checkStorex(t, eval(t, "schedule(9,sequence('c'))"), "schedule(9,sequence('C'))")
+	for _, each := range []string{"wait('c')", "schedule('9',sequence('c'))", "schedule(0,sequence('c'))"} {
+		if _, err := newTestEvaluator().evaluateCleanStatement(each); err == nil {
+			t.Errorf("error expected for %s", each)
+		}
+	}
+}
+
+func TestTempo(t *testing.T) {
+	e := newTestEvaluator()
+	r, err := e.EvaluateProgram("l = tempo(0.5,loop(sequence('c d')))")
+	checkError(t, err)
+	checkStorex(t, r, "tempo(0.5,loop(sequence('C D')))")
+	// replacing keeps the loop and changes its tempo
+	_, err = e.EvaluateProgram("l = loop(sequence('c'))")
+	checkError(t, err)
+	if got, want := mustGet(t, e.context, "l").(*core.Loop).Tempo(), 0.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	checkStorex(t, eval(t, "tempo(2,track('drums',10,onbar(1,sequence('c'))))"), "tempo(2,track('drums',10,onbar(1,sequence('C'))))")
+	for _, each := range []string{"tempo(0,loop(sequence('c')))", "tempo(2,sequence('c'))"} {
+		if _, err := newTestEvaluator().evaluateCleanStatement(each); err == nil {
+			t.Errorf("error expected for %s", each)
+		}
+	}
+}
+
+func TestTransposeAll(t *testing.T) {
+	looper := &core.TestLooper{Biab: 4}
+	e := NewEvaluator(core.PlayContext{VariableStorage: NewVariableStore(), LoopControl: looper, AudioDevice: testAudioDevice{}})
+	r, err := e.EvaluateProgram("transposeall(-2)")
+	checkError(t, err)
+	checkStorex(t, r, "transposeall(-2)")
+	if got, want := looper.Transpose(), -2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	_, err = e.EvaluateProgram("transposeall('m3')")
+	checkError(t, err)
+	if got, want := looper.Transpose(), 3; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	for _, each := range []string{"transposeall('x3')", "transposeall(1.5)"} {
+		if _, err := newTestEvaluator().evaluateCleanStatement(each); err == nil {
+			t.Errorf("error expected for %s", each)
+		}
+	}
+}
+
+func TestChangeKey(t *testing.T) {
+	e := newTestEvaluator()
+	r, err := e.EvaluateProgram(`p = progression('c','I V')
+t = track('chords',1,onbar(1,p),changekey(1,'d'))`)
+	checkError(t, err)
+	checkStorex(t, r, "track('chords',1,onbar(1,p),changekey(1,'d'))")
+	if got, want := r.(*core.Track).SequenceAt(1, 4).Storex(), "sequence('(D G_ A) (A D_5 E5)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	r, err = e.EvaluateProgram("b = 5\nchangekey(b,'d')")
+	checkError(t, err)
+	checkStorex(t, r, "changekey(b,'d')")
+	for _, each := range []string{"changekey(1,'k')", "changekey('1','d')", "track('t',1,sequence('c'))"} {
+		if _, err := newTestEvaluator().evaluateCleanStatement(each); err == nil {
+			t.Errorf("error expected for %s", each)
+		}
+	}
+}
+
+func TestVolumeAndPan(t *testing.T) {
+	e := newTestEvaluator()
+	_, err := e.EvaluateProgram(`bass = track('bass',2,onbar(1,sequence('c2')))
+i = interval(60,100,20)`)
+	checkError(t, err)
+	checkStorex(t, eval(t, "volume(100,track('bass',2))"), "volume(100,track('bass',2))")
+	r, err := e.EvaluateProgram("pan(i,volume(90,bass))")
+	checkError(t, err)
+	checkStorex(t, r, "volume(90,pan(i,track('bass',2,onbar(1,sequence('C2')))))")
+	for _, each := range []string{"volume(128,track('bass',2))", "pan(64,sequence('c'))", "pan('left',track('bass',2))"} {
+		if _, err := newTestEvaluator().evaluateCleanStatement(each); err == nil {
+			t.Errorf("error expected for %s", each)
+		}
+	}
+}
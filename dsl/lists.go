@@ -0,0 +1,90 @@
+package dsl
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/expr-lang/expr"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// elementName is the name of the current element in an expression given to map or filter.
+const elementName = "_"
+
+// listOf returns the elements of a list or of a variable that has a list.
+func listOf(v interface{}) ([]interface{}, bool) {
+	list, ok := core.ValueOf(v).([]interface{})
+	return list, ok
+}
+
+// apply returns the result of calling a function with an element.
+// The function is either a DSL function, e.g. chord, or an expression in which _ is the element, e.g. '_ > 2'.
+func (e *Evaluator) apply(fn interface{}, element interface{}) (interface{}, error) {
+	if entry, ok := fn.(string); ok {
+		program, env, err := e.compile(entry, map[string]interface{}{elementName: element})
+		if err != nil {
+			return nil, err
+		}
+		return expr.Run(program, env)
+	}
+	f := reflect.ValueOf(fn)
+	if f.Kind() != reflect.Func {
+		return nil, fmt.Errorf("cannot apply (%T) %v, expected function or expression", fn, fn)
+	}
+	t := f.Type()
+	if t.NumIn() == 0 || (!t.IsVariadic() && t.NumIn() != 1) || (t.IsVariadic() && t.NumIn() > 2) {
+		return nil, fmt.Errorf("cannot apply function with %d parameters on a single element", t.NumIn())
+	}
+	argType := t.In(0)
+	if t.IsVariadic() && t.NumIn() == 1 {
+		argType = argType.Elem()
+	}
+	arg := reflect.ValueOf(element)
+	if element == nil {
+		arg = reflect.Zero(argType)
+	}
+	if !arg.Type().AssignableTo(argType) {
+		return nil, fmt.Errorf("cannot apply function on (%T) %v", element, element)
+	}
+	out := f.Call([]reflect.Value{arg})
+	if len(out) == 0 {
+		return nil, nil
+	}
+	if len(out) == 2 && !out[1].IsNil() {
+		return nil, out[1].Interface().(error)
+	}
+	return out[0].Interface(), nil
+}
+
+// mapList returns a new list with the result of fn for each element.
+func (e *Evaluator) mapList(fn interface{}, list []interface{}) ([]interface{}, error) {
+	mapped := []interface{}{}
+	for _, each := range list {
+		r, err := e.apply(fn, each)
+		if err != nil {
+			return nil, err
+		}
+		mapped = append(mapped, r)
+	}
+	return mapped, nil
+}
+
+// filterList returns a new list with the elements for which fn is true.
+func (e *Evaluator) filterList(fn interface{}, list []interface{}) ([]interface{}, error) {
+	filtered := []interface{}{}
+	for _, each := range list {
+		r, err := e.apply(fn, each)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := core.ValueOf(r).(bool)
+		if !ok {
+			return nil, fmt.Errorf("filter condition must be true or false, got (%T) %v", r, r)
+		}
+		if b {
+			filtered = append(filtered, each)
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,49 @@
+package dsl
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestMapFunction(t *testing.T) {
+	e := newTestEvaluator()
+	r, err := e.EvaluateProgram(`roots = ['c','f','g']
+join(map(chord, roots))`)
+	checkError(t, err)
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('(C E G) (F A C5) (G B D5)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestMapExpression(t *testing.T) {
+	e := newTestEvaluator()
+	r, err := e.EvaluateProgram(`join(map('octave(-1, chord(_))', ['c','g']))`)
+	checkError(t, err)
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('(C3 E3 G3) (G3 B3 D)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestFilterExpression(t *testing.T) {
+	e := newTestEvaluator()
+	r, err := e.EvaluateProgram(`filter('_ % 2 == 0', [1,2,3,4])`)
+	checkError(t, err)
+	if got, want := core.Storex(r), "[2,4]"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestFilterNoCondition(t *testing.T) {
+	e := newTestEvaluator()
+	if _, err := e.EvaluateProgram(`filter(chord, ['c'])`); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestMapNoList(t *testing.T) {
+	e := newTestEvaluator()
+	if _, err := e.EvaluateProgram(`map(chord, 'c')`); err == nil {
+		t.Error("error expected")
+	}
+}
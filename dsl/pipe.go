@@ -0,0 +1,134 @@
+package dsl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// pipeOperator passes the result of its left expression as the last argument of the function call on its right.
+const pipeOperator = "|>"
+
+var pipeStageRegex = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*)\s*(\((.*)\))?$`)
+
+// rewritePipes returns the expression with all pipelines replaced by nested function calls.
+//
+//	sequence('C D E') |> reverse() |> octave(1) => octave(1,reverse(sequence('C D E')))
+func rewritePipes(entry string) (string, error) {
+	if !strings.Contains(entry, pipeOperator) {
+		return entry, nil
+	}
+	entry, err := rewriteNestedPipes(entry)
+	if err != nil {
+		return "", err
+	}
+	stages := splitTopLevel(entry, pipeOperator)
+	if len(stages) == 1 {
+		return entry, nil
+	}
+	piped := strings.TrimSpace(stages[0])
+	if len(piped) == 0 {
+		return "", fmt.Errorf("missing expression before %s", pipeOperator)
+	}
+	for _, each := range stages[1:] {
+		stage := strings.TrimSpace(each)
+		match := pipeStageRegex.FindStringSubmatch(stage)
+		if match == nil || !isBalanced(match[3]) {
+			return "", fmt.Errorf("after %s a function call is expected, got [%s]", pipeOperator, stage)
+		}
+		if args := strings.TrimSpace(match[3]); len(args) > 0 {
+			piped = fmt.Sprintf("%s(%s,%s)", match[1], args, piped)
+		} else {
+			piped = fmt.Sprintf("%s(%s)", match[1], piped)
+		}
+	}
+	return piped, nil
+}
+
+// rewriteNestedPipes rewrites the pipelines in each argument or element between brackets.
+func rewriteNestedPipes(entry string) (string, error) {
+	var b strings.Builder
+	depth, open := 0, 0
+	var quote rune
+	for i, each := range entry {
+		switch {
+		case quote != 0:
+			if each == quote {
+				quote = 0
+			}
+		case each == '\'' || each == '"' || each == '`':
+			quote = each
+		case strings.ContainsRune("([{", each):
+			if depth == 0 {
+				b.WriteString(entry[open : i+1])
+				open = i + 1
+			}
+			depth++
+		case strings.ContainsRune(")]}", each):
+			depth--
+			if depth == 0 {
+				parts := splitTopLevel(entry[open:i], ",")
+				for p, part := range parts {
+					rewritten, err := rewritePipes(part)
+					if err != nil {
+						return "", err
+					}
+					parts[p] = rewritten
+				}
+				b.WriteString(strings.Join(parts, ","))
+				open = i
+			}
+		}
+	}
+	b.WriteString(entry[open:])
+	return b.String(), nil
+}
+
+// splitTopLevel splits the entry by a separator that is not inside quotes or brackets.
+func splitTopLevel(entry, separator string) (parts []string) {
+	depth := 0
+	var quote rune
+	start := 0
+	for i, each := range entry {
+		switch {
+		case quote != 0:
+			if each == quote {
+				quote = 0
+			}
+		case each == '\'' || each == '"' || each == '`':
+			quote = each
+		case strings.ContainsRune("([{", each):
+			depth++
+		case strings.ContainsRune(")]}", each):
+			depth--
+		case depth == 0 && strings.HasPrefix(entry[i:], separator):
+			parts = append(parts, entry[start:i])
+			start = i + len(separator)
+		}
+	}
+	return append(parts, entry[start:])
+}
+
+// isBalanced returns whether the brackets outside quotes are balanced, e.g. not for "1) + f(2".
+func isBalanced(s string) bool {
+	depth := 0
+	var quote rune
+	for _, each := range s {
+		switch {
+		case quote != 0:
+			if each == quote {
+				quote = 0
+			}
+		case each == '\'' || each == '"' || each == '`':
+			quote = each
+		case strings.ContainsRune("([{", each):
+			depth++
+		case strings.ContainsRune(")]}", each):
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0 && quote == 0
+}
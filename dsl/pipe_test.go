@@ -0,0 +1,48 @@
+package dsl
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestRewritePipes(t *testing.T) {
+	for entry, want := range map[string]string{
+		"sequence('C D E') |> reverse() |> octave(1) |> repeat(2)": "repeat(2,octave(1,reverse(sequence('C D E'))))",
+		"m |> reverse":                    "reverse(m)",
+		"join(a |> reverse(), b)":         "join(reverse(a), b)",
+		"[a |> reverse()] |> join()":      "join([reverse(a)])",
+		"sequence('c |> d') |> reverse()": "reverse(sequence('c |> d'))",
+		"sequence('c')":                   "sequence('c')",
+		"f(1) |> g(h(2), [3, 4])":         "g(h(2), [3, 4],f(1))",
+	} {
+		got, err := rewritePipes(entry)
+		checkError(t, err)
+		if got != want {
+			t.Errorf("%s: got [%v] want [%v]", entry, got, want)
+		}
+	}
+}
+
+func TestRewritePipesErrors(t *testing.T) {
+	for _, each := range []string{
+		"|> reverse()",
+		"m |> 1 + 2",
+		"m |> f(1) + g(2)",
+	} {
+		if _, err := rewritePipes(each); err == nil {
+			t.Errorf("%s: error expected", each)
+		}
+	}
+}
+
+func TestEvaluatePipeline(t *testing.T) {
+	e := newTestEvaluator()
+	r, err := e.EvaluateProgram(`m = sequence('C D E')
+	|> reverse()
+	|> octave(1)`)
+	checkError(t, err)
+	if got, want := r.(core.Sequenceable).S().Storex(), "sequence('E5 D5 C5')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,115 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/midi"
+	"github.com/emicklei/melrose/notify"
+)
+
+// ProjectFile is the name of the project manifest that is loaded at startup if present.
+const ProjectFile = "melrose.json"
+
+// projectTracksKey is a key in a context environment for the MIDI channel by track title of a project.
+const projectTracksKey = "project.tracks"
+
+// Project is the manifest of a song such that it opens identically on each machine.
+// Devices are found by (part of) their name because their IDs differ per machine.
+type Project struct {
+	Input    string         `json:"input,omitempty"`  // name of the default MIDI input device
+	Output   string         `json:"output,omitempty"` // name of the default MIDI output device
+	BPM      float64        `json:"bpm,omitempty"`
+	BIAB     int            `json:"biab,omitempty"`
+	Tracks   map[string]int `json:"tracks,omitempty"`  // track title -> MIDI channel
+	Includes []string       `json:"include,omitempty"` // scripts relative to the manifest, evaluated in order
+}
+
+// ReadProject decodes a project manifest.
+func ReadProject(r io.Reader) (Project, error) {
+	var p Project
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return p, fmt.Errorf("invalid project: %v", err)
+	}
+	for title, channel := range p.Tracks {
+		if err := checkMIDIChannel(channel); err != nil {
+			return p, fmt.Errorf("invalid project: track %s: %v", title, err)
+		}
+	}
+	return p, nil
+}
+
+// LoadProject reads a project manifest, applies its devices and settings and evaluates its includes.
+// The directory of the manifest becomes the WorkingDirectory of the context.
+// A device that is not available is reported ; the default device is used instead.
+func LoadProject(ctx core.Context, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	p, err := ReadProject(f)
+	if err != nil {
+		return err
+	}
+	ctx.Environment().Store(core.WorkingDirectory, filepath.Dir(filename))
+	if len(p.Input) > 0 || len(p.Output) > 0 {
+		setProjectDevices(ctx, p)
+	}
+	if p.BPM > 0 {
+		ctx.Control().SetBPM(p.BPM)
+	}
+	if p.BIAB > 0 {
+		ctx.Control().SetBIAB(p.BIAB)
+	}
+	if len(p.Tracks) > 0 {
+		ctx.Environment().Store(projectTracksKey, p.Tracks)
+	}
+	for _, each := range p.Includes {
+		if err := ImportProgram(ctx, each); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setProjectDevices(ctx core.Context, p Project) {
+	reg, ok := ctx.Device().(*midi.DeviceRegistry)
+	if !ok {
+		notify.Warnf("project devices cannot be found by name for this device")
+		return
+	}
+	if len(p.Input) > 0 {
+		if id, err := reg.InputDeviceID(p.Input); err != nil {
+			notify.Warnf("cannot use project input device: %v", err)
+		} else if err := reg.HandleSetting("midi.in", []interface{}{id}); err != nil {
+			notify.Warnf("cannot use project input device: %v", err)
+		}
+	}
+	if len(p.Output) > 0 {
+		if id, err := reg.OutputDeviceID(p.Output); err != nil {
+			notify.Warnf("cannot use project output device: %v", err)
+		} else if err := reg.HandleSetting("midi.out", []interface{}{id}); err != nil {
+			notify.Warnf("cannot use project output device: %v", err)
+		}
+	}
+}
+
+// projectChannel returns the MIDI channel of a track title assigned by the project, if any.
+func projectChannel(ctx core.Context, title string) (int, bool) {
+	if ctx.Environment() == nil {
+		return 0, false
+	}
+	v, ok := ctx.Environment().Load(projectTracksKey)
+	if !ok {
+		return 0, false
+	}
+	channel, ok := v.(map[string]int)[title]
+	return channel, ok
+}
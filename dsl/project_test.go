@@ -0,0 +1,61 @@
+package dsl
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestLoadProject(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, filepath.Join(dir, "song", ProjectFile), `{"bpm":90,"tracks":{"bass":3},"include":["parts/bass.mel"]}`)
+	writeScript(t, filepath.Join(dir, "song", "parts", "bass.mel"), "b = track('bass',1,onbar(1,sequence('c2')))\nl = track('lead',2,onbar(1,sequence('c5')))")
+	ctx := importTestContext(dir)
+	checkError(t, LoadProject(ctx, filepath.Join(dir, "song", ProjectFile)))
+	for name, channel := range map[string]int{"b": 3, "l": 2} {
+		v, ok := ctx.Variables().Get(name)
+		if !ok {
+			t.Fatalf("missing %s", name)
+		}
+		if got, want := v.(*core.Track).Channel, channel; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	}
+	if got, want := mustLoad(t, ctx, core.WorkingDirectory), filepath.Join(dir, "song"); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestReadProject_Invalid(t *testing.T) {
+	for _, each := range []string{
+		`{"tracks":{"bass":17}}`,
+		`{"tracks":{"bass":0}}`,
+		`{"bmp":90}`,
+		`bpm: 90`,
+	} {
+		if _, err := ReadProject(strings.NewReader(each)); err == nil {
+			t.Errorf("%s: error expected", each)
+		}
+	}
+}
+
+func TestReadProject_Channel16(t *testing.T) {
+	p, err := ReadProject(strings.NewReader(`{"tracks":{"pad":16}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.Tracks["pad"], 16; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func mustLoad(t *testing.T, ctx core.Context, key string) interface{} {
+	t.Helper()
+	v, ok := ctx.Environment().Load(key)
+	if !ok {
+		t.Fatalf("missing %s", key)
+	}
+	return v
+}
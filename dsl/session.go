@@ -0,0 +1,231 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// SessionFormatVersion is the version of the on-disk representation of a session.
+// Increment it when the format changes and add a migration from the previous version.
+const SessionFormatVersion = 1
+
+// DefaultSessionFile is the name of the file to save a session to if none is given.
+const DefaultSessionFile = ".melrose.session"
+
+// Session is the versioned on-disk representation of the variables of a context.
+// Fields unknown to this version are ignored such that newer minor additions can still be loaded.
+type Session struct {
+	FormatVersion int                       `json:"format"`
+	SyntaxVersion string                    `json:"syntax"`
+	BuildTag      string                    `json:"build,omitempty"`
+	Created       time.Time                 `json:"created"`
+	BPM           float64                   `json:"bpm,omitempty"`
+	BIAB          int                       `json:"biab,omitempty"`
+	RandomSource  *core.RandomSourceSetting `json:"random,omitempty"`
+	Devices       *SessionDevices           `json:"devices,omitempty"`
+	Variables     []SessionVariable         `json:"variables"`
+}
+
+// SessionDevices are the default input and output device ; -1 if none.
+type SessionDevices struct {
+	Input  int `json:"in"`
+	Output int `json:"out"`
+}
+
+// SessionVariable is a variable with the source (Storex) of its value.
+type SessionVariable struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+// sessionMigrations upgrades a session from the version (key) to the next.
+var sessionMigrations = map[int]func(s *Session){
+	// 0 is a plain program of assignments ; there is no metadata
+	0: func(s *Session) {},
+}
+
+// NewSession captures the storable variables and the settings of a context.
+func NewSession(ctx core.Context) Session {
+	s := Session{
+		FormatVersion: SessionFormatVersion,
+		SyntaxVersion: SyntaxVersion,
+		BuildTag:      core.BuildTag,
+		Created:       time.Now(),
+		BPM:           ctx.Control().BPM(),
+		BIAB:          ctx.Control().BIAB(),
+		Variables:     []SessionVariable{},
+	}
+	if r := core.CurrentRandomSource(); r.Name != "" {
+		s.RandomSource = &r
+	}
+	if ctx.Device() != nil {
+		in, out := ctx.Device().DefaultDeviceIDs()
+		s.Devices = &SessionDevices{Input: in, Output: out}
+	}
+	for name, value := range ctx.Variables().Variables() {
+		st, ok := value.(core.Storable)
+		if !ok {
+			continue
+		}
+		s.Variables = append(s.Variables, SessionVariable{Name: name, Source: st.Storex()})
+	}
+	sort.Slice(s.Variables, func(i, j int) bool { return s.Variables[i].Name < s.Variables[j].Name })
+	return s
+}
+
+// SaveSession writes the session of a context as JSON.
+func SaveSession(ctx core.Context, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(NewSession(ctx))
+}
+
+// SaveSessionFile writes the session of a context as JSON to a file.
+// The session is written to a temporary file first such that an interrupted save cannot corrupt it.
+// The previous session file is kept with the .bak extension.
+func SaveSessionFile(ctx core.Context, name string) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op after rename
+	if err := SaveSession(ctx, f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(name); err == nil {
+		if err := os.Rename(name, name+".bak"); err != nil {
+			return fmt.Errorf("cannot keep previous session: %v", err)
+		}
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// ReadSession decodes a session and migrates it to the current format version.
+// Data that is not JSON is read as a program of assignments, as saved by older versions.
+func ReadSession(r io.Reader) (Session, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Session{}, err
+	}
+	var s Session
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &s); err != nil {
+			return s, fmt.Errorf("invalid session: %v", err)
+		}
+	} else {
+		s = programSession(string(data))
+	}
+	if s.FormatVersion > SessionFormatVersion {
+		return s, fmt.Errorf("session format %d is newer than supported (%d), upgrade melrose", s.FormatVersion, SessionFormatVersion)
+	}
+	for s.FormatVersion < SessionFormatVersion {
+		migrate, ok := sessionMigrations[s.FormatVersion]
+		if !ok {
+			return s, fmt.Errorf("no migration for session format %d", s.FormatVersion)
+		}
+		migrate(&s)
+		s.FormatVersion++
+	}
+	return s, nil
+}
+
+// programSession returns a session (format 0) with a variable for each line "name = source".
+func programSession(program string) Session {
+	s := Session{}
+	for _, each := range strings.Split(program, "\n") {
+		each = strings.TrimSpace(withoutTrailingComment(each))
+		if len(each) == 0 || strings.HasPrefix(each, "//") {
+			continue
+		}
+		if name, source, ok := IsAssignment(each); ok {
+			s.Variables = append(s.Variables, SessionVariable{Name: name, Source: source})
+		}
+	}
+	return s
+}
+
+// LoadSession reads a session and restores its settings and variables in a context.
+// Variables can refer to each other so evaluation is repeated until no more variables can be restored.
+// Variables that cannot be restored are reported and skipped.
+func LoadSession(ctx core.Context, r io.Reader) error {
+	s, err := ReadSession(r)
+	if err != nil {
+		return err
+	}
+	if s.SyntaxVersion != "" && s.SyntaxVersion != SyntaxVersion {
+		notify.Warnf("session was saved with syntax version %s, current is %s", s.SyntaxVersion, SyntaxVersion)
+	}
+	if s.BPM > 0 {
+		ctx.Control().SetBPM(s.BPM)
+	}
+	if s.BIAB > 0 {
+		ctx.Control().SetBIAB(s.BIAB)
+	}
+	if s.RandomSource != nil {
+		if err := core.UseRandomSource(s.RandomSource.Name, s.RandomSource.Seed); err != nil {
+			notify.Warnf("cannot restore random source: %v", err)
+		}
+	}
+	if s.Devices != nil && ctx.Device() != nil {
+		restoreDevices(ctx, *s.Devices)
+	}
+	eval := NewEvaluator(ctx)
+	pending := s.Variables
+	errs := map[string]error{}
+	for len(pending) > 0 {
+		failed := []SessionVariable{}
+		for _, each := range pending {
+			if err := evaluateSessionVariable(eval, each); err != nil {
+				errs[each.Name] = err
+				failed = append(failed, each)
+			}
+		}
+		if len(failed) == len(pending) {
+			break // no progress
+		}
+		pending = failed
+	}
+	for _, each := range pending {
+		notify.Warnf("cannot restore variable %s: %v", each.Name, errs[each.Name])
+	}
+	return nil
+}
+
+// restoreDevices changes the default devices of a context if they differ from the session ; a device that is not available is reported.
+func restoreDevices(ctx core.Context, d SessionDevices) {
+	in, out := ctx.Device().DefaultDeviceIDs()
+	if d.Input >= 0 && d.Input != in {
+		if err := ctx.Device().HandleSetting("midi.in", []interface{}{d.Input}); err != nil {
+			notify.Warnf("cannot restore input device %d: %v", d.Input, err)
+		}
+	}
+	if d.Output >= 0 && d.Output != out {
+		if err := ctx.Device().HandleSetting("midi.out", []interface{}{d.Output}); err != nil {
+			notify.Warnf("cannot restore output device %d: %v", d.Output, err)
+		}
+	}
+}
+
+func evaluateSessionVariable(eval *Evaluator, v SessionVariable) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	_, err = eval.EvaluateStatement(fmt.Sprintf("%s = %s", v.Name, v.Source))
+	return
+}
@@ -0,0 +1,165 @@
+package dsl
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestSaveLoadSession(t *testing.T) {
+	ctx := testContext()
+	e := NewEvaluator(ctx)
+	e.EvaluateProgram(`a = sequence('c e g')
+b = join(a,note('c5'))`)
+	core.UseRandomSource("seeded", 42)
+	defer core.UseRandomSource("pseudo", 0)
+	var buf bytes.Buffer
+	if err := SaveSession(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	core.UseRandomSource("crypto", 0)
+
+	other := testContext()
+	if err := LoadSession(other, &buf); err != nil {
+		t.Fatal(err)
+	}
+	v, ok := other.Variables().Get("b")
+	if !ok {
+		t.Fatal("missing b")
+	}
+	if got, want := core.Storex(v), "join(a,note('C5'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.CurrentRandomSource(), (core.RandomSourceSetting{Name: "seeded", Seed: 42}); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestSaveSessionFile(t *testing.T) {
+	ctx := testContext()
+	NewEvaluator(ctx).EvaluateProgram("a = sequence('c e g')")
+	name := filepath.Join(t.TempDir(), DefaultSessionFile)
+	for i := 0; i < 2; i++ {
+		if err := SaveSessionFile(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the previous one is kept
+	if _, err := os.Stat(name + ".bak"); err != nil {
+		t.Error(err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	other := testContext()
+	if err := LoadSession(other, f); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := other.Variables().Get("a"); !ok {
+		t.Error("missing a")
+	}
+	// no temporary files are left
+	entries, _ := os.ReadDir(filepath.Dir(name))
+	if got, want := len(entries), 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestReadSession_Program(t *testing.T) {
+	s, err := ReadSession(strings.NewReader(`// old
+a = sequence('c')
+play(a)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.FormatVersion, SessionFormatVersion; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := len(s.Variables), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := s.Variables[0].Source, "sequence('c')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestReadSession_Newer(t *testing.T) {
+	_, err := ReadSession(strings.NewReader(`{"format":99,"variables":[]}`))
+	if err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestReadSession_UnknownFields(t *testing.T) {
+	s, err := ReadSession(strings.NewReader(`{"format":1,"future":true,"variables":[{"name":"a","source":"note('c')","tags":["x"]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Variables[0].Name, "a"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestLoadSession_SkipsInvalid(t *testing.T) {
+	ctx := testContext()
+	err := LoadSession(ctx, strings.NewReader(`{"format":1,"variables":[
+		{"name":"a","source":"unknownfunction(1)"},
+		{"name":"b","source":"note('c')"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ctx.Variables().Get("a"); ok {
+		t.Error("a not expected")
+	}
+	if _, ok := ctx.Variables().Get("b"); !ok {
+		t.Error("b expected")
+	}
+}
+
+func TestLoadSession_ReferenceBeforeDefinition(t *testing.T) {
+	ctx := testContext()
+	err := LoadSession(ctx, strings.NewReader(`{"format":1,"variables":[
+		{"name":"b","source":"join(a,a)"},
+		{"name":"a","source":"note('c')"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, _ := ctx.Variables().Get("b")
+	if got, want := core.Storex(v), "join(a,a)"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+type settingsDevice struct {
+	testAudioDevice
+	settings []string
+}
+
+func (d *settingsDevice) HandleSetting(name string, values []interface{}) error {
+	d.settings = append(d.settings, fmt.Sprintf("%s %v", name, values))
+	return nil
+}
+
+func TestLoadSession_Devices(t *testing.T) {
+	if got, want := NewSession(testContext()).Devices, (SessionDevices{Input: 1, Output: 1}); got == nil || *got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	dev := new(settingsDevice)
+	ctx := core.PlayContext{
+		VariableStorage: NewVariableStore(),
+		LoopControl:     core.NoLooper,
+		AudioDevice:     dev,
+	}
+	// input is the current one
+	checkError(t, LoadSession(ctx, strings.NewReader(`{"format":1,"devices":{"in":1,"out":3},"variables":[]}`)))
+	if got, want := strings.Join(dev.settings, ","), "midi.out [3]"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"fmt"
 
 	"github.com/emicklei/melrose/core"
-
-	"github.com/emicklei/melrose/notify"
 )
 
 // At is called from expr after patching []. One-based
@@ -39,64 +37,61 @@ func (v variable) AtVariable(index variable) interface{} {
 }
 
 // dispatchSubFrom  v(l) - r
-func (v variable) dispatchSub(r interface{}) interface{} {
+func (v variable) dispatchSub(r interface{}) (interface{}, error) {
 	if vr, ok := r.(core.HasValue); ok {
 		// int
 		il, lok := resolveInt(v)
 		ir, rok := resolveInt(vr)
 		if lok && rok {
-			return il - ir
+			return il - ir, nil
 		}
 	}
 	if ir, ok := r.(int); ok {
 		// int
 		il, lok := resolveInt(v)
 		if lok {
-			return il - ir
+			return il - ir, nil
 		}
 	}
-	notify.Panic(fmt.Errorf("subtraction failed [%v (%T) - %v (%T)]", v, v, r, r))
-	return nil
+	return nil, fmt.Errorf("subtraction failed [%v (%T) - %v (%T)]", v, v, r, r)
 }
 
 // dispatchSubFrom  l - v(r)
-func (v variable) dispatchSubFrom(l interface{}) interface{} {
+func (v variable) dispatchSubFrom(l interface{}) (interface{}, error) {
 	if vl, ok := l.(core.HasValue); ok {
 		// int
 		il, lok := resolveInt(vl)
 		ir, rok := resolveInt(v)
 		if lok && rok {
-			return il - ir
+			return il - ir, nil
 		}
 	}
 	if il, ok := l.(int); ok {
 		// int
 		ir, rok := resolveInt(v)
 		if rok {
-			return il - ir
+			return il - ir, nil
 		}
 	}
-	notify.Panic(fmt.Errorf("subtraction failed [%v (%T) - %v (%T)]", l, l, v, v))
-	return nil
+	return nil, fmt.Errorf("subtraction failed [%v (%T) - %v (%T)]", l, l, v, v)
 }
 
-func (v variable) dispatchAdd(r interface{}) interface{} {
+func (v variable) dispatchAdd(r interface{}) (interface{}, error) {
 	if vr, ok := r.(core.HasValue); ok {
 		// int
 		il, lok := resolveInt(v)
 		ir, rok := resolveInt(vr)
 		if lok && rok {
-			return il + ir
+			return il + ir, nil
 		}
 	}
 	if ir, ok := r.(int); ok {
 		il, lok := resolveInt(v)
 		if lok {
-			return il + ir
+			return il + ir, nil
 		}
 	}
-	notify.Panic(fmt.Errorf("addition failed [%v (%T) + %v (%T)]", r, r, v, v))
-	return nil
+	return nil, fmt.Errorf("addition failed [%v (%T) + %v (%T)]", r, r, v, v)
 }
 
 // func (v variable) dispatchMultiply(r interface{}) interface{} {
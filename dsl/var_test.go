@@ -8,22 +8,38 @@ func Test_variable_Sub(t *testing.T) {
 	s := NewVariableStore()
 	s.Put("a", 1)
 	a := s.getVariable("a")
-	if got, want := a.dispatchAdd(1), 2; got != want {
+	if got, want := mustDispatch(t)(a.dispatchAdd(1)), 2; got != want {
 		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
 	}
-	if got, want := a.dispatchAdd(a), 2; got != want {
+	if got, want := mustDispatch(t)(a.dispatchAdd(a)), 2; got != want {
 		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
 	}
-	if got, want := a.dispatchSub(1), 0; got != want {
+	if got, want := mustDispatch(t)(a.dispatchSub(1)), 0; got != want {
 		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
 	}
-	if got, want := a.dispatchSub(a), 0; got != want {
+	if got, want := mustDispatch(t)(a.dispatchSub(a)), 0; got != want {
 		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
 	}
-	if got, want := a.dispatchSubFrom(2), 1; got != want {
+	if got, want := mustDispatch(t)(a.dispatchSubFrom(2)), 1; got != want {
 		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
 	}
-	if got, want := a.dispatchSubFrom(a), 0; got != want {
+	if got, want := mustDispatch(t)(a.dispatchSubFrom(a)), 0; got != want {
 		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
 	}
 }
+
+func mustDispatch(t *testing.T) func(interface{}, error) interface{} {
+	return func(v interface{}, err error) interface{} {
+		t.Helper()
+		checkError(t, err)
+		return v
+	}
+}
+
+func Test_variable_AddFailed(t *testing.T) {
+	s := NewVariableStore()
+	s.Put("a", "b")
+	if _, err := s.getVariable("a").dispatchAdd(1); err == nil {
+		t.Error("error expected")
+	}
+}
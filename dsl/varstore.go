@@ -17,12 +17,32 @@ import (
 type VariableStore struct {
 	mutex     sync.RWMutex
 	variables map[string]interface{}
+	history   [][]change // changes that can be undone, last is most recent
+	snapshots map[string]snapshot
+}
+
+// maxUndo is the number of changes that can be undone.
+const maxUndo = 100
+
+// change records the value of a variable before it was changed.
+type change struct {
+	name    string
+	value   interface{}
+	existed bool
+	target  []core.Sequenceable // of the value if it is a Loop ; its target is changed instead of the variable
+}
+
+// snapshot is a copy of all variables and the targets of the loops.
+type snapshot struct {
+	variables map[string]interface{}
+	targets   map[string][]core.Sequenceable
 }
 
 // NewVariableStore returns a new
 func NewVariableStore() *VariableStore {
 	return &VariableStore{
 		variables: map[string]interface{}{},
+		snapshots: map[string]snapshot{},
 	}
 }
 
@@ -52,19 +72,127 @@ func (v *VariableStore) Get(key string) (interface{}, bool) {
 }
 
 // Put stores a value by the key. Overwrites any existing value.
+// The change cannot be undone ; listeners use it for each incoming message.
 func (v *VariableStore) Put(key string, value interface{}) {
 	v.mutex.Lock()
 	v.variables[key] = value
 	v.mutex.Unlock()
 }
 
+// Assign stores a value by the key, as the result of an assignment, such that it can be undone.
+func (v *VariableStore) Assign(key string, value interface{}) {
+	v.mutex.Lock()
+	v.record([]change{v.changeOf(key)})
+	v.variables[key] = value
+	v.mutex.Unlock()
+}
+
 // Delete removes a stored value by the key. Ignores if the key is not found.
 func (v *VariableStore) Delete(key string) {
 	v.mutex.Lock()
+	if _, ok := v.variables[key]; ok {
+		v.record([]change{v.changeOf(key)})
+	}
 	delete(v.variables, key)
 	v.mutex.Unlock()
 }
 
+// changeOf returns the current state of a variable. Requires a lock.
+func (v *VariableStore) changeOf(key string) change {
+	value, ok := v.variables[key]
+	c := change{name: key, value: value, existed: ok}
+	if l, ok := value.(*core.Loop); ok {
+		c.target = l.Target()
+	}
+	return c
+}
+
+// record adds changes to the history. Requires a lock.
+func (v *VariableStore) record(changes []change) {
+	v.history = append(v.history, changes)
+	if len(v.history) > maxUndo {
+		v.history = v.history[1:]
+	}
+}
+
+// Undo reverts the last change of a variable, or the last restore of a snapshot.
+// If the variable was a running Loop then its target is reverted such that it keeps playing.
+// It returns the names of the reverted variables ; empty if there was nothing to undo.
+func (v *VariableStore) Undo() []string {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if len(v.history) == 0 {
+		return []string{}
+	}
+	last := v.history[len(v.history)-1]
+	v.history = v.history[:len(v.history)-1]
+	names := []string{}
+	// in reverse order of changing
+	for i := len(last) - 1; i >= 0; i-- {
+		each := last[i]
+		v.revert(each.name, each.value, each.existed, each.target)
+		names = append(names, each.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// revert sets the value of a variable and the target of its Loop, if any. Requires a lock.
+func (v *VariableStore) revert(key string, value interface{}, existed bool, target []core.Sequenceable) {
+	if !existed {
+		delete(v.variables, key)
+		return
+	}
+	if l, ok := value.(*core.Loop); ok && target != nil {
+		l.SetTarget(target)
+	}
+	v.variables[key] = value
+}
+
+// SaveSnapshot keeps a copy of all variables under a name. An existing snapshot with that name is replaced.
+func (v *VariableStore) SaveSnapshot(name string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	s := snapshot{variables: map[string]interface{}{}, targets: map[string][]core.Sequenceable{}}
+	for k, each := range v.variables {
+		s.variables[k] = each
+		if l, ok := each.(*core.Loop); ok {
+			s.targets[k] = l.Target()
+		}
+	}
+	v.snapshots[name] = s
+}
+
+// RestoreSnapshot sets all variables to their values of a saved snapshot ; variables created after it are kept.
+// Running loops keep playing with their target of the snapshot. A restore can be undone.
+func (v *VariableStore) RestoreSnapshot(name string) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	s, ok := v.snapshots[name]
+	if !ok {
+		return fmt.Errorf("no snapshot named %q", name)
+	}
+	changes := []change{}
+	for k, each := range s.variables {
+		changes = append(changes, v.changeOf(k))
+		v.revert(k, each, true, s.targets[k])
+	}
+	v.record(changes)
+	return nil
+}
+
+// SnapshotNames returns the sorted names of all saved snapshots.
+func (v *VariableStore) SnapshotNames() []string {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	names := []string{}
+	for k := range v.snapshots {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Variables returns a copy of all stores variables.
 func (v *VariableStore) Variables() map[string]interface{} {
 	v.mutex.RLock()
@@ -0,0 +1,94 @@
+package dsl
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/control"
+	"github.com/emicklei/melrose/core"
+)
+
+func TestVariableStore_UndoLoopTarget(t *testing.T) {
+	ctx := testContext()
+	e := NewEvaluator(ctx)
+	_, err := e.EvaluateProgram(`l = loop(sequence('C'))`)
+	checkError(t, err)
+	first, _ := ctx.Variables().Get("l")
+	_, err = e.EvaluateProgram(`l = loop(sequence('D'))`)
+	checkError(t, err)
+	store := ctx.Variables().(*VariableStore)
+	if got, want := store.Undo(), []string{"l"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	l, _ := ctx.Variables().Get("l")
+	if l != first {
+		t.Fatal("loop must be the same")
+	}
+	if got, want := core.Storex(l.(*core.Loop).Target()[0]), "sequence('C')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	store.Undo()
+	if _, ok := ctx.Variables().Get("l"); ok {
+		t.Error("l must be undone")
+	}
+	if got := store.Undo(); len(got) != 0 {
+		t.Errorf("got [%v] want nothing to undo", got)
+	}
+}
+
+func TestVariableStore_Snapshot(t *testing.T) {
+	ctx := testContext()
+	e := NewEvaluator(ctx)
+	_, err := e.EvaluateProgram("a = 1\nl = loop(sequence('C'))")
+	checkError(t, err)
+	store := ctx.Variables().(*VariableStore)
+	store.SaveSnapshot("verse")
+	_, err = e.EvaluateProgram("a = 2\nl = loop(sequence('D'))\nb = 3")
+	checkError(t, err)
+	if err := store.RestoreSnapshot("chorus"); err == nil {
+		t.Error("error expected")
+	}
+	checkError(t, store.RestoreSnapshot("verse"))
+	if got, want := core.ValueOf(mustGet(t, ctx, "a")), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.Storex(mustGet(t, ctx, "l").(*core.Loop).Target()[0]), "sequence('C')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	mustGet(t, ctx, "b")
+	// undo the restore
+	store.Undo()
+	if got, want := core.ValueOf(mustGet(t, ctx, "a")), 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.Storex(mustGet(t, ctx, "l").(*core.Loop).Target()[0]), "sequence('D')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func mustGet(t *testing.T, ctx core.Context, name string) interface{} {
+	t.Helper()
+	v, ok := ctx.Variables().Get(name)
+	if !ok {
+		t.Fatalf("missing %s", name)
+	}
+	return v
+}
+
+func TestVariableStore_ListenDoesNotUndo(t *testing.T) {
+	ctx := testContext()
+	e := NewEvaluator(ctx)
+	_, err := e.EvaluateProgram("n = note('c')\nrec = listen(n,print(n))")
+	checkError(t, err)
+	rec := mustGet(t, ctx, "rec").(*control.Listen)
+	for i := 0; i < maxUndo+1; i++ {
+		rec.NoteOn(1, core.MustParseNote("c"))
+		rec.NoteOff(1, core.MustParseNote("c"))
+	}
+	store := ctx.Variables().(*VariableStore)
+	if got, want := store.Undo(), []string{"rec"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := store.Undo(), []string{"n"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,153 @@
+package dsl
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// WatchInterval is how often a watched file is checked for changes.
+var WatchInterval = 500 * time.Millisecond
+
+// Watcher evaluates a script file whenever it changes on disk.
+// Only statements that changed, or that use a variable that changed, are evaluated again.
+// Running loops keep running ; an assignment of a loop to the same variable changes its target.
+type Watcher struct {
+	evaluator *Evaluator
+	fileName  string
+	mutex     *sync.Mutex
+	modTime   time.Time
+	evaluated map[string]string // variable name or statement -> statement of the last evaluation
+	stop      chan bool
+}
+
+// NewWatcher returns a Watcher for a file that uses the variables of the context.
+func NewWatcher(ctx core.Context, fileName string) *Watcher {
+	return &Watcher{
+		evaluator: NewEvaluator(ctx),
+		fileName:  fileName,
+		mutex:     new(sync.Mutex),
+		evaluated: map[string]string{},
+	}
+}
+
+// FileName returns the name of the watched file.
+func (w *Watcher) FileName() string { return w.fileName }
+
+// Start evaluates the file and then keeps checking it for changes until Stop is called.
+func (w *Watcher) Start() error {
+	if err := w.check(); err != nil {
+		return err
+	}
+	w.stop = make(chan bool)
+	go func(stop chan bool) {
+		ticker := time.NewTicker(WatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := w.check(); err != nil {
+					notify.Errorf("watch %s: %v", w.fileName, err)
+				}
+			}
+		}
+	}(w.stop)
+	return nil
+}
+
+// Stop ends checking the file ; variables and running loops are kept.
+func (w *Watcher) Stop() {
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+// check reloads the file if it was modified since the last check.
+func (w *Watcher) check() error {
+	info, err := os.Stat(w.fileName)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(w.modTime) {
+		return nil
+	}
+	w.modTime = info.ModTime()
+	source, err := os.ReadFile(w.fileName)
+	if err != nil {
+		return err
+	}
+	n, err := w.Reload(string(source))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		notify.Infof("watch %s: evaluated %d changed statement(s)", w.fileName, n)
+	}
+	return nil
+}
+
+var identifierRegex = regexp.MustCompile(`[a-zA-Z_][a-zA-Z0-9_]*`)
+
+// Reload evaluates the statements of the source that are new or changed since the last evaluation,
+// and the assignments that use a variable that was assigned again. It returns the number of evaluated statements.
+// A statement that fails is reported and evaluated again on the next reload.
+func (w *Watcher) Reload(source string) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	lines, err := splitStatements(source)
+	if err != nil {
+		return 0, err
+	}
+	current := map[string]string{}
+	changed := map[string]bool{} // variable names
+	count := 0
+	for _, each := range lines {
+		entry := strings.TrimSpace(withoutTrailingComment(strings.Replace(each.text, "\t", " ", -1)))
+		if len(entry) == 0 {
+			continue
+		}
+		key := entry
+		varName, expression, isAssignment := IsAssignment(entry)
+		if isAssignment {
+			key = varName
+		}
+		if w.evaluated[key] == entry && !(isAssignment && usesAny(expression, changed)) {
+			current[key] = entry
+			continue
+		}
+		if _, err := w.evaluator.evaluateCleanStatement(entry); err != nil {
+			located := newError(source, each, err)
+			located.File = w.fileName
+			notify.Errorf("%v", located)
+			continue
+		}
+		count++
+		current[key] = entry
+		if isAssignment {
+			changed[varName] = true
+		}
+	}
+	w.evaluated = current
+	return count, nil
+}
+
+// usesAny returns whether the expression has an identifier that is one of the names.
+func usesAny(expression string, names map[string]bool) bool {
+	if len(names) == 0 {
+		return false
+	}
+	for _, each := range identifierRegex.FindAllString(expression, -1) {
+		if names[each] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package dsl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestWatcher_Reload(t *testing.T) {
+	ctx := testContext()
+	w := NewWatcher(ctx, "song.mel")
+	n, err := w.Reload("m = sequence('c e')\nl = loop(m)\nx = sequence('a')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n, 3; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	before, _ := ctx.Variables().Get("l")
+	// only m changed ; l uses m and x is unchanged
+	n, _ = w.Reload("m = sequence('c e g') // more\nl = loop(m)\nx = sequence('a')")
+	if got, want := n, 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	after, _ := ctx.Variables().Get("l")
+	if before != after {
+		t.Error("loop must be preserved")
+	}
+	if got, want := after.(*core.Loop).Target()[0].S().Storex(), "sequence('C E G')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// failing statements are evaluated again
+	n, _ = w.Reload("m = sequence('c e g')\nl = loop(m)\nx = unknown()")
+	if got, want := n, 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	n, _ = w.Reload("m = sequence('c e g')\nl = loop(m)\nx = sequence('b')")
+	if got, want := n, 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestWatcher_Check(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "song.mel")
+	if err := os.WriteFile(name, []byte("a = note('c')"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := testContext()
+	w := NewWatcher(ctx, name)
+	if err := w.check(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ctx.Variables().Get("a"); !ok {
+		t.Error("missing variable a")
+	}
+}
@@ -0,0 +1,413 @@
+package link
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+var multicastAddr = &net.UDPAddr{IP: net.IPv4(224, 76, 78, 75), Port: 20808}
+
+const (
+	// broadcastPeriod is how often the state is announced to the peers.
+	broadcastPeriod = 250 * time.Millisecond
+	// remeasurePeriod is how often the ghost time of a session is measured again.
+	remeasurePeriod = 30 * time.Second
+	// sessionEpsilon is how much older, in ghost microseconds, another session must be to be joined.
+	sessionEpsilon = 500000
+)
+
+// Link is a peer of an Ableton Link session on the local network.
+// It shares the tempo and the beat phase of the session with the control of a context, e.g. Ableton Live or Bitwig.
+// A new peer starts its own session and joins the oldest session it finds.
+type Link struct {
+	mutex     *sync.RWMutex
+	ctx       core.Context
+	clock     hostClock
+	id        nodeID
+	session   nodeID
+	xform     ghostXForm // host time to the ghost time of the session
+	timeline  timeline   // of the session
+	peers     map[nodeID]peer
+	measured  map[nodeID]time.Time // start of the last measurement of a session
+	requested map[float64]bool     // BPM values set on the control on behalf of the session
+	observed  float64              // BPM of the control at the last poll
+	discovery *net.UDPConn         // sends the state and receives responses
+	multicast *net.UDPConn         // receives the state of peers
+	responder *net.UDPConn         // answers measurement pings
+	endpoint  *net.UDPAddr         // of the responder, as seen by peers
+	done      chan bool
+}
+
+// peer is the last known state of another peer.
+type peer struct {
+	state   peerState
+	expires time.Time
+}
+
+func newLink(ctx core.Context, clock hostClock) *Link {
+	id := newNodeID()
+	now := clock.now()
+	bpm := ctx.Control().BPM()
+	return &Link{
+		mutex:     new(sync.RWMutex),
+		ctx:       ctx,
+		clock:     clock,
+		id:        id,
+		session:   id,
+		xform:     ghostXForm{slope: 1, intercept: -now}, // the ghost time of a new session starts at zero
+		timeline:  newTimeline(bpm, 0),
+		peers:     map[nodeID]peer{},
+		measured:  map[nodeID]time.Time{},
+		requested: map[float64]bool{},
+		observed:  bpm,
+		done:      make(chan bool),
+	}
+}
+
+// newNodeID returns a random id of printable characters, as Link does.
+func newNodeID() nodeID {
+	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	id := nodeID{}
+	rand.Read(id[:])
+	for i, each := range id {
+		id[i] = chars[int(each)%len(chars)]
+	}
+	return id
+}
+
+// BeatAt is part of core.BeatClock
+func (l *Link) BeatAt(t time.Time) float64 {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.timeline.toBeats(l.xform.hostToGhost(l.clock.micros(t)))
+}
+
+// TimeAt is part of core.BeatClock
+func (l *Link) TimeAt(beats float64) time.Time {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.clock.time(l.xform.ghostToHost(l.timeline.fromBeats(beats)))
+}
+
+// open starts listening for peers and announces the state of this peer.
+func (l *Link) open() error {
+	local, err := localAddress()
+	if err != nil {
+		return err
+	}
+	if l.multicast, err = net.ListenMulticastUDP("udp4", nil, multicastAddr); err != nil {
+		return fmt.Errorf("cannot listen for Link peers on %s: %v", multicastAddr, err)
+	}
+	if l.discovery, err = net.ListenUDP("udp4", &net.UDPAddr{}); err != nil {
+		l.multicast.Close()
+		return fmt.Errorf("cannot open Link discovery: %v", err)
+	}
+	if l.responder, err = net.ListenUDP("udp4", &net.UDPAddr{}); err != nil {
+		l.multicast.Close()
+		l.discovery.Close()
+		return fmt.Errorf("cannot open Link measurement: %v", err)
+	}
+	l.endpoint = &net.UDPAddr{IP: local, Port: l.responder.LocalAddr().(*net.UDPAddr).Port}
+	go l.receive(l.multicast)
+	go l.receive(l.discovery)
+	go l.respond(l.responder)
+	go l.announce()
+	l.broadcast(aliveMessage)
+	return nil
+}
+
+// close leaves the session.
+func (l *Link) close() {
+	close(l.done)
+	l.broadcast(byeByeMessage)
+	for _, each := range []*net.UDPConn{l.multicast, l.discovery, l.responder} {
+		if each != nil {
+			each.Close()
+		}
+	}
+}
+
+// localAddress returns the address of the interface that is used to reach the peers.
+func localAddress() (net.IP, error) {
+	// no packets are sent
+	conn, err := net.DialUDP("udp4", nil, multicastAddr)
+	if err != nil {
+		return nil, fmt.Errorf("no network for Link: %v", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+// state returns the state of this peer as announced.
+func (l *Link) state() peerState {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return peerState{id: l.id, session: l.session, timeline: l.timeline, endpoint: l.endpoint}
+}
+
+// broadcast sends the state to all peers.
+func (l *Link) broadcast(messageType byte) {
+	l.send(messageType, multicastAddr)
+}
+
+func (l *Link) send(messageType byte, to *net.UDPAddr) {
+	if l.discovery == nil {
+		return
+	}
+	if _, err := l.discovery.WriteToUDP(encodeState(messageType, l.state()), to); err != nil && core.IsDebug() {
+		notify.Debugf("link.send error:%v", err)
+	}
+}
+
+// announce broadcasts the state periodically, forgets peers that are gone and publishes changes of the tempo of the control.
+func (l *Link) announce() {
+	ticker := time.NewTicker(broadcastPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			l.prune()
+			l.pollTempo()
+			l.remeasure()
+			l.broadcast(aliveMessage)
+		}
+	}
+}
+
+// receive handles the states of peers until the connection is closed.
+func (l *Link) receive(conn *net.UDPConn) {
+	buffer := make([]byte, maxMessageSize)
+	for {
+		n, from, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			// closed
+			return
+		}
+		messageType, s, err := decodeState(buffer[:n])
+		if err != nil {
+			if core.IsDebug() {
+				notify.Debugf("link.receive from %s error:%v", from, err)
+			}
+			continue
+		}
+		if s.id == l.id {
+			continue
+		}
+		if messageType == aliveMessage {
+			l.send(responseMessage, from)
+		}
+		l.handleState(messageType, s)
+	}
+}
+
+// respond answers measurement pings until the connection is closed.
+func (l *Link) respond(conn *net.UDPConn) {
+	buffer := make([]byte, maxMessageSize)
+	for {
+		n, from, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			// closed
+			return
+		}
+		l.mutex.RLock()
+		session, ghostTime := l.session, l.xform.hostToGhost(l.clock.now())
+		l.mutex.RUnlock()
+		if reply := respond(buffer[:n], session, ghostTime); reply != nil {
+			conn.WriteToUDP(reply, from)
+		}
+	}
+}
+
+// handleState updates the session with the state of a peer.
+// The timeline of a peer in the same session is adopted if it is later ; another session is measured to see if it must be joined.
+func (l *Link) handleState(messageType byte, s peerState) {
+	l.mutex.Lock()
+	if messageType == byeByeMessage {
+		delete(l.peers, s.id)
+		l.mutex.Unlock()
+		return
+	}
+	l.peers[s.id] = peer{state: s, expires: time.Now().Add(ttl * time.Second)}
+	if s.session == l.session {
+		if s.timeline.beatOrigin <= l.timeline.beatOrigin {
+			l.mutex.Unlock()
+			return
+		}
+		l.timeline = s.timeline
+		bpm := l.timeline.bpm()
+		l.mutex.Unlock()
+		l.applyTempo(bpm)
+		return
+	}
+	if s.endpoint == nil || time.Since(l.measured[s.session]) < remeasurePeriod {
+		l.mutex.Unlock()
+		return
+	}
+	l.measured[s.session] = time.Now()
+	l.mutex.Unlock()
+	go l.measureSession(s.session, s.endpoint)
+}
+
+// measureSession measures the ghost time of a session and joins it if it is older than the current one.
+// A session with about the same ghost time is joined if its id is smaller.
+func (l *Link) measureSession(session nodeID, endpoint *net.UDPAddr) {
+	xform, err := measure(l.clock, session, endpoint)
+	if err != nil {
+		if core.IsDebug() {
+			notify.Debugf("link.measure error:%v", err)
+		}
+		return
+	}
+	l.mutex.Lock()
+	if session == l.session {
+		l.xform = xform
+		l.mutex.Unlock()
+		return
+	}
+	now := l.clock.now()
+	diff := xform.hostToGhost(now) - l.xform.hostToGhost(now)
+	join := diff > sessionEpsilon || (diff > -sessionEpsilon && diff < sessionEpsilon && session.less(l.session))
+	if !join {
+		l.mutex.Unlock()
+		return
+	}
+	l.session = session
+	l.xform = xform
+	peers := 0
+	for _, each := range l.peers {
+		if each.state.session != session {
+			continue
+		}
+		peers++
+		if peers == 1 || each.state.timeline.beatOrigin > l.timeline.beatOrigin {
+			l.timeline = each.state.timeline
+		}
+	}
+	bpm := l.timeline.bpm()
+	l.mutex.Unlock()
+	notify.Infof("joined Link session with %d peer(s) at %.2f BPM", peers, bpm)
+	l.applyTempo(bpm)
+	l.broadcast(aliveMessage)
+}
+
+// remeasure measures the ghost time of the current session again, if it was started by another peer.
+func (l *Link) remeasure() {
+	l.mutex.Lock()
+	if l.session == l.id || time.Since(l.measured[l.session]) < remeasurePeriod {
+		l.mutex.Unlock()
+		return
+	}
+	var endpoint *net.UDPAddr
+	for _, each := range l.peers {
+		if each.state.session == l.session && each.state.endpoint != nil {
+			endpoint = each.state.endpoint
+			break
+		}
+	}
+	if endpoint == nil {
+		l.mutex.Unlock()
+		return
+	}
+	l.measured[l.session] = time.Now()
+	session := l.session
+	l.mutex.Unlock()
+	go l.measureSession(session, endpoint)
+}
+
+// prune forgets the peers that did not announce their state in time.
+func (l *Link) prune() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := time.Now()
+	for id, each := range l.peers {
+		if now.After(each.expires) {
+			delete(l.peers, id)
+		}
+	}
+}
+
+// applyTempo changes the BPM of the control to the tempo of the session.
+func (l *Link) applyTempo(bpm float64) {
+	if l.ctx.Control().BPM() == bpm {
+		return
+	}
+	l.mutex.Lock()
+	l.requested[bpm] = true
+	l.mutex.Unlock()
+	l.ctx.Control().SetBPM(bpm)
+}
+
+// pollTempo publishes a new timeline to the peers if the BPM of the control was changed, e.g. by bpm(90).
+func (l *Link) pollTempo() {
+	bpm := l.ctx.Control().BPM()
+	l.mutex.Lock()
+	if bpm == l.observed {
+		l.mutex.Unlock()
+		return
+	}
+	l.observed = bpm
+	// changed on behalf of the session
+	if l.requested[bpm] {
+		delete(l.requested, bpm)
+		l.mutex.Unlock()
+		return
+	}
+	if bpm == l.timeline.bpm() {
+		l.mutex.Unlock()
+		return
+	}
+	l.requested = map[float64]bool{}
+	l.timeline = l.timeline.withTempo(bpm, l.xform.hostToGhost(l.clock.now()))
+	l.mutex.Unlock()
+	l.broadcast(aliveMessage)
+}
+
+var current = struct {
+	mutex sync.Mutex
+	link  *Link
+}{}
+
+// Enable starts a peer that shares the tempo and the beat phase of the control of a context with a Link session.
+func Enable(ctx core.Context) error {
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+	if current.link != nil {
+		return nil
+	}
+	controller, ok := ctx.Control().(core.ClockedController)
+	if !ok {
+		return errors.New("the control cannot follow a Link session")
+	}
+	l := newLink(ctx, hostClock{origin: time.Now()})
+	if err := l.open(); err != nil {
+		return err
+	}
+	controller.SetClock(l)
+	current.link = l
+	notify.Infof("Ableton Link is enabled, the tempo and bars are shared with other applications")
+	return nil
+}
+
+// Disable leaves the Link session ; the control uses its own tempo and bars again.
+func Disable(ctx core.Context) {
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+	if current.link == nil {
+		return
+	}
+	if controller, ok := ctx.Control().(core.ClockedController); ok {
+		controller.SetClock(nil)
+	}
+	current.link.close()
+	current.link = nil
+	notify.Infof("Ableton Link is disabled")
+}
@@ -0,0 +1,189 @@
+package link
+
+import (
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func newTestLink(bpm float64) *Link {
+	ctx := core.PlayContext{}
+	ctx.LoopControl = core.NewBeatmaster(ctx, bpm)
+	return newLink(ctx, hostClock{origin: time.Now()})
+}
+
+// startResponder answers pings on the loopback interface as a peer of a session with a ghost time.
+func startResponder(t *testing.T, session nodeID, ghostTime func() int64) *net.UDPAddr {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buffer := make([]byte, maxMessageSize)
+		for {
+			n, from, err := conn.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+			if reply := respond(buffer[:n], session, ghostTime()); reply != nil {
+				conn.WriteToUDP(reply, from)
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr)
+}
+
+func TestTimeline_Beats(t *testing.T) {
+	tl := newTimeline(120, 1000000)
+	if got, want := tl.toBeats(3000000), 4.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := tl.fromBeats(4), int64(3000000); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := tl.bpm(), 120.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestTimeline_WithTempo(t *testing.T) {
+	tl := newTimeline(120, 0)
+	next := tl.withTempo(90, 1750000)
+	if got, want := next.bpm(), 90.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if next.beatOrigin <= tl.beatOrigin {
+		t.Errorf("beat origin must be later, got %d after %d", next.beatOrigin, tl.beatOrigin)
+	}
+	// the beats continue at the change
+	if got, want := next.toBeats(1750000), tl.toBeats(1750000); math.Abs(got-want) > 1e-6 {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// one beat later at the new tempo
+	if got, want := next.toBeats(1750000+666667), tl.toBeats(1750000)+1; math.Abs(got-want) > 1e-6 {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestLink_BeatAndTime(t *testing.T) {
+	l := newTestLink(120)
+	// the ghost time of a new session starts at zero
+	start := l.clock.time(l.xform.ghostToHost(0))
+	if got, want := l.BeatAt(start.Add(time.Second)), 2.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := l.TimeAt(6), start.Add(3*time.Second); !got.Equal(want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	clock := hostClock{origin: time.Now()}
+	session := nodeID{'s', 'e', 's', 's', 'i', 'o', 'n', '1'}
+	offset := int64(10000000)
+	endpoint := startResponder(t, session, func() int64 { return clock.now() + offset })
+	xform, err := measure(clock, session, endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := xform.intercept, offset; got < want-5000 || got > want+5000 {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestMeasure_NoAnswer(t *testing.T) {
+	clock := hostClock{origin: time.Now()}
+	session := nodeID{'s', 'e', 's', 's', 'i', 'o', 'n', '1'}
+	endpoint := startResponder(t, nodeID{}, func() int64 { return clock.now() })
+	if _, err := measure(clock, session, endpoint); err == nil {
+		t.Error("error expected for pongs of another session")
+	}
+}
+
+func TestLink_HandleStateSameSession(t *testing.T) {
+	l := newTestLink(120)
+	other := nodeID{'o', 't', 'h', 'e', 'r', 'p', 'e', 'e'}
+	later := l.timeline.withTempo(100, 2000000)
+	l.handleState(aliveMessage, peerState{id: other, session: l.session, timeline: later})
+	if got, want := l.timeline, later; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := l.ctx.Control().BPM(), 100.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// an earlier timeline is ignored
+	l.handleState(aliveMessage, peerState{id: other, session: l.session, timeline: newTimeline(80, 0)})
+	if got, want := l.timeline, later; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := len(l.peers), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	l.handleState(byeByeMessage, peerState{id: other})
+	if got, want := len(l.peers), 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestLink_JoinOlderSession(t *testing.T) {
+	l := newTestLink(120)
+	other := nodeID{'o', 't', 'h', 'e', 'r', 'p', 'e', 'e'}
+	// the other session started ten seconds earlier
+	offset := int64(10000000)
+	endpoint := startResponder(t, other, func() int64 { return l.clock.now() + offset })
+	tl := newTimeline(95, 0)
+	l.peers[other] = peer{state: peerState{id: other, session: other, timeline: tl, endpoint: endpoint}, expires: time.Now().Add(time.Minute)}
+	l.measureSession(other, endpoint)
+	if got, want := l.session, other; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := l.timeline, tl; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := l.ctx.Control().BPM(), 95.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// the beats follow the ghost time of the other session
+	if got, want := l.BeatAt(l.clock.time(l.clock.now())), 95.0/60*10; math.Abs(got-want) > 0.02 {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestLink_KeepOlderSession(t *testing.T) {
+	l := newTestLink(120)
+	time.Sleep(10 * time.Millisecond)
+	other := nodeID{'0', '0', '0', '0', '0', '0', '0', '0'}
+	// the other session started ten seconds later
+	endpoint := startResponder(t, other, func() int64 { return l.clock.now() - 10000000 })
+	l.measureSession(other, endpoint)
+	if got, want := l.session, l.id; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := l.ctx.Control().BPM(), 120.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestLink_PollTempo(t *testing.T) {
+	l := newTestLink(120)
+	before := l.timeline
+	// changed on behalf of the session
+	l.applyTempo(100)
+	l.pollTempo()
+	if got, want := l.timeline, before; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// changed locally, e.g. by bpm(90)
+	l.ctx.Control().SetBPM(90)
+	l.pollTempo()
+	if got, want := l.timeline.bpm(), 90.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if l.timeline.beatOrigin <= before.beatOrigin {
+		t.Errorf("beat origin must be later, got %d after %d", l.timeline.beatOrigin, before.beatOrigin)
+	}
+}
@@ -0,0 +1,82 @@
+package link
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// measurementPoints is the number of offsets that are measured to compute the ghost time of a session.
+	measurementPoints = 100
+	// measurementTimeout is how long a pong is awaited before the ping is sent again.
+	measurementTimeout = 50 * time.Millisecond
+	// measurementRetries is the number of unanswered pings before a measurement fails.
+	measurementRetries = 5
+)
+
+// measure pings a peer of a session to compute the transformation of host time to the ghost time of that session.
+// Each pong gives the ghost time of the peer between the host times of sending and receiving ; the median offset is used.
+func measure(clock hostClock, session nodeID, endpoint *net.UDPAddr) (ghostXForm, error) {
+	conn, err := net.DialUDP("udp4", nil, endpoint)
+	if err != nil {
+		return ghostXForm{}, err
+	}
+	defer conn.Close()
+	offsets := []float64{}
+	prevGhostTime := int64(0)
+	retries := 0
+	buffer := make([]byte, maxMessageSize)
+	if _, err := conn.Write(encodePing(clock.now(), 0)); err != nil {
+		return ghostXForm{}, err
+	}
+	for len(offsets) <= measurementPoints {
+		conn.SetReadDeadline(time.Now().Add(measurementTimeout))
+		n, err := conn.Read(buffer)
+		if err != nil {
+			var timeout net.Error
+			if errors.As(err, &timeout) && timeout.Timeout() && retries < measurementRetries {
+				retries++
+				if _, err := conn.Write(encodePing(clock.now(), prevGhostTime)); err != nil {
+					return ghostXForm{}, err
+				}
+				continue
+			}
+			return ghostXForm{}, fmt.Errorf("cannot measure Link session %s at %s: %v", session, endpoint, err)
+		}
+		messageType, payload, err := decodeMeasurement(buffer[:n])
+		if err != nil || messageType != pongMessage {
+			continue
+		}
+		p, err := decodePong(payload)
+		if err != nil || p.session != session {
+			continue
+		}
+		hostTime := clock.now()
+		if _, err := conn.Write(encodePing(hostTime, p.ghostTime)); err != nil {
+			return ghostXForm{}, err
+		}
+		if p.ghostTime != 0 && p.hostTime != 0 {
+			offsets = append(offsets, float64(p.ghostTime)-float64(hostTime+p.hostTime)/2)
+			if p.prevGhostTime != 0 {
+				offsets = append(offsets, float64(p.ghostTime+p.prevGhostTime)/2-float64(p.hostTime))
+			}
+		}
+		prevGhostTime = p.ghostTime
+	}
+	return ghostXForm{slope: 1, intercept: int64(median(offsets))}, nil
+}
+
+// respond answers a ping with the ghost time of the session ; returns nil if the message is not a ping.
+func respond(message []byte, session nodeID, ghostTime int64) []byte {
+	messageType, payload, err := decodeMeasurement(message)
+	if err != nil || messageType != pingMessage {
+		return nil
+	}
+	// the payload of the ping is sent back
+	if _, err := parsePayload(payload); err != nil {
+		return nil
+	}
+	return encodePong(session, ghostTime, payload)
+}
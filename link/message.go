@@ -0,0 +1,250 @@
+package link
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Messages of the Ableton Link protocol, see https://github.com/Ableton/link.
+// All numbers are big endian. A payload is a list of entries: key (uint32), size (uint32) and value.
+
+var (
+	// discoveryHeader starts each message that announces the state of a peer.
+	discoveryHeader = []byte{'_', 'a', 's', 'd', 'p', '_', 'v', 1}
+	// measurementHeader starts each ping and pong message to measure the time of a session.
+	measurementHeader = []byte{'_', 'l', 'i', 'n', 'k', '_', 'v', 1}
+)
+
+// discovery message types
+const (
+	aliveMessage    = 1
+	responseMessage = 2
+	byeByeMessage   = 3
+)
+
+// measurement message types
+const (
+	pingMessage = 1
+	pongMessage = 2
+)
+
+// payload entry keys
+const (
+	timelineKey      = 0x746d6c6e // 'tmln'
+	sessionKey       = 0x73657373 // 'sess'
+	startStopKey     = 0x73747374 // 'stst'
+	endpointV4Key    = 0x6d657034 // 'mep4'
+	hostTimeKey      = 0x5f5f6874 // '__ht'
+	ghostTimeKey     = 0x5f5f6774 // '__gt'
+	prevGhostTimeKey = 0x5f706774 // '_pgt'
+)
+
+const (
+	// ttl is the number of seconds the state of a peer is valid.
+	ttl = 5
+	// maxMessageSize is the largest message that is sent or accepted.
+	maxMessageSize = 512
+)
+
+// nodeID identifies a peer ; the id of a session is the id of the peer that started it.
+type nodeID [8]byte
+
+func (n nodeID) String() string { return fmt.Sprintf("%x", n[:]) }
+
+// less is the order of ids used to choose between sessions with the same time.
+func (n nodeID) less(o nodeID) bool { return bytes.Compare(n[:], o[:]) < 0 }
+
+// peerState is the state that a peer announces.
+type peerState struct {
+	id       nodeID
+	session  nodeID
+	timeline timeline
+	endpoint *net.UDPAddr // to measure the time of the session of the peer ; nil if unknown
+}
+
+// payloadWriter writes the entries of a payload.
+type payloadWriter struct {
+	bytes.Buffer
+}
+
+// entry writes a key with values of fixed size.
+func (w *payloadWriter) entry(key uint32, values ...interface{}) {
+	size := 0
+	for _, each := range values {
+		size += binary.Size(each)
+	}
+	binary.Write(w, binary.BigEndian, key)
+	binary.Write(w, binary.BigEndian, uint32(size))
+	for _, each := range values {
+		binary.Write(w, binary.BigEndian, each)
+	}
+}
+
+// parsePayload returns the values of the entries by key ; entries with an unknown key are kept as well.
+func parsePayload(data []byte) (map[uint32][]byte, error) {
+	entries := map[uint32][]byte{}
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return entries, errors.New("incomplete payload entry")
+		}
+		key := binary.BigEndian.Uint32(data)
+		size := binary.BigEndian.Uint32(data[4:])
+		data = data[8:]
+		if int(size) > len(data) {
+			return entries, fmt.Errorf("payload entry %x is larger than the message", key)
+		}
+		entries[key] = data[:size]
+		data = data[size:]
+	}
+	return entries, nil
+}
+
+// readEntry decodes the value of an entry into fixed size values ; returns false if the entry is missing.
+func readEntry(entries map[uint32][]byte, key uint32, values ...interface{}) (bool, error) {
+	data, ok := entries[key]
+	if !ok {
+		return false, nil
+	}
+	r := bytes.NewReader(data)
+	for _, each := range values {
+		if err := binary.Read(r, binary.BigEndian, each); err != nil {
+			return true, fmt.Errorf("invalid payload entry %x: %v", key, err)
+		}
+	}
+	return true, nil
+}
+
+// encodeState returns a discovery message with the state of a peer.
+// A bye bye message has no payload.
+func encodeState(messageType byte, s peerState) []byte {
+	var b bytes.Buffer
+	b.Write(discoveryHeader)
+	b.WriteByte(messageType)
+	if messageType == byeByeMessage {
+		b.WriteByte(0)
+	} else {
+		b.WriteByte(ttl)
+	}
+	binary.Write(&b, binary.BigEndian, uint16(0)) // group
+	b.Write(s.id[:])
+	if messageType == byeByeMessage {
+		return b.Bytes()
+	}
+	var p payloadWriter
+	p.entry(timelineKey, s.timeline.microsPerBeat, s.timeline.beatOrigin, s.timeline.timeOrigin)
+	p.entry(sessionKey, s.session)
+	p.entry(startStopKey, false, int64(0), int64(0))
+	if s.endpoint != nil {
+		if ip := s.endpoint.IP.To4(); ip != nil {
+			p.entry(endpointV4Key, binary.BigEndian.Uint32(ip), uint16(s.endpoint.Port))
+		}
+	}
+	b.Write(p.Bytes())
+	return b.Bytes()
+}
+
+// decodeState returns the type and the state of a discovery message.
+func decodeState(data []byte) (byte, peerState, error) {
+	s := peerState{}
+	if len(data) < 20 || !bytes.Equal(data[:8], discoveryHeader) {
+		return 0, s, errors.New("not a Link discovery message")
+	}
+	messageType := data[8]
+	if group := binary.BigEndian.Uint16(data[10:]); group != 0 {
+		return 0, s, fmt.Errorf("unknown Link group %d", group)
+	}
+	copy(s.id[:], data[12:20])
+	if messageType == byeByeMessage {
+		return messageType, s, nil
+	}
+	entries, err := parsePayload(data[20:])
+	if err != nil {
+		return 0, s, err
+	}
+	tl := timeline{}
+	ok, err := readEntry(entries, timelineKey, &tl.microsPerBeat, &tl.beatOrigin, &tl.timeOrigin)
+	if err != nil {
+		return 0, s, err
+	}
+	if !ok || tl.microsPerBeat <= 0 {
+		return 0, s, errors.New("missing or invalid Link timeline")
+	}
+	s.timeline = tl
+	if ok, err := readEntry(entries, sessionKey, &s.session); err != nil || !ok {
+		return 0, s, errors.New("missing or invalid Link session")
+	}
+	var ip uint32
+	var port uint16
+	if ok, err := readEntry(entries, endpointV4Key, &ip, &port); err == nil && ok {
+		addr := make(net.IP, 4)
+		binary.BigEndian.PutUint32(addr, ip)
+		s.endpoint = &net.UDPAddr{IP: addr, Port: int(port)}
+	}
+	return messageType, s, nil
+}
+
+// encodePing returns a measurement ping with the host time of the sender and the ghost time of the previous pong, if any.
+func encodePing(hostTime, prevGhostTime int64) []byte {
+	var b bytes.Buffer
+	b.Write(measurementHeader)
+	b.WriteByte(pingMessage)
+	var p payloadWriter
+	p.entry(hostTimeKey, hostTime)
+	if prevGhostTime != 0 {
+		p.entry(prevGhostTimeKey, prevGhostTime)
+	}
+	b.Write(p.Bytes())
+	return b.Bytes()
+}
+
+// encodePong returns the answer to a ping: the session, the ghost time at receiving and the payload of the ping.
+func encodePong(session nodeID, ghostTime int64, pingPayload []byte) []byte {
+	var b bytes.Buffer
+	b.Write(measurementHeader)
+	b.WriteByte(pongMessage)
+	var p payloadWriter
+	p.entry(sessionKey, session)
+	p.entry(ghostTimeKey, ghostTime)
+	b.Write(p.Bytes())
+	b.Write(pingPayload)
+	return b.Bytes()
+}
+
+// pong is a decoded pong message ; times are zero if missing.
+type pong struct {
+	session       nodeID
+	ghostTime     int64
+	prevGhostTime int64
+	hostTime      int64
+}
+
+// decodeMeasurement returns the type and the payload of a measurement message.
+func decodeMeasurement(data []byte) (byte, []byte, error) {
+	if len(data) < 9 || !bytes.Equal(data[:8], measurementHeader) {
+		return 0, nil, errors.New("not a Link measurement message")
+	}
+	return data[8], data[9:], nil
+}
+
+// decodePong returns the times of the payload of a pong message.
+func decodePong(payload []byte) (pong, error) {
+	p := pong{}
+	entries, err := parsePayload(payload)
+	if err != nil {
+		return p, err
+	}
+	for key, value := range map[uint32]interface{}{
+		sessionKey:       &p.session,
+		ghostTimeKey:     &p.ghostTime,
+		prevGhostTimeKey: &p.prevGhostTime,
+		hostTimeKey:      &p.hostTime,
+	} {
+		if _, err := readEntry(entries, key, value); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
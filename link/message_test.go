@@ -0,0 +1,93 @@
+package link
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestEncodeDecodeState(t *testing.T) {
+	s := peerState{
+		id:       nodeID{'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h'},
+		session:  nodeID{'s', 'e', 's', 's', 'i', 'o', 'n', '1'},
+		timeline: timeline{microsPerBeat: 500000, beatOrigin: 8000000, timeOrigin: 123456789},
+		endpoint: &net.UDPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 50000},
+	}
+	data := encodeState(aliveMessage, s)
+	// header, type, ttl, group and id
+	if got, want := data[:20], []byte("_asdp_v\x01\x01\x05\x00\x00abcdefgh"); !bytes.Equal(got, want) {
+		t.Errorf("got [%q] want [%q]", got, want)
+	}
+	// the timeline is the first entry: key, size and three int64
+	if got, want := data[20:28], []byte("tmln\x00\x00\x00\x18"); !bytes.Equal(got, want) {
+		t.Errorf("got [%q] want [%q]", got, want)
+	}
+	messageType, back, err := decodeState(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := messageType, byte(aliveMessage); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := back.timeline, s.timeline; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := back.session, s.session; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := back.endpoint.String(), "192.168.1.2:50000"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestDecodeState_ByeBye(t *testing.T) {
+	s := peerState{id: nodeID{'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h'}}
+	messageType, back, err := decodeState(encodeState(byeByeMessage, s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := messageType, byte(byeByeMessage); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := back.id, s.id; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestDecodeState_Invalid(t *testing.T) {
+	for _, each := range [][]byte{
+		[]byte("_asdp_v\x01"),
+		[]byte("_link_v\x01\x01\x05\x00\x00abcdefgh"),
+		// missing timeline
+		[]byte("_asdp_v\x01\x01\x05\x00\x00abcdefgh"),
+		// entry larger than the message
+		[]byte("_asdp_v\x01\x01\x05\x00\x00abcdefghtmln\x00\x00\x00\x18\x00"),
+	} {
+		if _, _, err := decodeState(each); err == nil {
+			t.Errorf("error expected for %q", each)
+		}
+	}
+}
+
+func TestRespond(t *testing.T) {
+	session := nodeID{'s', 'e', 's', 's', 'i', 'o', 'n', '1'}
+	reply := respond(encodePing(1000, 2000), session, 3000)
+	messageType, payload, err := decodeMeasurement(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := messageType, byte(pongMessage); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	p, err := decodePong(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p, (pong{session: session, ghostTime: 3000, prevGhostTime: 2000, hostTime: 1000}); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// a pong is not answered
+	if got := respond(reply, session, 3000); got != nil {
+		t.Errorf("no reply expected, got %q", got)
+	}
+}
@@ -0,0 +1,98 @@
+package link
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// timeline maps the ghost time of a session to beats.
+type timeline struct {
+	microsPerBeat int64 // tempo
+	beatOrigin    int64 // micro beats at the time origin
+	timeOrigin    int64 // ghost time in microseconds
+}
+
+// the range of tempi accepted by Link
+const (
+	minBPM = 20.0
+	maxBPM = 999.0
+)
+
+// newTimeline returns a timeline that starts at beat zero at a ghost time.
+func newTimeline(bpm float64, ghostTime int64) timeline {
+	return timeline{microsPerBeat: microsPerBeat(bpm), timeOrigin: ghostTime}
+}
+
+// microsPerBeat returns the tempo in the resolution of Link.
+func microsPerBeat(bpm float64) int64 {
+	bpm = math.Max(minBPM, math.Min(maxBPM, bpm))
+	return int64(math.Round(60e6 / bpm))
+}
+
+// bpm returns the tempo rounded to hundredths, as shown by Link-enabled applications.
+func (t timeline) bpm() float64 {
+	return math.Round(6e9/float64(t.microsPerBeat)) / 100
+}
+
+// toBeats returns the beats at a ghost time.
+func (t timeline) toBeats(ghostTime int64) float64 {
+	return float64(t.beatOrigin)/1e6 + float64(ghostTime-t.timeOrigin)/float64(t.microsPerBeat)
+}
+
+// fromBeats returns the ghost time of a number of beats.
+func (t timeline) fromBeats(beats float64) int64 {
+	return t.timeOrigin + int64(math.Round((beats-float64(t.beatOrigin)/1e6)*float64(t.microsPerBeat)))
+}
+
+// withTempo returns a timeline that continues the beats from a ghost time with another tempo.
+// Its beat origin is later such that peers adopt it.
+func (t timeline) withTempo(bpm float64, ghostTime int64) timeline {
+	origin := int64(math.Round(t.toBeats(ghostTime) * 1e6))
+	if origin <= t.beatOrigin {
+		origin = t.beatOrigin + 1
+	}
+	return timeline{microsPerBeat: microsPerBeat(bpm), beatOrigin: origin, timeOrigin: t.fromBeats(float64(origin) / 1e6)}
+}
+
+// ghostXForm maps the time of this host to the ghost time of a session ; both in microseconds.
+type ghostXForm struct {
+	slope     float64
+	intercept int64
+}
+
+func (x ghostXForm) hostToGhost(hostTime int64) int64 {
+	return int64(math.Round(x.slope*float64(hostTime))) + x.intercept
+}
+
+func (x ghostXForm) ghostToHost(ghostTime int64) int64 {
+	return int64(math.Round(float64(ghostTime-x.intercept) / x.slope))
+}
+
+// hostClock is the monotonic time of this process in microseconds.
+type hostClock struct {
+	origin time.Time
+}
+
+func (c hostClock) micros(t time.Time) int64 {
+	return t.Sub(c.origin).Microseconds()
+}
+
+func (c hostClock) now() int64 {
+	return c.micros(time.Now())
+}
+
+func (c hostClock) time(micros int64) time.Time {
+	return c.origin.Add(time.Duration(micros) * time.Microsecond)
+}
+
+// median returns the middle value of measured offsets.
+func median(values []float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,278 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
+)
+
+// Server is a Language Server Protocol server for melrose scripts that talks JSON-RPC over a stream.
+// It provides completion, hover documentation, diagnostics and go-to-definition of variables.
+type Server struct {
+	context   core.Context
+	functions map[string]dsl.Function
+	documents map[string]string // uri -> text
+	out       io.Writer
+	mutex     *sync.Mutex // for writing
+}
+
+// NewServer returns a server that uses the context to check scripts ; scripts are never evaluated.
+func NewServer(ctx core.Context) *Server {
+	return &Server{
+		context:   ctx,
+		functions: dsl.EvalFunctions(ctx),
+		documents: map[string]string{},
+		mutex:     new(sync.Mutex),
+	}
+}
+
+// Run reads requests and notifications from r and writes responses to w until the client sends exit.
+func (s *Server) Run(r io.Reader, w io.Writer) error {
+	s.out = w
+	reader := bufio.NewReader(r)
+	for {
+		body, err := readMessage(reader)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.reply(nil, nil, &responseError{Code: parseError, Message: err.Error()})
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		s.handle(req)
+	}
+}
+
+// readMessage returns the content of a message with a Content-Length header.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %v", err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *Server) write(v interface{}) {
+	data, _ := json.Marshal(v)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(data), data)
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, err *responseError) {
+	s.write(response{JSONRPC: "2.0", ID: id, Result: result, Error: err})
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) handle(req request) {
+	switch req.Method {
+	case "initialize":
+		s.reply(req.ID, initializeResult{
+			Capabilities: capabilities{
+				TextDocumentSync:   1, // full
+				CompletionProvider: completionOptions{TriggerCharacters: []string{"."}},
+				HoverProvider:      true,
+				DefinitionProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "melrose", Version: core.BuildTag},
+		}, nil)
+	case "shutdown":
+		s.reply(req.ID, nil, nil)
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if s.decode(req, &p) {
+			s.update(p.TextDocument.URI, p.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var p didChangeParams
+		if s.decode(req, &p) && len(p.ContentChanges) > 0 {
+			// full sync ; the last change has the complete text
+			s.update(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+		}
+	case "textDocument/didClose":
+		var p didCloseParams
+		if s.decode(req, &p) {
+			delete(s.documents, p.TextDocument.URI)
+			s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []diagnostic{}})
+		}
+	case "textDocument/completion":
+		var p positionParams
+		if s.decode(req, &p) {
+			s.reply(req.ID, s.completion(p), nil)
+		}
+	case "textDocument/hover":
+		var p positionParams
+		if s.decode(req, &p) {
+			s.reply(req.ID, s.hover(p), nil)
+		}
+	case "textDocument/definition":
+		var p positionParams
+		if s.decode(req, &p) {
+			s.reply(req.ID, s.definition(p), nil)
+		}
+	default:
+		// notifications without handling, such as initialized, are ignored
+		if req.ID != nil {
+			s.reply(req.ID, nil, &responseError{Code: methodNotFound, Message: "method not found: " + req.Method})
+		}
+	}
+}
+
+// decode reads the params of a request ; it replies with an error if that fails.
+func (s *Server) decode(req request, v interface{}) bool {
+	if err := json.Unmarshal(req.Params, v); err != nil {
+		if req.ID != nil {
+			s.reply(req.ID, nil, &responseError{Code: invalidParams, Message: err.Error()})
+		}
+		return false
+	}
+	return true
+}
+
+// update stores the text of a document and publishes its problems.
+func (s *Server) update(uri, text string) {
+	s.documents[uri] = text
+	list := []diagnostic{}
+	for _, each := range dsl.NewEvaluator(s.context).CheckProgram(text) {
+		list = append(list, diagnostic{
+			Range: rangeOf(each.Line, each.Column, each.Column+1),
+			// error
+			Severity: 1,
+			Source:   "melrose",
+			Message:  each.Message,
+		})
+	}
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: list})
+}
+
+// completion returns all functions and the variables assigned in the document.
+func (s *Server) completion(p positionParams) []completionItem {
+	list := []completionItem{}
+	for k, f := range s.functions {
+		if len(f.Title) == 0 {
+			continue
+		}
+		doc := markup(f.Description)
+		list = append(list, completionItem{
+			Label:            k,
+			Kind:             functionKind,
+			Detail:           f.Title,
+			Documentation:    &doc,
+			InsertText:       f.Template,
+			InsertTextFormat: snippetFormat,
+		})
+	}
+	for name, line := range assignments(s.documents[p.TextDocument.URI]) {
+		list = append(list, completionItem{
+			Label:  name,
+			Kind:   variableKind,
+			Detail: strings.TrimSpace(line.text),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Label < list[j].Label })
+	return list
+}
+
+// hover returns the documentation of a function or the assignment of a variable under the cursor.
+func (s *Server) hover(p positionParams) *hover {
+	text := s.documents[p.TextDocument.URI]
+	word := wordAt(text, p.Position)
+	if len(word) == 0 {
+		return nil
+	}
+	if f, ok := s.functions[word]; ok {
+		doc := f.Markdown()
+		if len(f.Samples) > 0 {
+			doc += "\n\n```\n" + strings.TrimSpace(f.Samples) + "\n```"
+		}
+		return &hover{Contents: markup(doc)}
+	}
+	if a, ok := assignments(text)[word]; ok {
+		return &hover{Contents: markup("```\n" + strings.TrimSpace(a.text) + "\n```")}
+	}
+	return nil
+}
+
+// definition returns the location of the first assignment of the variable under the cursor.
+func (s *Server) definition(p positionParams) *location {
+	text := s.documents[p.TextDocument.URI]
+	word := wordAt(text, p.Position)
+	a, ok := assignments(text)[word]
+	if !ok {
+		return nil
+	}
+	return &location{URI: p.TextDocument.URI, Range: rangeOf(a.line, a.column, a.column+len(word))}
+}
+
+type assignment struct {
+	line, column int
+	text         string
+}
+
+// assignments returns the first assignment of each variable in a script.
+func assignments(text string) map[string]assignment {
+	found := map[string]assignment{}
+	for i, each := range strings.Split(text, "\n") {
+		if strings.HasPrefix(each, "\t") || strings.HasPrefix(each, "    ") {
+			continue
+		}
+		name, _, ok := dsl.IsAssignment(strings.TrimSuffix(each, "\r"))
+		if !ok {
+			continue
+		}
+		if _, seen := found[name]; !seen {
+			found[name] = assignment{line: i, column: strings.Index(each, name), text: each}
+		}
+	}
+	return found
+}
+
+// wordAt returns the identifier at a position in the text.
+func wordAt(text string, p position) string {
+	lines := strings.Split(text, "\n")
+	if p.Line < 0 || p.Line >= len(lines) {
+		return ""
+	}
+	line := lines[p.Line]
+	if p.Character > len(line) {
+		return ""
+	}
+	isWord := func(c byte) bool {
+		return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+	}
+	from, to := p.Character, p.Character
+	for from > 0 && isWord(line[from-1]) {
+		from--
+	}
+	for to < len(line) && isWord(line[to]) {
+		to++
+	}
+	return line[from:to]
+}
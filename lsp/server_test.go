@@ -0,0 +1,113 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
+)
+
+func testContext() core.Context {
+	ctx := new(core.PlayContext)
+	ctx.EnvironmentVars = new(sync.Map)
+	ctx.VariableStorage = dsl.NewVariableStore()
+	ctx.LoopControl = core.NewBeatmaster(ctx, 120)
+	return ctx
+}
+
+func frame(s string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(s), s)
+}
+
+// session runs the server with all messages and returns its output messages.
+func session(t *testing.T, messages ...string) (list []map[string]interface{}) {
+	in := new(bytes.Buffer)
+	for _, each := range messages {
+		in.WriteString(frame(each))
+	}
+	out := new(bytes.Buffer)
+	if err := NewServer(testContext()).Run(in, out); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(out)
+	for {
+		body, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		m := map[string]interface{}{}
+		if err := json.Unmarshal(body, &m); err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, m)
+	}
+	return
+}
+
+const didOpen = `{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///song.mel","text":"m = sequence('c e g')\nf = fraction(8, m)\nbogus(1"}}}`
+
+func TestServer_DiagnosticsAndDefinition(t *testing.T) {
+	list := session(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		didOpen,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/definition","params":{"textDocument":{"uri":"file:///song.mel"},"position":{"line":1,"character":17}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+	if got, want := len(list), 4; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	caps := list[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	if got, want := caps["hoverProvider"], true; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	diags := list[1]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if got, want := len(diags), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	start := diags[0].(map[string]interface{})["range"].(map[string]interface{})["start"].(map[string]interface{})
+	if got, want := start["line"], 2.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	def := list[2]["result"].(map[string]interface{})["range"].(map[string]interface{})["start"].(map[string]interface{})
+	if got, want := def["line"], 0.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestServer_CompletionAndHover(t *testing.T) {
+	list := session(t,
+		didOpen,
+		`{"jsonrpc":"2.0","id":1,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///song.mel"},"position":{"line":2,"character":0}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///song.mel"},"position":{"line":1,"character":6}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"unknown/method"}`,
+	)
+	if got, want := len(list), 4; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	labels := map[string]float64{}
+	for _, each := range list[1]["result"].([]interface{}) {
+		item := each.(map[string]interface{})
+		labels[item["label"].(string)] = item["kind"].(float64)
+	}
+	if got, want := labels["sequence"], float64(functionKind); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := labels["m"], float64(variableKind); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	value := list[2]["result"].(map[string]interface{})["contents"].(map[string]interface{})["value"].(string)
+	if !strings.Contains(value, "fraction(") {
+		t.Errorf("got [%v] want documentation of fraction", value)
+	}
+	if got, want := list[3]["error"].(map[string]interface{})["code"], float64(methodNotFound); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,145 @@
+package midi
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/emicklei/melrose/midi/transport"
+	"github.com/emicklei/melrose/notify"
+)
+
+// broadcastOut is a MIDIOut that duplicates all messages of a device to one or more other devices,
+// e.g. a main and a backup synthesizer in a live setup.
+// Errors of the copies are reported but do not fail the write to the main stream.
+// Each output device has one for its whole life such that the timeline and watchdog always write through the current copies.
+type broadcastOut struct {
+	id     int
+	main   transport.MIDIOut
+	mutex  *sync.RWMutex // guards copies
+	copies []broadcastCopy
+	remap  *channelRemap
+}
+
+func newBroadcastOut(id int, main transport.MIDIOut, remap *channelRemap) *broadcastOut {
+	return &broadcastOut{
+		id:    id,
+		main:  main,
+		mutex: new(sync.RWMutex),
+		remap: remap,
+	}
+}
+
+type broadcastCopy struct {
+	id  int
+	out transport.MIDIOut
+}
+
+// WriteShort is part of transport.MIDIOut
+func (b *broadcastOut) WriteShort(status int64, data1 int64, data2 int64) error {
+	err := b.main.WriteShort(status, data1, data2)
+	b.mutex.RLock()
+	copies := b.copies
+	b.mutex.RUnlock()
+	for _, each := range copies {
+		if cerr := each.out.WriteShort(b.remap.status(each.id, status), data1, data2); cerr != nil {
+			notify.Warnf("device.%d: broadcast to device %d failed, error:%v", b.id, each.id, cerr)
+		}
+	}
+	return err
+}
+
+// setCopies replaces the devices that receive a copy of each message ; none stops broadcasting.
+func (b *broadcastOut) setCopies(copies []broadcastCopy) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.copies = copies
+}
+
+// Close is part of transport.MIDIOut. The streams of the copies are owned by their own devices.
+func (b *broadcastOut) Close() error {
+	return b.main.Close()
+}
+
+// channelRemap holds per output device which channels are mapped to another channel.
+type channelRemap struct {
+	mutex    *sync.RWMutex
+	channels map[int]map[int]int // device id -> from -> to
+}
+
+func newChannelRemap() *channelRemap {
+	return &channelRemap{
+		mutex:    new(sync.RWMutex),
+		channels: map[int]map[int]int{},
+	}
+}
+
+func (c *channelRemap) set(id, from, to int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	m, ok := c.channels[id]
+	if !ok {
+		m = map[int]int{}
+		c.channels[id] = m
+	}
+	if from == to {
+		delete(m, from)
+		return
+	}
+	m[from] = to
+}
+
+// status returns the status with its channel remapped for a device ; system messages are not changed.
+func (c *channelRemap) status(id int, status int64) int64 {
+	if status >= 0xF0 {
+		return status
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	to, ok := c.channels[id][int(status&0x0F)+1]
+	if !ok {
+		return status
+	}
+	return (status & 0xF0) | int64(to-1)
+}
+
+// Broadcast makes all messages for a device also go to other devices.
+// Without other device IDs, broadcasting for that device is stopped.
+func (r *DeviceRegistry) Broadcast(id int, otherIDs ...int) error {
+	od, err := r.Output(id)
+	if err != nil {
+		return err
+	}
+	b, ok := od.stream.(*broadcastOut)
+	if !ok {
+		return fmt.Errorf("cannot broadcast device %d", id)
+	}
+	copies := []broadcastCopy{}
+	for _, each := range otherIDs {
+		if each == id {
+			return fmt.Errorf("cannot broadcast device %d to itself", id)
+		}
+		other, err := r.Output(each)
+		if err != nil {
+			return err
+		}
+		copies = append(copies, broadcastCopy{id: each, out: unwrapBroadcast(other.stream)})
+	}
+	b.setCopies(copies)
+	return nil
+}
+
+// RemapChannel changes the channel of messages broadcasted to a device.
+func (r *DeviceRegistry) RemapChannel(id, from, to int) error {
+	if from < 1 || from > 16 || to < 1 || to > 16 {
+		return fmt.Errorf("MIDI channel must be in [1..16]")
+	}
+	r.remap.set(id, from, to)
+	return nil
+}
+
+func unwrapBroadcast(out transport.MIDIOut) transport.MIDIOut {
+	if b, ok := out.(*broadcastOut); ok {
+		return b.main
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package midi
+
+import (
+	"testing"
+)
+
+type recordingOut struct {
+	statuses []int64
+}
+
+func (r *recordingOut) WriteShort(status int64, data1 int64, data2 int64) error {
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+func (r *recordingOut) Close() error { return nil }
+
+func TestBroadcastOut_RemapChannel(t *testing.T) {
+	main, backup := new(recordingOut), new(recordingOut)
+	remap := newChannelRemap()
+	remap.set(2, 1, 10)
+	b := newBroadcastOut(1, main, remap)
+	b.setCopies([]broadcastCopy{{id: 2, out: backup}})
+	b.WriteShort(noteOn, 60, 100)   // channel 1
+	b.WriteShort(noteOn|1, 60, 100) // channel 2
+	b.WriteShort(0xF8, 0, 0)        // timing clock
+	if got, want := main.statuses, []int64{0x90, 0x91, 0xF8}; !equalStatuses(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := backup.statuses, []int64{0x99, 0x91, 0xF8}; !equalStatuses(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestBroadcastOut_CopyFailureDoesNotFailMain(t *testing.T) {
+	main := new(recordingOut)
+	b := newBroadcastOut(1, main, newChannelRemap())
+	b.setCopies([]broadcastCopy{{id: 2, out: &failingOut{failures: 1}}})
+	if err := b.WriteShort(noteOn, 60, 100); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(main.statuses), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestDeviceRegistry_Broadcast(t *testing.T) {
+	tr := new(countingTransporter)
+	r := reopenTestRegistry(tr)
+	main, err := r.Output(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := main.stream
+	if _, err := r.Output(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Broadcast(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if main.stream != stream {
+		t.Error("stream of device must not be replaced")
+	}
+	main.stream.WriteShort(noteOn, 60, 100)
+	if err := r.Broadcast(1); err != nil {
+		t.Fatal(err)
+	}
+	main.stream.WriteShort(noteOn, 60, 100)
+	if got, want := tr.opened[0].writes, 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := tr.opened[1].writes, 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func equalStatuses(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
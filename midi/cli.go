@@ -3,7 +3,10 @@ package midi
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/emicklei/melrose/core"
 	"github.com/emicklei/melrose/notify"
 )
 
@@ -47,6 +50,71 @@ func (r *DeviceRegistry) HandleSetting(name string, values []interface{}) error
 		} else {
 			notify.Infof("echo output notes is disabled ; no output device")
 		}
+	case "watchdog", "watchdog.noteoff":
+		if len(values) != 1 {
+			return fmt.Errorf("one argument expected")
+		}
+		enable, ok := values[0].(bool)
+		if !ok {
+			return fmt.Errorf("boolean device argument expected, got %T", values[0])
+		}
+		od, err := r.Output(r.defaultOutputID)
+		if err != nil {
+			return fmt.Errorf("no output device: %v", err)
+		}
+		sendNoteOff := name == "watchdog.noteoff"
+		od.setWatchdog(enable, sendNoteOff)
+		notify.Infof("stuck note watchdog for device %d is enabled: %v, send note off: %v", od.id, enable, enable && sendNoteOff)
+	case "hotplug":
+		if len(values) != 1 {
+			return fmt.Errorf("one argument expected")
+		}
+		enable, ok := values[0].(bool)
+		if !ok {
+			return fmt.Errorf("boolean device argument expected, got %T", values[0])
+		}
+		if enable {
+			r.StartHotplugDetection()
+		} else {
+			r.StopHotplugDetection()
+		}
+		notify.Infof("reconnect replugged devices is enabled: %v", enable)
+	case "broadcast":
+		if len(values) == 0 {
+			return fmt.Errorf("at least one argument expected")
+		}
+		ids := []int{}
+		for _, each := range values {
+			id, ok := each.(int)
+			if !ok {
+				return fmt.Errorf("integer device argument expected, got %T", each)
+			}
+			ids = append(ids, id)
+		}
+		if err := r.Broadcast(ids[0], ids[1:]...); err != nil {
+			return fmt.Errorf("cannot broadcast: %v", err)
+		}
+		if len(ids) == 1 {
+			notify.Infof("broadcast for device %d is disabled", ids[0])
+		} else {
+			notify.Infof("broadcast messages for device %d to devices %v", ids[0], ids[1:])
+		}
+	case "broadcast.channel":
+		if len(values) != 3 {
+			return fmt.Errorf("three arguments expected")
+		}
+		ints := []int{}
+		for _, each := range values {
+			i, ok := each.(int)
+			if !ok {
+				return fmt.Errorf("integer argument expected, got %T", each)
+			}
+			ints = append(ints, i)
+		}
+		if err := r.RemapChannel(ints[0], ints[1], ints[2]); err != nil {
+			return err
+		}
+		notify.Infof("broadcast messages for channel %d to device %d on channel %d", ints[1], ints[0], ints[2])
 	case "midi.in":
 		if len(values) != 1 {
 			return fmt.Errorf("one argument expected")
@@ -61,6 +129,29 @@ func (r *DeviceRegistry) HandleSetting(name string, values []interface{}) error
 		}
 		r.defaultInputID = id
 		notify.Infof("Set default MIDI input device id: %d", id)
+	case "midi.in.velocity":
+		if len(values) < 2 {
+			return fmt.Errorf("device and curve arguments expected")
+		}
+		id, ok := values[0].(int)
+		if !ok {
+			return fmt.Errorf("integer device argument expected")
+		}
+		in, err := r.Input(id)
+		if err != nil {
+			return fmt.Errorf("bad input device number: %v", err)
+		}
+		if values[1] == "off" {
+			in.listener.SetVelocityMapping(nil)
+			notify.Infof("Set velocity curve of MIDI input device id: %d to off", id)
+			return nil
+		}
+		curve, err := newVelocityCurve(values[1:])
+		if err != nil {
+			return err
+		}
+		in.listener.SetVelocityMapping(curve.apply)
+		notify.Infof("Set velocity curve of MIDI input device id: %d to %s", id, curve)
 	case "midi.out.channel":
 		if len(values) != 2 {
 			return fmt.Errorf("two argument expected")
@@ -79,6 +170,111 @@ func (r *DeviceRegistry) HandleSetting(name string, values []interface{}) error
 		}
 		out.defaultChannel = ch
 		notify.Infof("Set default MIDI output device id: %d with default channel: %d", id, ch)
+	case "midi.out.latency":
+		if len(values) != 2 {
+			return fmt.Errorf("two argument expected")
+		}
+		id, ok := values[0].(int)
+		if !ok {
+			return fmt.Errorf("integer device argument expected")
+		}
+		ms, ok := values[1].(int)
+		if !ok {
+			return fmt.Errorf("integer milliseconds argument expected")
+		}
+		if ms < 0 {
+			return fmt.Errorf("latency must be zero or positive ; delay the devices with less latency instead")
+		}
+		out, err := r.Output(id)
+		if err != nil {
+			return fmt.Errorf("bad output device number: %v", err)
+		}
+		latency := time.Duration(ms) * time.Millisecond
+		out.setLatency(latency)
+		notify.Infof("Set latency of MIDI output device id: %d to %v", id, latency)
+	case "midi.out.noteoff":
+		if len(values) != 2 {
+			return fmt.Errorf("two argument expected")
+		}
+		id, ok := values[0].(int)
+		if !ok {
+			return fmt.Errorf("integer device argument expected")
+		}
+		policy, ok := values[1].(string)
+		if !ok {
+			return fmt.Errorf("string policy argument expected, %q or %q", retriggerPolicy, tiePolicy)
+		}
+		out, err := r.Output(id)
+		if err != nil {
+			return fmt.Errorf("bad output device number: %v", err)
+		}
+		if err := out.setNoteOffPolicy(policy); err != nil {
+			return err
+		}
+		notify.Infof("Set note off policy of MIDI output device id: %d to %s", id, policy)
+	case "midi.out.tuning":
+		if len(values) < 2 || len(values) > 3 {
+			return fmt.Errorf("device and scale file arguments expected, and an optional keyboard mapping file")
+		}
+		id, ok := values[0].(int)
+		if !ok {
+			return fmt.Errorf("integer device argument expected")
+		}
+		files := []string{}
+		for _, each := range values[1:] {
+			f, ok := each.(string)
+			if !ok {
+				return fmt.Errorf("string file argument expected, got %T", each)
+			}
+			files = append(files, f)
+		}
+		out, err := r.Output(id)
+		if err != nil {
+			return fmt.Errorf("bad output device number: %v", err)
+		}
+		if files[0] == "off" {
+			out.setTuning("", "")
+			notify.Infof("Set tuning of MIDI output device id: %d to 12-TET", id)
+			return nil
+		}
+		keyboard := ""
+		if len(files) == 2 {
+			keyboard = files[1]
+		}
+		if err := out.setTuning(files[0], keyboard); err != nil {
+			return err
+		}
+		out.mutex.RLock()
+		tun := out.tuning
+		out.mutex.RUnlock()
+		notify.Infof("Set tuning of MIDI output device id: %d to %s", id, tun)
+	case "midi.out.cc":
+		if len(values) != 4 {
+			return fmt.Errorf("device, channel, control number and value arguments expected")
+		}
+		ints := []int{}
+		for _, each := range values {
+			v, ok := each.(int)
+			if !ok {
+				return fmt.Errorf("integer argument expected, got %T", each)
+			}
+			ints = append(ints, v)
+		}
+		id, channel, number, value := ints[0], ints[1], ints[2], ints[3]
+		if id < 0 {
+			id = r.defaultOutputID
+		}
+		if channel < 1 || channel > 16 || number < 0 || number > 127 || value < 0 || value > 127 {
+			return fmt.Errorf("channel [1..16], control number [0..127] and value [0..127] expected, got %d,%d,%d", channel, number, value)
+		}
+		out, err := r.Output(id)
+		if err != nil {
+			return fmt.Errorf("bad output device number: %v", err)
+		}
+		if core.IsDebug() {
+			notify.Debugf("midi.cc device=%d channel=%d number=%d value=%d", id, channel, number, value)
+		}
+		return sendRaw(int(controlChange), channel, number, value, out.stream)
 	case "midi.out":
 		if len(values) != 1 {
 			return fmt.Errorf("one argument expected")
@@ -101,20 +297,28 @@ func (r *DeviceRegistry) HandleSetting(name string, values []interface{}) error
 
 // Command is part of melrose.AudioDevice
 func (r *DeviceRegistry) Command(args []string) notify.Message {
-	if len(args) == 2 && args[0] == "o" {
+	if len(args) >= 2 && (args[0] == "o" || args[0] == "out") {
 		id, err := strconv.Atoi(args[1])
 		if err != nil {
-			return notify.NewError(err)
+			// try name
+			id, err = r.OutputDeviceID(strings.Join(args[1:], " "))
+			if err != nil {
+				return notify.NewError(err)
+			}
 		}
 		if err := r.HandleSetting("midi.out", []interface{}{id}); err != nil {
 			return notify.NewError(err)
 		}
 		return nil
 	}
-	if len(args) == 2 && args[0] == "i" {
+	if len(args) >= 2 && (args[0] == "i" || args[0] == "in") {
 		id, err := strconv.Atoi(args[1])
 		if err != nil {
-			return notify.NewError(err)
+			// try name
+			id, err = r.InputDeviceID(strings.Join(args[1:], " "))
+			if err != nil {
+				return notify.NewError(err)
+			}
 		}
 		if err := r.HandleSetting("midi.in", []interface{}{id}); err != nil {
 			return notify.NewError(err)
@@ -149,6 +353,12 @@ func (r *DeviceRegistry) printInfo() {
 	if err == nil {
 		fmt.Printf("output device = %d, channel = %d\n", r.defaultOutputID, od.defaultChannel)
 		fmt.Printf("   echo notes = %v\n", od.echo)
+		od.mutex.RLock()
+		latency := od.latency
+		od.mutex.RUnlock()
+		if latency > 0 {
+			fmt.Printf("      latency = %v\n", latency)
+		}
 	} else {
 		fmt.Printf(" no output device (restart?)\n")
 	}
@@ -158,7 +368,15 @@ func (r *DeviceRegistry) printInfo() {
 	notify.PrintHighlighted("change:")
 	fmt.Println("set('midi.in',<device-id>)               --- change the default MIDI input device id (or e.g. \":m i 1\")")
 	fmt.Println("set('midi.out',<device-id>)              --- change the default MIDI output device id (or e.g. \":m o 1\")")
+	fmt.Println("set('midi.in',inputdevice('<name>'))     --- change the default MIDI input device by name (or e.g. \":m i arturia\")")
+	fmt.Println("set('midi.out',outputdevice('<name>'))   --- change the default MIDI output device by name (or e.g. \":m o fluid\")")
 	fmt.Println("set('midi.out.channel',<device-id>,<nr>) --- change the default MIDI channel for an output device id")
+	fmt.Println("set('midi.out.latency',<device-id>,<ms>) --- delay all events for an output device id to keep devices in sync")
 	fmt.Println("set('echo.toggle')                       --- toggle printing the notes (or \":m e\" )")
 	fmt.Println("set('echo',true)                         --- true = print the notes")
+	fmt.Println("set('broadcast',<device-id>,<other-id>...) --- also send all messages for a device to other devices")
+	fmt.Println("set('broadcast.channel',<other-id>,<from>,<to>) --- change the channel of messages broadcasted to a device")
+	fmt.Println("set('hotplug',true)                      --- true = reconnect devices that are unplugged and replugged")
+	fmt.Println("set('watchdog',true)                     --- true = warn about notes that are on longer than scheduled")
+	fmt.Println("set('watchdog.noteoff',true)             --- true = warn and send note off for notes that are stuck")
 }
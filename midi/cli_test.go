@@ -0,0 +1,22 @@
+package midi
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestHandleSetting_ControlChange(t *testing.T) {
+	out := new(recordingOut)
+	r := &DeviceRegistry{mutex: new(sync.RWMutex), out: map[int]*OutputDevice{}, defaultOutputID: 1}
+	r.out[1] = NewOutputDevice(1, out, 1, nil)
+	// -1 is the default output device
+	if err := r.HandleSetting("midi.out.cc", []interface{}{-1, 3, 7, 100}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.statuses, []int64{0xB2}; !equalStatuses(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if err := r.HandleSetting("midi.out.cc", []interface{}{1, 3, 7, 128}); err == nil {
+		t.Error("error expected")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/emicklei/melrose/core"
@@ -72,54 +73,22 @@ func createMidiTrack(t *core.Track, bpm float64, biab int) (*smf.Track, error) {
 
 	// All the notes
 	wholeNoteDuration := time.Duration(int(math.Round(4*60*1000/bpm))) * time.Millisecond // 4 = signature TODO create func
-	var moment time.Duration
 	var lastTicks uint32 = 0
-	for _, group := range buildSequenceFromTrack(t, biab).Notes {
-		if len(group) == 0 {
-			continue
-		}
-		channel := uint8(0x00)
-		actualDuration := time.Duration(float32(wholeNoteDuration) * group[0].DurationFactor())
-		if group[0].IsRest() {
-			//log.Println("rest", moment)
-			moment = moment + actualDuration
-			continue
+	events, moment := noteEventsOf(buildSequenceFromTrack(t, biab), wholeNoteDuration, quarterMS)
+	for _, each := range events {
+		var status uint8 = smf.NoteOffStatus
+		velocity := uint8(0x00) // zero velocity
+		if each.isOn {
+			status, velocity = smf.NoteOnStatus, each.velocity
 		}
-		absoluteTicks := ticksFromDuration(moment, quarterMS)
-		//log.Println("on", moment)
-		for i, each := range group {
-			var deltaTicks uint32 = 0
-			if i == 0 {
-				deltaTicks = absoluteTicks - lastTicks
-			}
-			noteOn, err := smf.NewMIDIEvent(deltaTicks, smf.NoteOnStatus, channel, uint8(each.MIDI()), uint8(each.Velocity))
-			if err != nil {
-				return nil, err
-			}
-			err = track.AddEvent(noteOn)
-			if err != nil {
-				return nil, err
-			}
+		event, err := smf.NewMIDIEvent(each.ticks-lastTicks, status, 0x00, each.number, velocity)
+		if err != nil {
+			return nil, err
 		}
-		lastTicks = absoluteTicks
-		moment = moment + actualDuration
-		//log.Println("off", moment)
-		absoluteTicks = ticksFromDuration(moment, quarterMS)
-		for i, each := range group {
-			var deltaTicks uint32 = 0
-			if i == 0 {
-				deltaTicks = absoluteTicks - lastTicks
-			}
-			noteOff, err := smf.NewMIDIEvent(deltaTicks, smf.NoteOffStatus, channel, uint8(each.MIDI()), 0x00) // zero velocity
-			if err != nil {
-				return nil, err
-			}
-			err = track.AddEvent(noteOff)
-			if err != nil {
-				return nil, err
-			}
+		if err := track.AddEvent(event); err != nil {
+			return nil, err
 		}
-		lastTicks = absoluteTicks
+		lastTicks = each.ticks
 	}
 
 	// Track end
@@ -135,6 +104,46 @@ func createMidiTrack(t *core.Track, bpm float64, biab int) (*smf.Track, error) {
 	return track, nil
 }
 
+type noteEvent struct {
+	ticks    uint32
+	isOn     bool
+	number   uint8
+	velocity uint8
+}
+
+// noteEventsOf returns the note on and off events sorted by time and the total duration.
+// Like the output devices, each note lasts its own duration and the next group starts when the shortest has ended.
+func noteEventsOf(seq core.Sequence, wholeNoteDuration time.Duration, quarterMS uint32) (events []noteEvent, moment time.Duration) {
+	for _, group := range seq.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		shortest := time.Duration(math.MaxInt64)
+		for _, each := range group {
+			actualDuration := time.Duration(float32(wholeNoteDuration) * each.DurationFactor())
+			if actualDuration < shortest {
+				shortest = actualDuration
+			}
+			if each.IsRest() || each.IsPedal() {
+				continue
+			}
+			nr, velocity := uint8(each.MIDI()), uint8(each.Velocity)
+			events = append(events,
+				noteEvent{ticks: ticksFromDuration(moment, quarterMS), isOn: true, number: nr, velocity: velocity},
+				noteEvent{ticks: ticksFromDuration(moment+actualDuration, quarterMS), number: nr})
+		}
+		moment += shortest
+	}
+	// at the same time, notes end before notes start
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].ticks != events[j].ticks {
+			return events[i].ticks < events[j].ticks
+		}
+		return !events[i].isOn && events[j].isOn
+	})
+	return
+}
+
 func exportMultiTrack(w io.Writer, m core.MultiTrack, bpm float64, biab int) error {
 	// Create division
 	// https://www.recordingblogs.com/wiki/time-division-of-a-midi-file
@@ -189,9 +198,14 @@ func quarterUSFromBPM(bpm float64) uint32 {
 
 func buildSequenceFromTrack(t *core.Track, biab int) core.Sequence {
 	target := []core.Sequenceable{}
-	for bar, seq := range t.Content {
-		each := core.RestSequence(bar-1, biab).SequenceJoin(seq.S())
+	for bar := range t.Content {
+		each := core.RestSequence(bar-1, biab).SequenceJoin(t.SequenceAt(bar, biab))
 		target = append(target, each)
 	}
-	return op.Merge{Target: target}.S()
+	merged := op.Merge{Target: target}.S()
+	if t.Tempo > 0 && t.Tempo != 1 {
+		// in bars of the control
+		return merged.Stretched(float32(1 / t.Tempo))
+	}
+	return merged
 }
@@ -49,3 +49,31 @@ func Test_Export(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func Test_noteEventsOf(t *testing.T) {
+	// a half note against two quarters, written as (=,2C,E) and E
+	long := core.MustParseNote("2c")
+	rest := core.MustParseNote("=")
+	short := core.MustParseNote("e")
+	seq := core.Sequence{Notes: [][]core.Note{{rest, long, short}, {short}}}
+	events, end := noteEventsOf(seq, 2*time.Second, quarterUSFromBPM(120))
+	if got, want := end, time.Second; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	want := []noteEvent{
+		{ticks: 0, isOn: true, number: 60, velocity: uint8(core.Normal)},
+		{ticks: 0, isOn: true, number: 64, velocity: uint8(core.Normal)},
+		{ticks: 960, number: 64},
+		{ticks: 960, isOn: true, number: 64, velocity: uint8(core.Normal)},
+		{ticks: 1920, number: 60},
+		{ticks: 1920, number: 64},
+	}
+	if got := events; len(got) != len(want) {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	for i, each := range want {
+		if got := events[i]; got != each {
+			t.Errorf("%d: got [%v:%T] want [%v:%T]", i, got, got, each, each)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package midi
+
+import (
+	"time"
+
+	"github.com/emicklei/melrose/notify"
+)
+
+// hotplugInterval is the time between two scans of available devices.
+const hotplugInterval = 2 * time.Second
+
+// plugState keeps the port names of open devices to detect unplugging and replugging.
+type plugState struct {
+	names     map[int]string // device ID -> port name
+	unplugged map[int]bool
+}
+
+func newPlugState() *plugState {
+	return &plugState{
+		names:     map[int]string{},
+		unplugged: map[int]bool{},
+	}
+}
+
+// changes compares the names of the open devices with the available port names.
+// It returns the IDs of devices that are gone and, for devices that are back, the port to reattach to.
+func (p *plugState) changes(open []int, available []string) (gone []int, back map[int]int) {
+	back = map[int]int{}
+	if len(available) == 0 {
+		// no information from the transport
+		return
+	}
+	for _, id := range open {
+		name, known := p.names[id]
+		if !known {
+			if id >= 0 && id < len(available) {
+				p.names[id] = available[id]
+			}
+			continue
+		}
+		port := indexOfName(available, name)
+		if port == -1 {
+			if !p.unplugged[id] {
+				p.unplugged[id] = true
+				gone = append(gone, id)
+			}
+			continue
+		}
+		if p.unplugged[id] {
+			delete(p.unplugged, id)
+			back[id] = port
+		}
+	}
+	return
+}
+
+func indexOfName(names []string, name string) int {
+	for i, each := range names {
+		if each == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// StartHotplugDetection starts scanning for devices that are unplugged and replugged.
+// Open devices that are replugged are re-opened and their listeners re-armed.
+func (r *DeviceRegistry) StartHotplugDetection() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.hotplugDone != nil {
+		return
+	}
+	r.hotplugDone = make(chan bool)
+	go func(done chan bool) {
+		ticker := time.NewTicker(hotplugInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				r.rescan()
+			}
+		}
+	}(r.hotplugDone)
+}
+
+// StopHotplugDetection stops scanning for devices.
+func (r *DeviceRegistry) StopHotplugDetection() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.hotplugDone != nil {
+		close(r.hotplugDone)
+		r.hotplugDone = nil
+	}
+}
+
+func (r *DeviceRegistry) rescan() {
+	r.mutex.RLock()
+	idle := len(r.out) == 0 && len(r.in) == 0
+	r.mutex.RUnlock()
+	// do not touch the MIDI driver as long as no device was opened
+	if idle {
+		return
+	}
+	// enumerating the ports can take a while, do not block the devices meanwhile
+	outNames := r.streamRegistry.transport.OutputDeviceNames()
+	inNames := r.streamRegistry.transport.InputDeviceNames()
+
+	r.mutex.Lock()
+	outIDs := []int{}
+	for id := range r.out {
+		outIDs = append(outIDs, id)
+	}
+	gone, outBack := r.outPlugs.changes(outIDs, outNames)
+	for _, id := range gone {
+		notify.Warnf("output device %d (%s) is disconnected", id, r.outPlugs.names[id])
+	}
+	outs := map[int]*OutputDevice{}
+	for id := range outBack {
+		outs[id] = r.out[id]
+	}
+	inIDs := []int{}
+	for id := range r.in {
+		inIDs = append(inIDs, id)
+	}
+	gone, inBack := r.inPlugs.changes(inIDs, inNames)
+	for _, id := range gone {
+		notify.Warnf("input device %d (%s) is disconnected", id, r.inPlugs.names[id])
+	}
+	ins := map[int]*InputDevice{}
+	for id := range inBack {
+		ins[id] = r.in[id]
+	}
+	r.mutex.Unlock()
+
+	for id, port := range outBack {
+		stream, err := r.streamRegistry.reopenOutput(id, port)
+		if err != nil {
+			notify.Warnf("failed to reconnect output device %d, error:%v", id, err)
+			continue
+		}
+		outs[id].replaceStream(stream)
+		notify.Infof("output device %d (%s) is reconnected", id, outNames[port])
+	}
+	for id, port := range inBack {
+		stream, err := r.streamRegistry.reopenInput(id, port)
+		if err != nil {
+			notify.Warnf("failed to reconnect input device %d, error:%v", id, err)
+			continue
+		}
+		if err := ins[id].listener.Rearm(stream); err != nil {
+			notify.Warnf("failed to reconnect input device %d, error:%v", id, err)
+			continue
+		}
+		notify.Infof("input device %d (%s) is reconnected", id, inNames[port])
+	}
+}
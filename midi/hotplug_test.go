@@ -0,0 +1,36 @@
+package midi
+
+import "testing"
+
+func TestPlugState_Changes(t *testing.T) {
+	p := newPlugState()
+	// first scan records the names
+	gone, back := p.changes([]int{1}, []string{"Through", "Arturia"})
+	if len(gone) != 0 || len(back) != 0 {
+		t.Fatalf("no changes expected, got %v %v", gone, back)
+	}
+	// unplugged
+	gone, _ = p.changes([]int{1}, []string{"Through"})
+	if got, want := len(gone), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// still unplugged, reported once
+	gone, _ = p.changes([]int{1}, []string{"Through"})
+	if got, want := len(gone), 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// replugged on another port
+	_, back = p.changes([]int{1}, []string{"Through", "Fluid", "Arturia"})
+	if got, want := back[1], 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestPlugState_NoInformation(t *testing.T) {
+	p := newPlugState()
+	p.names[0] = "Arturia"
+	gone, back := p.changes([]int{0}, []string{})
+	if len(gone) != 0 || len(back) != 0 {
+		t.Fatalf("no changes expected, got %v %v", gone, back)
+	}
+}
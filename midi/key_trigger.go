@@ -8,6 +8,8 @@ import (
 	"github.com/emicklei/melrose/notify"
 )
 
+// KeyTrigger plays, stops or evaluates a function when a key (note) is pressed on an input device.
+// A channel of zero matches any channel.
 type KeyTrigger struct {
 	mutex   *sync.RWMutex
 	playing bool
@@ -31,13 +33,11 @@ func (t *KeyTrigger) NoteOn(channel int, n core.Note) {
 	if core.IsDebug() {
 		notify.Debugf("keytrigger.NoteOn ch=%d note=%v", channel, n)
 	}
-	if channel != t.channel {
+	if t.channel != 0 && channel != t.channel {
 		return
 	}
-	if n.Name != t.note.Name {
-		return
-	}
-	if n.Octave != t.note.Octave {
+	// compare numbers such that sharps and flats match too
+	if n.MIDI() != t.note.MIDI() {
 		return
 	}
 	val := t.fun.Value()
@@ -67,7 +67,9 @@ func (t *KeyTrigger) NoteOn(channel int, n core.Note) {
 	}
 	// not playable, maybe evaluatable
 	if eval, ok := val.(core.Evaluatable); ok {
-		eval.Evaluate(t.ctx)
+		if err := eval.Evaluate(t.ctx); err != nil {
+			notify.Warnf("%s -> %s failed: %v", t.note.String(), core.Storex(t.fun), err)
+		}
 	}
 }
 
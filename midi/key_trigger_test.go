@@ -0,0 +1,34 @@
+package midi
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+type countingEvaluatable struct {
+	count *int
+}
+
+func (c countingEvaluatable) Evaluate(ctx core.Context) error {
+	*c.count++
+	return nil
+}
+
+func TestKeyTrigger_AnyChannelAndSharps(t *testing.T) {
+	count := 0
+	sharp, _ := core.MIDItoNote(0.25, 61, core.Normal)
+	natural, _ := core.MIDItoNote(0.25, 60, core.Normal)
+	trigger := NewKeyTrigger(core.PlayContext{}, 0, sharp, core.On(countingEvaluatable{count: &count}))
+	trigger.NoteOn(10, natural)
+	trigger.NoteOn(3, sharp)
+	trigger.NoteOn(10, sharp)
+	if got, want := count, 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	channel1 := NewKeyTrigger(core.PlayContext{}, 1, sharp, core.On(countingEvaluatable{count: &count}))
+	channel1.NoteOn(2, sharp)
+	if got, want := count, 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
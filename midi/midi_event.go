@@ -16,9 +16,15 @@ type midiEvent struct {
 	onoff      int64
 	channel    int
 	velocity   int64
+	cents      float64 // deviation from the pitch, played using pitch bend
 	device     int
 	out        transport.MIDIOut
 	mustHandle core.Condition
+	watchdog   *noteWatchdog // can be nil
+	ties       *noteTies     // nil if notes are retriggered
+	tuning     *tuning       // nil if 12-TET
+	duration   time.Duration // expected time between on and off
+	group      *noteGroup    // has which if taken from the pool ; can be nil
 }
 
 func (m midiEvent) NoteChangesDo(block func(core.NoteChange)) {
@@ -28,6 +34,9 @@ func (m midiEvent) NoteChangesDo(block func(core.NoteChange)) {
 }
 
 func (m midiEvent) Handle(tim *core.Timeline, when time.Time) {
+	if m.group != nil {
+		defer m.group.release()
+	}
 	// TODO not sure if the noteOn check is correct
 	if m.mustHandle != nil && m.onoff == noteOn && !m.mustHandle() {
 		return
@@ -37,9 +46,58 @@ func (m midiEvent) Handle(tim *core.Timeline, when time.Time) {
 	}
 	status := m.onoff | int64(m.channel-1)
 	for _, each := range m.which {
+		bend := int64(-1)
+		if m.tuning != nil {
+			if key, b, ok := m.tuning.realize(each); ok {
+				each, bend = key, b
+			}
+		}
+		if m.cents != 0 {
+			bend = bentBy(bend, m.cents)
+		}
+		if m.ties != nil {
+			if m.onoff == noteOff {
+				single := m
+				single.which = []int64{each}
+				single.group = nil
+				single.ties = nil
+				// already realized
+				single.tuning = nil
+				tim.Schedule(heldNoteOff{event: single, ties: m.ties, generation: m.ties.hold(m.channel, each)}, when.Add(tieWindow))
+				continue
+			}
+			if m.ties.tie(m.channel, each) {
+				// keeps sounding
+				if m.watchdog != nil {
+					m.watchdog.noteOn(m.channel, each, m.duration, when)
+				}
+				continue
+			}
+		}
+		if bend != -1 && m.onoff == noteOn {
+			if err := m.out.WriteShort(pitchBend|int64(m.channel-1), bend&0x7F, bend>>7); err != nil {
+				notify.Errorf("failed to write MIDI data, error:%v", err)
+			}
+		}
 		if err := m.out.WriteShort(status, each, m.velocity); err != nil {
 			notify.Errorf("failed to write MIDI data, error:%v", err)
 		}
+		if m.cents != 0 && m.tuning == nil && m.onoff == noteOff {
+			// other notes on the channel are not bent
+			if err := m.out.WriteShort(pitchBend|int64(m.channel-1), bendCenter&0x7F, bendCenter>>7); err != nil {
+				notify.Errorf("failed to write MIDI data, error:%v", err)
+			}
+		}
+		if notify.HasSubscribers() {
+			notify.Publish(notify.NoteEventKind, notify.NoteData{Device: m.device, Channel: m.channel, Number: each, Velocity: m.velocity, On: m.onoff == noteOn})
+		}
+		if m.watchdog != nil {
+			if m.onoff == noteOn {
+				m.watchdog.noteOn(m.channel, each, m.duration, when)
+			} else {
+				m.watchdog.noteOff(m.channel, each)
+			}
+		}
 	}
 	if core.IsDebug() {
 		m.log(status, when)
@@ -14,6 +14,8 @@ const (
 	noteOff       int64 = 0x80 // 10000000 , 128
 	controlChange int64 = 0xB0 // 10110000 , 176
 	noteAllOff    int64 = 0x78 // 01111000 , 120  (not 123 because sustain)
+	allNotesOff   int64 = 0x7B // 01111011 , 123
+	pitchBend     int64 = 0xE0 // 11100000 , 224
 	sustainPedal  int64 = 0x40
 	anyChannel    int   = -1
 )
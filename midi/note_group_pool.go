@@ -0,0 +1,40 @@
+package midi
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// noteGroupPool reuses the MIDI numbers of the notes that the planner schedules.
+// A loop that plays for days plans millions of notes ; reusing their groups keeps the garbage collector quiet.
+var noteGroupPool = sync.Pool{
+	New: func() interface{} { return &noteGroup{numbers: make([]int64, 0, 4)} },
+}
+
+// noteGroup has the MIDI numbers that a note on event and its note off event play.
+// The event of the pair that is handled last returns the group to the pool.
+// If the timeline is reset before then, the group is left to the garbage collector.
+type noteGroup struct {
+	numbers []int64
+	handled int32 // number of events of the pair that were handled
+}
+
+func newNoteGroup() *noteGroup {
+	g := noteGroupPool.Get().(*noteGroup)
+	g.numbers = g.numbers[:0]
+	g.handled = 0
+	return g
+}
+
+// add appends a MIDI number and returns the numbers.
+func (g *noteGroup) add(nr int64) []int64 {
+	g.numbers = append(g.numbers, nr)
+	return g.numbers
+}
+
+// release is called by each event of the pair after it was handled.
+func (g *noteGroup) release() {
+	if atomic.AddInt32(&g.handled, 1) == 2 {
+		noteGroupPool.Put(g)
+	}
+}
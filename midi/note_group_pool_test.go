@@ -0,0 +1,34 @@
+package midi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestNoteGroup_ReleasedByLastEvent(t *testing.T) {
+	out := new(recordingOut)
+	d := NewOutputDevice(0, out, 1, core.NewTimeline())
+	d.Play(core.NoCondition, core.MustParseSequence("(c e g)"), 120, time.Now().Add(time.Second))
+	events := []midiEvent{}
+	d.timeline.EventsDo(func(event core.TimelineEvent, at time.Time) {
+		events = append(events, event.(midiEvent))
+	})
+	if got, want := len(events), 2; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	group := events[0].group
+	if group == nil || group != events[1].group {
+		t.Fatal("on and off must share a pooled group")
+	}
+	// in any order
+	events[1].Handle(d.timeline, time.Now())
+	if got, want := group.handled, int32(1); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	events[0].Handle(d.timeline, time.Now())
+	if got, want := group.handled, int32(2); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
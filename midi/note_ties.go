@@ -0,0 +1,104 @@
+package midi
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// Policies for a note that is played again when the same note ends, e.g. at the restart of a loop.
+const (
+	retriggerPolicy = "retrigger" // send note off and note on ; the sound starts again (piano)
+	tiePolicy       = "tie"       // skip both ; the note keeps sounding (pad)
+)
+
+// tieWindow is how long a note off is held back to find out whether the same note is played again.
+const tieWindow = 20 * time.Millisecond
+
+// noteTies keeps a note sounding if the same note on the same channel is played again within the tie window after its note off.
+type noteTies struct {
+	mutex      *sync.Mutex
+	held       map[watchKey]int64 // generation of the held back note off
+	generation int64
+}
+
+func newNoteTies() *noteTies {
+	return &noteTies{
+		mutex: new(sync.Mutex),
+		held:  map[watchKey]int64{},
+	}
+}
+
+// hold registers a held back note off and returns its generation.
+func (n *noteTies) hold(channel int, nr int64) int64 {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.generation++
+	n.held[watchKey{channel: channel, number: nr}] = n.generation
+	return n.generation
+}
+
+// tie returns whether the note is still sounding ; its held back note off is cancelled.
+func (n *noteTies) tie(channel int, nr int64) bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	key := watchKey{channel: channel, number: nr}
+	if _, ok := n.held[key]; !ok {
+		return false
+	}
+	delete(n.held, key)
+	return true
+}
+
+// release returns whether the held back note off of a generation must be sent.
+func (n *noteTies) release(channel int, nr int64, generation int64) bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	key := watchKey{channel: channel, number: nr}
+	if n.held[key] != generation {
+		return false
+	}
+	delete(n.held, key)
+	return true
+}
+
+func (n *noteTies) reset() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.held = map[watchKey]int64{}
+}
+
+// heldNoteOff is the note off of a single note that is sent after the tie window unless the note was played again.
+type heldNoteOff struct {
+	event      midiEvent // without ties
+	ties       *noteTies
+	generation int64
+}
+
+func (h heldNoteOff) NoteChangesDo(block func(core.NoteChange)) {}
+
+func (h heldNoteOff) Handle(tim *core.Timeline, when time.Time) {
+	if !h.ties.release(h.event.channel, h.event.which[0], h.generation) {
+		return
+	}
+	h.event.Handle(tim, when)
+}
+
+// setNoteOffPolicy changes what happens when a note is played again right after it ended.
+func (d *OutputDevice) setNoteOffPolicy(policy string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	switch policy {
+	case retriggerPolicy:
+		d.ties = nil
+	case tiePolicy:
+		if d.ties == nil {
+			d.ties = newNoteTies()
+		}
+	default:
+		return fmt.Errorf("unknown note off policy %q, expected %q or %q", policy, retriggerPolicy, tiePolicy)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package midi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// handleHeld handles all scheduled held back note offs.
+func handleHeld(tim *core.Timeline) {
+	tim.EventsDo(func(event core.TimelineEvent, at time.Time) {
+		if held, ok := event.(heldNoteOff); ok {
+			held.Handle(tim, at)
+		}
+	})
+}
+
+func TestNoteTies_Retrigger(t *testing.T) {
+	out := new(recordingOut)
+	d := NewOutputDevice(0, out, 1, core.NewTimeline())
+	on := midiEvent{which: []int64{60}, onoff: noteOn, channel: 1, velocity: 70, out: out, ties: d.ties}
+	now := time.Now()
+	on.Handle(d.timeline, now)
+	on.asNoteoff().Handle(d.timeline, now)
+	on.Handle(d.timeline, now)
+	if got, want := out.statuses, []int64{noteOn, noteOff, noteOn}; !equalStatuses(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestNoteTies_Tie(t *testing.T) {
+	out := new(recordingOut)
+	d := NewOutputDevice(0, out, 1, core.NewTimeline())
+	if err := d.setNoteOffPolicy(tiePolicy); err != nil {
+		t.Fatal(err)
+	}
+	on := midiEvent{which: []int64{60, 64}, onoff: noteOn, channel: 1, velocity: 70, out: out, ties: d.ties}
+	now := time.Now()
+	on.Handle(d.timeline, now)
+	on.asNoteoff().Handle(d.timeline, now)
+	// only 60 is played again
+	again := on
+	again.which = []int64{60}
+	again.Handle(d.timeline, now)
+	handleHeld(d.timeline)
+	// 60 and 64 on, 64 off
+	if got, want := out.statuses, []int64{noteOn, noteOn, noteOff}; !equalStatuses(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	again.asNoteoff().Handle(d.timeline, now)
+	handleHeld(d.timeline)
+	if got, want := out.statuses, []int64{noteOn, noteOn, noteOff, noteOff}; !equalStatuses(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestOutputDevice_setNoteOffPolicy(t *testing.T) {
+	d := NewOutputDevice(0, new(recordingOut), 1, core.NewTimeline())
+	if err := d.setNoteOffPolicy("legato"); err == nil {
+		t.Error("error expected")
+	}
+	d.setNoteOffPolicy(tiePolicy)
+	if d.ties == nil {
+		t.Error("ties expected")
+	}
+	d.setNoteOffPolicy(retriggerPolicy)
+	if d.ties != nil {
+		t.Error("no ties expected")
+	}
+}
+
+func TestOutputDevice_setNoteOffPolicyWhilePlaying(t *testing.T) {
+	d := NewOutputDevice(0, new(recordingOut), 1, core.NewTimeline())
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			if i%2 == 0 {
+				d.setNoteOffPolicy(tiePolicy)
+			} else {
+				d.setNoteOffPolicy(retriggerPolicy)
+			}
+		}
+		done <- true
+	}()
+	for i := 0; i < 100; i++ {
+		d.Play(core.NoCondition, core.MustParseSequence("c e"), 120, time.Now().Add(time.Hour))
+	}
+	<-done
+}
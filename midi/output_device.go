@@ -1,6 +1,7 @@
 package midi
 
 import (
+	"sync"
 	"time"
 
 	"github.com/emicklei/melrose/core"
@@ -15,6 +16,11 @@ type OutputDevice struct {
 
 	echo     bool
 	timeline *core.Timeline
+	mutex    *sync.RWMutex // guards the settings below that are changed while playing
+	watchdog *noteWatchdog // nil if not enabled
+	latency  time.Duration // delay of all scheduled events to compensate for faster devices
+	ties     *noteTies     // nil if notes are retriggered
+	tuning   *tuning       // nil if 12-TET
 }
 
 func NewOutputDevice(id int, out transport.MIDIOut, ch int, line *core.Timeline) *OutputDevice {
@@ -24,6 +30,7 @@ func NewOutputDevice(id int, out transport.MIDIOut, ch int, line *core.Timeline)
 		defaultChannel: ch,
 		echo:           false,
 		timeline:       line,
+		mutex:          new(sync.RWMutex),
 	}
 }
 
@@ -33,6 +40,14 @@ func (d *OutputDevice) Start() {
 
 func (d *OutputDevice) Reset() {
 	d.timeline.Reset()
+	d.mutex.RLock()
+	if d.watchdog != nil {
+		d.watchdog.reset()
+	}
+	if d.ties != nil {
+		d.ties.reset()
+	}
+	d.mutex.RUnlock()
 	if core.IsDebug() {
 		notify.Debugf("device.%d: sending Note OFF to all 16 channels", d.id)
 	}
@@ -40,12 +55,60 @@ func (d *OutputDevice) Reset() {
 		// send note off all to all channels for current device
 		for c := 1; c <= 16; c++ {
 			if err := d.stream.WriteShort(controlChange|int64(c-1), noteAllOff, 0); err != nil {
-				notify.Console.Errorf("device.%d: portmidi write error:%v", d.id, err)
+				notify.Console.Errorf("device.%d: MIDI write error:%v", d.id, err)
+			}
+		}
+	}
+}
+
+// Panic clears the timeline and sends All Notes Off and All Sound Off to all 16 channels.
+func (d *OutputDevice) Panic() {
+	d.timeline.Reset()
+	d.mutex.RLock()
+	if d.watchdog != nil {
+		d.watchdog.reset()
+	}
+	if d.ties != nil {
+		d.ties.reset()
+	}
+	d.mutex.RUnlock()
+	if d.stream == nil {
+		return
+	}
+	for c := 1; c <= 16; c++ {
+		for _, each := range []int64{allNotesOff, noteAllOff} {
+			if err := d.stream.WriteShort(controlChange|int64(c-1), each, 0); err != nil {
+				notify.Console.Errorf("device.%d: MIDI write error:%v", d.id, err)
 			}
 		}
 	}
 }
 
+// replaceStream changes the stream of the transport, keeping the re-opening and broadcast wrappers.
+func (d *OutputDevice) replaceStream(stream transport.MIDIOut) {
+	if ro, ok := unwrapBroadcast(d.stream).(*reopeningOut); ok {
+		ro.replace(stream)
+		return
+	}
+	d.stream = stream
+}
+
+// setWatchdog starts or stops the detection of stuck notes.
+func (d *OutputDevice) setWatchdog(enabled bool, sendNoteOff bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.watchdog != nil {
+		d.watchdog.stop()
+		d.watchdog = nil
+	}
+	if !enabled {
+		return
+	}
+	d.watchdog = newNoteWatchdog(d.id, d.stream)
+	d.watchdog.sendNoteOff = sendNoteOff
+	d.watchdog.start()
+}
+
 func (d *OutputDevice) handledPedalChange(condition core.Condition, channel int, timeline *core.Timeline, moment time.Time, group []core.Note) bool {
 	if len(group) == 0 || len(group) > 1 {
 		return false
@@ -82,6 +145,13 @@ func (d *OutputDevice) handledPedalChange(condition core.Condition, channel int,
 	return false
 }
 
+// setLatency changes the delay of all scheduled events.
+func (d *OutputDevice) setLatency(latency time.Duration) {
+	d.mutex.Lock()
+	d.latency = latency
+	d.mutex.Unlock()
+}
+
 func (d *OutputDevice) Play(condition core.Condition, seq core.Sequenceable, bpm float64, beginAt time.Time) time.Time {
 	// which channel?
 	channel := d.defaultChannel
@@ -89,10 +159,13 @@ func (d *OutputDevice) Play(condition core.Condition, seq core.Sequenceable, bpm
 		channel = sel.Channel()
 		seq = sel.Unwrap()
 	}
+	// settings can be changed while playing
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
 
 	// schedule all notes of the sequenceable
 	wholeNoteDuration := core.WholeNoteDuration(bpm)
-	moment := beginAt
+	moment := beginAt.Add(d.latency)
 	for _, eachGroup := range seq.S().Notes {
 		if len(eachGroup) == 0 {
 			continue
@@ -127,7 +200,8 @@ func (d *OutputDevice) Play(condition core.Condition, seq core.Sequenceable, bpm
 		}
 		moment = earliest
 	}
-	return moment
+	// the end time is not delayed for the caller
+	return moment.Add(-d.latency)
 }
 
 // returns the longest TODO in core?
@@ -154,12 +228,15 @@ func scheduleOneNote(device *OutputDevice, condition core.Condition, channel int
 	}
 	// midi variable length note?
 	if fixed, ok := note.NonFractionBasedDuration(); ok {
+		group := newNoteGroup()
 		event := midiEvent{
-			which:      []int64{int64(note.MIDI())},
+			which:      group.add(int64(note.MIDI())),
+			group:      group,
 			onoff:      noteOn,
 			device:     device.id,
 			channel:    channel,
 			velocity:   int64(note.Velocity),
+			cents:      note.Cents(),
 			out:        device.stream,
 			mustHandle: condition,
 		}
@@ -169,12 +246,15 @@ func scheduleOneNote(device *OutputDevice, condition core.Condition, channel int
 		return scheduleOnOffEvents(device, event, fixed, moment)
 	}
 	// normal note
+	group := newNoteGroup()
 	event := midiEvent{
-		which:      []int64{int64(note.MIDI())},
+		which:      group.add(int64(note.MIDI())),
+		group:      group,
 		onoff:      noteOn,
 		device:     device.id,
 		channel:    channel,
 		velocity:   int64(note.Velocity),
+		cents:      note.Cents(),
 		out:        device.stream,
 		mustHandle: condition,
 	}
@@ -187,6 +267,10 @@ func scheduleOneNote(device *OutputDevice, condition core.Condition, channel int
 }
 
 func scheduleOnOffEvents(device *OutputDevice, event midiEvent, duration time.Duration, at time.Time) time.Time {
+	event.watchdog = device.watchdog
+	event.ties = device.ties
+	event.tuning = device.tuning
+	event.duration = duration
 	device.timeline.Schedule(event, at)
 	moment := at.Add(duration)
 	device.timeline.Schedule(event.asNoteoff(), moment)
@@ -198,6 +282,12 @@ func canCombineEvent(notes []core.Note) bool {
 		return true
 	}
 	dur, vel := notes[0].DurationFactor(), notes[0].Velocity
+	for n := 0; n < len(notes); n++ {
+		// each needs its own pitch bend
+		if notes[n].Cents() != 0 {
+			return false
+		}
+	}
 	for n := 1; n < len(notes); n++ {
 		d, v := notes[n].DurationFactor(), notes[n].Velocity
 		if d != dur || v != vel {
@@ -217,12 +307,13 @@ func combinedMidiEvent(deviceID int, channel int, notes []core.Note, stream tran
 	if velocity < 1 {
 		velocity = core.Normal
 	}
-	nrs := []int64{}
+	group := newNoteGroup()
 	for _, each := range notes {
-		nrs = append(nrs, int64(each.MIDI()))
+		group.add(int64(each.MIDI()))
 	}
 	return midiEvent{
-		which:    nrs,
+		which:    group.numbers,
+		group:    group,
 		onoff:    noteOn,
 		device:   deviceID,
 		channel:  channel,
@@ -0,0 +1,70 @@
+package midi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestOutputDevice_Panic(t *testing.T) {
+	out := new(failingOut)
+	d := NewOutputDevice(0, out, 1, core.NewTimeline())
+	d.Panic()
+	if got, want := out.writes, 32; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestOutputDevice_Latency(t *testing.T) {
+	tim := core.NewTimeline()
+	d := NewOutputDevice(0, new(failingOut), 1, tim)
+	d.setLatency(20 * time.Millisecond)
+	begin := time.Now().Add(time.Second)
+	end := d.Play(core.NoCondition, core.MustParseSequence("c"), 120, begin)
+	if got, want := end, begin.Add(500*time.Millisecond); !got.Equal(want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	var first time.Time
+	tim.EventsDo(func(event core.TimelineEvent, when time.Time) {
+		if first.IsZero() {
+			first = when
+		}
+	})
+	if got, want := first, begin.Add(20*time.Millisecond); !got.Equal(want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestOutputDevice_SetWatchdogWhilePlaying(t *testing.T) {
+	tim := core.NewTimeline()
+	d := NewOutputDevice(0, new(failingOut), 1, tim)
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			d.setWatchdog(i%2 == 0, false)
+		}
+		done <- true
+	}()
+	for i := 0; i < 100; i++ {
+		d.Play(core.NoCondition, core.MustParseSequence("c e"), 120, time.Now().Add(time.Hour))
+	}
+	<-done
+	d.setWatchdog(false, false)
+}
+
+func TestOutputDevice_SetLatencyWhilePlaying(t *testing.T) {
+	tim := core.NewTimeline()
+	d := NewOutputDevice(0, new(failingOut), 1, tim)
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			d.setLatency(time.Duration(i) * time.Millisecond)
+		}
+		done <- true
+	}()
+	for i := 0; i < 100; i++ {
+		d.Play(core.NoCondition, core.MustParseSequence("c e"), 120, time.Now().Add(time.Hour))
+	}
+	<-done
+}
@@ -18,6 +18,11 @@ type DeviceRegistry struct {
 	defaultInputID  int
 	defaultOutputID int
 	streamRegistry  *streamRegistry
+	outPlugs        *plugState
+	inPlugs         *plugState
+	hotplugDone     chan bool
+	remap           *channelRemap
+	inRetry         *backoff
 }
 
 func NewDeviceRegistry() (*DeviceRegistry, error) {
@@ -26,6 +31,10 @@ func NewDeviceRegistry() (*DeviceRegistry, error) {
 		in:              map[int]*InputDevice{},
 		out:             map[int]*OutputDevice{},
 		streamRegistry:  newStreamRegistry(),
+		outPlugs:        newPlugState(),
+		inPlugs:         newPlugState(),
+		remap:           newChannelRemap(),
+		inRetry:         newBackoff(),
 		defaultInputID:  -1,
 		defaultOutputID: -1,
 	}
@@ -43,14 +52,53 @@ func (r *DeviceRegistry) DefaultDeviceIDs() (inputDeviceID, outputDeviceID int)
 }
 
 func (r *DeviceRegistry) Reset() {
-	for _, each := range r.out {
+	outs, ins := r.devices()
+	for _, each := range outs {
 		each.Reset()
 	}
-	for _, each := range r.in {
+	for _, each := range ins {
 		each.stopListener()
 	}
 }
 
+// devices returns a copy of the open devices such that they can be used without holding the lock ;
+// writing to an output may need to reopen it which requires the write lock.
+func (r *DeviceRegistry) devices() (outs map[int]*OutputDevice, ins map[int]*InputDevice) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	outs = make(map[int]*OutputDevice, len(r.out))
+	for id, each := range r.out {
+		outs[id] = each
+	}
+	ins = make(map[int]*InputDevice, len(r.in))
+	for id, each := range r.in {
+		ins[id] = each
+	}
+	return
+}
+
+// Panic silences all open output devices.
+func (r *DeviceRegistry) Panic() {
+	outs, _ := r.devices()
+	for id, each := range outs {
+		if core.IsDebug() {
+			notify.Debugf("device.%d: sending All Notes Off and All Sound Off to all 16 channels", id)
+		}
+		each.Panic()
+	}
+}
+
+// Timing returns the timing statistics of the last minute for each open output device.
+func (r *DeviceRegistry) Timing() map[int]core.TimingStats {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	all := map[int]core.TimingStats{}
+	for id, each := range r.out {
+		all[id] = each.timeline.Timing()
+	}
+	return all
+}
+
 func (r *DeviceRegistry) Output(id int) (*OutputDevice, error) {
 	if id == -1 {
 		return nil, errors.New("no output available")
@@ -68,7 +116,7 @@ func (r *DeviceRegistry) Output(id int) (*OutputDevice, error) {
 	if err != nil {
 		return nil, tre.New(err, "Output", "id", id)
 	}
-	od := NewOutputDevice(id, midiOut, 1, core.NewTimeline())
+	od := NewOutputDevice(id, newBroadcastOut(id, newReopeningOut(id, midiOut, r), r.remap), 1, core.NewTimeline())
 	r.out[id] = od
 	od.Start() // play outgoing notes
 	return od, nil
@@ -103,6 +151,7 @@ func (r *DeviceRegistry) init() error {
 }
 
 func (r *DeviceRegistry) Close() error {
+	r.StopHotplugDetection()
 	for _, each := range r.in {
 		each.stopListener()
 	}
@@ -0,0 +1,29 @@
+package midi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InputDeviceID returns the ID of the first input device for which the name contains the substring (case-insensitive).
+func (r *DeviceRegistry) InputDeviceID(substring string) (int, error) {
+	return findDeviceID("input", r.streamRegistry.transport.InputDeviceNames(), substring)
+}
+
+// OutputDeviceID returns the ID of the first output device for which the name contains the substring (case-insensitive).
+func (r *DeviceRegistry) OutputDeviceID(substring string) (int, error) {
+	return findDeviceID("output", r.streamRegistry.transport.OutputDeviceNames(), substring)
+}
+
+func findDeviceID(kind string, names []string, substring string) (int, error) {
+	if len(substring) == 0 {
+		return -1, fmt.Errorf("missing %s device name", kind)
+	}
+	lower := strings.ToLower(substring)
+	for id, each := range names {
+		if strings.Contains(strings.ToLower(each), lower) {
+			return id, nil
+		}
+	}
+	return -1, fmt.Errorf("no %s device found with name containing %q", kind, substring)
+}
@@ -0,0 +1,25 @@
+package midi
+
+import "testing"
+
+func TestFindDeviceID(t *testing.T) {
+	names := []string{"Midi Through:Midi Through Port-0 14:0", "Arturia MiniLab mkII:Arturia MiniLab mkII MIDI 1 20:0", "FLUID Synth (1234):Synth input port 128:0"}
+	for _, each := range []struct {
+		substring string
+		id        int
+	}{
+		{"arturia", 1},
+		{"Fluid", 2},
+		{"port", 0},
+		{"yamaha", -1},
+		{"", -1},
+	} {
+		id, err := findDeviceID("input", names, each.substring)
+		if got, want := id, each.id; got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each.substring, got, got, want, want)
+		}
+		if each.id == -1 && err == nil {
+			t.Errorf("%s: error expected", each.substring)
+		}
+	}
+}
@@ -58,6 +58,38 @@ func (s *streamRegistry) input(id int) (transport.MIDIIn, error) {
 	return in, nil
 }
 
+// reopenOutput closes the current stream, if any, and opens a new one on a port.
+func (s *streamRegistry) reopenOutput(id, port int) (transport.MIDIOut, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if old, ok := s.out[id]; ok {
+		old.Close()
+		delete(s.out, id)
+	}
+	out, err := s.transport.NewMIDIOut(port)
+	if err != nil {
+		return nil, err
+	}
+	s.out[id] = out
+	return out, nil
+}
+
+// reopenInput closes the current stream, if any, and opens a new one on a port.
+func (s *streamRegistry) reopenInput(id, port int) (transport.MIDIIn, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if old, ok := s.in[id]; ok {
+		old.Close()
+		delete(s.in, id)
+	}
+	in, err := s.transport.NewMIDIIn(port)
+	if err != nil {
+		return nil, err
+	}
+	s.in[id] = in
+	return in, nil
+}
+
 func (s *streamRegistry) close() error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
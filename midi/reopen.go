@@ -0,0 +1,204 @@
+package midi
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/midi/transport"
+	"github.com/emicklei/melrose/notify"
+)
+
+const (
+	// reopenBackoff is the time to wait before re-opening again after a failed attempt ; it doubles up to maxReopenBackoff.
+	reopenBackoff    = 100 * time.Millisecond
+	maxReopenBackoff = 5 * time.Second
+)
+
+// backoff postpones the next attempt to re-open after a failed one.
+type backoff struct {
+	mutex   *sync.Mutex // held while re-opening
+	retryAt time.Time
+	delay   time.Duration
+}
+
+func newBackoff() *backoff {
+	return &backoff{mutex: new(sync.Mutex)}
+}
+
+// ready returns whether the backoff has passed.
+func (b *backoff) ready() bool {
+	return !time.Now().Before(b.retryAt)
+}
+
+// failed doubles the delay up to maxReopenBackoff.
+func (b *backoff) failed() {
+	b.delay *= 2
+	if b.delay == 0 {
+		b.delay = reopenBackoff
+	}
+	if b.delay > maxReopenBackoff {
+		b.delay = maxReopenBackoff
+	}
+	b.retryAt = time.Now().Add(b.delay)
+}
+
+// reopeningOut is a MIDIOut that re-opens its stream after a failed write.
+// After a system suspend/resume the streams can become invalid and writes start failing.
+// Only one write at a time re-opens the stream ; after a failed attempt, writes fail until the backoff has passed.
+type reopeningOut struct {
+	mutex    *sync.Mutex
+	id       int
+	stream   transport.MIDIOut
+	registry *DeviceRegistry
+	retry    *backoff
+}
+
+func newReopeningOut(id int, stream transport.MIDIOut, registry *DeviceRegistry) *reopeningOut {
+	return &reopeningOut{
+		mutex:    new(sync.Mutex),
+		id:       id,
+		stream:   stream,
+		registry: registry,
+		retry:    newBackoff(),
+	}
+}
+
+// WriteShort is part of transport.MIDIOut
+func (o *reopeningOut) WriteShort(status int64, data1 int64, data2 int64) error {
+	o.mutex.Lock()
+	err := o.stream.WriteShort(status, data1, data2)
+	o.mutex.Unlock()
+	if err == nil {
+		return nil
+	}
+	if !o.retry.mutex.TryLock() {
+		// another write is re-opening
+		return err
+	}
+	defer o.retry.mutex.Unlock()
+	if !o.retry.ready() {
+		return err
+	}
+	notify.Warnf("device.%d: write failed, re-opening MIDI device, error:%v", o.id, err)
+	if rerr := o.registry.reopenOutput(o.id); rerr != nil {
+		o.retry.failed()
+		return err
+	}
+	// the input streams are invalid too if the system was suspended
+	o.registry.rearmInputs()
+	// retry once
+	o.mutex.Lock()
+	err = o.stream.WriteShort(status, data1, data2)
+	o.mutex.Unlock()
+	if err != nil {
+		o.retry.failed()
+		return err
+	}
+	o.retry.delay = 0
+	return nil
+}
+
+// Close is part of transport.MIDIOut
+func (o *reopeningOut) Close() error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.stream.Close()
+}
+
+func (o *reopeningOut) replace(stream transport.MIDIOut) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.stream = stream
+}
+
+// reopenOutput closes and re-opens the stream of an open output device.
+// The port is the one with the name of the device, as detected by hotplugging ; it can differ from the ID after replugging.
+func (r *DeviceRegistry) reopenOutput(id int) error {
+	r.mutex.RLock()
+	device, ok := r.out[id]
+	name, named := r.outPlugs.names[id]
+	r.mutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("no output device %d", id)
+	}
+	port := id
+	if named {
+		port = indexOfName(r.streamRegistry.transport.OutputDeviceNames(), name)
+		if port == -1 {
+			return fmt.Errorf("output device %d (%s) is not available", id, name)
+		}
+	}
+	if core.IsDebug() {
+		notify.Debugf("midi.reopen: output=%d port=%d", id, port)
+	}
+	stream, err := r.streamRegistry.reopenOutput(id, port)
+	if err != nil {
+		return err
+	}
+	device.replaceStream(stream)
+	notify.Infof("re-opened output MIDI device %d", id)
+	return nil
+}
+
+// rearmInputs closes and re-opens the streams of all open input devices and re-arms their listeners.
+// Outputs that fail together re-arm the inputs once ; after a failed attempt, inputs are re-armed again when the backoff has passed.
+func (r *DeviceRegistry) rearmInputs() {
+	if !r.inRetry.mutex.TryLock() {
+		// another output is re-arming
+		return
+	}
+	defer r.inRetry.mutex.Unlock()
+	if !r.inRetry.ready() {
+		return
+	}
+	r.mutex.RLock()
+	ins := map[int]*InputDevice{}
+	names := map[int]string{}
+	for id, each := range r.in {
+		ins[id] = each
+		if name, ok := r.inPlugs.names[id]; ok {
+			names[id] = name
+		}
+	}
+	r.mutex.RUnlock()
+	var portNames []string
+	if len(names) > 0 {
+		portNames = r.streamRegistry.transport.InputDeviceNames()
+	}
+	failed := false
+	for id, each := range ins {
+		port := id
+		if name, named := names[id]; named {
+			port = indexOfName(portNames, name)
+			if port == -1 {
+				notify.Warnf("input device %d (%s) is not available", id, name)
+				failed = true
+				continue
+			}
+		}
+		if core.IsDebug() {
+			notify.Debugf("midi.reopen: input=%d port=%d", id, port)
+		}
+		stream, err := r.streamRegistry.reopenInput(id, port)
+		if err != nil {
+			notify.Warnf("failed to re-open input device %d, error:%v", id, err)
+			failed = true
+			continue
+		}
+		if err := each.listener.Rearm(stream); err != nil {
+			notify.Warnf("failed to re-arm input device %d, error:%v", id, err)
+			failed = true
+			continue
+		}
+		notify.Infof("re-opened input MIDI device %d", id)
+	}
+	if failed {
+		r.inRetry.failed()
+		return
+	}
+	// other outputs that failed by the same suspend must not re-arm again
+	r.inRetry.delay = 0
+	r.inRetry.retryAt = time.Now().Add(reopenBackoff)
+}
@@ -0,0 +1,181 @@
+package midi
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/midi/transport"
+)
+
+type failingOut struct {
+	failures int
+	writes   int
+}
+
+func (f *failingOut) WriteShort(status int64, data1 int64, data2 int64) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("stream closed")
+	}
+	f.writes++
+	return nil
+}
+func (f *failingOut) Close() error { return nil }
+
+type countingTransporter struct {
+	transport.Transporter
+	opened  []*failingOut
+	ports   []int
+	names   []string
+	fails   bool
+	inPorts []int
+}
+
+func (c *countingTransporter) NewMIDIOut(id int) (transport.MIDIOut, error) {
+	if c.fails {
+		return nil, errors.New("no such port")
+	}
+	out := new(failingOut)
+	c.opened = append(c.opened, out)
+	c.ports = append(c.ports, id)
+	return out, nil
+}
+
+func (c *countingTransporter) OutputDeviceNames() []string { return c.names }
+
+func (c *countingTransporter) NewMIDIIn(id int) (transport.MIDIIn, error) {
+	c.inPorts = append(c.inPorts, id)
+	return new(failingOut), nil
+}
+
+func (c *countingTransporter) InputDeviceNames() []string { return c.names }
+
+type rearmingListener struct {
+	transport.MIDIListener
+	rearmed []transport.MIDIIn
+}
+
+func (l *rearmingListener) Rearm(in transport.MIDIIn) error {
+	l.rearmed = append(l.rearmed, in)
+	return nil
+}
+
+func reopenTestRegistry(tr *countingTransporter) *DeviceRegistry {
+	return &DeviceRegistry{
+		mutex:          new(sync.RWMutex),
+		in:             map[int]*InputDevice{},
+		out:            map[int]*OutputDevice{},
+		outPlugs:       newPlugState(),
+		inPlugs:        newPlugState(),
+		inRetry:        newBackoff(),
+		remap:          newChannelRemap(),
+		streamRegistry: &streamRegistry{mutex: new(sync.RWMutex), out: map[int]transport.MIDIOut{}, in: map[int]transport.MIDIIn{}, transport: tr},
+	}
+}
+
+func TestReopeningOut_WriteAfterFailure(t *testing.T) {
+	tr := new(countingTransporter)
+	r := reopenTestRegistry(tr)
+	broken := &failingOut{failures: 1}
+	ro := newReopeningOut(0, broken, r)
+	r.out[0] = NewOutputDevice(0, ro, 1, nil)
+	if err := ro.WriteShort(0x90, 60, 100); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tr.opened), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := tr.opened[0].writes, 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestDeviceRegistry_PanicAfterFailure(t *testing.T) {
+	tr := new(countingTransporter)
+	r := reopenTestRegistry(tr)
+	ro := newReopeningOut(0, &failingOut{failures: 1}, r)
+	r.out[0] = NewOutputDevice(0, ro, 1, core.NewTimeline())
+	done := make(chan bool)
+	go func() {
+		r.Panic()
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("panic did not complete, deadlock on registry lock")
+	}
+}
+
+func TestReopeningOut_OnlyFailingOutput(t *testing.T) {
+	tr := &countingTransporter{names: []string{"synth", "drums"}}
+	r := reopenTestRegistry(tr)
+	// drums were replugged and are now on port 0
+	r.outPlugs.names[1] = "drums"
+	tr.names = []string{"drums", "synth"}
+	ro := newReopeningOut(1, &failingOut{failures: 1}, r)
+	r.out[0] = NewOutputDevice(0, newReopeningOut(0, new(failingOut), r), 1, nil)
+	r.out[1] = NewOutputDevice(1, ro, 1, nil)
+	if err := ro.WriteShort(0x90, 60, 100); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(tr.ports), "[0]"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestReopeningOut_Backoff(t *testing.T) {
+	tr := &countingTransporter{fails: true}
+	r := reopenTestRegistry(tr)
+	ro := newReopeningOut(0, &failingOut{failures: 3}, r)
+	r.out[0] = NewOutputDevice(0, ro, 1, nil)
+	if err := ro.WriteShort(0x90, 60, 100); err == nil {
+		t.Fatal("error expected")
+	}
+	tr.fails = false
+	// within the backoff, no attempt to re-open
+	if err := ro.WriteShort(0x90, 60, 100); err == nil {
+		t.Fatal("error expected")
+	}
+	if got, want := len(tr.opened), 0; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	ro.retry.retryAt = time.Now()
+	if err := ro.WriteShort(0x90, 60, 100); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tr.opened), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestReopeningOut_RearmsInputs(t *testing.T) {
+	tr := new(countingTransporter)
+	r := reopenTestRegistry(tr)
+	listener := new(rearmingListener)
+	r.in[2] = &InputDevice{id: 2, listener: listener}
+	ro := newReopeningOut(0, &failingOut{failures: 1}, r)
+	other := newReopeningOut(1, &failingOut{failures: 1}, r)
+	r.out[0] = NewOutputDevice(0, ro, 1, nil)
+	r.out[1] = NewOutputDevice(1, other, 1, nil)
+	if err := ro.WriteShort(0x90, 60, 100); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(tr.inPorts), "[2]"; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := len(listener.rearmed), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// the other output failed by the same suspend, inputs are not re-armed again
+	if err := other.WriteShort(0x90, 60, 100); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(listener.rearmed), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -19,6 +19,7 @@ type mListener struct {
 	noteOn        map[int]mNoteEvent
 	noteListeners []core.NoteListener
 	keyListeners  map[int]core.NoteListener
+	velocity      func(int) int // can be nil
 }
 
 func newMListener() *mListener {
@@ -54,6 +55,13 @@ func (l *mListener) safeRemove(lis core.NoteListener) {
 	l.noteListeners = without
 }
 
+// SetVelocityMapping is part of MIDIListener
+func (l *mListener) SetVelocityMapping(mapping func(velocity int) int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.velocity = mapping
+}
+
 func (l *mListener) OnKey(note core.Note, handler core.NoteListener) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
@@ -93,6 +101,9 @@ func (l *mListener) HandleMIDIMessage(status int16, nr int, data2 int) {
 		if _, ok := l.noteOn[nr]; ok {
 			return
 		}
+		if l.velocity != nil {
+			velocity = l.velocity(velocity)
+		}
 		onNote, _ := core.MIDItoNote(0.25, nr, velocity)
 		l.noteOn[nr] = mNoteEvent{
 			note: onNote,
@@ -54,3 +54,19 @@ func Test_mListener_HandleMIDIMessage(t *testing.T) {
 		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
 	}
 }
+
+func Test_mListener_VelocityMapping(t *testing.T) {
+	nc := new(noteCollector)
+	lis := newMListener()
+	lis.Add(nc)
+	lis.SetVelocityMapping(func(v int) int { return 100 })
+	lis.HandleMIDIMessage(noteOn, 60, 20)
+	if got, want := nc.data2, 100; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// velocity 0 is a note off and is not mapped
+	lis.HandleMIDIMessage(noteOn, 60, 0)
+	if got, want := nc.noteOff, true; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,65 @@
+//go:build nomidi && !wasm
+// +build nomidi,!wasm
+
+package transport
+
+import (
+	"errors"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// errNoMIDI is returned when a MIDI port is opened in a binary built without MIDI support.
+var errNoMIDI = errors.New("MIDI is not available in this build (nomidi)")
+
+func init() { Initializer = noneInitialize }
+
+func noneInitialize() {
+	if core.IsDebug() {
+		notify.Debugf("transport.init: use NoneTransporter")
+	}
+	Factory = func() Transporter {
+		return NoneTransporter{}
+	}
+}
+
+// NoneTransporter is used for a CGo-free binary (go build -tags nomidi) ; it has no MIDI ports.
+type NoneTransporter struct{}
+
+func (t NoneTransporter) HasInputCapability() bool {
+	return false
+}
+func (t NoneTransporter) PrintInfo(inID, outID int) {
+	notify.Warnf("MIDI is not available in this build (nomidi), use -osc to send notes")
+}
+func (t NoneTransporter) DefaultOutputDeviceID() int {
+	return -1
+}
+func (t NoneTransporter) DefaultInputDeviceID() int {
+	return -1
+}
+func (t NoneTransporter) NewMIDIOut(id int) (MIDIOut, error) {
+	return nil, errNoMIDI
+}
+func (t NoneTransporter) NewMIDIIn(id int) (MIDIIn, error) {
+	return nil, errNoMIDI
+}
+func (t NoneTransporter) NewMIDIListener(in MIDIIn) MIDIListener {
+	return noneListener{mListener: newMListener()}
+}
+func (t NoneTransporter) InputDeviceNames() []string {
+	return []string{}
+}
+func (t NoneTransporter) OutputDeviceNames() []string {
+	return []string{}
+}
+
+// noneListener never receives messages because there are no input ports.
+type noneListener struct {
+	*mListener
+}
+
+func (l noneListener) Start()                {}
+func (l noneListener) Stop()                 {}
+func (l noneListener) Rearm(in MIDIIn) error { return nil }
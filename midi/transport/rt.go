@@ -1,9 +1,11 @@
-//go:build !wasm
-// +build !wasm
+//go:build !wasm && !nomidi
+// +build !wasm,!nomidi
 
 package transport
 
 import (
+	"fmt"
+
 	"github.com/emicklei/melrose/core"
 	"github.com/emicklei/melrose/notify"
 	"gitlab.com/gomidi/rtmididrv/imported/rtmidi"
@@ -100,14 +102,31 @@ func newRtListener(in rtmidi.MIDIIn) *RtListener {
 		midiIn:    in,
 		mListener: newMListener(),
 	}
+	lis.setCallback()
+	return lis
+}
+
+func (l *RtListener) setCallback() {
 	// since l.midiIn.SetCallback is blocking on success, there is no meaningful way to get an error
 	// and set the callback non blocking
 	go func(l *RtListener) {
 		if err := l.midiIn.SetCallback(l.handleRtEvent); err != nil {
 			notify.Warnf("failed to set listener callback")
 		}
-	}(lis)
-	return lis
+	}(l)
+}
+
+// Rearm is part of MIDIListener
+func (l *RtListener) Rearm(in MIDIIn) error {
+	rin, ok := in.(RtmidiIn)
+	if !ok {
+		return fmt.Errorf("cannot rearm listener with (%T) input, expected rtmidi input", in)
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.midiIn = rin.in
+	l.setCallback()
+	return nil
 }
 
 func (l *RtListener) Start() {
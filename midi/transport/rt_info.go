@@ -1,5 +1,5 @@
-//go:build !wasm
-// +build !wasm
+//go:build !wasm && !nomidi
+// +build !wasm,!nomidi
 
 package transport
 
@@ -55,3 +55,49 @@ func (t RtmidiTransporter) PrintInfo(inID, outID int) {
 	}
 	fmt.Println()
 }
+
+func (t RtmidiTransporter) InputDeviceNames() []string {
+	names := []string{}
+	in, err := rtmidi.NewMIDIInDefault()
+	if err != nil {
+		notify.Warnf("can't open default MIDI in: %v", err)
+		return names
+	}
+	defer in.Close()
+	ports, err := in.PortCount()
+	if err != nil {
+		notify.Warnf("can't get number of in ports: %v", err)
+		return names
+	}
+	for i := 0; i < ports; i++ {
+		name, err := in.PortName(i)
+		if err != nil {
+			name = ""
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func (t RtmidiTransporter) OutputDeviceNames() []string {
+	names := []string{}
+	out, err := rtmidi.NewMIDIOutDefault()
+	if err != nil {
+		notify.Warnf("can't open default MIDI out: %v", err)
+		return names
+	}
+	defer out.Close()
+	ports, err := out.PortCount()
+	if err != nil {
+		notify.Warnf("can't get number of out ports: %v", err)
+		return names
+	}
+	for i := 0; i < ports; i++ {
+		name, err := out.PortName(i)
+		if err != nil {
+			name = ""
+		}
+		names = append(names, name)
+	}
+	return names
+}
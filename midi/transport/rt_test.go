@@ -1,3 +1,6 @@
+//go:build !wasm && !nomidi
+// +build !wasm,!nomidi
+
 package transport
 
 import "testing"
@@ -23,6 +23,10 @@ type Transporter interface {
 	NewMIDIOut(id int) (MIDIOut, error)
 	NewMIDIIn(id int) (MIDIIn, error)
 	NewMIDIListener(MIDIIn) MIDIListener
+	// InputDeviceNames returns the names of all input ports ; the index is the device ID.
+	InputDeviceNames() []string
+	// OutputDeviceNames returns the names of all output ports ; the index is the device ID.
+	OutputDeviceNames() []string
 }
 
 type MIDIOut interface {
@@ -41,4 +45,8 @@ type MIDIListener interface {
 	HandleMIDIMessage(status int16, nr, data2 int)
 	Start()
 	Stop()
+	// Rearm binds the listener to a re-opened input stream, keeping all registered listeners.
+	Rearm(MIDIIn) error
+	// SetVelocityMapping changes the velocity of each incoming note before it is handled ; nil means unchanged.
+	SetVelocityMapping(func(velocity int) int)
 }
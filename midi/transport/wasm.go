@@ -47,6 +47,13 @@ func (t WASMmidiTransporter) NewMIDIListener(m MIDIIn) MIDIListener {
 	}
 }
 
+func (t WASMmidiTransporter) InputDeviceNames() []string {
+	return []string{}
+}
+func (t WASMmidiTransporter) OutputDeviceNames() []string {
+	return []string{}
+}
+
 type WASMMidiOut struct {
 	id int
 }
@@ -90,6 +97,9 @@ func (l *WASMListener) Stop() {
 	l.listening = false
 }
 
+// Rearm is part of MIDIListener
+func (l *WASMListener) Rearm(in MIDIIn) error { return nil }
+
 func (l *WASMListener) HandleMIDIMessage(status int16, nr int, data2 int) {
 	if !l.listening {
 		return
@@ -0,0 +1,84 @@
+package midi
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/emicklei/melrose/notation/scala"
+)
+
+// bendRange is the number of semitones of a full pitch bend ; the default of most synthesizers.
+const bendRange = 2.0
+
+// bendCenter is the pitch bend value for no bend.
+const bendCenter = 8192
+
+// tuning plays each note with the frequency of a Scala tuning, using the nearest key and a pitch bend on its channel.
+// Notes that sound together on a channel share its pitch bend ; use a channel for each voice.
+// The MIDI Tuning Standard is not used because the transport only writes short messages.
+type tuning struct {
+	name  string
+	scale *scala.Tuning
+}
+
+// realize returns the key and pitch bend to play a note ; false if the note is not retuned.
+func (t *tuning) realize(nr int64) (key int64, bend int64, ok bool) {
+	freq, ok := t.scale.Frequency(int(nr))
+	if !ok {
+		return nr, bendCenter, false
+	}
+	exact := 69 + 12*math.Log2(freq/440)
+	k := math.Round(exact)
+	if k < 0 || k > 127 {
+		return nr, bendCenter, false
+	}
+	return int64(k), bentBy(-1, (exact-k)*100), true
+}
+
+// bentBy returns the pitch bend for a deviation in cents added to a bend ; -1 means no bend.
+func bentBy(bend int64, cents float64) int64 {
+	if bend == -1 {
+		bend = bendCenter
+	}
+	b := math.Round(float64(bend) + cents/100/bendRange*bendCenter)
+	if b > 16383 {
+		b = 16383
+	}
+	if b < 0 {
+		b = 0
+	}
+	return int64(b)
+}
+
+func (t *tuning) String() string {
+	return fmt.Sprintf("%s (%s)", t.name, t.scale.Scale.Description)
+}
+
+// setTuning changes the tuning of the notes ; an empty scale file means 12-TET.
+func (d *OutputDevice) setTuning(scaleFile, keyboardFile string) error {
+	if len(scaleFile) == 0 {
+		d.mutex.Lock()
+		d.tuning = nil
+		d.mutex.Unlock()
+		return nil
+	}
+	s, err := scala.ReadScaleFile(scaleFile)
+	if err != nil {
+		return fmt.Errorf("cannot read scale [%s]: %v", scaleFile, err)
+	}
+	k := scala.DefaultKeyboard(s)
+	if len(keyboardFile) > 0 {
+		k, err = scala.ReadKeyboardFile(keyboardFile)
+		if err != nil {
+			return fmt.Errorf("cannot read keyboard mapping [%s]: %v", keyboardFile, err)
+		}
+	}
+	t, err := scala.NewTuning(s, k)
+	if err != nil {
+		return err
+	}
+	d.mutex.Lock()
+	d.tuning = &tuning{name: scaleFile, scale: t}
+	d.mutex.Unlock()
+	return nil
+}
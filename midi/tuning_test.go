@@ -0,0 +1,125 @@
+package midi
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notation/scala"
+)
+
+type writtenOut struct {
+	messages [][3]int64
+}
+
+func (w *writtenOut) WriteShort(status int64, data1 int64, data2 int64) error {
+	w.messages = append(w.messages, [3]int64{status, data1, data2})
+	return nil
+}
+func (w *writtenOut) Close() error { return nil }
+
+// testTuning has a degree of 25 cents above C4 at key 61 ; key 62 is C5.
+func testTuning(t *testing.T) *tuning {
+	s, err := scala.ReadScale(strings.NewReader("test\n2\n25.0\n2/1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := scala.DefaultKeyboard(s)
+	k.Reference = 60
+	k.Frequency = 261.6255653
+	tun, err := scala.NewTuning(s, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tuning{name: "test", scale: tun}
+}
+
+func TestTuningRealize(t *testing.T) {
+	tun := testTuning(t)
+	// the first degree is C4
+	key, bend, ok := tun.realize(60)
+	if !ok || key != 60 || bend != bendCenter {
+		t.Errorf("got %d,%d,%v", key, bend, ok)
+	}
+	// 25 cents above C4
+	key, bend, ok = tun.realize(61)
+	if got, want := key, int64(60); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := bend, int64(bendCenter+bendCenter/8); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestMidiEventWithTuning(t *testing.T) {
+	out := new(writtenOut)
+	e := midiEvent{which: []int64{61}, onoff: noteOn, channel: 2, velocity: 80, out: out, tuning: testTuning(t)}
+	e.Handle(core.NewTimeline(), time.Now())
+	e.asNoteoff().Handle(core.NewTimeline(), time.Now())
+	if got, want := len(out.messages), 3; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	bend := int64(bendCenter + bendCenter/8)
+	if got, want := out.messages[0], [3]int64{pitchBend | 1, bend & 0x7F, bend >> 7}; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := out.messages[1], [3]int64{noteOn | 1, 60, 80}; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := out.messages[2][1], int64(60); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestTuningRealizeNextPeriod(t *testing.T) {
+	key, bend, _ := testTuning(t).realize(62)
+	if key != 72 || bend != bendCenter {
+		t.Errorf("got %d,%d", key, bend)
+	}
+}
+
+func TestMidiEventWithCents(t *testing.T) {
+	out := new(writtenOut)
+	e := midiEvent{which: []int64{69}, onoff: noteOn, channel: 1, velocity: 80, cents: 50, out: out}
+	e.Handle(core.NewTimeline(), time.Now())
+	e.asNoteoff().Handle(core.NewTimeline(), time.Now())
+	if got, want := len(out.messages), 4; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	bend := int64(bendCenter + bendCenter/4)
+	if got, want := out.messages[0], [3]int64{pitchBend, bend & 0x7F, bend >> 7}; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// reset after note off
+	if got, want := out.messages[3], [3]int64{pitchBend, 0, 64}; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestOutputDevice_setTuningWhilePlaying(t *testing.T) {
+	scl := filepath.Join(t.TempDir(), "test.scl")
+	if err := os.WriteFile(scl, []byte("test\n2\n25.0\n2/1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d := NewOutputDevice(0, new(writtenOut), 1, core.NewTimeline())
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 20; i++ {
+			if i%2 == 0 {
+				if err := d.setTuning(scl, ""); err != nil {
+					t.Error(err)
+				}
+			} else {
+				d.setTuning("", "")
+			}
+		}
+		done <- true
+	}()
+	for i := 0; i < 100; i++ {
+		d.Play(core.NoCondition, core.MustParseSequence("c e"), 120, time.Now().Add(time.Hour))
+	}
+	<-done
+}
@@ -0,0 +1,91 @@
+package midi
+
+import (
+	"fmt"
+	"math"
+)
+
+// velocityCurve maps the velocity [1..127] of an incoming note to a velocity in [min..max].
+type velocityCurve struct {
+	kind     string // linear, exp or fixed
+	min, max int
+	exponent float64 // exp only ; > 1 makes soft notes softer, < 1 makes them louder
+}
+
+// newVelocityCurve returns a curve from setting values, e.g. 'linear',40,110 or 'exp',1,127,2.0 or 'fixed',100.
+func newVelocityCurve(values []interface{}) (velocityCurve, error) {
+	if len(values) == 0 {
+		return velocityCurve{}, fmt.Errorf("missing curve, one of linear, exp, fixed or off")
+	}
+	kind, ok := values[0].(string)
+	if !ok {
+		return velocityCurve{}, fmt.Errorf("string curve argument expected, got %T", values[0])
+	}
+	ints := []int{}
+	c := velocityCurve{kind: kind, min: 1, max: 127, exponent: 2.0}
+	for i, each := range values[1:] {
+		if kind == "exp" && i == 2 {
+			f, ok := toFloat(each)
+			if !ok || f <= 0 {
+				return c, fmt.Errorf("positive exponent argument expected, got %v", each)
+			}
+			c.exponent = f
+			continue
+		}
+		v, ok := each.(int)
+		if !ok || v < 1 || v > 127 {
+			return c, fmt.Errorf("velocity argument must be an integer in [1..127], got %v", each)
+		}
+		ints = append(ints, v)
+	}
+	switch kind {
+	case "fixed":
+		if len(ints) != 1 || len(values) != 2 {
+			return c, fmt.Errorf("fixed curve expects one velocity")
+		}
+		c.min, c.max = ints[0], ints[0]
+	case "linear", "exp":
+		if len(ints) != 2 {
+			return c, fmt.Errorf("%s curve expects a minimum and a maximum velocity", kind)
+		}
+		c.min, c.max = ints[0], ints[1]
+		if c.min > c.max {
+			return c, fmt.Errorf("minimum velocity must not be higher than the maximum, got %d and %d", c.min, c.max)
+		}
+	default:
+		return c, fmt.Errorf("unknown curve %s, expected linear, exp, fixed or off", kind)
+	}
+	return c, nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch f := v.(type) {
+	case int:
+		return float64(f), true
+	case float64:
+		return f, true
+	}
+	return 0, false
+}
+
+// apply returns the mapped velocity ; zero (note off) is not mapped.
+func (c velocityCurve) apply(velocity int) int {
+	if velocity <= 0 {
+		return velocity
+	}
+	x := float64(velocity) / 127.0
+	if c.kind == "exp" {
+		x = math.Pow(x, c.exponent)
+	}
+	return c.min + int(math.Round(float64(c.max-c.min)*x))
+}
+
+func (c velocityCurve) String() string {
+	switch c.kind {
+	case "fixed":
+		return fmt.Sprintf("fixed %d", c.min)
+	case "exp":
+		return fmt.Sprintf("exp [%d..%d] exponent %v", c.min, c.max, c.exponent)
+	}
+	return fmt.Sprintf("%s [%d..%d]", c.kind, c.min, c.max)
+}
@@ -0,0 +1,41 @@
+package midi
+
+import "testing"
+
+func TestVelocityCurve(t *testing.T) {
+	for _, each := range []struct {
+		values   []interface{}
+		velocity int
+		want     int
+	}{
+		{[]interface{}{"linear", 40, 110}, 127, 110},
+		{[]interface{}{"linear", 40, 110}, 1, 41},
+		{[]interface{}{"fixed", 100}, 12, 100},
+		{[]interface{}{"exp", 1, 127}, 64, 33},
+		{[]interface{}{"exp", 1, 127, 0.5}, 32, 64},
+		{[]interface{}{"linear", 40, 110}, 0, 0},
+	} {
+		c, err := newVelocityCurve(each.values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := c.apply(each.velocity), each.want; got != want {
+			t.Errorf("%v %d: got [%v:%T] want [%v:%T]", each.values, each.velocity, got, got, want, want)
+		}
+	}
+}
+
+func TestVelocityCurve_Invalid(t *testing.T) {
+	for _, each := range [][]interface{}{
+		{},
+		{"loud"},
+		{"linear", 110, 40},
+		{"linear", 0, 127},
+		{"fixed", 100, 110},
+		{"exp", 1, 127, -1.0},
+	} {
+		if _, err := newVelocityCurve(each); err == nil {
+			t.Errorf("%v: error expected", each)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package midi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/midi/transport"
+	"github.com/emicklei/melrose/notify"
+)
+
+// watchdogGrace is the extra time a note can be on before it is reported as stuck.
+const watchdogGrace = 1 * time.Second
+
+type watchKey struct {
+	channel int
+	number  int64
+}
+
+type watchEntry struct {
+	since    time.Time
+	expected time.Duration
+}
+
+// noteWatchdog tracks outstanding note-ons per channel and reports notes that are on
+// far longer than their scheduled duration, e.g. because of a dropped note-off.
+type noteWatchdog struct {
+	mutex       *sync.Mutex
+	deviceID    int
+	out         transport.MIDIOut
+	sendNoteOff bool
+	pending     map[watchKey]watchEntry
+	done        chan bool
+}
+
+func newNoteWatchdog(deviceID int, out transport.MIDIOut) *noteWatchdog {
+	return &noteWatchdog{
+		mutex:    new(sync.Mutex),
+		deviceID: deviceID,
+		out:      out,
+		pending:  map[watchKey]watchEntry{},
+	}
+}
+
+func (w *noteWatchdog) noteOn(channel int, nr int64, expected time.Duration, when time.Time) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.pending[watchKey{channel: channel, number: nr}] = watchEntry{since: when, expected: expected}
+}
+
+func (w *noteWatchdog) noteOff(channel int, nr int64) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	delete(w.pending, watchKey{channel: channel, number: nr})
+}
+
+// stuck returns and forgets all notes that are on longer than twice their expected duration plus the grace.
+func (w *noteWatchdog) stuck(now time.Time) (list []watchKey) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for k, v := range w.pending {
+		if now.Sub(v.since) > 2*v.expected+watchdogGrace {
+			list = append(list, k)
+			delete(w.pending, k)
+		}
+	}
+	return
+}
+
+func (w *noteWatchdog) check(now time.Time) {
+	for _, each := range w.stuck(now) {
+		n, _ := core.MIDItoNote(0.25, int(each.number), core.Normal)
+		if !w.sendNoteOff {
+			notify.Warnf("device.%d: note %s on channel %d is stuck", w.deviceID, n.String(), each.channel)
+			continue
+		}
+		notify.Warnf("device.%d: note %s on channel %d is stuck, sending note off", w.deviceID, n.String(), each.channel)
+		if err := w.out.WriteShort(noteOff|int64(each.channel-1), each.number, 0); err != nil {
+			notify.Errorf("failed to write MIDI data, error:%v", err)
+		}
+	}
+}
+
+func (w *noteWatchdog) reset() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.pending = map[watchKey]watchEntry{}
+}
+
+func (w *noteWatchdog) start() {
+	w.done = make(chan bool)
+	go func(done chan bool) {
+		ticker := time.NewTicker(watchdogGrace)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				w.check(now)
+			}
+		}
+	}(w.done)
+}
+
+func (w *noteWatchdog) stop() {
+	if w.done != nil {
+		close(w.done)
+		w.done = nil
+	}
+}
@@ -0,0 +1,40 @@
+package midi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNoteWatchdog_Stuck(t *testing.T) {
+	w := newNoteWatchdog(0, nil)
+	now := time.Now()
+	w.noteOn(1, 60, 500*time.Millisecond, now)
+	w.noteOn(2, 62, 500*time.Millisecond, now)
+	w.noteOff(2, 62)
+	if got, want := len(w.stuck(now.Add(time.Second))), 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	list := w.stuck(now.Add(3 * time.Second))
+	if got, want := len(list), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := list[0], (watchKey{channel: 1, number: 60}); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// reported once
+	if got, want := len(w.stuck(now.Add(4*time.Second))), 0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestNoteWatchdog_SendNoteOff(t *testing.T) {
+	out := new(failingOut)
+	w := newNoteWatchdog(0, out)
+	w.sendNoteOff = true
+	now := time.Now()
+	w.noteOn(1, 60, 100*time.Millisecond, now)
+	w.check(now.Add(2 * time.Second))
+	if got, want := out.writes, 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,529 @@
+package abc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// https://abcnotation.com/wiki/abc:standard:v2.1
+
+// ParseFile reads a file with ABC notation and returns the notes of the first tune, see Parse.
+func ParseFile(fileName string) (core.Sequence, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return core.Sequence{}, err
+	}
+	return Parse(string(data))
+}
+
+// Parse returns the notes of the first tune in ABC notation.
+// Repeats and first and second endings are expanded ; notes that cannot be notated by a single length are tied.
+// Without a header, the input is read as the body of a tune in C major.
+// Tuplets are played with the length of the notes as written; grace notes, decorations, annotations and chord symbols are ignored.
+func Parse(input string) (core.Sequence, error) {
+	p := newParser()
+	for i, line := range strings.Split(input, "\n") {
+		if err := p.parseLine(line); err != nil {
+			return core.Sequence{}, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		if p.done {
+			break
+		}
+	}
+	if len(p.events) == 0 {
+		return core.Sequence{}, errors.New("no notes in ABC tune")
+	}
+	return p.sequence(), nil
+}
+
+// event is a rest, a note or a chord with a length as a whole note fraction.
+type event struct {
+	notes  []core.Note // empty for a rest
+	length float32
+	tied   bool // to the next event
+}
+
+type parser struct {
+	unit        float32        // default length of a note, see L:
+	meter       float32        // length of a bar, see M:
+	key         map[string]int // note name -> accidental, see K:
+	accidentals map[string]int // note name and octave -> accidental, until the next bar line
+	events      []event
+	broken      float32 // if set then the length factor of the next event
+	repeatStart int     // index of the first event of the section to repeat
+	endingStart int     // index of the first event of the first ending, -1 if none
+	headers     bool    // a header field was read
+	body        bool    // a K: field was read
+	done        bool    // the end of the first tune
+}
+
+func newParser() *parser {
+	return &parser{
+		meter:       1,
+		key:         map[string]int{},
+		accidentals: map[string]int{},
+		endingStart: -1,
+	}
+}
+
+func (p *parser) parseLine(line string) error {
+	line = strings.TrimSpace(withoutComment(line))
+	if len(line) == 0 {
+		// an empty line ends a tune
+		p.done = p.body && len(p.events) > 0
+		return nil
+	}
+	if isField(line) {
+		return p.parseField(line[0], strings.TrimSpace(line[2:]))
+	}
+	if p.body || !p.headers {
+		return p.parseBody(line)
+	}
+	return nil // free text
+}
+
+// withoutComment returns the line up to an (unescaped) %.
+func withoutComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		if line[i] == '%' && (i == 0 || line[i-1] != '\\') {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// isField returns whether the line is a header or body field such as K:G.
+func isField(line string) bool {
+	return len(line) > 1 && line[1] == ':' && (line[0] >= 'A' && line[0] <= 'Z' || line[0] >= 'a' && line[0] <= 'z')
+}
+
+func (p *parser) parseField(name byte, value string) error {
+	switch name {
+	case 'X':
+		if p.body || len(p.events) > 0 {
+			p.done = true
+		}
+	case 'M':
+		m, err := parseMeter(value)
+		if err != nil {
+			return err
+		}
+		p.meter = m
+	case 'L':
+		l, err := parseFraction(value)
+		if err != nil {
+			return err
+		}
+		p.unit = l
+	case 'K':
+		k, err := parseKey(value)
+		if err != nil {
+			return err
+		}
+		p.key = k
+		p.body = true
+	}
+	p.headers = true
+	return nil
+}
+
+// decorations are single character decorations that are ignored.
+const decorations = "~.HLMOPRSTuvJy`"
+
+func (p *parser) parseBody(line string) error {
+	if p.unit == 0 {
+		// the default depends on the meter
+		p.unit = 0.125
+		if p.meter < 0.75 {
+			p.unit = 0.0625
+		}
+	}
+	s := &scanner{line: line}
+	for !s.atEnd() {
+		c := s.peek()
+		switch {
+		case c == ' ' || c == '\t' || c == '\\' || c == ')':
+			s.next()
+		case strings.IndexByte(decorations, c) != -1:
+			s.next()
+		case c == '"':
+			s.skipTo('"')
+		case c == '!' || c == '+':
+			s.skipTo(c)
+		case c == '{':
+			s.skipTo('}')
+		case c == '(':
+			s.next()
+			// tuplets and slurs are ignored
+			s.digits()
+			for s.peek() == ':' {
+				s.next()
+				s.digits()
+			}
+		case c == '-':
+			s.next()
+			if len(p.events) > 0 {
+				p.events[len(p.events)-1].tied = true
+			}
+		case c == '>' || c == '<':
+			p.parseBroken(s)
+		case c == '[' && s.peekAt(2) == ':':
+			field := s.skipTo(']')
+			if err := p.parseField(field[1], strings.TrimSpace(strings.TrimSuffix(field[3:], "]"))); err != nil {
+				return err
+			}
+		case c == '[' && isDigit(s.peekAt(1)):
+			s.next()
+			p.ending(s.digits())
+		case c == '|' || c == ':' || c == '[' && s.peekAt(1) == '|' || c == ']':
+			p.parseBar(s)
+		case c == '[':
+			if err := p.parseChord(s); err != nil {
+				return err
+			}
+		case c == 'z' || c == 'x':
+			s.next()
+			p.add(event{length: p.unit * s.length()})
+		case c == 'Z' || c == 'X':
+			s.next()
+			bars := 1
+			if d := s.digits(); d > 0 {
+				bars = d
+			}
+			p.add(event{length: p.meter * float32(bars)})
+		default:
+			n, err := p.parseNote(s)
+			if err != nil {
+				return err
+			}
+			p.add(event{notes: []core.Note{n}, length: p.unit * s.length()})
+		}
+	}
+	return nil
+}
+
+// add appends the event and applies a pending broken rhythm.
+func (p *parser) add(e event) {
+	if p.broken > 0 {
+		e.length *= p.broken
+		p.broken = 0
+	}
+	p.events = append(p.events, e)
+}
+
+// parseBroken handles a>b (dotted a, halved b) and a<b ; each extra sign halves again.
+func (p *parser) parseBroken(s *scanner) {
+	sign := s.next()
+	factor := float32(0.5)
+	for s.peek() == sign {
+		s.next()
+		factor /= 2
+	}
+	if len(p.events) == 0 {
+		return
+	}
+	last := &p.events[len(p.events)-1]
+	if sign == '>' {
+		last.length *= 2 - factor
+		p.broken = factor
+	} else {
+		last.length *= factor
+		p.broken = 2 - factor
+	}
+}
+
+// parseBar handles bar lines, repeats and endings such as |, ||, |], |:, :|, ::, |1 and :|2.
+func (p *parser) parseBar(s *scanner) {
+	token := ""
+	for strings.IndexByte("|:[]", s.peek()) != -1 && !s.atEnd() {
+		if s.peek() == '[' && !(s.peekAt(1) == '|' || isDigit(s.peekAt(1))) {
+			break // a chord
+		}
+		token += string(s.next())
+	}
+	p.accidentals = map[string]int{}
+	bar := strings.Index(token, "|")
+	if strings.HasPrefix(token, ":") {
+		p.repeat()
+	}
+	if strings.HasSuffix(strings.TrimSuffix(token, "["), ":") && (bar != -1 || strings.HasPrefix(token, "::")) {
+		p.repeatStart = len(p.events)
+		p.endingStart = -1
+	}
+	if isDigit(s.peek()) {
+		p.ending(s.digits())
+	}
+}
+
+// repeat appends the events of the section to repeat, without the first ending.
+func (p *parser) repeat() {
+	end := len(p.events)
+	if p.endingStart != -1 {
+		end = p.endingStart
+	}
+	section := make([]event, end-p.repeatStart)
+	copy(section, p.events[p.repeatStart:end])
+	p.events = append(p.events, section...)
+	p.repeatStart = len(p.events)
+	p.endingStart = -1
+}
+
+func (p *parser) ending(n int) {
+	if n == 1 {
+		p.endingStart = len(p.events)
+	}
+}
+
+// parseChord reads the notes in [] ; the length of the first note is used for all.
+func (p *parser) parseChord(s *scanner) error {
+	s.next() // [
+	chord := event{}
+	for !s.atEnd() && s.peek() != ']' {
+		n, err := p.parseNote(s)
+		if err != nil {
+			return err
+		}
+		l := s.length()
+		if len(chord.notes) == 0 {
+			chord.length = p.unit * l
+		}
+		chord.notes = append(chord.notes, n)
+	}
+	if s.atEnd() {
+		return errors.New("missing ] of chord")
+	}
+	s.next() // ]
+	chord.length *= s.length()
+	p.add(chord)
+	return nil
+}
+
+// parseNote reads the accidental, the name and the octave of a note.
+func (p *parser) parseNote(s *scanner) (core.Note, error) {
+	accidental, explicit := 0, false
+	for strings.IndexByte("^_=", s.peek()) != -1 && !s.atEnd() {
+		explicit = true
+		switch s.next() {
+		case '^':
+			accidental++
+		case '_':
+			accidental--
+		}
+	}
+	c := s.next()
+	octave := 4
+	switch {
+	case c >= 'A' && c <= 'G':
+	case c >= 'a' && c <= 'g':
+		octave = 5
+		c -= 'a' - 'A'
+	default:
+		return core.Rest4, fmt.Errorf("unexpected character %q", c)
+	}
+	for s.peek() == '\'' || s.peek() == ',' {
+		if s.next() == '\'' {
+			octave++
+		} else {
+			octave--
+		}
+	}
+	name := string(c)
+	pitch := name + strconv.Itoa(octave)
+	if explicit {
+		p.accidentals[pitch] = accidental
+	} else if a, ok := p.accidentals[pitch]; ok {
+		accidental = a
+	} else {
+		accidental = p.key[name]
+	}
+	if accidental < -1 || accidental > 1 {
+		// double flats and sharps are respelled
+		return core.MakeNote(name, octave, 0.25, 0, false, core.Normal).Pitched(accidental), nil
+	}
+	return core.MakeNote(name, octave, 0.25, accidental, false, core.Normal), nil
+}
+
+// sequence returns the notes of the events ; tied events with the same pitches are joined.
+func (p *parser) sequence() core.Sequence {
+	groups := [][]core.Note{}
+	for i, each := range p.events {
+		if i > 0 && p.events[i-1].tied && len(each.notes) > 0 && tieInto(groups[len(groups)-1], each) {
+			continue
+		}
+		if len(each.notes) == 0 {
+			// rests are not tied
+			for _, rest := range withLength(core.Rest4, each.length) {
+				groups = append(groups, []core.Note{rest})
+			}
+			continue
+		}
+		group := []core.Note{}
+		for _, n := range each.notes {
+			group = append(group, tied(withLength(n, each.length)))
+		}
+		groups = append(groups, group)
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// tieInto ties the notes of the event to the notes with the same pitch in the group, if all have one.
+func tieInto(group []core.Note, e event) bool {
+	indices := []int{}
+	for _, n := range e.notes {
+		found := false
+		for i, each := range group {
+			if each.IsHearable() && each.MIDI() == n.MIDI() {
+				indices = append(indices, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for j, i := range indices {
+		for _, each := range withLength(e.notes[j], e.length) {
+			group[i] = group[i].WithTiedNote(each)
+		}
+	}
+	return true
+}
+
+// lengths are the notated lengths (whole note fractions) from long to short.
+var lengths = []struct {
+	length   float32
+	fraction float32
+	dotted   bool
+}{
+	{1, 1, false},
+	{0.75, 0.5, true},
+	{0.5, 0.5, false},
+	{0.375, 0.25, true},
+	{0.25, 0.25, false},
+	{0.1875, 0.125, true},
+	{0.125, 0.125, false},
+	{0.09375, 0.0625, true},
+	{0.0625, 0.0625, false},
+}
+
+// lengthEpsilon is the tolerance when comparing lengths.
+const lengthEpsilon = 0.002
+
+// withLength returns the notes with notated lengths that add up to the length.
+func withLength(n core.Note, length float32) (list []core.Note) {
+	for _, each := range lengths {
+		for length >= each.length-lengthEpsilon {
+			list = append(list, n.WithFraction(each.fraction, each.dotted))
+			length -= each.length
+		}
+	}
+	if len(list) == 0 {
+		// shorter than a 16th
+		list = append(list, n.WithFraction(0.0625, false))
+	}
+	return
+}
+
+// tied returns the first note with the others tied to it.
+func tied(list []core.Note) core.Note {
+	first := list[0]
+	for _, each := range list[1:] {
+		first = first.WithTiedNote(each)
+	}
+	return first
+}
+
+// parseMeter returns the length of a bar, e.g. 3/4, C (common time) or C| (cut time).
+func parseMeter(value string) (float32, error) {
+	switch value {
+	case "", "none", "C", "C|":
+		return 1, nil
+	}
+	return parseFraction(value)
+}
+
+// parseFraction returns the value of e.g. 1/8 or 2+3/8 (as in a meter).
+func parseFraction(value string) (float32, error) {
+	numerator, denominator, _ := strings.Cut(strings.Fields(value + " ")[0], "/")
+	sum := 0
+	for _, each := range strings.Split(numerator, "+") {
+		n, err := strconv.Atoi(each)
+		if err != nil {
+			return 0, fmt.Errorf("invalid fraction %q", value)
+		}
+		sum += n
+	}
+	d := 1
+	if len(denominator) > 0 {
+		v, err := strconv.Atoi(denominator)
+		if err != nil || v == 0 {
+			return 0, fmt.Errorf("invalid fraction %q", value)
+		}
+		d = v
+	}
+	return float32(sum) / float32(d), nil
+}
+
+// naturalFifths is the position of each natural note on the circle of fifths, relative to C.
+var naturalFifths = map[byte]int{'F': -1, 'C': 0, 'G': 1, 'D': 2, 'A': 3, 'E': 4, 'B': 5}
+
+// modeFifths is the shift on the circle of fifths of each mode, relative to major (ionian).
+var modeFifths = map[string]int{
+	"": 0, "maj": 0, "ion": 0,
+	"m": -3, "min": -3, "aeo": -3,
+	"dor": -2, "phr": -4, "lyd": 1, "mix": -1, "loc": -5,
+}
+
+// parseKey returns the accidental of each note name for a key such as G, Dm, Bb, F#m or A dorian.
+func parseKey(value string) (map[string]int, error) {
+	key := map[string]int{}
+	fields := strings.Fields(value)
+	if len(fields) == 0 || fields[0] == "none" || strings.HasPrefix(fields[0], "clef=") {
+		return key, nil
+	}
+	root := fields[0]
+	fifths, ok := naturalFifths[root[0]]
+	if !ok {
+		return key, fmt.Errorf("invalid key %q", value)
+	}
+	mode := root[1:]
+	if strings.HasPrefix(mode, "#") {
+		fifths += 7
+		mode = mode[1:]
+	} else if strings.HasPrefix(mode, "b") {
+		fifths -= 7
+		mode = mode[1:]
+	}
+	if len(mode) == 0 && len(fields) > 1 && !strings.Contains(fields[1], "=") {
+		mode = fields[1]
+	}
+	mode = strings.ToLower(mode)
+	if len(mode) > 3 {
+		mode = mode[:3]
+	}
+	shift, ok := modeFifths[mode]
+	if !ok {
+		return key, fmt.Errorf("invalid mode in key %q", value)
+	}
+	fifths += shift
+	if fifths < -7 || fifths > 7 {
+		return key, fmt.Errorf("unsupported key %q", value)
+	}
+	for i := 0; i < fifths; i++ {
+		key[string("FCGDAEB"[i])] = 1
+	}
+	for i := 0; i < -fifths; i++ {
+		key[string("BEADGCF"[i])] = -1
+	}
+	return key, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
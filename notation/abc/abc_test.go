@@ -0,0 +1,81 @@
+package abc
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, each := range []struct {
+		abc, want string
+	}{
+		{"CDEF", "8C 8D 8E 8F"},
+		{"L:1/4\nK:C\nC2 D/ E3 z", "2C 8D 2.E ="},
+		{"L:1/4\nK:C\nc C, c'", "C5 C3 C6"},
+		{"L:1/4\nK:G\nF ^F =F | F", "F# F# F F#"},
+		{"L:1/4\nK:C\n^F F | F _B __B", "F# F# F B_ A"},
+		{"L:1/4\nK:Dm\nB [CEG]2", "B_ (2C 2E 2G)"},
+		{"L:1/8\nK:C\nA>B c<d", "8.A 16B 16C5 8.D5"},
+		{"L:1/4\nK:C\nC5", "1C~C"},
+		{"L:1/4\nK:C\nC2-C D", "2C~C D"},
+		{"L:1/4\nK:C\nz5", "1= ="},
+		{"L:1/4\nK:C\n|: C D :| E", "C D C D E"},
+		{"L:1/4\nK:C\n|: C |1 D :|2 E |]", "C D C E"},
+		{"L:1/4\nK:C\n|: C [1 D :| [2 E |]", "C D C E"},
+		{"L:1/4\nK:C\nC :: D :|", "C C D D"},
+		{"L:1/4\nK:C\n\"Am\"!trill!~C {g}D (3EFG", "C D E F G"},
+		{"M:3/4\nL:1/4\nK:C\nZ2 C", "1= 2= C"},
+		{"L:1/4\nK:A dorian\nF c % comment", "F# C5"},
+		{"X:1\nT:Tune\nL:1/4\nK:C\nC\n\nX:2\nT:Other\nK:C\nD", "C"},
+	} {
+		s, err := Parse(each.abc)
+		if err != nil {
+			t.Errorf("%q: %v", each.abc, err)
+			continue
+		}
+		if got, want := s.String(), each.want; got != want {
+			t.Errorf("%q: got [%v:%T] want [%v:%T]", each.abc, got, got, want, want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, each := range []string{
+		"",
+		"K:H",
+		"K:C\nC [DE",
+		"K:C\nC ? D",
+		"L:1/x\nK:C\nC",
+	} {
+		if _, err := Parse(each); err == nil {
+			t.Errorf("%q: error expected", each)
+		}
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	for _, each := range []struct {
+		key  string
+		want int // number of sharps (> 0) or flats (< 0)
+	}{
+		{"C", 0},
+		{"G", 1},
+		{"Bb", -2},
+		{"F#m", 3},
+		{"Ebmaj", -3},
+		{"D mix", 1},
+		{"E phrygian", 0},
+		{"none", 0},
+	} {
+		k, err := parseKey(each.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := 0
+		for _, a := range k {
+			got += a
+		}
+		if want := each.want; got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each.key, got, got, want, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package abc
+
+// scanner reads the characters of a line of a tune body.
+type scanner struct {
+	line string
+	pos  int
+}
+
+func (s *scanner) atEnd() bool {
+	return s.pos >= len(s.line)
+}
+
+// peek returns the current character or 0 at the end.
+func (s *scanner) peek() byte {
+	return s.peekAt(0)
+}
+
+// peekAt returns the character at an offset from the current or 0 if beyond the end.
+func (s *scanner) peekAt(offset int) byte {
+	if s.pos+offset >= len(s.line) {
+		return 0
+	}
+	return s.line[s.pos+offset]
+}
+
+func (s *scanner) next() byte {
+	c := s.peek()
+	s.pos++
+	return c
+}
+
+// skipTo reads the current character and all up to and including the closing character.
+func (s *scanner) skipTo(closing byte) string {
+	start := s.pos
+	s.next()
+	for !s.atEnd() && s.next() != closing {
+	}
+	if s.pos > len(s.line) {
+		s.pos = len(s.line)
+	}
+	return s.line[start:s.pos]
+}
+
+// digits reads a number ; it returns 0 if there are no digits.
+func (s *scanner) digits() int {
+	n := 0
+	for isDigit(s.peek()) {
+		n = n*10 + int(s.next()-'0')
+	}
+	return n
+}
+
+// length reads a length multiplier such as 2, /2, /, //, 3/2 ; it returns 1 if there is none.
+func (s *scanner) length() float32 {
+	numerator := s.digits()
+	if numerator == 0 {
+		numerator = 1
+	}
+	denominator := 1
+	for s.peek() == '/' {
+		s.next()
+		if d := s.digits(); d > 0 {
+			denominator *= d
+		} else {
+			denominator *= 2
+		}
+	}
+	return float32(numerator) / float32(denominator)
+}
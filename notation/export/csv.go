@@ -0,0 +1,64 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// csvHeader names the columns written by WriteCSV ; start and duration are in quarter notes (beats).
+var csvHeader = []string{"start", "duration", "number", "velocity", "channel"}
+
+// ExportCSV creates (overwrites) a CSV file with a row for each note event.
+func ExportCSV(fileName string, m interface{}, bpm float64, biab int) error {
+	out, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	notify.Infof("exporting CSV note events to [%s] ...", fileName)
+	return WriteCSV(out, m, bpm, biab)
+}
+
+// WriteCSV writes a row for each hearable note of each track with its start, duration, MIDI number, velocity and channel.
+// The channel is 1 if the object has none.
+func WriteCSV(w io.Writer, m interface{}, bpm float64, biab int) error {
+	staves, err := stavesOf(m, biab)
+	if err != nil {
+		return err
+	}
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, each := range staves {
+		channel := each.channel
+		if channel == 0 {
+			channel = 1
+		}
+		for _, row := range csvRowsOf(each.notes, channel) {
+			if err := out.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func csvRowsOf(s core.Sequence, channel int) (rows [][]string) {
+	for _, each := range jsonNotesOf(s) {
+		rows = append(rows, []string{
+			strconv.FormatFloat(each.Start, 'f', -1, 64),
+			strconv.FormatFloat(each.Duration, 'f', -1, 64),
+			strconv.Itoa(each.Number),
+			strconv.Itoa(each.Velocity),
+			strconv.Itoa(channel),
+		})
+	}
+	return
+}
@@ -0,0 +1,24 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	seq := core.NewChannelSelector(core.MustParseSequence("8C = (E G)"), core.On(2))
+	if err := WriteCSV(&b, seq, 120, 4); err != nil {
+		t.Fatal(err)
+	}
+	want := `start,duration,number,velocity,channel
+0,0.5,60,59,2
+1.5,1,64,59,2
+1.5,1,67,59,2
+`
+	if got := b.String(); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,308 @@
+package export
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// Dimensions of an engraving, in pixels.
+const (
+	engraveSpace          = 10.0 // between two staff lines
+	engraveMargin         = 20.0
+	engraveRoom           = 40.0 // above and below a staff for ledger lines and chord symbols
+	engraveSystemHeight   = engraveRoom + 4*engraveSpace + engraveRoom
+	engraveTitleHeight    = 20.0
+	engraveClefWidth      = 36.0
+	engraveTimeWidth      = 24.0
+	engraveMeasurePadding = 10.0
+	engraveBarsPerSystem  = 4
+)
+
+// canvas is what an engraving is drawn on ; it has black ink on white paper.
+type canvas interface {
+	line(x1, y1, x2, y2, width float64)
+	curve(x1, y1, cx, cy, x2, y2, width float64) // quadratic
+	head(x, y float64, filled bool)              // note head
+	dot(x, y, r float64)
+	rect(x, y, w, h float64)
+	text(x, y, size float64, s string) // size is ignored if the canvas has a fixed font
+}
+
+// system is a staff with a number of measures on one line.
+type system struct {
+	top      float64 // of the top staff line
+	title    string  // only for the first system of a staff
+	clef     string
+	withTime bool // only for the first system of a staff
+	measures []measure
+	last     bool // last system of a staff
+}
+
+// Engrave creates (overwrites) an image file with staff notation ; a PNG if the extension is .png, an SVG otherwise.
+func Engrave(fileName string, m interface{}, biab int) error {
+	out, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	notify.Infof("engraving staff notation to [%s] ...", fileName)
+	if strings.EqualFold(filepath.Ext(fileName), ".png") {
+		return WritePNG(out, m, biab)
+	}
+	return WriteSVG(out, m, biab)
+}
+
+// engraveLayout returns the systems of all staves and the size of the engraving.
+func engraveLayout(staves []staff, biab int) (list []system, width, height float64) {
+	y := engraveMargin
+	for _, each := range staves {
+		if len(each.title) > 0 {
+			y += engraveTitleHeight
+		}
+		clef := clefOf(each.notes)
+		measures := each.measures(biab)
+		for i := 0; i == 0 || i < len(measures); i += engraveBarsPerSystem {
+			end := i + engraveBarsPerSystem
+			if end > len(measures) {
+				end = len(measures)
+			}
+			sys := system{
+				top:      y + engraveRoom,
+				clef:     clef,
+				withTime: i == 0,
+				measures: measures[i:end],
+				last:     end == len(measures),
+			}
+			if i == 0 {
+				sys.title = each.title
+			}
+			if w := sys.width(); w > width {
+				width = w
+			}
+			list = append(list, sys)
+			y += engraveSystemHeight
+		}
+	}
+	return list, width + 2*engraveMargin, y + engraveMargin
+}
+
+func (s system) width() float64 {
+	w := s.clefWidth()
+	for _, each := range s.measures {
+		w += measureWidth(each)
+	}
+	return w
+}
+
+func (s system) clefWidth() float64 {
+	if s.withTime {
+		return engraveClefWidth + engraveTimeWidth
+	}
+	return engraveClefWidth
+}
+
+func measureWidth(m measure) float64 {
+	w := engraveMeasurePadding
+	for _, each := range m.groups {
+		w += groupWidth(each)
+	}
+	return w
+}
+
+// groupWidth is the horizontal room of a rest, note or chord ; longer notes take more room.
+func groupWidth(n notated) float64 {
+	w := 14 + 80*float64(n.value.fraction)
+	for _, each := range n.notes {
+		if each.Accidental != 0 {
+			return w + 10
+		}
+	}
+	return w
+}
+
+// draw engraves the systems on the canvas.
+func drawSystems(c canvas, list []system, biab int) {
+	for _, each := range list {
+		each.draw(c, biab)
+	}
+}
+
+func (s system) draw(c canvas, biab int) {
+	x := engraveMargin
+	if len(s.title) > 0 {
+		c.text(x, s.top-engraveRoom-6, 14, s.title)
+	}
+	end := x + s.width()
+	for i := 0; i < 5; i++ {
+		y := s.top + float64(i)*engraveSpace
+		c.line(x, y, end, y, 1)
+	}
+	c.line(x, s.top, x, s.top+4*engraveSpace, 1)
+	drawClef(c, s.clef, x+14, s.top)
+	if s.withTime {
+		tx := x + engraveClefWidth
+		c.text(tx, s.top+2*engraveSpace-2, 2*engraveSpace, strconv.Itoa(biab))
+		c.text(tx, s.top+4*engraveSpace-2, 2*engraveSpace, "4")
+	}
+	x += s.clefWidth()
+	for m, each := range s.measures {
+		x += engraveMeasurePadding
+		for _, group := range each.groups {
+			w := groupWidth(group)
+			drawGroup(c, s, group, x+w/2-6, w)
+			x += w
+		}
+		if each.complete || (s.last && m == len(s.measures)-1) {
+			c.line(x, s.top, x, s.top+4*engraveSpace, 1)
+		}
+	}
+}
+
+// diatonic returns the number of the staff position of a note ; C0 is zero.
+func diatonic(n core.Note) int {
+	return n.Octave*7 + strings.Index("CDEFGAB", n.Name)
+}
+
+// bottomLine returns the diatonic number of the bottom staff line: E4 for treble and G2 for bass.
+func bottomLine(clef string) int {
+	if clef == "bass" {
+		return 2*7 + 4
+	}
+	return 4*7 + 2
+}
+
+// drawGroup draws a rest, note or chord with its head(s) at x ; advance is the room until the next group.
+func drawGroup(c canvas, s system, n notated, x, advance float64) {
+	bottom := s.top + 4*engraveSpace
+	if len(n.symbol) > 0 {
+		c.text(x-6, s.top-engraveRoom+12, 12, n.symbol)
+	}
+	if len(n.notes) == 0 {
+		drawRest(c, n.value, x, s.top)
+		return
+	}
+	ref := bottomLine(s.clef)
+	notes := append([]core.Note{}, n.notes...)
+	sort.Slice(notes, func(i, j int) bool { return diatonic(notes[i]) < diatonic(notes[j]) })
+	yOf := func(d int) float64 { return bottom - float64(d-ref)*engraveSpace/2 }
+	low, high := diatonic(notes[0]), diatonic(notes[len(notes)-1])
+	// ledger lines
+	for d := ref - 2; d >= low; d -= 2 {
+		c.line(x-9, yOf(d), x+9, yOf(d), 1)
+	}
+	for d := ref + 10; d <= high; d += 2 {
+		c.line(x-9, yOf(d), x+9, yOf(d), 1)
+	}
+	filled := n.value.base <= 0.25
+	previous, displaced := -100, false
+	for _, each := range notes {
+		d := diatonic(each)
+		y := yOf(d)
+		hx := x
+		// a second in a chord is put next to the lower note
+		displaced = d-previous == 1 && !displaced
+		if displaced {
+			hx += 11
+		}
+		previous = d
+		c.head(hx, y, filled)
+		switch each.Accidental {
+		case 1:
+			c.text(x-17, y+4, 14, "#")
+		case -1:
+			c.text(x-16, y+4, 14, "b")
+		}
+		if n.value.dotted {
+			dy := 0.0
+			if (d-ref)%2 == 0 {
+				dy = -engraveSpace / 2 // on a line
+			}
+			c.dot(hx+9, y+dy, 1.5)
+		}
+		if n.tieStart {
+			c.curve(hx+6, y+6, hx+advance/2, y+12, hx+advance-6, y+6, 1)
+		}
+	}
+	if n.value.base >= 1 {
+		return
+	}
+	// stem and flags
+	flags := 0
+	switch n.value.base {
+	case 0.125:
+		flags = 1
+	case 0.0625:
+		flags = 2
+	case 0.03125:
+		flags = 3
+	}
+	if low+high < 2*(ref+4) {
+		sx, end := x+5, yOf(high)-3.5*engraveSpace
+		c.line(sx, yOf(low), sx, end, 1.2)
+		for i := 0; i < flags; i++ {
+			fy := end + float64(i)*6
+			c.line(sx, fy, sx+7, fy+10, 2)
+		}
+		return
+	}
+	sx, end := x-5, yOf(low)+3.5*engraveSpace
+	c.line(sx, yOf(high), sx, end, 1.2)
+	for i := 0; i < flags; i++ {
+		fy := end - float64(i)*6
+		c.line(sx, fy, sx+7, fy-10, 2)
+	}
+}
+
+// drawRest draws a rest of a notated duration around x.
+func drawRest(c canvas, v noteValue, x, top float64) {
+	s := engraveSpace
+	switch v.base {
+	case 1:
+		c.rect(x-6, top+s, 12, s/2)
+	case 0.5:
+		c.rect(x-6, top+1.5*s, 12, s/2)
+	case 0.25:
+		c.line(x-3, top+s, x+3, top+1.8*s, 2)
+		c.line(x+3, top+1.8*s, x-3, top+2.4*s, 2)
+		c.line(x-3, top+2.4*s, x+3, top+3*s, 2)
+		c.curve(x+3, top+3*s, x-6, top+3.1*s, x-1, top+3.7*s, 2)
+	default:
+		flags := int(math.Round(math.Log2(0.125 / float64(v.base))))
+		c.line(x+4, top+1.5*s, x-1, top+3.5*s, 1.2)
+		for i := 0; i <= flags; i++ {
+			y := top + 1.5*s + float64(i)*s*0.8
+			c.dot(x-2, y, 2)
+			c.curve(x-2, y+1, x+1, y+2, x+4-float64(i)*1.2, y-1, 1)
+		}
+	}
+	if v.dotted {
+		c.dot(x+9, top+1.5*s, 1.5)
+	}
+}
+
+// drawClef draws a simplified treble or bass clef at x.
+func drawClef(c canvas, clef string, x, top float64) {
+	s := engraveSpace
+	if clef == "bass" {
+		// curls around the F line
+		c.dot(x-2, top+s, 3)
+		c.curve(x-2, top+s, x+4, top-0.6*s, x+11, top+1.2*s, 2)
+		c.curve(x+11, top+1.2*s, x+12, top+3*s, x-3, top+4*s, 2)
+		c.dot(x+16, top+0.5*s, 1.5)
+		c.dot(x+16, top+1.5*s, 1.5)
+		return
+	}
+	// curls around the G line
+	c.line(x, top-1.2*s, x, top+5.2*s, 1.5)
+	c.curve(x, top-1.2*s, x+10, top+0.5*s, x-6, top+2.6*s, 2)
+	c.curve(x-6, top+2.6*s, x-8, top+4.4*s, x+3, top+4.2*s, 2)
+	c.curve(x+3, top+4.2*s, x+10, top+3.2*s, x, top+2.8*s, 2)
+	c.dot(x-3, top+5.2*s, 2.5)
+}
@@ -0,0 +1,67 @@
+package export
+
+import (
+	"io"
+
+	"github.com/fogleman/gg"
+)
+
+// WritePNG writes staff notation as a PNG image, see WriteSVG. Text is written in a small fixed font.
+func WritePNG(w io.Writer, m interface{}, biab int) error {
+	staves, err := stavesOf(m, biab)
+	if err != nil {
+		return err
+	}
+	systems, width, height := engraveLayout(staves, biab)
+	gc := gg.NewContext(int(width), int(height))
+	gc.SetRGB(1, 1, 1)
+	gc.Clear()
+	gc.SetRGB(0, 0, 0)
+	drawSystems(pngCanvas{gc}, systems, biab)
+	return gc.EncodePNG(w)
+}
+
+// pngCanvas draws on a graphics context.
+type pngCanvas struct {
+	gc *gg.Context
+}
+
+func (p pngCanvas) line(x1, y1, x2, y2, width float64) {
+	p.gc.SetLineWidth(width)
+	p.gc.DrawLine(x1, y1, x2, y2)
+	p.gc.Stroke()
+}
+
+func (p pngCanvas) curve(x1, y1, cx, cy, x2, y2, width float64) {
+	p.gc.SetLineWidth(width)
+	p.gc.MoveTo(x1, y1)
+	p.gc.QuadraticTo(cx, cy, x2, y2)
+	p.gc.Stroke()
+}
+
+func (p pngCanvas) head(x, y float64, filled bool) {
+	p.gc.Push()
+	defer p.gc.Pop()
+	p.gc.RotateAbout(gg.Radians(-20), x, y)
+	p.gc.DrawEllipse(x, y, 5.5, 4)
+	if filled {
+		p.gc.Fill()
+		return
+	}
+	p.gc.SetLineWidth(1.5)
+	p.gc.Stroke()
+}
+
+func (p pngCanvas) dot(x, y, r float64) {
+	p.gc.DrawCircle(x, y, r)
+	p.gc.Fill()
+}
+
+func (p pngCanvas) rect(x, y, w, h float64) {
+	p.gc.DrawRectangle(x, y, w, h)
+	p.gc.Fill()
+}
+
+func (p pngCanvas) text(x, y, size float64, t string) {
+	p.gc.DrawString(t, x, y)
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// WriteSVG writes staff notation as an SVG image with a staff for each track, in systems of 4 bars of BIAB quarter notes.
+func WriteSVG(w io.Writer, m interface{}, biab int) error {
+	staves, err := stavesOf(m, biab)
+	if err != nil {
+		return err
+	}
+	systems, width, height := engraveLayout(staves, biab)
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f">`+"\n", width, height, width, height)
+	fmt.Fprintf(b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	drawSystems(svgCanvas{b}, systems, biab)
+	fmt.Fprintln(b, "</svg>")
+	return b.Flush()
+}
+
+// svgCanvas writes SVG elements.
+type svgCanvas struct {
+	w io.Writer
+}
+
+func (s svgCanvas) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(s.w, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black" stroke-width="%.1f"/>`+"\n", x1, y1, x2, y2, width)
+}
+
+func (s svgCanvas) curve(x1, y1, cx, cy, x2, y2, width float64) {
+	fmt.Fprintf(s.w, `<path d="M %.1f %.1f Q %.1f %.1f %.1f %.1f" fill="none" stroke="black" stroke-width="%.1f"/>`+"\n", x1, y1, cx, cy, x2, y2, width)
+}
+
+func (s svgCanvas) head(x, y float64, filled bool) {
+	paint := `fill="black"`
+	if !filled {
+		paint = `fill="none" stroke="black" stroke-width="1.5"`
+	}
+	fmt.Fprintf(s.w, `<ellipse cx="%.1f" cy="%.1f" rx="5.5" ry="4" transform="rotate(-20 %.1f %.1f)" %s/>`+"\n", x, y, x, y, paint)
+}
+
+func (s svgCanvas) dot(x, y, r float64) {
+	fmt.Fprintf(s.w, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="black"/>`+"\n", x, y, r)
+}
+
+func (s svgCanvas) rect(x, y, w, h float64) {
+	fmt.Fprintf(s.w, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="black"/>`+"\n", x, y, w, h)
+}
+
+func (s svgCanvas) text(x, y, size float64, t string) {
+	fmt.Fprintf(s.w, `<text x="%.1f" y="%.1f" font-family="serif" font-size="%.0f">`, x, y, size)
+	xml.EscapeText(s.w, []byte(t))
+	fmt.Fprintln(s.w, "</text>")
+}
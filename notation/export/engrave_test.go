@@ -0,0 +1,58 @@
+package export
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestWriteSVG(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteSVG(&b, core.MustParseSequence("C E G C5 2G"), 4); err != nil {
+		t.Fatal(err)
+	}
+	svg := b.String()
+	if got, want := strings.Count(svg, "<ellipse"), 5; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// 5 staff lines, the left edge, the clef, a ledger line for C, 5 stems and 2 bar lines
+	if got, want := strings.Count(svg, "<line"), 15; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// hollow half note
+	if got, want := strings.Count(svg, `fill="none" stroke="black" stroke-width="1.5"/>`), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestWriteSVG_AccidentalAndTitle(t *testing.T) {
+	tr := core.NewTrack("lead", 1)
+	tr.Add(core.NewSequenceOnTrack(core.On(1), core.MustParseSequence("C# 8E_ 8E")))
+	var b bytes.Buffer
+	if err := WriteSVG(&b, tr, 4); err != nil {
+		t.Fatal(err)
+	}
+	svg := b.String()
+	for _, each := range []string{">lead</text>", ">#</text>", ">b</text>"} {
+		if !strings.Contains(svg, each) {
+			t.Errorf("missing %s in %s", each, svg)
+		}
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	var b bytes.Buffer
+	if err := WritePNG(&b, core.MustParseSequence("C3 E3 G3 2C3"), 3); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.Bounds().Dy(), int(2*engraveMargin+engraveSystemHeight); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// jsonScore is the document written by WriteJSON.
+type jsonScore struct {
+	BPM    float64     `json:"bpm"`
+	BIAB   int         `json:"biab"`
+	Tracks []jsonTrack `json:"tracks"`
+}
+
+type jsonTrack struct {
+	Title string     `json:"title,omitempty"`
+	Notes []jsonNote `json:"notes"`
+}
+
+// jsonNote is a hearable note ; start and duration are in quarter notes (beats).
+type jsonNote struct {
+	Note     string  `json:"note"`
+	Number   int     `json:"number"`
+	Start    float64 `json:"start"`
+	Duration float64 `json:"duration"`
+	Velocity int     `json:"velocity"`
+}
+
+// ExportJSON creates (overwrites) a JSON file with the notes of each track.
+func ExportJSON(fileName string, m interface{}, bpm float64, biab int) error {
+	out, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	notify.Infof("exporting JSON notes to [%s] ...", fileName)
+	return WriteJSON(out, m, bpm, biab)
+}
+
+// WriteJSON writes the hearable notes of each track with their MIDI number, start, duration and velocity.
+func WriteJSON(w io.Writer, m interface{}, bpm float64, biab int) error {
+	staves, err := stavesOf(m, biab)
+	if err != nil {
+		return err
+	}
+	score := jsonScore{BPM: bpm, BIAB: biab, Tracks: []jsonTrack{}}
+	for _, each := range staves {
+		score.Tracks = append(score.Tracks, jsonTrack{Title: each.title, Notes: jsonNotesOf(each.notes)})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(score)
+}
+
+func jsonNotesOf(s core.Sequence) []jsonNote {
+	list := []jsonNote{}
+	position := float64(0)
+	for _, group := range s.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		for _, each := range hearableNotes(group) {
+			list = append(list, jsonNote{
+				Note:     each.String(),
+				Number:   each.MIDI(),
+				Start:    position,
+				Duration: float64(each.DurationFactor()) * 4,
+				Velocity: each.Velocity,
+			})
+		}
+		position += float64(group[0].DurationFactor()) * 4
+	}
+	return list
+}
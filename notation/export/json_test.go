@@ -0,0 +1,36 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestWriteJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteJSON(&b, core.MustParseSequence("8C = (E G)"), 120, 4); err != nil {
+		t.Fatal(err)
+	}
+	var score jsonScore
+	if err := json.Unmarshal(b.Bytes(), &score); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(score.Tracks), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	notes := score.Tracks[0].Notes
+	if got, want := len(notes), 3; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := notes[0].Duration, 0.5; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := notes[2].Start, 1.5; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := notes[2].Number, 67; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,134 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// lilyVersion is the LilyPond version of the generated source.
+const lilyVersion = "2.24.0"
+
+// ExportLilyPond creates (overwrites) a LilyPond source file with a staff for each track.
+func ExportLilyPond(fileName string, m interface{}, bpm float64, biab int) error {
+	out, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	notify.Infof("exporting LilyPond score to [%s] ...", fileName)
+	return WriteLilyPond(out, m, bpm, biab)
+}
+
+// WriteLilyPond writes LilyPond source with pitches, durations, ties and bars of BIAB quarter notes.
+// For chord sequences and progressions the chord symbols are printed above the notes.
+func WriteLilyPond(w io.Writer, m interface{}, bpm float64, biab int) error {
+	staves, err := stavesOf(m, biab)
+	if err != nil {
+		return err
+	}
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "\\version \"%s\"\n\n", lilyVersion)
+	fmt.Fprintln(b, "\\score {")
+	fmt.Fprintln(b, "  <<")
+	for _, each := range staves {
+		writeLilyStaff(b, each, bpm, biab)
+	}
+	fmt.Fprintln(b, "  >>")
+	fmt.Fprintln(b, "  \\layout { }")
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+func writeLilyStaff(w io.Writer, s staff, bpm float64, biab int) {
+	fmt.Fprint(w, "    \\new Staff ")
+	if len(s.title) > 0 {
+		fmt.Fprintf(w, "\\with { instrumentName = \"%s\" } ", strings.ReplaceAll(s.title, `"`, `'`))
+	}
+	fmt.Fprintln(w, "{")
+	fmt.Fprintf(w, "      \\clef %s\n", clefOf(s.notes))
+	fmt.Fprintf(w, "      \\time %d/4\n", biab)
+	fmt.Fprintf(w, "      \\tempo 4 = %d\n", int(bpm))
+	for _, each := range s.measures(biab) {
+		fmt.Fprint(w, "     ")
+		for _, group := range each.groups {
+			fmt.Fprintf(w, " %s%s", lilyPitches(group.notes), lilyDuration(group.value))
+			if len(group.symbol) > 0 {
+				fmt.Fprintf(w, "^\"%s\"", group.symbol)
+			}
+			if group.tieStart {
+				fmt.Fprint(w, "~")
+			}
+		}
+		if each.complete {
+			fmt.Fprint(w, " |")
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "    }")
+}
+
+// clefOf returns bass if most notes are below middle C.
+func clefOf(s core.Sequence) string {
+	low, high := 0, 0
+	for _, group := range s.Notes {
+		for _, each := range hearableNotes(group) {
+			if each.MIDI() < 60 {
+				low++
+			} else {
+				high++
+			}
+		}
+	}
+	if low > high {
+		return "bass"
+	}
+	return "treble"
+}
+
+// lilyPitches returns a rest, a pitch or a chord of pitches.
+func lilyPitches(notes []core.Note) string {
+	if len(notes) == 0 {
+		return "r"
+	}
+	if len(notes) == 1 {
+		return lilyPitch(notes[0])
+	}
+	list := []string{}
+	for _, each := range notes {
+		list = append(list, lilyPitch(each))
+	}
+	return "<" + strings.Join(list, " ") + ">"
+}
+
+// lilyPitch returns the pitch in absolute mode, e.g. fis' is F#4 and bes, is B_2.
+func lilyPitch(n core.Note) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(n.Name))
+	switch n.Accidental {
+	case 1:
+		b.WriteString("is")
+	case -1:
+		b.WriteString("es")
+	}
+	// c is C3
+	if marks := n.Octave - 3; marks > 0 {
+		b.WriteString(strings.Repeat("'", marks))
+	} else if marks < 0 {
+		b.WriteString(strings.Repeat(",", -marks))
+	}
+	return b.String()
+}
+
+func lilyDuration(v noteValue) string {
+	d := fmt.Sprintf("%d", int(1/v.base+0.5))
+	if v.dotted {
+		d += "."
+	}
+	return d
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestWriteLilyPond(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteLilyPond(&b, core.MustParseSequence("c d e_ f# (2c 2e 2g) 8= 8b3"), 120, 4); err != nil {
+		t.Fatal(err)
+	}
+	src := b.String()
+	for _, want := range []string{
+		`\version "2.24.0"`,
+		`\time 4/4`,
+		`\tempo 4 = 120`,
+		" c'4 d'4 ees'4 fis'4 |",
+		" <c' e' g'>2 r8 b8",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("missing %q in\n%s", want, src)
+		}
+	}
+}
+
+func TestWriteLilyPond_TieOverBar(t *testing.T) {
+	var b bytes.Buffer
+	WriteLilyPond(&b, core.MustParseSequence("2.c 2d"), 120, 4)
+	if want := " c'2. d'4~ |\n      d'4\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("missing %q in\n%s", want, b.String())
+	}
+}
+
+func TestWriteLilyPond_Unsupported(t *testing.T) {
+	if err := WriteLilyPond(new(bytes.Buffer), 42, 120, 4); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestLilyPitch(t *testing.T) {
+	for _, each := range []struct {
+		note, want string
+	}{
+		{"c", "c'"},
+		{"c3", "c"},
+		{"b_2", "bes,"},
+		{"f#5", "fis''"},
+	} {
+		if got := lilyPitch(core.MustParseNote(each.note)); got != each.want {
+			t.Errorf("got [%v] want [%v]", got, each.want)
+		}
+	}
+}
+
+func TestSplitDuration(t *testing.T) {
+	if got, want := len(splitDuration(1.25)), 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := splitDuration(0.375)[0].dotted, true; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestWriteLilyPond_ChordSymbols(t *testing.T) {
+	var b bytes.Buffer
+	WriteLilyPond(&b, core.MustParseChordSequence("1a/m7/g 1c/1"), 120, 4)
+	for _, want := range []string{
+		` <g' a' c'' e'' g''>1^"Am7/G" |`,
+		` <e' g' c''>1^"C/E" |`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("missing %q in\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// xmlVersion is the MusicXML version of the generated document.
+const xmlVersion = "4.0"
+
+// xmlDivisions is the number of divisions of a quarter note ; a 32th note is the shortest.
+const xmlDivisions = 8
+
+const xmlDoctype = `<!DOCTYPE score-partwise PUBLIC "-//Recordare//DTD MusicXML 4.0 Partwise//EN" "http://www.musicxml.org/dtds/partwise.dtd">`
+
+type xmlScore struct {
+	XMLName  xml.Name       `xml:"score-partwise"`
+	Version  string         `xml:"version,attr"`
+	PartList []xmlScorePart `xml:"part-list>score-part"`
+	Parts    []xmlPart      `xml:"part"`
+}
+
+type xmlScorePart struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"part-name"`
+}
+
+type xmlPart struct {
+	ID       string       `xml:"id,attr"`
+	Measures []xmlMeasure `xml:"measure"`
+}
+
+type xmlMeasure struct {
+	Number     string         `xml:"number,attr"`
+	Attributes *xmlAttributes `xml:"attributes"`
+	Direction  *xmlDirection  `xml:"direction"`
+	Notes      []xmlNote      `xml:"note"`
+}
+
+type xmlAttributes struct {
+	Divisions int      `xml:"divisions,omitempty"`
+	Time      *xmlTime `xml:"time"`
+	Clef      *xmlClef `xml:"clef"`
+}
+
+type xmlTime struct {
+	Beats    string `xml:"beats"`
+	BeatType string `xml:"beat-type"`
+}
+
+type xmlClef struct {
+	Sign string `xml:"sign"`
+	Line int    `xml:"line"`
+}
+
+type xmlDirection struct {
+	Placement string        `xml:"placement,attr,omitempty"`
+	Metronome *xmlMetronome `xml:"direction-type>metronome"`
+	Sound     *xmlSound     `xml:"sound"`
+}
+
+type xmlMetronome struct {
+	BeatUnit  string `xml:"beat-unit"`
+	PerMinute string `xml:"per-minute"`
+}
+
+type xmlSound struct {
+	Tempo string `xml:"tempo,attr,omitempty"`
+}
+
+type xmlNote struct {
+	Grace     *struct{}     `xml:"grace"`
+	Chord     *struct{}     `xml:"chord"`
+	Pitch     *xmlPitch     `xml:"pitch"`
+	Rest      *struct{}     `xml:"rest"`
+	Duration  int           `xml:"duration"`
+	Ties      []xmlTie      `xml:"tie"`
+	Voice     string        `xml:"voice,omitempty"`
+	Type      string        `xml:"type,omitempty"`
+	Dots      []struct{}    `xml:"dot"`
+	Notations *xmlNotations `xml:"notations"`
+}
+
+type xmlNotations struct {
+	Tied []xmlTie `xml:"tied"`
+}
+
+type xmlPitch struct {
+	Step   string `xml:"step"`
+	Alter  int    `xml:"alter,omitempty"`
+	Octave int    `xml:"octave"`
+}
+
+type xmlTie struct {
+	Type string `xml:"type,attr"`
+}
+
+// tieStop returns whether the note is tied to the previous one.
+func (n xmlNote) tieStop() bool {
+	ties := n.Ties
+	if n.Notations != nil {
+		ties = append(ties, n.Notations.Tied...)
+	}
+	for _, each := range ties {
+		if each.Type == "stop" {
+			return true
+		}
+	}
+	return false
+}
+
+// xmlTypes maps the notated (undotted) duration to the MusicXML note type.
+var xmlTypes = map[float32]string{
+	1:       "whole",
+	0.5:     "half",
+	0.25:    "quarter",
+	0.125:   "eighth",
+	0.0625:  "16th",
+	0.03125: "32nd",
+}
+
+// ExportMusicXML creates (overwrites) a MusicXML file with a part for each track.
+func ExportMusicXML(fileName string, m interface{}, bpm float64, biab int) error {
+	out, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	notify.Infof("exporting MusicXML score to [%s] ...", fileName)
+	return WriteMusicXML(out, m, bpm, biab)
+}
+
+// WriteMusicXML writes a partwise MusicXML document with pitches, durations, ties and bars of BIAB quarter notes.
+func WriteMusicXML(w io.Writer, m interface{}, bpm float64, biab int) error {
+	staves, err := stavesOf(m, biab)
+	if err != nil {
+		return err
+	}
+	score := xmlScore{Version: xmlVersion}
+	for i, each := range staves {
+		id := fmt.Sprintf("P%d", i+1)
+		name := each.title
+		if len(name) == 0 {
+			name = fmt.Sprintf("Part %d", i+1)
+		}
+		score.PartList = append(score.PartList, xmlScorePart{ID: id, Name: name})
+		score.Parts = append(score.Parts, xmlPartOf(id, each, bpm, biab, i == 0))
+	}
+	b := bufio.NewWriter(w)
+	io.WriteString(b, xml.Header)
+	fmt.Fprintln(b, xmlDoctype)
+	enc := xml.NewEncoder(b)
+	enc.Indent("", "  ")
+	if err := enc.Encode(score); err != nil {
+		return err
+	}
+	fmt.Fprintln(b)
+	return b.Flush()
+}
+
+// xmlPartOf returns the measures of a staff ; the first measure has the attributes and, if withTempo, the tempo.
+func xmlPartOf(id string, s staff, bpm float64, biab int, withTempo bool) xmlPart {
+	measures := s.measures(biab)
+	if len(measures) == 0 {
+		measures = append(measures, measure{})
+	}
+	part := xmlPart{ID: id}
+	for i, each := range measures {
+		xm := xmlMeasure{Number: strconv.Itoa(i + 1)}
+		if i == 0 {
+			clef := &xmlClef{Sign: "G", Line: 2}
+			if clefOf(s.notes) == "bass" {
+				clef = &xmlClef{Sign: "F", Line: 4}
+			}
+			xm.Attributes = &xmlAttributes{
+				Divisions: xmlDivisions,
+				Time:      &xmlTime{Beats: strconv.Itoa(biab), BeatType: "4"},
+				Clef:      clef,
+			}
+			if withTempo {
+				tempo := strconv.Itoa(int(bpm))
+				xm.Direction = &xmlDirection{
+					Placement: "above",
+					Metronome: &xmlMetronome{BeatUnit: "quarter", PerMinute: tempo},
+					Sound:     &xmlSound{Tempo: tempo},
+				}
+			}
+		}
+		for _, group := range each.groups {
+			xm.Notes = append(xm.Notes, xmlNotesOf(group)...)
+		}
+		part.Measures = append(part.Measures, xm)
+	}
+	return part
+}
+
+// xmlNotesOf returns a rest, a note or the notes of a chord.
+func xmlNotesOf(group notated) []xmlNote {
+	note := xmlNote{
+		Duration: int(group.value.fraction*4*xmlDivisions + 0.5),
+		Voice:    "1",
+		Type:     xmlTypes[group.value.base],
+	}
+	if group.value.dotted {
+		note.Dots = []struct{}{{}}
+	}
+	if len(group.notes) == 0 {
+		note.Rest = &struct{}{}
+		return []xmlNote{note}
+	}
+	if group.tieStop {
+		note.Ties = append(note.Ties, xmlTie{Type: "stop"})
+	}
+	if group.tieStart {
+		note.Ties = append(note.Ties, xmlTie{Type: "start"})
+	}
+	if len(note.Ties) > 0 {
+		note.Notations = &xmlNotations{Tied: note.Ties}
+	}
+	list := []xmlNote{}
+	for i, each := range group.notes {
+		pitched := note
+		if i > 0 {
+			pitched.Chord = &struct{}{}
+		}
+		pitched.Pitch = &xmlPitch{Step: each.Name, Alter: each.Accidental, Octave: each.Octave}
+		list = append(list, pitched)
+	}
+	return list
+}
+
+// ImportMusicXML reads a partwise MusicXML file, see ReadMusicXML.
+func ImportMusicXML(fileName string) (interface{}, error) {
+	in, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return ReadMusicXML(in)
+}
+
+// ReadMusicXML returns a sequence for a single part or a multi-track with a track for each part.
+// Only the first voice of each part is read ; grace notes are skipped.
+func ReadMusicXML(r io.Reader) (interface{}, error) {
+	var score xmlScore
+	if err := xml.NewDecoder(r).Decode(&score); err != nil {
+		return nil, fmt.Errorf("invalid MusicXML (only partwise is supported): %v", err)
+	}
+	if len(score.Parts) == 0 {
+		return nil, fmt.Errorf("MusicXML has no parts")
+	}
+	if len(score.Parts) == 1 {
+		return sequenceOfPart(score.Parts[0]), nil
+	}
+	names := map[string]string{}
+	for _, each := range score.PartList {
+		names[each.ID] = each.Name
+	}
+	mt := core.MultiTrack{}
+	for i, each := range score.Parts {
+		// MIDI has 16 channels
+		t := core.NewTrack(names[each.ID], i%16+1)
+		t.Content[1] = sequenceOfPart(each)
+		mt.Tracks = append(mt.Tracks, core.On(t))
+	}
+	return mt, nil
+}
+
+// sequenceOfPart returns the notes of the first voice ; tied notes are joined.
+func sequenceOfPart(p xmlPart) core.Sequence {
+	divisions := 1
+	voice := ""
+	groups := [][]core.Note{}
+	tied := false // the notes of the current group are tied to the previous group
+	for _, m := range p.Measures {
+		if m.Attributes != nil && m.Attributes.Divisions > 0 {
+			divisions = m.Attributes.Divisions
+		}
+		for _, each := range m.Notes {
+			if each.Grace != nil {
+				continue
+			}
+			if len(voice) == 0 {
+				voice = each.Voice
+			}
+			if each.Voice != voice {
+				continue
+			}
+			note := noteOf(each, float32(each.Duration)/float32(divisions*4))
+			if each.Chord == nil || len(groups) == 0 {
+				tied = each.tieStop() && len(groups) > 0 && tieInto(groups[len(groups)-1], note)
+				if !tied {
+					groups = append(groups, []core.Note{note})
+				}
+				continue
+			}
+			last := len(groups) - 1
+			if tied && each.tieStop() && tieInto(groups[last], note) {
+				continue
+			}
+			groups[last] = append(groups[last], note)
+		}
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// tieInto ties the note to the note with the same pitch in the group, if any.
+func tieInto(group []core.Note, n core.Note) bool {
+	for i, each := range group {
+		if each.IsHearable() && each.MIDI() == n.MIDI() {
+			group[i] = each.WithTiedNote(n)
+			return true
+		}
+	}
+	return false
+}
+
+// noteOf returns the rest or note with a length in whole note fractions.
+func noteOf(n xmlNote, length float32) core.Note {
+	fraction, dotted := length, false
+	for _, each := range noteValues {
+		if abs32(each.fraction-length) < durationEpsilon {
+			fraction, dotted = each.base, each.dotted
+			break
+		}
+	}
+	if n.Pitch == nil {
+		return core.Rest4.WithFraction(fraction, dotted)
+	}
+	if n.Pitch.Alter < -1 || n.Pitch.Alter > 1 {
+		// double flats and sharps are respelled
+		natural := core.MakeNote(n.Pitch.Step, n.Pitch.Octave, fraction, 0, dotted, core.Normal)
+		return natural.Pitched(n.Pitch.Alter)
+	}
+	return core.MakeNote(n.Pitch.Step, n.Pitch.Octave, fraction, n.Pitch.Alter, dotted, core.Normal)
+}
+
+func abs32(f float32) float32 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestWriteMusicXML(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteMusicXML(&b, core.MustParseSequence("c e_ (c e) 2.g 8="), 120, 4); err != nil {
+		t.Fatal(err)
+	}
+	src := b.String()
+	for _, want := range []string{
+		`<score-partwise version="4.0">`,
+		`<divisions>8</divisions>`,
+		`<per-minute>120</per-minute>`,
+		`<measure number="2">`,
+		`<alter>-1</alter>`,
+		`<chord></chord>`,
+		`<tie type="start"></tie>`,
+		`<rest></rest>`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("missing %q in\n%s", want, src)
+		}
+	}
+}
+
+func TestMusicXML_Roundtrip(t *testing.T) {
+	for _, each := range []struct {
+		notes, want string
+	}{
+		{"c d e_ f#", "C D E_ F#"},
+		{"(c e g) = 2.g3", "(C E G) = 2G3~G3"}, // tied over the bar
+		{"2.c 8e 16f 16b_2", "2.C 8E 16F 16B_2"},
+		{"1c 1c", "1C 1C"}, // not tied
+	} {
+		var b bytes.Buffer
+		if err := WriteMusicXML(&b, core.MustParseSequence(each.notes), 120, 4); err != nil {
+			t.Fatal(err)
+		}
+		v, err := ReadMusicXML(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v.(core.Sequence).String(), each.want; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	}
+}
+
+func TestReadMusicXML_Tracks(t *testing.T) {
+	mt := core.MultiTrack{Tracks: []core.HasValue{
+		core.On(trackWith("right", "c d")),
+		core.On(trackWith("left", "c3")),
+	}}
+	var b bytes.Buffer
+	if err := WriteMusicXML(&b, mt, 120, 4); err != nil {
+		t.Fatal(err)
+	}
+	v, err := ReadMusicXML(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, ok := v.(core.MultiTrack)
+	if !ok {
+		t.Fatalf("got %T", v)
+	}
+	left := read.Tracks[1].Value().(*core.Track)
+	if got, want := left.Title, "left"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := left.Channel, 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := left.Content[1].S().String(), "C3"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestReadMusicXML_TiedAndVoices(t *testing.T) {
+	src := `<score-partwise version="3.1"><part id="P1">
+	<measure number="1"><attributes><divisions>2</divisions></attributes>
+		<note><grace/><pitch><step>B</step><octave>4</octave></pitch><voice>1</voice></note>
+		<note><pitch><step>C</step><octave>5</octave></pitch><duration>4</duration><tie type="start"/><voice>1</voice></note>
+		<note><pitch><step>D</step><alter>2</alter><octave>4</octave></pitch><duration>8</duration><voice>2</voice></note>
+	</measure>
+	<measure number="2">
+		<note><pitch><step>C</step><octave>5</octave></pitch><duration>4</duration><tie type="stop"/><voice>1</voice></note>
+		<note><pitch><step>D</step><alter>2</alter><octave>4</octave></pitch><duration>1</duration><voice>1</voice></note>
+	</measure></part></score-partwise>`
+	v, err := ReadMusicXML(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := v.(core.Sequence)
+	if got, want := len(s.Notes), 2; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := s.Notes[0][0].DurationFactor(), float32(1); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := s.Notes[1][0].String(), "8E"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestReadMusicXML_Invalid(t *testing.T) {
+	if _, err := ReadMusicXML(strings.NewReader(`<score-timewise/>`)); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func trackWith(title, notes string) *core.Track {
+	t := core.NewTrack(title, 1)
+	t.Content[1] = core.MustParseSequence(notes)
+	return t
+}
@@ -0,0 +1,192 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/op"
+)
+
+// staff is the music of one part in a score.
+type staff struct {
+	title   string
+	channel int // 0 if not known
+	notes   core.Sequence
+	symbols []string // if set then the chord symbol for each group of notes, see chordSymbols
+}
+
+// stavesOf returns a staff for each track of a multi-track, for a track or for a sequenceable.
+func stavesOf(m interface{}, biab int) ([]staff, error) {
+	switch v := m.(type) {
+	case core.MultiTrack:
+		list := []staff{}
+		for i, each := range v.Tracks {
+			t, ok := each.Value().(*core.Track)
+			if !ok {
+				return nil, fmt.Errorf("multi track contains non-track at [%d] (%T)", i+1, each.Value())
+			}
+			list = append(list, staff{title: t.Title, channel: t.Channel, notes: sequenceFromTrack(t, biab)})
+		}
+		return list, nil
+	case *core.Track:
+		return []staff{{title: v.Title, channel: v.Channel, notes: sequenceFromTrack(v, biab)}}, nil
+	case core.ChordSequence:
+		return []staff{{notes: v.S(), symbols: chordSymbols(v.Chords)}}, nil
+	case core.ChordProgression:
+		groups := [][]core.Chord{}
+		for _, each := range v.C() {
+			groups = append(groups, []core.Chord{each})
+		}
+		return []staff{{notes: v.S(), symbols: chordSymbols(groups)}}, nil
+	case *core.Loop:
+		return []staff{{notes: v.ToSequence(1)}}, nil
+	case core.ChannelSelector:
+		return []staff{{channel: v.Channel(), notes: v.S()}}, nil
+	case core.Sequenceable:
+		return []staff{{notes: v.S()}}, nil
+	}
+	return nil, fmt.Errorf("cannot export a (%T)", m)
+}
+
+// chordSymbols returns a symbol for each group of chords ; parallel chords are separated by a space.
+func chordSymbols(groups [][]core.Chord) []string {
+	list := []string{}
+	for _, group := range groups {
+		names := []string{}
+		for _, each := range group {
+			names = append(names, each.Symbol())
+		}
+		list = append(list, strings.Join(names, " "))
+	}
+	return list
+}
+
+// sequenceFromTrack merges the pieces of a track, each starting at its bar.
+func sequenceFromTrack(t *core.Track, biab int) core.Sequence {
+	target := []core.Sequenceable{}
+	for bar := range t.Content {
+		target = append(target, core.RestSequence(bar-1, biab).SequenceJoin(t.SequenceAt(bar, biab)))
+	}
+	merged := op.Merge{Target: target}.S()
+	if t.Tempo > 0 && t.Tempo != 1 {
+		// in bars of the control
+		return merged.Stretched(float32(1 / t.Tempo))
+	}
+	return merged
+}
+
+// durationEpsilon is the tolerance when comparing durations in whole note fractions.
+const durationEpsilon = 0.002
+
+// noteValue is a notated duration as a fraction of a whole note.
+type noteValue struct {
+	fraction float32
+	base     float32 // without the dot
+	dotted   bool
+}
+
+// noteValues are the notated durations from long to short.
+var noteValues = []noteValue{
+	{1, 1, false},
+	{0.75, 0.5, true},
+	{0.5, 0.5, false},
+	{0.375, 0.25, true},
+	{0.25, 0.25, false},
+	{0.1875, 0.125, true},
+	{0.125, 0.125, false},
+	{0.09375, 0.0625, true},
+	{0.0625, 0.0625, false},
+	{0.03125, 0.03125, false},
+}
+
+// splitDuration returns the notated durations that add up to a length ; they must be tied.
+// Lengths shorter than a 32th note are ignored.
+func splitDuration(length float32) (list []noteValue) {
+	for _, each := range noteValues {
+		for length >= each.fraction-durationEpsilon {
+			list = append(list, each)
+			length -= each.fraction
+		}
+	}
+	return
+}
+
+// notated is a rest, a note or a chord with a notated duration.
+type notated struct {
+	notes    []core.Note // empty for a rest
+	value    noteValue
+	tieStart bool   // tied to the next
+	tieStop  bool   // tied to the previous
+	symbol   string // chord symbol, if any
+}
+
+// measure is a bar of notated groups.
+type measure struct {
+	groups   []notated
+	complete bool // false if the music ends before the bar line
+}
+
+// measures returns the music of the staff in bars of BIAB quarter notes.
+// Groups are split into notated durations ; groups that cross a bar line are tied.
+func (s staff) measures(biab int) []measure {
+	bar := float32(biab) / 4
+	position := float32(0)
+	list := []measure{}
+	current := measure{}
+	for g, group := range s.notes.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		notes := hearableNotes(group)
+		if len(notes) == 0 && !group[0].IsRest() {
+			continue // pedal changes
+		}
+		symbol := ""
+		if g < len(s.symbols) {
+			symbol = s.symbols[g]
+		}
+		length := group[0].DurationFactor()
+		tied := false
+		for length > durationEpsilon {
+			part := length
+			if room := bar - position; part > room {
+				part = room
+			}
+			values := splitDuration(part)
+			for i, each := range values {
+				current.groups = append(current.groups, notated{
+					notes:    notes,
+					value:    each,
+					tieStart: len(notes) > 0 && (i < len(values)-1 || length-part > durationEpsilon),
+					tieStop:  len(notes) > 0 && tied,
+					symbol:   symbol,
+				})
+				symbol = "" // only at the first
+				tied = true
+			}
+			length -= part
+			position += part
+			if position >= bar-durationEpsilon {
+				current.complete = true
+				list = append(list, current)
+				current = measure{}
+				position = 0
+			}
+		}
+	}
+	if len(current.groups) > 0 {
+		list = append(list, current)
+	}
+	return list
+}
+
+// hearableNotes returns the notes of a group that are not rests or pedal changes.
+func hearableNotes(group []core.Note) (list []core.Note) {
+	for _, each := range group {
+		if each.IsHearable() {
+			list = append(list, each)
+		}
+	}
+	return
+}
@@ -0,0 +1,193 @@
+package hydrogen
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// http://hydrogen-music.org
+
+// DrumChannel is the General MIDI channel for percussion.
+const DrumChannel = 10
+
+// ticksPerWhole is the resolution of Hydrogen: 48 ticks for a quarter note.
+const ticksPerWhole = 192
+
+// firstMIDINote is the MIDI note of the first instrument if it has none ; Hydrogen uses 36 + its index.
+const firstMIDINote = 36
+
+// song is a .h2song or a .h2pattern document ; the root element is not checked.
+type song struct {
+	Instruments []instrument `xml:"instrumentList>instrument"`
+	Patterns    []pattern    `xml:"patternList>pattern"`
+	Pattern     []pattern    `xml:"pattern"` // .h2pattern
+}
+
+type instrument struct {
+	ID          int    `xml:"id"`
+	Name        string `xml:"name"`
+	MIDIOutNote *int   `xml:"midiOutNote"`
+}
+
+type pattern struct {
+	Name        string `xml:"name"`
+	PatternName string `xml:"pattern_name"` // older .h2pattern
+	Size        int    `xml:"size"`
+	Notes       []note `xml:"noteList>note"`
+}
+
+func (p pattern) title() string {
+	if len(p.Name) > 0 {
+		return p.Name
+	}
+	return p.PatternName
+}
+
+type note struct {
+	Position   int     `xml:"position"`
+	Velocity   float64 `xml:"velocity"`
+	Instrument int     `xml:"instrument"`
+}
+
+// ParseFile reads a Hydrogen song or pattern file, see Read.
+func ParseFile(fileName string, selector interface{}) (core.Sequenceable, error) {
+	in, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return Read(in, selector)
+}
+
+// Read returns the notes of a drum pattern on the drum channel.
+// The pattern is selected by its (1-based) index or by its name ; nil selects the first pattern.
+// The notes of the instruments are their MIDI out notes. Each step has the longest length, down to a 32nd, that fits all notes of the pattern.
+func Read(r io.Reader, selector interface{}) (core.Sequenceable, error) {
+	var doc song
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid Hydrogen XML: %v", err)
+	}
+	patterns := append(doc.Patterns, doc.Pattern...)
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("Hydrogen XML has no patterns")
+	}
+	p, err := selectPattern(patterns, selector)
+	if err != nil {
+		return nil, err
+	}
+	midi := map[int]int{}
+	for _, each := range doc.Instruments {
+		if each.MIDIOutNote != nil {
+			midi[each.ID] = *each.MIDIOutNote
+		}
+	}
+	seq, err := sequenceOfPattern(p, midi)
+	if err != nil {
+		return nil, fmt.Errorf("pattern [%s]: %v", p.title(), err)
+	}
+	return core.NewChannelSelector(seq, core.On(DrumChannel)), nil
+}
+
+func selectPattern(patterns []pattern, selector interface{}) (pattern, error) {
+	switch v := selector.(type) {
+	case nil:
+		return patterns[0], nil
+	case int:
+		if v < 1 || v > len(patterns) {
+			return pattern{}, fmt.Errorf("no pattern at index [%d], there are [%d]", v, len(patterns))
+		}
+		return patterns[v-1], nil
+	case string:
+		for _, each := range patterns {
+			if each.title() == v {
+				return each, nil
+			}
+		}
+		return pattern{}, fmt.Errorf("no pattern with name [%s]", v)
+	}
+	return pattern{}, fmt.Errorf("pattern must be selected by index or name, got (%T) %v", selector, selector)
+}
+
+// sequenceOfPattern returns a group of notes, or a rest, for each step of the pattern.
+func sequenceOfPattern(p pattern, midi map[int]int) (core.Sequence, error) {
+	size := p.Size
+	if size <= 0 {
+		size = ticksPerWhole
+	}
+	step := stepTicks(p, size)
+	fraction := float32(step) / ticksPerWhole
+	groups := make([]map[int]core.Note, size/step)
+	for _, each := range p.Notes {
+		// round to the nearest step
+		i := int(math.Round(float64(each.Position) / float64(step)))
+		if i < 0 || i >= len(groups) {
+			continue
+		}
+		nr, ok := midi[each.Instrument]
+		if !ok {
+			nr = firstMIDINote + each.Instrument
+		}
+		n, err := core.MIDItoNote(fraction, nr, velocityOf(each.Velocity))
+		if err != nil {
+			return core.Sequence{}, err
+		}
+		if groups[i] == nil {
+			groups[i] = map[int]core.Note{}
+		}
+		groups[i][nr] = n
+	}
+	notes := [][]core.Note{}
+	for _, each := range groups {
+		if len(each) == 0 {
+			notes = append(notes, []core.Note{core.Rest4.WithFraction(fraction, false)})
+			continue
+		}
+		nrs := []int{}
+		for nr := range each {
+			nrs = append(nrs, nr)
+		}
+		sort.Ints(nrs)
+		group := []core.Note{}
+		for _, nr := range nrs {
+			group = append(group, each[nr])
+		}
+		notes = append(notes, group)
+	}
+	return core.Sequence{Notes: notes}, nil
+}
+
+// stepTicks returns the longest step, a quarter down to a 32nd, on which the size and all notes fall.
+func stepTicks(p pattern, size int) int {
+	step := ticksPerWhole / 4
+	for ; step > ticksPerWhole/32; step /= 2 {
+		fits := size%step == 0
+		for _, each := range p.Notes {
+			if each.Position%step != 0 {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			break
+		}
+	}
+	return step
+}
+
+// velocityOf returns the MIDI velocity of a Hydrogen velocity [0..1].
+func velocityOf(v float64) int {
+	vel := int(math.Round(v * 127))
+	if vel < 1 {
+		return 1
+	}
+	if vel > 127 {
+		return 127
+	}
+	return vel
+}
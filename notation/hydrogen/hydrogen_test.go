@@ -0,0 +1,79 @@
+package hydrogen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+const testSong = `<?xml version="1.0" encoding="UTF-8"?>
+<song>
+ <bpm>120</bpm>
+ <instrumentList>
+  <instrument><id>0</id><name>Kick</name><midiOutNote>36</midiOutNote></instrument>
+  <instrument><id>1</id><name>Snare</name><midiOutNote>38</midiOutNote></instrument>
+  <instrument><id>2</id><name>Hat</name><midiOutNote>42</midiOutNote></instrument>
+ </instrumentList>
+ <patternList>
+  <pattern>
+   <name>beat</name>
+   <size>192</size>
+   <noteList>
+    <note><position>0</position><velocity>0.8</velocity><instrument>0</instrument></note>
+    <note><position>0</position><velocity>0.8</velocity><instrument>2</instrument></note>
+    <note><position>48</position><velocity>1</velocity><instrument>1</instrument></note>
+    <note><position>96</position><velocity>0.8</velocity><instrument>0</instrument></note>
+   </noteList>
+  </pattern>
+  <pattern>
+   <name>hats</name>
+   <size>96</size>
+   <noteList>
+    <note><position>0</position><velocity>0.5</velocity><instrument>2</instrument></note>
+    <note><position>36</position><velocity>0.5</velocity><instrument>2</instrument></note>
+   </noteList>
+  </pattern>
+ </patternList>
+</song>`
+
+func TestReadFirstPattern(t *testing.T) {
+	s, err := Read(strings.NewReader(testSong), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.(core.ChannelSelector).Channel(), 10; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.Storex(s.S()), "sequence('(C2:v102 G_2:v102) D2+++++ C2:v102 =')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestReadPatternByName(t *testing.T) {
+	s, err := Read(strings.NewReader(testSong), "hats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 16th steps
+	if got, want := len(s.S().Notes), 8; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := Read(strings.NewReader(testSong), 3); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestReadPatternFile(t *testing.T) {
+	h2pattern := `<drumkit_pattern><pattern><pattern_name>one</pattern_name><size>48</size>
+<noteList><note><position>0</position><velocity>0.8</velocity><instrument>1</instrument></note></noteList>
+</pattern></drumkit_pattern>`
+	s, err := Read(strings.NewReader(h2pattern), "one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// no instrument list, 36 + 1
+	if got, want := s.S().Notes[0][0].MIDI(), 37; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
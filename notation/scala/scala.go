@@ -0,0 +1,240 @@
+package scala
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// https://www.huygens-fokker.org/scala/scl_format.html
+
+// Scale is the content of a Scala (.scl) file.
+type Scale struct {
+	Description string
+	// Cents of each degree relative to the first (0) ; the last is the period, usually the octave (1200).
+	Cents []float64
+}
+
+// Keyboard is the content of a Scala keyboard mapping (.kbm) file.
+type Keyboard struct {
+	First, Last int     // MIDI notes that are retuned
+	Middle      int     // MIDI note of the first degree of the scale
+	Reference   int     // MIDI note with the reference frequency
+	Frequency   float64 // Hz
+	Octave      int     // scale degree of the formal octave
+	// Mapping has a degree for each key in a pattern of keys starting at Middle ; -1 means not mapped.
+	// If empty then each next key is the next degree.
+	Mapping []int
+}
+
+// DefaultKeyboard maps middle C to the first degree and A4 to 440 Hz.
+func DefaultKeyboard(s Scale) Keyboard {
+	return Keyboard{First: 0, Last: 127, Middle: 60, Reference: 69, Frequency: 440, Octave: len(s.Cents)}
+}
+
+// Tuning is a Scale mapped on keys.
+type Tuning struct {
+	Scale    Scale
+	Keyboard Keyboard
+	offset   float64 // cents of the reference key
+}
+
+// NewTuning returns a Tuning ; the reference key must be mapped.
+func NewTuning(s Scale, k Keyboard) (*Tuning, error) {
+	if len(s.Cents) == 0 {
+		return nil, errors.New("scale has no degrees")
+	}
+	if k.Octave <= 0 {
+		// the period of the scale
+		k.Octave = len(s.Cents)
+	}
+	t := &Tuning{Scale: s, Keyboard: k}
+	ref, ok := t.cents(k.Reference)
+	if !ok {
+		return nil, fmt.Errorf("reference key %d is not mapped", k.Reference)
+	}
+	t.offset = ref
+	return t, nil
+}
+
+// Frequency returns the frequency in Hz of a MIDI note ; false if the key is not retuned.
+func (t *Tuning) Frequency(key int) (float64, bool) {
+	if key < t.Keyboard.First || key > t.Keyboard.Last {
+		return 0, false
+	}
+	c, ok := t.cents(key)
+	if !ok {
+		return 0, false
+	}
+	return t.Keyboard.Frequency * math.Pow(2, (c-t.offset)/1200), true
+}
+
+// cents returns the pitch of a key relative to the first degree at the middle key.
+func (t *Tuning) cents(key int) (float64, bool) {
+	d := key - t.Keyboard.Middle
+	degree := d
+	if size := len(t.Keyboard.Mapping); size > 0 {
+		octaves, i := floorDiv(d, size)
+		entry := t.Keyboard.Mapping[i]
+		if entry < 0 {
+			return 0, false
+		}
+		degree = octaves*t.Keyboard.Octave + entry
+	}
+	n := len(t.Scale.Cents)
+	periods, i := floorDiv(degree, n)
+	c := float64(periods) * t.Scale.Cents[n-1]
+	if i > 0 {
+		c += t.Scale.Cents[i-1]
+	}
+	return c, true
+}
+
+// floorDiv returns the floored quotient and the non-negative remainder.
+func floorDiv(a, b int) (int, int) {
+	q, r := a/b, a%b
+	if r < 0 {
+		q--
+		r += b
+	}
+	return q, r
+}
+
+// ReadScaleFile reads a .scl file, see ReadScale.
+func ReadScaleFile(fileName string) (Scale, error) {
+	in, err := os.Open(fileName)
+	if err != nil {
+		return Scale{}, err
+	}
+	defer in.Close()
+	return ReadScale(in)
+}
+
+// ReadScale reads a description, the number of notes and a pitch for each note, in cents (with a period) or as a ratio.
+func ReadScale(r io.Reader) (Scale, error) {
+	lines, err := contentLines(r, true)
+	if err != nil {
+		return Scale{}, err
+	}
+	if len(lines) < 2 {
+		return Scale{}, errors.New("missing description or number of notes")
+	}
+	s := Scale{Description: strings.TrimSpace(lines[0])}
+	count, err := strconv.Atoi(firstField(lines[1]))
+	if err != nil {
+		return s, fmt.Errorf("invalid number of notes: %v", err)
+	}
+	if count < 1 || len(lines)-2 < count {
+		return s, fmt.Errorf("expected %d notes, got %d", count, len(lines)-2)
+	}
+	for _, each := range lines[2 : 2+count] {
+		c, err := parsePitch(firstField(each))
+		if err != nil {
+			return s, err
+		}
+		s.Cents = append(s.Cents, c)
+	}
+	return s, nil
+}
+
+// parsePitch returns the cents of a pitch such as 150.0, 3/2 or 2.
+func parsePitch(p string) (float64, error) {
+	if strings.Contains(p, ".") {
+		c, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cents %q", p)
+		}
+		return c, nil
+	}
+	num, den := p, "1"
+	if i := strings.Index(p, "/"); i != -1 {
+		num, den = p[:i], p[i+1:]
+	}
+	n, err1 := strconv.ParseFloat(num, 64)
+	d, err2 := strconv.ParseFloat(den, 64)
+	if err1 != nil || err2 != nil || n <= 0 || d <= 0 {
+		return 0, fmt.Errorf("invalid ratio %q", p)
+	}
+	return 1200 * math.Log2(n/d), nil
+}
+
+// ReadKeyboardFile reads a .kbm file, see ReadKeyboard.
+func ReadKeyboardFile(fileName string) (Keyboard, error) {
+	in, err := os.Open(fileName)
+	if err != nil {
+		return Keyboard{}, err
+	}
+	defer in.Close()
+	return ReadKeyboard(in)
+}
+
+// ReadKeyboard reads the size of the mapping, the first, last, middle and reference keys,
+// the reference frequency, the degree of the octave and the mapping in which x is a key that is not mapped.
+func ReadKeyboard(r io.Reader) (Keyboard, error) {
+	lines, err := contentLines(r, false)
+	if err != nil {
+		return Keyboard{}, err
+	}
+	if len(lines) < 7 {
+		return Keyboard{}, fmt.Errorf("expected at least 7 lines, got %d", len(lines))
+	}
+	ints := make([]int, 7)
+	for i, each := range []int{0, 1, 2, 3, 4, 6} {
+		v, err := strconv.Atoi(firstField(lines[each]))
+		if err != nil {
+			return Keyboard{}, fmt.Errorf("invalid line %d: %v", each+1, err)
+		}
+		ints[i] = v
+	}
+	freq, err := strconv.ParseFloat(firstField(lines[5]), 64)
+	if err != nil || freq <= 0 {
+		return Keyboard{}, fmt.Errorf("invalid reference frequency %q", lines[5])
+	}
+	size := ints[0]
+	k := Keyboard{First: ints[1], Last: ints[2], Middle: ints[3], Reference: ints[4], Frequency: freq, Octave: ints[5]}
+	if len(lines)-7 < size {
+		return k, fmt.Errorf("expected %d mapping entries, got %d", size, len(lines)-7)
+	}
+	for _, each := range lines[7 : 7+size] {
+		f := firstField(each)
+		if f == "x" {
+			k.Mapping = append(k.Mapping, -1)
+			continue
+		}
+		degree, err := strconv.Atoi(f)
+		if err != nil {
+			return k, fmt.Errorf("invalid mapping entry %q", f)
+		}
+		k.Mapping = append(k.Mapping, degree)
+	}
+	return k, nil
+}
+
+// contentLines returns the lines that are not comments ; empty lines are kept if the description can be empty.
+func contentLines(r io.Reader, keepEmpty bool) (lines []string, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		if len(strings.TrimSpace(line)) == 0 && (!keepEmpty || len(lines) > 0) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func firstField(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
@@ -0,0 +1,124 @@
+package scala
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const justScale = `! just.scl
+!
+5-limit just intonation
+ 12
+!
+ 16/15
+ 9/8
+ 6/5
+ 5/4
+ 4/3
+ 45/32
+ 3/2
+ 8/5
+ 5/3
+ 9/5
+ 15/8
+ 2/1
+`
+
+func TestReadScale(t *testing.T) {
+	s, err := ReadScale(strings.NewReader(justScale))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Description, "5-limit just intonation"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := len(s.Cents), 12; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := s.Cents[11], 1200.0; math.Abs(got-want) > 1e-9 {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestParsePitch(t *testing.T) {
+	for _, each := range []struct {
+		pitch string
+		want  float64
+	}{
+		{"150.0", 150},
+		{"3/2", 701.955},
+		{"2", 1200},
+	} {
+		got, err := parsePitch(each.pitch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-each.want) > 0.001 {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each.pitch, got, got, each.want, each.want)
+		}
+	}
+	if _, err := parsePitch("3/x"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestTuningFrequency(t *testing.T) {
+	s, _ := ReadScale(strings.NewReader(justScale))
+	tun, err := NewTuning(s, DefaultKeyboard(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A4 is the reference
+	if got, _ := tun.Frequency(69); math.Abs(got-440) > 1e-9 {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, 440.0, 440.0)
+	}
+	// E4 is a just third above C4 = 440 / (5/3)
+	c4, _ := tun.Frequency(60)
+	e4, _ := tun.Frequency(64)
+	if got, want := e4/c4, 1.25; math.Abs(got-want) > 1e-9 {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// one octave lower
+	c3, _ := tun.Frequency(48)
+	if got, want := c4/c3, 2.0; math.Abs(got-want) > 1e-9 {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestReadKeyboard(t *testing.T) {
+	kbm := `! white keys only
+7
+0
+127
+60
+69
+440.0
+12
+0
+x
+2
+x
+4
+5
+x
+`
+	k, err := ReadKeyboard(strings.NewReader(kbm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(k.Mapping), 7; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := k.Mapping[1], -1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	s, _ := ReadScale(strings.NewReader(justScale))
+	tun, err := NewTuning(s, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tun.Frequency(61); ok {
+		t.Error("unmapped key expected")
+	}
+}
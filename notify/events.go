@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Kinds of Event
+const (
+	NoteEventKind    = "note"
+	MessageEventKind = "message"
+)
+
+// Event is something that happened, such as a played note or a printed message, for user interfaces to show.
+type Event struct {
+	Kind string      `json:"kind"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// MessageData is the data of a message event.
+type MessageData struct {
+	Level string `json:"level"` // info, warning or error
+	Text  string `json:"text"`
+}
+
+// NoteData is the data of a note event ; a note on or off that is sent to a device.
+type NoteData struct {
+	Device   int   `json:"device"`
+	Channel  int   `json:"channel"`
+	Number   int64 `json:"number"`
+	Velocity int64 `json:"velocity"`
+	On       bool  `json:"on"`
+}
+
+// eventBufferSize is the number of events a subscriber can fall behind ; newer events are dropped for it.
+const eventBufferSize = 256
+
+var (
+	subscribersMutex sync.Mutex
+	subscribers      = map[chan Event]bool{}
+	subscriberCount  int32
+)
+
+// Subscribe returns a channel that receives all published events and a function to stop receiving.
+func Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBufferSize)
+	subscribersMutex.Lock()
+	subscribers[ch] = true
+	atomic.AddInt32(&subscriberCount, 1)
+	subscribersMutex.Unlock()
+	return ch, func() {
+		subscribersMutex.Lock()
+		defer subscribersMutex.Unlock()
+		if subscribers[ch] {
+			delete(subscribers, ch)
+			atomic.AddInt32(&subscriberCount, -1)
+			close(ch)
+		}
+	}
+}
+
+// HasSubscribers returns whether there are subscribers ; use it to avoid creating events that nobody receives.
+func HasSubscribers() bool {
+	return atomic.LoadInt32(&subscriberCount) > 0
+}
+
+// Publish sends the event to all subscribers ; it never blocks.
+func Publish(kind string, data interface{}) {
+	if !HasSubscribers() {
+		return
+	}
+	e := Event{Kind: kind, Time: time.Now(), Data: data}
+	subscribersMutex.Lock()
+	defer subscribersMutex.Unlock()
+	for each := range subscribers {
+		select {
+		case each <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package notify
+
+import "testing"
+
+func TestPublishSubscribe(t *testing.T) {
+	Publish(MessageEventKind, "nobody")
+	events, stop := Subscribe()
+	if !HasSubscribers() {
+		t.Error("subscribers expected")
+	}
+	Publish(MessageEventKind, "hello")
+	e := <-events
+	if got, want := e.Data, "hello"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	stop()
+	stop()
+	if HasSubscribers() {
+		t.Error("no subscribers expected")
+	}
+	if _, ok := <-events; ok {
+		t.Error("closed channel expected")
+	}
+}
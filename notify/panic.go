@@ -1,7 +0,0 @@
-package notify
-
-var Panic = RuntimePanic
-
-func RuntimePanic(err error) error {
-	panic(err)
-}
@@ -78,10 +78,12 @@ func Errorf(format string, args ...interface{}) {
 }
 
 func printInfo(args ...interface{}) {
+	Publish(MessageEventKind, MessageData{Level: "info", Text: fmt.Sprint(args...)})
 	fmt.Fprintf(Console.StandardOut, "%s\n", args...)
 }
 
 func printError(args ...interface{}) {
+	Publish(MessageEventKind, MessageData{Level: "error", Text: fmt.Sprint(args...)})
 	if ansiColorsEnabled {
 		Println(append([]interface{}{"\033[1;31merror:\033[0m"}, args...)...)
 	} else {
@@ -90,6 +92,7 @@ func printError(args ...interface{}) {
 }
 
 func printWarning(args ...interface{}) {
+	Publish(MessageEventKind, MessageData{Level: "warning", Text: fmt.Sprint(args...)})
 	if ansiColorsEnabled {
 		Println(append([]interface{}{"\033[1;33mwarning:\033[0m"}, args...)...)
 	} else {
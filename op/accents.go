@@ -0,0 +1,105 @@
+package op
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// Accents raises the velocity of the groups of notes on the marked steps of a pattern such as '1 0 0 1'.
+// The pattern repeats over the groups ; rests take a step but are not changed.
+type Accents struct {
+	pattern []bool
+	amount  core.HasValue
+	target  core.HasValue
+}
+
+func NewAccents(pattern string, amount, target core.HasValue) Accents {
+	return Accents{pattern: parseAccentPattern(pattern), amount: amount, target: target}
+}
+
+// CheckAccentPattern returns an error if the pattern has steps other than 1 and 0 or no steps.
+func CheckAccentPattern(pattern string) error {
+	steps := strings.Fields(pattern)
+	if len(steps) == 0 {
+		return fmt.Errorf("accent pattern must have steps, got [%s]", pattern)
+	}
+	for _, each := range steps {
+		if each != "0" && each != "1" {
+			return fmt.Errorf("accent pattern step must be 1 or 0, got [%s]", each)
+		}
+	}
+	return nil
+}
+
+func parseAccentPattern(pattern string) (marks []bool) {
+	for _, each := range strings.Fields(pattern) {
+		marks = append(marks, each == "1")
+	}
+	return
+}
+
+// S is part of core.Sequenceable
+func (a Accents) S() core.Sequence {
+	seq := core.ToSequenceable(a.target).S()
+	if len(a.pattern) == 0 {
+		return seq
+	}
+	amount := core.Int(a.amount)
+	groups := [][]core.Note{}
+	for i, group := range seq.Notes {
+		if !a.pattern[i%len(a.pattern)] {
+			groups = append(groups, group)
+			continue
+		}
+		accented := []core.Note{}
+		for _, each := range group {
+			if each.IsHearable() {
+				each = each.WithVelocity(clampVelocity(each.Velocity + amount))
+			}
+			accented = append(accented, each)
+		}
+		groups = append(groups, accented)
+	}
+	return core.Sequence{Notes: groups}
+}
+
+func clampVelocity(v int) int {
+	if v < 1 {
+		return 1
+	}
+	if v > 127 {
+		return 127
+	}
+	return v
+}
+
+// Storex is part of core.Storable
+func (a Accents) Storex() string {
+	steps := []string{}
+	for _, each := range a.pattern {
+		if each {
+			steps = append(steps, "1")
+		} else {
+			steps = append(steps, "0")
+		}
+	}
+	return fmt.Sprintf("accents('%s',%s,%s)", strings.Join(steps, " "), core.Storex(a.amount), core.Storex(a.target))
+}
+
+// Replaced is part of Replaceable
+func (a Accents) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(a, from) {
+		return to
+	}
+	if s, ok := a.target.Value().(core.Sequenceable); ok {
+		if core.IsIdenticalTo(s, from) {
+			return Accents{pattern: a.pattern, amount: a.amount, target: core.On(to)}
+		}
+		if rep, ok := s.(core.Replaceable); ok {
+			return Accents{pattern: a.pattern, amount: a.amount, target: core.On(rep.Replaced(from, to))}
+		}
+	}
+	return a
+}
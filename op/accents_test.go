@@ -0,0 +1,35 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestAccents_Repeats(t *testing.T) {
+	target := core.MustParseSequence("e = (c e) e f")
+	a := NewAccents("1 0 1", core.On(20), core.On(target))
+	if got, want := core.Storex(a.S()), "sequence('E:v79 = (C:v79 E:v79) E:v79 F')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestAccents_Clamped(t *testing.T) {
+	target := core.MustParseSequence("e++++ e")
+	a := NewAccents("1 0", core.On(100), core.On(target))
+	if got, want := core.Storex(a.S()), "sequence('E+++++ E')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := a.Storex(), "accents('1 0',100,sequence('E++++ E'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestCheckAccentPattern(t *testing.T) {
+	if err := CheckAccentPattern("1 0 x"); err == nil {
+		t.Error("error expected")
+	}
+	if err := CheckAccentPattern(" "); err == nil {
+		t.Error("error expected")
+	}
+}
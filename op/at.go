@@ -12,8 +12,11 @@ type AtIndex struct {
 }
 
 func (a AtIndex) S() core.Sequence {
-	s := a.Target.S()
 	i := core.Int(a.Index)
+	if indexable, ok := core.ValueOf(a.Target).(core.Indexable); ok {
+		return indexable.At(i).S()
+	}
+	s := a.Target.S()
 	if i < 1 {
 		return core.EmptySequence
 	}
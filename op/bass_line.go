@@ -0,0 +1,186 @@
+package op
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+)
+
+/**
+
+bassline('walking',progression('c','1I 1vi')) = C2 E2 G2 A_2 A2 C3 E3 D_2
+
+**/
+
+// BassLineStyles are the names of the styles to generate a bass line with.
+var BassLineStyles = []string{"root", "root-fifth", "walking", "octaves"}
+
+// CheckBassLineStyle returns an error if the style is unknown.
+func CheckBassLineStyle(style string) error {
+	for _, each := range BassLineStyles {
+		if each == style {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown bass line style [%s], expected one of %s", style, strings.Join(BassLineStyles, ","))
+}
+
+const (
+	bassLow = 36   // C2 ; roots are played in the octave above
+	quarter = 0.25 // in whole notes
+)
+
+// BassLine generates a bass sequence that follows the chords of a progression.
+// The root of a chord is its lowest note ; it sounds in the octave from C2.
+type BassLine struct {
+	style       string
+	progression core.HasValue
+	control     core.LoopController // for the number of beats in a bar
+}
+
+func NewBassLine(style string, progression core.HasValue, control core.LoopController) BassLine {
+	return BassLine{style: style, progression: progression, control: control}
+}
+
+// bassChord is a chord of the progression that starts at a position.
+type bassChord struct {
+	at, length float64 // in whole notes
+	root       int     // MIDI number in the bass octave
+	intervals  []int   // semitones above the root, ascending, including 0
+	velocity   int
+}
+
+// bassTone is a generated note.
+type bassTone struct {
+	pitch  int
+	length float64
+}
+
+// S is part of core.Sequenceable
+func (b BassLine) S() core.Sequence {
+	seq := core.ToSequenceable(b.progression).S()
+	chords := []bassChord{}
+	rests := map[int]float64{} // index of the next chord -> length of the rest before it
+	position := 0.0
+	for _, group := range seq.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		length := float64(group[0].DurationFactor())
+		pitches := []int{}
+		velocity := core.Normal
+		for _, each := range group {
+			if each.IsHearable() {
+				pitches = append(pitches, each.MIDI())
+				velocity = each.Velocity
+			}
+		}
+		if len(pitches) == 0 {
+			rests[len(chords)] += length
+		} else {
+			sort.Ints(pitches)
+			intervals := []int{}
+			for _, each := range pitches {
+				if i := (each - pitches[0]) % 12; len(intervals) == 0 || i > intervals[len(intervals)-1] {
+					intervals = append(intervals, i)
+				}
+			}
+			chords = append(chords, bassChord{
+				at:        position,
+				length:    length,
+				root:      bassLow + pitches[0]%12,
+				intervals: intervals,
+				velocity:  velocity,
+			})
+		}
+		position += length
+	}
+	groups := [][]core.Note{}
+	for i, each := range chords {
+		if r, ok := rests[i]; ok {
+			groups = append(groups, []core.Note{core.Rest4.WithFraction(float32(r), false)})
+		}
+		next := chords[(i+1)%len(chords)]
+		for _, tone := range b.tones(each, next) {
+			n, err := core.MIDItoNote(quarter, tone.pitch, each.velocity)
+			if err != nil {
+				continue
+			}
+			groups = append(groups, []core.Note{n.WithFraction(float32(tone.length), false)})
+		}
+	}
+	if r, ok := rests[len(chords)]; ok {
+		groups = append(groups, []core.Note{core.Rest4.WithFraction(float32(r), false)})
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// tones returns the notes for a chord in the style of the bass line.
+func (b BassLine) tones(c, next bassChord) (list []bassTone) {
+	switch b.style {
+	case "octaves":
+		for t, k := 0.0, 0; t < c.length-positionEpsilon; t, k = t+quarter/2, k+1 {
+			list = append(list, bassTone{pitch: c.root + 12*(k%2), length: math.Min(quarter/2, c.length-t)})
+		}
+	case "root-fifth":
+		// the root on the first beat of the chord or bar, the fifth from the middle of the bar
+		bar := float64(b.control.BIAB()) * quarter
+		middle := math.Max(1, math.Floor(float64(b.control.BIAB())/2)) * quarter
+		for t, end := c.at, c.at+c.length; t < end-positionEpsilon; {
+			start := math.Floor((t+positionEpsilon)/bar) * bar
+			pitch, until := c.root, start+middle
+			if t >= until-positionEpsilon {
+				pitch, until = c.root+7, start+bar
+				if t-c.at < positionEpsilon {
+					pitch = c.root
+				}
+			}
+			until = math.Min(until, end)
+			list = append(list, bassTone{pitch: pitch, length: until - t})
+			t = until
+		}
+	case "walking":
+		// chord tones on each beat and a chromatic approach to the root of the next chord
+		beats := int(c.length/quarter + positionEpsilon)
+		if beats < 2 {
+			return []bassTone{{pitch: c.root, length: c.length}}
+		}
+		walk := append(append([]int{}, c.intervals[1:]...), 12)
+		for k := 0; k < beats; k++ {
+			pitch := c.root
+			switch {
+			case k == beats-1:
+				pitch = next.root - 1
+				if next.root < c.root {
+					pitch = next.root + 1
+				}
+			case k > 0:
+				pitch = c.root + walk[(k-1)%len(walk)]
+			}
+			length := quarter
+			if k == beats-1 {
+				length = c.length - float64(beats-1)*quarter
+			}
+			list = append(list, bassTone{pitch: pitch, length: length})
+		}
+	default: // root
+		list = append(list, bassTone{pitch: c.root, length: c.length})
+	}
+	return
+}
+
+// Storex is part of core.Storable
+func (b BassLine) Storex() string {
+	return fmt.Sprintf("bassline('%s',%s)", b.style, core.Storex(b.progression))
+}
+
+// Replaced is part of Replaceable
+func (b BassLine) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(b, from) {
+		return to
+	}
+	return BassLine{style: b.style, progression: replacedValue(b.progression, from, to), control: b.control}
+}
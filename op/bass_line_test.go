@@ -0,0 +1,45 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestBassLine_Styles(t *testing.T) {
+	p := core.On(core.NewChordProgression(core.On("c"), core.On("1I 1vi 2ii 2V")))
+	control := &core.TestLooper{Biab: 4}
+	for _, each := range []struct {
+		style string
+		want  string
+	}{
+		{"root", "sequence('1C2 1A2 2D2 2G2')"},
+		{"root-fifth", "sequence('2C2 2G2 2A2 2E3 2D2 2G2')"},
+		{"walking", "sequence('C2 E2 G2 A_2 A2 C3 E3 E_2 D2 G_2 G2 D_2')"},
+		{"octaves", "sequence('8C2 8C3 8C2 8C3 8C2 8C3 8C2 8C3 8A2 8A3 8A2 8A3 8A2 8A3 8A2 8A3 8D2 8D3 8D2 8D3 8G2 8G3 8G2 8G3')"},
+	} {
+		b := NewBassLine(each.style, p, control)
+		if got, want := b.S().Storex(), each.want; got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each.style, got, got, want, want)
+		}
+	}
+}
+
+func TestBassLine_RootFifthInThreeFour(t *testing.T) {
+	p := core.On(core.NewChordProgression(core.On("c"), core.On("2.I 2.V")))
+	b := NewBassLine("root-fifth", p, &core.TestLooper{Biab: 3})
+	if got, want := b.S().Storex(), "sequence('C2 2G2 G2 2D3')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := b.Storex(), "bassline('root-fifth',progression('c','2.I 2.V'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestBassLine_Rests(t *testing.T) {
+	s := core.MustParseSequence("= (c e g) 2=")
+	b := NewBassLine("root", core.On(s), &core.TestLooper{Biab: 4})
+	if got, want := b.S().Storex(), "sequence('= C2 2=')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
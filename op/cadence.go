@@ -0,0 +1,100 @@
+package op
+
+import (
+	"fmt"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+/**
+
+cadence(4,progression('c','I vi IV ii I vi IV ii')) = I vi IV ii I vi V I , with 4 bars of 4 beats
+
+**/
+
+// Cadence substitutes the last two chords of every phrase of a chord progression
+// with an authentic (V I) or plagal (IV I) cadence.
+type Cadence struct {
+	every       core.HasValue // bars per phrase
+	progression core.HasValue
+	plagal      bool
+	control     core.LoopController // for the number of beats in a bar
+}
+
+func NewCadence(every, progression core.HasValue, plagal bool, control core.LoopController) Cadence {
+	return Cadence{every: every, progression: progression, plagal: plagal, control: control}
+}
+
+// S is part of core.Sequenceable
+func (c Cadence) S() core.Sequence {
+	prog, ok := c.progression.Value().(core.ChordProgression)
+	if !ok {
+		notify.Warnf("cadence requires a chord progression, got %T", c.progression.Value())
+		return core.EmptySequence
+	}
+	chords := prog.C()
+	closing, err := core.ParseChordProgression(core.String(prog.Root()), c.closing())
+	if err != nil {
+		notify.Warnf("cannot create cadence, error: %v", err)
+		return prog.S()
+	}
+	bars := core.Int(c.every)
+	if bars <= 0 {
+		return prog.S()
+	}
+	phrase := float64(bars*c.control.BIAB()) / 4.0 // in whole notes
+	// collect the indices of the chords that start in each phrase
+	phrases := map[int][]int{}
+	position := 0.0
+	for i, each := range chords {
+		p := int((position + positionEpsilon) / phrase)
+		phrases[p] = append(phrases[p], i)
+		position += chordDuration(each)
+	}
+	for _, indices := range phrases {
+		if len(indices) < 2 {
+			continue
+		}
+		last := len(indices) - 1
+		chords[indices[last-1]] = shaped(closing[0], chords[indices[last-1]])
+		chords[indices[last]] = shaped(closing[1], chords[indices[last]])
+	}
+	j := core.EmptySequence
+	for _, each := range chords {
+		j = j.SequenceJoin(each.S())
+	}
+	return j
+}
+
+func (c Cadence) closing() string {
+	if c.plagal {
+		return "IV I"
+	}
+	return "V I"
+}
+
+func chordDuration(c core.Chord) float64 {
+	notes := c.Notes()
+	if len(notes) == 0 {
+		return 0
+	}
+	return float64(notes[0].DurationFactor())
+}
+
+// shaped returns the chord with the duration and velocity of the original.
+func shaped(c, original core.Chord) core.Chord {
+	notes := original.Notes()
+	if len(notes) == 0 {
+		return c
+	}
+	return c.WithFraction(notes[0].Fraction(), notes[0].Dotted).WithVelocity(notes[0].Velocity)
+}
+
+// Storex is part of core.Storable
+func (c Cadence) Storex() string {
+	if c.plagal {
+		return fmt.Sprintf("cadence(%s,%s,'plagal')", core.Storex(c.every), core.Storex(c.progression))
+	}
+	return fmt.Sprintf("cadence(%s,%s)", core.Storex(c.every), core.Storex(c.progression))
+}
@@ -0,0 +1,24 @@
+package op
+
+import (
+	"fmt"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// NewCanon returns a multi-track with a track for each voice of a canon.
+// Voice i (zero-based) plays the subject on channel i+1, entering after i times the delay in bars,
+// transposed by i times the interval in semitones.
+func NewCanon(voices, delayBars, interval int, subject core.Sequenceable) core.MultiTrack {
+	tracks := []core.HasValue{}
+	for i := 0; i < voices; i++ {
+		t := core.NewTrack(fmt.Sprintf("voice %d", i+1), i+1)
+		var s core.Sequenceable = subject
+		if interval != 0 && i > 0 {
+			s = Transpose{Target: subject, Semitones: core.On(i * interval)}
+		}
+		t.Add(core.NewSequenceOnTrack(core.On(1+i*delayBars), s))
+		tracks = append(tracks, core.On(t))
+	}
+	return core.MultiTrack{Tracks: tracks}
+}
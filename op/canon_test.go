@@ -0,0 +1,21 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestNewCanon(t *testing.T) {
+	m := NewCanon(3, 2, 7, core.MustParseSequence("c d"))
+	if got, want := len(m.Tracks), 3; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	third := m.Tracks[2].Value().(*core.Track)
+	if got, want := third.Channel, 3; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := core.Storex(third), "track('voice 3',3,onbar(5,transpose(14,sequence('C D'))))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
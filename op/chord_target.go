@@ -0,0 +1,159 @@
+package op
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/emicklei/melrose/core"
+)
+
+/**
+
+target(progression('c','I IV V I'), sequence('8d 8e 8f 8g 8a 8b 8c5 8d5'))
+
+**/
+
+// ChordTarget moves notes that start on a strong beat to the nearest tone of the chord
+// that sounds at the same position in another object, e.g. a progression.
+// Positions of the chords repeat, as if they were looping along.
+// If played by a loop or track then the notes start at its bar and beat, else both are aligned at their start in 4/4.
+type ChordTarget struct {
+	chords core.HasValue
+	target core.HasValue
+}
+
+func NewChordTarget(chords, target core.HasValue) ChordTarget {
+	return ChordTarget{chords: chords, target: target}
+}
+
+// chordSpan is a group of notes sounding from a position.
+type chordSpan struct {
+	at    float64
+	notes []core.Note
+}
+
+// S is part of core.Sequenceable
+func (c ChordTarget) S() core.Sequence {
+	return c.targeted(0, 4)
+}
+
+// SWith is part of core.ContextSequenceable
+func (c ChordTarget) SWith(ctx core.Context) core.Sequence {
+	p, ok := core.PositionOf(ctx)
+	if !ok {
+		return c.S()
+	}
+	biab := p.BIAB
+	if biab <= 0 && ctx.Control() != nil {
+		biab = ctx.Control().BIAB()
+	}
+	if biab <= 0 {
+		biab = 4
+	}
+	return c.targeted(float64(p.Bar*int64(biab)+p.Beat)/4, biab)
+}
+
+// targeted returns the notes of the target that starts at an offset, in bars of a number of beats.
+func (c ChordTarget) targeted(offset float64, biab int) core.Sequence {
+	seq := core.ToSequenceable(c.target).S()
+	spans, length := chordSpans(core.ToSequenceable(c.chords).S())
+	if len(spans) == 0 {
+		return seq
+	}
+	groups := [][]core.Note{}
+	position := offset
+	for _, group := range seq.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		if isStrongBeat(position, biab) {
+			chord := activeChord(spans, length, position)
+			changed := []core.Note{}
+			for _, each := range group {
+				if each.IsHearable() {
+					each = each.Pitched(semitonesToNearest(each.MIDI(), chord))
+				}
+				changed = append(changed, each)
+			}
+			group = changed
+		}
+		groups = append(groups, group)
+		position += float64(group[0].DurationFactor())
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// chordSpans returns the positions of all groups with a hearable note and the total length.
+func chordSpans(seq core.Sequence) (spans []chordSpan, length float64) {
+	for _, group := range seq.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		if isHearable(group) {
+			spans = append(spans, chordSpan{at: length, notes: group})
+		}
+		length += float64(group[0].DurationFactor())
+	}
+	return
+}
+
+// activeChord returns the notes of the last span that started at or before the position.
+// pre: spans not empty
+func activeChord(spans []chordSpan, length, position float64) []core.Note {
+	if length > 0 {
+		position = math.Mod(position, length)
+	}
+	active := spans[len(spans)-1].notes
+	for _, each := range spans {
+		if each.at > position+positionEpsilon {
+			break
+		}
+		active = each.notes
+	}
+	return active
+}
+
+// isStrongBeat returns whether a position, in whole note fractions, is on the first beat of a bar
+// or, if a bar has an even number of beats of at least 4, on the beat halfway, e.g. 1 and 3 of a 4/4 bar.
+func isStrongBeat(position float64, biab int) bool {
+	strong := float64(biab) / 4
+	if biab >= 4 && biab%2 == 0 {
+		strong /= 2
+	}
+	r := math.Mod(position, strong)
+	return r < positionEpsilon || strong-r < positionEpsilon
+}
+
+// semitonesToNearest returns the smallest pitch change to reach a tone of the chord in any octave.
+// If two tones are equally near then the lower one is taken.
+func semitonesToNearest(nr int, chord []core.Note) int {
+	best := 12
+	for _, each := range chord {
+		if !each.IsHearable() {
+			continue
+		}
+		up := ((each.MIDI()-nr)%12 + 12) % 12 // [0..11]
+		down := up - 12                       // [-12..-1]
+		for _, delta := range []int{down, up} {
+			if abs(delta) < abs(best) || (abs(delta) == abs(best) && delta < best) {
+				best = delta
+			}
+		}
+	}
+	if best == 12 {
+		return 0
+	}
+	return best
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// Storex is part of core.Storable
+func (c ChordTarget) Storex() string {
+	return fmt.Sprintf("target(%s,%s)", core.Storex(c.chords), core.Storex(c.target))
+}
@@ -0,0 +1,56 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestChordTarget_StrongBeats(t *testing.T) {
+	chords := core.MustParseSequence("(1c 1e 1g) (1f 1a 1c5)")
+	line := core.MustParseSequence("f d f d g d g d")
+	c := NewChordTarget(core.On(chords), core.On(line))
+	if got, want := core.Storex(c.S()), "sequence('E D E D F D F D')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func Test_semitonesToNearest(t *testing.T) {
+	chord := core.MustParseSequence("(c e g)").Notes[0]
+	for _, each := range []struct {
+		note string
+		want int
+	}{
+		{"c", 0},
+		{"d", -2}, // C and E are equally near, take the lower
+		{"f", -1}, // E
+		{"b", 1},  // C5
+		{"a", -2}, // G
+		{"c3", 0}, // other octave
+		{"b_", 2}, // C5
+	} {
+		n := core.MustParseNote(each.note)
+		if got, want := semitonesToNearest(n.MIDI(), chord), each.want; got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each.note, got, got, want, want)
+		}
+	}
+}
+
+func TestChordTarget_Position(t *testing.T) {
+	chords := core.MustParseSequence("(1c 1e 1g) (1f 1a 1c5)")
+	line := core.MustParseSequence("f d f d")
+	c := NewChordTarget(core.On(chords), core.On(line))
+	ctx := core.PlayContext{LoopControl: core.NoLooper}
+	// second bar of a loop
+	at := core.WithPosition(ctx, core.Position{Bar: 1, BIAB: 4})
+	if got, want := core.Storex(c.SWith(at)), "sequence('F D F D')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// only the first beat is strong in 3/4
+	line = core.MustParseSequence("f d f")
+	c = NewChordTarget(core.On(chords), core.On(line))
+	at = core.WithPosition(ctx, core.Position{BIAB: 3})
+	if got, want := core.Storex(c.SWith(at)), "sequence('E D F')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
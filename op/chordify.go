@@ -0,0 +1,145 @@
+package op
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// Chordify groups the notes that start within the same rhythmic window into a chord.
+// Each chord lasts until the window of the next chord ; windows before the first chord become a rest.
+type Chordify struct {
+	Window core.HasValue // fraction of a whole note ; e.g. 4 is a quarter
+	Target core.Sequenceable
+}
+
+// S is part of Sequenceable
+func (c Chordify) S() core.Sequence {
+	seq := c.Target.S()
+	window := float64(core.Float(c.Window))
+	if window > 1.0 {
+		window = 1.0 / window
+	}
+	if window <= 0 {
+		return seq
+	}
+	// collect the hearable notes per window index
+	windows := map[int][]core.Note{}
+	position := 0.0
+	for _, group := range seq.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		index := int(math.Floor(position/window + positionEpsilon))
+		for _, each := range group {
+			if each.IsHearable() {
+				windows[index] = append(windows[index], each)
+			}
+		}
+		position += float64(group[0].DurationFactor())
+	}
+	if len(windows) == 0 {
+		return seq
+	}
+	indices := []int{}
+	for i := range windows {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	end := int(math.Ceil(position/window - positionEpsilon))
+	groups := [][]core.Note{}
+	if indices[0] > 0 {
+		groups = append(groups, []core.Note{core.Rest4.WithFraction(float32(float64(indices[0])*window), false)})
+	}
+	for i, index := range indices {
+		next := end
+		if i+1 < len(indices) {
+			next = indices[i+1]
+		}
+		length := float32(float64(next-index) * window)
+		chord := []core.Note{}
+		seen := map[int]bool{}
+		for _, each := range windows[index] {
+			if seen[each.MIDI()] {
+				continue
+			}
+			seen[each.MIDI()] = true
+			chord = append(chord, each.WithFraction(length, false))
+		}
+		sort.SliceStable(chord, func(i, j int) bool { return chord[i].MIDI() < chord[j].MIDI() })
+		groups = append(groups, chord)
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// Storex is part of Storable
+func (c Chordify) Storex() string {
+	return fmt.Sprintf("chordify(%s,%s)", core.Storex(c.Window), core.Storex(c.Target))
+}
+
+// Replaced is part of Replaceable
+func (c Chordify) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(c, from) {
+		return to
+	}
+	return Chordify{Window: c.Window, Target: replacedAll([]core.Sequenceable{c.Target}, from, to)[0]}
+}
+
+// Line keeps the highest (topline) or lowest (bassline) hearable note of each group.
+type Line struct {
+	Target  core.Sequenceable
+	Highest bool
+}
+
+// S is part of Sequenceable
+func (l Line) S() core.Sequence {
+	seq := l.Target.S()
+	groups := [][]core.Note{}
+	for _, group := range seq.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		var kept *core.Note
+		shortest := float32(math.MaxFloat32)
+		for i, each := range group {
+			if f := each.DurationFactor(); f > 0 && f < shortest {
+				shortest = f
+			}
+			if !each.IsHearable() {
+				continue
+			}
+			if kept == nil || (l.Highest && each.MIDI() > kept.MIDI()) || (!l.Highest && each.MIDI() < kept.MIDI()) {
+				kept = &group[i]
+			}
+		}
+		if kept == nil {
+			groups = append(groups, group)
+			continue
+		}
+		line := []core.Note{*kept}
+		if kept.DurationFactor() != shortest {
+			// keep the timing of the group
+			line = append([]core.Note{core.Rest4.WithFraction(shortest, false)}, line...)
+		}
+		groups = append(groups, line)
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// Storex is part of Storable
+func (l Line) Storex() string {
+	if l.Highest {
+		return fmt.Sprintf("topline(%s)", core.Storex(l.Target))
+	}
+	return fmt.Sprintf("bassline(%s)", core.Storex(l.Target))
+}
+
+// Replaced is part of Replaceable
+func (l Line) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(l, from) {
+		return to
+	}
+	return Line{Target: replacedAll([]core.Sequenceable{l.Target}, from, to)[0], Highest: l.Highest}
+}
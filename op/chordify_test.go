@@ -0,0 +1,39 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestChordify_Quarters(t *testing.T) {
+	s := core.MustParseSequence("8c 8e 8g 8= 16a 16b 8= 2c5")
+	c := Chordify{Window: core.On(4), Target: s}
+	if got, want := c.S().Storex(), "sequence('(C E) G (A B) 2C5')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := c.Storex(), "chordify(4,sequence('8C 8E 8G 8= 16A 16B 8= 2C5'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestChordify_LeadingRestAndSustain(t *testing.T) {
+	s := core.MustParseSequence("= 8c 8c 8e 8= 2=")
+	c := Chordify{Window: core.On(4), Target: s}
+	if got, want := c.S().Storex(), "sequence('= C 2.E')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestLine_TopAndBass(t *testing.T) {
+	s := core.MustParseSequence("(c e g) = 8a (2c5 8e5)")
+	if got, want := (Line{Target: s, Highest: true}).S().Storex(), "sequence('G = 8A 8E5')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := (Line{Target: s}).S().Storex(), "sequence('C = 8A (8= 2C5)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := (Line{Target: s}).Storex(), "bassline(sequence('(C E G) = 8A (2C5 8E5)'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -3,6 +3,7 @@ package op
 import (
 	"bytes"
 	"fmt"
+	"math"
 
 	"github.com/emicklei/melrose/core"
 	"github.com/emicklei/melrose/notify"
@@ -39,7 +40,7 @@ func (d Dynamic) S() core.Sequence {
 			} else if v, ok := e.(int); ok {
 				n = eachNote.WithVelocity(v)
 			}
-			mappedGroup = append(mappedGroup, n)
+			mappedGroup = append(mappedGroup, withDensity(n))
 		}
 		target = append(target, mappedGroup)
 	}
@@ -83,5 +84,13 @@ func (d Dynamic) ToNote() (core.Note, error) {
 	} else if v, ok := e.(int); ok {
 		not = not.WithVelocity(v)
 	}
-	return not, nil
+	return withDensity(not), nil
+}
+
+// withDensity returns the note with its velocity scaled by the global density.
+func withDensity(n core.Note) core.Note {
+	if core.Density() == core.NeutralDensity {
+		return n
+	}
+	return n.WithVelocity(int(math.Round(core.DensityScaled(float64(n.Velocity), 127))))
 }
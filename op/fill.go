@@ -0,0 +1,42 @@
+package op
+
+import (
+	"fmt"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// Fill plays the fill instead of the target in the last bar of every number of bars.
+// It uses the position of the context in which it is planned, e.g. by a loop or a track.
+type Fill struct {
+	Every  core.HasValue
+	Target core.Sequenceable
+	Fill   core.Sequenceable
+}
+
+// S is part of Sequenceable ; without a position the target is used.
+func (f Fill) S() core.Sequence {
+	return f.Target.S()
+}
+
+// SWith is part of ContextSequenceable
+func (f Fill) SWith(ctx core.Context) core.Sequence {
+	if p, ok := core.PositionOf(ctx); ok && p.IsLastBarOf(int64(core.Int(f.Every))) {
+		return f.Fill.S()
+	}
+	return f.Target.S()
+}
+
+// Storex is part of Storable
+func (f Fill) Storex() string {
+	return fmt.Sprintf("fill(%s,%s,%s)", core.Storex(f.Every), core.Storex(f.Target), core.Storex(f.Fill))
+}
+
+// Replaced is part of Replaceable
+func (f Fill) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(f, from) {
+		return to
+	}
+	replaced := replacedAll([]core.Sequenceable{f.Target, f.Fill}, from, to)
+	return Fill{Every: f.Every, Target: replaced[0], Fill: replaced[1]}
+}
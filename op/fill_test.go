@@ -0,0 +1,28 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestFill_SWith(t *testing.T) {
+	f := Fill{Every: core.On(4), Target: core.MustParseSequence("c"), Fill: core.MustParseSequence("d")}
+	ctx := core.PlayContext{}
+	for bar, want := range []string{"C", "C", "C", "D", "C"} {
+		at := core.WithPosition(ctx, core.Position{Bar: int64(bar), BIAB: 4})
+		if got := core.Positioned(at, f).S().String(); got != want {
+			t.Errorf("bar %d: got [%v:%T] want [%v:%T]", bar, got, got, want, want)
+		}
+	}
+	if got, want := f.S().String(), "C"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestFill_Storex(t *testing.T) {
+	f := Fill{Every: core.On(4), Target: core.MustParseSequence("c"), Fill: core.MustParseSequence("d")}
+	if got, want := f.Storex(), "fill(4,sequence('C'),sequence('D'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,83 @@
+package op
+
+import (
+	"fmt"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// FitBars pads a musical object with a rest or truncates it such that it takes exactly a number of bars.
+// A group of notes that sounds at the end is shortened.
+type FitBars struct {
+	bars    core.HasValue
+	target  core.HasValue
+	control core.LoopController // for the number of beats in a bar
+}
+
+func NewFitBars(bars, target core.HasValue, control core.LoopController) FitBars {
+	return FitBars{bars: bars, target: target, control: control}
+}
+
+// S is part of core.Sequenceable
+func (f FitBars) S() core.Sequence {
+	seq := core.ToSequenceable(f.target).S()
+	length := float64(core.Int(f.bars)*f.control.BIAB()) / 4.0 // in whole notes
+	if length <= 0 {
+		return core.EmptySequence
+	}
+	groups := [][]core.Note{}
+	position := 0.0
+	for _, group := range seq.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		if position > length-positionEpsilon {
+			break
+		}
+		duration := float64(group[0].DurationFactor())
+		if position+duration > length+positionEpsilon {
+			remaining := float32(length - position)
+			shortened := []core.Note{}
+			for _, each := range group {
+				// a new note without dot and without tied notes that takes exactly the remaining length
+				cut := core.MakeNote(each.Name, each.Octave, 0.25, each.Accidental, false, each.Velocity)
+				shortened = append(shortened, cut.WithFraction(remaining, false))
+			}
+			group = shortened
+			duration = length - position
+		}
+		groups = append(groups, group)
+		position += duration
+	}
+	for _, each := range restsOf(length - position) {
+		groups = append(groups, []core.Note{each})
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// restsOf returns rests of standard lengths, longest first, that together take a length in whole notes.
+func restsOf(length float64) (rests []core.Note) {
+	for _, each := range []float64{1, 0.5, 0.25, 0.125, 0.0625} {
+		for length > each-positionEpsilon {
+			rests = append(rests, core.Rest4.WithFraction(float32(each), false))
+			length -= each
+		}
+	}
+	if length > positionEpsilon {
+		rests = append(rests, core.Rest4.WithFraction(float32(length), false))
+	}
+	return
+}
+
+// Storex is part of core.Storable
+func (f FitBars) Storex() string {
+	return fmt.Sprintf("fitbars(%s,%s)", core.Storex(f.bars), core.Storex(f.target))
+}
+
+// Replaced is part of Replaceable
+func (f FitBars) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(f, from) {
+		return to
+	}
+	return FitBars{bars: f.bars, target: replacedValue(f.target, from, to), control: f.control}
+}
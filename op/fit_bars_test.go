@@ -0,0 +1,28 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestFitBars(t *testing.T) {
+	for _, each := range []struct {
+		bars, biab int
+		seq, want  string
+	}{
+		{1, 4, "c d e", "sequence('C D E =')"},
+		{1, 4, "c d e f g", "sequence('C D E F')"},
+		{1, 4, "c d e 2f", "sequence('C D E F')"},
+		{2, 3, "2c", "sequence('2C 1=')"},
+		{1, 4, "c d e f", "sequence('C D E F')"},
+		{1, 4, "2.c 2.d", "sequence('2.C D')"},
+		{1, 4, "2c 2.d", "sequence('2C 2D')"},
+		{1, 4, "2.c (2.d e)", "sequence('2.C (D E)')"},
+	} {
+		f := NewFitBars(core.On(each.bars), core.On(core.MustParseSequence(each.seq)), &core.TestLooper{Biab: int64(each.biab)})
+		if got, want := f.S().Storex(), each.want; got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each.seq, got, got, want, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package op
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// Freeze holds the value of a generator, such as random or interval, until it is thawed.
+// Other objects can advance the generator without changing the value of the Freeze.
+type Freeze struct {
+	Target core.HasValue
+	mutex  sync.RWMutex
+	value  interface{}
+}
+
+func NewFreeze(target core.HasValue) *Freeze {
+	return &Freeze{Target: target, value: core.ValueOf(target)}
+}
+
+// Value is part of HasValue ; it returns the value of the target when created or last thawed.
+func (f *Freeze) Value() interface{} {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.value
+}
+
+// Thaw takes the current value of the target.
+func (f *Freeze) Thaw() {
+	v := core.ValueOf(f.Target)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.value = v
+}
+
+// Next is part of Nextable ; it advances the target, if possible, and takes its new value.
+func (f *Freeze) Next() interface{} {
+	if n, ok := f.Target.Value().(core.Nextable); ok {
+		n.Next()
+	} else if n, ok := f.Target.(core.Nextable); ok {
+		n.Next()
+	}
+	f.Thaw()
+	return f.Value()
+}
+
+// Storex is part of Storable
+func (f *Freeze) Storex() string {
+	return fmt.Sprintf("freeze(%s)", core.Storex(f.Target))
+}
+
+// Thawer is an empty Sequence that has a sideeffect to thaw its target when asked for the Sequence.
+type Thawer struct {
+	Target core.HasValue
+}
+
+// S is part of Sequenceable
+func (t Thawer) S() core.Sequence {
+	if f, ok := t.Target.(*Freeze); ok {
+		f.Thaw()
+	} else if f, ok := t.Target.Value().(*Freeze); ok {
+		f.Thaw()
+	}
+	return core.EmptySequence
+}
+
+// Storex is part of Storable
+func (t Thawer) Storex() string {
+	return fmt.Sprintf("thaw(%s)", core.Storex(t.Target))
+}
@@ -0,0 +1,26 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestFreeze_HoldsUntilThawed(t *testing.T) {
+	i := core.NewInterval(core.On(1), core.On(3), core.On(1), core.RepeatFromTo)
+	f := NewFreeze(i)
+	i.Next()
+	if got, want := f.Value(), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	Thawer{Target: f}.S()
+	if got, want := f.Value(), 2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := f.Next(), 3; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := f.Storex(), "freeze(interval(1,3,1,'repeat'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
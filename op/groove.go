@@ -0,0 +1,185 @@
+package op
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// grooveTemplate shifts the notes that start on a grid step.
+type grooveTemplate struct {
+	step    float64   // length of a grid step in whole notes
+	offsets []float64 // shift of each step, as a fraction of the step ; repeats
+}
+
+// grooveSteps is the number of steps of the grid, one bar of sixteenths, of a groove that is extracted.
+const grooveSteps = 16
+
+// pushPull is the shift, as a fraction of a step, of the push and pull templates.
+const pushPull = 0.1
+
+var grooveTemplates = map[string]grooveTemplate{
+	"push16": {step: 1.0 / 16, offsets: []float64{0, -pushPull}},
+	"pull16": {step: 1.0 / 16, offsets: []float64{0, pushPull}},
+}
+
+func init() {
+	// MPC swing percentages ; 50 is straight and 66 is a triplet feel
+	for _, each := range []int{50, 54, 58, 62, 66, 71} {
+		// the second step of each pair starts at the percentage of the pair
+		swing := []float64{0, float64(2*each)/100 - 1}
+		grooveTemplates[fmt.Sprintf("mpc8_%d", each)] = grooveTemplate{step: 1.0 / 8, offsets: swing}
+		grooveTemplates[fmt.Sprintf("mpc16_%d", each)] = grooveTemplate{step: 1.0 / 16, offsets: swing}
+	}
+}
+
+// GrooveTemplateNames returns the sorted names of the built-in templates.
+func GrooveTemplateNames() (names []string) {
+	for k := range grooveTemplates {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return
+}
+
+// CheckGrooveTemplate returns an error if the name is not a built-in template.
+func CheckGrooveTemplate(name string) error {
+	if _, ok := grooveTemplates[name]; !ok {
+		return fmt.Errorf("unknown groove template [%s], expected one of %s", name, strings.Join(GrooveTemplateNames(), ","))
+	}
+	return nil
+}
+
+// extractGroove returns the average shift of the onsets from the nearest sixteenth, for each step of a bar.
+func extractGroove(onsets []float64) grooveTemplate {
+	t := grooveTemplate{step: 1.0 / 16, offsets: make([]float64, grooveSteps)}
+	counts := make([]int, grooveSteps)
+	for _, each := range onsets {
+		k := math.Round(each / t.step)
+		i := int(k) % grooveSteps
+		t.offsets[i] += each/t.step - k
+		counts[i]++
+	}
+	for i, each := range counts {
+		if each > 0 {
+			t.offsets[i] /= float64(each)
+		}
+	}
+	return t
+}
+
+// onsetsOf returns the start positions, in whole notes, of the hearable groups of a sequence.
+func onsetsOf(seq core.Sequence) (onsets []float64) {
+	position := 0.0
+	for _, group := range seq.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		if isHearable(group) {
+			onsets = append(onsets, position)
+		}
+		position += float64(group[0].DurationFactor())
+	}
+	return
+}
+
+// Groove changes the timing of notes that start on a grid step, keeping the total length.
+// The template is the name of a built-in template or an object of which the timing is extracted,
+// such as a recording.
+type Groove struct {
+	template core.HasValue
+	target   core.HasValue
+}
+
+func NewGroove(template, target core.HasValue) Groove {
+	return Groove{template: template, target: target}
+}
+
+func (g Groove) templateValue() (grooveTemplate, bool) {
+	switch v := g.template.Value().(type) {
+	case string:
+		t, ok := grooveTemplates[v]
+		return t, ok
+	case core.HasOnsets:
+		return extractGroove(v.Onsets()), true
+	case core.Sequenceable:
+		return extractGroove(onsetsOf(v.S())), true
+	}
+	return grooveTemplate{}, false
+}
+
+// S is part of core.Sequenceable
+func (g Groove) S() core.Sequence {
+	seq := core.ToSequenceable(g.target).S()
+	t, ok := g.templateValue()
+	if !ok || len(t.offsets) == 0 {
+		return seq
+	}
+	groups := [][]core.Note{}
+	for _, each := range seq.Notes {
+		if len(each) > 0 {
+			groups = append(groups, each)
+		}
+	}
+	if len(groups) == 0 {
+		return seq
+	}
+	// new start positions
+	starts := make([]float64, len(groups))
+	position, previous := 0.0, -1.0
+	for i, group := range groups {
+		start := position
+		k := math.Round(position / t.step)
+		if math.Abs(position/t.step-k) < positionEpsilon {
+			start = position + t.offsets[int(k)%len(t.offsets)]*t.step
+		}
+		if start <= previous || start < 0 {
+			start = position
+		}
+		starts[i] = start
+		previous = start
+		position += float64(group[0].DurationFactor())
+	}
+	end := position
+	notes := [][]core.Note{}
+	if starts[0] > positionEpsilon {
+		notes = append(notes, []core.Note{core.Rest4.WithFraction(float32(starts[0]), false)})
+	}
+	for i, group := range groups {
+		next := end
+		if i+1 < len(starts) {
+			next = starts[i+1]
+		}
+		factor := float32((next - starts[i]) / float64(group[0].DurationFactor()))
+		changed := []core.Note{}
+		for _, each := range group {
+			changed = append(changed, each.Stretched(factor))
+		}
+		notes = append(notes, changed)
+	}
+	return core.Sequence{Notes: notes}
+}
+
+// Storex is part of core.Storable
+func (g Groove) Storex() string {
+	return fmt.Sprintf("groove(%s,%s)", core.Storex(g.template), core.Storex(g.target))
+}
+
+// Replaced is part of Replaceable
+func (g Groove) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(g, from) {
+		return to
+	}
+	if s, ok := g.target.Value().(core.Sequenceable); ok {
+		if core.IsIdenticalTo(s, from) {
+			return Groove{template: g.template, target: core.On(to)}
+		}
+		if rep, ok := s.(core.Replaceable); ok {
+			return Groove{template: g.template, target: core.On(rep.Replaced(from, to))}
+		}
+	}
+	return g
+}
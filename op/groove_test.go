@@ -0,0 +1,69 @@
+package op
+
+import (
+	"math"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func durationsOf(s core.Sequence) (list []float64) {
+	for _, each := range s.Notes {
+		list = append(list, math.Round(float64(each[0].DurationFactor())*1e4)/1e4)
+	}
+	return
+}
+
+func TestGroove_Swing(t *testing.T) {
+	target := core.MustParseSequence("8c 8d (8e 8g) 8f")
+	g := NewGroove(core.On("mpc8_66"), core.On(target))
+	// second eighths start at 66% of a quarter
+	if got, want := durationsOf(g.S()), []float64{0.165, 0.085, 0.165, 0.085}; !equalFloats(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := g.Storex(), "groove('mpc8_66',sequence('8C 8D (8E 8G) 8F'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestGroove_OffGridUnchanged(t *testing.T) {
+	target := core.MustParseSequence("16c 8d 16e")
+	g := NewGroove(core.On("mpc8_58"), core.On(target))
+	if got, want := durationsOf(g.S()), []float64{0.0625, 0.125, 0.0625}; !equalFloats(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+type testOnsets []float64
+
+func (o testOnsets) Onsets() []float64 { return o }
+
+func TestGroove_Extracted(t *testing.T) {
+	// a played groove with the second sixteenth late by a quarter of a step
+	played := testOnsets{0, 0.078125, 0.125, 0.203125}
+	target := core.MustParseSequence("16c 16d 16e 16f")
+	g := NewGroove(core.On(played), core.On(target))
+	if got, want := durationsOf(g.S()), []float64{0.0781, 0.0469, 0.0781, 0.0469}; !equalFloats(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestGroove_LateFirst(t *testing.T) {
+	target := core.MustParseSequence("c")
+	g := NewGroove(core.On(testOnsets{0.01}), core.On(target))
+	if got, want := durationsOf(g.S()), []float64{0.01, 0.24}; !equalFloats(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-3 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package op
+
+import (
+	"fmt"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// Invert mirrors the pitches of the notes of a musical object around the pitch of an axis note.
+type Invert struct {
+	Axis   core.HasValue
+	Target core.Sequenceable
+}
+
+// S is part of Sequenceable
+func (i Invert) S() core.Sequence {
+	seq := i.Target.S()
+	conv, ok := i.Axis.Value().(core.NoteConvertable)
+	if !ok {
+		notify.Warnf("[op.Invert] axis must be a note, got (%T) %v", i.Axis.Value(), i.Axis.Value())
+		return seq
+	}
+	axis, err := conv.ToNote()
+	if err != nil {
+		notify.Warnf("[op.Invert] invalid axis: %v", err)
+		return seq
+	}
+	groups := [][]core.Note{}
+	for _, group := range seq.Notes {
+		mirrored := []core.Note{}
+		for _, each := range group {
+			if each.IsHearable() {
+				each = each.Pitched(2 * (axis.MIDI() - each.MIDI()))
+			}
+			mirrored = append(mirrored, each)
+		}
+		groups = append(groups, mirrored)
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// Storex is part of Storable
+func (i Invert) Storex() string {
+	return fmt.Sprintf("invert(%s,%s)", core.Storex(i.Axis), core.Storex(i.Target))
+}
+
+// Replaced is part of Replaceable
+func (i Invert) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(i, from) {
+		return to
+	}
+	return Invert{Axis: i.Axis, Target: replacedAll([]core.Sequenceable{i.Target}, from, to)[0]}
+}
@@ -0,0 +1,75 @@
+package op
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// MapNote replaces each note of a musical object by the result of an expression on that note.
+// The result of a note in a group of one note can be more groups ; for a larger group, the notes of all results form the group.
+// Rests and pedals are kept.
+type MapNote struct {
+	Expression string
+	Target     core.Sequenceable
+	// Mapper evaluates the expression with the note
+	Mapper func(expression string, n core.Note) (interface{}, error)
+}
+
+// S is part of Sequenceable
+func (m MapNote) S() core.Sequence {
+	groups := [][]core.Note{}
+	for _, group := range m.Target.S().Notes {
+		if len(group) == 0 {
+			continue
+		}
+		results := []core.Sequence{}
+		for _, each := range group {
+			if !each.IsHearable() {
+				results = append(results, core.BuildSequence([]core.Note{each}))
+				continue
+			}
+			r, err := m.Mapper(m.Expression, each)
+			if err != nil {
+				notify.Warnf("[op.MapNote] cannot map %s: %v", each, err)
+				return core.EmptySequence
+			}
+			s, ok := core.ValueOf(r).(core.Sequenceable)
+			if !ok {
+				notify.Warnf("[op.MapNote] result of mapping %s is not a note or group, got (%T) %v", each, r, r)
+				return core.EmptySequence
+			}
+			results = append(results, s.S())
+		}
+		if len(results) == 1 {
+			groups = append(groups, results[0].Notes...)
+			continue
+		}
+		combined := []core.Note{}
+		for _, each := range results {
+			for _, other := range each.Notes {
+				combined = append(combined, other...)
+			}
+		}
+		groups = append(groups, combined)
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// Storex is part of Storable
+func (m MapNote) Storex() string {
+	if strings.Contains(m.Expression, "'") {
+		return fmt.Sprintf("mapnote(%q,%s)", m.Expression, core.Storex(m.Target))
+	}
+	return fmt.Sprintf("mapnote('%s',%s)", m.Expression, core.Storex(m.Target))
+}
+
+// Replaced is part of Replaceable
+func (m MapNote) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(m, from) {
+		return to
+	}
+	return MapNote{Expression: m.Expression, Target: replacedAll([]core.Sequenceable{m.Target}, from, to)[0], Mapper: m.Mapper}
+}
@@ -0,0 +1,114 @@
+package op
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/emicklei/melrose/core"
+)
+
+/**
+
+genmelody(8,scale('c'),progression('c','2I 2V'),42) = 8 quarter notes ; C, E or G on beat 1 and G, B or D on beat 3
+
+**/
+
+// melodyRange is the number of semitones above the root of the scale in which the melody moves.
+const melodyRange = 19
+
+// Melody generates quarter notes that move in steps along a scale.
+// Notes on strong beats (1 and 3) are tones of the chord that sounds at the same position in a progression,
+// the others are passing tones from the scale. The same seed always generates the same melody.
+type Melody struct {
+	length      core.HasValue // number of quarter notes
+	scale       core.HasValue
+	progression core.HasValue
+	seed        core.HasValue
+}
+
+func NewMelody(length, scale, progression, seed core.HasValue) Melody {
+	return Melody{length: length, scale: scale, progression: progression, seed: seed}
+}
+
+// S is part of core.Sequenceable
+func (m Melody) S() core.Sequence {
+	sc, ok := core.ValueOf(m.scale).(core.Scale)
+	if !ok {
+		return core.EmptySequence
+	}
+	ladder := scaleLadder(sc)
+	if len(ladder) == 0 {
+		return core.EmptySequence
+	}
+	spans, length := chordSpans(core.ToSequenceable(m.progression).S())
+	r := rand.New(rand.NewSource(int64(core.Int(m.seed))))
+	groups := [][]core.Note{}
+	index := 0
+	for i := 0; i < core.Int(m.length); i++ {
+		position := float64(i) * quarter
+		if i > 0 {
+			// a step of at most two degrees up or down, bouncing off the ends of the range
+			index += r.Intn(5) - 2
+			if index < 0 {
+				index = -index
+			}
+			if last := len(ladder) - 1; index > last {
+				index = 2*last - index
+			}
+		}
+		pitch := ladder[index]
+		if len(spans) > 0 && isStrongBeat(position, 4) {
+			pitch += semitonesToNearest(pitch, activeChord(spans, length, position))
+			index = nearestIndex(ladder, pitch)
+		}
+		n, err := core.MIDItoNote(quarter, pitch, core.Normal)
+		if err != nil {
+			continue
+		}
+		groups = append(groups, []core.Note{n})
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// scaleLadder returns the ascending pitches of a scale within the melody range.
+func scaleLadder(sc core.Scale) (ladder []int) {
+	notes := sc.S().Notes
+	if len(notes) == 0 {
+		return
+	}
+	root := notes[0][0].MIDI()
+	for octave := 0; octave*12 <= melodyRange; octave++ {
+		for _, each := range notes {
+			if p := each[0].MIDI() + octave*12; p-root <= melodyRange && p <= 127 {
+				ladder = append(ladder, p)
+			}
+		}
+	}
+	sort.Ints(ladder)
+	return
+}
+
+// nearestIndex returns the index of the pitch in the ladder that is nearest.
+func nearestIndex(ladder []int, pitch int) int {
+	best := 0
+	for i, each := range ladder {
+		if abs(each-pitch) < abs(ladder[best]-pitch) {
+			best = i
+		}
+	}
+	return best
+}
+
+// Storex is part of core.Storable
+func (m Melody) Storex() string {
+	return fmt.Sprintf("genmelody(%s,%s,%s,%s)", core.Storex(m.length), core.Storex(m.scale), core.Storex(m.progression), core.Storex(m.seed))
+}
+
+// Replaced is part of Replaceable
+func (m Melody) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(m, from) {
+		return to
+	}
+	return Melody{length: m.length, scale: m.scale, progression: replacedValue(m.progression, from, to), seed: m.seed}
+}
@@ -0,0 +1,54 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestMelody_ChordTonesOnStrongBeats(t *testing.T) {
+	sc, _ := core.NewScale("C")
+	p := core.NewChordProgression(core.On("c"), core.On("2I 2IV 2V 2I"))
+	for seed := 0; seed < 20; seed++ {
+		m := NewMelody(core.On(16), core.On(sc), core.On(p), core.On(seed))
+		s := m.S()
+		if got, want := len(s.Notes), 16; got != want {
+			t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+		chords := [][]int{{0, 4, 7}, {5, 9, 0}, {7, 11, 2}, {0, 4, 7}}
+		for i, each := range s.Notes {
+			class := each[0].MIDI() % 12
+			if i%2 == 0 {
+				if !containsInt(chords[(i/2)%4], class) {
+					t.Errorf("seed %d, note %d: %s is not a chord tone", seed, i, each[0])
+				}
+				continue
+			}
+			if !containsInt([]int{0, 2, 4, 5, 7, 9, 11}, class) {
+				t.Errorf("seed %d, note %d: %s is not in the scale", seed, i, each[0])
+			}
+		}
+	}
+}
+
+func TestMelody_Reproducible(t *testing.T) {
+	sc, _ := core.NewScale("A/m")
+	p := core.NewChordProgression(core.On("a/m"), core.On("1i 1iv"))
+	a := NewMelody(core.On(8), core.On(sc), core.On(p), core.On(42))
+	b := NewMelody(core.On(8), core.On(sc), core.On(p), core.On(42))
+	if got, want := a.S().Storex(), b.S().Storex(); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := a.Storex(), "genmelody(8,scale('major A/m'),progression('a/m','1i 1iv'),42)"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func containsInt(list []int, i int) bool {
+	for _, each := range list {
+		if each == i {
+			return true
+		}
+	}
+	return false
+}
@@ -148,7 +148,7 @@ func (n NoteMap) S() core.Sequence {
 		var err error
 		note, err = notelike.ToNote()
 		if err != nil {
-			notify.Panic(err)
+			notify.Console.Errorf("cannot map %v: %v", n.Target.Value(), err)
 			return core.EmptySequence
 		}
 	}
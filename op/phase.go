@@ -0,0 +1,90 @@
+package op
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// Phase starts the target later by an amount in each iteration of a loop, wrapping around its length,
+// such that it slowly shifts against an unshifted copy in another loop.
+// It uses the position of the context in which it is planned.
+type Phase struct {
+	Delta  core.HasValue // fraction of a whole note ; e.g. 16 is a sixteenth
+	Target core.Sequenceable
+}
+
+// S is part of Sequenceable ; without a position the target is not shifted.
+func (p Phase) S() core.Sequence {
+	return p.Target.S()
+}
+
+// SWith is part of ContextSequenceable
+func (p Phase) SWith(ctx core.Context) core.Sequence {
+	seq := p.Target.S()
+	pos, ok := core.PositionOf(ctx)
+	if !ok || pos.Iteration == 0 {
+		return seq
+	}
+	length := seq.DurationFactor()
+	if length <= 0 {
+		return seq
+	}
+	return rotated(seq, math.Mod(float64(pos.Iteration)*p.delta(), length))
+}
+
+func (p Phase) delta() float64 {
+	f := core.Float(p.Delta)
+	if f > 1.0 {
+		f = 1.0 / f
+	}
+	return float64(f)
+}
+
+// rotated returns the sequence that starts at a position and continues from its start up to that position.
+// The part of a group that sounds at the position becomes a rest ; the group itself is shortened at the end.
+func rotated(seq core.Sequence, shift float64) core.Sequence {
+	if shift < positionEpsilon {
+		return seq
+	}
+	head, tail := [][]core.Note{}, [][]core.Note{}
+	position := 0.0
+	for _, group := range seq.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		length := float64(group[0].DurationFactor())
+		switch {
+		case position >= shift-positionEpsilon:
+			head = append(head, group)
+		case position+length <= shift+positionEpsilon:
+			tail = append(tail, group)
+		default:
+			// the group is cut at the shift
+			rest := core.Rest4.WithFraction(float32(position+length-shift), false)
+			head = append([][]core.Note{{rest}}, head...)
+			factor := float32((shift - position) / length)
+			shortened := []core.Note{}
+			for _, each := range group {
+				shortened = append(shortened, each.Stretched(factor))
+			}
+			tail = append(tail, shortened)
+		}
+		position += length
+	}
+	return core.Sequence{Notes: append(head, tail...)}
+}
+
+// Storex is part of Storable
+func (p Phase) Storex() string {
+	return fmt.Sprintf("phase(%s,%s)", core.Storex(p.Delta), core.Storex(p.Target))
+}
+
+// Replaced is part of Replaceable
+func (p Phase) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(p, from) {
+		return to
+	}
+	return Phase{Delta: p.Delta, Target: replacedAll([]core.Sequenceable{p.Target}, from, to)[0]}
+}
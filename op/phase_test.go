@@ -0,0 +1,27 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestPhase_Iterations(t *testing.T) {
+	p := Phase{Delta: core.On(16), Target: core.MustParseSequence("8c 8d 8e 8f")}
+	if got, want := p.S().Storex(), "sequence('8C 8D 8E 8F')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	second := p.SWith(core.WithPosition(core.PlayContext{}, core.Position{Iteration: 1}))
+	if got, want := durationsOf(second), []float64{0.0625, 0.125, 0.125, 0.125, 0.0625}; !equalFloats(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	third := p.SWith(core.WithPosition(core.PlayContext{}, core.Position{Iteration: 2}))
+	if got, want := third.Storex(), "sequence('8D 8E 8F 8C')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// wraps around
+	ninth := p.SWith(core.WithPosition(core.PlayContext{}, core.Position{Iteration: 8}))
+	if got, want := ninth.Storex(), "sequence('8C 8D 8E 8F')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,134 @@
+package op
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// Polyrhythm plays two objects at the same time, each evenly filling the length of the longest,
+// such as 3 notes against 4 notes.
+type Polyrhythm struct {
+	a, b core.HasValue
+}
+
+func NewPolyrhythm(a, b core.HasValue) Polyrhythm {
+	return Polyrhythm{a: a, b: b}
+}
+
+// onsetNotes are the notes that start at the same position.
+type onsetNotes struct {
+	position float64
+	notes    []core.Note
+}
+
+// S is part of core.Sequenceable
+// Each group starts with a rest that lasts until the next group if its notes are longer ;
+// notes that are shorter are lengthened until the next group.
+func (p Polyrhythm) S() core.Sequence {
+	sa, sb := core.ToSequenceable(p.a).S(), core.ToSequenceable(p.b).S()
+	span := math.Max(sa.DurationFactor(), sb.DurationFactor())
+	if span <= 0 {
+		return core.EmptySequence
+	}
+	onsets := append(spreadOnsets(sa, span), spreadOnsets(sb, span)...)
+	sort.SliceStable(onsets, func(i, j int) bool { return onsets[i].position < onsets[j].position })
+	// combine the notes of both that start together
+	merged := []onsetNotes{}
+	for _, each := range onsets {
+		if last := len(merged) - 1; last >= 0 && math.Abs(merged[last].position-each.position) < positionEpsilon {
+			merged[last].notes = append(merged[last].notes, each.notes...)
+			continue
+		}
+		merged = append(merged, each)
+	}
+	groups := [][]core.Note{}
+	if len(merged) == 0 || merged[0].position > positionEpsilon {
+		first := span
+		if len(merged) > 0 {
+			first = merged[0].position
+		}
+		groups = append(groups, []core.Note{core.Rest4.WithFraction(float32(first), false)})
+	}
+	for i, each := range merged {
+		next := span
+		if i+1 < len(merged) {
+			next = merged[i+1].position
+		}
+		gap := next - each.position
+		group := []core.Note{}
+		exact := true
+		for _, n := range each.notes {
+			length := float64(n.DurationFactor())
+			if gap-length > positionEpsilon {
+				n = n.Stretched(float32(gap / length))
+				length = gap
+			}
+			if length-gap > positionEpsilon {
+				exact = false
+			}
+			group = append(group, n)
+		}
+		if !exact {
+			group = append([]core.Note{core.Rest4.WithFraction(float32(gap), false)}, group...)
+		}
+		groups = append(groups, group)
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// spreadOnsets returns the hearable notes of a sequence with their positions, stretched to fill the span.
+func spreadOnsets(s core.Sequence, span float64) (list []onsetNotes) {
+	length := s.DurationFactor()
+	if length <= 0 {
+		return
+	}
+	factor := float32(span / length)
+	position := 0.0
+	for _, group := range s.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		notes := []core.Note{}
+		for _, each := range group {
+			if each.IsHearable() {
+				notes = append(notes, each.Stretched(factor))
+			}
+		}
+		if len(notes) > 0 {
+			list = append(list, onsetNotes{position: position, notes: notes})
+		}
+		position += float64(group[0].DurationFactor()) * float64(factor)
+	}
+	return
+}
+
+// Storex is part of core.Storable
+func (p Polyrhythm) Storex() string {
+	return fmt.Sprintf("polyrhythm(%s,%s)", core.Storex(p.a), core.Storex(p.b))
+}
+
+// Replaced is part of Replaceable
+func (p Polyrhythm) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(p, from) {
+		return to
+	}
+	return Polyrhythm{a: replacedValue(p.a, from, to), b: replacedValue(p.b, from, to)}
+}
+
+// replacedValue returns the value with any occurrences of "from" replaced by "to".
+func replacedValue(v core.HasValue, from, to core.Sequenceable) core.HasValue {
+	s, ok := v.Value().(core.Sequenceable)
+	if !ok {
+		return v
+	}
+	if core.IsIdenticalTo(s, from) {
+		return core.On(to)
+	}
+	if rep, ok := s.(core.Replaceable); ok {
+		return core.On(rep.Replaced(from, to))
+	}
+	return v
+}
@@ -0,0 +1,35 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestPolyrhythm_ThreeAgainstFour(t *testing.T) {
+	three := core.MustParseSequence("c5 c5 c5")
+	four := core.MustParseSequence("c3 c3 c3 c3")
+	p := NewPolyrhythm(core.On(three), core.On(four))
+	s := p.S()
+	if got, want := durationsOf(s), []float64{0.25, 0.0833, 0.1667, 0.1667, 0.0833, 0.25}; !equalFloats(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// the first group has both notes after a rest until the second
+	first := s.Notes[0]
+	if got, want := len(first), 3; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := first[1].DurationFactor(), float32(1.0/3); !equalFloats([]float64{float64(got)}, []float64{float64(want)}) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := p.Storex(), "polyrhythm(sequence('C5 C5 C5'),sequence('C3 C3 C3 C3'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestPolyrhythm_LeadingRest(t *testing.T) {
+	p := NewPolyrhythm(core.On(core.MustParseSequence("= c")), core.On(core.MustParseSequence("= = = d")))
+	if got, want := durationsOf(p.S()), []float64{0.5, 0.25, 0.25}; !equalFloats(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
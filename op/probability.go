@@ -2,8 +2,6 @@ package op
 
 import (
 	"fmt"
-	"math/rand"
-	"time"
 
 	"github.com/emicklei/melrose/core"
 )
@@ -15,14 +13,13 @@ prob(0.8,note('c')) =  a sequence with 80% chance of playing C
 **/
 type Probability struct {
 	chance core.HasValue
-	seed   *rand.Rand
+	seed   core.RandomSource // if nil then the current source is used
 	target core.HasValue
 }
 
 func NewProbability(chance, target core.HasValue) *Probability {
 	return &Probability{
 		chance: chance,
-		seed:   rand.New(rand.NewSource(time.Now().Unix())),
 		target: target,
 	}
 }
@@ -56,6 +53,7 @@ func (p *Probability) hit() bool {
 	if f > 1 {
 		f = f / 100.0
 	}
-	a := p.seed.Float32()
+	f = float32(core.DensityScaled(float64(f), 1))
+	a := float32(randomFloat64(p.seed))
 	return a <= f
 }
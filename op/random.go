@@ -2,8 +2,6 @@ package op
 
 import (
 	"fmt"
-	"math/rand"
-	"time"
 
 	"github.com/emicklei/melrose/core"
 )
@@ -11,7 +9,7 @@ import (
 type RandomInteger struct {
 	From core.HasValue
 	To   core.HasValue
-	rnd  *rand.Rand
+	rnd  core.RandomSource // if nil then the current source is used
 	last int
 }
 
@@ -19,7 +17,6 @@ func NewRandomInteger(from, to core.HasValue) *RandomInteger {
 	rnd := &RandomInteger{
 		From: from,
 		To:   to,
-		rnd:  rand.New(rand.NewSource(time.Now().Unix())),
 	}
 	rnd.Next()
 	return rnd
@@ -43,8 +40,16 @@ func (r *RandomInteger) Next() interface{} {
 		r.last = f
 		return f
 	}
-	r.last = f + r.rnd.Intn(t-f+1)
+	r.last = f + int(randomFloat64(r.rnd)*float64(t-f+1))
 	return r.last
 }
 
 // TODO  Replaceable
+
+// randomFloat64 returns the next number from a source or the current source if nil.
+func randomFloat64(source core.RandomSource) float64 {
+	if source == nil {
+		return core.RandomFloat64()
+	}
+	return source.Float64()
+}
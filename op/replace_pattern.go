@@ -0,0 +1,167 @@
+package op
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// Kinds of replace patterns.
+const (
+	pitchPattern  = iota // e.g. F#, every note of the pitch class in any octave
+	rhythmPattern        // e.g. 8. , every note and rest with that length
+	chordPattern         // e.g. /m7, every group that is a chord of that quality in root position
+)
+
+// replacePattern matches notes or groups of notes.
+type replacePattern struct {
+	kind       int
+	pitchClass int       // 0..11
+	length     core.Note // a rest with the fraction and dotted of the rhythm value
+	intervals  []int     // semitones above the root of a chord
+}
+
+// parseReplacePattern returns the pattern of a pitch class (F#), a rhythm value (8.) or a chord quality (/m7).
+func parseReplacePattern(s string) (replacePattern, error) {
+	switch {
+	case strings.HasPrefix(s, "/"):
+		c, err := core.ParseChord("C" + s)
+		if err != nil {
+			return replacePattern{}, fmt.Errorf("invalid chord quality [%s]: %v", s, err)
+		}
+		notes := c.Notes()
+		intervals := []int{}
+		for _, each := range notes[1:] {
+			intervals = append(intervals, each.MIDI()-notes[0].MIDI())
+		}
+		return replacePattern{kind: chordPattern, intervals: intervals}, nil
+	case len(s) > 0 && strings.Trim(s, "0123456789.") == "":
+		n, err := core.ParseNote(s + "=")
+		if err != nil {
+			return replacePattern{}, fmt.Errorf("invalid rhythm value [%s]: %v", s, err)
+		}
+		return replacePattern{kind: rhythmPattern, length: n}, nil
+	default:
+		n, err := core.ParseNote(s)
+		if err != nil || !n.IsHearable() || !strings.EqualFold(s, n.Name+accidentalOf(s)) {
+			return replacePattern{}, fmt.Errorf("invalid pattern [%s], expected a pitch class (F#), rhythm value (8.) or chord quality (/m7)", s)
+		}
+		return replacePattern{kind: pitchPattern, pitchClass: n.MIDI() % 12}, nil
+	}
+}
+
+// accidentalOf returns the sharp or flat at the end of a pitch class, if any.
+func accidentalOf(s string) string {
+	if strings.HasSuffix(s, "#") || strings.HasSuffix(s, "_") {
+		return s[len(s)-1:]
+	}
+	return ""
+}
+
+// CheckReplacePatterns returns an error if the patterns are invalid or not of the same kind.
+func CheckReplacePatterns(from, to string) error {
+	f, err := parseReplacePattern(from)
+	if err != nil {
+		return err
+	}
+	t, err := parseReplacePattern(to)
+	if err != nil {
+		return err
+	}
+	if f.kind != t.kind {
+		return fmt.Errorf("cannot replace [%s] by [%s], patterns must be of the same kind", from, to)
+	}
+	return nil
+}
+
+// ReplacePattern replaces every pitch class, rhythm value or chord quality in the notes of a musical object.
+type ReplacePattern struct {
+	Target   core.Sequenceable
+	From, To string
+}
+
+// S is part of Sequenceable
+func (r ReplacePattern) S() core.Sequence {
+	seq := r.Target.S()
+	from, err := parseReplacePattern(r.From)
+	if err != nil {
+		return seq
+	}
+	to, err := parseReplacePattern(r.To)
+	if err != nil || from.kind != to.kind {
+		return seq
+	}
+	groups := [][]core.Note{}
+	for _, group := range seq.Notes {
+		groups = append(groups, replacedGroup(group, from, to))
+	}
+	return core.Sequence{Notes: groups}
+}
+
+func replacedGroup(group []core.Note, from, to replacePattern) []core.Note {
+	if from.kind == chordPattern {
+		root, ok := chordRootOf(group, from.intervals)
+		if !ok {
+			return group
+		}
+		chord := []core.Note{root}
+		for _, each := range to.intervals {
+			chord = append(chord, root.Pitched(each))
+		}
+		return chord
+	}
+	changed := []core.Note{}
+	for _, each := range group {
+		switch from.kind {
+		case pitchPattern:
+			if each.IsHearable() && each.MIDI()%12 == from.pitchClass {
+				// to the nearest note of the pitch class
+				up := ((to.pitchClass-from.pitchClass)%12 + 12) % 12
+				if up > 6 {
+					up -= 12
+				}
+				each = each.Pitched(up)
+			}
+		case rhythmPattern:
+			if each.Fraction() == from.length.Fraction() && each.Dotted == from.length.Dotted {
+				each = each.WithFraction(to.length.Fraction(), to.length.Dotted)
+			}
+		}
+		changed = append(changed, each)
+	}
+	return changed
+}
+
+// chordRootOf returns the lowest note of a group if the others are the intervals above it.
+func chordRootOf(group []core.Note, intervals []int) (core.Note, bool) {
+	if len(group) != len(intervals)+1 {
+		return core.Rest4, false
+	}
+	sorted := append([]core.Note{}, group...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MIDI() < sorted[j].MIDI() })
+	root := sorted[0]
+	if !root.IsHearable() {
+		return core.Rest4, false
+	}
+	for i, each := range sorted[1:] {
+		if each.MIDI()-root.MIDI() != intervals[i] {
+			return core.Rest4, false
+		}
+	}
+	return root, true
+}
+
+// Storex is part of Storable
+func (r ReplacePattern) Storex() string {
+	return fmt.Sprintf("replace(%s,'%s','%s')", core.Storex(r.Target), r.From, r.To)
+}
+
+// Replaced is part of Replaceable
+func (r ReplacePattern) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(r, from) {
+		return to
+	}
+	return ReplacePattern{Target: replacedAll([]core.Sequenceable{r.Target}, from, to)[0], From: r.From, To: r.To}
+}
@@ -73,3 +73,28 @@ func TestReplace_JSON(t *testing.T) {
 	r := Replace{Target: p, From: c, To: d}
 	json.NewEncoder(os.Stdout).Encode(r)
 }
+
+func TestReplacePattern(t *testing.T) {
+	for _, each := range []struct {
+		target, from, to, want string
+	}{
+		{"f g f5 a", "f", "f#", "G_ G G_5 A"},
+		{"b c", "b", "c", "C5 C"},
+		{"8c 8d e 8=", "8", "16.", "16.C 16.D E 16.="},
+		{"(c e g) (d f a) d", "/m", "/m7", "(C E G) (D F A C5) D"},
+		{"(c e g) (e g c5)", "/", "/m", "(C E_ G) (E G C5)"},
+	} {
+		r := ReplacePattern{Target: core.MustParseSequence(each.target), From: each.from, To: each.to}
+		if got, want := r.S().String(), each.want; got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each.from, got, got, want, want)
+		}
+	}
+}
+
+func TestCheckReplacePatterns(t *testing.T) {
+	for _, each := range [][2]string{{"f", "8"}, {"c4", "d"}, {"8c", "d"}, {"/x", "/m"}} {
+		if err := CheckReplacePatterns(each[0], each[1]); err == nil {
+			t.Errorf("%v: error expected", each)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package op
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// noteCondition compares a property of a note with one or more values.
+type noteCondition struct {
+	property string // pitch, velocity, beat or duration
+	operator string // == != < <= > >= in
+	values   []float64
+}
+
+// notePredicate is true if all its conditions are true.
+type notePredicate []noteCondition
+
+var conditionOperators = []string{"==", "!=", "<=", ">=", "<", ">", " in "}
+
+// ParseNotePredicate returns a predicate such as "pitch>C4", "velocity<64", "beat in [1,3]" or "duration==16".
+// Conditions can be combined with "and". A pitch is a note or a MIDI number, a beat is one-based in a bar
+// and a duration is a note length such as 8 or 8. that compares by length ; duration<4 is shorter than a quarter.
+func ParseNotePredicate(s string) (notePredicate, error) {
+	p := notePredicate{}
+	for _, each := range strings.Split(s, " and ") {
+		c, err := parseNoteCondition(strings.TrimSpace(each))
+		if err != nil {
+			return p, err
+		}
+		p = append(p, c)
+	}
+	return p, nil
+}
+
+func parseNoteCondition(s string) (noteCondition, error) {
+	for _, op := range conditionOperators {
+		i := strings.Index(s, op)
+		if i == -1 {
+			continue
+		}
+		c := noteCondition{
+			property: strings.TrimSpace(s[:i]),
+			operator: strings.TrimSpace(op),
+		}
+		literal := strings.TrimSpace(s[i+len(op):])
+		literals := []string{literal}
+		if c.operator == "in" {
+			if !strings.HasPrefix(literal, "[") || !strings.HasSuffix(literal, "]") {
+				return c, fmt.Errorf("expected a list such as [1,3] after in, got [%s]", literal)
+			}
+			literals = strings.Split(literal[1:len(literal)-1], ",")
+		}
+		for _, each := range literals {
+			v, err := conditionValue(c.property, strings.TrimSpace(each))
+			if err != nil {
+				return c, err
+			}
+			c.values = append(c.values, v)
+		}
+		return c, nil
+	}
+	return noteCondition{}, fmt.Errorf("invalid condition [%s], expected a property, an operator (%s) and a value", s, strings.Join(conditionOperators, ""))
+}
+
+// conditionValue returns the number to compare with a property of a note.
+func conditionValue(property, literal string) (float64, error) {
+	switch property {
+	case "pitch":
+		if nr, err := strconv.Atoi(literal); err == nil {
+			return float64(nr), nil
+		}
+		n, err := core.ParseNote(literal)
+		if err != nil || !n.IsHearable() {
+			return 0, fmt.Errorf("invalid pitch [%s], expected a note or MIDI number", literal)
+		}
+		return float64(n.MIDI()), nil
+	case "velocity", "beat":
+		v, err := strconv.Atoi(literal)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s [%s], expected an integer", property, literal)
+		}
+		return float64(v), nil
+	case "duration":
+		n, err := core.ParseNote(literal + "=")
+		if err != nil || strings.Trim(literal, "0123456789.") != "" {
+			return 0, fmt.Errorf("invalid duration [%s], expected a note length such as 8 or 8.", literal)
+		}
+		return float64(n.DurationFactor()), nil
+	}
+	return 0, fmt.Errorf("unknown property [%s], expected pitch, velocity, beat or duration", property)
+}
+
+// matches returns whether a note at a (one-based) beat meets all conditions.
+func (p notePredicate) matches(n core.Note, beat int) bool {
+	for _, each := range p {
+		var v float64
+		switch each.property {
+		case "pitch":
+			v = float64(n.MIDI())
+		case "velocity":
+			v = float64(n.Velocity)
+		case "beat":
+			v = float64(beat)
+		case "duration":
+			v = float64(n.DurationFactor())
+		}
+		if !each.holds(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c noteCondition) holds(v float64) bool {
+	const epsilon = 1e-4
+	w := c.values[0]
+	switch c.operator {
+	case "==":
+		return math.Abs(v-w) < epsilon
+	case "!=":
+		return math.Abs(v-w) >= epsilon
+	case "<":
+		return v < w-epsilon
+	case "<=":
+		return v < w+epsilon
+	case ">":
+		return v > w+epsilon
+	case ">=":
+		return v > w-epsilon
+	case "in":
+		for _, each := range c.values {
+			if math.Abs(v-each) < epsilon {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Select keeps the notes that match a predicate ; the others become rests.
+type Select struct {
+	Predicate string
+	Target    core.Sequenceable
+}
+
+// S is part of Sequenceable ; beats are counted from the start of the target in bars of 4.
+func (s Select) S() core.Sequence {
+	return s.selected(core.Position{BIAB: 4})
+}
+
+// SWith is part of ContextSequenceable ; beats are counted from the position, if known.
+func (s Select) SWith(ctx core.Context) core.Sequence {
+	p, ok := core.PositionOf(ctx)
+	if !ok || p.BIAB <= 0 {
+		p = core.Position{BIAB: ctx.Control().BIAB()}
+	}
+	return s.selected(p)
+}
+
+func (s Select) selected(at core.Position) core.Sequence {
+	seq := s.Target.S()
+	predicate, err := ParseNotePredicate(s.Predicate)
+	if err != nil {
+		return seq
+	}
+	groups := [][]core.Note{}
+	position := 0.0 // in quarters
+	for _, group := range seq.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		lead := group[0]
+		if lead.IsPedal() {
+			groups = append(groups, group)
+			continue
+		}
+		beat := (int(at.Beat)+int(math.Floor(position+1e-6)))%at.BIAB + 1
+		kept := []core.Note{}
+		for _, each := range group {
+			if each.IsHearable() && predicate.matches(each, beat) {
+				kept = append(kept, each)
+			}
+		}
+		if len(kept) == 0 || kept[0].DurationFactor() != lead.DurationFactor() {
+			// keep the timing of the group
+			kept = append([]core.Note{lead.ToRest()}, kept...)
+		}
+		groups = append(groups, kept)
+		position += float64(lead.DurationFactor()) * 4
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// Storex is part of Storable
+func (s Select) Storex() string {
+	return fmt.Sprintf("select('%s',%s)", s.Predicate, core.Storex(s.Target))
+}
+
+// Replaced is part of Replaceable
+func (s Select) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(s, from) {
+		return to
+	}
+	return Select{Predicate: s.Predicate, Target: replacedAll([]core.Sequenceable{s.Target}, from, to)[0]}
+}
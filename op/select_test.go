@@ -0,0 +1,41 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestSelect(t *testing.T) {
+	drums := core.MustParseSequence("(c2 f#2) f#2 (d2 f#2) 8f#2+ 8c2")
+	for _, each := range []struct {
+		predicate, want string
+	}{
+		{"pitch==C2", "C2 = = 8= 8C2"},
+		{"pitch>36", "F#2 F#2 (D2 F#2) 8F#2+ 8="},
+		{"beat in [1,3]", "(C2 F#2) = (D2 F#2) 8= 8="},
+		{"duration==8 and velocity>60", "= = = 8F#2+ 8="},
+		{"duration<4", "= = = 8F#2+ 8C2"},
+	} {
+		s := Select{Predicate: each.predicate, Target: drums}
+		if got, want := s.S().String(), each.want; got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each.predicate, got, got, want, want)
+		}
+	}
+}
+
+func TestSelect_Position(t *testing.T) {
+	s := Select{Predicate: "beat==1", Target: core.MustParseSequence("c d")}
+	at := core.WithPosition(core.PlayContext{}, core.Position{Beat: 3, BIAB: 4})
+	if got, want := s.SWith(at).String(), "= D"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestParseNotePredicate_Errors(t *testing.T) {
+	for _, each := range []string{"pitch", "color==red", "pitch==X", "beat in 1,3", "duration==8c", "velocity>loud"} {
+		if _, err := ParseNotePredicate(each); err == nil {
+			t.Errorf("%s: error expected", each)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package op
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+/**
+
+unless(snare,hihat) = hihat with rests where snare has a hit
+with(kick,bass)     = bass with rests where kick has no hit
+
+**/
+
+// Sidechain changes notes into rests depending on whether another object (typically another loop)
+// has a hit at the same position. Positions of the other object repeat, as if it was looping along.
+// If the other object is a running loop then its hits are those that loop has scheduled, taken from where that loop is
+// when this object is played, else both are aligned at their start.
+type Sidechain struct {
+	other  core.HasValue
+	target core.HasValue
+	// if true then notes play only when the other has a hit, else notes are silenced when the other has a hit
+	with bool
+}
+
+func NewSidechain(other, target core.HasValue, with bool) Sidechain {
+	return Sidechain{other: other, target: target, with: with}
+}
+
+// positionEpsilon is used to compare positions expressed in whole note fractions
+const positionEpsilon = 1e-4
+
+// S is part of core.Sequenceable
+func (s Sidechain) S() core.Sequence {
+	hits, length := hitPositions(core.ToSequenceable(s.other).S())
+	return s.sidechained(hits, length, 0)
+}
+
+// SWith is part of core.ContextSequenceable
+func (s Sidechain) SWith(ctx core.Context) core.Sequence {
+	other, ok := core.ValueOf(s.other).(*core.Loop)
+	if !ok {
+		return s.S()
+	}
+	p, ok := core.PositionOf(ctx)
+	if !ok || p.Start.IsZero() {
+		return s.S()
+	}
+	iteration, notes, offset, playing := other.PlayedAt(p.Start)
+	if !playing {
+		// the other loop has no hits
+		return s.sidechained(nil, 0, 0)
+	}
+	if core.IsDebug() {
+		notify.Debugf("sidechain: other loop is at iteration %d, %.3f whole notes", iteration, offset)
+	}
+	hits, length := hitPositions(notes)
+	return s.sidechained(hits, length, offset)
+}
+
+// sidechained returns the notes of the target with the hits of the other, in which the target starts at an offset.
+func (s Sidechain) sidechained(hits []float64, length, offset float64) core.Sequence {
+	seq := core.ToSequenceable(s.target).S()
+	groups := [][]core.Note{}
+	position := offset
+	for _, group := range seq.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		if isHearable(group) && s.with != hasHitAt(hits, length, position) {
+			groups = append(groups, []core.Note{group[0].ToRest()})
+		} else {
+			groups = append(groups, group)
+		}
+		position += float64(group[0].DurationFactor())
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// hitPositions returns the start positions of all groups with a hearable note and the total length.
+func hitPositions(seq core.Sequence) (hits []float64, length float64) {
+	for _, group := range seq.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		if isHearable(group) {
+			hits = append(hits, length)
+		}
+		length += float64(group[0].DurationFactor())
+	}
+	return
+}
+
+func hasHitAt(hits []float64, length, position float64) bool {
+	if length <= 0 {
+		return false
+	}
+	position = math.Mod(position, length)
+	if length-position < positionEpsilon {
+		position = 0
+	}
+	for _, each := range hits {
+		if math.Abs(each-position) < positionEpsilon {
+			return true
+		}
+	}
+	return false
+}
+
+func isHearable(group []core.Note) bool {
+	for _, each := range group {
+		if each.IsHearable() {
+			return true
+		}
+	}
+	return false
+}
+
+// Storex is part of core.Storable
+func (s Sidechain) Storex() string {
+	name := "unless"
+	if s.with {
+		name = "with"
+	}
+	return fmt.Sprintf("%s(%s,%s)", name, core.Storex(s.other), core.Storex(s.target))
+}
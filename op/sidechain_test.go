@@ -0,0 +1,103 @@
+package op
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestSidechain_OtherRepeats(t *testing.T) {
+	other := core.MustParseSequence("c =")
+	target := core.MustParseSequence("e e e e")
+	s := NewSidechain(core.On(other), core.On(target), false)
+	if got, want := core.Storex(s.S()), "sequence('= E = E')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestSidechain_EmptyOther(t *testing.T) {
+	target := core.MustParseSequence("e e")
+	s := NewSidechain(core.On(core.EmptySequence), core.On(target), true)
+	if got, want := core.Storex(s.S()), "sequence('= =')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+// timingDevice plays by returning the end time of a sequence.
+type timingDevice struct {
+	core.AudioDevice
+}
+
+func (d timingDevice) Play(condition core.Condition, seq core.Sequenceable, bpm float64, beginAt time.Time) time.Time {
+	return beginAt.Add(time.Duration(float64(core.WholeNoteDuration(bpm)) * seq.S().DurationFactor()))
+}
+func (d timingDevice) Schedule(event core.TimelineEvent, beginAt time.Time) {}
+
+func TestSidechain_OtherLoopOfDifferentLength(t *testing.T) {
+	ctx := core.PlayContext{LoopControl: core.NoLooper, AudioDevice: timingDevice{}}
+	// two bars of snare against one bar of hi-hat
+	snare := core.NewLoop(ctx, []core.Sequenceable{core.MustParseSequence("= c = c c = c =")})
+	start := time.Now()
+	snare.Play(ctx, start)
+	defer snare.Stop(ctx)
+	s := NewSidechain(core.On(snare), core.On(core.MustParseSequence("e e e e")), false)
+	bar := 2 * time.Second // at 120 BPM
+	for i, want := range []string{
+		"sequence('E = E =')",
+		"sequence('= E = E')",
+		"sequence('E = E =')",
+	} {
+		at := core.WithPosition(ctx, core.Position{Start: start.Add(time.Duration(i) * bar), BIAB: 4})
+		if got := core.Storex(s.SWith(at)); got != want {
+			t.Errorf("bar %d: got [%v:%T] want [%v:%T]", i, got, got, want, want)
+		}
+	}
+	// hi-hat started on the second beat of the snare
+	at := core.WithPosition(ctx, core.Position{Start: start.Add(bar / 4), BIAB: 4})
+	if got, want := core.Storex(s.SWith(at)), "sequence('= E = =')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestSidechain_OtherLoopNotRunning(t *testing.T) {
+	ctx := core.PlayContext{LoopControl: core.NoLooper, AudioDevice: timingDevice{}}
+	snare := core.NewLoop(ctx, []core.Sequenceable{core.MustParseSequence("= c = c")})
+	s := NewSidechain(core.On(snare), core.On(core.MustParseSequence("e e e e")), false)
+	at := core.WithPosition(ctx, core.Position{Start: time.Now(), BIAB: 4})
+	if got, want := core.Storex(s.SWith(at)), "sequence('E E E E')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+// alternating returns other notes each time they are created, like a target using next.
+type alternating struct {
+	calls *int
+}
+
+func (a alternating) S() core.Sequence {
+	*a.calls++
+	if *a.calls%2 == 1 {
+		return core.MustParseSequence("c = = =")
+	}
+	return core.MustParseSequence("= = = c")
+}
+
+func TestSidechain_OtherLoopAsScheduled(t *testing.T) {
+	ctx := core.PlayContext{LoopControl: core.NoLooper, AudioDevice: timingDevice{}}
+	calls := 0
+	snare := core.NewLoop(ctx, []core.Sequenceable{alternating{calls: &calls}})
+	start := time.Now()
+	snare.Play(ctx, start)
+	defer snare.Stop(ctx)
+	s := NewSidechain(core.On(snare), core.On(core.MustParseSequence("e e e e")), false)
+	at := core.WithPosition(ctx, core.Position{Start: start, BIAB: 4})
+	for i := 0; i < 2; i++ {
+		if got, want := core.Storex(s.SWith(at)), "sequence('= E E E')"; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	}
+	if got, want := calls, 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,95 @@
+package op
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/emicklei/melrose/core"
+)
+
+var toneRowForm = regexp.MustCompile(`^(P|I|R|RI)([0-9]|10|11)$`)
+
+// ToneRow is a row of distinct pitch classes, as used in twelve-tone composition, in one of its forms:
+// P (prime), I (inversion), R (retrograde) or RI (retrograde inversion), transposed by 0..11 semitones.
+// The pitches of a form are within the octave above the first note of the row ; durations and velocities are those of the row.
+type ToneRow struct {
+	Row  core.Sequence
+	Form string // e.g. P0, I5, R2, RI11
+}
+
+// NewToneRow returns the prime form of a row ; each note must be a single pitch class that is not repeated.
+func NewToneRow(row string) (ToneRow, error) {
+	seq, err := core.ParseSequence(row)
+	if err != nil {
+		return ToneRow{}, err
+	}
+	seen := map[int]bool{}
+	for _, group := range seq.Notes {
+		if len(group) != 1 || !group[0].IsHearable() {
+			return ToneRow{}, fmt.Errorf("a tone row must have single notes, got [%s]", core.Sequence{Notes: [][]core.Note{group}}.String())
+		}
+		pc := group[0].MIDI() % 12
+		if seen[pc] {
+			return ToneRow{}, fmt.Errorf("a tone row cannot repeat a pitch class, got [%s] again", group[0].String())
+		}
+		seen[pc] = true
+	}
+	if len(seen) == 0 {
+		return ToneRow{}, fmt.Errorf("a tone row must have notes")
+	}
+	return ToneRow{Row: seq, Form: "P0"}, nil
+}
+
+// CheckToneRowForm returns an error if the form is not one of P,I,R,RI followed by 0..11.
+func CheckToneRowForm(form string) error {
+	if !toneRowForm.MatchString(form) {
+		return fmt.Errorf("invalid tone row form [%s], expected P,I,R or RI followed by 0..11, e.g. RI3", form)
+	}
+	return nil
+}
+
+// WithForm returns the row in another form.
+func (t ToneRow) WithForm(form string) ToneRow {
+	return ToneRow{Row: t.Row, Form: form}
+}
+
+// S is part of Sequenceable
+func (t ToneRow) S() core.Sequence {
+	parts := toneRowForm.FindStringSubmatch(t.Form)
+	if len(parts) == 0 || len(t.Row.Notes) == 0 {
+		return t.Row
+	}
+	kind := parts[1]
+	n, _ := strconv.Atoi(parts[2])
+	first := t.Row.Notes[0][0].MIDI()
+	pitches := []int{}
+	for _, group := range t.Row.Notes {
+		p := group[0].MIDI()
+		if kind == "I" || kind == "RI" {
+			p = 2*first - p
+		}
+		pitches = append(pitches, p+n)
+	}
+	if kind == "R" || kind == "RI" {
+		for i, j := 0, len(pitches)-1; i < j; i, j = i+1, j-1 {
+			pitches[i], pitches[j] = pitches[j], pitches[i]
+		}
+	}
+	groups := [][]core.Note{}
+	for i, group := range t.Row.Notes {
+		// within the octave above the first note
+		target := first + ((pitches[i]-first)%12+12)%12
+		groups = append(groups, []core.Note{group[0].Pitched(target - group[0].MIDI())})
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// Storex is part of Storable
+func (t ToneRow) Storex() string {
+	row := t.Row.String()
+	if t.Form == "P0" {
+		return fmt.Sprintf("tonerow('%s')", row)
+	}
+	return fmt.Sprintf("tonerow('%s','%s')", row, t.Form)
+}
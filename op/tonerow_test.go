@@ -0,0 +1,49 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestToneRow_Forms(t *testing.T) {
+	row, err := NewToneRow("c d e_ 8f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, each := range []struct {
+		form, want string
+	}{
+		{"P0", "C D E_ 8F"},
+		{"P2", "D E F 8G"},
+		{"I0", "C B_ A 8G"},
+		{"R0", "F E_ D 8C"},
+		{"RI0", "G A B_ 8C"},
+		{"RI1", "A_ B_ B 8D_"},
+	} {
+		if got, want := row.WithForm(each.form).S().String(), each.want; got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each.form, got, got, want, want)
+		}
+	}
+	if got, want := row.WithForm("RI1").Storex(), "tonerow('C D E_ 8F','RI1')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestToneRow_Errors(t *testing.T) {
+	for _, each := range []string{"c d c5", "(c e) d", "c = d", ""} {
+		if _, err := NewToneRow(each); err == nil {
+			t.Errorf("%s: error expected", each)
+		}
+	}
+	if err := CheckToneRowForm("X1"); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestInvert(t *testing.T) {
+	i := Invert{Axis: core.On(core.MustParseNote("e")), Target: core.MustParseSequence("c (d e) = g5")}
+	if got, want := i.S().String(), "A_ (G_ E) = D_3"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -4,15 +4,28 @@ import (
 	"fmt"
 
 	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
 )
 
 type Transpose struct {
 	Target    core.Sequenceable
-	Semitones core.HasValue
+	Semitones core.HasValue // an integer or a named interval such as 'm3' or '-P5'
 }
 
 func (p Transpose) S() core.Sequence {
-	return p.Target.S().Pitched(core.Int(p.Semitones))
+	return p.Target.S().Pitched(semitonesOf(p.Semitones))
+}
+
+// semitonesOf returns the integer value or the semitones of a named interval.
+func semitonesOf(h core.HasValue) int {
+	if name, ok := core.ValueOf(h).(string); ok {
+		semitones, err := core.ParseInterval(name)
+		if err != nil {
+			notify.Warnf("cannot transpose, error: %v", err)
+		}
+		return semitones
+	}
+	return core.Int(h)
 }
 
 func (p Transpose) Storex() string {
@@ -8,7 +8,7 @@ import (
 
 func TestVelocityMap_S(t *testing.T) {
 	o := NewVelocityMap(core.MustParseSequence("C (D E) F"), "1:30,2:60")
-	if got, want := o.S().Storex(), "sequence('C--- (D+ E+)')"; got != want {
+	if got, want := o.S().Storex(), "sequence('C:v30 (D:v60 E:v60)')"; got != want {
 		t.Errorf("got [%v] want [%v]", got, want)
 	}
 
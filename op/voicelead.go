@@ -0,0 +1,141 @@
+package op
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/emicklei/melrose/core"
+)
+
+/**
+
+voicelead(progression('c','I IV V I')) = (C E G) (C F A) (D G B) (E G C5)
+
+**/
+
+// VoiceLead chooses the inversion and octave of each chord such that the voices move as little as possible
+// from the previous chord, like a keyboard player would, instead of parallel chords in root position.
+// The first chord is kept as is ; groups with less than two hearable notes are not changed.
+type VoiceLead struct {
+	progression core.HasValue
+}
+
+func NewVoiceLead(progression core.HasValue) VoiceLead {
+	return VoiceLead{progression: progression}
+}
+
+// S is part of core.Sequenceable
+func (v VoiceLead) S() core.Sequence {
+	seq := core.ToSequenceable(v.progression).S()
+	groups := [][]core.Note{}
+	var previous []int // pitches of the last chord, ascending
+	for _, group := range seq.Notes {
+		chord, others := []core.Note{}, []core.Note{}
+		for _, each := range group {
+			if each.IsHearable() {
+				chord = append(chord, each)
+			} else {
+				others = append(others, each)
+			}
+		}
+		if len(chord) < 2 {
+			groups = append(groups, group)
+			continue
+		}
+		sort.SliceStable(chord, func(i, j int) bool { return chord[i].MIDI() < chord[j].MIDI() })
+		if previous != nil {
+			chord = nearestVoicing(chord, previous)
+		}
+		previous = pitchesOf(chord)
+		groups = append(groups, append(others, chord...))
+	}
+	return core.Sequence{Notes: groups}
+}
+
+// nearestVoicing returns the inversion of an ascending chord, in any octave, with the least movement from the previous pitches.
+// If two voicings are equally near then the one closest to the register of the chord is taken.
+func nearestVoicing(chord []core.Note, previous []int) []core.Note {
+	center := meanPitch(pitchesOf(chord))
+	best, bestCost, bestDrift := chord, math.MaxInt32, math.MaxFloat64
+	for inversion := 0; inversion < len(chord); inversion++ {
+		for octave := -2; octave <= 2; octave++ {
+			voicing := []core.Note{}
+			for i, each := range chord {
+				up := octave * 12
+				if i < inversion {
+					up += 12
+				}
+				voicing = append(voicing, each.Pitched(up))
+			}
+			pitches := pitchesOf(voicing)
+			sort.Ints(pitches)
+			if pitches[0] < 0 || pitches[len(pitches)-1] > 127 {
+				continue
+			}
+			cost := voiceMovement(previous, pitches)
+			drift := math.Abs(meanPitch(pitches) - center)
+			if cost < bestCost || (cost == bestCost && drift < bestDrift) {
+				best, bestCost, bestDrift = voicing, cost, drift
+			}
+		}
+	}
+	sort.SliceStable(best, func(i, j int) bool { return best[i].MIDI() < best[j].MIDI() })
+	return best
+}
+
+// voiceMovement returns the total number of semitones that voices move between two ascending lists of pitches.
+// If the number of voices differs then each pitch is moved to the nearest of the other list.
+func voiceMovement(from, to []int) int {
+	if len(from) == len(to) {
+		sum := 0
+		for i := range from {
+			sum += abs(to[i] - from[i])
+		}
+		return sum
+	}
+	return nearestMovement(from, to) + nearestMovement(to, from)
+}
+
+func nearestMovement(from, to []int) int {
+	sum := 0
+	for _, f := range from {
+		nearest := math.MaxInt32
+		for _, t := range to {
+			if d := abs(t - f); d < nearest {
+				nearest = d
+			}
+		}
+		sum += nearest
+	}
+	return sum
+}
+
+func pitchesOf(notes []core.Note) []int {
+	pitches := []int{}
+	for _, each := range notes {
+		pitches = append(pitches, each.MIDI())
+	}
+	return pitches
+}
+
+func meanPitch(pitches []int) float64 {
+	sum := 0
+	for _, each := range pitches {
+		sum += each
+	}
+	return float64(sum) / float64(len(pitches))
+}
+
+// Storex is part of core.Storable
+func (v VoiceLead) Storex() string {
+	return fmt.Sprintf("voicelead(%s)", core.Storex(v.progression))
+}
+
+// Replaced is part of Replaceable
+func (v VoiceLead) Replaced(from, to core.Sequenceable) core.Sequenceable {
+	if core.IsIdenticalTo(v, from) {
+		return to
+	}
+	return VoiceLead{progression: replacedValue(v.progression, from, to)}
+}
@@ -0,0 +1,32 @@
+package op
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestVoiceLead_Progression(t *testing.T) {
+	p := core.NewChordProgression(core.On("c"), core.On("I IV V I"))
+	v := NewVoiceLead(core.On(p))
+	if got, want := v.S().Storex(), "sequence('(C E G) (C F A) (D G B) (E G C5)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := v.Storex(), "voicelead(progression('c','I IV V I'))"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestVoiceLead_KeepsRestsAndSingleNotes(t *testing.T) {
+	s := core.MustParseSequence("(c e g) = 8a (c5 e5 g5)")
+	v := NewVoiceLead(core.On(s))
+	if got, want := v.S().Storex(), "sequence('(C E G) = 8A (C E G)')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestVoiceMovement_DifferentSizes(t *testing.T) {
+	if got, want := voiceMovement([]int{60, 64, 67}, []int{60, 64, 67, 70}), 3; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
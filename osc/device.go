@@ -0,0 +1,307 @@
+package osc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+const (
+	// DefaultNoteAddress is the address pattern of note messages.
+	DefaultNoteAddress = "/melrose/note"
+	// DefaultControlAddress is the address pattern of control change messages.
+	DefaultControlAddress = "/melrose/control"
+	// DefaultParamAddress is the address pattern of parameter messages.
+	DefaultParamAddress = "/melrose/param"
+	// lookahead is how much earlier bundles are sent than the time they must be played.
+	// The receiver uses the time tag to play them exactly in time.
+	lookahead = 100 * time.Millisecond
+)
+
+// Device is an AudioDevice that sends notes as OSC bundles to e.g. SuperCollider, Sonic Pi or TouchDesigner.
+// Each note is a message with the arguments: channel (int), number (int), velocity (int), duration in seconds (float).
+// Each control change is a message with the arguments: channel (int), number (int), value (int).
+// Each parameter is a message with the arguments: name (string) followed by its values.
+type Device struct {
+	mutex          *sync.RWMutex
+	target         string
+	out            io.WriteCloser
+	noteAddress    string
+	controlAddress string
+	paramAddress   string
+	timeline       *core.Timeline
+	echo           bool
+}
+
+// NewDevice returns a Device that sends UDP packets to a host:port.
+func NewDevice(target string) (*Device, error) {
+	conn, err := net.Dial("udp", target)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to OSC target %s: %v", target, err)
+	}
+	d := newDevice(target, conn)
+	go d.timeline.Play()
+	return d, nil
+}
+
+func newDevice(target string, out io.WriteCloser) *Device {
+	return &Device{
+		mutex:          new(sync.RWMutex),
+		target:         target,
+		out:            out,
+		noteAddress:    DefaultNoteAddress,
+		controlAddress: DefaultControlAddress,
+		paramAddress:   DefaultParamAddress,
+		timeline:       core.NewTimeline(),
+	}
+}
+
+// DefaultDeviceIDs is part of AudioDevice ; there is no input and one output.
+func (d *Device) DefaultDeviceIDs() (inputDeviceID, outputDeviceID int) {
+	return -1, 0
+}
+
+// Command is part of AudioDevice
+func (d *Device) Command(args []string) notify.Message {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	notify.PrintHighlighted("OSC output:")
+	fmt.Printf("         target = %s\n", d.target)
+	fmt.Printf("   note address = %s (channel,number,velocity,seconds)\n", d.noteAddress)
+	fmt.Printf("control address = %s (channel,number,value)\n", d.controlAddress)
+	fmt.Printf("  param address = %s (name,value...)\n", d.paramAddress)
+	fmt.Printf("     echo notes = %v\n", d.echo)
+	fmt.Println()
+	notify.PrintHighlighted("change:")
+	fmt.Println("set('osc.note','<address>')    --- change the address pattern of note messages")
+	fmt.Println("set('osc.control','<address>') --- change the address pattern of control change messages")
+	fmt.Println("set('osc.param','<address>')   --- change the address pattern of parameter messages")
+	fmt.Println("set('osc.cc',2,7,100)          --- send control change 7 (volume) with value 100 on channel 2")
+	fmt.Println("set('osc.send','cutoff',0.5)   --- send the parameter cutoff with value 0.5")
+	fmt.Println("set('echo',true)               --- true = print the notes")
+	return nil
+}
+
+// HandleSetting is part of AudioDevice
+func (d *Device) HandleSetting(name string, values []interface{}) error {
+	switch name {
+	case "osc.note", "osc.control", "osc.param":
+		if len(values) != 1 {
+			return fmt.Errorf("one argument expected")
+		}
+		address, ok := values[0].(string)
+		if !ok || len(address) == 0 || address[0] != '/' {
+			return fmt.Errorf("address pattern starting with / expected, got %v", values[0])
+		}
+		d.mutex.Lock()
+		switch name {
+		case "osc.note":
+			d.noteAddress = address
+		case "osc.control":
+			d.controlAddress = address
+		case "osc.param":
+			d.paramAddress = address
+		}
+		d.mutex.Unlock()
+		notify.Infof("OSC %s address is set to %s", name[len("osc."):], address)
+	case "osc.cc":
+		if len(values) != 3 {
+			return fmt.Errorf("channel, control number and value arguments expected")
+		}
+		ints := []int{}
+		for _, each := range values {
+			v, ok := each.(int)
+			if !ok {
+				return fmt.Errorf("integer argument expected, got %T", each)
+			}
+			ints = append(ints, v)
+		}
+		channel, number, value := ints[0], ints[1], ints[2]
+		if channel < 1 || channel > 16 || number < 0 || number > 127 || value < 0 || value > 127 {
+			return fmt.Errorf("channel [1..16], control number [0..127] and value [0..127] expected, got %d,%d,%d", channel, number, value)
+		}
+		d.mutex.RLock()
+		address := d.controlAddress
+		d.mutex.RUnlock()
+		// a zero time means immediately
+		return d.send(Bundle{Messages: []Message{NewMessage(address, channel, number, value)}})
+	case "osc.send":
+		if len(values) < 2 {
+			return fmt.Errorf("parameter name and one or more values expected")
+		}
+		param, ok := values[0].(string)
+		if !ok || len(param) == 0 {
+			return fmt.Errorf("parameter name expected, got %v", values[0])
+		}
+		d.mutex.RLock()
+		address := d.paramAddress
+		d.mutex.RUnlock()
+		return d.send(Bundle{Messages: []Message{NewMessage(address, values...)}})
+	case "echo":
+		if len(values) != 1 {
+			return fmt.Errorf("one argument expected")
+		}
+		enable, ok := values[0].(bool)
+		if !ok {
+			return fmt.Errorf("boolean device argument expected, got %T", values[0])
+		}
+		d.mutex.Lock()
+		d.echo = enable
+		d.mutex.Unlock()
+		notify.Infof("echo notes is enabled: %v", enable)
+	default:
+		return fmt.Errorf("unknown setting:%s", name)
+	}
+	return nil
+}
+
+// Play is part of AudioDevice
+func (d *Device) Play(condition core.Condition, seq core.Sequenceable, bpm float64, beginAt time.Time) time.Time {
+	seq = core.UnValue(seq)
+	if sel, ok := seq.(core.DeviceSelector); ok {
+		seq = sel.Unwrap()
+	}
+	channel := 1
+	if sel, ok := seq.(core.ChannelSelector); ok {
+		channel = sel.Channel()
+		seq = sel.Unwrap()
+	}
+	d.mutex.RLock()
+	address, controlAddress, echo := d.noteAddress, d.controlAddress, d.echo
+	d.mutex.RUnlock()
+
+	whole := core.WholeNoteDuration(bpm)
+	moment := beginAt
+	for _, group := range seq.S().Notes {
+		if len(group) == 0 {
+			continue
+		}
+		// pedal changes are sent as control change messages and take no time
+		if len(group) == 1 && group[0].IsPedal() {
+			d.schedule(condition, Bundle{Time: moment, Messages: pedalMessages(controlAddress, channel, group[0])}, "")
+			continue
+		}
+		msgs := []Message{}
+		for _, each := range group {
+			if !each.IsHearable() {
+				continue
+			}
+			duration := time.Duration(float32(whole) * each.DurationFactor())
+			if fixed, ok := each.NonFractionBasedDuration(); ok {
+				duration = fixed
+			}
+			msgs = append(msgs, NewMessage(address, channel, each.MIDI(), each.Velocity, float32(duration.Seconds())))
+		}
+		if len(msgs) > 0 {
+			echoString := ""
+			if echo {
+				echoString = core.StringFromNoteGroup(group)
+			}
+			d.schedule(condition, Bundle{Time: moment, Messages: msgs}, echoString)
+		}
+		moment = moment.Add(time.Duration(float32(whole) * group[0].DurationFactor()))
+	}
+	return moment
+}
+
+// schedule puts a bundle on the timeline such that it is sent ahead of its time.
+func (d *Device) schedule(condition core.Condition, b Bundle, echoString string) {
+	event := bundleEvent{
+		device:     d,
+		bundle:     b,
+		mustHandle: condition,
+		echoString: echoString,
+	}
+	sendAt := b.Time.Add(-lookahead)
+	if now := time.Now(); sendAt.Before(now) {
+		sendAt = now
+	}
+	d.timeline.Schedule(event, sendAt)
+}
+
+// sustainPedal is the control number of the sustain (damper) pedal.
+const sustainPedal = 64
+
+// pedalMessages returns the control change messages for a pedal note.
+func pedalMessages(address string, channel int, pedal core.Note) []Message {
+	up := NewMessage(address, channel, sustainPedal, 0)
+	down := NewMessage(address, channel, sustainPedal, 127)
+	switch {
+	case pedal.IsPedalUp():
+		return []Message{up}
+	case pedal.IsPedalDown():
+		return []Message{down}
+	default:
+		return []Message{up, down}
+	}
+}
+
+// bundleEvent sends a bundle when handled by the timeline.
+type bundleEvent struct {
+	device     *Device
+	bundle     Bundle
+	mustHandle core.Condition
+	echoString string
+}
+
+// Handle is part of TimelineEvent
+func (e bundleEvent) Handle(tim *core.Timeline, when time.Time) {
+	if e.mustHandle != nil && !e.mustHandle() {
+		return
+	}
+	if len(e.echoString) > 0 {
+		fmt.Fprintf(notify.Console.DeviceOut, " %s", e.echoString)
+	}
+	if err := e.device.send(e.bundle); err != nil {
+		notify.Console.Errorf("OSC send error:%v", err)
+	}
+}
+
+// NoteChangesDo is part of TimelineEvent
+func (e bundleEvent) NoteChangesDo(block func(core.NoteChange)) {}
+
+func (d *Device) send(b Bundle) error {
+	data, err := b.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	_, err = d.out.Write(data)
+	return err
+}
+
+// HasInputCapability is part of AudioDevice
+func (d *Device) HasInputCapability() bool { return false }
+
+// Listen is part of AudioDevice
+func (d *Device) Listen(deviceID int, who core.NoteListener, startOrStop bool) {
+	notify.Warnf("listen is not available for an OSC device")
+}
+
+// OnKey is part of AudioDevice
+func (d *Device) OnKey(ctx core.Context, deviceID int, channel int, note core.Note, fun core.HasValue) error {
+	return errors.New("onkey is not available for an OSC device")
+}
+
+// Schedule is part of AudioDevice
+func (d *Device) Schedule(event core.TimelineEvent, beginAt time.Time) {
+	d.timeline.Schedule(event, beginAt)
+}
+
+// Reset is part of AudioDevice
+func (d *Device) Reset() {
+	d.timeline.Reset()
+}
+
+// Close is part of AudioDevice
+func (d *Device) Close() error {
+	d.Reset()
+	return d.out.Close()
+}
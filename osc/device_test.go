@@ -0,0 +1,140 @@
+package osc
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+)
+
+type bufferCloser struct {
+	bytes.Buffer
+}
+
+func (b *bufferCloser) Close() error { return nil }
+
+func TestDevice_Play(t *testing.T) {
+	out := new(bufferCloser)
+	d := newDevice("test", out)
+	begin := time.Now().Add(time.Second)
+	end := d.Play(core.NoCondition, core.MustParseSequence("c = (d e)"), 120, begin)
+	if got, want := end, begin.Add(1500*time.Millisecond); !got.Equal(want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// rest is not sent
+	if got, want := d.timeline.Len(), int64(2); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	d.timeline.EventsDo(func(event core.TimelineEvent, when time.Time) {
+		event.Handle(d.timeline, when)
+	})
+	if !bytes.Contains(out.Bytes(), []byte(DefaultNoteAddress)) {
+		t.Error("note address expected")
+	}
+}
+
+func TestDevice_HandleSetting(t *testing.T) {
+	d := newDevice("test", new(bufferCloser))
+	if err := d.HandleSetting("osc.note", []interface{}{"/s_new"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.noteAddress, "/s_new"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if err := d.HandleSetting("osc.note", []interface{}{"s_new"}); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestDevice_PlayPedal(t *testing.T) {
+	out := new(bufferCloser)
+	d := newDevice("test", out)
+	begin := time.Now().Add(time.Second)
+	end := d.Play(core.NoCondition, core.MustParseSequence("> c <"), 120, begin)
+	// pedal changes take no time
+	if got, want := end, begin.Add(500*time.Millisecond); !got.Equal(want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := d.timeline.Len(), int64(3); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	messages := []Message{}
+	d.timeline.EventsDo(func(event core.TimelineEvent, when time.Time) {
+		out.Reset()
+		event.Handle(d.timeline, when)
+		list, err := ParsePacket(out.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, list...)
+	})
+	if got, want := len(messages), 3; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	down, up := messages[0], messages[2]
+	if got, want := down.Address, DefaultControlAddress; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := fmt.Sprint(down.Arguments), "[1 64 127]"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := fmt.Sprint(up.Arguments), "[1 64 0]"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestDevice_HandleSettingControlChange(t *testing.T) {
+	out := new(bufferCloser)
+	d := newDevice("test", out)
+	if err := d.HandleSetting("osc.control", []interface{}{"/cc"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.HandleSetting("osc.cc", []interface{}{2, 7, 100}); err != nil {
+		t.Fatal(err)
+	}
+	messages, err := ParsePacket(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(messages), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := messages[0].Address, "/cc"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := fmt.Sprint(messages[0].Arguments), "[2 7 100]"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if err := d.HandleSetting("osc.cc", []interface{}{17, 7, 100}); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestDevice_HandleSettingParam(t *testing.T) {
+	out := new(bufferCloser)
+	d := newDevice("test", out)
+	if err := d.HandleSetting("osc.param", []interface{}{"/synth/set"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.HandleSetting("osc.send", []interface{}{"cutoff", 0.5, "lowpass"}); err != nil {
+		t.Fatal(err)
+	}
+	messages, err := ParsePacket(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(messages), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := messages[0].Address, "/synth/set"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := fmt.Sprint(messages[0].Arguments), "[cutoff 0.5 lowpass]"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if err := d.HandleSetting("osc.send", []interface{}{"cutoff"}); err == nil {
+		t.Error("error expected")
+	}
+}
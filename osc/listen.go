@@ -0,0 +1,119 @@
+package osc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// Listen stores the first argument of each received message, that matches an address pattern, in a variable
+// and optionally evaluates a function.
+type Listen struct {
+	mutex        *sync.RWMutex
+	ctx          core.Context
+	port         int
+	pattern      string
+	variableName string
+	callback     core.HasValue // can be nil
+	isRunning    bool
+}
+
+func NewListen(ctx core.Context, port int, pattern string, variableName string, callback core.HasValue) *Listen {
+	return &Listen{
+		mutex:        new(sync.RWMutex),
+		ctx:          ctx,
+		port:         port,
+		pattern:      pattern,
+		variableName: variableName,
+		callback:     callback,
+	}
+}
+
+// Inspect implements Inspectable
+func (l *Listen) Inspect(i core.Inspection) {
+	i.Properties["running"] = l.IsPlaying()
+	i.Properties["port"] = l.port
+	i.Properties["address"] = l.pattern
+}
+
+// Target is for replacing functions
+func (l *Listen) Target() core.HasValue { return l.callback }
+
+// SetTarget is for replacing functions
+func (l *Listen) SetTarget(c core.HasValue) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.callback = c
+}
+
+// Play is part of core.Playable
+func (l *Listen) Play(ctx core.Context, at time.Time) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.isRunning {
+		return nil
+	}
+	if err := addHandler(l.port, l.pattern, l); err != nil {
+		return err
+	}
+	l.isRunning = true
+	return nil
+}
+
+// Stop is part of core.Stoppable
+func (l *Listen) Stop(ctx core.Context) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.isRunning {
+		return nil
+	}
+	removeHandler(l.port, l)
+	l.isRunning = false
+	return nil
+}
+
+// IsPlaying is part of core.Stoppable
+func (l *Listen) IsPlaying() bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.isRunning
+}
+
+func (l *Listen) handleMessage(m Message) {
+	l.mutex.RLock()
+	callback := l.callback
+	l.mutex.RUnlock()
+	if len(m.Arguments) > 0 {
+		l.ctx.Variables().Put(l.variableName, argumentValue(m.Arguments[0]))
+	}
+	if callback == nil {
+		return
+	}
+	if e, ok := callback.Value().(core.Evaluatable); ok {
+		if err := e.Evaluate(l.ctx); err != nil {
+			notify.Warnf("OSC %s evaluation failed: %v", m.Address, err)
+		}
+	}
+}
+
+// argumentValue converts an OSC argument to a value as used in the language.
+func argumentValue(a interface{}) interface{} {
+	switch v := a.(type) {
+	case int32:
+		return int(v)
+	case float32:
+		return float64(v)
+	}
+	return a
+}
+
+// Storex is part of core.Storable
+func (l *Listen) Storex() string {
+	if l.callback == nil {
+		return fmt.Sprintf("osclisten(%d,'%s',%s)", l.port, l.pattern, l.variableName)
+	}
+	return fmt.Sprintf("osclisten(%d,'%s',%s,%s)", l.port, l.pattern, l.variableName, core.Storex(l.callback))
+}
@@ -0,0 +1,203 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Message is an OSC message with an address pattern and arguments.
+// Supported argument types are int32, int, float32, float64 and string.
+type Message struct {
+	Address   string
+	Arguments []interface{}
+}
+
+// NewMessage returns a Message for an address with arguments.
+func NewMessage(address string, args ...interface{}) Message {
+	return Message{Address: address, Arguments: args}
+}
+
+// MarshalBinary encodes the message according to the OSC 1.0 specification.
+func (m Message) MarshalBinary() ([]byte, error) {
+	var tags bytes.Buffer
+	var data bytes.Buffer
+	tags.WriteByte(',')
+	for _, each := range m.Arguments {
+		switch v := each.(type) {
+		case int32:
+			tags.WriteByte('i')
+			binary.Write(&data, binary.BigEndian, v)
+		case int:
+			tags.WriteByte('i')
+			binary.Write(&data, binary.BigEndian, int32(v))
+		case float32:
+			tags.WriteByte('f')
+			binary.Write(&data, binary.BigEndian, math.Float32bits(v))
+		case float64:
+			tags.WriteByte('f')
+			binary.Write(&data, binary.BigEndian, math.Float32bits(float32(v)))
+		case string:
+			tags.WriteByte('s')
+			writePaddedString(&data, v)
+		default:
+			return nil, fmt.Errorf("unsupported OSC argument type %T", each)
+		}
+	}
+	var b bytes.Buffer
+	writePaddedString(&b, m.Address)
+	writePaddedString(&b, tags.String())
+	b.Write(data.Bytes())
+	return b.Bytes(), nil
+}
+
+// Bundle is an OSC bundle of messages that must be handled at a given time.
+type Bundle struct {
+	Time     time.Time
+	Messages []Message
+}
+
+// MarshalBinary encodes the bundle according to the OSC 1.0 specification.
+func (b Bundle) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	writePaddedString(&buf, "#bundle")
+	binary.Write(&buf, binary.BigEndian, timeTag(b.Time))
+	for _, each := range b.Messages {
+		data, err := each.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		binary.Write(&buf, binary.BigEndian, int32(len(data)))
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// secondsFrom1900To1970 is the offset between the NTP and the Unix epoch.
+const secondsFrom1900To1970 = 2208988800
+
+// timeTag returns the NTP time format used by OSC: seconds since 1900 and a fraction of 2^32.
+func timeTag(t time.Time) uint64 {
+	if t.IsZero() {
+		return 1 // immediately
+	}
+	secs := uint64(t.Unix() + secondsFrom1900To1970)
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return secs<<32 | frac
+}
+
+// writePaddedString writes a zero terminated string padded to a multiple of 4 bytes.
+func writePaddedString(b *bytes.Buffer, s string) {
+	b.WriteString(s)
+	pad := 4 - len(s)%4
+	for i := 0; i < pad; i++ {
+		b.WriteByte(0)
+	}
+}
+
+// ParsePacket decodes the messages of an OSC packet that is either a message or a (nested) bundle.
+func ParsePacket(data []byte) ([]Message, error) {
+	if bytes.HasPrefix(data, []byte("#bundle\x00")) {
+		return parseBundle(data)
+	}
+	m, err := parseMessage(data)
+	if err != nil {
+		return nil, err
+	}
+	return []Message{m}, nil
+}
+
+func parseBundle(data []byte) ([]Message, error) {
+	r := bytes.NewReader(data[8:])
+	var tag uint64
+	if err := binary.Read(r, binary.BigEndian, &tag); err != nil {
+		return nil, fmt.Errorf("invalid OSC bundle time tag: %v", err)
+	}
+	all := []Message{}
+	for r.Len() > 0 {
+		var size int32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return nil, fmt.Errorf("invalid OSC bundle element size: %v", err)
+		}
+		if size < 0 || int(size) > r.Len() {
+			return nil, fmt.Errorf("invalid OSC bundle element size: %d", size)
+		}
+		element := make([]byte, size)
+		r.Read(element)
+		list, err := ParsePacket(element)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, list...)
+	}
+	return all, nil
+}
+
+func parseMessage(data []byte) (Message, error) {
+	r := bytes.NewReader(data)
+	address, err := readPaddedString(r)
+	if err != nil || len(address) == 0 || address[0] != '/' {
+		return Message{}, fmt.Errorf("invalid OSC address")
+	}
+	m := Message{Address: address}
+	if r.Len() == 0 {
+		return m, nil // no type tags
+	}
+	tags, err := readPaddedString(r)
+	if err != nil || len(tags) == 0 || tags[0] != ',' {
+		return m, fmt.Errorf("invalid OSC type tags")
+	}
+	for _, each := range tags[1:] {
+		switch each {
+		case 'i':
+			var v int32
+			if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+				return m, fmt.Errorf("invalid OSC int argument: %v", err)
+			}
+			m.Arguments = append(m.Arguments, v)
+		case 'f':
+			var v uint32
+			if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+				return m, fmt.Errorf("invalid OSC float argument: %v", err)
+			}
+			m.Arguments = append(m.Arguments, math.Float32frombits(v))
+		case 's':
+			s, err := readPaddedString(r)
+			if err != nil {
+				return m, fmt.Errorf("invalid OSC string argument: %v", err)
+			}
+			m.Arguments = append(m.Arguments, s)
+		case 'T':
+			m.Arguments = append(m.Arguments, true)
+		case 'F':
+			m.Arguments = append(m.Arguments, false)
+		default:
+			return m, fmt.Errorf("unsupported OSC type tag %q", each)
+		}
+	}
+	return m, nil
+}
+
+// readPaddedString reads a zero terminated string and skips the padding to a multiple of 4 bytes.
+func readPaddedString(r *bytes.Reader) (string, error) {
+	var b bytes.Buffer
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if c == 0 {
+			break
+		}
+		b.WriteByte(c)
+	}
+	// already read one zero
+	for pad := 3 - b.Len()%4; pad > 0; pad-- {
+		if _, err := r.ReadByte(); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,88 @@
+package osc
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestMessage_MarshalBinary(t *testing.T) {
+	data, err := NewMessage("/note", 1, float32(0.5), "c").MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		'/', 'n', 'o', 't', 'e', 0, 0, 0,
+		',', 'i', 'f', 's', 0, 0, 0, 0,
+		0, 0, 0, 1,
+		0x3f, 0, 0, 0,
+		'c', 0, 0, 0,
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("got [%v] want [%v]", data, want)
+	}
+}
+
+func TestMessage_UnsupportedArgument(t *testing.T) {
+	if _, err := NewMessage("/x", true).MarshalBinary(); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestBundle_MarshalBinary(t *testing.T) {
+	at := time.Unix(1, int64(time.Second/2))
+	data, err := Bundle{Time: at, Messages: []Message{NewMessage("/a")}}.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data[:8]), "#bundle\x00"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// seconds since 1900 and half a second
+	if got, want := data[8:16], []byte{0x83, 0xaa, 0x7e, 0x81, 0x80, 0, 0, 0}; !bytes.Equal(got, want) {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+	// size of message
+	if got, want := data[16:20], []byte{0, 0, 0, 8}; !bytes.Equal(got, want) {
+		t.Errorf("got [%v] want [%v]", got, want)
+	}
+}
+
+func TestParsePacket_Bundle(t *testing.T) {
+	data, _ := Bundle{Messages: []Message{
+		NewMessage("/fader/1", float32(0.25)),
+		NewMessage("/pad", 3, "on"),
+	}}.MarshalBinary()
+	list, err := ParsePacket(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(list), 2; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := list[0].Address, "/fader/1"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := list[0].Arguments[0], float32(0.25); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := list[1].Arguments[0], int32(3); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := list[1].Arguments[1], "on"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestParsePacket_Invalid(t *testing.T) {
+	for _, each := range [][]byte{
+		{},
+		[]byte("nope"),
+		[]byte("/a\x00\x00,x\x00\x00"),
+		[]byte("#bundle\x00\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x10"),
+	} {
+		if _, err := ParsePacket(each); err == nil {
+			t.Errorf("error expected for %q", each)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package osc
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"sync"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+var listenHost = flag.String("osc.host", "127.0.0.1", "host on which to listen for OSC messages ; use 0.0.0.0 to receive from other machines, e.g. TouchOSC on a phone")
+
+// messageHandler is called for each received message that matches its address pattern.
+type messageHandler interface {
+	handleMessage(m Message)
+}
+
+type registration struct {
+	pattern string
+	handler messageHandler
+}
+
+// server receives OSC packets on a UDP port and dispatches the messages.
+type server struct {
+	mutex         *sync.RWMutex
+	conn          net.PacketConn
+	registrations []registration
+}
+
+var servers = struct {
+	mutex  sync.Mutex
+	byPort map[int]*server
+}{byPort: map[int]*server{}}
+
+// addHandler registers a handler for an address pattern on a port and starts a server if needed.
+// Patterns can use the wildcards * ? and [..]
+func addHandler(port int, pattern string, h messageHandler) error {
+	if _, err := path.Match(pattern, "/"); err != nil {
+		return fmt.Errorf("invalid OSC address pattern %s: %v", pattern, err)
+	}
+	servers.mutex.Lock()
+	defer servers.mutex.Unlock()
+	s, ok := servers.byPort[port]
+	if !ok {
+		address := net.JoinHostPort(*listenHost, strconv.Itoa(port))
+		conn, err := net.ListenPacket("udp", address)
+		if err != nil {
+			return fmt.Errorf("cannot listen for OSC on %s: %v", address, err)
+		}
+		s = &server{mutex: new(sync.RWMutex), conn: conn}
+		servers.byPort[port] = s
+		go s.receive()
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.registrations = append(s.registrations, registration{pattern: pattern, handler: h})
+	return nil
+}
+
+// removeHandler unregisters a handler and stops the server if no handlers are left.
+func removeHandler(port int, h messageHandler) {
+	servers.mutex.Lock()
+	defer servers.mutex.Unlock()
+	s, ok := servers.byPort[port]
+	if !ok {
+		return
+	}
+	s.mutex.Lock()
+	without := []registration{}
+	for _, each := range s.registrations {
+		if each.handler != h {
+			without = append(without, each)
+		}
+	}
+	s.registrations = without
+	s.mutex.Unlock()
+	if len(without) == 0 {
+		s.conn.Close()
+		delete(servers.byPort, port)
+	}
+}
+
+func (s *server) receive() {
+	buffer := make([]byte, 65535)
+	for {
+		n, _, err := s.conn.ReadFrom(buffer)
+		if err != nil {
+			// closed
+			return
+		}
+		messages, err := ParsePacket(buffer[:n])
+		if err != nil {
+			notify.Warnf("invalid OSC packet: %v", err)
+			continue
+		}
+		for _, each := range messages {
+			s.dispatch(each)
+		}
+	}
+}
+
+func (s *server) dispatch(m Message) {
+	if core.IsDebug() {
+		notify.Debugf("osc.receive %s %v", m.Address, m.Arguments)
+	}
+	// copy such that handlers can add or remove registrations
+	s.mutex.RLock()
+	list := append([]registration{}, s.registrations...)
+	s.mutex.RUnlock()
+	for _, each := range list {
+		if ok, _ := path.Match(each.pattern, m.Address); ok {
+			each.handler.handleMessage(m)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package osc
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+type channelHandler chan Message
+
+func (c channelHandler) handleMessage(m Message) { c <- m }
+
+func TestServer_Dispatch(t *testing.T) {
+	free, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("no UDP available:", err)
+	}
+	port := free.LocalAddr().(*net.UDPAddr).Port
+	free.Close()
+
+	pads := make(channelHandler, 1)
+	if err := addHandler(port, "/pads/*", pads); err != nil {
+		t.Fatal(err)
+	}
+	defer removeHandler(port, pads)
+	// only local messages by default
+	servers.mutex.Lock()
+	local := servers.byPort[port].conn.LocalAddr().(*net.UDPAddr).IP
+	servers.mutex.Unlock()
+	if !local.IsLoopback() {
+		t.Errorf("loopback address expected, got %v", local)
+	}
+
+	conn, err := net.Dial("udp", free.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, each := range []Message{NewMessage("/fader", 1), NewMessage("/pads/2", 127)} {
+		data, _ := each.MarshalBinary()
+		conn.Write(data)
+	}
+	select {
+	case m := <-pads:
+		if got, want := m.Address, "/pads/2"; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestArgumentValue(t *testing.T) {
+	if got, want := argumentValue(int32(1)), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := argumentValue(float32(0.5)), 0.5; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
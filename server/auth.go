@@ -0,0 +1,106 @@
+package server
+
+import (
+	"crypto/subtle"
+	"flag"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/emicklei/melrose/notify"
+)
+
+var (
+	httpToken   = flag.String("token", "", "token that HTTP requests must send as Bearer authorization ; empty means no authentication")
+	httpAllow   = flag.String("allow", "", "comma separated names of functions that HTTP requests may call ; empty means all")
+	httpOrigins = flag.String("origin", "", "comma separated origins, e.g. http://localhost:3000, of other web pages that may send requests")
+)
+
+// guard protects the HTTP handlers when melrose is remote controlled, or when a web page of another site wants to control it.
+// The allowed functions are enforced by the evaluator of the service, see api.NewRestrictedService.
+type guard struct {
+	token   string
+	allowed []string        // empty means all functions are allowed
+	origins map[string]bool // of other web pages that may send requests
+}
+
+func newGuard(token, allow, origins string) guard {
+	g := guard{token: token, origins: map[string]bool{}}
+	for _, each := range strings.Split(allow, ",") {
+		if name := strings.TrimSpace(each); len(name) > 0 {
+			g.allowed = append(g.allowed, name)
+		}
+	}
+	for _, each := range strings.Split(origins, ",") {
+		if origin := strings.TrimSuffix(strings.TrimSpace(each), "/"); len(origin) > 0 {
+			g.origins[origin] = true
+		}
+	}
+	return g
+}
+
+// allowsOrigin returns true if the request does not come from a web page of another site, or that site is allowed.
+// Browsers send the Origin of the page ; other clients, such as editor plugins, do not.
+// Without this check, any web page that is visited can remote control melrose.
+func (g guard) allowsOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if len(origin) == 0 {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host == r.Host {
+		// a page of melrose itself, e.g. the dashboard
+		return true
+	}
+	return g.origins[origin]
+}
+
+// isAuthenticated returns true if no token is required or the request has the required Bearer token.
+// Browsers cannot set the header to open a page or a WebSocket, so the token can also be a query parameter.
+func (g guard) isAuthenticated(r *http.Request) bool {
+	if len(g.token) == 0 {
+		return true
+	}
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		bearer = r.URL.Query().Get("token")
+		if len(bearer) == 0 {
+			return false
+		}
+	}
+	return subtle.ConstantTimeCompare([]byte(bearer), []byte(g.token)) == 1
+}
+
+// hasAllowedContentType returns true if the request has no body type or a JSON one.
+// A web page of another site can send other types, such as text/plain, without asking permission first.
+func hasAllowedContentType(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	if len(contentType) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
+
+// protect wraps a handler such that requests from web pages of other sites, unauthenticated requests
+// and POST requests with a body type other than JSON are rejected.
+func (g guard) protect(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !g.allowsOrigin(r) {
+			notify.Console.Warnf("HTTP request origin not allowed:%s", r.Header.Get("Origin"))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Method == http.MethodPost && !hasAllowedContentType(r) {
+			notify.Console.Warnf("HTTP request content type not allowed:%s", r.Header.Get("Content-Type"))
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		if !g.isAuthenticated(r) {
+			notify.Console.Warnf("HTTP request not authenticated:%s", r.URL.Path)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
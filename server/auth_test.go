@@ -0,0 +1,110 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emicklei/melrose/api"
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
+)
+
+func TestGuard_IsAuthenticated(t *testing.T) {
+	g := newGuard("secret", "", "")
+	r := httptest.NewRequest("POST", "/v1/statements", nil)
+	if g.isAuthenticated(r) {
+		t.Error("missing token must be rejected")
+	}
+	r.Header.Set("Authorization", "Bearer wrong")
+	if g.isAuthenticated(r) {
+		t.Error("wrong token must be rejected")
+	}
+	r.Header.Set("Authorization", "Bearer secret")
+	if !g.isAuthenticated(r) {
+		t.Error("token must be accepted")
+	}
+	if !g.isAuthenticated(httptest.NewRequest("GET", "/ui?token=secret", nil)) {
+		t.Error("token parameter must be accepted")
+	}
+	if !newGuard("", "", "").isAuthenticated(httptest.NewRequest("POST", "/", nil)) {
+		t.Error("no token required")
+	}
+}
+
+func TestStatementHandler_NotAllowed(t *testing.T) {
+	l := allowTestServer("note, sequence,reverse")
+	for _, each := range []string{
+		"s = sequence('c e')\nexport('x',s)",
+		"import('other.mel')",
+		"map(midi_send, [1])",
+		"sequence('c') |> midi_send(1,1)",
+	} {
+		rec := httptest.NewRecorder()
+		l.statementHandler(rec, httptest.NewRequest("POST", "/v1/statements?action=eval", strings.NewReader(each)))
+		if got, want := rec.Code, http.StatusForbidden; got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each, got, got, want, want)
+		}
+		if !strings.Contains(rec.Body.String(), "functions not allowed") {
+			t.Errorf("%s: not allowed expected in %s", each, rec.Body.String())
+		}
+	}
+	rec := httptest.NewRecorder()
+	l.statementHandler(rec, httptest.NewRequest("POST", "/v1/statements?action=eval", strings.NewReader("sequence('c e') |> reverse")))
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestEvaluate_PipeNotAllowed(t *testing.T) {
+	l := allowTestServer("sequence")
+	result := l.evaluate("", 1, "sequence('c') |> play")
+	if !result.IsError {
+		t.Fatal("error expected")
+	}
+	if got, want := result.Message, "functions not allowed:play"; !strings.Contains(got, want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func allowTestServer(allow string) *LanguageServer {
+	ctx := core.PlayContext{
+		VariableStorage: dsl.NewVariableStore(),
+		LoopControl:     &core.TestLooper{Biab: 4},
+		EnvironmentVars: new(sync.Map),
+	}
+	g := newGuard("", allow, "")
+	return &LanguageServer{context: ctx, service: api.NewRestrictedService(ctx, g.allowed), guard: g}
+}
+
+func TestGuard_Protect(t *testing.T) {
+	l := allowTestServer("")
+	h := l.guard.protect(l.evalHandler)
+	for _, each := range []struct {
+		origin, contentType string
+		want                int
+	}{
+		{"http://evil.example.com", "", http.StatusForbidden},
+		{"http://evil.example.com", "application/json", http.StatusForbidden},
+		{"", "text/plain", http.StatusUnsupportedMediaType},
+		{"", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"", "application/json; charset=utf-8", http.StatusOK},
+		{"", "", http.StatusOK},
+		{"http://example.com", "application/json", http.StatusOK}, // same host
+	} {
+		r := httptest.NewRequest("POST", "http://example.com/v1/eval", strings.NewReader("1"))
+		if len(each.origin) > 0 {
+			r.Header.Set("Origin", each.origin)
+		}
+		if len(each.contentType) > 0 {
+			r.Header.Set("Content-Type", each.contentType)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, r)
+		if got, want := rec.Code, each.want; got != want {
+			t.Errorf("%s %s: got [%v:%T] want [%v:%T]", each.origin, each.contentType, got, got, want, want)
+		}
+	}
+}
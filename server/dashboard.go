@@ -0,0 +1,133 @@
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+//go:embed dashboard.html
+var dashboardPage []byte
+
+// dashboardState is what the browser UI shows about the loops and the beat.
+type dashboardState struct {
+	BPM   float64         `json:"bpm"`
+	BIAB  int             `json:"biab"`
+	Bars  int64           `json:"bars"`
+	Loops []dashboardLoop `json:"loops"`
+}
+
+type dashboardLoop struct {
+	Name    string `json:"name"`
+	Running bool   `json:"running"`
+	Bar     int64  `json:"bar"` // zero-based, -1 if not running
+	Source  string `json:"source"`
+}
+
+// dashboardPageHandler returns the browser UI.
+func (l *LanguageServer) dashboardPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("content-type", "text/html; charset=utf-8")
+	w.Write(dashboardPage)
+}
+
+// dashboardHandler returns the state of the loops and the beat as JSON.
+func (l *LanguageServer) dashboardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(newDashboardState(l.context, time.Now()))
+}
+
+func newDashboardState(ctx core.Context, now time.Time) dashboardState {
+	_, bars := ctx.Control().BeatsAndBars()
+	state := dashboardState{
+		BPM:   ctx.Control().BPM(),
+		BIAB:  ctx.Control().BIAB(),
+		Bars:  bars,
+		Loops: []dashboardLoop{},
+	}
+	for name, value := range ctx.Variables().Variables() {
+		if each, ok := value.(*core.Loop); ok {
+			state.Loops = append(state.Loops, dashboardLoop{
+				Name:    name,
+				Running: each.IsRunning(),
+				Bar:     each.PlayingBar(now),
+				Source:  each.Storex(),
+			})
+		}
+	}
+	sort.Slice(state.Loops, func(i, j int) bool { return state.Loops[i].Name < state.Loops[j].Name })
+	return state
+}
+
+// loopHandler begins or ends the loop of a variable.
+// curl -X POST 'http://localhost:8118/v1/loops?var=l1&action=begin'
+func (l *LanguageServer) loopHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	name := r.URL.Query().Get("var")
+	value, _ := l.context.Variables().Get(name)
+	lp, ok := value.(*core.Loop)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	switch r.URL.Query().Get("action") {
+	case "begin":
+		notify.Infof("begin %s", name)
+		lp.Play(l.context, time.Now())
+	case "end":
+		notify.Infof("end %s", name)
+		lp.Stop(l.context)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// eventsHandler streams notes and messages as JSON over a WebSocket.
+func (l *LanguageServer) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	ws, err := l.guard.upgradeWebsocket(w, r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errOriginNotAllowed) {
+			status = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	defer ws.Close()
+	events, stop := notify.Subscribe()
+	defer stop()
+	for {
+		select {
+		case <-ws.closed:
+			return
+		case <-ws.received:
+			// messages from the dashboard are ignored
+		case each := <-events:
+			data, err := json.Marshal(each)
+			if err != nil {
+				continue
+			}
+			if err := ws.writeText(data); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
+	"github.com/emicklei/melrose/notify"
+)
+
+func TestWebsocketAccept(t *testing.T) {
+	// example from RFC 6455
+	if got, want := websocketAccept("dGhlIHNhbXBsZSBub25jZQ=="), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	l := &LanguageServer{}
+	srv := httptest.NewServer(http.HandlerFunc(l.eventsHandler))
+	defer srv.Close()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "GET /v1/events HTTP/1.1\r\nHost: melrose\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := resp.StatusCode, http.StatusSwitchingProtocols; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// wait for the subscription
+	for !notify.HasSubscribers() {
+		time.Sleep(time.Millisecond)
+	}
+	notify.Publish(notify.NoteEventKind, notify.NoteData{Channel: 2, Number: 60, On: true})
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := header[0], byte(0x81); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	data := make([]byte, header[1])
+	if _, err := io.ReadFull(r, data); err != nil {
+		t.Fatal(err)
+	}
+	var event struct {
+		Kind string
+		Data notify.NoteData
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := event.Data.Number, int64(60); got != want || event.Kind != notify.NoteEventKind {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestEventsHandler_NoHandshake(t *testing.T) {
+	rec := httptest.NewRecorder()
+	new(LanguageServer).eventsHandler(rec, httptest.NewRequest("GET", "/v1/events", nil))
+	if got, want := rec.Code, http.StatusBadRequest; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestNewDashboardState(t *testing.T) {
+	ctx := core.PlayContext{
+		VariableStorage: dsl.NewVariableStore(),
+		LoopControl:     &core.TestLooper{Biab: 4},
+	}
+	ctx.Variables().Put("l2", core.NewLoop(ctx, []core.Sequenceable{core.MustParseSequence("c")}))
+	ctx.Variables().Put("l1", core.NewLoop(ctx, []core.Sequenceable{core.MustParseSequence("d")}))
+	ctx.Variables().Put("s", core.MustParseSequence("e"))
+	state := newDashboardState(ctx, time.Now())
+	if got, want := len(state.Loops), 2; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := state.Loops[0].Name, "l1"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := state.Loops[0].Bar, int64(-1); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// evalHandler evaluates the statements in the request body and returns the result as JSON.
+// With a WebSocket handshake, each received text message is evaluated and its result is sent back.
+// curl -H 'content-type: application/json' -d "s = sequence('c e g')" http://localhost:8118/v1/eval
+func (l *LanguageServer) evalHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		l.evalWebsocket(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	line, err := strconv.Atoi(query.Get("line"))
+	if err != nil {
+		line = 1
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	result := l.evaluate(query.Get("file"), line, string(data))
+	w.Header().Set("content-type", "application/json")
+	if result.IsError {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	enc.Encode(result)
+}
+
+func (l *LanguageServer) evalWebsocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := l.guard.upgradeWebsocket(w, r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errOriginNotAllowed) {
+			status = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	defer ws.Close()
+	for {
+		select {
+		case <-ws.closed:
+			return
+		case source := <-ws.received:
+			data, err := json.Marshal(l.evaluate("", 1, string(source)))
+			if err != nil {
+				continue
+			}
+			if err := ws.writeText(data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// evaluate returns the result of the statements, or the error if functions are not allowed or the evaluation failed.
+func (l *LanguageServer) evaluate(file string, line int, source string) evaluationResult {
+	source, line = removeTrailingWhitespace(source, line)
+	ret, err := l.service.CommandEvaluate(file, line, source)
+	if err != nil {
+		return resultFrom(file, line, err)
+	}
+	return resultFrom(file, line, ret)
+}
+
+// variableInfo describes a variable for GET /v1/vars.
+type variableInfo struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Source string `json:"source,omitempty"`
+}
+
+// varsHandler returns the variables, optionally whose name has a prefix, sorted by name.
+// curl http://localhost:8118/v1/vars?prefix=s
+func (l *LanguageServer) varsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	enc.Encode(variableInfos(l.context, r.URL.Query().Get("prefix")))
+}
+
+func variableInfos(ctx core.Context, prefix string) []variableInfo {
+	list := []variableInfo{}
+	for name, value := range ctx.Variables().Variables() {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		info := variableInfo{Name: name, Type: fmt.Sprintf("%T", value)}
+		if st, ok := value.(core.Storable); ok {
+			info.Source = st.Storex()
+		}
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
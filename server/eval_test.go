@@ -0,0 +1,246 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emicklei/melrose/api"
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
+)
+
+func evalTestServer() *LanguageServer {
+	ctx := core.PlayContext{
+		VariableStorage: dsl.NewVariableStore(),
+		LoopControl:     &core.TestLooper{Biab: 4},
+		EnvironmentVars: new(sync.Map),
+	}
+	g := newGuard("", "note,sequence", "")
+	return &LanguageServer{context: ctx, service: api.NewRestrictedService(ctx, g.allowed), guard: g}
+}
+
+func TestEvalHandler(t *testing.T) {
+	l := evalTestServer()
+	rec := httptest.NewRecorder()
+	l.evalHandler(rec, httptest.NewRequest("POST", "/v1/eval", strings.NewReader("s = sequence('c e')\n")))
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	var result evaluationResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := result.Message, "sequence('C E')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, ok := l.context.Variables().Get("s"); !ok {
+		t.Error("variable expected")
+	}
+}
+
+func TestEvalHandler_Errors(t *testing.T) {
+	l := evalTestServer()
+	for _, each := range []string{"sequence('c'", "export('x',note('c'))"} {
+		rec := httptest.NewRecorder()
+		l.evalHandler(rec, httptest.NewRequest("POST", "/v1/eval", strings.NewReader(each)))
+		if got, want := rec.Code, http.StatusBadRequest; got != want {
+			t.Errorf("%s: got [%v:%T] want [%v:%T]", each, got, got, want, want)
+		}
+		if !strings.Contains(rec.Body.String(), `"is-error": true`) {
+			t.Errorf("%s: error expected in %s", each, rec.Body.String())
+		}
+	}
+}
+
+func TestVarsHandler(t *testing.T) {
+	l := evalTestServer()
+	l.context.Variables().Put("b", core.MustParseSequence("d"))
+	l.context.Variables().Put("a", core.MustParseSequence("c"))
+	l.context.Variables().Put("x", core.MustParseSequence("e"))
+	rec := httptest.NewRecorder()
+	l.varsHandler(rec, httptest.NewRequest("GET", "/v1/vars?prefix=", nil))
+	var list []variableInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(list), 3; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := list[0].Source, "sequence('C')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := len(variableInfos(l.context, "x")), 1; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestEvalHandler_Websocket(t *testing.T) {
+	l := evalTestServer()
+	srv := httptest.NewServer(http.HandlerFunc(l.evalHandler))
+	defer srv.Close()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "GET /v1/eval HTTP/1.1\r\nHost: melrose\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	r := bufio.NewReader(conn)
+	if _, err := http.ReadResponse(r, nil); err != nil {
+		t.Fatal(err)
+	}
+	// clients must mask
+	source := []byte("note('c')")
+	mask := []byte{1, 2, 3, 4}
+	frame := append([]byte{0x81, 0x80 | byte(len(source))}, mask...)
+	for i, each := range source {
+		frame = append(frame, each^mask[i%4])
+	}
+	conn.Write(frame)
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, header[1])
+	if _, err := io.ReadFull(r, data); err != nil {
+		t.Fatal(err)
+	}
+	var result evaluationResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := result.Message, "note('C')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+// dialEval opens a WebSocket on the eval handler ; it returns the connection and the response of the handshake.
+func dialEval(t *testing.T, l *LanguageServer, origin string) (net.Conn, *bufio.Reader, *http.Response) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(l.evalHandler))
+	t.Cleanup(srv.Close)
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	handshake := "GET /v1/eval HTTP/1.1\r\nHost: melrose\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"
+	if len(origin) > 0 {
+		handshake += "Origin: " + origin + "\r\n"
+	}
+	io.WriteString(conn, handshake+"\r\n")
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, r, resp
+}
+
+// maskedFrame returns a client frame ; fin tells whether it is the last of a message.
+func maskedFrame(fin bool, opcode byte, data string) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame := append([]byte{first, 0x80 | byte(len(data))}, mask...)
+	for i := 0; i < len(data); i++ {
+		frame = append(frame, data[i]^mask[i%4])
+	}
+	return frame
+}
+
+func TestEvalHandler_WebsocketOrigin(t *testing.T) {
+	l := evalTestServer()
+	_, _, resp := dialEval(t, l, "http://evil.example.com")
+	if got, want := resp.StatusCode, http.StatusForbidden; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	_, _, resp = dialEval(t, l, "http://melrose")
+	if got, want := resp.StatusCode, http.StatusSwitchingProtocols; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	l.guard = newGuard("", "", "http://evil.example.com/")
+	_, _, resp = dialEval(t, l, "http://evil.example.com")
+	if got, want := resp.StatusCode, http.StatusSwitchingProtocols; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestEvalHandler_WebsocketUnmasked(t *testing.T) {
+	conn, r, _ := dialEval(t, evalTestServer(), "")
+	source := "note('c')"
+	conn.Write(append([]byte{0x81, byte(len(source))}, source...))
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		t.Fatal(err)
+	}
+	// close with protocol error 1002
+	if got, want := header, []byte{0x88, 2, 0x03, 0xEA}; string(got) != string(want) {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestEvalHandler_WebsocketFragmented(t *testing.T) {
+	conn, r, _ := dialEval(t, evalTestServer(), "")
+	conn.Write(maskedFrame(false, websocketText, "note("))
+	conn.Write(maskedFrame(true, websocketPing, ""))
+	conn.Write(maskedFrame(true, websocketContinuation, "'c')"))
+	header := make([]byte, 2)
+	// pong
+	if _, err := io.ReadFull(r, header); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := header[0], byte(0x8A); got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := io.ReadFull(r, header); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, header[1])
+	if _, err := io.ReadFull(r, data); err != nil {
+		t.Fatal(err)
+	}
+	var result evaluationResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := result.Message, "note('C')"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestEvalHandler_WebsocketBurst(t *testing.T) {
+	conn, r, _ := dialEval(t, evalTestServer(), "")
+	// more statements than can be queued
+	count := 40
+	for i := 0; i < count; i++ {
+		conn.Write(maskedFrame(true, websocketText, "note('c')"))
+	}
+	header := make([]byte, 2)
+	for i := 0; i < count; i++ {
+		if _, err := io.ReadFull(r, header); err != nil {
+			t.Fatalf("result %d: %v", i, err)
+		}
+		data := make([]byte, header[1])
+		if _, err := io.ReadFull(r, data); err != nil {
+			t.Fatal(err)
+		}
+		var result evaluationResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := result.Message, "note('C')"; got != want {
+			t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+		}
+	}
+}
@@ -15,20 +15,28 @@ type LanguageServer struct {
 	context core.Context
 	address string
 	service api.Service
+	guard   guard
 }
 
 // NewLanguageServer returns a new LanguageService. It is not started.
 func NewLanguageServer(ctx core.Context, addr string) *LanguageServer {
-	return &LanguageServer{context: ctx, address: addr, service: api.NewService(ctx)}
+	g := newGuard(*httpToken, *httpAllow, *httpOrigins)
+	return &LanguageServer{context: ctx, address: addr, service: api.NewRestrictedService(ctx, g.allowed), guard: g}
 }
 
 // Start will start a HTTP listener for serving DSL statements
-// curl -v -d 'n = note("C")' http://localhost:8118/v1/statements
+// curl -v -H 'content-type: application/json' -d 'n = note("C")' http://localhost:8118/v1/statements
 func (l *LanguageServer) Start() error {
-	http.HandleFunc("/v1/statements", l.statementHandler)
-	http.HandleFunc("/v1/inspect", l.inspectHandler)
-	http.HandleFunc("/v1/notes", l.notesPageHandler)
-	http.HandleFunc("/v1/pianoroll", l.pianorollImageHandler)
+	http.HandleFunc("/v1/statements", l.guard.protect(l.statementHandler))
+	http.HandleFunc("/v1/inspect", l.guard.protect(l.inspectHandler))
+	http.HandleFunc("/v1/notes", l.guard.protect(l.notesPageHandler))
+	http.HandleFunc("/v1/pianoroll", l.guard.protect(l.pianorollImageHandler))
+	http.HandleFunc("/v1/eval", l.guard.protect(l.evalHandler))
+	http.HandleFunc("/v1/vars", l.guard.protect(l.varsHandler))
+	http.HandleFunc("/ui", l.guard.protect(l.dashboardPageHandler))
+	http.HandleFunc("/v1/dashboard", l.guard.protect(l.dashboardHandler))
+	http.HandleFunc("/v1/loops", l.guard.protect(l.loopHandler))
+	http.HandleFunc("/v1/events", l.guard.protect(l.eventsHandler))
 	http.HandleFunc("/version", l.versionHandler)
 	return http.ListenAndServe(l.address, nil)
 }
@@ -3,6 +3,7 @@ package server
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
 	"github.com/emicklei/melrose/notify"
 )
 
@@ -63,6 +65,12 @@ func (l *LanguageServer) statementHandler(w http.ResponseWriter, r *http.Request
 		} else {
 			evalResult = ret
 		}
+	case "audition":
+		if ret, err := l.service.CommandAudition(file, line, source); err != nil {
+			evalResult = err
+		} else {
+			evalResult = ret
+		}
 	case "stop":
 		if ret, err := l.service.CommandStop(file, line, source); err != nil {
 			evalResult = err
@@ -78,9 +86,14 @@ func (l *LanguageServer) statementHandler(w http.ResponseWriter, r *http.Request
 	default:
 		evalResult = fmt.Errorf("unknown command:%s", query.Get("action"))
 	}
-	if _, ok := evalResult.(error); ok {
-		// evaluation failed.
-		w.WriteHeader(http.StatusBadRequest)
+	if err, ok := evalResult.(error); ok {
+		var denied *dsl.NotAllowedError
+		if errors.As(err, &denied) {
+			w.WriteHeader(http.StatusForbidden)
+		} else {
+			// evaluation failed.
+			w.WriteHeader(http.StatusBadRequest)
+		}
 	}
 	response := resultFrom(file, line, evalResult)
 	w.Header().Set("content-type", "application/json")
@@ -124,7 +137,7 @@ func resultFrom(filename string, line int, val interface{}) evaluationResult {
 	t := fmt.Sprintf("%T", val)
 	_, isStoppable := val.(core.Stoppable)
 	if err, ok := val.(error); ok {
-		return evaluationResult{
+		result := evaluationResult{
 			Type:         t,
 			IsError:      true,
 			IsStoppeable: isStoppable,
@@ -133,6 +146,12 @@ func resultFrom(filename string, line int, val interface{}) evaluationResult {
 			Line:         line,
 			Object:       val,
 		}
+		// the location of the failing statement
+		if located, ok := err.(*dsl.Error); ok {
+			result.Line = located.Line
+			result.Column = located.Column
+		}
+		return result
 	}
 	// no error
 	var msg string
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// websocketGUID is defined by RFC 6455 to compute the accept key of the handshake.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	websocketContinuation = 0x0
+	websocketText         = 0x1
+	websocketClose        = 0x8
+	websocketPing         = 0x9
+	websocketPong         = 0xA
+)
+
+// websocketProtocolError is the status code of a close frame when a client violates RFC 6455.
+const websocketProtocolError = 1002
+
+// errOriginNotAllowed is returned when a web page of another site wants to open a WebSocket.
+var errOriginNotAllowed = errors.New("WebSocket origin not allowed")
+
+// websocketMaxMessage is the maximum length of a received message ; the connection is closed for longer ones.
+const websocketMaxMessage = 1 << 20
+
+// websocketConn is the server side of a WebSocket that sends text messages in single frames
+// and receives text messages that can be fragmented.
+type websocketConn struct {
+	conn      net.Conn
+	rw        *bufio.ReadWriter
+	mutex     sync.Mutex
+	closeSent bool // guarded by mutex
+	received  chan []byte
+	closed    chan struct{} // closed when the client has closed the connection
+	done      chan struct{} // closed when the server has closed the connection
+	closeOnce sync.Once
+}
+
+// upgradeWebsocket completes the WebSocket handshake of a request.
+// If the request is not a handshake, or comes from a web page whose origin is not allowed by the guard,
+// then an error is returned and the response is not yet written.
+func (g guard) upgradeWebsocket(w http.ResponseWriter, r *http.Request) (*websocketConn, error) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		!strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") {
+		return nil, errors.New("not a WebSocket handshake")
+	}
+	if !g.allowsOrigin(r) {
+		return nil, errOriginNotAllowed
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if len(key) == 0 {
+		return nil, errors.New("missing WebSocket key")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("WebSocket is not supported by the server")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	io.WriteString(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: "+websocketAccept(key)+"\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	ws := &websocketConn{conn: conn, rw: rw, received: make(chan []byte, 16), closed: make(chan struct{}), done: make(chan struct{})}
+	go ws.receive()
+	return ws, nil
+}
+
+func websocketAccept(key string) string {
+	h := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// receive reads frames from the client until it closes the connection.
+// Text messages are available on the received channel ; if they are not read in time then reading frames waits.
+// The connection is closed with a protocol error if a frame is not masked or not in sequence.
+func (c *websocketConn) receive() {
+	defer close(c.closed)
+	header := make([]byte, 2)
+	var message []byte // of a fragmented text message
+	fragmented := false
+	for {
+		if _, err := io.ReadFull(c.rw, header); err != nil {
+			return
+		}
+		final := header[0]&0x80 != 0
+		opcode := header[0] & 0x0f
+		length := uint64(header[1] & 0x7f)
+		// clients must mask all frames and must not use extensions
+		if header[1]&0x80 == 0 || header[0]&0x70 != 0 {
+			c.fail()
+			return
+		}
+		// control frames cannot be fragmented
+		if opcode >= websocketClose && (!final || length > 125) {
+			c.fail()
+			return
+		}
+		switch length {
+		case 126:
+			ext := make([]byte, 2)
+			if _, err := io.ReadFull(c.rw, ext); err != nil {
+				return
+			}
+			length = uint64(binary.BigEndian.Uint16(ext))
+		case 127:
+			ext := make([]byte, 8)
+			if _, err := io.ReadFull(c.rw, ext); err != nil {
+				return
+			}
+			length = binary.BigEndian.Uint64(ext)
+		}
+		if length > websocketMaxMessage || uint64(len(message))+length > websocketMaxMessage {
+			return
+		}
+		mask := make([]byte, 4)
+		if _, err := io.ReadFull(c.rw, mask); err != nil {
+			return
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.rw, payload); err != nil {
+			return
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+		switch opcode {
+		case websocketClose:
+			return
+		case websocketPing:
+			c.writeFrame(websocketPong, payload)
+			continue
+		case websocketPong:
+			continue
+		case websocketText:
+			if fragmented {
+				c.fail()
+				return
+			}
+			message = payload
+		case websocketContinuation:
+			if !fragmented {
+				c.fail()
+				return
+			}
+			message = append(message, payload...)
+		default:
+			// binary messages are not supported
+			c.fail()
+			return
+		}
+		fragmented = !final
+		if fragmented {
+			continue
+		}
+		select {
+		case c.received <- message:
+		case <-c.done:
+			return
+		}
+		message = nil
+	}
+}
+
+// fail sends a close frame with the protocol error status.
+func (c *websocketConn) fail() {
+	c.writeFrame(websocketClose, binary.BigEndian.AppendUint16(nil, websocketProtocolError))
+}
+
+// writeText sends a message in a single unmasked frame.
+func (c *websocketConn) writeText(data []byte) error {
+	return c.writeFrame(websocketText, data)
+}
+
+func (c *websocketConn) writeFrame(opcode byte, data []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	// nothing can be sent after a close frame
+	if c.closeSent {
+		return nil
+	}
+	c.closeSent = opcode == websocketClose
+	header := []byte{0x80 | opcode}
+	switch n := len(data); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n < 1<<16:
+		header = append(header, 126, byte(n>>8), byte(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	if _, err := c.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(data); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+// Close sends a close frame and closes the connection.
+func (c *websocketConn) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	c.writeFrame(websocketClose, nil)
+	return c.conn.Close()
+}
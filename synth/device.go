@@ -0,0 +1,321 @@
+package synth
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+const (
+	// SampleRate is the number of stereo frames per second that are rendered.
+	SampleRate = 44100
+	// framesPerBlock is the number of frames rendered and written at once.
+	framesPerBlock = 512
+)
+
+// players are commands that play raw signed 16-bit little-endian stereo samples from stdin.
+var players = [][]string{
+	{"aplay", "-q", "-t", "raw", "-f", "S16_LE", "-c", "2", "-r", fmt.Sprint(SampleRate)},
+	{"play", "-q", "-t", "raw", "-e", "signed", "-b", "16", "-c", "2", "-r", fmt.Sprint(SampleRate), "-"},
+	{"ffplay", "-nodisp", "-loglevel", "quiet", "-f", "s16le", "-ac", "2", "-ar", fmt.Sprint(SampleRate), "-"},
+}
+
+// Device is an AudioDevice that plays notes with a SoundFont such that no MIDI synthesizer is needed.
+// The rendered samples are written to the first available audio player (aplay, play of SoX or ffplay).
+type Device struct {
+	mutex    *sync.RWMutex
+	fontName string
+	renderer *Renderer
+	out      io.WriteCloser
+	player   *exec.Cmd
+	timeline *core.Timeline
+	echo     bool
+	done     chan bool
+}
+
+// NewDevice returns a Device that loads a SoundFont file and starts an audio player.
+func NewDevice(fontName string) (*Device, error) {
+	font, err := LoadSoundFont(fontName)
+	if err != nil {
+		return nil, err
+	}
+	var cmd *exec.Cmd
+	for _, each := range players {
+		if _, err := exec.LookPath(each[0]); err == nil {
+			cmd = exec.Command(each[0], each[1:]...)
+			break
+		}
+	}
+	if cmd == nil {
+		return nil, errors.New("no audio player found, install one of aplay, sox or ffplay")
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("cannot start audio player %s: %v", cmd.Path, err)
+	}
+	d := newDevice(fontName, font, stdin)
+	d.player = cmd
+	go d.timeline.Play()
+	go d.render()
+	return d, nil
+}
+
+func newDevice(fontName string, font *SoundFont, out io.WriteCloser) *Device {
+	return &Device{
+		mutex:    new(sync.RWMutex),
+		fontName: fontName,
+		renderer: NewRenderer(font, SampleRate),
+		out:      out,
+		timeline: core.NewTimeline(),
+		done:     make(chan bool),
+	}
+}
+
+// Font returns the SoundFont that is used to render notes.
+func (d *Device) Font() *SoundFont {
+	return d.renderer.font
+}
+
+// render writes blocks of samples to the player ; a write blocks while the player is busy which keeps the pace.
+func (d *Device) render() {
+	left := make([]float32, framesPerBlock)
+	right := make([]float32, framesPerBlock)
+	data := make([]byte, framesPerBlock*4)
+	for {
+		select {
+		case <-d.done:
+			return
+		default:
+		}
+		d.renderer.Render(left, right)
+		encodeFrames(data, left, right)
+		if _, err := d.out.Write(data); err != nil {
+			notify.Console.Errorf("audio write error:%v", err)
+			return
+		}
+	}
+}
+
+// encodeFrames writes interleaved signed 16-bit little-endian samples.
+func encodeFrames(data []byte, left, right []float32) {
+	for i := range left {
+		binary.LittleEndian.PutUint16(data[i*4:], uint16(toInt16(left[i])))
+		binary.LittleEndian.PutUint16(data[i*4+2:], uint16(toInt16(right[i])))
+	}
+}
+
+func toInt16(f float32) int16 {
+	return int16(math.Max(-1, math.Min(1, float64(f))) * 32767)
+}
+
+// DefaultDeviceIDs is part of AudioDevice ; there is no input and one output.
+func (d *Device) DefaultDeviceIDs() (inputDeviceID, outputDeviceID int) {
+	return -1, 0
+}
+
+// Command is part of AudioDevice
+func (d *Device) Command(args []string) notify.Message {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	notify.PrintHighlighted("SoundFont output:")
+	fmt.Printf("  font = %s\n", d.fontName)
+	if d.player != nil {
+		fmt.Printf("player = %s\n", d.player.Path)
+	}
+	fmt.Printf("  echo = %v\n", d.echo)
+	fmt.Println()
+	notify.PrintHighlighted("presets:")
+	for _, each := range d.renderer.font.Presets {
+		fmt.Printf(" %3d:%-3d %s\n", each.Bank, each.Number, each.Name)
+	}
+	fmt.Println()
+	notify.PrintHighlighted("change:")
+	fmt.Println("set('synth.program',<channel>,<program>) --- select a preset for a channel")
+	fmt.Println("set('echo',true)                         --- true = print the notes")
+	return nil
+}
+
+// HandleSetting is part of AudioDevice
+func (d *Device) HandleSetting(name string, values []interface{}) error {
+	switch name {
+	case "synth.program":
+		if len(values) != 2 {
+			return fmt.Errorf("two arguments expected")
+		}
+		channel, ok := values[0].(int)
+		if !ok || channel < 1 || channel > 16 {
+			return fmt.Errorf("channel [1..16] expected, got %v", values[0])
+		}
+		program, ok := values[1].(int)
+		if !ok || program < 0 || program > 127 {
+			return fmt.Errorf("program [0..127] expected, got %v", values[1])
+		}
+		if err := d.renderer.SetProgram(channel, program); err != nil {
+			return err
+		}
+		notify.Infof("program of channel %d is set to %d", channel, program)
+	case "echo":
+		if len(values) != 1 {
+			return fmt.Errorf("one argument expected")
+		}
+		enable, ok := values[0].(bool)
+		if !ok {
+			return fmt.Errorf("boolean device argument expected, got %T", values[0])
+		}
+		d.mutex.Lock()
+		d.echo = enable
+		d.mutex.Unlock()
+		notify.Infof("echo notes is enabled: %v", enable)
+	default:
+		return fmt.Errorf("unknown setting:%s", name)
+	}
+	return nil
+}
+
+// Play is part of AudioDevice
+func (d *Device) Play(condition core.Condition, seq core.Sequenceable, bpm float64, beginAt time.Time) time.Time {
+	seq = core.UnValue(seq)
+	if sel, ok := seq.(core.DeviceSelector); ok {
+		seq = sel.Unwrap()
+	}
+	channel := 1
+	if sel, ok := seq.(core.ChannelSelector); ok {
+		channel = sel.Channel()
+		seq = sel.Unwrap()
+	}
+	d.mutex.RLock()
+	echo := d.echo
+	d.mutex.RUnlock()
+
+	whole := core.WholeNoteDuration(bpm)
+	moment := beginAt
+	for _, group := range seq.S().Notes {
+		if len(group) == 0 {
+			continue
+		}
+		for _, each := range group {
+			if !each.IsHearable() {
+				continue
+			}
+			duration := time.Duration(float32(whole) * each.DurationFactor())
+			if fixed, ok := each.NonFractionBasedDuration(); ok {
+				duration = fixed
+			}
+			on := noteEvent{renderer: d.renderer, channel: channel, note: each, on: true, mustHandle: condition}
+			d.timeline.Schedule(on, moment)
+			d.timeline.Schedule(on.asNoteOff(), moment.Add(duration))
+		}
+		if echo && isHearable(group) {
+			d.timeline.Schedule(echoEvent{text: core.StringFromNoteGroup(group), mustHandle: condition}, moment)
+		}
+		moment = moment.Add(time.Duration(float32(whole) * group[0].DurationFactor()))
+	}
+	return moment
+}
+
+func isHearable(group []core.Note) bool {
+	for _, each := range group {
+		if each.IsHearable() {
+			return true
+		}
+	}
+	return false
+}
+
+// noteEvent starts or releases a note when handled by the timeline.
+type noteEvent struct {
+	renderer   *Renderer
+	channel    int
+	note       core.Note
+	on         bool
+	mustHandle core.Condition
+}
+
+func (e noteEvent) asNoteOff() noteEvent {
+	e.on = false
+	e.mustHandle = nil
+	return e
+}
+
+// Handle is part of TimelineEvent
+func (e noteEvent) Handle(tim *core.Timeline, when time.Time) {
+	if e.on {
+		if e.mustHandle != nil && !e.mustHandle() {
+			return
+		}
+		e.renderer.NoteOn(e.channel, e.note.MIDI(), e.note.Velocity)
+	} else {
+		e.renderer.NoteOff(e.channel, e.note.MIDI())
+	}
+	if notify.HasSubscribers() {
+		notify.Publish(notify.NoteEventKind, notify.NoteData{Channel: e.channel, Number: int64(e.note.MIDI()), Velocity: int64(e.note.Velocity), On: e.on})
+	}
+}
+
+// NoteChangesDo is part of TimelineEvent
+func (e noteEvent) NoteChangesDo(block func(core.NoteChange)) {
+	block(core.NewNoteChange(e.on, int64(e.note.MIDI()), int64(e.note.Velocity)))
+}
+
+// echoEvent prints the notes of a group when handled by the timeline.
+type echoEvent struct {
+	text       string
+	mustHandle core.Condition
+}
+
+// Handle is part of TimelineEvent
+func (e echoEvent) Handle(tim *core.Timeline, when time.Time) {
+	if e.mustHandle != nil && !e.mustHandle() {
+		return
+	}
+	fmt.Fprintf(notify.Console.DeviceOut, " %s", e.text)
+}
+
+// NoteChangesDo is part of TimelineEvent
+func (e echoEvent) NoteChangesDo(block func(core.NoteChange)) {}
+
+// HasInputCapability is part of AudioDevice
+func (d *Device) HasInputCapability() bool { return false }
+
+// Listen is part of AudioDevice
+func (d *Device) Listen(deviceID int, who core.NoteListener, startOrStop bool) {
+	notify.Warnf("listen is not available for a SoundFont device")
+}
+
+// OnKey is part of AudioDevice
+func (d *Device) OnKey(ctx core.Context, deviceID int, channel int, note core.Note, fun core.HasValue) error {
+	return errors.New("onkey is not available for a SoundFont device")
+}
+
+// Schedule is part of AudioDevice
+func (d *Device) Schedule(event core.TimelineEvent, beginAt time.Time) {
+	d.timeline.Schedule(event, beginAt)
+}
+
+// Reset is part of AudioDevice
+func (d *Device) Reset() {
+	d.timeline.Reset()
+	d.renderer.AllNotesOff()
+}
+
+// Close is part of AudioDevice
+func (d *Device) Close() error {
+	d.Reset()
+	close(d.done)
+	err := d.out.Close()
+	if d.player != nil {
+		d.player.Wait()
+	}
+	return err
+}
@@ -0,0 +1,203 @@
+package synth
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+const (
+	// maxVoices is the number of samples that can sound at the same time ; the oldest is stopped first.
+	maxVoices = 64
+	// minRelease avoids clicks when a voice stops.
+	minRelease = 0.01 // seconds
+	// drumChannel plays the presets of the percussion bank.
+	drumChannel = 10
+	drumBank    = 128
+)
+
+// Renderer mixes the voices of sounding notes into stereo samples.
+type Renderer struct {
+	mutex      sync.Mutex
+	font       *SoundFont
+	sampleRate int
+	programs   map[int]*Preset // channel -> preset
+	voices     []*voice
+}
+
+// NewRenderer returns a Renderer for a SoundFont that produces samples at a given rate.
+func NewRenderer(font *SoundFont, sampleRate int) *Renderer {
+	return &Renderer{
+		font:       font,
+		sampleRate: sampleRate,
+		programs:   map[int]*Preset{},
+	}
+}
+
+type voice struct {
+	channel, key int
+	zone         zone
+	position     float64
+	step         float64
+	left, right  float64
+	released     bool
+	releaseLeft  int
+	releaseTotal int
+	done         bool
+}
+
+// SetProgram selects the preset of the current bank of a channel.
+func (r *Renderer) SetProgram(channel, program int) error {
+	bank := 0
+	if channel == drumChannel {
+		bank = drumBank
+	}
+	p := r.font.Preset(bank, program)
+	if p == nil {
+		return fmt.Errorf("no preset in bank %d with program %d", bank, program)
+	}
+	r.mutex.Lock()
+	r.programs[channel] = p
+	r.mutex.Unlock()
+	return nil
+}
+
+// preset returns the selected preset of a channel or a default one.
+// pre: mutex locked
+func (r *Renderer) preset(channel int) *Preset {
+	if p, ok := r.programs[channel]; ok {
+		return p
+	}
+	var p *Preset
+	if channel == drumChannel {
+		p = r.font.Preset(drumBank, 0)
+	}
+	if p == nil {
+		p = r.font.Preset(0, 0)
+	}
+	if p == nil && len(r.font.Presets) > 0 {
+		p = r.font.Presets[0]
+	}
+	r.programs[channel] = p
+	return p
+}
+
+// NoteOn starts a voice for each zone of the channel preset that matches the key and velocity.
+func (r *Renderer) NoteOn(channel, key, velocity int) {
+	if velocity <= 0 {
+		r.NoteOff(channel, key)
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	p := r.preset(channel)
+	if p == nil {
+		return
+	}
+	loudness := float64(velocity) / 127
+	loudness *= loudness
+	for _, z := range p.zones {
+		if !z.matches(key, velocity) {
+			continue
+		}
+		angle := (z.pan + 1) * math.Pi / 4
+		v := &voice{
+			channel:  channel,
+			key:      key,
+			zone:     z,
+			position: float64(z.start),
+			step:     math.Pow(2, (float64(key-z.rootKey)+z.tune)/12) * float64(z.sampleRate) / float64(r.sampleRate),
+			left:     math.Cos(angle) * z.gain * loudness,
+			right:    math.Sin(angle) * z.gain * loudness,
+		}
+		if len(r.voices) == maxVoices {
+			r.voices = r.voices[1:]
+		}
+		r.voices = append(r.voices, v)
+	}
+}
+
+// NoteOff releases all voices of a key on a channel.
+func (r *Renderer) NoteOff(channel, key int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, each := range r.voices {
+		if each.channel == channel && each.key == key && !each.released {
+			r.release(each)
+		}
+	}
+}
+
+// AllNotesOff releases all voices.
+func (r *Renderer) AllNotesOff() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, each := range r.voices {
+		if !each.released {
+			r.release(each)
+		}
+	}
+}
+
+func (r *Renderer) release(v *voice) {
+	v.released = true
+	v.releaseTotal = int(math.Max(v.zone.release, minRelease) * float64(r.sampleRate))
+	v.releaseLeft = v.releaseTotal
+}
+
+// IsSounding returns whether any voice is producing samples.
+func (r *Renderer) IsSounding() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.voices) > 0
+}
+
+// Render mixes all voices into the left and right buffers which must have the same length.
+func (r *Renderer) Render(left, right []float32) {
+	for i := range left {
+		left[i], right[i] = 0, 0
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	samples := r.font.samples
+	sounding := r.voices[:0]
+	for _, v := range r.voices {
+		for i := range left {
+			index := int(v.position)
+			frac := v.position - float64(index)
+			s0 := float64(samples[index])
+			s1 := s0
+			if index+1 < v.zone.end {
+				s1 = float64(samples[index+1])
+			}
+			value := (s0 + (s1-s0)*frac) / 32768
+			if v.released {
+				value *= float64(v.releaseLeft) / float64(v.releaseTotal)
+				v.releaseLeft--
+				if v.releaseLeft <= 0 {
+					v.done = true
+				}
+			}
+			left[i] += float32(value * v.left)
+			right[i] += float32(value * v.right)
+			v.position += v.step
+			if v.zone.loop && v.position >= float64(v.zone.loopEnd) {
+				// a step can be longer than the loop, e.g. a high key on a single cycle
+				if length := float64(v.zone.loopEnd - v.zone.loopStart); length > 0 {
+					v.position = float64(v.zone.loopStart) + math.Mod(v.position-float64(v.zone.loopStart), length)
+				} else {
+					v.done = true
+				}
+			} else if v.position >= float64(v.zone.end) {
+				v.done = true
+			}
+			if v.done {
+				break
+			}
+		}
+		if !v.done {
+			sounding = append(sounding, v)
+		}
+	}
+	r.voices = sounding
+}
@@ -0,0 +1,85 @@
+package synth
+
+import (
+	"math"
+	"testing"
+)
+
+func peak(buffer []float32) float64 {
+	p := 0.0
+	for _, each := range buffer {
+		p = math.Max(p, math.Abs(float64(each)))
+	}
+	return p
+}
+
+func TestRenderer_NoteOnOff(t *testing.T) {
+	sf, _ := ParseSoundFont(testFont())
+	r := NewRenderer(sf, SampleRate)
+	left := make([]float32, 1000)
+	right := make([]float32, 1000)
+	r.Render(left, right)
+	if got, want := peak(left), 0.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	r.NoteOn(1, 69, 127)
+	r.Render(left, right)
+	if got := peak(left); got < 0.3 {
+		t.Errorf("got [%v] want sound", got)
+	}
+	// looping keeps the voice
+	r.Render(left, right)
+	if got, want := r.IsSounding(), true; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	r.NoteOff(1, 69)
+	// release is minRelease = 441 samples
+	r.Render(left, right)
+	if got, want := r.IsSounding(), false; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	r.Render(left, right)
+	if got, want := peak(left), 0.0; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestRenderer_Pitch(t *testing.T) {
+	sf, _ := ParseSoundFont(testFont())
+	r := NewRenderer(sf, SampleRate)
+	r.NoteOn(1, 81, 100) // octave higher than root
+	if got, want := r.voices[0].step, 2.0; math.Abs(got-want) > 1e-9 {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestRenderer_SetProgram(t *testing.T) {
+	sf, _ := ParseSoundFont(testFont())
+	r := NewRenderer(sf, SampleRate)
+	if err := r.SetProgram(1, 0); err != nil {
+		t.Error(err)
+	}
+	if err := r.SetProgram(1, 42); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestRenderer_ShortLoopHighKey(t *testing.T) {
+	sf := &SoundFont{
+		samples: []int16{0, 16000, -16000, 0, 0},
+		Presets: []*Preset{{zones: []zone{{
+			keyHi: 127, velHi: 127,
+			start: 0, end: 3, loopStart: 1, loopEnd: 3, loop: true,
+			sampleRate: SampleRate, rootKey: 60, gain: 1,
+		}}}},
+	}
+	r := NewRenderer(sf, SampleRate)
+	r.NoteOn(1, 60+5*12, 127)
+	left := make([]float32, 1000)
+	right := make([]float32, 1000)
+	r.Render(left, right)
+	v := r.voices[0]
+	if v.position < float64(v.zone.loopStart) || v.position >= float64(v.zone.loopEnd) {
+		t.Errorf("position %v outside loop", v.position)
+	}
+}
@@ -0,0 +1,303 @@
+package synth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// SoundFont holds the presets and sample data of a SoundFont 2 (.sf2) file.
+// Only the generators needed to play samples are supported: ranges, tuning, looping,
+// attenuation, pan and volume release ; modulators are ignored.
+type SoundFont struct {
+	Presets []*Preset
+	samples []int16
+}
+
+// Preset is an instrument that can be selected by bank and program number.
+type Preset struct {
+	Name   string
+	Bank   int
+	Number int
+	zones  []zone
+}
+
+// zone is a sample that plays for a key and velocity range.
+type zone struct {
+	keyLo, keyHi int
+	velLo, velHi int
+	start, end   int // sample data indices
+	loopStart    int
+	loopEnd      int
+	loop         bool
+	sampleRate   int
+	rootKey      int
+	tune         float64 // semitones
+	gain         float64
+	pan          float64 // -1 = left, 1 = right
+	release      float64 // seconds
+}
+
+func (z zone) matches(key, velocity int) bool {
+	return key >= z.keyLo && key <= z.keyHi && velocity >= z.velLo && velocity <= z.velHi
+}
+
+// generator operators, see SoundFont 2.04 section 8.1.2
+const (
+	genStartAddrsOffset           = 0
+	genEndAddrsOffset             = 1
+	genStartloopAddrsOffset       = 2
+	genEndloopAddrsOffset         = 3
+	genStartAddrsCoarseOffset     = 4
+	genEndAddrsCoarseOffset       = 12
+	genPan                        = 17
+	genReleaseVolEnv              = 38
+	genInstrument                 = 41
+	genKeyRange                   = 43
+	genVelRange                   = 44
+	genStartloopAddrsCoarseOffset = 45
+	genInitialAttenuation         = 48
+	genEndloopAddrsCoarseOffset   = 50
+	genCoarseTune                 = 51
+	genFineTune                   = 52
+	genSampleID                   = 53
+	genSampleModes                = 54
+	genOverridingRootKey          = 58
+)
+
+// generators maps an operator to its raw amount.
+type generators map[uint16]uint16
+
+func (g generators) int(op uint16, absent int) int {
+	if v, ok := g[op]; ok {
+		return int(int16(v))
+	}
+	return absent
+}
+
+func (g generators) rangeOf(op uint16) (lo, hi int) {
+	if v, ok := g[op]; ok {
+		return int(v & 0xFF), int(v >> 8)
+	}
+	return 0, 127
+}
+
+func (g generators) merged(with generators) generators {
+	m := generators{}
+	for k, v := range g {
+		m[k] = v
+	}
+	for k, v := range with {
+		m[k] = v
+	}
+	return m
+}
+
+// LoadSoundFont reads a SoundFont 2 file.
+func LoadSoundFont(name string) (*SoundFont, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read SoundFont %s: %v", name, err)
+	}
+	return ParseSoundFont(data)
+}
+
+// ParseSoundFont decodes the RIFF data of a SoundFont 2 file.
+func ParseSoundFont(data []byte) (*SoundFont, error) {
+	r := bytes.NewReader(data)
+	id, body, err := readChunk(r)
+	if err != nil {
+		return nil, err
+	}
+	if id != "RIFF" || len(body) < 4 || string(body[:4]) != "sfbk" {
+		return nil, fmt.Errorf("not a SoundFont 2 file")
+	}
+	chunks := map[string][]byte{}
+	lists := bytes.NewReader(body[4:])
+	for lists.Len() > 0 {
+		id, list, err := readChunk(lists)
+		if err != nil {
+			return nil, err
+		}
+		if id != "LIST" || len(list) < 4 {
+			continue
+		}
+		sub := bytes.NewReader(list[4:])
+		for sub.Len() > 0 {
+			id, chunk, err := readChunk(sub)
+			if err != nil {
+				return nil, err
+			}
+			chunks[id] = chunk
+		}
+	}
+	for _, each := range []string{"smpl", "phdr", "pbag", "pgen", "inst", "ibag", "igen", "shdr"} {
+		if _, ok := chunks[each]; !ok {
+			return nil, fmt.Errorf("invalid SoundFont, missing %s chunk", each)
+		}
+	}
+	sf := &SoundFont{samples: make([]int16, len(chunks["smpl"])/2)}
+	binary.Read(bytes.NewReader(chunks["smpl"]), binary.LittleEndian, sf.samples)
+	return sf, sf.parsePresets(chunks)
+}
+
+func readChunk(r *bytes.Reader) (string, []byte, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", nil, fmt.Errorf("invalid RIFF chunk: %v", err)
+	}
+	size := int(binary.LittleEndian.Uint32(header[4:]))
+	if size > r.Len() {
+		return "", nil, fmt.Errorf("invalid RIFF chunk size: %d", size)
+	}
+	body := make([]byte, size)
+	r.Read(body)
+	if size%2 == 1 {
+		r.ReadByte() // pad
+	}
+	return string(header[:4]), body, nil
+}
+
+// sampleHeader is a record of the shdr chunk.
+type sampleHeader struct {
+	start, end, loopStart, loopEnd int
+	rate                           int
+	originalPitch                  int
+	correction                     int // cents
+}
+
+// zoneGenerators returns the generators of each zone in a bag range.
+func zoneGenerators(bags, gens []byte, from, to int) []generators {
+	list := []generators{}
+	for b := from; b < to && (b+1)*4+2 <= len(bags); b++ {
+		g := generators{}
+		first := int(binary.LittleEndian.Uint16(bags[b*4:]))
+		last := int(binary.LittleEndian.Uint16(bags[(b+1)*4:]))
+		for i := first; i < last && (i+1)*4 <= len(gens); i++ {
+			g[binary.LittleEndian.Uint16(gens[i*4:])] = binary.LittleEndian.Uint16(gens[i*4+2:])
+		}
+		list = append(list, g)
+	}
+	return list
+}
+
+// splitGlobal returns the global zone (if any) and the other zones.
+// A global zone is the first zone without the operator that ends each zone.
+func splitGlobal(zones []generators, terminal uint16) (generators, []generators) {
+	if len(zones) > 0 {
+		if _, ok := zones[0][terminal]; !ok {
+			return zones[0], zones[1:]
+		}
+	}
+	return generators{}, zones
+}
+
+func (sf *SoundFont) parsePresets(chunks map[string][]byte) error {
+	shdr := chunks["shdr"]
+	headers := []sampleHeader{}
+	for i := 0; (i+1)*46 <= len(shdr); i++ {
+		rec := shdr[i*46:]
+		headers = append(headers, sampleHeader{
+			start:         int(binary.LittleEndian.Uint32(rec[20:])),
+			end:           int(binary.LittleEndian.Uint32(rec[24:])),
+			loopStart:     int(binary.LittleEndian.Uint32(rec[28:])),
+			loopEnd:       int(binary.LittleEndian.Uint32(rec[32:])),
+			rate:          int(binary.LittleEndian.Uint32(rec[36:])),
+			originalPitch: int(rec[40]),
+			correction:    int(int8(rec[41])),
+		})
+	}
+	// instruments
+	inst := chunks["inst"]
+	instruments := [][]generators{}
+	for i := 0; (i+2)*22 <= len(inst); i++ {
+		from := int(binary.LittleEndian.Uint16(inst[i*22+20:]))
+		to := int(binary.LittleEndian.Uint16(inst[(i+1)*22+20:]))
+		global, zones := splitGlobal(zoneGenerators(chunks["ibag"], chunks["igen"], from, to), genSampleID)
+		merged := []generators{}
+		for _, each := range zones {
+			merged = append(merged, global.merged(each))
+		}
+		instruments = append(instruments, merged)
+	}
+	// presets
+	phdr := chunks["phdr"]
+	for i := 0; (i+2)*38 <= len(phdr); i++ {
+		rec := phdr[i*38:]
+		p := &Preset{
+			Name:   strings.TrimRight(string(rec[:20]), "\x00"),
+			Number: int(binary.LittleEndian.Uint16(rec[20:])),
+			Bank:   int(binary.LittleEndian.Uint16(rec[22:])),
+		}
+		from := int(binary.LittleEndian.Uint16(rec[24:]))
+		to := int(binary.LittleEndian.Uint16(phdr[(i+1)*38+24:]))
+		global, zones := splitGlobal(zoneGenerators(chunks["pbag"], chunks["pgen"], from, to), genInstrument)
+		for _, each := range zones {
+			pg := global.merged(each)
+			index := pg.int(genInstrument, -1)
+			if index < 0 || index >= len(instruments) {
+				return fmt.Errorf("invalid SoundFont, preset %s refers to unknown instrument %d", p.Name, index)
+			}
+			for _, ig := range instruments[index] {
+				id := ig.int(genSampleID, -1)
+				if id < 0 || id >= len(headers) {
+					return fmt.Errorf("invalid SoundFont, preset %s refers to unknown sample %d", p.Name, id)
+				}
+				if z, ok := newZone(pg, ig, headers[id], len(sf.samples)); ok {
+					p.zones = append(p.zones, z)
+				}
+			}
+		}
+		sf.Presets = append(sf.Presets, p)
+	}
+	return nil
+}
+
+// newZone combines the preset and instrument generators for a sample.
+// Ranges are intersected and tuning and attenuation of the preset are added.
+func newZone(pg, ig generators, h sampleHeader, size int) (zone, bool) {
+	z := zone{sampleRate: h.rate}
+	pLo, pHi := pg.rangeOf(genKeyRange)
+	iLo, iHi := ig.rangeOf(genKeyRange)
+	z.keyLo, z.keyHi = max(pLo, iLo), min(pHi, iHi)
+	pLo, pHi = pg.rangeOf(genVelRange)
+	iLo, iHi = ig.rangeOf(genVelRange)
+	z.velLo, z.velHi = max(pLo, iLo), min(pHi, iHi)
+	if z.keyLo > z.keyHi || z.velLo > z.velHi {
+		return z, false
+	}
+	z.start = h.start + ig.int(genStartAddrsOffset, 0) + 32768*ig.int(genStartAddrsCoarseOffset, 0)
+	z.end = h.end + ig.int(genEndAddrsOffset, 0) + 32768*ig.int(genEndAddrsCoarseOffset, 0)
+	z.loopStart = h.loopStart + ig.int(genStartloopAddrsOffset, 0) + 32768*ig.int(genStartloopAddrsCoarseOffset, 0)
+	z.loopEnd = h.loopEnd + ig.int(genEndloopAddrsOffset, 0) + 32768*ig.int(genEndloopAddrsCoarseOffset, 0)
+	if z.start < 0 || z.end > size || z.start >= z.end || z.sampleRate <= 0 {
+		return z, false
+	}
+	z.loop = ig.int(genSampleModes, 0)&1 == 1 &&
+		z.loopStart >= z.start && z.loopEnd <= z.end && z.loopEnd-z.loopStart > 1
+	z.rootKey = ig.int(genOverridingRootKey, -1)
+	if z.rootKey < 0 {
+		z.rootKey = h.originalPitch
+	}
+	z.tune = float64(ig.int(genCoarseTune, 0)+pg.int(genCoarseTune, 0)) +
+		float64(ig.int(genFineTune, 0)+pg.int(genFineTune, 0)+h.correction)/100
+	centibels := ig.int(genInitialAttenuation, 0) + pg.int(genInitialAttenuation, 0)
+	z.gain = math.Pow(10, -float64(centibels)/200)
+	z.pan = math.Max(-1, math.Min(1, float64(ig.int(genPan, 0)+pg.int(genPan, 0))/500))
+	z.release = math.Pow(2, float64(ig.int(genReleaseVolEnv, -12000)+pg.int(genReleaseVolEnv, 0))/1200)
+	return z, true
+}
+
+// Preset returns the preset for a bank and program number, or nil if absent.
+func (sf *SoundFont) Preset(bank, number int) *Preset {
+	for _, each := range sf.Presets {
+		if each.Bank == bank && each.Number == number {
+			return each
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package synth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func chunk(id string, body []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(id)
+	binary.Write(&b, binary.LittleEndian, uint32(len(body)))
+	b.Write(body)
+	if len(body)%2 == 1 {
+		b.WriteByte(0)
+	}
+	return b.Bytes()
+}
+
+func list(kind string, chunks ...[]byte) []byte {
+	body := []byte(kind)
+	for _, each := range chunks {
+		body = append(body, each...)
+	}
+	return chunk("LIST", body)
+}
+
+func records(values ...interface{}) []byte {
+	var b bytes.Buffer
+	for _, each := range values {
+		if s, ok := each.(string); ok {
+			name := make([]byte, 20)
+			copy(name, s)
+			b.Write(name)
+			continue
+		}
+		binary.Write(&b, binary.LittleEndian, each)
+	}
+	return b.Bytes()
+}
+
+// testFont returns a SoundFont with one looping sine preset that has A4 as root key.
+func testFont() []byte {
+	sine := make([]int16, 100)
+	for i := range sine {
+		sine[i] = int16(16000 * math.Sin(2*math.Pi*float64(i)/80))
+	}
+	smpl := records(sine, make([]int16, 46))
+	phdr := records(
+		"Sine", uint16(0), uint16(0), uint16(0), uint32(0), uint32(0), uint32(0),
+		"EOP", uint16(0), uint16(0), uint16(1), uint32(0), uint32(0), uint32(0))
+	pbag := records(uint16(0), uint16(0), uint16(1), uint16(0))
+	pgen := records(uint16(genInstrument), uint16(0), uint16(0), uint16(0))
+	inst := records("Sine", uint16(0), "EOI", uint16(1))
+	ibag := records(uint16(0), uint16(0), uint16(3), uint16(0))
+	igen := records(
+		uint16(genKeyRange), uint16(127<<8),
+		uint16(genSampleModes), uint16(1),
+		uint16(genSampleID), uint16(0),
+		uint16(0), uint16(0))
+	shdr := records(
+		"sine", uint32(0), uint32(100), uint32(0), uint32(80), uint32(44100), uint8(69), int8(0), uint16(0), uint16(1),
+		"EOS", uint32(0), uint32(0), uint32(0), uint32(0), uint32(0), uint8(0), int8(0), uint16(0), uint16(0))
+	body := []byte("sfbk")
+	body = append(body, list("INFO", chunk("ifil", records(uint16(2), uint16(1))))...)
+	body = append(body, list("sdta", chunk("smpl", smpl))...)
+	body = append(body, list("pdta",
+		chunk("phdr", phdr), chunk("pbag", pbag), chunk("pmod", make([]byte, 10)), chunk("pgen", pgen),
+		chunk("inst", inst), chunk("ibag", ibag), chunk("imod", make([]byte, 10)), chunk("igen", igen),
+		chunk("shdr", shdr))...)
+	return chunk("RIFF", body)
+}
+
+func TestParseSoundFont(t *testing.T) {
+	sf, err := ParseSoundFont(testFont())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(sf.Presets), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	p := sf.Preset(0, 0)
+	if got, want := p.Name, "Sine"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := len(p.zones), 1; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	z := p.zones[0]
+	if got, want := z.rootKey, 69; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := z.loop, true; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestParseSoundFont_Invalid(t *testing.T) {
+	if _, err := ParseSoundFont(chunk("RIFF", []byte("WAVE"))); err == nil {
+		t.Error("error expected")
+	}
+	if _, err := ParseSoundFont(chunk("RIFF", []byte("sfbk"))); err == nil {
+		t.Error("error expected")
+	}
+}
@@ -0,0 +1,145 @@
+package synth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/notify"
+)
+
+// maxTail is the longest time that is rendered after the last note off, for releasing voices.
+const maxTail = 5 * time.Second
+
+// renderEvent is a note change at a frame.
+type renderEvent struct {
+	frame    int
+	on       bool
+	channel  int
+	key      int
+	velocity int
+}
+
+// ExportWAV creates (overwrites) a WAV file with the rendered audio of a sequenceable.
+func ExportWAV(fileName string, font *SoundFont, m interface{}, bpm float64) error {
+	out, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	notify.Infof("rendering audio to [%s] ...", fileName)
+	return RenderWAV(out, font, m, bpm)
+}
+
+// RenderWAV writes a 16-bit stereo WAV with the audio of a sequenceable, rendered offline with a SoundFont.
+func RenderWAV(w io.Writer, font *SoundFont, m interface{}, bpm float64) error {
+	var seq core.Sequenceable
+	if lp, ok := m.(*core.Loop); ok {
+		seq = lp.ToSequence(4)
+	} else if s, ok := m.(core.Sequenceable); ok {
+		seq = s
+	} else {
+		return fmt.Errorf("cannot render audio of a (%T)", m)
+	}
+	events := renderEvents(seq, bpm)
+	r := NewRenderer(font, SampleRate)
+	var data bytes.Buffer
+	left := make([]float32, framesPerBlock)
+	right := make([]float32, framesPerBlock)
+	block := make([]byte, framesPerBlock*4)
+	frame := 0
+	renderUntil := func(end int) {
+		for frame < end {
+			n := min(framesPerBlock, end-frame)
+			r.Render(left[:n], right[:n])
+			encodeFrames(block, left[:n], right[:n])
+			data.Write(block[:n*4])
+			frame += n
+		}
+	}
+	for _, each := range events {
+		renderUntil(each.frame)
+		if each.on {
+			r.NoteOn(each.channel, each.key, each.velocity)
+		} else {
+			r.NoteOff(each.channel, each.key)
+		}
+	}
+	tailEnd := frame + int(maxTail.Seconds()*SampleRate)
+	for r.IsSounding() && frame < tailEnd {
+		renderUntil(frame + framesPerBlock)
+	}
+	return writeWAV(w, data.Bytes())
+}
+
+// renderEvents returns the note on and off changes of a sequenceable ordered by frame.
+func renderEvents(seq core.Sequenceable, bpm float64) []renderEvent {
+	seq = core.UnValue(seq)
+	if sel, ok := seq.(core.DeviceSelector); ok {
+		seq = sel.Unwrap()
+	}
+	channel := 1
+	if sel, ok := seq.(core.ChannelSelector); ok {
+		channel = sel.Channel()
+		seq = sel.Unwrap()
+	}
+	toFrame := func(d time.Duration) int {
+		return int(d.Seconds() * SampleRate)
+	}
+	whole := core.WholeNoteDuration(bpm)
+	events := []renderEvent{}
+	var moment time.Duration
+	for _, group := range seq.S().Notes {
+		if len(group) == 0 {
+			continue
+		}
+		for _, each := range group {
+			if !each.IsHearable() {
+				continue
+			}
+			duration := time.Duration(float32(whole) * each.DurationFactor())
+			if fixed, ok := each.NonFractionBasedDuration(); ok {
+				duration = fixed
+			}
+			events = append(events,
+				renderEvent{frame: toFrame(moment), on: true, channel: channel, key: each.MIDI(), velocity: each.Velocity},
+				renderEvent{frame: toFrame(moment + duration), channel: channel, key: each.MIDI()})
+		}
+		moment += time.Duration(float32(whole) * group[0].DurationFactor())
+	}
+	// note offs go first such that a repeated note is not released right after it starts
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].frame != events[j].frame {
+			return events[i].frame < events[j].frame
+		}
+		return !events[i].on && events[j].on
+	})
+	return events
+}
+
+// writeWAV writes the RIFF header of 16-bit stereo PCM followed by the sample data.
+func writeWAV(w io.Writer, data []byte) error {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+len(data)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))           // fmt size
+	binary.Write(&b, binary.LittleEndian, uint16(1))            // PCM
+	binary.Write(&b, binary.LittleEndian, uint16(2))            // channels
+	binary.Write(&b, binary.LittleEndian, uint32(SampleRate))   // sample rate
+	binary.Write(&b, binary.LittleEndian, uint32(SampleRate*4)) // byte rate
+	binary.Write(&b, binary.LittleEndian, uint16(4))            // block align
+	binary.Write(&b, binary.LittleEndian, uint16(16))           // bits per sample
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
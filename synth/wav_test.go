@@ -0,0 +1,52 @@
+package synth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestRenderWAV(t *testing.T) {
+	sf, _ := ParseSoundFont(testFont())
+	var b bytes.Buffer
+	// two quarter notes at bpm 120 = 1 second
+	if err := RenderWAV(&b, sf, core.MustParseSequence("a a"), 120); err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	if got, want := string(data[:4])+string(data[8:12]), "RIFFWAVE"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	size := int(binary.LittleEndian.Uint32(data[40:]))
+	if got, want := len(data)-44, size; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// one second plus the release of the last note
+	frames := size / 4
+	if frames < SampleRate || frames > SampleRate+2*framesPerBlock {
+		t.Errorf("got %d frames want about %d", frames, SampleRate)
+	}
+}
+
+func TestRenderEvents(t *testing.T) {
+	events := renderEvents(core.MustParseSequence("a a"), 120)
+	if got, want := len(events), 4; got != want {
+		t.Fatalf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	// the off of the first note comes before the on of the second
+	if got, want := events[1].on, false; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if got, want := events[2].frame, SampleRate/2; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestRenderWAV_Unsupported(t *testing.T) {
+	sf, _ := ParseSoundFont(testFont())
+	if err := RenderWAV(new(bytes.Buffer), sf, 42, 120); err == nil {
+		t.Error("error expected")
+	}
+}
@@ -0,0 +1,28 @@
+package system
+
+import (
+	"flag"
+	"os"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
+	"github.com/emicklei/melrose/notify"
+)
+
+var projectFile = flag.String("project", "", "project manifest to load at startup (default "+dsl.ProjectFile+" if present)")
+
+// LoadProject loads the project manifest given by the flag or, if present, the one in the current directory.
+func LoadProject(ctx core.Context) {
+	name := *projectFile
+	if len(name) == 0 {
+		if _, err := os.Stat(dsl.ProjectFile); err != nil {
+			return
+		}
+		name = dsl.ProjectFile
+	}
+	if err := dsl.LoadProject(ctx, name); err != nil {
+		notify.Errorf("cannot load project %s: %v", name, err)
+		return
+	}
+	notify.Infof("loaded project %s", name)
+}
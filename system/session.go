@@ -0,0 +1,56 @@
+package system
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
+	"github.com/emicklei/melrose/notify"
+)
+
+var (
+	sessionFile    = flag.String("session", dsl.DefaultSessionFile, "file to save all variables and settings to on exit")
+	restoreSession = flag.Bool("restore", false, "restore all variables and settings from the session file of the last exit")
+)
+
+// RestoreSession loads the session file if the restore flag is set.
+func RestoreSession(ctx core.Context) {
+	if !*restoreSession {
+		return
+	}
+	f, err := os.Open(*sessionFile)
+	if err != nil {
+		notify.Warnf("cannot restore session: %v", err)
+		return
+	}
+	defer f.Close()
+	if err := dsl.LoadSession(ctx, f); err != nil {
+		notify.Warnf("cannot restore session from %s: %v", *sessionFile, err)
+		return
+	}
+	notify.Infof("restored session from %s", *sessionFile)
+}
+
+// saveSession writes all variables and settings to the session file, if any variable is defined.
+func saveSession(ctx core.Context) {
+	if len(*sessionFile) == 0 || len(ctx.Variables().Variables()) == 0 {
+		return
+	}
+	if err := dsl.SaveSessionFile(ctx, *sessionFile); err != nil {
+		notify.Warnf("cannot save session to %s: %v", *sessionFile, err)
+	}
+}
+
+// TearDownOnSignal tears down, which saves the session, when the process is terminated or its terminal is closed.
+func TearDownOnSignal(ctx core.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		<-signals
+		TearDown(ctx)
+		os.Exit(1)
+	}()
+}
@@ -2,6 +2,7 @@ package system
 
 import (
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -12,17 +13,25 @@ import (
 	"github.com/emicklei/melrose/midi"
 	"github.com/emicklei/melrose/midi/transport"
 	"github.com/emicklei/melrose/notify"
+	"github.com/emicklei/melrose/osc"
+	"github.com/emicklei/melrose/synth"
 
 	"github.com/emicklei/melrose/dsl"
 )
 
 var (
 	debugLogging = flag.Bool("d", false, "debug logging")
+	oscTarget    = flag.String("osc", "", "host:port to send notes as OSC bundles to, instead of MIDI")
+	audioOutput  = flag.String("audio", "", "sf2=<file> to play notes with a SoundFont, instead of MIDI")
+	hotplug      = flag.Bool("hotplug", false, "scan for MIDI devices every 2 seconds to reconnect open devices that are replugged")
 )
 
 func Setup(buildTag string) (core.Context, error) {
 	core.BuildTag = buildTag
-	flag.Parse()
+	// a subcommand can have parsed the flags already
+	if !flag.Parsed() {
+		flag.Parse()
+	}
 	if *debugLogging {
 		core.ToggleDebug()
 	}
@@ -34,10 +43,33 @@ func Setup(buildTag string) (core.Context, error) {
 	ctx.VariableStorage = dsl.NewVariableStore()
 	ctx.LoopControl = core.NewBeatmaster(ctx, 120)
 	ctx.CapabilityFlags = core.NewCapabilities()
+	if *oscTarget != "" {
+		dev, err := osc.NewDevice(*oscTarget)
+		if err != nil {
+			return nil, err
+		}
+		ctx.AudioDevice = dev
+		return ctx, nil
+	}
+	if *audioOutput != "" {
+		fontName, ok := strings.CutPrefix(*audioOutput, "sf2=")
+		if !ok {
+			return nil, fmt.Errorf("unsupported audio output %s, use sf2=<file>", *audioOutput)
+		}
+		dev, err := synth.NewDevice(fontName)
+		if err != nil {
+			return nil, err
+		}
+		ctx.AudioDevice = dev
+		return ctx, nil
+	}
 	reg, err := midi.NewDeviceRegistry()
 	if err != nil {
 		log.Fatalln("unable to initialize MIDI")
 	}
+	if *hotplug {
+		reg.StartHotplugDetection()
+	}
 	ctx.AudioDevice = reg
 	return ctx, nil
 }
@@ -1,15 +1,25 @@
 package system
 
 import (
+	"sync"
+
 	"github.com/emicklei/melrose/core"
 	"github.com/emicklei/melrose/dsl"
+	"github.com/emicklei/melrose/link"
 	"github.com/emicklei/melrose/notify"
 )
 
+var tearDownOnce sync.Once
+
+// TearDown saves the session, leaves a Link session and closes all devices. It can be called by a signal handler and on exit ; only the first call does it.
 func TearDown(ctx core.Context) error {
-	dsl.StopAllPlayables(ctx)
-	ctx.Control().Reset()
-	ctx.Device().Close()
-	notify.PrintBye()
+	tearDownOnce.Do(func() {
+		saveSession(ctx)
+		dsl.StopAllPlayables(ctx)
+		link.Disable(ctx)
+		ctx.Control().Reset()
+		ctx.Device().Close()
+		notify.PrintBye()
+	})
 	return nil
 }
@@ -2,12 +2,17 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 
+	"github.com/emicklei/melrose/control"
 	"github.com/emicklei/melrose/core"
 
 	"github.com/emicklei/melrose/dsl"
+	"github.com/emicklei/melrose/midi"
 	"github.com/emicklei/melrose/notify"
+	"github.com/emicklei/melrose/ui/roll"
 )
 
 var cmdFuncMap = cmdFunctions()
@@ -23,12 +28,21 @@ func cmdFunctions() map[string]Command {
 		ctx.Device().Reset()
 		return nil
 	}}
+	cmds[":panic"] = Command{Description: "send all notes and sound off to all open output devices", Func: handlePanic}
+	cmds[":timing"] = Command{Description: "show scheduling jitter, late events and queue depth of the last minute", Func: handleTiming}
 	cmds[":b"] = Command{Description: "beat settings", Func: handleBeatSetting}
 	cmds[":m"] = Command{Description: "MIDI settings", Func: handleMIDISetting}
 	cmds[":q"] = Command{Description: "quit"} // no Func because it is handled in the main loop
 	cmds[":d"] = Command{Description: "toggle debug lines", Func: handleToggleDebug}
 	cmds[":p"] = Command{Description: "list all running", Func: handleListAllRunning}
 	cmds[":e"] = Command{Description: "echo notes", Func: handleEchoNotes}
+	cmds[":plot"] = Command{Description: "print a piano roll (or a drum grid for channel 10) of a variable", Sample: ":plot melody", Func: handlePlot}
+	cmds[":audition"] = Command{Description: "play an expression once on the audition channel at a reduced velocity", Sample: ":audition fraction(8,melody)", Func: handleAudition}
+	cmds[":watch"] = Command{Description: "evaluate a file whenever it changes on disk ; without a file it stops watching", Sample: ":watch song.mel", Func: handleWatch}
+	cmds[":save"] = Command{Description: "save all variables and settings to a session file", Sample: ":save my-session.json", Func: handleSaveSession}
+	cmds[":snapshot"] = Command{Description: "save or restore all variables by name ; without arguments it lists the snapshots", Sample: ":snapshot save verse", Func: handleSnapshot}
+	cmds[":undo"] = Command{Description: "revert the last change of a variable, e.g. the target of a running loop", Func: handleUndo}
+	cmds[":load"] = Command{Description: "restore variables and settings from a session file", Sample: ":load my-session.json", Func: handleLoadSession}
 	return cmds
 }
 
@@ -50,9 +64,33 @@ func lookupCommand(cmd string) (Command, bool) {
 }
 
 func handleMIDISetting(ctx core.Context, args []string) notify.Message {
+	if len(args) > 0 && args[0] == "learn" {
+		return handleLearn(ctx, args[1:])
+	}
 	return ctx.Device().Command(args)
 }
 
+// handleLearn maps the next control change from the default input device to a variable.
+// The listener is stored in the variable with the suffix "_cc" such that it can be stopped.
+func handleLearn(ctx core.Context, args []string) notify.Message {
+	if len(args) != 1 {
+		return notify.NewWarningf("missing variable name, e.g. :m learn cutoff")
+	}
+	name := args[0]
+	if _, _, ok := dsl.IsAssignment(name + " = 0"); !ok {
+		return notify.NewWarningf("invalid variable name %s", name)
+	}
+	deviceID, _ := ctx.Device().DefaultDeviceIDs()
+	learn := control.NewCCLearn(ctx, deviceID, name, func(cc *control.CCListen) {
+		ctx.Variables().Put(name+"_cc", cc)
+		notify.Infof("%s_cc = %s", name, cc.Storex())
+	})
+	if err := learn.Start(); err != nil {
+		return notify.NewError(err)
+	}
+	return notify.NewInfof("move a knob or slider on input device %d to change %s", deviceID, name)
+}
+
 func handleBeatSetting(ctx core.Context, args []string) notify.Message {
 	l := ctx.Control()
 	fmt.Printf("[sequencer] beats per minute (BPM): %v\n", l.BPM())
@@ -85,3 +123,93 @@ func handleListAllRunning(ctx core.Context, args []string) notify.Message {
 func handleEchoNotes(ctx core.Context, args []string) notify.Message {
 	return ctx.Device().Command([]string{"e"})
 }
+
+func handlePanic(ctx core.Context, args []string) notify.Message {
+	reg, ok := ctx.Device().(*midi.DeviceRegistry)
+	if !ok {
+		return notify.NewWarningf("panic is not available for this device")
+	}
+	reg.Panic()
+	return notify.NewInfof("sent all notes and sound off")
+}
+
+func handleTiming(ctx core.Context, args []string) notify.Message {
+	reg, ok := ctx.Device().(*midi.DeviceRegistry)
+	if !ok {
+		return notify.NewWarningf("timing is not available for this device")
+	}
+	all := reg.Timing()
+	if len(all) == 0 {
+		return notify.NewInfof("no output devices are open")
+	}
+	ids := []int{}
+	for id := range all {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		s := all[id]
+		notify.PrintHighlighted(fmt.Sprintf("output device %d (last minute):", id))
+		fmt.Printf("        events = %d\n", s.Events)
+		fmt.Printf("   late events = %d (> 5ms)\n", s.Late)
+		fmt.Printf("   mean jitter = %v\n", s.MeanJitter)
+		fmt.Printf("    max jitter = %v\n", s.MaxJitter)
+		fmt.Printf(" mean callback = %v\n", s.MeanCallback)
+		fmt.Printf("   queue depth = %d\n", s.QueueDepth)
+	}
+	return nil
+}
+
+func handlePlot(ctx core.Context, args []string) notify.Message {
+	if len(args) == 0 {
+		return notify.NewWarningf("missing variable name, e.g. :plot melody")
+	}
+	v, ok := ctx.Variables().Get(args[0])
+	if !ok {
+		return notify.NewWarningf("unknown variable %s", args[0])
+	}
+	s, err := roll.Of(v, ctx.Control().BIAB())
+	if err != nil {
+		return notify.NewError(err)
+	}
+	fmt.Print(s)
+	return nil
+}
+
+func handleAudition(ctx core.Context, args []string) notify.Message {
+	if len(args) == 0 {
+		return notify.NewWarningf("missing expression, e.g. :audition fraction(8,melody)")
+	}
+	if _, err := dsl.NewEvaluator(ctx).Audition(strings.Join(args, " ")); err != nil {
+		return notify.NewError(err)
+	}
+	return nil
+}
+
+func sessionFile(args []string) string {
+	if len(args) == 0 {
+		return dsl.DefaultSessionFile
+	}
+	return args[0]
+}
+
+func handleSaveSession(ctx core.Context, args []string) notify.Message {
+	name := sessionFile(args)
+	if err := dsl.SaveSessionFile(ctx, name); err != nil {
+		return notify.NewError(err)
+	}
+	return notify.NewInfof("saved session to %s", name)
+}
+
+func handleLoadSession(ctx core.Context, args []string) notify.Message {
+	name := sessionFile(args)
+	f, err := os.Open(name)
+	if err != nil {
+		return notify.NewError(err)
+	}
+	defer f.Close()
+	if err := dsl.LoadSession(ctx, f); err != nil {
+		return notify.NewError(err)
+	}
+	return notify.NewInfof("loaded session from %s", name)
+}
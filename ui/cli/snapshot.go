@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
+	"github.com/emicklei/melrose/notify"
+)
+
+func variableStore(ctx core.Context) (*dsl.VariableStore, notify.Message) {
+	store, ok := ctx.Variables().(*dsl.VariableStore)
+	if !ok {
+		return nil, notify.NewWarningf("snapshots are not available for this variable storage")
+	}
+	return store, nil
+}
+
+func handleSnapshot(ctx core.Context, args []string) notify.Message {
+	store, msg := variableStore(ctx)
+	if msg != nil {
+		return msg
+	}
+	if len(args) == 0 {
+		names := store.SnapshotNames()
+		if len(names) == 0 {
+			return notify.NewInfof("no snapshots, e.g. :snapshot save verse")
+		}
+		return notify.NewInfof("snapshots: %s", strings.Join(names, ", "))
+	}
+	if len(args) != 2 {
+		return notify.NewWarningf("expected save or restore and a name, e.g. :snapshot save verse")
+	}
+	switch args[0] {
+	case "save":
+		store.SaveSnapshot(args[1])
+		return notify.NewInfof("saved snapshot %s", args[1])
+	case "restore":
+		if err := store.RestoreSnapshot(args[1]); err != nil {
+			return notify.NewError(err)
+		}
+		return notify.NewInfof("restored snapshot %s, use :undo to revert", args[1])
+	}
+	return notify.NewWarningf("unknown snapshot action %s, expected save or restore", args[0])
+}
+
+func handleUndo(ctx core.Context, args []string) notify.Message {
+	store, msg := variableStore(ctx)
+	if msg != nil {
+		return msg
+	}
+	names := store.Undo()
+	if len(names) == 0 {
+		return notify.NewInfof("nothing to undo")
+	}
+	return notify.NewInfof("reverted %s", strings.Join(names, ", "))
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"github.com/emicklei/melrose/core"
+	"github.com/emicklei/melrose/dsl"
+	"github.com/emicklei/melrose/notify"
+)
+
+// watcher is the current file watch ; nil if no file is watched.
+var watcher *dsl.Watcher
+
+// Watch evaluates a script file and keeps evaluating its changes ; it replaces the current watch, if any.
+func Watch(ctx core.Context, fileName string) error {
+	StopWatch()
+	w := dsl.NewWatcher(ctx, fileName)
+	if err := w.Start(); err != nil {
+		return err
+	}
+	watcher = w
+	return nil
+}
+
+// StopWatch stops the current watch, if any.
+func StopWatch() {
+	if watcher != nil {
+		watcher.Stop()
+		watcher = nil
+	}
+}
+
+func handleWatch(ctx core.Context, args []string) notify.Message {
+	if len(args) == 0 {
+		if watcher == nil {
+			return notify.NewWarningf("missing file name, e.g. :watch song.mel")
+		}
+		name := watcher.FileName()
+		StopWatch()
+		return notify.NewInfof("stopped watching %s", name)
+	}
+	if err := Watch(ctx, args[0]); err != nil {
+		return notify.NewError(err)
+	}
+	return notify.NewInfof("watching %s, use :watch to stop", args[0])
+}
@@ -0,0 +1,248 @@
+package roll
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/emicklei/melrose/core"
+)
+
+// barsPerBlock is the number of bars printed next to each other ; longer music continues in the next block.
+const barsPerBlock = 4
+
+const (
+	cellEmpty   = '.'
+	cellStart   = 'o'
+	cellSustain = '-'
+	cellHit     = 'x'
+)
+
+// drumNames are short names of General MIDI percussion (channel 10).
+var drumNames = map[int]string{
+	35: "Kick 2",
+	36: "Kick",
+	37: "Side stick",
+	38: "Snare",
+	39: "Clap",
+	40: "Snare 2",
+	41: "Floor tom 2",
+	42: "Closed HH",
+	43: "Floor tom",
+	44: "Pedal HH",
+	45: "Low tom",
+	46: "Open HH",
+	47: "Mid tom",
+	48: "High tom",
+	49: "Crash",
+	50: "High tom 2",
+	51: "Ride",
+	53: "Ride bell",
+	54: "Tambourine",
+	56: "Cowbell",
+	57: "Crash 2",
+	59: "Ride 2",
+}
+
+// note is a hearable note in the grid, in steps.
+type note struct {
+	number int // MIDI
+	start  int
+	length int
+}
+
+// grid is the music in steps of a fixed length.
+type grid struct {
+	step  float32 // whole note fraction of a column
+	steps int     // total number of columns
+	notes []note
+}
+
+// drumChannel is the MIDI channel of General MIDI percussion.
+const drumChannel = 10
+
+// Of returns the piano roll of a musical object, or a drum grid if the object is played on channel 10.
+func Of(m interface{}, biab int) (string, error) {
+	if v, ok := m.(core.HasValue); ok {
+		m = v.Value()
+	}
+	drums := false
+	if sel, ok := m.(core.ChannelSelector); ok {
+		drums = sel.Channel() == drumChannel
+	}
+	s, ok := m.(core.Sequenceable)
+	if !ok {
+		return "", fmt.Errorf("cannot draw (%T) %v", m, m)
+	}
+	return String(s.S(), biab, drums), nil
+}
+
+// String returns the piano roll, see Write.
+func String(s core.Sequence, biab int, drums bool) string {
+	var b bytes.Buffer
+	Write(&b, s, biab, drums)
+	return b.String()
+}
+
+// Write prints a piano roll with a row for each pitch (high to low) and a column for each step in time.
+// If drums is true then it prints a drum grid with a row for each used percussion sound and a cell for each hit.
+func Write(w io.Writer, s core.Sequence, biab int, drums bool) {
+	g := newGrid(s)
+	if len(g.notes) == 0 {
+		fmt.Fprintln(w, "(no notes)")
+		return
+	}
+	rows := g.rows(drums)
+	labels := map[int]string{}
+	width := 0
+	for _, each := range rows {
+		labels[each] = label(each, drums)
+		if l := len(labels[each]); l > width {
+			width = l
+		}
+	}
+	perBar := int(math.Round(float64(biab) / 4 / float64(g.step)))
+	if perBar == 0 {
+		perBar = 1
+	}
+	// complete the last bar
+	steps := (g.steps + perBar - 1) / perBar * perBar
+	perBlock := perBar * barsPerBlock
+	for from := 0; from < steps; from += perBlock {
+		to := from + perBlock
+		if to > steps {
+			to = steps
+		}
+		if from > 0 {
+			fmt.Fprintln(w)
+		}
+		// bar numbers
+		ruler := strings.Repeat(" ", width+1)
+		for c := from; c < to; c += perBar {
+			number := fmt.Sprintf("|%d", c/perBar+1)
+			if n := perBar + 1 - len(number); n > 0 {
+				number += strings.Repeat(" ", n)
+			}
+			ruler += number
+		}
+		fmt.Fprintln(w, strings.TrimRight(ruler, " "))
+		for _, each := range rows {
+			fmt.Fprintf(w, "%*s ", width, labels[each])
+			cells := g.cells(each, drums, steps)
+			for c := from; c < to; c++ {
+				if c%perBar == 0 {
+					fmt.Fprint(w, "|")
+				}
+				fmt.Fprint(w, string(cells[c]))
+			}
+			fmt.Fprintln(w, "|")
+		}
+	}
+}
+
+func newGrid(s core.Sequence) grid {
+	// the shortest notated length that is commonly used
+	g := grid{step: 0.0625}
+	for _, group := range s.Notes {
+		for _, each := range group {
+			if f := each.DurationFactor(); f > 0 && f < g.step-0.001 {
+				g.step = 0.03125
+			}
+		}
+	}
+	position := float32(0)
+	for _, group := range s.Notes {
+		if len(group) == 0 {
+			continue
+		}
+		length := group[0].DurationFactor()
+		start := int(math.Round(float64(position / g.step)))
+		for _, each := range group {
+			if !each.IsHearable() {
+				continue
+			}
+			steps := int(math.Round(float64(each.DurationFactor() / g.step)))
+			if steps == 0 {
+				steps = 1
+			}
+			g.notes = append(g.notes, note{number: each.MIDI(), start: start, length: steps})
+			if end := start + steps; end > g.steps {
+				g.steps = end
+			}
+		}
+		position += length
+	}
+	if end := int(math.Round(float64(position / g.step))); end > g.steps {
+		g.steps = end
+	}
+	return g
+}
+
+// rows returns the MIDI numbers from high to low ; a piano roll has all numbers in between.
+func (g grid) rows(drums bool) (list []int) {
+	if drums {
+		used := map[int]bool{}
+		for _, each := range g.notes {
+			if !used[each.number] {
+				used[each.number] = true
+				list = append(list, each.number)
+			}
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(list)))
+		return
+	}
+	low, high := 127, 0
+	for _, each := range g.notes {
+		if each.number < low {
+			low = each.number
+		}
+		if each.number > high {
+			high = each.number
+		}
+	}
+	for n := high; n >= low; n-- {
+		list = append(list, n)
+	}
+	return
+}
+
+// cells returns a cell for each step of a row.
+func (g grid) cells(number int, drums bool, steps int) []rune {
+	cells := []rune(strings.Repeat(string(cellEmpty), steps))
+	for _, each := range g.notes {
+		if each.number != number {
+			continue
+		}
+		if drums {
+			cells[each.start] = cellHit
+			continue
+		}
+		for c := each.start; c < each.start+each.length; c++ {
+			if cells[c] != cellStart {
+				cells[c] = cellSustain
+			}
+		}
+		cells[each.start] = cellStart
+	}
+	return cells
+}
+
+// label returns the name of a note with its octave, e.g. E_4, or the name of a percussion sound.
+func label(number int, drums bool) string {
+	if drums {
+		if name, ok := drumNames[number]; ok {
+			return name
+		}
+	}
+	name, octave, accidental := core.MIDIToNoteParts(number)
+	switch accidental {
+	case -1:
+		name += "_"
+	case 1:
+		name += "#"
+	}
+	return fmt.Sprintf("%s%d", name, octave)
+}
@@ -0,0 +1,52 @@
+package roll
+
+import (
+	"testing"
+
+	"github.com/emicklei/melrose/core"
+)
+
+func TestString(t *testing.T) {
+	got := String(core.MustParseSequence("c 8d 8= 2e_ (c e)"), 4, false)
+	want := `    |1               |2
+ E4 |................|o---............|
+E_4 |........o-------|................|
+ D4 |....o-..........|................|
+D_4 |................|................|
+ C4 |o---............|o---............|
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestString_Drums(t *testing.T) {
+	got := String(core.MustParseSequence("8c2 8f#2 8d2 8f#2"), 2, true)
+	want := `          |1
+Closed HH |..x...x.|
+    Snare |....x...|
+     Kick |x.......|
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestString_Empty(t *testing.T) {
+	if got, want := String(core.MustParseSequence("= ="), 4, false), "(no notes)\n"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+}
+
+func TestOf_ChannelTen(t *testing.T) {
+	s, err := Of(core.NewChannelSelector(core.MustParseSequence("c2"), core.On(10)), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s[:7], "     |1"; got != want {
+		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
+	}
+	if _, err := Of(42, 4); err == nil {
+		t.Error("error expected")
+	}
+}